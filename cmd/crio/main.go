@@ -151,6 +151,7 @@ func main() {
 		criocli.CheckCommand,
 		criocli.ConfigCommand,
 		criocli.PublishCommand,
+		criocli.RestoreTuningCommand,
 		criocli.StatusCommand,
 		criocli.VersionCommand,
 		criocli.WipeCommand,
@@ -2,7 +2,7 @@
 
 function __fish_crio_no_subcommand --description 'Test if there has been any subcommand yet'
     for i in (commandline -opc)
-        if contains -- $i check complete completion help h config man markdown md status config c containers container cs s info i goroutines g heap hp version wipe help h
+        if contains -- $i check complete completion help h config man markdown md restore-tuning status config c containers container cs s info i goroutines g heap hp snapshot sn tuning t node-tuning nt tuning-health th verify vf version wipe help h
             return 1
         end
     end
@@ -42,6 +42,8 @@ complete -c crio -n '__fish_crio_no_subcommand' -f -l conmon-cgroup -r -d 'cgrou
 complete -c crio -n '__fish_crio_no_subcommand' -f -l conmon-env -r -d 'Environment variable list for the conmon process, used for passing necessary environment variables to conmon or the runtime. This option is deprecated and will be removed in the future.'
 complete -c crio -n '__fish_crio_no_subcommand' -l container-attach-socket-dir -r -d 'Path to directory for container attach sockets.'
 complete -c crio -n '__fish_crio_no_subcommand' -l container-exits-dir -r -d 'Path to directory in which container exit files are written to by conmon.'
+complete -c crio -n '__fish_crio_no_subcommand' -l credential-provider-bin-dir -r -d 'Path to the directory of the kubelet credential provider plugins.'
+complete -c crio -n '__fish_crio_no_subcommand' -l credential-provider-config -r -d 'Path to a kubelet credential provider config file, whose plugins provide the credentials for the images CRI-O pulls on its own, like the pause image and the OCI artifacts of the pods. Requires --credential-provider-bin-dir.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l ctr-stop-timeout -r -d 'The minimal amount of time in seconds to wait before issuing a timeout regarding the proper termination of the container. The lowest possible value is 30s, whereas lower values are not considered by CRI-O.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l decryption-keys-path -r -d 'Path to load keys for image decryption.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l default-capabilities -r -d 'Capabilities to add to the containers.'
@@ -64,6 +66,16 @@ complete -c crio -n '__fish_crio_no_subcommand' -f -l gid-mappings -r -d 'Specif
 complete -c crio -n '__fish_crio_no_subcommand' -l global-auth-file -r -d 'Path to a file like /var/lib/kubelet/config.json holding credentials necessary for pulling images from secure registries.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l grpc-max-recv-msg-size -r -d 'Maximum grpc receive message size in bytes.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l grpc-max-send-msg-size -r -d 'Maximum grpc receive message size.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l high-performance-cpu-c-states -d 'Enables the high-performance hooks to configure the c-states of the container CPUs.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l high-performance-cpu-freq-governor -d 'Enables the high-performance hooks to configure the frequency governor of the container CPUs.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l high-performance-cpu-load-balancing -d 'Enables the high-performance hooks to disable the CPU load balancing of the container CPUs.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l high-performance-cpu-quota -d 'Enables the high-performance hooks to disable the CFS quota of the container.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l high-performance-dry-run -d 'Makes the high-performance hooks log and save the plan of the tuning of the containers instead of applying it.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l high-performance-fail-open -r -d 'A list of high-performance features whose failures are logged instead of failing the CRI request. Supported features: cpu-load-balancing, irq-load-balancing, cpu-quota, cpu-c-states, cpu-freq-governor, packet-steering, vf-queues, arfs, vf-irq-affinity, af-xdp, napi-affinity, interrupt-coalescing, qdisc, netdev-budget and gro.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l high-performance-irq-load-balancing -d 'Enables the high-performance hooks to disable the IRQ load balancing of the container CPUs.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l high-performance-reconcile-on-reload -d 'Makes the high-performance hooks reconcile the tuning of the running containers with the configuration reloaded on SIGHUP.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l high-performance-shared-cpus -d 'Enables the high-performance hooks to grant the shared CPUs to the containers requesting them.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l high-performance-tuned-conflict -r -d 'The policy of the high-performance hooks when the active TuneD profile manages the same settings: ignore, warn or refuse.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l hooks-dir -r -d 'Set the OCI hooks directory path (may be set multiple times)
     If one of the directories does not exist, then CRI-O will automatically
     skip them.
@@ -115,6 +127,8 @@ complete -c crio -n '__fish_crio_no_subcommand' -f -l log-format -r -d 'Set the
 complete -c crio -n '__fish_crio_no_subcommand' -f -l log-journald -d 'Log to systemd journal (journald) in addition to kubernetes log file.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l log-level -s l -r -d 'Log messages above specified level: trace, debug, info, warn, error, fatal or panic.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l log-size-max -r -d 'Maximum log size in bytes for a container. If it is positive, it must be >= 8192 to match/exceed conmon read buffer. This option is deprecated. The Kubelet flag \'--container-log-max-size\' should be used instead.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l max-parallel-image-pulls -r -d 'The maximum number of images pulled at the same time, the other pulls wait for one of them to complete. Can be set to 0 to not limit the image pulls.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l max-parallel-layer-downloads -r -d 'The maximum number of layers an image pull downloads at the same time. Can be set to 0 to use the default of the containers/image library.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l metrics-cert -r -d 'Certificate for the secure metrics endpoint.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l metrics-collectors -r -d 'Enabled metrics collectors.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l metrics-host -r -d 'Host for the metrics endpoint.'
@@ -144,8 +158,11 @@ complete -c crio -n '__fish_crio_no_subcommand' -f -l profile-port -r -d 'Port f
 complete -c crio -n '__fish_crio_no_subcommand' -f -l pull-progress-timeout -r -d 'The timeout for an image pull to make progress until the pull operation gets canceled. This value will be also used for calculating the pull progress interval to --pull-progress-timeout / 10. Can be set to 0 to disable the timeout as well as the progress output.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l rdt-config-file -r -d 'Path to the RDT configuration file for configuring the resctrl pseudo-filesystem.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l read-only -d 'Setup all unprivileged containers to run as read-only. Automatically mounts the containers\' tmpfs on \'/run\', \'/tmp\' and \'/var/tmp\'.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l registry-mirror-health-check-interval -r -d 'The interval at which the health of the registry mirrors is checked, the image pulls trying the healthy mirrors first. Can be set to 0 to disable the health checks.'
 complete -c crio -n '__fish_crio_no_subcommand' -l root -s r -r -d 'The CRI-O root directory.'
 complete -c crio -n '__fish_crio_no_subcommand' -l runroot -r -d 'The CRI-O state directory.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l runtime-handler-hooks-log-format -r -d 'The format of the log lines of the runtime handler hooks: \'text\' or \'json\', independently of the log-format. If empty, they are logged in the log-format.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l runtime-handler-hooks-timeout -r -d 'The maximum time a runtime handler hook gets to run. The pending file writes and commands of the hook are canceled once it expires. Can be set to 0 to disable the timeout.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l runtimes -r -d 'OCI runtimes, format is \'runtime_name:runtime_path:runtime_root:runtime_type:privileged_without_host_devices:runtime_config_path:container_min_memory\'.'
 complete -c crio -n '__fish_crio_no_subcommand' -l seccomp-profile -r -d 'Path to the seccomp.json profile to be used as the runtime\'s default. If not specified, then the internal default seccomp profile will be used.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l selinux -d 'Enable selinux support. This option is deprecated, and be interpreted from whether SELinux is enabled on the host in the future.'
@@ -166,6 +183,15 @@ complete -c crio -n '__fish_crio_no_subcommand' -l stream-tls-key -r -d 'Path to
 complete -c crio -n '__fish_crio_no_subcommand' -f -l timezone -s tz -r -d 'To set the timezone for a container in CRI-O. If an empty string is provided, CRI-O retains its default behavior. Use \'Local\' to match the timezone of the host machine.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l tracing-endpoint -r -d 'Address on which the gRPC tracing collector will listen.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l tracing-sampling-rate-per-million -r -d 'Number of samples to collect per million OpenTelemetry spans. Set to 1000000 to always sample.'
+complete -c crio -n '__fish_crio_no_subcommand' -l tuning-audit-log -r -d 'File every write of the runtime handler hooks to the sysfs, procfs and cgroup files of the node is recorded to. If empty, the writes are not recorded.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l tuning-audit-log-size-max -r -d 'Size in bytes after which the tuning audit log gets rotated, 0 to never rotate it.'
+complete -c crio -n '__fish_crio_no_subcommand' -l tuning-container-state-dir -r -d 'Directory the runtime handler hooks write the tuning of every tuned container to, as <container-id>/tuning.json, for node-local agents. If empty, it is not written.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l tuning-drift-check-interval -r -d 'The interval at which the tuning applied to the running containers is compared with the node and repaired when it drifted. Can be set to 0 to disable the drift detection.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l tuning-linux-audit -d 'Report the privileged tuning operations of the runtime handler hooks, like the changes of the IRQ affinity and of the CPU frequency governor, to the Linux audit subsystem.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l tuning-schedstat-interval -r -d 'The interval at which the scheduler statistics of the isolated CPUs are sampled into the run delay metrics. Can be set to 0 to disable the sampling.'
+complete -c crio -n '__fish_crio_no_subcommand' -l tuning-state-dir -r -d 'Directory the runtime handler hooks record the tuning they applied to every container to, so that it can still be reverted after a crash or restart of CRI-O.'
+complete -c crio -n '__fish_crio_no_subcommand' -f -l tuning-telemetry-interval -r -d 'The interval at which the CPU telemetry of the tuned containers, like the c-state residency of their CPUs, is sampled into the metrics. Can be set to 0 to disable the sampling.'
+complete -c crio -n '__fish_crio_no_subcommand' -l tuning-topology-file -r -d 'File the runtime handler hooks write the CPU consumption of the tuned containers per NUMA zone to, for an exporter to the NodeResourceTopology API. If empty, it is not written.'
 complete -c crio -n '__fish_crio_no_subcommand' -f -l uid-mappings -r -d 'Specify the UID mappings to use for the user namespace. This option is deprecated, and will be replaced with Kubernetes user namespace support (KEP-127) in the future.'
 complete -c crio -n '__fish_crio_no_subcommand' -l version-file -r -d 'Location for CRI-O to lay down the temporary version file. It is used to check if crio wipe should wipe containers, which should always happen on a node reboot.'
 complete -c crio -n '__fish_crio_no_subcommand' -l version-file-persist -r -d 'Location for CRI-O to lay down the persistent version file. It is used to check if crio wipe should wipe images, which should only happen when CRI-O has been upgraded.'
@@ -212,6 +238,9 @@ complete -c crio -n '__fish_seen_subcommand_from man' -f -l help -s h -d 'show h
 complete -r -c crio -n '__fish_crio_no_subcommand' -a 'man' -d 'Generate the man page documentation.'
 complete -c crio -n '__fish_seen_subcommand_from markdown md' -f -l help -s h -d 'show help'
 complete -r -c crio -n '__fish_crio_no_subcommand' -a 'markdown md' -d 'Generate the markdown documentation.'
+complete -c crio -n '__fish_seen_subcommand_from restore-tuning' -f -l help -s h -d 'show help'
+complete -r -c crio -n '__fish_crio_no_subcommand' -a 'restore-tuning' -d 'restore the node tuning applied by the runtime handler hooks after a reboot or crash'
+complete -c crio -n '__fish_seen_subcommand_from restore-tuning' -f -l force -s f -d 'restore the tuning by skipping the reboot check, only when none of the recorded containers is running'
 complete -c crio -n '__fish_seen_subcommand_from status' -f -l help -s h -d 'show help'
 complete -r -c crio -n '__fish_crio_no_subcommand' -a 'status' -d 'Display status information'
 complete -c crio -n '__fish_seen_subcommand_from status' -l socket -s s -r -d 'absolute path to the unix socket'
@@ -227,6 +256,26 @@ complete -r -c crio -n '__fish_seen_subcommand_from status' -a 'goroutines g' -d
 complete -c crio -n '__fish_seen_subcommand_from heap hp' -f -l help -s h -d 'show help'
 complete -r -c crio -n '__fish_seen_subcommand_from status' -a 'heap hp' -d 'Write the heap dump to a temp file and print its location on disk.'
 complete -c crio -n '__fish_seen_subcommand_from heap hp' -l file -s f -r -d 'Output file of the heap dump.'
+complete -c crio -n '__fish_seen_subcommand_from snapshot sn' -f -l help -s h -d 'show help'
+complete -r -c crio -n '__fish_seen_subcommand_from status' -a 'snapshot sn' -d 'Write an archive of the cgroup, sysfs and tuning state of the provided container ID for bug reports.'
+complete -c crio -n '__fish_seen_subcommand_from snapshot sn' -f -l id -s i -r -d 'the container ID'
+complete -c crio -n '__fish_seen_subcommand_from snapshot sn' -l file -s f -r -d 'Output file of the snapshot.'
+complete -c crio -n '__fish_seen_subcommand_from tuning t' -f -l help -s h -d 'show help'
+complete -r -c crio -n '__fish_seen_subcommand_from status' -a 'tuning t' -d 'Display the high-performance tuning in effect for every container, and whether it currently holds.'
+complete -c crio -n '__fish_seen_subcommand_from tuning t' -f -l json -s j -d 'print JSON instead of text'
+complete -c crio -n '__fish_seen_subcommand_from tuning t' -f -l pod -s p -r -d 'only display the containers of the pods with this name'
+complete -c crio -n '__fish_seen_subcommand_from tuning t' -f -l namespace -s n -r -d 'only display the containers of the pods in this namespace'
+complete -c crio -n '__fish_seen_subcommand_from tuning t' -f -l cpus -s c -r -d 'only display the containers with any of these exclusive CPUs, like 2-3,6'
+complete -c crio -n '__fish_seen_subcommand_from node-tuning nt' -f -l help -s h -d 'show help'
+complete -r -c crio -n '__fish_seen_subcommand_from status' -a 'node-tuning nt' -d 'Display the tuning of the node bookkept for the running containers, like the CPU allocation and the IRQ affinity.'
+complete -c crio -n '__fish_seen_subcommand_from node-tuning nt' -f -l json -s j -d 'print JSON instead of text'
+complete -c crio -n '__fish_seen_subcommand_from tuning-health th' -f -l help -s h -d 'show help'
+complete -r -c crio -n '__fish_seen_subcommand_from status' -a 'tuning-health th' -d 'Display the health checks of the tuning capability of the node, like the presence of irqbalance.'
+complete -c crio -n '__fish_seen_subcommand_from tuning-health th' -f -l json -s j -d 'print JSON instead of text'
+complete -c crio -n '__fish_seen_subcommand_from verify vf' -f -l help -s h -d 'show help'
+complete -r -c crio -n '__fish_seen_subcommand_from status' -a 'verify vf' -d 'Compare the expected and actual value of each file tuned for the provided container ID.'
+complete -c crio -n '__fish_seen_subcommand_from verify vf' -f -l id -s i -r -d 'the container ID'
+complete -c crio -n '__fish_seen_subcommand_from verify vf' -f -l json -s j -d 'print JSON instead of text'
 complete -c crio -n '__fish_seen_subcommand_from version' -f -l help -s h -d 'show help'
 complete -r -c crio -n '__fish_crio_no_subcommand' -a 'version' -d 'display detailed version information'
 complete -c crio -n '__fish_seen_subcommand_from version' -f -l json -s j -d 'print JSON instead of text'
@@ -7,9 +7,12 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/url"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/cri-o/cri-o/internal/runtimehandlerhooks"
 	"github.com/cri-o/cri-o/pkg/types"
 	"github.com/cri-o/cri-o/server"
 )
@@ -25,6 +28,11 @@ type CrioClient interface {
 	ConfigInfo(context.Context) (string, error)
 	GoRoutinesInfo(context.Context) (string, error)
 	HeapInfo(context.Context) ([]byte, error)
+	TuningInfo(context.Context, types.TuningFilter) ([]types.ContainerTuning, error)
+	NodeTuningInfo(context.Context) (*runtimehandlerhooks.NodeTuningState, error)
+	DebugSnapshot(context.Context, string) ([]byte, error)
+	VerifyTuning(context.Context, string) (*runtimehandlerhooks.TuningVerification, error)
+	TuningHealth(context.Context) ([]runtimehandlerhooks.TuningHealthCheck, error)
 }
 
 type crioClientImpl struct {
@@ -80,6 +88,9 @@ func (c *crioClientImpl) doGetRequest(ctx context.Context, path string) ([]byte,
 	if err != nil {
 		return nil, fmt.Errorf("read body: %w", err)
 	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
 
 	return body, nil
 }
@@ -136,3 +147,83 @@ func (c *crioClientImpl) HeapInfo(ctx context.Context) ([]byte, error) {
 	}
 	return body, nil
 }
+
+// TuningInfo returns the high-performance tuning in effect for the containers selected
+// by the filter by querying the cri-o tuning endpoint.
+func (c *crioClientImpl) TuningInfo(ctx context.Context, filter types.TuningFilter) ([]types.ContainerTuning, error) {
+	query := url.Values{}
+	for key, value := range map[string]string{
+		server.InspectTuningPodQuery:       filter.PodName,
+		server.InspectTuningNamespaceQuery: filter.PodNamespace,
+		server.InspectTuningCPUsQuery:      filter.CPUs,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	path := server.InspectTuningEndpoint
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	body, err := c.doGetRequest(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	tunings := []types.ContainerTuning{}
+	if err := json.Unmarshal(body, &tunings); err != nil {
+		return nil, err
+	}
+	return tunings, nil
+}
+
+// NodeTuningInfo returns the tuning of the node bookkept by the high-performance hooks
+// by querying the cri-o node tuning endpoint.
+func (c *crioClientImpl) NodeTuningInfo(ctx context.Context) (*runtimehandlerhooks.NodeTuningState, error) {
+	body, err := c.doGetRequest(ctx, server.InspectNodeTuningEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	state := &runtimehandlerhooks.NodeTuningState{}
+	if err := json.Unmarshal(body, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// DebugSnapshot returns the gzipped tar archive of the state of the container relevant to its tuning
+// by querying the cri-o snapshot endpoint.
+func (c *crioClientImpl) DebugSnapshot(ctx context.Context, id string) ([]byte, error) {
+	body, err := c.doGetRequest(ctx, server.InspectSnapshotEndpoint+"/"+id)
+	if err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// VerifyTuning returns the tuning applied to the container re-validated against the live state of the node
+// by querying the cri-o tuning verification endpoint.
+func (c *crioClientImpl) VerifyTuning(ctx context.Context, id string) (*runtimehandlerhooks.TuningVerification, error) {
+	body, err := c.doGetRequest(ctx, server.InspectVerifyEndpoint+"/"+id)
+	if err != nil {
+		return nil, err
+	}
+	verification := &runtimehandlerhooks.TuningVerification{}
+	if err := json.Unmarshal(body, verification); err != nil {
+		return nil, err
+	}
+	return verification, nil
+}
+
+// TuningHealth returns the health checks of the tuning capability of the node
+// by querying the cri-o tuning health endpoint.
+func (c *crioClientImpl) TuningHealth(ctx context.Context) ([]runtimehandlerhooks.TuningHealthCheck, error) {
+	body, err := c.doGetRequest(ctx, server.InspectTuningHealthEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	checks := []runtimehandlerhooks.TuningHealthCheck{}
+	if err := json.Unmarshal(body, &checks); err != nil {
+		return nil, err
+	}
+	return checks, nil
+}
@@ -34,6 +34,11 @@ const (
 	cgroupMemoryMaxFileV1 = "memory.limit_in_bytes"
 	cgroupMemoryPathV2    = "/sys/fs/cgroup"
 	cgroupMemoryMaxFileV2 = "memory.max"
+
+	cgroupMountPoint = "/sys/fs/cgroup"
+	// delegatedChildCgroup is the child cgroup crun moves the processes of the container to when systemd
+	// delegates the container cgroup to it, to comply with the single writer rule of systemd.
+	delegatedChildCgroup = "container"
 )
 
 // CgroupManager is an interface to interact with cgroups on a node. CRI-O is configured at startup to either use
@@ -52,6 +57,10 @@ type CgroupManager interface {
 	// returns the cgroup path on disk for that containerID. If parentCgroup is empty, it
 	// uses the default parent for that particular manager
 	ContainerCgroupAbsolutePath(string, string) (string, error)
+	// ContainerCgroupDir takes the sandbox parent cgroup, the container ID and the controller, the controller
+	// being ignored on cgroup v2. It returns the directory of the container cgroup on disk for that controller.
+	// It does not depend on the container process, so it holds once the container exited.
+	ContainerCgroupDir(sbParent, containerID, controller string) (string, error)
 	// ContainerCgroupManager takes the cgroup parent, and container ID.
 	// It returns the raw libcontainer cgroup manager for that container.
 	ContainerCgroupManager(sbParent, containerID string) (libctr.Manager, error)
@@ -85,6 +94,20 @@ type CgroupManager interface {
 	// It creates a new cgroup for that sandbox if it does not already exist.
 	// It returns the cgroup stats for that sandbox.
 	SandboxCgroupStats(sbParent, sbID string) (*CgroupStats, error)
+	// ReadCgroupFile takes the cgroup path, as returned by ContainerCgroupPath, SandboxCgroupPath or
+	// ContainerCgroupAbsolutePath, the controller and the file name, the controller being ignored on cgroup v2.
+	// It returns the content of the file in the cgroup the processes run in, that is the child cgroup
+	// the cgroup got delegated to by the OCI runtime, if any.
+	ReadCgroupFile(cgroupPath, controller, file string) (string, error)
+	// WriteCgroupFile takes the cgroup path, the controller, the file name and the data to write.
+	// It writes the file of the cgroup, then the one of the child cgroup the cgroup got delegated to
+	// by the OCI runtime, if any, so that the value holds for the processes of the cgroup.
+	WriteCgroupFile(cgroupPath, controller, file, data string) error
+	// SetCgroupCpuset takes the cgroup path, and the CPUs and memory nodes of its cpuset, an empty
+	// value keeping the current one. With systemd, they are set as the AllowedCPUs and AllowedMemoryNodes
+	// properties of the unit of the cgroup, so that a daemon reload of systemd does not revert them.
+	// The cpuset of the child cgroup the cgroup got delegated to by the OCI runtime, if any, is set as well.
+	SetCgroupCpuset(cgroupPath, cpus, mems string) error
 }
 
 // New creates a new CgroupManager with defaults.
@@ -234,3 +257,51 @@ func removeSandboxCgroup(sbParent, containerCgroup string) error {
 func containerCgroupPath(id string) string {
 	return CrioPrefix + "-" + id
 }
+
+// cgroupDir returns the directory on disk of the controller of the cgroup, whose path is relative
+// to the cgroup mount point. The controller is ignored on cgroup v2.
+func cgroupDir(cgroupPath, controller string) string {
+	if node.CgroupIsV2() {
+		return filepath.Join(cgroupMountPoint, cgroupPath)
+	}
+	return filepath.Join(cgroupMountPoint, controller, cgroupPath)
+}
+
+// cgroupFileDirs returns the directories of the controller of the cgroup found at the path relative
+// to the cgroup mount point, followed by the one of the child cgroup it got delegated to, if any.
+func cgroupFileDirs(cgroupPath, controller string) []string {
+	dir := cgroupDir(cgroupPath, controller)
+	dirs := []string{dir}
+	if _, err := os.Stat(filepath.Join(dir, delegatedChildCgroup)); err == nil {
+		dirs = append(dirs, filepath.Join(dir, delegatedChildCgroup))
+	}
+	return dirs
+}
+
+func readCgroupFile(cgroupPath, controller, file string) (string, error) {
+	dirs := cgroupFileDirs(cgroupPath, controller)
+	return libctr.ReadFile(dirs[len(dirs)-1], file)
+}
+
+func setCgroupCpuset(cgroupPath, cpus, mems string) error {
+	if cpus != "" {
+		if err := writeCgroupFile(cgroupPath, "cpuset", "cpuset.cpus", cpus); err != nil {
+			return err
+		}
+	}
+	if mems != "" {
+		if err := writeCgroupFile(cgroupPath, "cpuset", "cpuset.mems", mems); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCgroupFile(cgroupPath, controller, file, data string) error {
+	for _, dir := range cgroupFileDirs(cgroupPath, controller) {
+		if err := libctr.WriteFile(dir, file, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -132,6 +132,18 @@ var _ = t.Describe("Cgmgr", func() {
 				Expect(cgroupPath).To(ContainSubstring(genericSandboxParent))
 			})
 		})
+		t.Describe("ContainerCgroupDir", func() {
+			It("should be under the cgroup mount point", func() {
+				// Given
+				// When
+				cgroupDir, err := sut.ContainerCgroupDir(genericSandboxParent, cID, "cpuset")
+
+				// Then
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cgroupDir).To(HavePrefix("/sys/fs/cgroup/"))
+				Expect(cgroupDir).To(HaveSuffix(sut.ContainerCgroupPath(genericSandboxParent, cID)))
+			})
+		})
 		t.Describe("SandboxCgroupPath", func() {
 			It("should fail if sandbox parent has .slice", func() {
 				// Given
@@ -156,6 +168,42 @@ var _ = t.Describe("Cgmgr", func() {
 				Expect(err).ToNot(HaveOccurred())
 			})
 		})
+		t.Describe("ReadCgroupFile", func() {
+			It("should read the file of the root cgroup", func() {
+				// Given
+				// When
+				_, err := sut.ReadCgroupFile("/", "cpu", "cgroup.procs")
+
+				// Then
+				Expect(err).ToNot(HaveOccurred())
+			})
+			It("should fail if the cgroup does not exist", func() {
+				// Given
+				// When
+				_, err := sut.ReadCgroupFile(sut.ContainerCgroupPath("", cID), "cpu", "cgroup.procs")
+
+				// Then
+				Expect(err).To(HaveOccurred())
+			})
+		})
+		t.Describe("SetCgroupCpuset", func() {
+			It("should not write anything without CPUs nor memory nodes", func() {
+				// Given
+				// When
+				err := sut.SetCgroupCpuset(sut.ContainerCgroupPath("", cID), "", "")
+
+				// Then
+				Expect(err).ToNot(HaveOccurred())
+			})
+			It("should fail if the cgroup does not exist", func() {
+				// Given
+				// When
+				err := sut.SetCgroupCpuset(sut.ContainerCgroupPath("", cID), "0", "")
+
+				// Then
+				Expect(err).To(HaveOccurred())
+			})
+		})
 		t.Describe("MoveConmonToCgroup", func() {
 			It("should fail if invalid conmon cgroup", func() {
 				// Given
@@ -217,6 +265,63 @@ var _ = t.Describe("Cgmgr", func() {
 				Expect(cgroupPath).To(Equal(""))
 			})
 		})
+		t.Describe("ContainerCgroupDir", func() {
+			It("should be the scope of the container under the cgroup mount point", func() {
+				// Given
+				// When
+				cgroupDir, err := sut.ContainerCgroupDir("", cID, "cpuset")
+
+				// Then
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cgroupDir).To(HavePrefix("/sys/fs/cgroup/"))
+				Expect(cgroupDir).To(HaveSuffix("system.slice/crio-" + cID + ".scope"))
+			})
+			It("should fail to expand slice", func() {
+				// Given
+				// When
+				cgroupDir, err := sut.ContainerCgroupDir("::::", cID, "cpuset")
+
+				// Then
+				Expect(err).To(HaveOccurred())
+				Expect(cgroupDir).To(BeEmpty())
+			})
+		})
+		t.Describe("SetCgroupCpuset", func() {
+			It("should fail if the cgroup path is not in the slice:prefix:name form", func() {
+				// Given
+				// When
+				err := sut.SetCgroupCpuset("system.slice:"+cID, "0", "")
+
+				// Then
+				Expect(err).To(HaveOccurred())
+			})
+			It("should not write anything without CPUs nor memory nodes", func() {
+				// Given
+				// When
+				err := sut.SetCgroupCpuset(sut.ContainerCgroupPath("", cID), "", "")
+
+				// Then
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+		t.Describe("WriteCgroupFile", func() {
+			It("should fail if the cgroup path is not in the slice:prefix:name form", func() {
+				// Given
+				// When
+				err := sut.WriteCgroupFile("system.slice:"+cID, "cpu", "cpu.shares", "2")
+
+				// Then
+				Expect(err).To(HaveOccurred())
+			})
+			It("should fail if the cgroup does not exist", func() {
+				// Given
+				// When
+				err := sut.WriteCgroupFile(sut.ContainerCgroupPath("", cID), "cpu", "cpu.shares", "2")
+
+				// Then
+				Expect(err).To(HaveOccurred())
+			})
+		})
 		t.Describe("SandboxCgroupPath", func() {
 			It("should fail when parent too short", func() {
 				// Given
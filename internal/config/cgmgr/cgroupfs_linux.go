@@ -64,6 +64,12 @@ func (m *CgroupfsManager) ContainerCgroupAbsolutePath(sbParent, containerID stri
 	return m.ContainerCgroupPath(sbParent, containerID), nil
 }
 
+// ContainerCgroupDir takes the sandbox parent, the container ID and the controller.
+// It returns the directory of the container cgroup on disk for that controller.
+func (m *CgroupfsManager) ContainerCgroupDir(sbParent, containerID, controller string) (string, error) {
+	return cgroupDir(m.ContainerCgroupPath(sbParent, containerID), controller), nil
+}
+
 // ContainerCgroupManager takes the cgroup parent, and container ID.
 // It returns the raw libcontainer cgroup manager for that container.
 func (m *CgroupfsManager) ContainerCgroupManager(sbParent, containerID string) (libctrCg.Manager, error) {
@@ -263,3 +269,18 @@ func (m *CgroupfsManager) RemoveSandboxCgroup(sbParent, containerID string) erro
 	// https://github.com/opencontainers/runc/blob/fd5debf3aa/libcontainer/cgroups/fs/paths.go#L156
 	return removeSandboxCgroup(filepath.Join("/", sbParent), containerCgroupPath(containerID))
 }
+
+// ReadCgroupFile returns the content of the file of the controller of the cgroup.
+func (*CgroupfsManager) ReadCgroupFile(cgroupPath, controller, file string) (string, error) {
+	return readCgroupFile(cgroupPath, controller, file)
+}
+
+// WriteCgroupFile writes data to the file of the controller of the cgroup.
+func (*CgroupfsManager) WriteCgroupFile(cgroupPath, controller, file, data string) error {
+	return writeCgroupFile(cgroupPath, controller, file, data)
+}
+
+// SetCgroupCpuset writes the CPUs and memory nodes of the cpuset of the cgroup.
+func (*CgroupfsManager) SetCgroupCpuset(cgroupPath, cpus, mems string) error {
+	return setCgroupCpuset(cgroupPath, cpus, mems)
+}
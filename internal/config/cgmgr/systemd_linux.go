@@ -3,6 +3,7 @@
 package cgmgr
 
 import (
+	"context"
 	"fmt"
 	"path"
 	"path/filepath"
@@ -90,6 +91,16 @@ func (m *SystemdManager) ContainerCgroupAbsolutePath(sbParent, containerID strin
 	return filepath.Join(cgroup, containerCgroupPath(containerID)+".scope"), nil
 }
 
+// ContainerCgroupDir takes the sandbox parent, the container ID and the controller.
+// It returns the directory of the container scope on disk for that controller.
+func (m *SystemdManager) ContainerCgroupDir(sbParent, containerID, controller string) (string, error) {
+	cgPath, err := m.ContainerCgroupAbsolutePath(sbParent, containerID)
+	if err != nil {
+		return "", err
+	}
+	return cgroupDir(cgPath, controller), nil
+}
+
 // ContainerCgroupManager takes the cgroup parent, and container ID.
 // It returns the raw libcontainer cgroup manager for that container.
 func (m *SystemdManager) ContainerCgroupManager(sbParent, containerID string) (cgroups.Manager, error) {
@@ -335,3 +346,88 @@ func (m *SystemdManager) RemoveSandboxCgroup(sbParent, containerID string) error
 	}
 	return removeSandboxCgroup(expandedParent, containerCgroupPath(containerID))
 }
+
+// ReadCgroupFile returns the content of the file of the controller of the cgroup.
+// The cgroup path can be given in the "slice:prefix:name" form of systemd.
+func (*SystemdManager) ReadCgroupFile(cgroupPath, controller, file string) (string, error) {
+	path, err := expandSystemdCgroupPath(cgroupPath)
+	if err != nil {
+		return "", err
+	}
+	return readCgroupFile(path, controller, file)
+}
+
+// WriteCgroupFile writes data to the file of the controller of the cgroup.
+// The cgroup path can be given in the "slice:prefix:name" form of systemd.
+func (*SystemdManager) WriteCgroupFile(cgroupPath, controller, file, data string) error {
+	path, err := expandSystemdCgroupPath(cgroupPath)
+	if err != nil {
+		return err
+	}
+	return writeCgroupFile(path, controller, file, data)
+}
+
+// SetCgroupCpuset sets the CPUs and memory nodes of the cpuset of the cgroup as the AllowedCPUs and
+// AllowedMemoryNodes properties of its unit, before writing them to the cgroup. systemd only manages
+// the cpuset controller on cgroup v2, so the properties are not set on cgroup v1, nor the ones the
+// running systemd does not support, which are only written to the cgroup.
+// The cgroup path can be given in the "slice:prefix:name" form of systemd.
+func (m *SystemdManager) SetCgroupCpuset(cgroupPath, cpus, mems string) error {
+	path, err := expandSystemdCgroupPath(cgroupPath)
+	if err != nil {
+		return err
+	}
+	if !node.CgroupIsV2() {
+		return setCgroupCpuset(path, cpus, mems)
+	}
+
+	props := []systemdDbus.Property{}
+	for _, prop := range []struct {
+		name       string
+		value      string
+		capability node.Capability
+	}{
+		{"AllowedCPUs", cpus, node.CapabilitySystemdAllowedCPUs},
+		{"AllowedMemoryNodes", mems, node.CapabilitySystemdAllowedMemoryNodes},
+	} {
+		if prop.value == "" {
+			continue
+		}
+		if err := node.RequireCapability(prop.capability); err != nil {
+			logrus.Warnf("Not setting %s of %s, which may be reverted by systemd: %v", prop.name, path, err)
+			continue
+		}
+		bits, err := systemd.RangeToBits(prop.value)
+		if err != nil {
+			return fmt.Errorf("%s conversion error: %w", prop.name, err)
+		}
+		props = append(props, systemdDbus.Property{Name: prop.name, Value: dbus.MakeVariant(bits)})
+	}
+	if len(props) == 0 {
+		return setCgroupCpuset(path, cpus, mems)
+	}
+	unit := filepath.Base(path)
+	if err := m.dbusMgr.RetryOnDisconnect(func(c *systemdDbus.Conn) error {
+		return c.SetUnitPropertiesContext(context.Background(), unit, true, props...)
+	}); err != nil {
+		return fmt.Errorf("set cpuset properties of unit %s: %w", unit, err)
+	}
+	return setCgroupCpuset(path, cpus, mems)
+}
+
+// expandSystemdCgroupPath returns the path on disk of the cgroup given in the "slice:prefix:name" form
+// of systemd, like "kubepods.slice:crio:<id>", or the path itself if it is not in that form.
+func expandSystemdCgroupPath(cgroupPath string) (string, error) {
+	if !strings.Contains(cgroupPath, ":") {
+		return cgroupPath, nil
+	}
+	parts := strings.Split(cgroupPath, ":")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("expected cgroup path in the slice:prefix:name form, got %q", cgroupPath)
+	}
+	slice, err := systemd.ExpandSlice(parts[0])
+	if err != nil {
+		return "", fmt.Errorf("expanding systemd slice %s: %w", parts[0], err)
+	}
+	return filepath.Join(slice, parts[1]+"-"+parts[2]+".scope"), nil
+}
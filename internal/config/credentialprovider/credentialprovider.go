@@ -0,0 +1,344 @@
+package credentialprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/cri-o/cri-o/internal/log"
+)
+
+const (
+	configKind       = "CredentialProviderConfig"
+	configAPIVersion = "kubelet.config.k8s.io/v1"
+
+	requestKind   = "CredentialProviderRequest"
+	responseKind  = "CredentialProviderResponse"
+	pluginVersion = "credentialprovider.kubelet.k8s.io/v1"
+
+	// pluginTimeout bounds a run of a credential provider plugin.
+	pluginTimeout = time.Minute
+)
+
+// The cache key types of the credential provider plugin responses, telling which images the credentials apply to.
+const (
+	cacheKeyImage    = "Image"
+	cacheKeyRegistry = "Registry"
+	cacheKeyGlobal   = "Global"
+)
+
+// Config is the kubelet credential provider configuration, the same file being usable by the kubelet and CRI-O.
+type Config struct {
+	Kind       string     `json:"kind"`
+	APIVersion string     `json:"apiVersion"`
+	Providers  []Provider `json:"providers"`
+}
+
+// Provider is a credential provider plugin, run for the images it matches.
+type Provider struct {
+	// Name is the name of the plugin binary in the plugin directory.
+	Name string `json:"name"`
+	// MatchImages are the patterns of the images the plugin provides credentials for, like
+	// "*.dkr.ecr.*.amazonaws.com", the host name parts being matched by glob and the path by prefix.
+	MatchImages []string `json:"matchImages"`
+	// DefaultCacheDuration is the time the credentials get cached for if the plugin does not tell.
+	DefaultCacheDuration *metav1.Duration `json:"defaultCacheDuration"`
+	// APIVersion is the version of the requests and responses of the plugin.
+	APIVersion string `json:"apiVersion"`
+	// Args are the arguments of the plugin.
+	Args []string `json:"args,omitempty"`
+	// Env are the environment variables set for the plugin on top of the ones of CRI-O.
+	Env []EnvVar `json:"env,omitempty"`
+}
+
+// EnvVar is an environment variable of a credential provider plugin.
+type EnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type request struct {
+	Kind       string `json:"kind"`
+	APIVersion string `json:"apiVersion"`
+	Image      string `json:"image"`
+}
+
+type response struct {
+	Kind          string                `json:"kind"`
+	APIVersion    string                `json:"apiVersion"`
+	CacheKeyType  string                `json:"cacheKeyType"`
+	CacheDuration *metav1.Duration      `json:"cacheDuration,omitempty"`
+	Auth          map[string]authConfig `json:"auth,omitempty"`
+}
+
+type authConfig struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type cacheEntry struct {
+	auth    map[string]authConfig
+	expires time.Time
+}
+
+// Providers runs the kubelet credential provider plugins to get the credentials of the images CRI-O pulls on
+// its own, like the pause image, as the kubelet only sends credentials for the images it requests, so that
+// short-lived registry tokens work without a static auth file.
+type Providers struct {
+	binDir    string
+	providers []Provider
+
+	lock sync.Mutex
+	// cache are the credentials returned by the plugins by plugin and cache key.
+	cache map[string]cacheEntry
+}
+
+// New loads the credential provider configuration, the plugins being in the bin directory.
+func New(configPath, binDir string) (*Providers, error) {
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	config := &Config{}
+	if err := yaml.UnmarshalStrict(content, config); err != nil {
+		return nil, fmt.Errorf("parse credential provider config %s: %w", configPath, err)
+	}
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("invalid credential provider config %s: %w", configPath, err)
+	}
+	for i := range config.Providers {
+		if _, err := os.Stat(filepath.Join(binDir, config.Providers[i].Name)); err != nil {
+			return nil, fmt.Errorf("credential provider plugin %s: %w", config.Providers[i].Name, err)
+		}
+	}
+	return &Providers{
+		binDir:    binDir,
+		providers: config.Providers,
+		cache:     make(map[string]cacheEntry),
+	}, nil
+}
+
+func (c *Config) validate() error {
+	if c.Kind != configKind || c.APIVersion != configAPIVersion {
+		return fmt.Errorf("expected kind %s of apiVersion %s, got %s of %s", configKind, configAPIVersion, c.Kind, c.APIVersion)
+	}
+	if len(c.Providers) == 0 {
+		return errors.New("no providers")
+	}
+	names := make(map[string]bool)
+	for i := range c.Providers {
+		provider := &c.Providers[i]
+		if provider.Name == "" || provider.Name == "." || provider.Name == ".." || strings.ContainsAny(provider.Name, "/ ") {
+			return fmt.Errorf("invalid provider name %q", provider.Name)
+		}
+		if names[provider.Name] {
+			return fmt.Errorf("duplicate provider %s", provider.Name)
+		}
+		names[provider.Name] = true
+		if len(provider.MatchImages) == 0 {
+			return fmt.Errorf("provider %s matches no images", provider.Name)
+		}
+		for _, pattern := range provider.MatchImages {
+			if err := validateImagePattern(pattern); err != nil {
+				return fmt.Errorf("invalid image pattern %q of provider %s: %w", pattern, provider.Name, err)
+			}
+		}
+		if provider.DefaultCacheDuration == nil || provider.DefaultCacheDuration.Duration < 0 {
+			return fmt.Errorf("provider %s requires a non-negative defaultCacheDuration", provider.Name)
+		}
+		if provider.APIVersion != pluginVersion {
+			return fmt.Errorf("unsupported apiVersion %q of provider %s, expected %s", provider.APIVersion, provider.Name, pluginVersion)
+		}
+	}
+	return nil
+}
+
+// Credentials returns the credentials for the image from the first plugin matching it which provides any,
+// nil if none does. The credentials get cached by the plugin for the time it tells.
+func (p *Providers) Credentials(ctx context.Context, image reference.Named) (*types.DockerAuthConfig, error) {
+	name := image.String()
+	for i := range p.providers {
+		provider := &p.providers[i]
+		if !matchesAny(provider.MatchImages, name) {
+			continue
+		}
+		auth, ok := p.cached(provider, image)
+		if !ok {
+			var err error
+			auth, err = p.run(ctx, provider, image)
+			if err != nil {
+				return nil, fmt.Errorf("credential provider %s: %w", provider.Name, err)
+			}
+		}
+		if credentials := credentialsFor(auth, name); credentials != nil {
+			log.Debugf(ctx, "Using the credentials of credential provider %s for image %s", provider.Name, name)
+			return credentials, nil
+		}
+	}
+	return nil, nil
+}
+
+// cacheKeys returns the cache keys of the credentials of the provider for the image by cache key type.
+func cacheKeys(provider *Provider, image reference.Named) map[string]string {
+	return map[string]string{
+		cacheKeyImage:    provider.Name + "/image/" + image.String(),
+		cacheKeyRegistry: provider.Name + "/registry/" + reference.Domain(image),
+		cacheKeyGlobal:   provider.Name + "/global",
+	}
+}
+
+func (p *Providers) cached(provider *Provider, image reference.Named) (map[string]authConfig, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	now := time.Now()
+	for _, key := range cacheKeys(provider, image) {
+		entry, ok := p.cache[key]
+		if !ok {
+			continue
+		}
+		if now.After(entry.expires) {
+			delete(p.cache, key)
+			continue
+		}
+		return entry.auth, true
+	}
+	return nil, false
+}
+
+// run runs the plugin for the image, caching the credentials it returns.
+func (p *Providers) run(ctx context.Context, provider *Provider, image reference.Named) (map[string]authConfig, error) {
+	req, err := json.Marshal(&request{Kind: requestKind, APIVersion: provider.APIVersion, Image: image.String()})
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, pluginTimeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, filepath.Join(p.binDir, provider.Name), provider.Args...)
+	cmd.Env = os.Environ()
+	for _, env := range provider.Env {
+		cmd.Env = append(cmd.Env, env.Name+"="+env.Value)
+	}
+	cmd.Stdin = bytes.NewReader(req)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("run plugin: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	resp := &response{}
+	if err := json.Unmarshal(stdout.Bytes(), resp); err != nil {
+		return nil, fmt.Errorf("parse plugin response: %w", err)
+	}
+	if resp.Kind != responseKind || resp.APIVersion != provider.APIVersion {
+		return nil, fmt.Errorf("expected a response of kind %s of apiVersion %s, got %s of %s", responseKind, provider.APIVersion, resp.Kind, resp.APIVersion)
+	}
+	key, ok := cacheKeys(provider, image)[resp.CacheKeyType]
+	if !ok {
+		return nil, fmt.Errorf("invalid cacheKeyType %q of plugin response", resp.CacheKeyType)
+	}
+	duration := provider.DefaultCacheDuration.Duration
+	if resp.CacheDuration != nil {
+		duration = resp.CacheDuration.Duration
+	}
+	if duration > 0 {
+		p.lock.Lock()
+		now := time.Now()
+		p.pruneCache(now)
+		p.cache[key] = cacheEntry{auth: resp.Auth, expires: now.Add(duration)}
+		p.lock.Unlock()
+	}
+	return resp.Auth, nil
+}
+
+// pruneCache removes the expired credentials from the cache, which would otherwise keep the ones of the images
+// and registries not pulled from anymore. It must be called with the lock held.
+func (p *Providers) pruneCache(now time.Time) {
+	for key, entry := range p.cache {
+		if now.After(entry.expires) {
+			delete(p.cache, key)
+		}
+	}
+}
+
+// credentialsFor returns the credentials of the most specific pattern of the plugin response matching the image.
+func credentialsFor(auth map[string]authConfig, image string) *types.DockerAuthConfig {
+	var credentials *types.DockerAuthConfig
+	best := -1
+	for pattern, config := range auth {
+		if !matchImage(pattern, image) || len(pattern) <= best {
+			continue
+		}
+		best = len(pattern)
+		credentials = &types.DockerAuthConfig{Username: config.Username, Password: config.Password}
+	}
+	return credentials
+}
+
+func matchesAny(patterns []string, image string) bool {
+	for _, pattern := range patterns {
+		if matchImage(pattern, image) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateImagePattern returns an error if the image pattern has no host name or a malformed glob.
+func validateImagePattern(pattern string) error {
+	host, _, _ := strings.Cut(strings.TrimPrefix(pattern, "https://"), "/")
+	host, _ = splitPort(host)
+	if host == "" {
+		return errors.New("no host name")
+	}
+	for _, part := range strings.Split(host, ".") {
+		if _, err := filepath.Match(part, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchImage returns true if the image matches the pattern the way the kubelet matches them: the host name
+// parts match the glob parts of the pattern one by one, the ports are the same and the path of the pattern
+// is a prefix of the one of the image.
+func matchImage(pattern, image string) bool {
+	patternHost, patternPath, _ := strings.Cut(strings.TrimPrefix(pattern, "https://"), "/")
+	imageHost, imagePath, _ := strings.Cut(image, "/")
+	patternHost, patternPort := splitPort(patternHost)
+	imageHost, imagePort := splitPort(imageHost)
+	if patternPort != imagePort {
+		return false
+	}
+	patternParts, imageParts := strings.Split(patternHost, "."), strings.Split(imageHost, ".")
+	if len(patternParts) != len(imageParts) {
+		return false
+	}
+	for i := range patternParts {
+		if matched, err := filepath.Match(patternParts[i], imageParts[i]); err != nil || !matched {
+			return false
+		}
+	}
+	return strings.HasPrefix(imagePath, patternPath)
+}
+
+func splitPort(host string) (hostname, port string) {
+	if hostname, port, err := net.SplitHostPort(host); err == nil {
+		return hostname, port
+	}
+	return host, ""
+}
@@ -0,0 +1,202 @@
+package credentialprovider_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cri-o/cri-o/internal/config/credentialprovider"
+)
+
+const pluginConfig = `
+kind: CredentialProviderConfig
+apiVersion: kubelet.config.k8s.io/v1
+providers:
+- name: registry-token
+  matchImages:
+  - "*.registry.example.com"
+  - "registry.example.com:5000/team"
+  defaultCacheDuration: 10m
+  apiVersion: credentialprovider.kubelet.k8s.io/v1
+  args: ["--token"]
+  env:
+  - name: TOKEN_FILE
+    value: /etc/token
+`
+
+// The plugin records its requests and arguments, and returns credentials for the whole registry.
+const pluginScript = `#!/bin/sh
+cat >> "$(dirname "$0")/requests"
+echo " $* $TOKEN_FILE" >> "$(dirname "$0")/requests"
+cat <<EOF
+{
+  "kind": "CredentialProviderResponse",
+  "apiVersion": "credentialprovider.kubelet.k8s.io/v1",
+  "cacheKeyType": "Registry",
+  "auth": {
+    "*.registry.example.com": {"username": "user", "password": "token"},
+    "eu.registry.example.com/restricted": {"username": "restricted", "password": "other-token"},
+    "registry.example.com:5000": {"username": "team", "password": "team-token"}
+  }
+}
+EOF
+`
+
+var _ = t.Describe("Providers", func() {
+	var dir, config string
+
+	BeforeEach(func() {
+		dir = t.MustTempDir("crio-credential-provider-")
+		config = filepath.Join(dir, "config.yaml")
+		Expect(os.WriteFile(config, []byte(pluginConfig), 0o600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "registry-token"), []byte(pluginScript), 0o700)).To(Succeed())
+	})
+
+	credentials := func(sut *credentialprovider.Providers, image string) *types.DockerAuthConfig {
+		named, err := reference.ParseNormalizedNamed(image)
+		Expect(err).ToNot(HaveOccurred())
+		auth, err := sut.Credentials(context.Background(), named)
+		Expect(err).ToNot(HaveOccurred())
+		return auth
+	}
+
+	requests := func() []string {
+		content, err := os.ReadFile(filepath.Join(dir, "requests"))
+		if os.IsNotExist(err) {
+			return nil
+		}
+		Expect(err).ToNot(HaveOccurred())
+		return strings.Split(strings.TrimSpace(string(content)), "\n")
+	}
+
+	It("should provide the credentials of the plugins, cached by registry", func() {
+		// Given
+		sut, err := credentialprovider.New(config, dir)
+		Expect(err).ToNot(HaveOccurred())
+
+		// When
+		auth := credentials(sut, "eu.registry.example.com/crio/pause:3.10")
+		restricted := credentials(sut, "eu.registry.example.com/restricted/pause:3.10")
+
+		// Then
+		Expect(auth).To(Equal(&types.DockerAuthConfig{Username: "user", Password: "token"}))
+		Expect(restricted).To(Equal(&types.DockerAuthConfig{Username: "restricted", Password: "other-token"}))
+		Expect(requests()).To(Equal([]string{
+			`{"kind":"CredentialProviderRequest","apiVersion":"credentialprovider.kubelet.k8s.io/v1","image":"eu.registry.example.com/crio/pause:3.10"} --token /etc/token`,
+		}))
+	})
+
+	It("should prune the expired credentials", func() {
+		// Given
+		script := strings.Replace(pluginScript, `"cacheKeyType": "Registry",`, `"cacheKeyType": "Image", "cacheDuration": "1ms",`, 1)
+		Expect(os.WriteFile(filepath.Join(dir, "registry-token"), []byte(script), 0o700)).To(Succeed())
+		sut, err := credentialprovider.New(config, dir)
+		Expect(err).ToNot(HaveOccurred())
+		credentials(sut, "eu.registry.example.com/crio/pause:3.10")
+		Expect(sut.CachedCredentials()).To(Equal(1))
+		time.Sleep(10 * time.Millisecond)
+
+		// When
+		credentials(sut, "us.registry.example.com/crio/pause:3.10")
+
+		// Then
+		Expect(sut.CachedCredentials()).To(Equal(1))
+		Expect(requests()).To(HaveLen(2))
+	})
+
+	It("should not cache the credentials without cache duration", func() {
+		// Given
+		Expect(os.WriteFile(config, []byte(strings.Replace(pluginConfig, "defaultCacheDuration: 10m", "defaultCacheDuration: 0s", 1)), 0o600)).To(Succeed())
+		sut, err := credentialprovider.New(config, dir)
+		Expect(err).ToNot(HaveOccurred())
+
+		// When
+		credentials(sut, "eu.registry.example.com/crio/pause:3.10")
+
+		// Then
+		Expect(sut.CachedCredentials()).To(BeZero())
+	})
+
+	It("should match the ports and paths of the images", func() {
+		// Given
+		sut, err := credentialprovider.New(config, dir)
+		Expect(err).ToNot(HaveOccurred())
+
+		// When
+		team := credentials(sut, "registry.example.com:5000/team/pause:3.10")
+		other := credentials(sut, "registry.example.com:5000/other/pause:3.10")
+		noPort := credentials(sut, "registry.example.com/team/pause:3.10")
+
+		// Then
+		Expect(team).To(Equal(&types.DockerAuthConfig{Username: "team", Password: "team-token"}))
+		Expect(other).To(BeNil())
+		Expect(noPort).To(BeNil())
+		Expect(requests()).To(HaveLen(1))
+	})
+
+	It("should not run the plugins for other images", func() {
+		// Given
+		sut, err := credentialprovider.New(config, dir)
+		Expect(err).ToNot(HaveOccurred())
+
+		// When
+		auth := credentials(sut, "quay.io/crio/pause:3.10")
+
+		// Then
+		Expect(auth).To(BeNil())
+		Expect(requests()).To(BeEmpty())
+	})
+
+	It("should fail on a failing plugin", func() {
+		// Given
+		Expect(os.WriteFile(filepath.Join(dir, "registry-token"), []byte("#!/bin/sh\necho denied >&2\nexit 1\n"), 0o700)).To(Succeed())
+		sut, err := credentialprovider.New(config, dir)
+		Expect(err).ToNot(HaveOccurred())
+		named, err := reference.ParseNormalizedNamed("eu.registry.example.com/crio/pause:3.10")
+		Expect(err).ToNot(HaveOccurred())
+
+		// When
+		_, err = sut.Credentials(context.Background(), named)
+
+		// Then
+		Expect(err).To(MatchError(ContainSubstring("denied")))
+	})
+
+	It("should fail without the plugin", func() {
+		// Given
+		Expect(os.Remove(filepath.Join(dir, "registry-token"))).To(Succeed())
+
+		// When
+		_, err := credentialprovider.New(config, dir)
+
+		// Then
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should fail on an invalid config", func() {
+		for _, invalid := range []string{
+			strings.Replace(pluginConfig, "kubelet.config.k8s.io/v1", "kubelet.config.k8s.io/v1alpha1", 1),
+			strings.Replace(pluginConfig, "credentialprovider.kubelet.k8s.io/v1", "credentialprovider.kubelet.k8s.io/v2", 1),
+			strings.Replace(pluginConfig, "  defaultCacheDuration: 10m\n", "", 1),
+			strings.Replace(pluginConfig, `"*.registry.example.com"`, `"[.registry.example.com"`, 1),
+			strings.Replace(pluginConfig, "name: registry-token", "name: ../registry-token", 1),
+			pluginConfig + "unknown: true\n",
+		} {
+			// Given
+			Expect(os.WriteFile(config, []byte(invalid), 0o600)).To(Succeed())
+
+			// When
+			_, err := credentialprovider.New(config, dir)
+
+			// Then
+			Expect(err).To(HaveOccurred(), invalid)
+		}
+	})
+})
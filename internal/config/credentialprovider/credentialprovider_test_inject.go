@@ -0,0 +1,13 @@
+//go:build test
+
+// All *_inject.go files are meant to be used by tests only. Purpose of this
+// files is to provide a way to inject mocked data into the current setup.
+
+package credentialprovider
+
+// CachedCredentials returns the number of credentials in the cache.
+func (p *Providers) CachedCredentials() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return len(p.cache)
+}
@@ -0,0 +1,55 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Capability is a capability of the node the tuning of the containers depends on.
+type Capability string
+
+const (
+	// CapabilityCPUFreq is the scaling of the frequency of the CPUs by a cpufreq driver.
+	CapabilityCPUFreq Capability = "cpufreq"
+	// CapabilityCPUIdle is the entering of the idle states of the CPUs by a cpuidle driver.
+	CapabilityCPUIdle Capability = "cpuidle"
+	// CapabilityResctrl is the resctrl filesystem the RDT classes are applied through.
+	CapabilityResctrl Capability = "resctrl"
+	// CapabilitySchedCore is the core scheduling of the kernel.
+	CapabilitySchedCore Capability = "sched_core"
+	// CapabilityCpusetPartition is the support of cpuset partitions by cgroup v2.
+	CapabilityCpusetPartition Capability = "cpuset partition"
+	// CapabilitySystemdAllowedCPUs is the AllowedCPUs property systemd sets the CPUs of the cpuset of its units with.
+	CapabilitySystemdAllowedCPUs Capability = "systemd AllowedCPUs"
+	// CapabilitySystemdAllowedMemoryNodes is the AllowedMemoryNodes property systemd sets the memory nodes of
+	// the cpuset of its units with.
+	CapabilitySystemdAllowedMemoryNodes Capability = "systemd AllowedMemoryNodes"
+)
+
+// capabilityMissingReasons tell why the node lacks a capability when probing it did not fail.
+var capabilityMissingReasons = map[Capability]string{
+	CapabilityCPUFreq:                   "no cpufreq driver is loaded",
+	CapabilityCPUIdle:                   "no cpuidle driver is loaded",
+	CapabilityResctrl:                   "the resctrl filesystem is not mounted",
+	CapabilitySchedCore:                 "the kernel is built without core scheduling or SMT is disabled",
+	CapabilityCpusetPartition:           "the cpuset controller of cgroup v2 does not support partitions",
+	CapabilitySystemdAllowedCPUs:        "systemd is older than 244",
+	CapabilitySystemdAllowedMemoryNodes: "systemd is older than 244",
+}
+
+// ErrUnsupported is wrapped by the errors of the features the node lacks a capability for.
+var ErrUnsupported = errors.New("unsupported on this node")
+
+// UnsupportedError is returned by RequireCapability when the node lacks the capability.
+type UnsupportedError struct {
+	Capability Capability
+	Err        error
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("%s is %v: %v", e.Capability, ErrUnsupported, e.Err)
+}
+
+func (e *UnsupportedError) Unwrap() []error {
+	return []error{ErrUnsupported, e.Err}
+}
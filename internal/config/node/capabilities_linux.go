@@ -0,0 +1,204 @@
+//go:build linux
+
+package node
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"github.com/containers/storage/pkg/unshare"
+	systemdDbus "github.com/coreos/go-systemd/v22/dbus"
+	"golang.org/x/sys/unix"
+
+	"github.com/cri-o/cri-o/internal/dbusmgr"
+)
+
+const (
+	cpuFreqDriverGlob   = "/sys/devices/system/cpu/cpu*/cpufreq/scaling_driver"
+	cpuIdleDriverFile   = "/sys/devices/system/cpu/cpuidle/current_driver"
+	resctrlInfoDir      = "/sys/fs/resctrl/info"
+	cpusetPartitionGlob = "/sys/fs/cgroup/*/cpuset.cpus.partition"
+	cpuIdleDriverNone   = "none"
+	pidTypePID          = 0
+)
+
+var (
+	cpuFreqDriverOnce sync.Once
+	cpuFreqDriver     string
+	cpuFreqDriverErr  error
+
+	cpuIdleDriverOnce sync.Once
+	cpuIdleDriver     string
+	cpuIdleDriverErr  error
+
+	hasResctrlOnce sync.Once
+	hasResctrl     bool
+	hasResctrlErr  error
+
+	hasSchedCoreOnce sync.Once
+	hasSchedCore     bool
+	hasSchedCoreErr  error
+
+	cgroupHasCpusetPartitionOnce sync.Once
+	cgroupHasCpusetPartition     bool
+	cgroupHasCpusetPartitionErr  error
+
+	systemdVersionOnce sync.Once
+	systemdVersion     int
+	systemdVersionErr  error
+)
+
+// CPUFreqDriver returns the name of the cpufreq driver scaling the frequency of the CPUs, like
+// "intel_pstate" or "acpi-cpufreq", or an empty string if the frequency of the CPUs cannot be scaled.
+func CPUFreqDriver() string {
+	cpuFreqDriverOnce.Do(func() {
+		files, err := filepath.Glob(cpuFreqDriverGlob)
+		if err != nil || len(files) == 0 {
+			cpuFreqDriverErr = err
+			return
+		}
+		cpuFreqDriver, cpuFreqDriverErr = readDriver(files[0])
+	})
+	return cpuFreqDriver
+}
+
+// CPUIdleDriver returns the name of the cpuidle driver entering the idle states of the CPUs, like
+// "intel_idle" or "acpi_idle", or an empty string if the CPUs do not have any idle state.
+func CPUIdleDriver() string {
+	cpuIdleDriverOnce.Do(func() {
+		driver, err := readDriver(cpuIdleDriverFile)
+		if driver == cpuIdleDriverNone {
+			driver = ""
+		}
+		cpuIdleDriver, cpuIdleDriverErr = driver, err
+	})
+	return cpuIdleDriver
+}
+
+func readDriver(file string) (string, error) {
+	content, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// HasResctrl returns whether the resctrl filesystem is mounted, so that the RDT classes can be applied.
+func HasResctrl() bool {
+	hasResctrlOnce.Do(func() {
+		if _, err := os.Stat(resctrlInfoDir); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				hasResctrlErr = err
+			}
+			return
+		}
+		hasResctrl = true
+	})
+	return hasResctrl
+}
+
+// HasSchedCore returns whether the kernel supports core scheduling, which needs
+// CONFIG_SCHED_CORE and simultaneous multithreading to be enabled.
+func HasSchedCore() bool {
+	hasSchedCoreOnce.Do(func() {
+		var cookie uint64
+		err := unix.Prctl(unix.PR_SCHED_CORE, unix.PR_SCHED_CORE_GET, 0, pidTypePID, uintptr(unsafe.Pointer(&cookie)))
+		switch {
+		case err == nil:
+			hasSchedCore = true
+		case errors.Is(err, unix.EINVAL), errors.Is(err, unix.ENODEV):
+		default:
+			hasSchedCoreErr = err
+		}
+	})
+	return hasSchedCore
+}
+
+// CgroupHasCpusetPartition returns whether the cpuset controller of cgroup v2 supports partitions,
+// which are needed to disable the CPU load balancing of the exclusive CPUs of the containers.
+func CgroupHasCpusetPartition() bool {
+	cgroupHasCpusetPartitionOnce.Do(func() {
+		if !CgroupIsV2() {
+			return
+		}
+		files, err := filepath.Glob(cpusetPartitionGlob)
+		if err != nil {
+			cgroupHasCpusetPartitionErr = err
+			return
+		}
+		cgroupHasCpusetPartition = len(files) > 0
+	})
+	return cgroupHasCpusetPartition
+}
+
+// SystemdVersion returns the version of the running systemd, like 252, or 0 if it cannot be determined.
+// It is read once from the systemd manager over D-Bus, instead of running systemctl.
+func SystemdVersion() int {
+	systemdVersionOnce.Do(func() {
+		var version string
+		err := dbusmgr.NewDbusConnManager(unshare.IsRootless()).RetryOnDisconnect(func(c *systemdDbus.Conn) (err error) {
+			version, err = c.GetManagerProperty("Version")
+			return err
+		})
+		if err != nil {
+			systemdVersionErr = fmt.Errorf("get systemd version: %w", err)
+			return
+		}
+		systemdVersion, systemdVersionErr = parseSystemdVersion(version)
+	})
+	return systemdVersion
+}
+
+// parseSystemdVersion parses the major version of systemd out of the Version property of its manager,
+// which reads like "252.22-1.el9" or "v255-stable", quoted as a D-Bus string variant.
+func parseSystemdVersion(version string) (int, error) {
+	trimmed := strings.TrimPrefix(strings.Trim(version, `"`), "v")
+	end := strings.IndexFunc(trimmed, func(r rune) bool { return r < '0' || r > '9' })
+	if end < 0 {
+		end = len(trimmed)
+	}
+	major, err := strconv.Atoi(trimmed[:end])
+	if err != nil {
+		return 0, fmt.Errorf("unexpected systemd version %q", version)
+	}
+	return major, nil
+}
+
+// capabilityProbes tell whether the node has a capability, along with the error of probing it.
+var capabilityProbes = map[Capability]struct {
+	supported func() bool
+	err       *error
+}{
+	CapabilityCPUFreq:                   {func() bool { return CPUFreqDriver() != "" }, &cpuFreqDriverErr},
+	CapabilityCPUIdle:                   {func() bool { return CPUIdleDriver() != "" }, &cpuIdleDriverErr},
+	CapabilityResctrl:                   {HasResctrl, &hasResctrlErr},
+	CapabilitySchedCore:                 {HasSchedCore, &hasSchedCoreErr},
+	CapabilityCpusetPartition:           {CgroupHasCpusetPartition, &cgroupHasCpusetPartitionErr},
+	CapabilitySystemdAllowedCPUs:        {SystemdHasAllowedCPUs, &systemdHasAllowedCPUsErr},
+	CapabilitySystemdAllowedMemoryNodes: {SystemdHasAllowedMemoryNodes, &systemdHasAllowedMemoryNodesErr},
+}
+
+// RequireCapability returns an error wrapping ErrUnsupported which tells why the
+// node lacks the capability, or nil if the node has it.
+func RequireCapability(capability Capability) error {
+	probe, ok := capabilityProbes[capability]
+	if !ok {
+		return fmt.Errorf("unknown node capability %q", capability)
+	}
+	if probe.supported() {
+		return nil
+	}
+	if *probe.err != nil {
+		return &UnsupportedError{Capability: capability, Err: *probe.err}
+	}
+	return &UnsupportedError{Capability: capability, Err: errors.New(capabilityMissingReasons[capability])}
+}
@@ -0,0 +1,87 @@
+package node
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RequireCapability", func() {
+	It("should fail for an unknown capability", func() {
+		err := RequireCapability("unknown")
+		Expect(err).To(HaveOccurred())
+		Expect(errors.Is(err, ErrUnsupported)).To(BeFalse())
+	})
+
+	It("should tell why the node lacks every capability", func() {
+		for capability := range capabilityProbes {
+			Expect(capabilityMissingReasons).To(HaveKey(capability))
+		}
+	})
+
+	It("should report the probed capabilities", func() {
+		for capability, probe := range capabilityProbes {
+			err := RequireCapability(capability)
+			if probe.supported() {
+				Expect(err).ToNot(HaveOccurred())
+				continue
+			}
+			Expect(err).To(MatchError(ErrUnsupported))
+			unsupported := &UnsupportedError{}
+			Expect(errors.As(err, &unsupported)).To(BeTrue())
+			Expect(unsupported.Capability).To(Equal(capability))
+		}
+	})
+})
+
+var _ = Describe("UnsupportedError", func() {
+	It("should wrap ErrUnsupported and the reason", func() {
+		reason := errors.New("reason")
+		err := error(&UnsupportedError{Capability: CapabilityResctrl, Err: reason})
+		Expect(err).To(MatchError(ErrUnsupported))
+		Expect(err).To(MatchError(reason))
+		Expect(err.Error()).To(Equal("resctrl is unsupported on this node: reason"))
+	})
+})
+
+var _ = Describe("readDriver", func() {
+	It("should read the driver", func() {
+		file := filepath.Join(GinkgoT().TempDir(), "scaling_driver")
+		Expect(os.WriteFile(file, []byte("intel_pstate\n"), 0o644)).To(Succeed())
+		Expect(readDriver(file)).To(Equal("intel_pstate"))
+	})
+
+	It("should not fail without a driver", func() {
+		Expect(readDriver(filepath.Join(GinkgoT().TempDir(), "current_driver"))).To(BeEmpty())
+	})
+})
+
+var _ = Describe("parseSystemdVersion", func() {
+	DescribeTable("should parse the major version",
+		func(version string, expected int) {
+			Expect(parseSystemdVersion(version)).To(Equal(expected))
+		},
+		Entry("quoted", `"252.22-1.el9"`, 252),
+		Entry("prefixed", `"v255-stable"`, 255),
+		Entry("major only", "244", 244),
+	)
+
+	It("should fail on an unexpected version", func() {
+		_, err := parseSystemdVersion(`"unknown"`)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("systemdSupportsCpusetProperty", func() {
+	It("should follow the version of systemd", func() {
+		if SystemdVersion() == 0 {
+			Skip("systemd is not running")
+		}
+		supported, err := systemdSupportsCpusetProperty("AllowedCPUs")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(supported).To(Equal(SystemdVersion() >= systemdCpusetPropertiesVersion))
+	})
+})
@@ -0,0 +1,34 @@
+//go:build !linux
+
+package node
+
+import "errors"
+
+func CPUFreqDriver() string {
+	return ""
+}
+
+func CPUIdleDriver() string {
+	return ""
+}
+
+func HasResctrl() bool {
+	return false
+}
+
+func HasSchedCore() bool {
+	return false
+}
+
+func CgroupHasCpusetPartition() bool {
+	return false
+}
+
+func SystemdVersion() int {
+	return 0
+}
+
+// RequireCapability returns an error wrapping ErrUnsupported, as the capabilities are only probed on Linux.
+func RequireCapability(capability Capability) error {
+	return &UnsupportedError{Capability: capability, Err: errors.New("only supported on Linux")}
+}
@@ -10,7 +10,8 @@ import (
 
 // ValidateConfig initializes and validates all of the singleton variables
 // that store the node's configuration.
-// Currently, we check hugetlb, cgroup v1 or v2, pid and memory swap support for cgroups.
+// Currently, we check hugetlb, cgroup v1 or v2, pid and memory swap support for cgroups,
+// and probe the capabilities of the node the tuning of the containers depends on.
 // We check the error at server configuration validation, and if we error, shutdown
 // cri-o early, instead of when we're already trying to run containers.
 func ValidateConfig() error {
@@ -57,6 +58,34 @@ func ValidateConfig() error {
 			activated: &systemdHasAllowedCPUs,
 			fatal:     false,
 		},
+		{
+			name:      "systemd AllowedMemoryNodes",
+			init:      SystemdHasAllowedMemoryNodes,
+			err:       &systemdHasAllowedMemoryNodesErr,
+			activated: &systemdHasAllowedMemoryNodes,
+			fatal:     false,
+		},
+		{
+			name:      "resctrl",
+			init:      HasResctrl,
+			err:       &hasResctrlErr,
+			activated: &hasResctrl,
+			fatal:     false,
+		},
+		{
+			name:      "sched_core",
+			init:      HasSchedCore,
+			err:       &hasSchedCoreErr,
+			activated: &hasSchedCore,
+			fatal:     false,
+		},
+		{
+			name:      "cgroup v2 cpuset partition",
+			init:      CgroupHasCpusetPartition,
+			err:       &cgroupHasCpusetPartitionErr,
+			activated: &cgroupHasCpusetPartition,
+			fatal:     false,
+		},
 		{
 			name:      "fs.may_detach_mounts sysctl",
 			init:      checkFsMayDetachMounts,
@@ -78,5 +107,21 @@ func ValidateConfig() error {
 			logrus.Infof("Node configuration value for %s is %v", i.name, *i.activated)
 		}
 	}
+	for _, i := range []struct {
+		name  string
+		value func() any
+		err   *error
+	}{
+		{"cpufreq driver", func() any { return CPUFreqDriver() }, &cpuFreqDriverErr},
+		{"cpuidle driver", func() any { return CPUIdleDriver() }, &cpuIdleDriverErr},
+		{"systemd version", func() any { return SystemdVersion() }, &systemdVersionErr},
+	} {
+		value := i.value()
+		if *i.err != nil {
+			logrus.Warn(fmt.Errorf("node configuration validation for %s failed: %w", i.name, *i.err))
+			continue
+		}
+		logrus.Infof("Node configuration value for %s is %q", i.name, fmt.Sprint(value))
+	}
 	return nil
 }
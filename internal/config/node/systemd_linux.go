@@ -9,19 +9,47 @@ import (
 	"github.com/cri-o/cri-o/utils/cmdrunner"
 )
 
+// systemdCpusetPropertiesVersion is the version of systemd which introduced the AllowedCPUs
+// and AllowedMemoryNodes properties.
+const systemdCpusetPropertiesVersion = 244
+
 var (
 	systemdHasAllowedCPUsOnce sync.Once
 	systemdHasAllowedCPUs     bool
 	systemdHasAllowedCPUsErr  error
+
+	systemdHasAllowedMemoryNodesOnce sync.Once
+	systemdHasAllowedMemoryNodes     bool
+	systemdHasAllowedMemoryNodesErr  error
 )
 
+// SystemdHasAllowedCPUs returns whether systemd supports the AllowedCPUs property,
+// to set the CPUs of the cpuset of its units.
 func SystemdHasAllowedCPUs() bool {
 	systemdHasAllowedCPUsOnce.Do(func() {
-		systemdHasAllowedCPUs, systemdHasAllowedCPUsErr = systemdSupportsProperty("AllowedCPUs")
+		systemdHasAllowedCPUs, systemdHasAllowedCPUsErr = systemdSupportsCpusetProperty("AllowedCPUs")
 	})
 	return systemdHasAllowedCPUs
 }
 
+// SystemdHasAllowedMemoryNodes returns whether systemd supports the AllowedMemoryNodes property,
+// to set the memory nodes of the cpuset of its units.
+func SystemdHasAllowedMemoryNodes() bool {
+	systemdHasAllowedMemoryNodesOnce.Do(func() {
+		systemdHasAllowedMemoryNodes, systemdHasAllowedMemoryNodesErr = systemdSupportsCpusetProperty("AllowedMemoryNodes")
+	})
+	return systemdHasAllowedMemoryNodes
+}
+
+// systemdSupportsCpusetProperty checks whether systemd supports a property of the cpuset of its units
+// from its version, or by querying the property if the version cannot be determined.
+func systemdSupportsCpusetProperty(property string) (bool, error) {
+	if version := SystemdVersion(); version > 0 {
+		return version >= systemdCpusetPropertiesVersion, nil
+	}
+	return systemdSupportsProperty(property)
+}
+
 // systemdSupportsProperty checks whether systemd supports a property
 // It returns an error if it does not.
 func systemdSupportsProperty(property string) (bool, error) {
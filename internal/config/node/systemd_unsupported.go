@@ -10,6 +10,10 @@ func SystemdHasAllowedCPUs() bool {
 	return false
 }
 
+func SystemdHasAllowedMemoryNodes() bool {
+	return false
+}
+
 // systemdSupportsProperty checks whether systemd supports a property
 // It returns an error if it does not.
 func systemdSupportsProperty(property string) (bool, error) {
@@ -43,28 +43,11 @@ func (s *SeccompOCIArtifact) TryPull(
 ) (profile []byte, err error) {
 	log.Debugf(ctx, "Evaluating seccomp annotations")
 
-	profileRef := ""
-	containerKey := fmt.Sprintf("%s/%s", annotations.SeccompProfileAnnotation, containerName)
-	if val, ok := podAnnotations[containerKey]; ok {
-		log.Infof(ctx, "Found container specific seccomp profile annotation: %s=%s", containerKey, val)
-		profileRef = val
-	} else if val, ok := podAnnotations[SeccompProfilePodAnnotation]; ok {
-		log.Infof(ctx, "Found pod specific seccomp profile annotation: %s=%s", annotations.SeccompProfileAnnotation, val)
-		profileRef = val
-	} else if val, ok := imageAnnotations[annotations.SeccompProfileAnnotation]; ok {
-		log.Infof(ctx, "Found image specific seccomp profile annotation: %s=%s", annotations.SeccompProfileAnnotation, val)
-		profileRef = val
-	} else if val, ok := imageAnnotations[containerKey]; ok {
-		log.Infof(ctx, "Found image specific seccomp profile annotation for container %s: %s=%s", containerName, annotations.SeccompProfileAnnotation, val)
-		profileRef = val
-	} else if val, ok := imageAnnotations[SeccompProfilePodAnnotation]; ok {
-		log.Infof(ctx, "Found image specific seccomp profile annotation for pod: %s=%s", annotations.SeccompProfileAnnotation, val)
-		profileRef = val
-	}
-
+	profileRef, source := ProfileReference(containerName, podAnnotations, imageAnnotations)
 	if profileRef == "" {
 		return nil, nil
 	}
+	log.Infof(ctx, "Found %s seccomp profile annotation: %s", source, profileRef)
 
 	pullOptions := &ociartifact.PullOptions{
 		SystemContext:          sys,
@@ -79,3 +62,25 @@ func (s *SeccompOCIArtifact) TryPull(
 	log.Infof(ctx, "Retrieved OCI artifact seccomp profile of len: %d", len(artifact.Data))
 	return artifact.Data, nil
 }
+
+// ProfileReference returns the reference of the OCI artifact seccomp profile of the container requested by the
+// annotations of its pod or image, empty if there is none, along with a description of the annotation it got
+// found in.
+func ProfileReference(containerName string, podAnnotations, imageAnnotations map[string]string) (ref, source string) {
+	containerKey := fmt.Sprintf("%s/%s", annotations.SeccompProfileAnnotation, containerName)
+	for _, candidate := range []struct {
+		annotations map[string]string
+		key, source string
+	}{
+		{podAnnotations, containerKey, "container specific"},
+		{podAnnotations, SeccompProfilePodAnnotation, "pod specific"},
+		{imageAnnotations, annotations.SeccompProfileAnnotation, "image specific"},
+		{imageAnnotations, containerKey, "image specific container"},
+		{imageAnnotations, SeccompProfilePodAnnotation, "image specific pod"},
+	} {
+		if val, ok := candidate.annotations[candidate.key]; ok {
+			return val, candidate.source
+		}
+	}
+	return "", ""
+}
@@ -156,4 +156,32 @@ var _ = t.Describe("SeccompOCIArtifact", func() {
 			Expect(res).To(BeNil())
 		})
 	})
+
+	t.Describe("ProfileReference", func() {
+		It("should prefer the pod annotations over the image ones", func() {
+			// Given
+			podAnnotations := map[string]string{
+				seccompociartifact.SeccompProfilePodAnnotation: "pod",
+			}
+			imageAnnotations := map[string]string{
+				annotations.SeccompProfileAnnotation + "/container": "image",
+			}
+
+			// When
+			ref, source := seccompociartifact.ProfileReference("container", podAnnotations, imageAnnotations)
+
+			// Then
+			Expect(ref).To(Equal("pod"))
+			Expect(source).To(Equal("pod specific"))
+		})
+
+		It("should be empty without matching annotations", func() {
+			// Given
+			// When
+			ref, _ := seccompociartifact.ProfileReference("container", nil, nil)
+
+			// Then
+			Expect(ref).To(BeEmpty())
+		})
+	})
 })
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -84,6 +85,12 @@ func mergeConfig(config *libconfig.Config, ctx *cli.Context) error {
 	if ctx.IsSet("global-auth-file") {
 		config.GlobalAuthFile = ctx.String("global-auth-file")
 	}
+	if ctx.IsSet("credential-provider-config") {
+		config.CredentialProviderConfig = ctx.String("credential-provider-config")
+	}
+	if ctx.IsSet("credential-provider-bin-dir") {
+		config.CredentialProviderBinDir = ctx.String("credential-provider-bin-dir")
+	}
 	if ctx.IsSet("signature-policy") {
 		config.SignaturePolicyPath = ctx.String("signature-policy")
 	}
@@ -191,6 +198,7 @@ func mergeConfig(config *libconfig.Config, ctx *cli.Context) error {
 	}
 	if ctx.IsSet("irqbalance-config-file") {
 		config.IrqBalanceConfigFile = ctx.String("irqbalance-config-file")
+		config.HighPerformance.IrqBalanceConfigFile = config.IrqBalanceConfigFile
 	}
 	if ctx.IsSet("rdt-config-file") {
 		config.RdtConfigFile = ctx.String("rdt-config-file")
@@ -344,6 +352,26 @@ func mergeConfig(config *libconfig.Config, ctx *cli.Context) error {
 	}
 	if ctx.IsSet("irqbalance-config-restore-file") {
 		config.IrqBalanceConfigRestoreFile = ctx.String("irqbalance-config-restore-file")
+		config.HighPerformance.IrqBalanceConfigRestoreFile = config.IrqBalanceConfigRestoreFile
+	}
+	if ctx.IsSet("tuning-state-dir") {
+		config.TuningStateDir = ctx.String("tuning-state-dir")
+		config.HighPerformance.StateDir = config.TuningStateDir
+	}
+	if ctx.IsSet("tuning-audit-log") {
+		config.TuningAuditLog = ctx.String("tuning-audit-log")
+	}
+	if ctx.IsSet("tuning-audit-log-size-max") {
+		config.TuningAuditLogSizeMax = ctx.Int64("tuning-audit-log-size-max")
+	}
+	if ctx.IsSet("tuning-linux-audit") {
+		config.TuningLinuxAudit = ctx.Bool("tuning-linux-audit")
+	}
+	if ctx.IsSet("tuning-topology-file") {
+		config.TuningTopologyFile = ctx.String("tuning-topology-file")
+	}
+	if ctx.IsSet("tuning-container-state-dir") {
+		config.TuningContainerStateDir = ctx.String("tuning-container-state-dir")
 	}
 	if ctx.IsSet("internal-wipe") {
 		config.InternalWipe = ctx.Bool("internal-wipe")
@@ -411,6 +439,15 @@ func mergeConfig(config *libconfig.Config, ctx *cli.Context) error {
 	if ctx.IsSet("pull-progress-timeout") {
 		config.PullProgressTimeout = ctx.Duration("pull-progress-timeout")
 	}
+	if ctx.IsSet("max-parallel-image-pulls") {
+		config.MaxParallelImagePulls = ctx.Uint("max-parallel-image-pulls")
+	}
+	if ctx.IsSet("max-parallel-layer-downloads") {
+		config.MaxParallelLayerDownloads = ctx.Uint("max-parallel-layer-downloads")
+	}
+	if ctx.IsSet("registry-mirror-health-check-interval") {
+		config.RegistryMirrorHealthCheckInterval = ctx.Duration("registry-mirror-health-check-interval")
+	}
 	if ctx.IsSet("separate-pull-cgroup") {
 		config.SeparatePullCgroup = ctx.String("separate-pull-cgroup")
 	}
@@ -419,6 +456,60 @@ func mergeConfig(config *libconfig.Config, ctx *cli.Context) error {
 	}
 	if ctx.IsSet("shared-cpuset") {
 		config.SharedCPUSet = ctx.String("shared-cpuset")
+		config.HighPerformance.SharedCPUSet = config.SharedCPUSet
+	}
+	if ctx.IsSet("high-performance-cpu-load-balancing") {
+		config.HighPerformanceCPULoadBalancing = ctx.Bool("high-performance-cpu-load-balancing")
+		setHighPerformanceFeature(config, libconfig.HighPerformanceFeatureCPULoadBalancing, config.HighPerformanceCPULoadBalancing)
+	}
+	if ctx.IsSet("high-performance-irq-load-balancing") {
+		config.HighPerformanceIRQLoadBalancing = ctx.Bool("high-performance-irq-load-balancing")
+		setHighPerformanceFeature(config, libconfig.HighPerformanceFeatureIRQLoadBalancing, config.HighPerformanceIRQLoadBalancing)
+	}
+	if ctx.IsSet("high-performance-cpu-quota") {
+		config.HighPerformanceCPUQuota = ctx.Bool("high-performance-cpu-quota")
+		setHighPerformanceFeature(config, libconfig.HighPerformanceFeatureCPUQuota, config.HighPerformanceCPUQuota)
+	}
+	if ctx.IsSet("high-performance-cpu-c-states") {
+		config.HighPerformanceCPUCStates = ctx.Bool("high-performance-cpu-c-states")
+		setHighPerformanceFeature(config, libconfig.HighPerformanceFeatureCPUCStates, config.HighPerformanceCPUCStates)
+	}
+	if ctx.IsSet("high-performance-cpu-freq-governor") {
+		config.HighPerformanceCPUFreqGovernor = ctx.Bool("high-performance-cpu-freq-governor")
+		setHighPerformanceFeature(config, libconfig.HighPerformanceFeatureCPUFreqGovernor, config.HighPerformanceCPUFreqGovernor)
+	}
+	if ctx.IsSet("high-performance-shared-cpus") {
+		config.HighPerformanceSharedCPUs = ctx.Bool("high-performance-shared-cpus")
+		setHighPerformanceFeature(config, libconfig.HighPerformanceFeatureSharedCPUs, config.HighPerformanceSharedCPUs)
+	}
+	if ctx.IsSet("high-performance-fail-open") {
+		config.HighPerformanceFailOpen = StringSliceTrySplit(ctx, "high-performance-fail-open")
+	}
+	if ctx.IsSet("high-performance-tuned-conflict") {
+		config.HighPerformanceTunedConflict = ctx.String("high-performance-tuned-conflict")
+		config.HighPerformance.TunedConflict = config.HighPerformanceTunedConflict
+	}
+	if ctx.IsSet("high-performance-dry-run") {
+		config.HighPerformanceDryRun = ctx.Bool("high-performance-dry-run")
+		config.HighPerformance.DryRun = config.HighPerformanceDryRun
+	}
+	if ctx.IsSet("high-performance-reconcile-on-reload") {
+		config.HighPerformanceReconcileOnReload = ctx.Bool("high-performance-reconcile-on-reload")
+	}
+	if ctx.IsSet("runtime-handler-hooks-timeout") {
+		config.RuntimeHandlerHooksTimeout = ctx.Duration("runtime-handler-hooks-timeout")
+	}
+	if ctx.IsSet("runtime-handler-hooks-log-format") {
+		config.RuntimeHandlerHooksLogFormat = ctx.String("runtime-handler-hooks-log-format")
+	}
+	if ctx.IsSet("tuning-drift-check-interval") {
+		config.TuningDriftCheckInterval = ctx.Duration("tuning-drift-check-interval")
+	}
+	if ctx.IsSet("tuning-schedstat-interval") {
+		config.TuningSchedstatInterval = ctx.Duration("tuning-schedstat-interval")
+	}
+	if ctx.IsSet("tuning-telemetry-interval") {
+		config.TuningTelemetryInterval = ctx.Duration("tuning-telemetry-interval")
 	}
 	if ctx.IsSet("stats-collection-period") {
 		config.StatsCollectionPeriod = ctx.Int("stats-collection-period")
@@ -580,6 +671,18 @@ func getCrioFlags(defConf *libconfig.Config) []cli.Flag {
 			EnvVars:   []string{"CONTAINER_GLOBAL_AUTH_FILE"},
 			TakesFile: true,
 		},
+		&cli.StringFlag{
+			Name:      "credential-provider-config",
+			Usage:     "Path to a kubelet credential provider config file, whose plugins provide the credentials for the images CRI-O pulls on its own, like the pause image and the OCI artifacts of the pods. Requires --credential-provider-bin-dir.",
+			EnvVars:   []string{"CONTAINER_CREDENTIAL_PROVIDER_CONFIG"},
+			TakesFile: true,
+		},
+		&cli.StringFlag{
+			Name:      "credential-provider-bin-dir",
+			Usage:     "Path to the directory of the kubelet credential provider plugins.",
+			EnvVars:   []string{"CONTAINER_CREDENTIAL_PROVIDER_BIN_DIR"},
+			TakesFile: true,
+		},
 		&cli.StringFlag{
 			Name:      "signature-policy",
 			Usage:     "Path to signature policy JSON file.",
@@ -949,6 +1052,24 @@ func getCrioFlags(defConf *libconfig.Config) []cli.Flag {
 			EnvVars: []string{"CONTAINER_PULL_PROGRESS_TIMEOUT"},
 			Value:   defConf.PullProgressTimeout,
 		},
+		&cli.UintFlag{
+			Name:    "max-parallel-image-pulls",
+			Usage:   "The maximum number of images pulled at the same time, the other pulls wait for one of them to complete. Can be set to 0 to not limit the image pulls.",
+			EnvVars: []string{"CONTAINER_MAX_PARALLEL_IMAGE_PULLS"},
+			Value:   defConf.MaxParallelImagePulls,
+		},
+		&cli.UintFlag{
+			Name:    "max-parallel-layer-downloads",
+			Usage:   "The maximum number of layers an image pull downloads at the same time. Can be set to 0 to use the default of the containers/image library.",
+			EnvVars: []string{"CONTAINER_MAX_PARALLEL_LAYER_DOWNLOADS"},
+			Value:   defConf.MaxParallelLayerDownloads,
+		},
+		&cli.DurationFlag{
+			Name:    "registry-mirror-health-check-interval",
+			Usage:   "The interval at which the health of the registry mirrors is checked, the image pulls trying the healthy mirrors first. Can be set to 0 to disable the health checks.",
+			EnvVars: []string{"CONTAINER_REGISTRY_MIRROR_HEALTH_CHECK_INTERVAL"},
+			Value:   defConf.RegistryMirrorHealthCheckInterval,
+		},
 		&cli.BoolFlag{
 			Name:    "read-only",
 			Usage:   "Setup all unprivileged containers to run as read-only. Automatically mounts the containers' tmpfs on '/run', '/tmp' and '/var/tmp'.",
@@ -1164,6 +1285,96 @@ func getCrioFlags(defConf *libconfig.Config) []cli.Flag {
 			EnvVars: []string{"CONTAINER_SHARED_CPUSET"},
 			Value:   defConf.SharedCPUSet,
 		},
+		&cli.BoolFlag{
+			Name:    "high-performance-cpu-load-balancing",
+			Usage:   "Enables the high-performance hooks to disable the CPU load balancing of the container CPUs.",
+			EnvVars: []string{"CONTAINER_HIGH_PERFORMANCE_CPU_LOAD_BALANCING"},
+			Value:   defConf.HighPerformanceCPULoadBalancing,
+		},
+		&cli.BoolFlag{
+			Name:    "high-performance-irq-load-balancing",
+			Usage:   "Enables the high-performance hooks to disable the IRQ load balancing of the container CPUs.",
+			EnvVars: []string{"CONTAINER_HIGH_PERFORMANCE_IRQ_LOAD_BALANCING"},
+			Value:   defConf.HighPerformanceIRQLoadBalancing,
+		},
+		&cli.BoolFlag{
+			Name:    "high-performance-cpu-quota",
+			Usage:   "Enables the high-performance hooks to disable the CFS quota of the container.",
+			EnvVars: []string{"CONTAINER_HIGH_PERFORMANCE_CPU_QUOTA"},
+			Value:   defConf.HighPerformanceCPUQuota,
+		},
+		&cli.BoolFlag{
+			Name:    "high-performance-cpu-c-states",
+			Usage:   "Enables the high-performance hooks to configure the c-states of the container CPUs.",
+			EnvVars: []string{"CONTAINER_HIGH_PERFORMANCE_CPU_C_STATES"},
+			Value:   defConf.HighPerformanceCPUCStates,
+		},
+		&cli.BoolFlag{
+			Name:    "high-performance-cpu-freq-governor",
+			Usage:   "Enables the high-performance hooks to configure the frequency governor of the container CPUs.",
+			EnvVars: []string{"CONTAINER_HIGH_PERFORMANCE_CPU_FREQ_GOVERNOR"},
+			Value:   defConf.HighPerformanceCPUFreqGovernor,
+		},
+		&cli.BoolFlag{
+			Name:    "high-performance-shared-cpus",
+			Usage:   "Enables the high-performance hooks to grant the shared CPUs to the containers requesting them.",
+			EnvVars: []string{"CONTAINER_HIGH_PERFORMANCE_SHARED_CPUS"},
+			Value:   defConf.HighPerformanceSharedCPUs,
+		},
+		&cli.StringSliceFlag{
+			Name:    "high-performance-fail-open",
+			Usage:   "A list of high-performance features whose failures are logged instead of failing the CRI request. Supported features: cpu-load-balancing, irq-load-balancing, cpu-quota, cpu-c-states, cpu-freq-governor, packet-steering, vf-queues, arfs, vf-irq-affinity, af-xdp, napi-affinity, interrupt-coalescing, qdisc, netdev-budget and gro.",
+			EnvVars: []string{"CONTAINER_HIGH_PERFORMANCE_FAIL_OPEN"},
+			Value:   cli.NewStringSlice(defConf.HighPerformanceFailOpen...),
+		},
+		&cli.StringFlag{
+			Name:    "high-performance-tuned-conflict",
+			Usage:   "The policy of the high-performance hooks when the active TuneD profile manages the same settings: ignore, warn or refuse.",
+			EnvVars: []string{"CONTAINER_HIGH_PERFORMANCE_TUNED_CONFLICT"},
+			Value:   defConf.HighPerformanceTunedConflict,
+		},
+		&cli.BoolFlag{
+			Name:    "high-performance-dry-run",
+			Usage:   "Makes the high-performance hooks log and save the plan of the tuning of the containers instead of applying it.",
+			EnvVars: []string{"CONTAINER_HIGH_PERFORMANCE_DRY_RUN"},
+			Value:   defConf.HighPerformanceDryRun,
+		},
+		&cli.BoolFlag{
+			Name:    "high-performance-reconcile-on-reload",
+			Usage:   "Makes the high-performance hooks reconcile the tuning of the running containers with the configuration reloaded on SIGHUP.",
+			EnvVars: []string{"CONTAINER_HIGH_PERFORMANCE_RECONCILE_ON_RELOAD"},
+			Value:   defConf.HighPerformanceReconcileOnReload,
+		},
+		&cli.DurationFlag{
+			Name:    "runtime-handler-hooks-timeout",
+			Usage:   "The maximum time a runtime handler hook gets to run. The pending file writes and commands of the hook are canceled once it expires. Can be set to 0 to disable the timeout.",
+			EnvVars: []string{"CONTAINER_RUNTIME_HANDLER_HOOKS_TIMEOUT"},
+			Value:   defConf.RuntimeHandlerHooksTimeout,
+		},
+		&cli.StringFlag{
+			Name:    "runtime-handler-hooks-log-format",
+			Usage:   "The format of the log lines of the runtime handler hooks: 'text' or 'json', independently of the log-format. If empty, they are logged in the log-format.",
+			EnvVars: []string{"CONTAINER_RUNTIME_HANDLER_HOOKS_LOG_FORMAT"},
+			Value:   defConf.RuntimeHandlerHooksLogFormat,
+		},
+		&cli.DurationFlag{
+			Name:    "tuning-drift-check-interval",
+			Usage:   "The interval at which the tuning applied to the running containers is compared with the node and repaired when it drifted. Can be set to 0 to disable the drift detection.",
+			EnvVars: []string{"CONTAINER_TUNING_DRIFT_CHECK_INTERVAL"},
+			Value:   defConf.TuningDriftCheckInterval,
+		},
+		&cli.DurationFlag{
+			Name:    "tuning-schedstat-interval",
+			Usage:   "The interval at which the scheduler statistics of the isolated CPUs are sampled into the run delay metrics. Can be set to 0 to disable the sampling.",
+			EnvVars: []string{"CONTAINER_TUNING_SCHEDSTAT_INTERVAL"},
+			Value:   defConf.TuningSchedstatInterval,
+		},
+		&cli.DurationFlag{
+			Name:    "tuning-telemetry-interval",
+			Usage:   "The interval at which the CPU telemetry of the tuned containers, like the c-state residency of their CPUs, is sampled into the metrics. Can be set to 0 to disable the sampling.",
+			EnvVars: []string{"CONTAINER_TUNING_TELEMETRY_INTERVAL"},
+			Value:   defConf.TuningTelemetryInterval,
+		},
 		&cli.StringFlag{
 			Name:      "clean-shutdown-file",
 			Usage:     "Location for CRI-O to lay down the clean shutdown file. It indicates whether we've had time to sync changes to disk before shutting down. If not found, crio wipe will clear the storage directory.",
@@ -1211,6 +1422,46 @@ func getCrioFlags(defConf *libconfig.Config) []cli.Flag {
 			Value: defConf.IrqBalanceConfigRestoreFile,
 			Usage: "Determines if CRI-O should attempt to restore the irqbalance config at startup with the mask in this file. Use the 'disable' value to disable the restore flow entirely.",
 		},
+		&cli.StringFlag{
+			Name:      "tuning-state-dir",
+			Usage:     "Directory the runtime handler hooks record the tuning they applied to every container to, so that it can still be reverted after a crash or restart of CRI-O.",
+			Value:     defConf.TuningStateDir,
+			EnvVars:   []string{"CONTAINER_TUNING_STATE_DIR"},
+			TakesFile: true,
+		},
+		&cli.StringFlag{
+			Name:      "tuning-audit-log",
+			Usage:     "File every write of the runtime handler hooks to the sysfs, procfs and cgroup files of the node is recorded to. If empty, the writes are not recorded.",
+			Value:     defConf.TuningAuditLog,
+			EnvVars:   []string{"CONTAINER_TUNING_AUDIT_LOG"},
+			TakesFile: true,
+		},
+		&cli.Int64Flag{
+			Name:    "tuning-audit-log-size-max",
+			Usage:   "Size in bytes after which the tuning audit log gets rotated, 0 to never rotate it.",
+			Value:   defConf.TuningAuditLogSizeMax,
+			EnvVars: []string{"CONTAINER_TUNING_AUDIT_LOG_SIZE_MAX"},
+		},
+		&cli.BoolFlag{
+			Name:    "tuning-linux-audit",
+			Usage:   "Report the privileged tuning operations of the runtime handler hooks, like the changes of the IRQ affinity and of the CPU frequency governor, to the Linux audit subsystem.",
+			Value:   defConf.TuningLinuxAudit,
+			EnvVars: []string{"CONTAINER_TUNING_LINUX_AUDIT"},
+		},
+		&cli.StringFlag{
+			Name:      "tuning-topology-file",
+			Usage:     "File the runtime handler hooks write the CPU consumption of the tuned containers per NUMA zone to, for an exporter to the NodeResourceTopology API. If empty, it is not written.",
+			Value:     defConf.TuningTopologyFile,
+			EnvVars:   []string{"CONTAINER_TUNING_TOPOLOGY_FILE"},
+			TakesFile: true,
+		},
+		&cli.StringFlag{
+			Name:      "tuning-container-state-dir",
+			Usage:     "Directory the runtime handler hooks write the tuning of every tuned container to, as <container-id>/tuning.json, for node-local agents. If empty, it is not written.",
+			Value:     defConf.TuningContainerStateDir,
+			EnvVars:   []string{"CONTAINER_TUNING_CONTAINER_STATE_DIR"},
+			TakesFile: true,
+		},
 		&cli.BoolFlag{
 			Name:    "hostnetwork-disable-selinux",
 			Usage:   "Determines whether SELinux should be disabled within a pod when it is running in the host network namespace.",
@@ -1239,6 +1490,16 @@ func getCrioFlags(defConf *libconfig.Config) []cli.Flag {
 	}
 }
 
+// setHighPerformanceFeature enables or disables the feature of the high-performance hooks in the
+// [crio.runtime.high_performance] table too, so that the command line flag takes precedence over it.
+func setHighPerformanceFeature(config *libconfig.Config, feature string, enabled bool) {
+	disabled := slices.DeleteFunc(config.HighPerformance.DisabledFeatures, func(f string) bool { return f == feature })
+	if !enabled {
+		disabled = append(disabled, feature)
+	}
+	config.HighPerformance.DisabledFeatures = disabled
+}
+
 // StringSliceTrySplit parses the string slice from the CLI context.
 // If the parsing returns just a single item, then we try to parse them by `,`
 // to allow users to provide their flags comma separated.
@@ -160,4 +160,36 @@ var _ = t.Describe("CLI Flags", func() {
 		// Then
 		Expect(config.RuntimeConfig.DisableHostPortMapping).To(BeTrue())
 	})
+
+	It("Flag test shared-cpuset takes precedence over the high_performance table", func() {
+		// Default Config
+		app.Flags, app.Metadata, err = criocli.GetFlagsAndMetadata()
+		Expect(err).ToNot(HaveOccurred())
+		config, err := criocli.GetConfigFromContext(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		config.HighPerformance.SharedCPUSet = "0-1"
+		config.HighPerformance.DisabledFeatures = []string{"cpu-quota"}
+
+		// Set Config & Merge
+		sharedCPUSetFlag := &cli.StringFlag{
+			Name:       "shared-cpuset",
+			Value:      "2-3",
+			HasBeenSet: true,
+		}
+		Expect(sharedCPUSetFlag.Apply(flagSet)).To(Succeed())
+		cpuQuotaFlag := &cli.BoolFlag{
+			Name:       "high-performance-cpu-quota",
+			Value:      true,
+			HasBeenSet: true,
+		}
+		Expect(cpuQuotaFlag.Apply(flagSet)).To(Succeed())
+		ctx.Command.Flags = append(commandFlags, sharedCPUSetFlag, cpuQuotaFlag)
+		config, err = criocli.GetAndMergeConfigFromContext(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		// Then
+		settings := config.HighPerformanceSettings()
+		Expect(settings.SharedCPUSet).To(Equal("2-3"))
+		Expect(settings.FeatureEnabled("cpu-quota")).To(BeTrue())
+	})
 })
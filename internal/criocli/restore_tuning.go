@@ -0,0 +1,53 @@
+package criocli
+
+import (
+	"errors"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"github.com/cri-o/cri-o/internal/runtimehandlerhooks"
+)
+
+var RestoreTuningCommand = &cli.Command{
+	Name:  "restore-tuning",
+	Usage: "restore the node tuning applied by the runtime handler hooks after a reboot or crash",
+	Description: `Reverts the IRQ affinity, CPU frequency governors, PM QoS resume latencies and other
+files tuned by the runtime handler hooks for the containers recorded in the tuning state
+directory, and restores the irqbalance banned CPU list. It is meant to run before CRI-O
+starts, e.g. from a systemd unit.
+
+By default, the tuning is only restored after a reboot, detected by the missing version
+file. The --force option restores it after a crash of CRI-O as well, which must only be
+used when none of the recorded containers is running anymore.`,
+	Action: crioRestoreTuning,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "force",
+			Aliases: []string{"f"},
+			Usage:   "restore the tuning by skipping the reboot check, only when none of the recorded containers is running",
+		},
+	},
+}
+
+func crioRestoreTuning(c *cli.Context) error {
+	config, err := GetConfigFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	if !c.IsSet("force") && config.VersionFile != "" {
+		// The version file lives in a tmpfs, so it is only missing after a reboot.
+		_, err := os.Stat(config.VersionFile)
+		if err == nil {
+			logrus.Infof("Node did not reboot since CRI-O last started, not restoring the tuning")
+			return nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return runtimehandlerhooks.RestoreTuning(c.Context, config)
+}
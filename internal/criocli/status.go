@@ -1,20 +1,27 @@
 package criocli
 
 import (
+	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 
 	"github.com/cri-o/cri-o/internal/client"
+	"github.com/cri-o/cri-o/pkg/types"
 )
 
 const (
 	defaultSocket = "/var/run/crio/crio.sock"
 	idArg         = "id"
 	socketArg     = "socket"
+	podArg        = "pod"
+	namespaceArg  = "namespace"
+	cpusArg       = "cpus"
 )
 
 var StatusCommand = &cli.Command{
@@ -68,6 +75,92 @@ var StatusCommand = &cli.Command{
 				TakesFile: true,
 			},
 		},
+	}, {
+		Action:  snapshot,
+		Aliases: []string{"sn"},
+		Name:    "snapshot",
+		Usage:   "Write an archive of the cgroup, sysfs and tuning state of the provided container ID for bug reports.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    idArg,
+				Aliases: []string{"i"},
+				Usage:   "the container ID",
+			},
+			&cli.StringFlag{
+				Name:      "file",
+				Aliases:   []string{"f"},
+				Usage:     "Output file of the snapshot.",
+				TakesFile: true,
+			},
+		},
+	}, {
+		Action:  tuning,
+		Aliases: []string{"t"},
+		Name:    "tuning",
+		Usage:   "Display the high-performance tuning in effect for every container, and whether it currently holds.",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    jsonFlag,
+				Aliases: []string{"j"},
+				Usage:   "print JSON instead of text",
+			},
+			&cli.StringFlag{
+				Name:    podArg,
+				Aliases: []string{"p"},
+				Usage:   "only display the containers of the pods with this name",
+			},
+			&cli.StringFlag{
+				Name:    namespaceArg,
+				Aliases: []string{"n"},
+				Usage:   "only display the containers of the pods in this namespace",
+			},
+			&cli.StringFlag{
+				Name:    cpusArg,
+				Aliases: []string{"c"},
+				Usage:   "only display the containers with any of these exclusive CPUs, like 2-3,6",
+			},
+		},
+	}, {
+		Action:  nodeTuning,
+		Aliases: []string{"nt"},
+		Name:    "node-tuning",
+		Usage:   "Display the tuning of the node bookkept for the running containers, like the CPU allocation and the IRQ affinity.",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    jsonFlag,
+				Aliases: []string{"j"},
+				Usage:   "print JSON instead of text",
+			},
+		},
+	}, {
+		Action:  tuningHealth,
+		Aliases: []string{"th"},
+		Name:    "tuning-health",
+		Usage:   "Display the health checks of the tuning capability of the node, like the presence of irqbalance.",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    jsonFlag,
+				Aliases: []string{"j"},
+				Usage:   "print JSON instead of text",
+			},
+		},
+	}, {
+		Action:  verify,
+		Aliases: []string{"vf"},
+		Name:    "verify",
+		Usage:   "Compare the expected and actual value of each file tuned for the provided container ID.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    idArg,
+				Aliases: []string{"i"},
+				Usage:   "the container ID",
+			},
+			&cli.BoolFlag{
+				Name:    jsonFlag,
+				Aliases: []string{"j"},
+				Usage:   "print JSON instead of text",
+			},
+		},
 	}},
 }
 
@@ -212,3 +305,215 @@ func heap(c *cli.Context) error {
 
 	return nil
 }
+
+func snapshot(c *cli.Context) error {
+	crioClient, err := crioClient(c)
+	if err != nil {
+		return err
+	}
+
+	id := c.String(idArg)
+	if id == "" {
+		return fmt.Errorf("the argument --%s cannot be empty", idArg)
+	}
+
+	data, err := crioClient.DebugSnapshot(c.Context, id)
+	if err != nil {
+		return err
+	}
+
+	outputPath := c.String("file")
+	switch outputPath {
+	case "-":
+		if _, err := os.Stdout.Write(data); err != nil {
+			return fmt.Errorf("write snapshot to stdout: %w", err)
+		}
+
+	case "":
+		outputPath = fmt.Sprintf("crio-snapshot-%s-%s.tar.gz", id, Timestamp())
+		fallthrough
+
+	default:
+		if err := os.WriteFile(outputPath, data, 0o600); err != nil {
+			return fmt.Errorf("write snapshot: %w", err)
+		}
+
+		logrus.Infof("Wrote snapshot to: %s", outputPath)
+	}
+
+	return nil
+}
+
+func tuning(c *cli.Context) error {
+	crioClient, err := crioClient(c)
+	if err != nil {
+		return err
+	}
+
+	tunings, err := crioClient.TuningInfo(c.Context, types.TuningFilter{
+		PodName:      c.String(podArg),
+		PodNamespace: c.String(namespaceArg),
+		CPUs:         c.String(cpusArg),
+	})
+	if err != nil {
+		return err
+	}
+
+	if c.Bool(jsonFlag) {
+		j, err := json.MarshalIndent(tunings, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to generate JSON from tuning info: %w", err)
+		}
+		fmt.Println(string(j))
+		return nil
+	}
+
+	for _, t := range tunings {
+		fmt.Printf("%s (%s):\n", t.ID, t.Name)
+		if t.PodName != "" {
+			fmt.Printf("  pod: %s/%s\n", t.PodNamespace, t.PodName)
+		}
+		fmt.Printf("  cpus: %s\n", t.Tuning.CPUs)
+		fmt.Printf("  shared cpus: %t\n", t.Tuning.SharedCPUs)
+		fmt.Printf("  cpu load balancing disabled: %t\n", t.Tuning.CPULoadBalancingDisabled)
+		if t.Tuning.Partition != "" {
+			fmt.Printf("  partition: %s\n", t.Tuning.Partition)
+		}
+		if t.Tuning.IRQBannedCPUs != "" {
+			fmt.Printf("  irq banned cpus: %s\n", t.Tuning.IRQBannedCPUs)
+		}
+		fmt.Printf("  cpu quota disabled: %t\n", t.Tuning.CPUQuotaDisabled)
+		if t.Tuning.FreqGovernor != "" {
+			fmt.Printf("  cpu freq governor: %s\n", t.Tuning.FreqGovernor)
+		}
+		if t.Tuning.ResumeLatency != "" {
+			fmt.Printf("  pm qos resume latency: %s\n", t.Tuning.ResumeLatency)
+		}
+		switch {
+		case t.VerificationError != "":
+			fmt.Printf("  verification: error: %s\n", t.VerificationError)
+		case t.Verified:
+			fmt.Printf("  verification: passed\n")
+		default:
+			fmt.Printf("  verification: failed: %s\n", strings.Join(t.Ineffective, ", "))
+		}
+	}
+
+	return nil
+}
+
+func nodeTuning(c *cli.Context) error {
+	crioClient, err := crioClient(c)
+	if err != nil {
+		return err
+	}
+
+	state, err := crioClient.NodeTuningInfo(c.Context)
+	if err != nil {
+		return err
+	}
+
+	if c.Bool(jsonFlag) {
+		j, err := json.MarshalIndent(state, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to generate JSON from node tuning info: %w", err)
+		}
+		fmt.Println(string(j))
+		return nil
+	}
+
+	fmt.Printf("isolated cpus: %s\n", state.CPUAllocation.Isolated)
+	fmt.Printf("exclusive cpus: %s\n", state.CPUAllocation.Exclusive)
+	fmt.Printf("shared cpus: %s\n", state.CPUAllocation.Shared)
+	fmt.Printf("housekeeping cpus: %s\n", state.CPUAllocation.Housekeeping)
+	fmt.Printf("containers:\n")
+	for _, id := range slices.Sorted(maps.Keys(state.ExclusiveCPUs)) {
+		fmt.Printf("  %s: %s\n", id, state.ExclusiveCPUs[id])
+	}
+	fmt.Printf("shared cpu pools:\n")
+	for _, pool := range state.SharedCPUPools {
+		fmt.Printf("  %s: %s\n", pool.SandboxID, pool.SharedCPUs)
+	}
+	if state.IRQAffinity.Error != "" {
+		fmt.Printf("irq affinity: error: %s\n", state.IRQAffinity.Error)
+	} else {
+		fmt.Printf("irq affinity: %s (cpus %s)\n", state.IRQAffinity.Mask, state.IRQAffinity.CPUs)
+	}
+	fmt.Printf("irq banned cpus: %s\n", state.IRQAffinity.BannedCPUs)
+
+	return nil
+}
+
+func tuningHealth(c *cli.Context) error {
+	crioClient, err := crioClient(c)
+	if err != nil {
+		return err
+	}
+
+	checks, err := crioClient.TuningHealth(c.Context)
+	if err != nil {
+		return err
+	}
+
+	if c.Bool(jsonFlag) {
+		j, err := json.MarshalIndent(checks, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to generate JSON from tuning health: %w", err)
+		}
+		fmt.Println(string(j))
+		return nil
+	}
+
+	for _, check := range checks {
+		if check.Healthy {
+			fmt.Printf("%s: healthy\n", check.Name)
+		} else {
+			fmt.Printf("%s: unhealthy: %s\n", check.Name, check.Message)
+		}
+	}
+
+	return nil
+}
+
+func verify(c *cli.Context) error {
+	crioClient, err := crioClient(c)
+	if err != nil {
+		return err
+	}
+
+	id := c.String(idArg)
+	if id == "" {
+		return fmt.Errorf("the argument --%s cannot be empty", idArg)
+	}
+
+	verification, err := crioClient.VerifyTuning(c.Context, id)
+	if err != nil {
+		return err
+	}
+
+	if c.Bool(jsonFlag) {
+		j, err := json.MarshalIndent(verification, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to generate JSON from tuning verification: %w", err)
+		}
+		fmt.Println(string(j))
+		return nil
+	}
+
+	for _, item := range verification.Items {
+		fmt.Printf("%s:\n", item.Path)
+		fmt.Printf("  expected: %s\n", item.Expected)
+		if item.Error != "" {
+			fmt.Printf("  error: %s\n", item.Error)
+			continue
+		}
+		fmt.Printf("  actual: %s\n", item.Actual)
+	}
+	if verification.Verified {
+		fmt.Println("verification: passed")
+	} else {
+		fmt.Println("verification: failed")
+	}
+
+	return nil
+}
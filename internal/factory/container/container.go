@@ -263,6 +263,14 @@ func (c *container) SpecAddAnnotations(ctx context.Context, sb SandboxIFace, con
 	}
 	c.spec.AddAnnotation(annotations.Volumes, string(volumesJSON))
 
+	if ociArtifacts := sb.OCIArtifacts(); len(ociArtifacts) > 0 {
+		ociArtifactsJSON, err := json.Marshal(ociArtifacts)
+		if err != nil {
+			return err
+		}
+		c.spec.AddAnnotation(annotations.OCIArtifacts, string(ociArtifactsJSON))
+	}
+
 	kubeAnnotationsJSON, err := json.Marshal(kubeAnnotations)
 	if err != nil {
 		return err
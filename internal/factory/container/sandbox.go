@@ -35,4 +35,7 @@ type SandboxIFace interface {
 
 	// NamespaceOptions returns the namespace options for the sandbox.
 	NamespaceOptions() *types.NamespaceOption
+
+	// OCIArtifacts returns the paths of the OCI artifacts pulled for the sandbox by name.
+	OCIArtifacts() map[string]string
 }
@@ -15,6 +15,7 @@ import (
 	rspec "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/opencontainers/runtime-tools/generate"
 
+	"github.com/cri-o/cri-o/internal/lib/constants"
 	"github.com/cri-o/cri-o/internal/log"
 	"github.com/cri-o/cri-o/internal/oci"
 	"github.com/cri-o/cri-o/pkg/annotations"
@@ -30,6 +31,9 @@ type ContainerCheckpointOptions struct {
 	// TargetFile tells the API to read (or write) the checkpoint image
 	// from (or to) the filename set in TargetFile
 	TargetFile string
+	// TargetImage tells the API to write the checkpoint as an OCI image
+	// to the image reference with a transport set in TargetImage
+	TargetImage string
 }
 
 // ContainerCheckpoint checkpoints a running container.
@@ -83,7 +87,8 @@ func (c *ContainerServer) ContainerCheckpoint(
 		}
 	}()
 
-	if opts.TargetFile != "" {
+	export := opts.TargetFile != "" || opts.TargetImage != ""
+	if export {
 		if err := c.prepareCheckpointExport(ctr); err != nil {
 			return "", fmt.Errorf("failed to write config dumps for container %s: %w", ctr.ID(), err)
 		}
@@ -96,6 +101,13 @@ func (c *ContainerServer) ContainerCheckpoint(
 		if err := c.exportCheckpoint(ctx, ctr, specgen.Config, opts.TargetFile); err != nil {
 			return "", fmt.Errorf("failed to write file system changes of container %s: %w", ctr.ID(), err)
 		}
+	}
+	if opts.TargetImage != "" {
+		if err := c.exportCheckpointImage(ctx, ctr, specgen.Config, opts.TargetImage); err != nil {
+			return "", fmt.Errorf("failed to export checkpoint image of container %s: %w", ctr.ID(), err)
+		}
+	}
+	if export {
 		defer func() {
 			// clean up checkpoint directory
 			if err := os.RemoveAll(ctr.CheckpointPath()); err != nil {
@@ -115,6 +127,7 @@ func (c *ContainerServer) ContainerCheckpoint(
 			stats.StatsDump,
 			metadata.ConfigDumpFile,
 			metadata.SpecDumpFile,
+			constants.TuningCheckpointFile,
 		}
 		for _, del := range cleanup {
 			file := filepath.Join(ctr.Dir(), del)
@@ -285,6 +298,7 @@ func (c *ContainerServer) exportCheckpoint(ctx context.Context, ctr *oci.Contain
 		metadata.ConfigDumpFile,
 		metadata.SpecDumpFile,
 		"bind.mounts",
+		constants.TuningCheckpointFile,
 	}
 
 	// To correctly track deleted files, let's go through the output of 'podman diff'
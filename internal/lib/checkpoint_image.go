@@ -0,0 +1,256 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	metadata "github.com/checkpoint-restore/checkpointctl/lib"
+	criu "github.com/checkpoint-restore/go-criu/v7/utils"
+	"github.com/containers/image/v5/copy"
+	"github.com/containers/image/v5/oci/layout"
+	"github.com/containers/image/v5/signature"
+	istorage "github.com/containers/image/v5/storage"
+	"github.com/containers/image/v5/transports"
+	"github.com/containers/image/v5/transports/alltransports"
+	"github.com/containers/image/v5/types"
+	"github.com/opencontainers/go-digest"
+	imgspec "github.com/opencontainers/image-spec/specs-go"
+	imgspecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	kubetypes "k8s.io/kubelet/pkg/types"
+
+	"github.com/cri-o/cri-o/internal/lib/constants"
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/internal/oci"
+	"github.com/cri-o/cri-o/internal/version"
+	"github.com/cri-o/cri-o/pkg/annotations"
+)
+
+// IsCheckpointImage returns true if the checkpoint location is an image reference with a
+// transport, like docker://quay.io/crio/checkpoint:latest, instead of the path of an archive.
+func IsCheckpointImage(location string) bool {
+	transport, _, ok := strings.Cut(location, ":")
+	return ok && transports.Get(transport) != nil
+}
+
+// checkpointImageReference parses the image reference to export a checkpoint to. The images of
+// the containers-storage transport go to the store of CRI-O, so that they can be restored from.
+func (c *ContainerServer) checkpointImageReference(name string) (types.ImageReference, error) {
+	transport, reference, _ := strings.Cut(name, ":")
+	if transport == istorage.Transport.Name() {
+		return istorage.Transport.ParseStoreReference(c.store, reference)
+	}
+	return alltransports.ParseImageName(name)
+}
+
+// exportCheckpointImage exports the checkpoint of the container as an OCI image, the single
+// layer of which is the checkpoint archive, annotated with the metadata of the container so
+// that the checkpoint can be inspected and restored from without downloading it first.
+func (c *ContainerServer) exportCheckpointImage(ctx context.Context, ctr *oci.Container, specgen *rspec.Spec, target string) error {
+	destRef, err := c.checkpointImageReference(target)
+	if err != nil {
+		return fmt.Errorf("invalid checkpoint image %q: %w", target, err)
+	}
+
+	tmpDir, err := os.MkdirTemp(ctr.Dir(), "checkpoint-image-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	archivePath := filepath.Join(tmpDir, "checkpoint.tar")
+	if err := c.exportCheckpoint(ctx, ctr, specgen, archivePath); err != nil {
+		return err
+	}
+	ann, err := c.checkpointImageAnnotations(ctr)
+	if err != nil {
+		return fmt.Errorf("collect checkpoint image annotations: %w", err)
+	}
+	layoutDir := filepath.Join(tmpDir, "layout")
+	if err := writeCheckpointImageLayout(layoutDir, archivePath, ann); err != nil {
+		return fmt.Errorf("create checkpoint image: %w", err)
+	}
+	srcRef, err := layout.NewReference(layoutDir, "")
+	if err != nil {
+		return err
+	}
+
+	// The source image is the checkpoint just created, there is nothing to verify.
+	policyContext, err := signature.NewPolicyContext(&signature.Policy{
+		Default: signature.PolicyRequirements{signature.NewPRInsecureAcceptAnything()},
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := policyContext.Destroy(); err != nil {
+			log.Errorf(ctx, "Error destroying policy: %+v", err)
+		}
+	}()
+
+	log.Debugf(ctx, "Copying checkpoint image of container %q to %q", ctr.ID(), target)
+	if _, err := copy.Image(ctx, policyContext, destRef, srcRef, &copy.Options{
+		DestinationCtx: c.config.SystemContext,
+	}); err != nil {
+		return fmt.Errorf("copy checkpoint image to %q: %w", target, err)
+	}
+	return nil
+}
+
+// checkpointImageAnnotations returns the annotations of the checkpoint image of the container,
+// describing where it comes from, its runtime annotations, the tuning the runtime handler hooks
+// applied to it and its bind mounts.
+func (c *ContainerServer) checkpointImageAnnotations(ctr *oci.Container) (map[string]string, error) {
+	ann := map[string]string{
+		annotations.CheckpointAnnotationName:         ctr.Name(),
+		annotations.CheckpointAnnotationRawImageName: ctr.UserRequestedImage(),
+		annotations.CheckpointAnnotationCRIOVersion:  version.Version,
+		metadata.CheckpointAnnotationEngine:          constants.ContainerManagerCRIO,
+		metadata.CheckpointAnnotationEngineVersion:   version.Version,
+		metadata.CheckpointAnnotationRawImageName:    ctr.UserRequestedImage(),
+		metadata.CheckpointAnnotationHostArch:        runtime.GOARCH,
+	}
+	if name := ctr.Labels()[kubetypes.KubernetesContainerNameLabel]; name != "" {
+		ann[metadata.CheckpointAnnotationName] = name
+	}
+	if id := ctr.ImageID(); id != nil {
+		ann[annotations.CheckpointAnnotationRootfsImageID] = id.IDStringForOutOfProcessConsumptionOnly()
+		ann[metadata.CheckpointAnnotationRootfsImageID] = id.IDStringForOutOfProcessConsumptionOnly()
+	}
+	if name := ctr.SomeNameOfTheImage(); name != nil {
+		ann[annotations.CheckpointAnnotationRootfsImageName] = name.StringForOutOfProcessConsumptionOnly()
+		ann[metadata.CheckpointAnnotationRootfsImageName] = name.StringForOutOfProcessConsumptionOnly()
+	}
+	if criuVersion, err := criu.GetCriuVersion(); err == nil {
+		ann[annotations.CheckpointAnnotationCriuVersion] = strconv.Itoa(criuVersion)
+		ann[metadata.CheckpointAnnotationCriuVersion] = strconv.Itoa(criuVersion)
+	}
+	runtimeHandler := c.config.DefaultRuntime
+	if sb := c.GetSandbox(ctr.Sandbox()); sb != nil {
+		ann[metadata.CheckpointAnnotationPod] = sb.KubeName()
+		ann[metadata.CheckpointAnnotationPodID] = sb.ID()
+		ann[metadata.CheckpointAnnotationNamespace] = sb.Namespace()
+		if sb.RuntimeHandler() != "" {
+			runtimeHandler = sb.RuntimeHandler()
+		}
+	}
+	ann[metadata.CheckpointAnnotationRuntimeName] = runtimeHandler
+
+	if len(ctr.Annotations()) > 0 {
+		content, err := json.Marshal(ctr.Annotations())
+		if err != nil {
+			return nil, err
+		}
+		ann[annotations.CheckpointAnnotationAnnotations] = string(content)
+	}
+	if tunings := ctr.Tunings(); len(tunings) > 0 {
+		ann[annotations.CheckpointAnnotationTunings] = string(tunings)
+	}
+	// The bind mounts got written by prepareCheckpointExport, if the container has any.
+	mounts, err := os.ReadFile(filepath.Join(ctr.Dir(), "bind.mounts"))
+	if err == nil {
+		ann[annotations.CheckpointAnnotationMounts] = string(mounts)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return ann, nil
+}
+
+// writeCheckpointImageLayout writes an OCI image layout to the directory with the image of the
+// checkpoint archive, its files being at the root of the image like the restore expects.
+func writeCheckpointImageLayout(dir, archivePath string, ann map[string]string) error {
+	blobsDir := filepath.Join(dir, imgspecv1.ImageBlobsDir, digest.Canonical.String())
+	if err := os.MkdirAll(blobsDir, 0o700); err != nil {
+		return err
+	}
+
+	// The archive is not compressed, so that the digest of the layer is its diff ID.
+	layerDigest, layerSize, err := digestFile(archivePath)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(archivePath, filepath.Join(blobsDir, layerDigest.Encoded())); err != nil {
+		return err
+	}
+
+	created := time.Now().UTC()
+	config := imgspecv1.Image{
+		Created: &created,
+		Platform: imgspecv1.Platform{
+			Architecture: runtime.GOARCH,
+			OS:           runtime.GOOS,
+		},
+		RootFS: imgspecv1.RootFS{
+			Type:    "layers",
+			DiffIDs: []digest.Digest{layerDigest},
+		},
+	}
+	configDesc, err := writeJSONBlob(blobsDir, imgspecv1.MediaTypeImageConfig, &config)
+	if err != nil {
+		return err
+	}
+
+	manifest := imgspecv1.Manifest{
+		Versioned: imgspec.Versioned{SchemaVersion: 2},
+		MediaType: imgspecv1.MediaTypeImageManifest,
+		Config:    configDesc,
+		Layers: []imgspecv1.Descriptor{{
+			MediaType: imgspecv1.MediaTypeImageLayer,
+			Digest:    layerDigest,
+			Size:      layerSize,
+		}},
+		Annotations: ann,
+	}
+	manifestDesc, err := writeJSONBlob(blobsDir, imgspecv1.MediaTypeImageManifest, &manifest)
+	if err != nil {
+		return err
+	}
+
+	index := imgspecv1.Index{
+		Versioned: imgspec.Versioned{SchemaVersion: 2},
+		MediaType: imgspecv1.MediaTypeImageIndex,
+		Manifests: []imgspecv1.Descriptor{manifestDesc},
+	}
+	if _, err := metadata.WriteJSONFile(&index, dir, imgspecv1.ImageIndexFile); err != nil {
+		return err
+	}
+	_, err = metadata.WriteJSONFile(&imgspecv1.ImageLayout{Version: imgspecv1.ImageLayoutVersion}, dir, imgspecv1.ImageLayoutFile)
+	return err
+}
+
+func digestFile(path string) (digest.Digest, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	d, err := digest.Canonical.FromReader(f)
+	if err != nil {
+		return "", 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return "", 0, err
+	}
+	return d, info.Size(), nil
+}
+
+func writeJSONBlob(blobsDir, mediaType string, v any) (imgspecv1.Descriptor, error) {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return imgspecv1.Descriptor{}, err
+	}
+	d := digest.FromBytes(content)
+	if err := os.WriteFile(filepath.Join(blobsDir, d.Encoded()), content, 0o600); err != nil {
+		return imgspecv1.Descriptor{}, err
+	}
+	return imgspecv1.Descriptor{MediaType: mediaType, Digest: d, Size: int64(len(content))}, nil
+}
@@ -2,20 +2,24 @@ package lib_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 
 	metadata "github.com/checkpoint-restore/checkpointctl/lib"
 	criu "github.com/checkpoint-restore/go-criu/v7/utils"
+	"github.com/containers/image/v5/oci/layout"
 	cstorage "github.com/containers/storage"
 	"github.com/containers/storage/pkg/archive"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	imgspecv1 "github.com/opencontainers/image-spec/specs-go/v1"
 	specs "github.com/opencontainers/runtime-spec/specs-go"
 	"go.uber.org/mock/gomock"
 
 	"github.com/cri-o/cri-o/internal/lib"
 	"github.com/cri-o/cri-o/internal/oci"
+	"github.com/cri-o/cri-o/pkg/annotations"
 )
 
 // The actual test suite.
@@ -181,6 +185,55 @@ var _ = t.Describe("ContainerCheckpoint", func() {
 			Expect(res).To(ContainSubstring(config.ID))
 		})
 	})
+	t.Describe("ContainerCheckpoint", func() {
+		It("should export an image", func() {
+			// Given
+			Expect(os.WriteFile("config.json", []byte(`{"linux":{},"process":{}}`), 0o644)).To(Succeed())
+			imageDir := t.MustTempDir("checkpoint-image")
+
+			addContainerAndSandbox()
+			config := &metadata.ContainerConfig{
+				ID: containerID,
+			}
+			opts := &lib.ContainerCheckpointOptions{
+				TargetImage: "oci:" + imageDir + ":checkpoint",
+			}
+
+			myContainer.SetState(&oci.ContainerState{
+				State: specs.State{Status: oci.ContainerStateRunning},
+			})
+			myContainer.SetSpec(&specs.Spec{Version: "1.0.0"})
+			myContainer.SetTunings([]byte(`{"sharedCPUs":true}`))
+
+			gomock.InOrder(
+				storeMock.EXPECT().Container(gomock.Any()).Return(&cstorage.Container{}, nil),
+				storeMock.EXPECT().Changes(gomock.Any(), gomock.Any()).Return([]archive.Change{}, nil),
+				storeMock.EXPECT().Mount(gomock.Any(), gomock.Any()).Return("/tmp/", nil),
+				storeMock.EXPECT().Container(gomock.Any()).Return(&cstorage.Container{}, nil),
+				storeMock.EXPECT().Unmount(gomock.Any(), gomock.Any()).Return(true, nil),
+			)
+
+			// When
+			res, err := sut.ContainerCheckpoint(context.Background(), config, opts)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).To(Equal(config.ID))
+			ref, err := layout.NewReference(imageDir, "checkpoint")
+			Expect(err).ToNot(HaveOccurred())
+			src, err := ref.NewImageSource(context.Background(), nil)
+			Expect(err).ToNot(HaveOccurred())
+			defer src.Close()
+			content, _, err := src.GetManifest(context.Background(), nil)
+			Expect(err).ToNot(HaveOccurred())
+			manifest := &imgspecv1.Manifest{}
+			Expect(json.Unmarshal(content, manifest)).To(Succeed())
+			Expect(manifest.Layers).To(HaveLen(1))
+			Expect(manifest.Annotations).To(HaveKeyWithValue(annotations.CheckpointAnnotationName, myContainer.Name()))
+			Expect(manifest.Annotations).To(HaveKeyWithValue(annotations.CheckpointAnnotationTunings, `{"sharedCPUs":true}`))
+			Expect(manifest.Annotations).To(HaveKeyWithValue(metadata.CheckpointAnnotationEngine, "cri-o"))
+		})
+	})
 	t.Describe("ContainerCheckpoint", func() {
 		It("should fail during unmount", func() {
 			// Given
@@ -236,6 +289,16 @@ var _ = t.Describe("ContainerCheckpoint", func() {
 			Expect(err.Error()).To(Equal(`failed to find container invalid: container with ID starting with invalid not found: ID does not exist`))
 		})
 	})
+	t.Describe("IsCheckpointImage", func() {
+		It("should tell the images from the archives", func() {
+			Expect(lib.IsCheckpointImage("docker://quay.io/crio/checkpoint:latest")).To(BeTrue())
+			Expect(lib.IsCheckpointImage("containers-storage:checkpoint")).To(BeTrue())
+			Expect(lib.IsCheckpointImage("oci-archive:/tmp/checkpoint.tar")).To(BeTrue())
+			Expect(lib.IsCheckpointImage("/var/lib/kubelet/checkpoints/checkpoint.tar")).To(BeFalse())
+			Expect(lib.IsCheckpointImage("checkpoint:1.tar")).To(BeFalse())
+			Expect(lib.IsCheckpointImage("")).To(BeFalse())
+		})
+	})
 	t.Describe("ContainerCheckpoint", func() {
 		It("should fail with invalid config", func() {
 			// Given
@@ -4,3 +4,7 @@ package constants
 // container has been created by CRI-O. Usually used together with the key
 // `io.container.manager`.
 const ContainerManagerCRIO = "cri-o"
+
+// TuningCheckpointFile is the file of the container directory holding the tuning
+// captured by the runtime handler hooks, which is part of the checkpoint archive.
+const TuningCheckpointFile = "tuning.dump"
@@ -250,6 +250,22 @@ func (c *ContainerServer) LoadSandbox(ctx context.Context, id string) (sb *sandb
 		}
 	}
 
+	if v, found := m.Annotations[annotations.HighPerformance]; found {
+		highPerformance := &sandbox.HighPerformance{}
+		if err := json.Unmarshal([]byte(v), highPerformance); err != nil {
+			return nil, fmt.Errorf("error unmarshalling %s annotation: %w", annotations.HighPerformance, err)
+		}
+		sbox.SetHighPerformance(highPerformance)
+	}
+
+	if v, found := m.Annotations[annotations.OCIArtifacts]; found {
+		ociArtifacts := map[string]string{}
+		if err := json.Unmarshal([]byte(v), &ociArtifacts); err != nil {
+			return nil, fmt.Errorf("error unmarshalling %s annotation: %w", annotations.OCIArtifacts, err)
+		}
+		sbox.SetOCIArtifacts(ociArtifacts)
+	}
+
 	sbox.SetLogDir(filepath.Dir(m.Annotations[annotations.LogPath]))
 	sbox.SetContainers(memorystore.New[*oci.Container]())
 	sbox.SetShmPath(m.Annotations[annotations.ShmPath])
@@ -14,6 +14,7 @@ import (
 	"github.com/opencontainers/runtime-tools/generate"
 	"github.com/sirupsen/logrus"
 
+	"github.com/cri-o/cri-o/internal/lib/constants"
 	"github.com/cri-o/cri-o/internal/log"
 	"github.com/cri-o/cri-o/internal/oci"
 	"github.com/cri-o/cri-o/pkg/annotations"
@@ -89,6 +90,7 @@ func (c *ContainerServer) ContainerRestore(
 				metadata.PodDumpFile,
 				stats.StatsDump,
 				"bind.mounts",
+				constants.TuningCheckpointFile,
 				annotations.LogPath,
 			}
 			for _, name := range checkpoint {
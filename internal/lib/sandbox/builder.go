@@ -109,6 +109,12 @@ type Builder interface {
 	// SetPodLinuxResources sets the PodLinuxResources.
 	SetPodLinuxResources(*types.LinuxContainerResources)
 
+	// SetHighPerformance sets the high-performance tuning decided at creation.
+	SetHighPerformance(*HighPerformance)
+
+	// SetOCIArtifacts sets the paths of the OCI artifacts pulled for the sandbox.
+	SetOCIArtifacts(map[string]string)
+
 	// SetHostnamePath sets the hostname path.
 	SetHostnamePath(string)
 
@@ -362,6 +368,16 @@ func (b *sandboxBuilder) SetPodLinuxResources(podLinuxResources *types.LinuxCont
 	b.sandboxRef.podLinuxResources = podLinuxResources
 }
 
+// SetHighPerformance sets the high-performance tuning decided at the creation of the sandbox.
+func (b *sandboxBuilder) SetHighPerformance(highPerformance *HighPerformance) {
+	b.sandboxRef.highPerformance = highPerformance
+}
+
+// SetOCIArtifacts sets the paths of the OCI artifacts pulled for the sandbox by name.
+func (b *sandboxBuilder) SetOCIArtifacts(ociArtifacts map[string]string) {
+	b.sandboxRef.ociArtifacts = ociArtifacts
+}
+
 // SetHostnamePath adds the hostname path to the sandbox.
 func (b *sandboxBuilder) SetHostnamePath(hostnamePath string) {
 	b.sandboxRef.hostnamePath = hostnamePath
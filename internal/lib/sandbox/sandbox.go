@@ -28,6 +28,16 @@ var (
 	sbNetworkStoppedFilename = "network-stopped"
 )
 
+// HighPerformance is the high-performance tuning decided for a sandbox at its creation. It is persisted
+// along with the sandbox, so that the runtime handler hooks revert the tuning of its containers the way
+// it got applied after a restart, whatever the configuration of CRI-O became.
+type HighPerformance struct {
+	// Annotations are the high-performance annotations of the sandbox.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// SharedCPUs is the shared CPU pool of the runtime handler of the sandbox at its creation.
+	SharedCPUs string `json:"sharedCPUs,omitempty"`
+}
+
 // Sandbox contains data surrounding kubernetes sandboxes on the server.
 type Sandbox struct {
 	criSandbox   *types.PodSandbox
@@ -68,6 +78,8 @@ type Sandbox struct {
 	containerEnvPath   string
 	podLinuxOverhead   *types.LinuxContainerResources
 	podLinuxResources  *types.LinuxContainerResources
+	highPerformance    *HighPerformance
+	ociArtifacts       map[string]string
 }
 
 // DefaultShmSize is the default shm size.
@@ -273,6 +285,18 @@ func (s *Sandbox) PodLinuxResources() *types.LinuxContainerResources {
 	return s.podLinuxResources
 }
 
+// HighPerformance returns the high-performance tuning decided for this sandbox at its creation,
+// nil if it did not request any.
+func (s *Sandbox) HighPerformance() *HighPerformance {
+	return s.highPerformance
+}
+
+// OCIArtifacts returns the paths of the OCI artifacts pulled for this sandbox by name,
+// as requested by its annotations.
+func (s *Sandbox) OCIArtifacts() map[string]string {
+	return s.ociArtifacts
+}
+
 // AddContainer adds a container to the sandbox.
 func (s *Sandbox) AddContainer(ctx context.Context, c *oci.Container) {
 	_, span := log.StartSpan(ctx)
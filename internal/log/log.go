@@ -14,6 +14,13 @@ type (
 	Name struct{}
 )
 
+type loggerKey struct{}
+
+// WithLogger returns a context logging through the entry e instead of the standard logger.
+func WithLogger(ctx context.Context, e *logrus.Entry) context.Context {
+	return context.WithValue(ctx, loggerKey{}, e)
+}
+
 func Debugf(ctx context.Context, format string, args ...any) {
 	entry(ctx).Debugf(format, args...)
 }
@@ -39,18 +46,22 @@ func WithFields(ctx context.Context, fields map[string]any) *logrus.Entry {
 }
 
 func entry(ctx context.Context) *logrus.Entry {
-	logger := logrus.StandardLogger()
+	base := logrus.NewEntry(logrus.StandardLogger())
 	if ctx == nil {
-		return logrus.NewEntry(logger)
+		return base
+	}
+
+	if e, ok := ctx.Value(loggerKey{}).(*logrus.Entry); ok {
+		base = e
 	}
 
 	id, idOk := ctx.Value(ID{}).(string)
 	name, nameOk := ctx.Value(Name{}).(string)
 	if idOk && nameOk {
-		return logger.WithField("id", id).WithField("name", name).WithContext(ctx)
+		return base.WithField("id", id).WithField("name", name).WithContext(ctx)
 	}
 
-	return logrus.NewEntry(logger).WithContext(ctx)
+	return base.WithContext(ctx)
 }
 
 func StartSpan(ctx context.Context) (context.Context, trace.Span) {
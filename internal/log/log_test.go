@@ -150,4 +150,24 @@ var _ = t.Describe("Log", func() {
 			Expect(buf.String()).To(BeEmpty())
 		})
 	})
+
+	t.Describe("WithLogger", func() {
+		BeforeEach(func() { beforeEach(logrus.InfoLevel) })
+
+		It("should log through the entry of the context", func() {
+			// Given
+			logger := logrus.New()
+			logger.SetOutput(buf)
+			logger.SetFormatter(&logrus.JSONFormatter{})
+			ctx := log.WithLogger(ctx(), logrus.NewEntry(logger).WithField("step", "some-step"))
+
+			// When
+			log.Infof(ctx, msg)
+
+			// Then
+			Expect(buf.String()).To(ContainSubstring(`"msg":"` + msg + `"`))
+			Expect(buf.String()).To(ContainSubstring(`"id":"` + id + `"`))
+			Expect(buf.String()).To(ContainSubstring(`"step":"some-step"`))
+		})
+	})
 })
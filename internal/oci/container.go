@@ -77,6 +77,7 @@ type Container struct {
 	runtimePath           string // runtime path for a given platform
 	execPIDs              map[int]bool
 	runtimeUser           *types.ContainerUser
+	specPatches           []*SpecPatch
 }
 
 func (c *Container) CRIAttributes() *types.ContainerAttributes {
@@ -116,6 +117,9 @@ type ContainerState struct {
 	InitStartTime string `json:"initStartTime,omitempty"`
 	// Checkpoint/Restore related states
 	CheckpointedAt time.Time `json:"checkpointedTime,omitempty"`
+	// Tunings is the tuning applied to the container by the runtime handler hooks, in their own format,
+	// so that they can revert it after a restart of CRI-O.
+	Tunings json.RawMessage `json:"tunings,omitempty"`
 }
 
 // NewContainer creates a container object.
@@ -422,6 +426,21 @@ func (c *Container) State() *ContainerState {
 	return c.state
 }
 
+// Tunings returns the tuning applied to the container by the runtime handler hooks, nil if it did not get tuned.
+func (c *Container) Tunings() json.RawMessage {
+	c.opLock.RLock()
+	defer c.opLock.RUnlock()
+	return c.state.Tunings
+}
+
+// SetTunings records the tuning applied to the container by the runtime handler hooks in its state,
+// nil if it got reverted.
+func (c *Container) SetTunings(tunings json.RawMessage) {
+	c.opLock.Lock()
+	defer c.opLock.Unlock()
+	c.state.Tunings = tunings
+}
+
 // StateNoLock returns the state of a container without using a lock.
 func (c *Container) StateNoLock() *ContainerState {
 	return c.state
@@ -0,0 +1,141 @@
+package oci
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+	"k8s.io/utils/cpuset"
+)
+
+// maxTaskPasses bounds the passes over the tasks of a container applying a scheduling setting,
+// which are repeated while new tasks show up, to catch the ones spawned in the meantime.
+const maxTaskPasses = 5
+
+// Task is a thread of a process of a container.
+type Task struct {
+	// PID is the ID of the process of the task, and TID the ID of the task itself.
+	PID int
+	TID int
+	// Comm is the name of the task, like the one set by the application to its threads.
+	Comm string
+}
+
+// Tasks returns the tasks of the processes of the running container, from its init process down to its
+// descendants. The tasks are a snapshot: some may have exited by now, and others may have been spawned.
+func (c *Container) Tasks() ([]Task, error) {
+	pid, err := c.Pid()
+	if err != nil {
+		return nil, fmt.Errorf("get container %q pid: %w", c.ID(), err)
+	}
+	return processTreeTasks(pid)
+}
+
+// SetTasksAffinity sets the CPU affinity of the tasks of the running container accepted by filter,
+// or of all of them if nil.
+func (c *Container) SetTasksAffinity(cpus cpuset.CPUSet, filter func(Task) bool) error {
+	if cpus.IsEmpty() {
+		return errors.New("CPU set of the affinity is empty")
+	}
+	var mask unix.CPUSet
+	for _, cpu := range cpus.List() {
+		mask.Set(cpu)
+	}
+	return c.applyToTasks(filter, func(tid int) error {
+		if err := unix.SchedSetaffinity(tid, &mask); err != nil {
+			return fmt.Errorf("set affinity of thread %d to %q: %w", tid, cpus.String(), err)
+		}
+		return nil
+	})
+}
+
+// SetTasksSchedAttr sets the scheduling policy and attributes of the tasks of the running container
+// accepted by filter, or of all of them if nil.
+func (c *Container) SetTasksSchedAttr(attr *unix.SchedAttr, filter func(Task) bool) error {
+	return c.applyToTasks(filter, func(tid int) error {
+		if err := unix.SchedSetAttr(tid, attr, 0); err != nil {
+			return fmt.Errorf("set scheduling attributes of thread %d: %w", tid, err)
+		}
+		return nil
+	})
+}
+
+// applyToTasks applies the setting to the tasks of the container accepted by filter. The tasks exiting
+// in between are skipped, and the tasks are listed again after every pass to apply the setting to the
+// ones spawned during the pass by the tasks it was not applied to yet, until no new task shows up.
+func (c *Container) applyToTasks(filter func(Task) bool, apply func(tid int) error) error {
+	done := map[int]bool{}
+	for range maxTaskPasses {
+		tasks, err := c.Tasks()
+		if err != nil {
+			return err
+		}
+		applied := 0
+		for _, task := range tasks {
+			if done[task.TID] || (filter != nil && !filter(task)) {
+				continue
+			}
+			if err := apply(task.TID); err != nil && !errors.Is(err, unix.ESRCH) {
+				return err
+			}
+			done[task.TID] = true
+			applied++
+		}
+		if applied == 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("tasks of container %q kept being spawned after %d passes", c.ID(), maxTaskPasses)
+}
+
+// processTreeTasks returns the tasks of the process and of its descendants.
+func processTreeTasks(pid int) ([]Task, error) {
+	var tasks []Task
+	pending := []int{pid}
+	seen := map[int]bool{}
+	for len(pending) > 0 {
+		pid := pending[0]
+		pending = pending[1:]
+		if seen[pid] {
+			continue
+		}
+		seen[pid] = true
+
+		entries, err := os.ReadDir(filepath.Join("/proc", strconv.Itoa(pid), "task"))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) && len(tasks) > 0 {
+				// The child exited in the meantime.
+				continue
+			}
+			return nil, err
+		}
+		for _, entry := range entries {
+			tid, err := strconv.Atoi(entry.Name())
+			if err != nil {
+				continue
+			}
+			dir := filepath.Join("/proc", strconv.Itoa(pid), "task", entry.Name())
+			comm, err := os.ReadFile(filepath.Join(dir, "comm"))
+			if err != nil {
+				// The task exited in the meantime.
+				continue
+			}
+			tasks = append(tasks, Task{PID: pid, TID: tid, Comm: strings.TrimSpace(string(comm))})
+
+			children, err := os.ReadFile(filepath.Join(dir, "children"))
+			if err != nil {
+				continue
+			}
+			for _, child := range strings.Fields(string(children)) {
+				if childPID, err := strconv.Atoi(child); err == nil {
+					pending = append(pending, childPID)
+				}
+			}
+		}
+	}
+	return tasks, nil
+}
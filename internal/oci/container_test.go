@@ -12,7 +12,9 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
 	types "k8s.io/cri-api/pkg/apis/runtime/v1"
+	"k8s.io/utils/cpuset"
 
 	"github.com/cri-o/cri-o/internal/oci"
 	"github.com/cri-o/cri-o/internal/storage"
@@ -629,6 +631,61 @@ var _ = t.Describe("Container", func() {
 			Expect(state.SetInitPid(state.Pid)).NotTo(Succeed())
 		})
 	})
+	t.Describe("SpecPatches", func() {
+		It("should merge the patches in their submission order", func() {
+			// Given
+			spec := &specs.Spec{
+				Process: &specs.Process{
+					Env:     []string{"PATH=/bin", "CPUS=0"},
+					Rlimits: []specs.POSIXRlimit{{Type: "RLIMIT_NOFILE", Hard: 1024, Soft: 1024}},
+				},
+				Mounts: []specs.Mount{{Destination: "/dev/shm", Source: "shm"}},
+			}
+			Expect(sut.SubmitSpecPatch(&oci.SpecPatch{
+				Source: "first",
+				Env:    []string{"CPUS=1-2"},
+				Mounts: []specs.Mount{{Destination: "/dev/shm/", Source: "tmpfs"}},
+			})).To(Succeed())
+			Expect(sut.SubmitSpecPatch(&oci.SpecPatch{
+				Source:  "second",
+				Env:     []string{"CPUS=3"},
+				Rlimits: []specs.POSIXRlimit{{Type: "RLIMIT_NOFILE", Hard: 4096, Soft: 2048}},
+				Unified: map[string]string{"cpu.idle": "1"},
+			})).To(Succeed())
+
+			// When
+			sut.ApplySpecPatches(spec)
+
+			// Then
+			Expect(spec.Process.Env).To(Equal([]string{"PATH=/bin", "CPUS=3"}))
+			Expect(spec.Mounts).To(Equal([]specs.Mount{{Destination: "/dev/shm/", Source: "tmpfs"}}))
+			Expect(spec.Process.Rlimits).To(Equal([]specs.POSIXRlimit{{Type: "RLIMIT_NOFILE", Hard: 4096, Soft: 2048}}))
+			Expect(spec.Linux.Resources.Unified).To(Equal(map[string]string{"cpu.idle": "1"}))
+		})
+		It("should apply the patches only once", func() {
+			// Given
+			spec := &specs.Spec{}
+			Expect(sut.SubmitSpecPatch(&oci.SpecPatch{Env: []string{"CPUS=1"}})).To(Succeed())
+			sut.ApplySpecPatches(spec)
+
+			// When
+			spec.Process.Env = nil
+			sut.ApplySpecPatches(spec)
+
+			// Then
+			Expect(spec.Process.Env).To(BeEmpty())
+		})
+		It("should reject the invalid patches", func() {
+			// Given
+			// When
+			// Then
+			Expect(sut.SubmitSpecPatch(&oci.SpecPatch{Env: []string{"CPUS"}})).NotTo(Succeed())
+			Expect(sut.SubmitSpecPatch(&oci.SpecPatch{Mounts: []specs.Mount{{Destination: "dev/shm"}}})).NotTo(Succeed())
+			Expect(sut.SubmitSpecPatch(&oci.SpecPatch{
+				Rlimits: []specs.POSIXRlimit{{Type: "RLIMIT_NOFILE", Hard: 1024, Soft: 2048}},
+			})).NotTo(Succeed())
+		})
+	})
 	t.Describe("GetPidStartTimeFromFile", func() {
 		var statFile string
 		BeforeEach(func() {
@@ -695,3 +752,79 @@ var _ = t.Describe("SpoofedContainer", func() {
 		Expect(sut.Sandbox()).To(Equal("sbox"))
 	})
 })
+
+var _ = t.Describe("ContainerTasks", func() {
+	var sut *oci.Container
+
+	BeforeEach(func() {
+		sut = getTestContainer()
+		state := &oci.ContainerState{}
+		state.Pid = os.Getpid()
+		Expect(state.SetInitPid(state.Pid)).To(Succeed())
+		sut.SetState(state)
+	})
+
+	It("should list the tasks of the init process", func() {
+		// When
+		tasks, err := sut.Tasks()
+
+		// Then
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tasks).To(ContainElement(HaveField("TID", os.Getpid())))
+		for _, task := range tasks {
+			Expect(task.Comm).NotTo(BeEmpty())
+		}
+	})
+
+	It("should fail to list the tasks if the pid is uninitialized", func() {
+		// Given
+		sut.SetState(&oci.ContainerState{})
+
+		// When
+		_, err := sut.Tasks()
+
+		// Then
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should set the affinity of the tasks", func() {
+		// Given
+		var current unix.CPUSet
+		Expect(unix.SchedGetaffinity(0, &current)).To(Succeed())
+		cpus := []int{}
+		for cpu := 0; len(cpus) < current.Count(); cpu++ {
+			if current.IsSet(cpu) {
+				cpus = append(cpus, cpu)
+			}
+		}
+
+		// When
+		err := sut.SetTasksAffinity(cpuset.New(cpus...), nil)
+
+		// Then
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should only apply the setting to the tasks accepted by the filter", func() {
+		// Given
+		var filtered []oci.Task
+
+		// When
+		err := sut.SetTasksAffinity(cpuset.New(0), func(task oci.Task) bool {
+			filtered = append(filtered, task)
+			return false
+		})
+
+		// Then
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filtered).To(ContainElement(HaveField("TID", os.Getpid())))
+	})
+
+	It("should fail to set an empty affinity", func() {
+		// When
+		err := sut.SetTasksAffinity(cpuset.New(), nil)
+
+		// Then
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -971,6 +971,7 @@ func (r *runtimeOCI) DeleteContainer(ctx context.Context, c *Container) error {
 		// Collect metric by container name
 		metrics.Instance().MetricContainersOOMCountTotalDelete(c.Name())
 	}
+	metrics.Instance().MetricTuningDriftTotalDelete(c.Name())
 
 	_, err := r.runtimeCmd("delete", "--force", c.ID())
 	if errors.Is(err, ErrNotFound) {
@@ -712,6 +712,7 @@ func (r *runtimeVM) DeleteContainer(ctx context.Context, c *Container) error {
 		// Collect metric by container name
 		metrics.Instance().MetricContainersOOMCountTotalDelete(c.Name())
 	}
+	metrics.Instance().MetricTuningDriftTotalDelete(c.Name())
 
 	return r.deleteContainer(c, false)
 }
@@ -0,0 +1,112 @@
+package oci
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// SpecPatch is a change of the spec of a container submitted by a runtime handler hook while the
+// container gets created. The patches are merged into the spec in their submission order before it
+// is handed to the runtime, so that the runtime creates the container with them.
+type SpecPatch struct {
+	// Source names the hook submitting the patch, for the logs and errors.
+	Source string
+	// Env are the KEY=VALUE variables set in the environment of the process, replacing the ones of the same key.
+	Env []string
+	// Mounts are the mounts added to the container, replacing the ones of the same destination.
+	Mounts []specs.Mount
+	// Rlimits are the rlimits set on the process, replacing the ones of the same type.
+	Rlimits []specs.POSIXRlimit
+	// Unified are the cgroup v2 files set in the unified resources of the container.
+	Unified map[string]string
+}
+
+// validate returns an error if the patch cannot be applied to any spec.
+func (p *SpecPatch) validate() error {
+	for _, env := range p.Env {
+		if key, _, ok := strings.Cut(env, "="); !ok || key == "" {
+			return fmt.Errorf("environment variable %q of spec patch from %s is not KEY=VALUE", env, p.Source)
+		}
+	}
+	for i := range p.Mounts {
+		if !filepath.IsAbs(p.Mounts[i].Destination) {
+			return fmt.Errorf("mount destination %q of spec patch from %s is not absolute", p.Mounts[i].Destination, p.Source)
+		}
+	}
+	for _, rlimit := range p.Rlimits {
+		if rlimit.Soft > rlimit.Hard {
+			return fmt.Errorf("soft limit of rlimit %s of spec patch from %s exceeds its hard limit", rlimit.Type, p.Source)
+		}
+	}
+	return nil
+}
+
+// apply merges the patch into the spec.
+func (p *SpecPatch) apply(spec *specs.Spec) {
+	if len(p.Env) > 0 || len(p.Rlimits) > 0 {
+		if spec.Process == nil {
+			spec.Process = &specs.Process{}
+		}
+	}
+	for _, env := range p.Env {
+		key, _, _ := strings.Cut(env, "=")
+		spec.Process.Env = slices.DeleteFunc(spec.Process.Env, func(e string) bool {
+			return strings.HasPrefix(e, key+"=")
+		})
+		spec.Process.Env = append(spec.Process.Env, env)
+	}
+	for i := range p.Mounts {
+		spec.Mounts = slices.DeleteFunc(spec.Mounts, func(m specs.Mount) bool {
+			return filepath.Clean(m.Destination) == filepath.Clean(p.Mounts[i].Destination)
+		})
+		spec.Mounts = append(spec.Mounts, p.Mounts[i])
+	}
+	for _, rlimit := range p.Rlimits {
+		spec.Process.Rlimits = slices.DeleteFunc(spec.Process.Rlimits, func(r specs.POSIXRlimit) bool {
+			return r.Type == rlimit.Type
+		})
+		spec.Process.Rlimits = append(spec.Process.Rlimits, rlimit)
+	}
+	if len(p.Unified) > 0 {
+		if spec.Linux == nil {
+			spec.Linux = &specs.Linux{}
+		}
+		if spec.Linux.Resources == nil {
+			spec.Linux.Resources = &specs.LinuxResources{}
+		}
+		if spec.Linux.Resources.Unified == nil {
+			spec.Linux.Resources.Unified = make(map[string]string, len(p.Unified))
+		}
+		for key, value := range p.Unified {
+			spec.Linux.Resources.Unified[key] = value
+		}
+	}
+}
+
+// SubmitSpecPatch queues the patch to be merged into the spec of the container before its creation,
+// or returns an error if the patch is invalid.
+func (c *Container) SubmitSpecPatch(patch *SpecPatch) error {
+	if err := patch.validate(); err != nil {
+		return err
+	}
+	c.opLock.Lock()
+	defer c.opLock.Unlock()
+	c.specPatches = append(c.specPatches, patch)
+	return nil
+}
+
+// ApplySpecPatches merges the queued spec patches into the spec in their submission order, and clears them.
+func (c *Container) ApplySpecPatches(spec *specs.Spec) {
+	c.opLock.Lock()
+	patches := c.specPatches
+	c.specPatches = nil
+	c.opLock.Unlock()
+
+	for _, patch := range patches {
+		patch.apply(spec)
+	}
+}
@@ -0,0 +1,195 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/opencontainers/runtime-tools/generate"
+	"k8s.io/apimachinery/pkg/fields"
+
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/internal/oci"
+	crioannotations "github.com/cri-o/cri-o/pkg/annotations"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+)
+
+const (
+	// napiDeferHardIRQsFile and groFlushTimeoutFile are the busy poll parameters of a network device: the NAPI polls
+	// finding no packet before its interrupts get enabled again, and the nanoseconds its GRO packets are held for.
+	napiDeferHardIRQsFile = "napi_defer_hard_irqs"
+	groFlushTimeoutFile   = "gro_flush_timeout"
+)
+
+// afXDPCapabilities are the capabilities of the AF_XDP applications: opening XDP sockets, loading and
+// attaching XDP programs, and locking the memory of their UMEM areas. The container must be granted them
+// by the security context of the pod.
+var afXDPCapabilities = []string{"CAP_NET_RAW", "CAP_NET_ADMIN", "CAP_BPF", "CAP_IPC_LOCK"}
+
+// afXDPBusyPoll are the busy poll parameters of the interfaces of the pods of AF_XDP applications,
+// whose interrupts stay masked while the applications keep polling their XDP sockets.
+var afXDPBusyPoll = map[string]string{
+	napiDeferHardIRQsFile: "2",
+	groFlushTimeoutFile:   "200000",
+}
+
+// requestedAFXDP returns the count of queues of the pod interfaces reserved to the AF_XDP applications
+// of the container, if it requests them.
+func requestedAFXDP(annotations fields.Set, cName string) (int, bool) {
+	value, ok := annotations[crioannotations.AFXDPAnnotation+"/"+cName]
+	if !ok {
+		return 0, false
+	}
+	queues, err := strconv.Atoi(value)
+	if err != nil || queues <= 0 {
+		return 0, false
+	}
+	return queues, true
+}
+
+// checkAFXDP rejects the container if the pod security context does not grant it the capabilities of the
+// AF_XDP applications, as the annotation must not grant more than what the pod is admitted with.
+func checkAFXDP(specgen *generate.Generator, containerID string) error {
+	var permitted []string
+	if specgen.Config.Process != nil && specgen.Config.Process.Capabilities != nil {
+		permitted = specgen.Config.Process.Capabilities.Permitted
+	}
+	var missing []string
+	for _, capability := range afXDPCapabilities {
+		if !slices.Contains(permitted, capability) {
+			missing = append(missing, capability)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("container %q requests AF_XDP queues without the capabilities %s of AF_XDP applications in its security context", containerID, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// reserveAFXDPQueues reserves queues of the interfaces of the pod to the XDP sockets of the container
+// and sets their busy poll parameters.
+func (h *HighPerformanceHooks) reserveAFXDPQueues(ctx context.Context, c *oci.Container, s *sandbox.Sandbox, queues int) error {
+	if s.HostNetwork() || s.NetNsPath() == "" {
+		log.Warnf(ctx, "AF_XDP queues requested for container %q of a pod on the host network, ignoring", c.ID())
+		noteUnfulfilledAnnotation(ctx, crioannotations.AFXDPAnnotation, ReasonHostNetwork)
+		return nil
+	}
+	network, err := sandboxNetwork(s)
+	if err != nil {
+		return err
+	}
+	reserved, err := setAFXDPQueues(ctx, c.ID(), network, queues)
+	if err != nil {
+		return err
+	}
+	if len(reserved) == 0 {
+		log.Warnf(ctx, "AF_XDP queues requested for container %q of a pod without RSS interface, ignoring", c.ID())
+		noteUnfulfilledAnnotation(ctx, crioannotations.AFXDPAnnotation, ReasonNoRSSInterface)
+		return nil
+	}
+	log.Infof(ctx, "Reserved the last %d queues of interfaces %v of the pod of container %q to AF_XDP", queues, reserved, c.ID())
+	return nil
+}
+
+// setAFXDPQueues reserves the last queues of the interfaces of the pod network,
+// by spreading their flows over the other queues only in their RSS indirection table, and sets their busy poll
+// parameters, recording the writes for the container. The interfaces without combined channels or RSS
+// indirection table, like the veth ones, are skipped. It returns the interfaces whose queues got reserved.
+func setAFXDPQueues(ctx context.Context, containerID string, network podNetwork, queues int) ([]podInterface, error) {
+	interfaces, err := network.interfaces()
+	if err != nil {
+		return nil, err
+	}
+	var reserved []podInterface
+	for _, iface := range interfaces {
+		if iface.NetNS == "" {
+			continue
+		}
+		table, err := afXDPIndirectionTable(iface, queues)
+		if errors.Is(err, errors.ErrUnsupported) {
+			log.Debugf(ctx, "Interface %s has no RSS queues to reserve, skipping", iface)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := writeNetTuningFile(ctx, containerID, iface.NetNS, ethtoolSettingFile(iface.Name, ethtoolRXFHIndir), []byte(table)); err != nil {
+			return nil, fmt.Errorf("reserve AF_XDP queues of interface %s: %w", iface, err)
+		}
+		for _, file := range []string{napiDeferHardIRQsFile, groFlushTimeoutFile} {
+			if err := writeNetTuningFile(ctx, containerID, iface.NetNS, netDeviceFile(iface.Name, file), []byte(afXDPBusyPoll[file])); err != nil {
+				return nil, fmt.Errorf("set busy poll of interface %s: %w", iface, err)
+			}
+		}
+		reserved = append(reserved, iface)
+	}
+	return reserved, nil
+}
+
+// afXDPIndirectionTable returns the RSS indirection table of the interface spreading the flows over its combined
+// channels but the last queues ones. It returns errors.ErrUnsupported for an interface without them.
+func afXDPIndirectionTable(iface podInterface, queues int) (string, error) {
+	content, err := readNetDeviceFile(iface.NetNS, ethtoolSettingFile(iface.Name, ethtoolCombinedChannels))
+	if err != nil {
+		return "", err
+	}
+	channels, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		return "", err
+	}
+	if channels <= queues {
+		return "", fmt.Errorf("interface %s has %d combined channels, which cannot be reserved %d AF_XDP queues", iface, channels, queues)
+	}
+	content, err = readNetDeviceFile(iface.NetNS, ethtoolSettingFile(iface.Name, ethtoolRXFHIndir))
+	if err != nil {
+		return "", err
+	}
+	entries := strings.Fields(string(content))
+	for i := range entries {
+		entries[i] = strconv.Itoa(i % (channels - queues))
+	}
+	return strings.Join(entries, " "), nil
+}
+
+// planAFXDP returns the RSS indirection tables and busy poll parameters setAFXDPQueues would set for the container.
+func planAFXDP(network podNetwork, queues int) ([]plannedChange, error) {
+	interfaces, err := network.interfaces()
+	if err != nil {
+		return nil, err
+	}
+	var changes []plannedChange
+	for _, iface := range interfaces {
+		if iface.NetNS == "" {
+			continue
+		}
+		table, err := afXDPIndirectionTable(iface, queues)
+		if errors.Is(err, errors.ErrUnsupported) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, plannedChange{
+			Feature: libconfig.HighPerformanceFeatureAFXDP,
+			Path:    ethtoolSettingFile(iface.Name, ethtoolRXFHIndir),
+			Value:   table,
+		})
+		for _, file := range []string{napiDeferHardIRQsFile, groFlushTimeoutFile} {
+			changes = append(changes, plannedChange{
+				Feature: libconfig.HighPerformanceFeatureAFXDP,
+				Path:    netDeviceFile(iface.Name, file),
+				Value:   afXDPBusyPoll[file],
+			})
+		}
+	}
+	return changes, nil
+}
+
+// revertAFXDPQueues restores the RSS indirection tables and busy poll parameters set for the container.
+func revertAFXDPQueues(ctx context.Context, containerID string) error {
+	return restoreNetDeviceTuning(ctx, containerID, ethtoolRXFHIndir, napiDeferHardIRQsFile, groFlushTimeoutFile)
+}
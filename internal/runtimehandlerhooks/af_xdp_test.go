@@ -0,0 +1,102 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/runtime-tools/generate"
+)
+
+var _ = Describe("AF_XDP", func() {
+	const (
+		netns       = "/var/run/netns/pod"
+		containerID = "ctr1"
+	)
+	var (
+		dir     string
+		ethtool fakeEthtool
+	)
+
+	readDeviceFile := func(device, file string) string {
+		content, err := os.ReadFile(filepath.Join(dir, "class", "net", device, file))
+		Expect(err).ToNot(HaveOccurred())
+		return strings.TrimSpace(string(content))
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		ethtool = fakeEthtool{
+			// veth devices have no combined channels
+			"eth0": {},
+			"net1": {ethtoolCombinedChannels: "4", ethtoolRXFHIndir: "0 1 2 3 0 1 2 3"},
+		}
+		useFakePodNetwork([]podInterface{{Name: "eth0", NetNS: netns}, {Name: "net1", NetNS: netns}, {Name: "veth1234"}}, map[string]string{netns: dir}, ethtool)
+		for _, device := range []string{"eth0", "net1"} {
+			Expect(os.MkdirAll(filepath.Join(dir, "class", "net", device), 0o755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "class", "net", device, napiDeferHardIRQsFile), []byte("0\n"), 0o644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "class", "net", device, groFlushTimeoutFile), []byte("0\n"), 0o644)).To(Succeed())
+		}
+	})
+
+	AfterEach(func() {
+		forgetAppliedTuning(context.TODO(), containerID)
+	})
+
+	It("should reserve and release the last queues of the pod interfaces", func() {
+		reserved, err := setAFXDPQueues(context.TODO(), containerID, podNetwork{NetNS: netns}, 1)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reserved).To(Equal([]podInterface{{Name: "net1", NetNS: netns}}))
+		Expect(ethtool["net1"][ethtoolRXFHIndir]).To(Equal("0 1 2 0 1 2 0 1"))
+		Expect(readDeviceFile("net1", napiDeferHardIRQsFile)).To(Equal("2"))
+		Expect(readDeviceFile("net1", groFlushTimeoutFile)).To(Equal("200000"))
+		Expect(readDeviceFile("eth0", napiDeferHardIRQsFile)).To(Equal("0"))
+
+		Expect(revertAFXDPQueues(context.TODO(), containerID)).To(Succeed())
+
+		Expect(ethtool["net1"][ethtoolRXFHIndir]).To(Equal("0 1 2 3 0 1 2 3"))
+		Expect(readDeviceFile("net1", napiDeferHardIRQsFile)).To(Equal("0"))
+		Expect(readDeviceFile("net1", groFlushTimeoutFile)).To(Equal("0"))
+	})
+
+	It("should fail to reserve all the queues of an interface", func() {
+		_, err := setAFXDPQueues(context.TODO(), containerID, podNetwork{NetNS: netns}, 4)
+
+		Expect(err).To(MatchError(ContainSubstring("cannot be reserved 4 AF_XDP queues")))
+		Expect(ethtool["net1"][ethtoolRXFHIndir]).To(Equal("0 1 2 3 0 1 2 3"))
+	})
+
+	It("should plan the changes it would apply", func() {
+		changes, err := planAFXDP(podNetwork{NetNS: netns}, 2)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changes).To(HaveLen(3))
+		Expect(changes[0].Path).To(Equal("/sys/class/net/net1/ethtool/rxfh-indir"))
+		Expect(changes[0].Value).To(Equal("0 1 0 1 0 1 0 1"))
+	})
+
+	It("should reject the containers without the capabilities of AF_XDP applications", func() {
+		specgen, err := generate.New("linux")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(specgen.AddProcessCapability("CAP_NET_RAW")).To(Succeed())
+
+		err = checkAFXDP(&specgen, containerID)
+
+		Expect(err).To(MatchError(ContainSubstring("CAP_NET_ADMIN, CAP_BPF, CAP_IPC_LOCK")))
+		Expect(specgen.Config.Process.Capabilities.Permitted).ToNot(ContainElement("CAP_BPF"))
+	})
+
+	It("should accept the containers with the capabilities of AF_XDP applications", func() {
+		specgen, err := generate.New("linux")
+		Expect(err).ToNot(HaveOccurred())
+		for _, capability := range afXDPCapabilities {
+			Expect(specgen.AddProcessCapability(capability)).To(Succeed())
+		}
+
+		Expect(checkAFXDP(&specgen, containerID)).To(Succeed())
+	})
+})
@@ -0,0 +1,28 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"k8s.io/utils/cpuset"
+
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/internal/oci"
+)
+
+// setInitAffinityToSharedCPUs pins the threads of the container init process to the shared CPUs.
+// The affinity is inherited by every thread and process spawned afterwards, so the isolated CPUs
+// remain free until the application explicitly pins its data-path threads to them.
+// It must run before the container is started, so no application thread escapes the pinning.
+func setInitAffinityToSharedCPUs(ctx context.Context, c *oci.Container, sharedCPUs string) error {
+	sharedCPUSet, err := cpuset.Parse(sharedCPUs)
+	if err != nil {
+		return fmt.Errorf("failed to parse shared cpus: %w", err)
+	}
+	if sharedCPUSet.IsEmpty() {
+		return errors.New("shared CPU set is empty")
+	}
+	log.Infof(ctx, "Pin the tasks of container %q to shared CPUs %q", c.ID(), sharedCPUSet.String())
+	return c.SetTasksAffinity(sharedCPUSet, nil)
+}
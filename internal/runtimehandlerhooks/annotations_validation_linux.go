@@ -0,0 +1,167 @@
+package runtimehandlerhooks
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/utils/cpuset"
+
+	"github.com/cri-o/cri-o/internal/config/node"
+	crioann "github.com/cri-o/cri-o/pkg/annotations"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+)
+
+// ValidateHighPerformanceAnnotations returns an *AnnotationError if a high-performance annotation of the pod
+// has an invalid value, so the pod is rejected on creation instead of failing later in PreStart. If the cpus
+// of a container are given, it also checks that the node supports the tuning requested for them.
+// The annotations are ignored if the runtime handler is not bound to the high-performance hooks, and the
+// support of the node is not checked if the policy of its runtime type is not to tune the node.
+func ValidateHighPerformanceAnnotations(config *libconfig.Config, handler string, annotations map[string]string, cpus string) error {
+	if runtime := config.RuntimeHandlerOrDefault(handler); runtime != nil {
+		switch runtime.RuntimeHandlerHooks {
+		case libconfig.RuntimeHandlerHooksDefault, libconfig.RuntimeHandlerHooksNone:
+			return nil
+		}
+	}
+	if config.HighPerformancePolicyFor(handler) != libconfig.RuntimeTypeTuningTune {
+		// The node does not get tuned for the containers of the runtime handler.
+		cpus = ""
+	}
+	settings := config.HighPerformanceSettings()
+	return validateHighPerformanceAnnotations(annotations, cpus, sysCPUDir, disabledFeaturesOf(&settings))
+}
+
+func validateHighPerformanceAnnotations(annotations map[string]string, cpus, cpuDir string, disabled disabledFeatures) error {
+	var errs []error
+	for _, key := range slices.Sorted(maps.Keys(annotations)) {
+		value := annotations[key]
+		annotation, container, perContainer := strings.Cut(key, "/")
+		invalid := func(format string, args ...any) {
+			errs = append(errs, &AnnotationError{
+				Reason:     ReasonInvalidTuningAnnotation,
+				Annotation: key,
+				Value:      value,
+				Err:        fmt.Errorf(format, args...),
+			})
+		}
+		switch annotation {
+		case crioann.CPULoadBalancingAnnotation, crioann.CPUQuotaAnnotation, crioann.IRQLoadBalancingAnnotation:
+			if value != annotationTrue && value != annotationDisable && value != annotationEnable {
+				invalid("expected %q or %q", annotationDisable, annotationEnable)
+			}
+		case crioann.CPUCStatesAnnotation:
+			if _, err := convertAnnotationToLatency(value); err != nil {
+				invalid("expected %q, %q or \"max_latency:<microseconds>\"", annotationEnable, annotationDisable)
+			}
+		case crioann.CPUFreqGovernorAnnotation:
+			if value == "" || strings.ContainsAny(value, " \t\n/") {
+				invalid("expected the name of a cpufreq governor")
+			}
+		case crioann.CPUSharedAnnotation:
+			if !perContainer || container == "" {
+				invalid("expected the annotation to be suffixed with the container name")
+			} else if value != annotationEnable && value != annotationDisable {
+				invalid("expected %q or %q", annotationEnable, annotationDisable)
+			}
+		case crioann.CPUInitAffinityAnnotation:
+			if !perContainer || container == "" {
+				invalid("expected the annotation to be suffixed with the container name")
+			} else if value != annotationShared {
+				invalid("expected %q", annotationShared)
+			}
+		case crioann.PacketSteeringAnnotation, crioann.NAPIAffinityAnnotation:
+			if !perContainer || container == "" {
+				invalid("expected the annotation to be suffixed with the container name")
+			} else if value != packetSteeringContainer && value != packetSteeringHousekeeping {
+				invalid("expected %q or %q", packetSteeringContainer, packetSteeringHousekeeping)
+			}
+		case crioann.VFQueuesAnnotation, crioann.ARFSAnnotation, crioann.VFIRQAffinityAnnotation:
+			if !perContainer || container == "" {
+				invalid("expected the annotation to be suffixed with the container name")
+			} else if value != annotationEnable && value != annotationDisable {
+				invalid("expected %q or %q", annotationEnable, annotationDisable)
+			}
+		case crioann.AFXDPAnnotation:
+			if !perContainer || container == "" {
+				invalid("expected the annotation to be suffixed with the container name")
+			} else if queues, err := strconv.Atoi(value); err != nil || queues <= 0 {
+				invalid("expected a positive count of queues")
+			}
+		case crioann.InterruptCoalescingAnnotation:
+			if _, err := parseInterruptCoalescing(value); err != nil {
+				invalid("%w", err)
+			}
+		case crioann.QdiscAnnotation:
+			if !slices.Contains(podQdiscs, value) {
+				invalid("expected one of %q", podQdiscs)
+			}
+		case crioann.GROAnnotation:
+			if value != annotationEnable && value != annotationDisable {
+				invalid("expected %q or %q", annotationEnable, annotationDisable)
+			}
+		case crioann.NetdevBudgetAnnotation:
+			if _, err := parseNetdevBudget(value); err != nil {
+				invalid("%w", err)
+			}
+		case crioann.DPDKAnnotation:
+			if !perContainer || container == "" {
+				invalid("expected the annotation to be suffixed with the container name")
+			} else if value != annotationEnable && value != annotationDisable {
+				invalid("expected %q or %q", annotationEnable, annotationDisable)
+			}
+		case crioann.TuningInterfacesAnnotation:
+			if _, ok := parseTuningInterfaces(value); !ok {
+				invalid("expected a comma separated list of interface or network names")
+			}
+		case crioann.TuningVerificationAnnotation:
+			if timeout, err := time.ParseDuration(value); err != nil || timeout <= 0 {
+				invalid("expected a positive duration like \"10s\"")
+			}
+		}
+	}
+	if len(errs) > 0 || cpus == "" {
+		return errors.Join(errs...)
+	}
+
+	cpuSet, err := cpuset.Parse(cpus)
+	if err != nil {
+		return fmt.Errorf("failed to parse container cpus %q: %w", cpus, err)
+	}
+	unsupported := func(annotation string, err error) {
+		errs = append(errs, &AnnotationError{
+			Reason:     ReasonUnsupportedTuning,
+			Annotation: annotation,
+			Value:      annotations[annotation],
+			Err:        err,
+		})
+	}
+	if present, _ := shouldCStatesBeConfigured(annotations); present && !disabled.cStates {
+		for _, cpu := range cpuSet.List() {
+			if !fileExists(fmt.Sprintf("%s/cpu%d/power/pm_qos_resume_latency_us", cpuDir, cpu)) {
+				unsupported(crioann.CPUCStatesAnnotation, fmt.Errorf("cpu %d does not support PM QoS resume latencies", cpu))
+				break
+			}
+		}
+	}
+	if present, governor := shouldFreqGovernorBeConfigured(annotations); present && !disabled.freqGovernor {
+		for _, cpu := range cpuSet.List() {
+			if err := isCPUGovernorSupported(governor, cpuDir, cpu); err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					err = fmt.Errorf("cpu %d does not support frequency scaling", cpu)
+					if capErr := node.RequireCapability(node.CapabilityCPUFreq); capErr != nil {
+						err = capErr
+					}
+				}
+				unsupported(crioann.CPUFreqGovernorAnnotation, err)
+				break
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
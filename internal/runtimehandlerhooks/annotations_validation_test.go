@@ -0,0 +1,117 @@
+package runtimehandlerhooks
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	crioann "github.com/cri-o/cri-o/pkg/annotations"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+)
+
+var _ = Describe("validateHighPerformanceAnnotations", func() {
+	var cpuDir string
+
+	reasonOf := func(err error) string {
+		var annotationErr *AnnotationError
+		Expect(errors.As(err, &annotationErr)).To(BeTrue())
+		return annotationErr.Reason
+	}
+
+	BeforeEach(func() {
+		cpuDir = GinkgoT().TempDir()
+		for _, cpu := range []string{"cpu1", "cpu2"} {
+			Expect(os.MkdirAll(filepath.Join(cpuDir, cpu, "power"), 0o755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(cpuDir, cpu, "power", "pm_qos_resume_latency_us"), []byte("0"), 0o644)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(cpuDir, cpu, "cpufreq"), 0o755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(cpuDir, cpu, "cpufreq", "scaling_available_governors"), []byte("performance powersave\n"), 0o644)).To(Succeed())
+		}
+	})
+
+	It("should accept the valid annotations", func() {
+		annotations := map[string]string{
+			crioann.CPULoadBalancingAnnotation:         "disable",
+			crioann.CPUQuotaAnnotation:                 "true",
+			crioann.IRQLoadBalancingAnnotation:         "enable",
+			crioann.CPUCStatesAnnotation:               "max_latency:10",
+			crioann.CPUFreqGovernorAnnotation:          "performance",
+			crioann.CPUSharedAnnotation + "/ctr":       "enable",
+			crioann.CPUInitAffinityAnnotation + "/ctr": "shared",
+			crioann.TuningVerificationAnnotation:       "10s",
+			crioann.InterruptCoalescingAnnotation:      "rx-usecs=0,tx-usecs=8",
+			crioann.AFXDPAnnotation + "/ctr":           "2",
+			crioann.TuningInterfacesAnnotation:         "default,sriov-net",
+			crioann.QdiscAnnotation:                    "fq",
+			crioann.NetdevBudgetAnnotation:             "budget=600",
+			crioann.GROAnnotation:                      "disable",
+			"unrelated":                                "value",
+		}
+
+		Expect(validateHighPerformanceAnnotations(annotations, "1-2", cpuDir, disabledFeatures{})).To(Succeed())
+	})
+
+	DescribeTable("should reject the invalid values",
+		func(annotation, value string) {
+			err := validateHighPerformanceAnnotations(map[string]string{annotation: value}, "", cpuDir, disabledFeatures{})
+
+			Expect(err).To(HaveOccurred())
+			Expect(reasonOf(err)).To(Equal(ReasonInvalidTuningAnnotation))
+		},
+		Entry("cpu load balancing", crioann.CPULoadBalancingAnnotation, "false"),
+		Entry("c-states", crioann.CPUCStatesAnnotation, "max_latency:0"),
+		Entry("governor", crioann.CPUFreqGovernorAnnotation, "../performance"),
+		Entry("shared cpus without container", crioann.CPUSharedAnnotation, "enable"),
+		Entry("shared cpus", crioann.CPUSharedAnnotation+"/ctr", "yes"),
+		Entry("init affinity", crioann.CPUInitAffinityAnnotation+"/ctr", "exclusive"),
+		Entry("packet steering without container", crioann.PacketSteeringAnnotation, "container"),
+		Entry("packet steering", crioann.PacketSteeringAnnotation+"/ctr", "isolated"),
+		Entry("VF queues without container", crioann.VFQueuesAnnotation, "enable"),
+		Entry("VF queues", crioann.VFQueuesAnnotation+"/ctr", "true"),
+		Entry("accelerated RFS without container", crioann.ARFSAnnotation, "enable"),
+		Entry("NAPI affinity", crioann.NAPIAffinityAnnotation+"/ctr", "isolated"),
+		Entry("VF IRQ affinity", crioann.VFIRQAffinityAnnotation+"/ctr", "on"),
+		Entry("AF_XDP without container", crioann.AFXDPAnnotation, "2"),
+		Entry("AF_XDP without queue", crioann.AFXDPAnnotation+"/ctr", "0"),
+		Entry("interrupt coalescing without usecs", crioann.InterruptCoalescingAnnotation, "rx-usecs"),
+		Entry("interrupt coalescing with unknown parameter", crioann.InterruptCoalescingAnnotation, "rx-frames=1"),
+		Entry("unknown qdisc", crioann.QdiscAnnotation, "fq_codel"),
+		Entry("GRO off", crioann.GROAnnotation, "off"),
+		Entry("zero netdev budget", crioann.NetdevBudgetAnnotation, "budget=0"),
+		Entry("DPDK without container", crioann.DPDKAnnotation, "enable"),
+		Entry("empty tuning interface", crioann.TuningInterfacesAnnotation, "net1,,net2"),
+		Entry("tuning verification", crioann.TuningVerificationAnnotation, "10"),
+		Entry("negative tuning verification", crioann.TuningVerificationAnnotation, "-1s"),
+	)
+
+	It("should reject the unsupported governors of the container cpus", func() {
+		err := validateHighPerformanceAnnotations(map[string]string{crioann.CPUFreqGovernorAnnotation: "ondemand"}, "1-2", cpuDir, disabledFeatures{})
+
+		Expect(err).To(MatchError("annotation cpu-freq-governor.crio.io=\"ondemand\": governor ondemand not available for cpu 1"))
+		Expect(reasonOf(err)).To(Equal(ReasonUnsupportedTuning))
+	})
+
+	It("should reject the c-states of the cpus without PM QoS", func() {
+		Expect(os.Remove(filepath.Join(cpuDir, "cpu2", "power", "pm_qos_resume_latency_us"))).To(Succeed())
+
+		err := validateHighPerformanceAnnotations(map[string]string{crioann.CPUCStatesAnnotation: "disable"}, "1-2", cpuDir, disabledFeatures{})
+
+		Expect(err).To(MatchError(ContainSubstring("cpu 2 does not support PM QoS resume latencies")))
+		Expect(reasonOf(err)).To(Equal(ReasonUnsupportedTuning))
+	})
+
+	It("should not check the support of the disabled features", func() {
+		annotations := map[string]string{crioann.CPUFreqGovernorAnnotation: "ondemand"}
+
+		Expect(validateHighPerformanceAnnotations(annotations, "1-2", cpuDir, disabledFeatures{freqGovernor: true})).To(Succeed())
+	})
+
+	It("should ignore the annotations of the runtime handlers without high-performance hooks", func() {
+		config := &libconfig.Config{}
+		config.Runtimes = libconfig.Runtimes{"runc": {RuntimeHandlerHooks: libconfig.RuntimeHandlerHooksNone}}
+
+		Expect(ValidateHighPerformanceAnnotations(config, "runc", map[string]string{crioann.CPUQuotaAnnotation: "false"}, "")).To(Succeed())
+	})
+})
@@ -0,0 +1,167 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/internal/oci"
+	crioannotations "github.com/cri-o/cri-o/pkg/annotations"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+)
+
+const (
+	rpsFlowCntFile = "rps_flow_cnt"
+	// rpsSockFlowEntriesFile is the size of the global flow table of RFS, which is disabled if zero.
+	rpsSockFlowEntriesFile = "/proc/sys/net/core/rps_sock_flow_entries"
+	// rfsDeviceFlowEntries are the flows RFS tracks for a device, spread over its receive queues.
+	rfsDeviceFlowEntries = 32768
+	// rfsSockFlowEntries is the minimal size of the global flow table of RFS for the flows of the pods to get steered.
+	rfsSockFlowEntries = 32768
+)
+
+// steerPodFlows enables accelerated RFS on the interfaces of the network namespace of the pod, so that their flows
+// get steered to the CPUs the threads consuming them run on: in hardware with the ntuple filters of the devices
+// supporting them, by RFS in software otherwise.
+func (h *HighPerformanceHooks) steerPodFlows(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	if s.HostNetwork() || s.NetNsPath() == "" {
+		log.Warnf(ctx, "Accelerated RFS requested for container %q of a pod on the host network, ignoring", c.ID())
+		noteUnfulfilledAnnotation(ctx, crioannotations.ARFSAnnotation, ReasonHostNetwork)
+		return nil
+	}
+	network, err := sandboxNetwork(s)
+	if err != nil {
+		return err
+	}
+	log.Infof(ctx, "Steer the flows of the interfaces of the pod of container %q to the CPUs consuming them", c.ID())
+	return setARFS(ctx, c.ID(), network)
+}
+
+// setARFS holds the global flow table of RFS at its size for the container, turns the ntuple filters on and programs
+// the RFS flow count of the receive queues of the interfaces of the pod network, recording the writes for the
+// container. The devices without ntuple filters, like the veth ones, and the queues of kernels built without
+// RFS are skipped.
+func setARFS(ctx context.Context, containerID string, network podNetwork) error {
+	size, err := sockFlowEntries()
+	if err != nil {
+		return fmt.Errorf("read size of the RFS flow table: %w", err)
+	}
+	if err := acquireNodeSysctl(ctx, containerID, rpsSockFlowEntriesFile, size); err != nil {
+		return fmt.Errorf("size the RFS flow table: %w", err)
+	}
+	interfaces, err := network.interfaces()
+	if err != nil {
+		return err
+	}
+	for _, iface := range interfaces {
+		if iface.NetNS == "" {
+			continue
+		}
+		err := writeNetTuningFile(ctx, containerID, iface.NetNS, ethtoolSettingFile(iface.Name, ethtoolNtuple), []byte("on"))
+		if errors.Is(err, errors.ErrUnsupported) {
+			log.Debugf(ctx, "Interface %s has no ntuple filters, steering its flows in software", iface)
+		} else if err != nil {
+			return fmt.Errorf("enable ntuple filters of interface %s: %w", iface, err)
+		}
+		queues, err := rxQueues(iface)
+		if err != nil {
+			return fmt.Errorf("list queues of interface %s: %w", iface, err)
+		}
+		flowCnt := []byte(strconv.Itoa(rfsDeviceFlowEntries / max(len(queues), 1)))
+		for _, queue := range queues {
+			err := writeNetTuningFile(ctx, containerID, iface.NetNS, netDeviceFile(iface.Name, "queues", queue, rpsFlowCntFile), flowCnt)
+			if errors.Is(err, os.ErrNotExist) {
+				log.Debugf(ctx, "Queue %s of interface %s has no %s, skipping", queue, iface, rpsFlowCntFile)
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("set RFS flow count of interface %s: %w", iface, err)
+			}
+		}
+	}
+	return nil
+}
+
+// sockFlowEntries returns the size of the global flow table of RFS to hold, at least rfsSockFlowEntries, leaving
+// a larger table to the node. The table is shared by all the containers steering their flows, so they all
+// hold it at the same size as long as it does not get changed behind their back.
+func sockFlowEntries() (string, error) {
+	content, err := hostFS.ReadFile(rpsSockFlowEntriesFile)
+	if err != nil {
+		return "", err
+	}
+	current, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(max(current, rfsSockFlowEntries)), nil
+}
+
+// planARFS returns the size of the RFS flow table, the RFS flow counts and the ntuple filters setARFS would
+// program for the container.
+func planARFS(network podNetwork) ([]plannedChange, error) {
+	size, err := sockFlowEntries()
+	if err != nil {
+		return nil, err
+	}
+	changes := []plannedChange{{
+		Feature: libconfig.HighPerformanceFeatureARFS,
+		Path:    rpsSockFlowEntriesFile,
+		Value:   size,
+	}}
+	interfaces, err := network.interfaces()
+	if err != nil {
+		return nil, err
+	}
+	for _, iface := range interfaces {
+		if iface.NetNS == "" {
+			continue
+		}
+		changes = append(changes, plannedChange{
+			Feature: libconfig.HighPerformanceFeatureARFS,
+			Path:    ethtoolSettingFile(iface.Name, ethtoolNtuple),
+			Value:   "on",
+		})
+		queues, err := rxQueues(iface)
+		if err != nil {
+			return nil, err
+		}
+		for _, queue := range queues {
+			changes = append(changes, plannedChange{
+				Feature: libconfig.HighPerformanceFeatureARFS,
+				Path:    netDeviceFile(iface.Name, "queues", queue, rpsFlowCntFile),
+				Value:   strconv.Itoa(rfsDeviceFlowEntries / len(queues)),
+			})
+		}
+	}
+	return changes, nil
+}
+
+// rxQueues returns the receive queues of the network device.
+func rxQueues(iface podInterface) ([]string, error) {
+	queues, err := netDeviceQueues(iface)
+	if err != nil {
+		return nil, err
+	}
+	var rx []string
+	for _, queue := range queues {
+		if strings.HasPrefix(queue, "rx-") {
+			rx = append(rx, queue)
+		}
+	}
+	return rx, nil
+}
+
+// revertARFS restores the RFS flow counts and ntuple filters programmed for the container, and releases its hold
+// of the RFS flow table, which gets restored once no other container holds it.
+func revertARFS(ctx context.Context, containerID string) error {
+	if err := restoreNetDeviceTuning(ctx, containerID, rpsFlowCntFile, ethtoolNtuple); err != nil {
+		return err
+	}
+	return releaseNodeSysctls(ctx, containerID)
+}
@@ -0,0 +1,124 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+
+	crioann "github.com/cri-o/cri-o/pkg/annotations"
+)
+
+var _ = Describe("accelerated RFS", func() {
+	const (
+		netns       = "/var/run/netns/pod"
+		containerID = "ctr1"
+	)
+	var (
+		dir     string
+		fs      *fakeHostFS
+		ethtool fakeEthtool
+	)
+
+	flowCntFile := func(device, queue string) string {
+		return filepath.Join(dir, "class", "net", device, "queues", queue, rpsFlowCntFile)
+	}
+	readFlowCnt := func(device, queue string) string {
+		return strings.TrimSpace(fs.content(flowCntFile(device, queue)))
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		ethtool = fakeEthtool{
+			// veth devices have no ntuple filters
+			"eth0": {},
+			"net1": {ethtoolNtuple: "off"},
+		}
+		useFakePodNetwork([]podInterface{{Name: "eth0", NetNS: netns}, {Name: "net1", NetNS: netns}, {Name: "veth1234"}}, map[string]string{netns: dir}, ethtool)
+		for _, queue := range []string{"eth0/queues/rx-0", "net1/queues/rx-0", "net1/queues/rx-1", "net1/queues/tx-0"} {
+			Expect(os.MkdirAll(filepath.Join(dir, "class", "net", queue), 0o755)).To(Succeed())
+		}
+		// the queues are listed from the disk, their files are faked along with the RFS flow table of the node
+		fs = useFakeHostFS(map[string]string{
+			rpsSockFlowEntriesFile:      "0\n",
+			flowCntFile("eth0", "rx-0"): "0\n",
+			flowCntFile("net1", "rx-0"): "0\n",
+			flowCntFile("net1", "rx-1"): "0\n",
+		})
+	})
+
+	AfterEach(func() {
+		forgetAppliedTuning(context.TODO(), containerID)
+		forgetAppliedTuning(context.TODO(), "ctr2")
+	})
+
+	It("should program and revert the flow steering of the pod interfaces", func() {
+		Expect(setARFS(context.TODO(), containerID, podNetwork{NetNS: netns})).To(Succeed())
+
+		Expect(fs.files[rpsSockFlowEntriesFile]).To(Equal("32768"))
+		Expect(ethtool["net1"][ethtoolNtuple]).To(Equal("on"))
+		Expect(readFlowCnt("eth0", "rx-0")).To(Equal("32768"))
+		Expect(readFlowCnt("net1", "rx-0")).To(Equal("16384"))
+		Expect(readFlowCnt("net1", "rx-1")).To(Equal("16384"))
+
+		Expect(revertARFS(context.TODO(), containerID)).To(Succeed())
+
+		Expect(fs.files[rpsSockFlowEntriesFile]).To(Equal("0"))
+		Expect(ethtool["net1"][ethtoolNtuple]).To(Equal("off"))
+		Expect(readFlowCnt("eth0", "rx-0")).To(Equal("0"))
+		Expect(readFlowCnt("net1", "rx-1")).To(Equal("0"))
+	})
+
+	It("should hold the RFS flow table until the last container steering its flows stops", func() {
+		Expect(setARFS(context.TODO(), containerID, podNetwork{NetNS: netns})).To(Succeed())
+		Expect(setARFS(context.TODO(), "ctr2", podNetwork{NetNS: netns})).To(Succeed())
+		Expect(recordedHolds(rpsSockFlowEntriesFile)).To(HaveLen(2))
+
+		Expect(revertARFS(context.TODO(), containerID)).To(Succeed())
+
+		Expect(fs.files[rpsSockFlowEntriesFile]).To(Equal("32768"))
+		Expect(recordedHolds(rpsSockFlowEntriesFile)).To(HaveKey("ctr2"))
+
+		Expect(revertARFS(context.TODO(), "ctr2")).To(Succeed())
+
+		Expect(fs.files[rpsSockFlowEntriesFile]).To(Equal("0"))
+		Expect(recordedHolds(rpsSockFlowEntriesFile)).To(BeEmpty())
+	})
+
+	It("should keep holding the RFS flow table for a container whose CPUs got updated", func() {
+		c := newTestContainer(containerID, containerID, "sandboxID")
+		shares := uint64(2048)
+		c.SetSpec(&specs.Spec{Linux: &specs.Linux{Resources: &specs.LinuxResources{
+			CPU: &specs.LinuxCPU{Cpus: "2-3", Shares: &shares},
+		}}})
+		sb := newTestSandbox("sandboxID", map[string]string{crioann.ARFSAnnotation + "/" + containerID: annotationEnable})
+		Expect(setARFS(context.TODO(), containerID, podNetwork{NetNS: netns})).To(Succeed())
+		Expect(setARFS(context.TODO(), "ctr2", podNetwork{NetNS: netns})).To(Succeed())
+		h := &HighPerformanceHooks{}
+
+		Expect(h.PreUpdate(context.TODO(), c, sb, &specs.LinuxResources{CPU: &specs.LinuxCPU{Cpus: "4-5"}})).To(Succeed())
+		Expect(revertARFS(context.TODO(), "ctr2")).To(Succeed())
+
+		Expect(fs.files[rpsSockFlowEntriesFile]).To(Equal("32768"))
+		Expect(recordedHolds(rpsSockFlowEntriesFile)).To(HaveKey(containerID))
+
+		Expect(h.PreStop(context.TODO(), c, sb)).To(Succeed())
+
+		Expect(fs.files[rpsSockFlowEntriesFile]).To(Equal("0"))
+		Expect(readFlowCnt("eth0", "rx-0")).To(Equal("0"))
+		Expect(recordedHolds(rpsSockFlowEntriesFile)).To(BeEmpty())
+	})
+
+	It("should leave a larger RFS flow table of the node", func() {
+		fs.files[rpsSockFlowEntriesFile] = "65536"
+
+		Expect(setARFS(context.TODO(), containerID, podNetwork{NetNS: netns})).To(Succeed())
+		Expect(revertARFS(context.TODO(), containerID)).To(Succeed())
+
+		Expect(fs.files[rpsSockFlowEntriesFile]).To(Equal("65536"))
+	})
+})
@@ -0,0 +1,117 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"time"
+
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"golang.org/x/sys/unix"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// cgroupWriteBackoff bounds the retries of the transiently failing cgroup writes, about 150ms in total.
+var cgroupWriteBackoff = wait.Backoff{
+	Duration: 10 * time.Millisecond,
+	Factor:   2,
+	Steps:    5,
+}
+
+// isTransientCgroupError returns true for the errors of a cgroup write racing with systemd
+// re-creating or populating the scope of the container, or interrupted by a signal, which
+// should go away on a retry.
+func isTransientCgroupError(err error) bool {
+	return errors.Is(err, unix.EBUSY) || errors.Is(err, unix.EAGAIN) || errors.Is(err, unix.ENOENT) ||
+		errors.Is(err, unix.EINTR)
+}
+
+// retryCgroupWrite runs the write of the cgroup at path, retrying it with backoff while it fails transiently.
+// A failure is returned as a *CgroupWriteError, telling whether it was still transient when the retries ran out.
+func retryCgroupWrite(path string, write func() error) error {
+	var lastErr error
+	if err := wait.ExponentialBackoff(cgroupWriteBackoff, func() (bool, error) {
+		lastErr = write()
+		if lastErr != nil && !isTransientCgroupError(lastErr) {
+			return false, lastErr
+		}
+		return lastErr == nil, nil
+	}); err != nil {
+		return &CgroupWriteError{Path: path, Transient: isTransientCgroupError(lastErr), Err: lastErr}
+	}
+	return nil
+}
+
+// writeCgroupFile writes data to the file of the cgroup dir, retrying the transient failures.
+// The write is recorded to the tuning audit log, if any. It is meant for the cgroups the cgroup manager
+// does not address, like the parents of the exclusive cpuset chain or the isolated child cgroup created
+// by the hooks, while the files of the container cgroup are written with writeContainerCgroupFile.
+func writeCgroupFile(ctx context.Context, dir, file, data string) error {
+	path := filepath.Join(dir, file)
+	read := func() (string, error) {
+		return hostFS.ReadCgroupFile(dir, file)
+	}
+	return auditedWrite(ctx, path, data, read, func() error {
+		return retryCgroupWrite(path, func() error {
+			return hostFS.WriteCgroupFile(dir, file, data)
+		})
+	})
+}
+
+// writeContainerCgroupFile writes data to the file of the controller of the container cgroup through the cgroup
+// manager matching the sandbox cgroup parent, which resolves the path of the cgroup for its driver and writes the
+// cgroup the OCI runtime delegated the container to as well. The transient failures are retried, and the write
+// is recorded to the tuning audit log, if any.
+func writeContainerCgroupFile(ctx context.Context, containerID, parentDir, controller, file, data string) error {
+	cgroupManager := cgroupManagerForParent(parentDir)
+	cgroupPath, err := cgroupManager.ContainerCgroupAbsolutePath(parentDir, containerID)
+	if err != nil {
+		return err
+	}
+	cgroupDir, err := cgroupManager.ContainerCgroupDir(parentDir, containerID, controller)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(cgroupDir, file)
+	read := func() (string, error) {
+		return cgroupManager.ReadCgroupFile(cgroupPath, controller, file)
+	}
+	return auditedWrite(ctx, path, data, read, func() error {
+		return retryCgroupWrite(path, func() error {
+			return cgroupManager.WriteCgroupFile(cgroupPath, controller, file, data)
+		})
+	})
+}
+
+// setSystemdContainerCPUs sets the CPUs of the cpuset of the container cgroup through the AllowedCPUs property
+// of its scope when the sandbox cgroup parent is a systemd slice, so that a daemon reload of systemd does not
+// revert them, and does nothing otherwise. The write is recorded to the tuning audit log, if any.
+func setSystemdContainerCPUs(ctx context.Context, containerID, parentDir, cpus string) error {
+	cgroupManager := cgroupManagerForParent(parentDir)
+	if !cgroupManager.IsSystemd() {
+		return nil
+	}
+	cgroupPath, err := cgroupManager.ContainerCgroupAbsolutePath(parentDir, containerID)
+	if err != nil {
+		return err
+	}
+	cgroupDir, err := cgroupManager.ContainerCgroupDir(parentDir, containerID, "cpuset")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(cgroupDir, cpusetCpus)
+	return auditedWrite(ctx, path, cpus, nil, func() error {
+		return cgroupManager.SetCgroupCpuset(cgroupPath, cpus, "")
+	})
+}
+
+// setCgroupResources sets the resources of the cgroup through its manager, retrying the transient failures.
+// The write is recorded to the tuning audit log, if any, without the former resources of the cgroup.
+func setCgroupResources(ctx context.Context, mgr cgroups.Manager, resources *configs.Resources) error {
+	return auditedWrite(ctx, mgr.Path(""), resourcesSummary(resources), nil, func() error {
+		return retryCgroupWrite(mgr.Path(""), func() error {
+			return mgr.Set(resources)
+		})
+	})
+}
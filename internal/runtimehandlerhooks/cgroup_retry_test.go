@@ -0,0 +1,99 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"golang.org/x/sys/unix"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+var _ = Describe("retryCgroupWrite", func() {
+	var attempts int
+
+	BeforeEach(func() {
+		attempts = 0
+		backoff := cgroupWriteBackoff
+		cgroupWriteBackoff = wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 3}
+		DeferCleanup(func() {
+			cgroupWriteBackoff = backoff
+		})
+	})
+
+	failing := func(errs ...error) func() error {
+		return func() error {
+			attempts++
+			if attempts > len(errs) {
+				return nil
+			}
+			return errs[attempts-1]
+		}
+	}
+
+	It("should retry the transient failures", func() {
+		err := retryCgroupWrite("cgroup", failing(unix.EBUSY, fmt.Errorf("failed to write: %w", unix.EAGAIN)))
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(attempts).To(Equal(3))
+	})
+
+	It("should retry the writes interrupted by a signal or racing with a recreated scope", func() {
+		err := retryCgroupWrite("cgroup", failing(unix.EINTR, &os.PathError{Op: "openat", Path: "cgroup", Err: unix.ENOENT}))
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(attempts).To(Equal(3))
+	})
+
+	It("should not retry the permanent failures", func() {
+		err := retryCgroupWrite("cgroup", failing(unix.EINVAL))
+
+		var cgroupErr *CgroupWriteError
+		Expect(errors.As(err, &cgroupErr)).To(BeTrue())
+		Expect(cgroupErr.Transient).To(BeFalse())
+		Expect(err).To(MatchError(unix.EINVAL))
+		Expect(attempts).To(Equal(1))
+	})
+
+	It("should report the transient failures persisting after the retries", func() {
+		err := retryCgroupWrite("cgroup", failing(unix.EBUSY, unix.EBUSY, unix.EBUSY, unix.EBUSY))
+
+		var cgroupErr *CgroupWriteError
+		Expect(errors.As(err, &cgroupErr)).To(BeTrue())
+		Expect(cgroupErr.Transient).To(BeTrue())
+		Expect(cgroupErr.Path).To(Equal("cgroup"))
+		Expect(err).To(MatchError(unix.EBUSY))
+		Expect(attempts).To(Equal(3))
+	})
+
+	It("should retry the writes of the cgroup manager", func() {
+		write := failing(unix.EINTR, unix.EBUSY)
+		mgr := &fakeCgroupManager{path: "/sys/fs/cgroup/pod/ctr1", set: func(*configs.Resources) error {
+			return write()
+		}}
+
+		Expect(setCgroupResources(context.TODO(), mgr, &configs.Resources{CpuQuota: 1000})).To(Succeed())
+		Expect(attempts).To(Equal(3))
+	})
+})
+
+// fakeCgroupManager is a cgroups.Manager whose Set is replaced, the other methods being unimplemented.
+type fakeCgroupManager struct {
+	cgroups.Manager
+	path string
+	set  func(*configs.Resources) error
+}
+
+func (m *fakeCgroupManager) Path(string) string {
+	return m.path
+}
+
+func (m *fakeCgroupManager) Set(r *configs.Resources) error {
+	return m.set(r)
+}
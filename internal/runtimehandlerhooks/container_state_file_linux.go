@@ -0,0 +1,135 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/google/renameio"
+	"k8s.io/utils/cpuset"
+
+	"github.com/cri-o/cri-o/internal/log"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+)
+
+// containerStateFileName is the name of the tuning state file in the directory of a container.
+const containerStateFileName = "tuning.json"
+
+// containerStateFiles configures the tuning state files written for the node-local agents.
+// The hooks are instantiated per request, so it is kept at package level.
+var containerStateFiles = struct {
+	sync.Mutex
+	// dir is the directory holding a directory per tuned container, empty if the files are not written.
+	dir string
+}{}
+
+// WriteContainerStateFiles writes the tuning of every tuned container to <dir>/<container-id>/tuning.json from now
+// on, so that node-local agents can consume it without talking to CRI-O, or stops writing them if dir is empty.
+func WriteContainerStateFiles(dir string) {
+	containerStateFiles.Lock()
+	defer containerStateFiles.Unlock()
+	containerStateFiles.dir = dir
+}
+
+// containerStateFile returns the path of the tuning state file of the container, empty if it is not written.
+func containerStateFile(containerID string) string {
+	containerStateFiles.Lock()
+	defer containerStateFiles.Unlock()
+	if containerStateFiles.dir == "" {
+		return ""
+	}
+	return filepath.Join(containerStateFiles.dir, containerID, containerStateFileName)
+}
+
+// currentContainerTuningState returns the state of the tuning applied to the container, nil if it did not get tuned.
+func currentContainerTuningState(containerID string) *ContainerTuningState {
+	record, ok := recordedTuning(containerID)
+	if !ok || record.Tuning == nil {
+		return nil
+	}
+	t := record.Tuning
+	state := &ContainerTuningState{
+		Version:       ContainerTuningStateVersion,
+		ContainerID:   containerID,
+		ExclusiveCPUs: t.CPUs,
+		SharedCPUs:    containerSharedCPUs(containerID).String(),
+		Tunings:       []string{},
+	}
+	if t.CPULoadBalancingDisabled {
+		state.IsolatedCPUs = t.CPUs
+	}
+	for feature, tuned := range map[string]bool{
+		libconfig.HighPerformanceFeatureCPULoadBalancing: t.CPULoadBalancingDisabled,
+		libconfig.HighPerformanceFeatureIRQLoadBalancing: t.IRQLoadBalancingDisabled,
+		libconfig.HighPerformanceFeatureCPUQuota:         t.CPUQuotaDisabled,
+		libconfig.HighPerformanceFeatureCPUCStates:       t.CStates != nil && *t.CStates != annotationEnable,
+		libconfig.HighPerformanceFeatureCPUFreqGovernor:  t.FreqGovernor != nil,
+		libconfig.HighPerformanceFeaturePacketSteering:   t.PacketSteering != nil,
+		libconfig.HighPerformanceFeatureVFQueues:         t.VFQueues,
+		libconfig.HighPerformanceFeatureARFS:             t.ARFS,
+		libconfig.HighPerformanceFeatureVFIRQAffinity:    t.VFIRQAffinity,
+		libconfig.HighPerformanceFeatureAFXDP:            t.AFXDPQueues > 0,
+		libconfig.HighPerformanceFeatureNAPIAffinity:     t.NAPIAffinity != nil,
+		planFeatureSharedCPUs:                            t.SharedCPUs,
+	} {
+		if tuned {
+			state.Tunings = append(state.Tunings, feature)
+		}
+	}
+	slices.Sort(state.Tunings)
+	return state
+}
+
+// containerSharedCPUs returns the shared CPUs the container consumes, empty if it does not consume any.
+func containerSharedCPUs(containerID string) cpuset.CPUSet {
+	sharedCPUsConsumers.Lock()
+	defer sharedCPUsConsumers.Unlock()
+	for _, sb := range sharedCPUsConsumers.sandboxes {
+		if _, ok := sb.containers[containerID]; ok {
+			return sb.sharedCPUs
+		}
+	}
+	return cpuset.New()
+}
+
+// writeContainerStateFile writes the tuning state file of the container, or removes it if the container
+// is not tuned anymore. The file gets replaced atomically, so the agents never read a partial state.
+func writeContainerStateFile(ctx context.Context, containerID string) {
+	file := containerStateFile(containerID)
+	if file == "" {
+		return
+	}
+	state := currentContainerTuningState(containerID)
+	if state == nil {
+		removeContainerStateFile(ctx, file)
+		return
+	}
+	content, err := json.Marshal(state)
+	if err != nil {
+		log.Warnf(ctx, "Failed to marshal the tuning state of container %q: %v", containerID, err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		log.Warnf(ctx, "Failed to create the state directory of container %q: %v", containerID, err)
+		return
+	}
+	if err := renameio.WriteFile(file, content, 0o644); err != nil {
+		log.Warnf(ctx, "Failed to write the tuning state file of container %q: %v", containerID, err)
+	}
+}
+
+// removeContainerStateFile removes the tuning state file, along with the directory of the container
+// unless something else is left in it.
+func removeContainerStateFile(ctx context.Context, file string) {
+	if err := os.Remove(file); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Warnf(ctx, "Failed to remove the tuning state file %s: %v", file, err)
+		}
+		return
+	}
+	_ = os.Remove(filepath.Dir(file))
+}
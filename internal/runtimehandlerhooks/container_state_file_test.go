@@ -0,0 +1,74 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/cpuset"
+)
+
+var _ = Describe("WriteContainerStateFiles", func() {
+	const sandboxID = "sandbox"
+	var dir string
+
+	readState := func() *ContainerTuningState {
+		content, err := os.ReadFile(filepath.Join(dir, "ctr1", containerStateFileName))
+		Expect(err).ToNot(HaveOccurred())
+		state := &ContainerTuningState{}
+		Expect(json.Unmarshal(content, state)).To(Succeed())
+		return state
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		WriteContainerStateFiles(dir)
+		DeferCleanup(func() {
+			forgetAppliedTuning(context.TODO(), "ctr1")
+			WriteContainerStateFiles("")
+			sharedCPUsConsumers.Lock()
+			delete(sharedCPUsConsumers.sandboxes, sandboxID)
+			sharedCPUsConsumers.Unlock()
+		})
+	})
+
+	It("should write the tuning of the container once applied", func() {
+		governor := "performance"
+		addSharedCPUsConsumer(sandboxID, "ctr1", cpuset.New(1, 2), cpuset.New(0))
+		recordAppliedTuning(context.TODO(), "ctr1", &tuning{
+			CPUs:                     "1-2",
+			SharedCPUs:               true,
+			CPULoadBalancingDisabled: true,
+			IRQLoadBalancingDisabled: true,
+			FreqGovernor:             &governor,
+		})
+
+		Expect(readState()).To(Equal(&ContainerTuningState{
+			Version:       ContainerTuningStateVersion,
+			ContainerID:   "ctr1",
+			ExclusiveCPUs: "1-2",
+			IsolatedCPUs:  "1-2",
+			SharedCPUs:    "0",
+			Tunings:       []string{"cpu-freq-governor", "cpu-load-balancing", "irq-load-balancing", "shared-cpus"},
+		}))
+	})
+
+	It("should remove the file and the directory of the container once its tuning is reverted", func() {
+		recordAppliedTuning(context.TODO(), "ctr1", &tuning{CPUs: "1-2"})
+		Expect(readState().Tunings).To(BeEmpty())
+
+		forgetAppliedTuning(context.TODO(), "ctr1")
+
+		Expect(filepath.Join(dir, "ctr1")).ToNot(BeADirectory())
+	})
+
+	It("should not write the file if disabled", func() {
+		WriteContainerStateFiles("")
+		recordAppliedTuning(context.TODO(), "ctr1", &tuning{CPUs: "1-2"})
+
+		Expect(filepath.Join(dir, "ctr1")).ToNot(BeADirectory())
+	})
+})
@@ -0,0 +1,127 @@
+package runtimehandlerhooks
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"k8s.io/utils/cpuset"
+
+	"github.com/cri-o/cri-o/internal/config/node"
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	"github.com/cri-o/cri-o/internal/oci"
+)
+
+// debugSnapshotRecordFile is the name of the tuning record of the container in its debug snapshot.
+const debugSnapshotRecordFile = "tuning-record.json"
+
+// The cgroup files of the container bundled in its debug snapshot, keyed by controller on cgroup v1.
+var (
+	debugSnapshotCgroupV2Files = []string{
+		"cpuset.cpus", "cpuset.cpus.effective", "cpuset.cpus.partition", "cpuset.mems",
+		"cpu.max", "cpu.weight", "cpu.stat",
+		"memory.max", "memory.current", "memory.stat",
+	}
+	debugSnapshotCgroupV1Files = map[string][]string{
+		"cpuset": {"cpuset.cpus", "cpuset.effective_cpus", "cpuset.sched_load_balance", "cpuset.mems"},
+		"cpu":    {"cpu.cfs_quota_us", "cpu.cfs_period_us", "cpu.shares", "cpu.stat"},
+		"memory": {"memory.limit_in_bytes", "memory.usage_in_bytes", "memory.stat"},
+	}
+)
+
+// WriteDebugSnapshot writes to w a gzipped tar archive of the state of the container relevant to its tuning,
+// to be attached to bug reports: the cpuset, cpu and memory files of its cgroup and of the child cgroups created
+// by crun and for the isolated CPUs, the per-CPU sysfs files tuned by the hooks for its CPUs, the IRQ affinity
+// mask and its tuning record. The files are archived under their path on the node, and the ones which do not
+// exist, like the files of another cgroup version or of features the container did not request, are skipped.
+func WriteDebugSnapshot(w io.Writer, c *oci.Container, s *sandbox.Sandbox) error {
+	containerCgroup, err := cgroupManagerForParent(s.CgroupParent()).ContainerCgroupAbsolutePath(s.CgroupParent(), c.ID())
+	if err != nil {
+		return fmt.Errorf("get container cgroup: %w", err)
+	}
+	files := debugSnapshotCgroupFiles(containerCgroup)
+	if cSpec := c.Spec(); !isContainerCPUsSpecEmpty(&cSpec) {
+		cpus, err := cpuset.Parse(cSpec.Linux.Resources.CPU.Cpus)
+		if err != nil {
+			return fmt.Errorf("parse container cpus: %w", err)
+		}
+		for _, cpu := range cpus.List() {
+			files = append(files,
+				fmt.Sprintf("%s/cpu%d/cpufreq/scaling_governor", sysCPUDir, cpu),
+				fmt.Sprintf("%s/cpu%d/power/pm_qos_resume_latency_us", sysCPUDir, cpu),
+			)
+		}
+	}
+	files = append(files, IrqSmpAffinityProcFile)
+
+	now := time.Now()
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, file := range files {
+		content, err := hostFS.ReadFile(file)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("read %s: %w", file, err)
+		}
+		if err := writeDebugSnapshotEntry(tw, strings.TrimPrefix(file, "/"), content, now); err != nil {
+			return err
+		}
+	}
+	if record, ok := recordedTuning(c.ID()); ok {
+		content, err := json.MarshalIndent(record, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := writeDebugSnapshotEntry(tw, debugSnapshotRecordFile, content, now); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// debugSnapshotCgroupFiles returns the cgroup files of the container bundled in its debug snapshot.
+func debugSnapshotCgroupFiles(containerCgroup string) []string {
+	dirs := []string{containerCgroup, filepath.Join(containerCgroup, "container"), filepath.Join(containerCgroup, isolatedChildCgroup)}
+	files := []string{}
+	for _, dir := range dirs {
+		if node.CgroupIsV2() {
+			for _, file := range debugSnapshotCgroupV2Files {
+				files = append(files, filepath.Join(cgroupMountPoint, dir, file))
+			}
+			continue
+		}
+		for _, controller := range []string{"cpuset", "cpu", "memory"} {
+			for _, file := range debugSnapshotCgroupV1Files[controller] {
+				files = append(files, filepath.Join(cgroupMountPoint, controller, dir, file))
+			}
+		}
+	}
+	return files
+}
+
+func writeDebugSnapshotEntry(tw *tar.Writer, name string, content []byte, modTime time.Time) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(content)),
+		ModTime: modTime,
+	}); err != nil {
+		return fmt.Errorf("write %s to the snapshot: %w", name, err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		return fmt.Errorf("write %s to the snapshot: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package runtimehandlerhooks
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+
+	"github.com/cri-o/cri-o/internal/config/node"
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	"github.com/cri-o/cri-o/internal/oci"
+)
+
+var _ = Describe("WriteDebugSnapshot", func() {
+	const cgroupParent = "/kubepods/pod1"
+	var (
+		c  *oci.Container
+		sb *sandbox.Sandbox
+	)
+
+	// entriesOf returns the content of the files of the snapshot, keyed by name.
+	entriesOf := func(snapshot []byte) map[string]string {
+		gz, err := gzip.NewReader(bytes.NewReader(snapshot))
+		ExpectWithOffset(1, err).ToNot(HaveOccurred())
+		tr := tar.NewReader(gz)
+		entries := map[string]string{}
+		for {
+			hdr, err := tr.Next()
+			if errors.Is(err, io.EOF) {
+				return entries
+			}
+			ExpectWithOffset(1, err).ToNot(HaveOccurred())
+			content, err := io.ReadAll(tr)
+			ExpectWithOffset(1, err).ToNot(HaveOccurred())
+			entries[hdr.Name] = string(content)
+		}
+	}
+
+	BeforeEach(func() {
+		c = newTestContainer("ctr1", "cnt1", "sandboxID")
+		c.SetSpec(&specs.Spec{Linux: &specs.Linux{Resources: &specs.LinuxResources{CPU: &specs.LinuxCPU{Cpus: "1"}}}})
+
+		sb = newTestSandbox("sandboxID", nil, func(sbox sandbox.Builder) {
+			sbox.SetCgroupParent(cgroupParent)
+		})
+		DeferCleanup(func() {
+			forgetAppliedTuning(context.TODO(), c.ID())
+		})
+	})
+
+	It("should bundle the cgroup and sysfs files of the container with its tuning record", func() {
+		containerCgroup, err := cgroupManagerForParent(cgroupParent).ContainerCgroupAbsolutePath(cgroupParent, c.ID())
+		Expect(err).ToNot(HaveOccurred())
+		cpusetFile := filepath.Join(cgroupMountPoint, containerCgroup, "cpuset.cpus")
+		if !node.CgroupIsV2() {
+			cpusetFile = filepath.Join(cgroupMountPoint, "cpuset", containerCgroup, "cpuset.cpus")
+		}
+		const governorFile = sysCPUDir + "/cpu1/cpufreq/scaling_governor"
+		useFakeHostFS(map[string]string{
+			cpusetFile:             "1\n",
+			governorFile:           "performance\n",
+			IrqSmpAffinityProcFile: "000000fd\n",
+		})
+		recordTuningWrite(context.TODO(), c.ID(), governorFile, "powersave", "performance")
+
+		var snapshot bytes.Buffer
+		Expect(WriteDebugSnapshot(&snapshot, c, sb)).To(Succeed())
+
+		entries := entriesOf(snapshot.Bytes())
+		Expect(entries).To(HaveLen(4))
+		Expect(entries).To(HaveKeyWithValue(cpusetFile[1:], "1\n"))
+		Expect(entries).To(HaveKeyWithValue(governorFile[1:], "performance\n"))
+		Expect(entries).To(HaveKeyWithValue(IrqSmpAffinityProcFile[1:], "000000fd\n"))
+		Expect(entries).To(HaveKeyWithValue(debugSnapshotRecordFile, ContainSubstring(`"original": "powersave"`)))
+	})
+
+	It("should not bundle any tuning record for the containers which did not get tuned", func() {
+		useFakeHostFS(map[string]string{IrqSmpAffinityProcFile: "000000ff\n"})
+
+		var snapshot bytes.Buffer
+		Expect(WriteDebugSnapshot(&snapshot, c, sb)).To(Succeed())
+
+		Expect(entriesOf(snapshot.Bytes())).To(Equal(map[string]string{IrqSmpAffinityProcFile[1:]: "000000ff\n"}))
+	})
+})
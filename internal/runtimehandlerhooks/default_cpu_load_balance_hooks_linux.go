@@ -3,6 +3,7 @@ package runtimehandlerhooks
 import (
 	"context"
 
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/opencontainers/runtime-tools/generate"
 
 	"github.com/cri-o/cri-o/internal/config/node"
@@ -18,6 +19,11 @@ import (
 // This is the only case it seeks to fix, and thus does not define any other members of the RuntimeHandlerHooks functions.
 type DefaultCPULoadBalanceHooks struct{}
 
+// Contract of the CPU load balancing hooks, which only write cpuset.sched_load_balance.
+func (*DefaultCPULoadBalanceHooks) Contract() HookContract {
+	return HookContract{Modifies: []HookResource{HookResourceCgroupCPUSet}}
+}
+
 // No-op.
 func (*DefaultCPULoadBalanceHooks) PreCreate(context.Context, *generate.Generator, *sandbox.Sandbox, *oci.Container) error {
 	return nil
@@ -34,16 +40,32 @@ func (*DefaultCPULoadBalanceHooks) PreStop(context.Context, *oci.Container, *san
 }
 
 func (*DefaultCPULoadBalanceHooks) PostStop(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	ctx = withHookContainer(ctx, c)
 	// Disable cpuset.sched_load_balance for all stale cgroups.
 	// This way, cpumanager can ignore stopped containers, but the running ones will still have exclusive access.
 	if c.Spoofed() || node.CgroupIsV2() {
 		return nil
 	}
 
-	_, containerManagers, err := libctrManagersForPodAndContainerCgroup(c, s.CgroupParent())
-	if err != nil {
-		return err
-	}
+	return disableCPULoadBalancingV1(ctx, c.ID(), s.CgroupParent())
+}
+
+// No-op.
+func (*DefaultCPULoadBalanceHooks) PreUpdate(context.Context, *oci.Container, *sandbox.Sandbox, *rspec.LinuxResources) error {
+	return nil
+}
+
+// No-op.
+func (*DefaultCPULoadBalanceHooks) PostUpdate(context.Context, *oci.Container, *sandbox.Sandbox, *rspec.LinuxResources) error {
+	return nil
+}
 
-	return disableCPULoadBalancingV1(containerManagers)
+// No-op.
+func (*DefaultCPULoadBalanceHooks) PreCheckpoint(context.Context, *oci.Container, *sandbox.Sandbox) error {
+	return nil
+}
+
+// No-op.
+func (*DefaultCPULoadBalanceHooks) PostRestore(context.Context, *oci.Container, *sandbox.Sandbox) error {
+	return nil
 }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/cri-o/cri-o/internal/lib/sandbox"
 	"github.com/cri-o/cri-o/internal/oci"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/opencontainers/runtime-tools/generate"
 )
 
@@ -36,3 +37,23 @@ func (*DefaultCPULoadBalanceHooks) PreStop(context.Context, *oci.Container, *san
 func (*DefaultCPULoadBalanceHooks) PostStop(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
 	return nil
 }
+
+// No-op
+func (*DefaultCPULoadBalanceHooks) PreUpdate(context.Context, *oci.Container, *sandbox.Sandbox, *rspec.LinuxResources) error {
+	return nil
+}
+
+// No-op
+func (*DefaultCPULoadBalanceHooks) PostUpdate(context.Context, *oci.Container, *sandbox.Sandbox, *rspec.LinuxResources) error {
+	return nil
+}
+
+// No-op.
+func (*DefaultCPULoadBalanceHooks) PreCheckpoint(context.Context, *oci.Container, *sandbox.Sandbox) error {
+	return nil
+}
+
+// No-op.
+func (*DefaultCPULoadBalanceHooks) PostRestore(context.Context, *oci.Container, *sandbox.Sandbox) error {
+	return nil
+}
@@ -0,0 +1,217 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/utils/cpuset"
+
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/internal/oci"
+	crioannotations "github.com/cri-o/cri-o/pkg/annotations"
+)
+
+const (
+	// iommuGroupsDir is the sysfs directory of the IOMMU groups, empty if the IOMMU is disabled.
+	iommuGroupsDir = "/sys/kernel/iommu_groups"
+	// isolatedCPUsFile lists the CPUs isolated from the scheduler by the isolcpus kernel parameter.
+	isolatedCPUsFile = sysCPUDir + "/isolated"
+	// vfioDir holds the VFIO container device and the devices of the IOMMU groups bound to VFIO.
+	vfioDir       = "/dev/vfio"
+	vfioContainer = vfioDir + "/vfio"
+	vfioPCIDriver = "vfio-pci"
+)
+
+// requestedDPDKReadiness returns whether the container is annotated as running a DPDK application.
+func requestedDPDKReadiness(annotations fields.Set, cName string) bool {
+	return annotations[crioannotations.DPDKAnnotation+"/"+cName] == annotationEnable
+}
+
+// verifyRequestedDPDKReadiness checks that the node is ready to run the DPDK application of the container,
+// if annotated as such, so that it fails to start with all the problems found instead of crashing once started.
+func verifyRequestedDPDKReadiness(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	if !requestedDPDKReadiness(s.Annotations(), c.CRIContainer().GetMetadata().GetName()) {
+		return nil
+	}
+	return measureHookStep(ctx, hookStepDPDKReadiness, hookStepAttributes(c, s.Annotations(), crioannotations.DPDKAnnotation+"/"+c.CRIContainer().GetMetadata().GetName()), func(ctx context.Context) error {
+		cSpec := c.Spec()
+		problems := dpdkReadinessProblems(&cSpec, shouldCPULoadBalancingBeDisabled(ctx, s.Annotations()), sysNodeDir, iommuGroupsDir, isolatedCPUsFile)
+		if len(problems) > 0 {
+			return &dpdkNotReadyError{Container: c.Name(), Problems: problems}
+		}
+		log.Infof(ctx, "Node is ready for the DPDK application of container %q", c.ID())
+		return nil
+	})
+}
+
+// dpdkReadinessProblems returns the problems preventing the DPDK application of the container of the spec
+// from running: its CPUs must be isolated and on a single NUMA node, its hugepages must be free on this node,
+// and its VFIO devices must be bound to vfio-pci, in IOMMU groups, on this node.
+func dpdkReadinessProblems(spec *rspec.Spec, cpuLoadBalancingDisabled bool, nodeDir, iommuDir, isolatedFile string) []string {
+	if isContainerCPUsSpecEmpty(spec) {
+		return []string{"the container has no exclusive CPUs, it must request integer CPUs in a Guaranteed pod"}
+	}
+	cpus, err := cpuset.Parse(spec.Linux.Resources.CPU.Cpus)
+	if err != nil {
+		return []string{fmt.Sprintf("invalid CPUs %q: %v", spec.Linux.Resources.CPU.Cpus, err)}
+	}
+	var problems []string
+	nodes, err := cpuNUMANodes(cpus, nodeDir)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("NUMA nodes of CPUs %s: %v", cpus.String(), err))
+	} else if nodes.Size() > 1 {
+		problems = append(problems, fmt.Sprintf("CPUs %s span NUMA nodes %s, enable the single-numa-node topology manager policy of the kubelet", cpus.String(), nodes.String()))
+	}
+	if !cpuLoadBalancingDisabled && !cpusIsolated(cpus, isolatedFile) {
+		problems = append(problems, fmt.Sprintf("CPUs %s are not isolated, annotate the pod with %s: %q or isolate them with the isolcpus kernel parameter", cpus.String(), crioannotations.CPULoadBalancingAnnotation, annotationDisable))
+	}
+	problems = append(problems, hugepagesProblems(spec, nodes, nodeDir)...)
+	problems = append(problems, vfioProblems(spec, nodes, iommuDir)...)
+	return problems
+}
+
+// cpuNUMANodes returns the NUMA nodes of the CPUs.
+func cpuNUMANodes(cpus cpuset.CPUSet, nodeDir string) (cpuset.CPUSet, error) {
+	content, err := hostFS.ReadFile(filepath.Join(nodeDir, "online"))
+	if err != nil {
+		return cpuset.New(), err
+	}
+	online, err := cpuset.Parse(strings.TrimSpace(string(content)))
+	if err != nil {
+		return cpuset.New(), err
+	}
+	var nodes []int
+	for _, node := range online.List() {
+		content, err := hostFS.ReadFile(filepath.Join(nodeDir, fmt.Sprintf("node%d", node), "cpulist"))
+		if err != nil {
+			return cpuset.New(), err
+		}
+		nodeCPUs, err := cpuset.Parse(strings.TrimSpace(string(content)))
+		if err != nil {
+			return cpuset.New(), err
+		}
+		if !nodeCPUs.Intersection(cpus).IsEmpty() {
+			nodes = append(nodes, node)
+		}
+	}
+	return cpuset.New(nodes...), nil
+}
+
+// cpusIsolated returns whether the CPUs are isolated by the isolcpus kernel parameter.
+func cpusIsolated(cpus cpuset.CPUSet, isolatedFile string) bool {
+	content, err := hostFS.ReadFile(isolatedFile)
+	if err != nil {
+		return false
+	}
+	isolated, err := cpuset.Parse(strings.TrimSpace(string(content)))
+	return err == nil && cpus.IsSubsetOf(isolated)
+}
+
+// hugepagesProblems returns the hugepages requested by the container of the spec which are not free
+// on the NUMA nodes of its CPUs.
+func hugepagesProblems(spec *rspec.Spec, nodes cpuset.CPUSet, nodeDir string) []string {
+	var limits []rspec.LinuxHugepageLimit
+	if spec.Linux != nil && spec.Linux.Resources != nil {
+		limits = slices.DeleteFunc(slices.Clone(spec.Linux.Resources.HugepageLimits), func(l rspec.LinuxHugepageLimit) bool {
+			return l.Limit == 0
+		})
+	}
+	if len(limits) == 0 {
+		return []string{"the container requests no hugepages, add a hugepages-2Mi or hugepages-1Gi limit to its resources"}
+	}
+	var problems []string
+	for _, limit := range limits {
+		sizeKB, err := hugepageSizeKB(limit.Pagesize)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		var free uint64
+		for _, node := range nodes.List() {
+			content, err := hostFS.ReadFile(filepath.Join(nodeDir, fmt.Sprintf("node%d", node), "hugepages", fmt.Sprintf("hugepages-%dkB", sizeKB), "free_hugepages"))
+			if err != nil {
+				continue
+			}
+			pages, err := strconv.ParseUint(strings.TrimSpace(string(content)), 10, 64)
+			if err == nil {
+				free += pages * sizeKB * 1024
+			}
+		}
+		if free < limit.Limit {
+			problems = append(problems, fmt.Sprintf("%d bytes of %s hugepages requested but %d free on NUMA nodes %s, allocate more of them on these nodes", limit.Limit, limit.Pagesize, free, nodes.String()))
+		}
+	}
+	return problems
+}
+
+// hugepageSizeKB returns the kilobytes of the hugepage size of the spec, like "2MB".
+func hugepageSizeKB(pagesize string) (uint64, error) {
+	for unit, kb := range map[string]uint64{"KB": 1, "MB": 1024, "GB": 1024 * 1024} {
+		if value, ok := strings.CutSuffix(pagesize, unit); ok {
+			size, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				break
+			}
+			return size * kb, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid hugepage size %q", pagesize)
+}
+
+// vfioProblems returns the problems of the VFIO devices of the container of the spec: each of their IOMMU
+// groups must exist and only hold PCI devices bound to vfio-pci, on the NUMA nodes of the CPUs. A container
+// without VFIO device, like the ones of the applications using bifurcated drivers, has no problem.
+func vfioProblems(spec *rspec.Spec, nodes cpuset.CPUSet, iommuDir string) []string {
+	if spec.Linux == nil {
+		return nil
+	}
+	var (
+		groups    []string
+		container bool
+	)
+	for _, device := range spec.Linux.Devices {
+		switch {
+		case device.Path == vfioContainer:
+			container = true
+		case strings.HasPrefix(device.Path, vfioDir+"/"):
+			groups = append(groups, strings.TrimPrefix(device.Path, vfioDir+"/"))
+		}
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+	var problems []string
+	if !container {
+		problems = append(problems, fmt.Sprintf("the container has VFIO devices but not %s, request it from the device plugin", vfioContainer))
+	}
+	for _, group := range groups {
+		devicesDir := filepath.Join(iommuDir, group, "devices")
+		entries, err := hostFS.ReadDir(devicesDir)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("IOMMU group %s does not exist, enable the IOMMU with the intel_iommu=on or amd_iommu=on kernel parameter", group))
+			continue
+		}
+		for _, entry := range entries {
+			device := entry.Name()
+			driver, err := hostFS.Readlink(filepath.Join(devicesDir, device, "driver"))
+			if err != nil || filepath.Base(driver) != vfioPCIDriver {
+				problems = append(problems, fmt.Sprintf("device %s of IOMMU group %s is not bound to %s", device, group, vfioPCIDriver))
+			}
+			content, err := hostFS.ReadFile(filepath.Join(devicesDir, device, "numa_node"))
+			if err != nil {
+				continue
+			}
+			if node, err := strconv.Atoi(strings.TrimSpace(string(content))); err == nil && node >= 0 && !nodes.IsEmpty() && !nodes.Contains(node) {
+				problems = append(problems, fmt.Sprintf("device %s is on NUMA node %d while the CPUs are on NUMA nodes %s, align them with the topology manager of the kubelet", device, node, nodes.String()))
+			}
+		}
+	}
+	return problems
+}
@@ -0,0 +1,92 @@
+package runtimehandlerhooks
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+var _ = Describe("DPDK readiness", func() {
+	const (
+		nodeDir      = "/sys/devices/system/node"
+		iommuDir     = "/sys/kernel/iommu_groups"
+		isolatedFile = "/sys/devices/system/cpu/isolated"
+	)
+	deviceDir := filepath.Join(iommuDir, "12", "devices", "0000:3b:02.0")
+	var (
+		fake *fakeHostFS
+		spec *rspec.Spec
+	)
+
+	BeforeEach(func() {
+		fake = useFakeHostFS(map[string]string{
+			nodeDir + "/online":        "0-1\n",
+			nodeDir + "/node0/cpulist": "0-3\n",
+			nodeDir + "/node1/cpulist": "4-7\n",
+			nodeDir + "/node1/hugepages/hugepages-1048576kB/free_hugepages": "4\n",
+			isolatedFile:             "2-7\n",
+			deviceDir + "/numa_node": "1\n",
+		})
+		fake.symlink("../../../bus/pci/drivers/vfio-pci", filepath.Join(deviceDir, "driver"))
+
+		spec = &rspec.Spec{Linux: &rspec.Linux{
+			Resources: &rspec.LinuxResources{
+				CPU:            &rspec.LinuxCPU{Cpus: "4-5"},
+				HugepageLimits: []rspec.LinuxHugepageLimit{{Pagesize: "1GB", Limit: 2 << 30}},
+			},
+			Devices: []rspec.LinuxDevice{{Path: "/dev/vfio/vfio"}, {Path: "/dev/vfio/12"}},
+		}}
+	})
+
+	It("should find no problem on a node ready for the DPDK application", func() {
+		Expect(dpdkReadinessProblems(spec, false, nodeDir, iommuDir, isolatedFile)).To(BeEmpty())
+	})
+
+	It("should report all the problems at once", func() {
+		spec.Linux.Resources.CPU.Cpus = "0-1"
+		spec.Linux.Devices = spec.Linux.Devices[1:]
+
+		problems := dpdkReadinessProblems(spec, false, nodeDir, iommuDir, isolatedFile)
+
+		Expect(problems).To(ConsistOf(
+			ContainSubstring("CPUs 0-1 are not isolated"),
+			ContainSubstring("2147483648 bytes of 1GB hugepages requested but 0 free on NUMA nodes 0"),
+			ContainSubstring("not /dev/vfio/vfio"),
+			ContainSubstring("device 0000:3b:02.0 is on NUMA node 1"),
+		))
+	})
+
+	It("should accept the CPUs isolated by the annotations of the pod", func() {
+		Expect(fake.WriteFile(isolatedFile, []byte("\n"), 0o644)).To(Succeed())
+
+		Expect(dpdkReadinessProblems(spec, true, nodeDir, iommuDir, isolatedFile)).To(BeEmpty())
+	})
+
+	It("should report the CPUs spanning NUMA nodes and the devices not bound to vfio-pci", func() {
+		spec.Linux.Resources.CPU.Cpus = "3-4"
+		Expect(fake.WriteFile(isolatedFile, []byte("3-7\n"), 0o644)).To(Succeed())
+		Expect(fake.Remove(filepath.Join(deviceDir, "driver"))).To(Succeed())
+
+		problems := dpdkReadinessProblems(spec, false, nodeDir, iommuDir, isolatedFile)
+
+		Expect(problems).To(ConsistOf(
+			ContainSubstring("CPUs 3-4 span NUMA nodes 0-1"),
+			ContainSubstring("device 0000:3b:02.0 of IOMMU group 12 is not bound to vfio-pci"),
+		))
+	})
+
+	It("should report the IOMMU groups missing and the hugepages not requested", func() {
+		spec.Linux.Resources.HugepageLimits = nil
+		Expect(fake.Remove(filepath.Join(deviceDir, "driver"))).To(Succeed())
+		Expect(fake.Remove(filepath.Join(deviceDir, "numa_node"))).To(Succeed())
+
+		problems := dpdkReadinessProblems(spec, false, nodeDir, iommuDir, isolatedFile)
+
+		Expect(problems).To(ConsistOf(
+			ContainSubstring("requests no hugepages"),
+			ContainSubstring("IOMMU group 12 does not exist"),
+		))
+	})
+})
@@ -0,0 +1,423 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+
+	"github.com/cri-o/cri-o/internal/log"
+)
+
+// ethtoolSettingsDir is the pseudo directory of a network device holding its ethtool settings, like
+// "/sys/class/net/eth0/ethtool/combined-channels". The settings are recorded like the files of the
+// device, but read and written over the ethtool netlink API instead of sysfs.
+const ethtoolSettingsDir = "ethtool"
+
+// The ethtool settings of the network devices.
+const (
+	// ethtoolCombinedChannels is the number of combined channels of the device, as set by "ethtool -L combined".
+	ethtoolCombinedChannels = "combined-channels"
+	// ethtoolCombinedChannelsMax is the maximum number of combined channels of the device, which is read-only.
+	ethtoolCombinedChannelsMax = "combined-channels-max"
+	// ethtoolAdaptiveRX and ethtoolAdaptiveTX are the adaptive interrupt coalescing of the device, "on" or "off",
+	// as set by "ethtool -C adaptive-rx" and "ethtool -C adaptive-tx".
+	ethtoolAdaptiveRX = "adaptive-rx"
+	ethtoolAdaptiveTX = "adaptive-tx"
+	// ethtoolRXUsecs and ethtoolTXUsecs are the microseconds the interrupts of the device get delayed by,
+	// as set by "ethtool -C rx-usecs" and "ethtool -C tx-usecs".
+	ethtoolRXUsecs = "rx-usecs"
+	ethtoolTXUsecs = "tx-usecs"
+)
+
+// The ethtool features of the network devices, "on" or "off", as set by "ethtool -K",
+// named after the kernel features.
+const (
+	// ethtoolNtuple is the ntuple filters of the device, which accelerated RFS steers the flows with.
+	ethtoolNtuple = "rx-ntuple-filter"
+	// ethtoolGRO, ethtoolGROHW and ethtoolLRO aggregate the received packets of a flow before passing them
+	// up the stack: in software, in hardware with the GRO rules, or in hardware as a whole.
+	ethtoolGRO   = "rx-gro"
+	ethtoolGROHW = "rx-gro-hw"
+	ethtoolLRO   = "rx-lro"
+)
+
+// ethtoolFeatures are the ethtool features which are handled as settings.
+var ethtoolFeatures = []string{ethtoolNtuple, ethtoolGRO, ethtoolGROHW, ethtoolLRO}
+
+// ethtoolRXFHIndir is the RSS indirection table of the device, the receive queues its entries spread the flows
+// over, as set by "ethtool -X". It is read and written with the ethtool ioctls, as the netlink API cannot set it.
+const ethtoolRXFHIndir = "rxfh-indir"
+
+// ethtoolSetting is the ethtool netlink attribute of a setting.
+type ethtoolSetting struct {
+	// get and set are the messages reading and writing the attribute, set being zero for a read-only one.
+	get, set   uint8
+	headerAttr int
+	attr       uint16
+	// flag is set for a boolean attribute, held in a u8 and reported as "on" or "off", instead of a u32.
+	flag bool
+}
+
+var ethtoolSettingAttrs = map[string]ethtoolSetting{
+	ethtoolCombinedChannels: {
+		get: unix.ETHTOOL_MSG_CHANNELS_GET, set: unix.ETHTOOL_MSG_CHANNELS_SET,
+		headerAttr: unix.ETHTOOL_A_CHANNELS_HEADER, attr: unix.ETHTOOL_A_CHANNELS_COMBINED_COUNT,
+	},
+	ethtoolCombinedChannelsMax: {
+		get:        unix.ETHTOOL_MSG_CHANNELS_GET,
+		headerAttr: unix.ETHTOOL_A_CHANNELS_HEADER, attr: unix.ETHTOOL_A_CHANNELS_COMBINED_MAX,
+	},
+	ethtoolAdaptiveRX: {
+		get: unix.ETHTOOL_MSG_COALESCE_GET, set: unix.ETHTOOL_MSG_COALESCE_SET,
+		headerAttr: unix.ETHTOOL_A_COALESCE_HEADER, attr: unix.ETHTOOL_A_COALESCE_USE_ADAPTIVE_RX, flag: true,
+	},
+	ethtoolAdaptiveTX: {
+		get: unix.ETHTOOL_MSG_COALESCE_GET, set: unix.ETHTOOL_MSG_COALESCE_SET,
+		headerAttr: unix.ETHTOOL_A_COALESCE_HEADER, attr: unix.ETHTOOL_A_COALESCE_USE_ADAPTIVE_TX, flag: true,
+	},
+	ethtoolRXUsecs: {
+		get: unix.ETHTOOL_MSG_COALESCE_GET, set: unix.ETHTOOL_MSG_COALESCE_SET,
+		headerAttr: unix.ETHTOOL_A_COALESCE_HEADER, attr: unix.ETHTOOL_A_COALESCE_RX_USECS,
+	},
+	ethtoolTXUsecs: {
+		get: unix.ETHTOOL_MSG_COALESCE_GET, set: unix.ETHTOOL_MSG_COALESCE_SET,
+		headerAttr: unix.ETHTOOL_A_COALESCE_HEADER, attr: unix.ETHTOOL_A_COALESCE_TX_USECS,
+	},
+}
+
+// ethtoolSettingFile returns the pseudo file of the ethtool setting of the device.
+func ethtoolSettingFile(device, setting string) string {
+	return netDeviceFile(device, ethtoolSettingsDir, setting)
+}
+
+// parseEthtoolSettingFile returns the device and the ethtool setting of the pseudo file, if it is one.
+func parseEthtoolSettingFile(name string) (device, setting string, ok bool) {
+	rel, ok := strings.CutPrefix(name, netSysfsDir+"/")
+	if !ok {
+		return "", "", false
+	}
+	parts := strings.Split(rel, "/")
+	if len(parts) != 3 || parts[1] != ethtoolSettingsDir {
+		return "", "", false
+	}
+	return parts[0], parts[2], true
+}
+
+// ethtoolSettings reads and writes the ethtool settings of the network devices of the network namespace the
+// calling thread is in. The tests replace it with a fake.
+var ethtoolSettings ethtoolAPI = netlinkEthtool{}
+
+type ethtoolAPI interface {
+	Get(device, setting string) (string, error)
+	Set(device, setting, value string) error
+}
+
+// netlinkEthtool implements the ethtool settings over the ethtool generic netlink family.
+type netlinkEthtool struct{}
+
+func (netlinkEthtool) Get(device, setting string) (string, error) {
+	if slices.Contains(ethtoolFeatures, setting) {
+		return ethtoolGetFeature(device, setting)
+	}
+	if setting == ethtoolRXFHIndir {
+		return ethtoolGetRXFHIndir(device)
+	}
+	a, ok := ethtoolSettingAttrs[setting]
+	if !ok {
+		return "", fmt.Errorf("unknown ethtool setting %q", setting)
+	}
+	attrs, err := ethtoolGet(device, a.get, a.headerAttr)
+	if err != nil {
+		return "", err
+	}
+	value, ok := attrs[a.attr]
+	switch {
+	case ok && a.flag && len(value) == 1:
+		if value[0] != 0 {
+			return "on", nil
+		}
+		return "off", nil
+	case ok && !a.flag && len(value) == 4:
+		return strconv.FormatUint(uint64(nl.NativeEndian().Uint32(value)), 10), nil
+	}
+	return "", fmt.Errorf("%w: device %s does not report its %s", errors.ErrUnsupported, device, setting)
+}
+
+func (netlinkEthtool) Set(device, setting, value string) error {
+	if slices.Contains(ethtoolFeatures, setting) {
+		return ethtoolSetFeature(device, setting, value)
+	}
+	if setting == ethtoolRXFHIndir {
+		return ethtoolSetRXFHIndir(device, value)
+	}
+	a, ok := ethtoolSettingAttrs[setting]
+	if !ok {
+		return fmt.Errorf("unknown ethtool setting %q", setting)
+	}
+	if a.set == 0 {
+		return fmt.Errorf("ethtool setting %q is read-only", setting)
+	}
+	var data []byte
+	if a.flag {
+		switch value {
+		case "on":
+			data = nl.Uint8Attr(1)
+		case "off":
+			data = nl.Uint8Attr(0)
+		default:
+			return fmt.Errorf("invalid %s %q, expected \"on\" or \"off\"", setting, value)
+		}
+	} else {
+		count, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %w", setting, value, err)
+		}
+		data = nl.Uint32Attr(uint32(count))
+	}
+	return ethtoolSet(device, a.set, a.headerAttr, nl.NewRtAttr(int(a.attr), data))
+}
+
+// ethtoolGetFeature returns whether the feature of the device is active, "on" or "off". The features
+// the device does not allow to change are reported as errors.ErrUnsupported.
+func ethtoolGetFeature(device, feature string) (string, error) {
+	attrs, err := ethtoolGet(device, unix.ETHTOOL_MSG_FEATURES_GET, unix.ETHTOOL_A_FEATURES_HEADER)
+	if err != nil {
+		return "", err
+	}
+	changeable, err := ethtoolBitsetNames(attrs[unix.ETHTOOL_A_FEATURES_HW])
+	if err != nil {
+		return "", err
+	}
+	if !slices.Contains(changeable, feature) {
+		return "", fmt.Errorf("%w: feature %s of device %s cannot be changed", errors.ErrUnsupported, feature, device)
+	}
+	active, err := ethtoolBitsetNames(attrs[unix.ETHTOOL_A_FEATURES_ACTIVE])
+	if err != nil {
+		return "", err
+	}
+	if slices.Contains(active, feature) {
+		return "on", nil
+	}
+	return "off", nil
+}
+
+// ethtoolSetFeature turns the feature of the device "on" or "off".
+func ethtoolSetFeature(device, feature, value string) error {
+	if value != "on" && value != "off" {
+		return fmt.Errorf("invalid %s %q, expected \"on\" or \"off\"", feature, value)
+	}
+	wanted := nl.NewRtAttr(unix.ETHTOOL_A_FEATURES_WANTED|unix.NLA_F_NESTED, nil)
+	bit := wanted.AddRtAttr(unix.ETHTOOL_A_BITSET_BITS|unix.NLA_F_NESTED, nil).
+		AddRtAttr(unix.ETHTOOL_A_BITSET_BITS_BIT|unix.NLA_F_NESTED, nil)
+	bit.AddRtAttr(unix.ETHTOOL_A_BITSET_BIT_NAME, nl.ZeroTerminated(feature))
+	if value == "on" {
+		bit.AddRtAttr(unix.ETHTOOL_A_BITSET_BIT_VALUE, nil)
+	}
+	return ethtoolSet(device, unix.ETHTOOL_MSG_FEATURES_SET, unix.ETHTOOL_A_FEATURES_HEADER, wanted)
+}
+
+// The ethtool ioctl commands of the RSS indirection table, see linux/ethtool.h.
+const (
+	ethtoolGRXFHINDIR = 0x38
+	ethtoolSRXFHINDIR = 0x39
+)
+
+// ethtoolGetRXFHIndir returns the entries of the RSS indirection table of the device, separated by spaces.
+func ethtoolGetRXFHIndir(device string) (string, error) {
+	// struct ethtool_rxfh_indir is the command and the size of the table, followed by its entries
+	header := []uint32{ethtoolGRXFHINDIR, 0}
+	if err := ethtoolIoctl(device, unsafe.Pointer(&header[0])); err != nil {
+		return "", err
+	}
+	if header[1] == 0 {
+		return "", fmt.Errorf("%w: device %s has no RSS indirection table", errors.ErrUnsupported, device)
+	}
+	table := make([]uint32, 2+header[1])
+	table[0], table[1] = ethtoolGRXFHINDIR, header[1]
+	if err := ethtoolIoctl(device, unsafe.Pointer(&table[0])); err != nil {
+		return "", err
+	}
+	entries := make([]string, 0, len(table)-2)
+	for _, queue := range table[2:] {
+		entries = append(entries, strconv.FormatUint(uint64(queue), 10))
+	}
+	return strings.Join(entries, " "), nil
+}
+
+// ethtoolSetRXFHIndir sets the entries of the RSS indirection table of the device, separated by spaces,
+// which must be as many as the ones of the table of the device.
+func ethtoolSetRXFHIndir(device, value string) error {
+	entries := strings.Fields(value)
+	table := make([]uint32, 2, 2+len(entries))
+	table[0], table[1] = ethtoolSRXFHINDIR, uint32(len(entries))
+	for _, entry := range entries {
+		queue, err := strconv.ParseUint(entry, 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid %s entry %q: %w", ethtoolRXFHIndir, entry, err)
+		}
+		table = append(table, uint32(queue))
+	}
+	return ethtoolIoctl(device, unsafe.Pointer(&table[0]))
+}
+
+// ethtoolIoctl runs the ethtool ioctl of the command data points to on the device.
+func ethtoolIoctl(device string, data unsafe.Pointer) error {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+	// struct ifreq, whose union holds the pointer to the command
+	var ifr struct {
+		name [unix.IFNAMSIZ]byte
+		data unsafe.Pointer
+		_    [24 - unsafe.Sizeof(uintptr(0))]byte
+	}
+	copy(ifr.name[:unix.IFNAMSIZ-1], device)
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), unix.SIOCETHTOOL, uintptr(unsafe.Pointer(&ifr))); errno != 0 {
+		return ethtoolError(device, errno)
+	}
+	return nil
+}
+
+// ethtoolBitsetNames returns the names of the bits set in the verbose ethtool bitset.
+func ethtoolBitsetNames(data []byte) ([]string, error) {
+	attrs, err := nl.ParseRouteAttr(data)
+	if err != nil {
+		return nil, err
+	}
+	// the bits of a bitset without mask are all set
+	noMask := slices.ContainsFunc(attrs, func(attr syscall.NetlinkRouteAttr) bool {
+		return attr.Attr.Type&^unix.NLA_F_NESTED == unix.ETHTOOL_A_BITSET_NOMASK
+	})
+	var names []string
+	for _, attr := range attrs {
+		if attr.Attr.Type&^unix.NLA_F_NESTED != unix.ETHTOOL_A_BITSET_BITS {
+			continue
+		}
+		bits, err := nl.ParseRouteAttr(attr.Value)
+		if err != nil {
+			return nil, err
+		}
+		for _, bit := range bits {
+			fields, err := nl.ParseRouteAttr(bit.Value)
+			if err != nil {
+				return nil, err
+			}
+			var (
+				name string
+				set  = noMask
+			)
+			for _, field := range fields {
+				switch field.Attr.Type &^ unix.NLA_F_NESTED {
+				case unix.ETHTOOL_A_BITSET_BIT_NAME:
+					name = strings.TrimRight(string(field.Value), "\x00")
+				case unix.ETHTOOL_A_BITSET_BIT_VALUE:
+					set = true
+				}
+			}
+			if set {
+				names = append(names, name)
+			}
+		}
+	}
+	return names, nil
+}
+
+// ethtoolRequest returns the request of the ethtool message for the device.
+func ethtoolRequest(device string, cmd uint8, headerAttr, flags int) (*nl.NetlinkRequest, error) {
+	family, err := netlink.GenlFamilyGet(unix.ETHTOOL_GENL_NAME)
+	if err != nil {
+		return nil, fmt.Errorf("get ethtool netlink family: %w", err)
+	}
+	req := nl.NewNetlinkRequest(int(family.ID), flags)
+	req.AddData(&nl.Genlmsg{Command: cmd, Version: unix.ETHTOOL_GENL_VERSION})
+	header := nl.NewRtAttr(headerAttr|unix.NLA_F_NESTED, nil)
+	header.AddRtAttr(unix.ETHTOOL_A_HEADER_DEV_NAME, nl.ZeroTerminated(device))
+	req.AddData(header)
+	return req, nil
+}
+
+// ethtoolGet returns the attributes of the reply of the ethtool get message for the device, keyed by type.
+func ethtoolGet(device string, cmd uint8, headerAttr int) (map[uint16][]byte, error) {
+	req, err := ethtoolRequest(device, cmd, headerAttr, 0)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := req.Execute(unix.NETLINK_GENERIC, 0)
+	if err != nil {
+		return nil, ethtoolError(device, err)
+	}
+	if len(msgs) == 0 || len(msgs[0]) < nl.SizeofGenlmsg {
+		return nil, fmt.Errorf("empty ethtool reply for device %s", device)
+	}
+	attrs, err := nl.ParseRouteAttr(msgs[0][nl.SizeofGenlmsg:])
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[uint16][]byte, len(attrs))
+	for _, attr := range attrs {
+		values[attr.Attr.Type&^unix.NLA_F_NESTED] = attr.Value
+	}
+	return values, nil
+}
+
+// ethtoolSet sends the ethtool set message with the attributes for the device.
+func ethtoolSet(device string, cmd uint8, headerAttr int, attrs ...*nl.RtAttr) error {
+	req, err := ethtoolRequest(device, cmd, headerAttr, unix.NLM_F_ACK)
+	if err != nil {
+		return err
+	}
+	for _, attr := range attrs {
+		req.AddData(attr)
+	}
+	if _, err := req.Execute(unix.NETLINK_GENERIC, 0); err != nil {
+		return ethtoolError(device, err)
+	}
+	return nil
+}
+
+// ethtoolError wraps the error of the ethtool message for the device, a missing device being reported
+// as os.ErrNotExist like its sysfs files, and a setting the driver does not support as errors.ErrUnsupported.
+func ethtoolError(device string, err error) error {
+	if errors.Is(err, unix.ENODEV) {
+		return fmt.Errorf("%w: device %s: %w", os.ErrNotExist, device, err)
+	}
+	if errors.Is(err, unix.EOPNOTSUPP) {
+		return fmt.Errorf("%w: device %s: %w", errors.ErrUnsupported, device, err)
+	}
+	return fmt.Errorf("ethtool on device %s: %w", device, err)
+}
+
+// readEthtoolSetting reads the ethtool setting of the pseudo file, from the network namespace sysfs got
+// mounted at. The device is looked up in sysfs first, so that a device gone is reported as os.ErrNotExist.
+func readEthtoolSetting(sysfs, name string) ([]byte, error) {
+	device, setting, _ := parseEthtoolSettingFile(name)
+	if _, err := os.Stat(filepath.Join(sysfs, "class", "net", device)); err != nil {
+		return nil, err
+	}
+	value, err := ethtoolSettings.Get(device, setting)
+	return []byte(value), err
+}
+
+// writeEthtoolSetting writes the ethtool setting of the pseudo file, in the network namespace sysfs got mounted at.
+func writeEthtoolSetting(ctx context.Context, sysfs, name string, data []byte) error {
+	device, setting, _ := parseEthtoolSettingFile(name)
+	if _, err := os.Stat(filepath.Join(sysfs, "class", "net", device)); err != nil {
+		return err
+	}
+	value := strings.TrimSpace(string(data))
+	log.Debugf(ctx, "Set ethtool %s of device %s to %q", setting, device, value)
+	return ethtoolSettings.Set(device, setting, value)
+}
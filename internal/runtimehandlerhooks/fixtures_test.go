@@ -0,0 +1,66 @@
+package runtimehandlerhooks
+
+import (
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	types "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	"github.com/cri-o/cri-o/internal/memorystore"
+	"github.com/cri-o/cri-o/internal/oci"
+)
+
+// newTestContainer returns a container of the sandbox, named name in its CRI metadata.
+func newTestContainer(id, name, sandboxID string) *oci.Container {
+	c, err := oci.NewContainer(id, name, "", "",
+		make(map[string]string), make(map[string]string),
+		make(map[string]string), "pauseImage", nil, nil, "",
+		&types.ContainerMetadata{Name: name}, sandboxID, false, false,
+		false, "", "", time.Now(), "")
+	Expect(err).ToNot(HaveOccurred())
+	return c
+}
+
+// newTestSandbox returns a sandbox with the annotations, which the options complete before it gets built.
+func newTestSandbox(id string, annotations map[string]string, options ...func(sandbox.Builder)) *sandbox.Sandbox {
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	sbox := sandbox.NewBuilder()
+	sbox.SetID(id)
+	sbox.SetCreatedAt(time.Now())
+	sbox.SetContainers(memorystore.New[*oci.Container]())
+	for _, option := range options {
+		option(sbox)
+	}
+	Expect(sbox.SetCRISandbox(id, make(map[string]string), annotations, &types.PodSandboxMetadata{})).To(Succeed())
+	sb, err := sbox.GetSandbox()
+	Expect(err).ToNot(HaveOccurred())
+	return sb
+}
+
+// useFakePodNetwork replaces the interfaces of the pods by interfaces, and the sysfs of the network namespaces
+// by the directories keyed by their path, "" being the host one, for the current spec.
+// The ethtool settings of the devices are replaced as well if ethtool is not nil.
+func useFakePodNetwork(interfaces []podInterface, sysfs map[string]string, ethtool ethtoolAPI) {
+	formerPodInterfaces, formerWithNetNSSysfs, formerEthtool := podInterfaces, withNetNSSysfs, ethtoolSettings
+	podInterfaces = func(string) ([]podInterface, error) {
+		return interfaces, nil
+	}
+	withNetNSSysfs = func(netnsPath string, fn func(sysfs string) error) error {
+		dir, ok := sysfs[netnsPath]
+		if !ok {
+			return os.ErrNotExist
+		}
+		return fn(dir)
+	}
+	if ethtool != nil {
+		ethtoolSettings = ethtool
+	}
+	DeferCleanup(func() {
+		podInterfaces, withNetNSSysfs, ethtoolSettings = formerPodInterfaces, formerWithNetNSSysfs, formerEthtool
+	})
+}
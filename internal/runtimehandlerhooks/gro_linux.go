@@ -0,0 +1,69 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	"github.com/cri-o/cri-o/internal/log"
+	crioann "github.com/cri-o/cri-o/pkg/annotations"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+)
+
+// receiveAggregationFeatures are the ethtool features turned off by the GRO annotation, in the order they are set.
+var receiveAggregationFeatures = []string{ethtoolLRO, ethtoolGROHW, ethtoolGRO}
+
+// SetPodGRO turns the aggregation of the received packets off on the devices backing the interfaces of the pod
+// if its annotations request it, once its network is set up, until RevertPodNetworkTuning gets called when the pod
+// stops. The aggregation holds the packets of a flow back to merge them, which delays the small messages.
+// The writes are recorded for the pod, keyed by its sandbox ID, like its interrupt coalescing. The CNI result
+// of the pod, if any, resolves its default network for the tuning interfaces annotation.
+func SetPodGRO(ctx context.Context, config *libconfig.Config, sb *sandbox.Sandbox, cniResult string) error {
+	if sb.Annotations()[crioann.GROAnnotation] != annotationDisable {
+		return nil
+	}
+	settings := config.HighPerformanceSettingsFor(sb.RuntimeHandler())
+	if !settings.FeatureEnabled(libconfig.HighPerformanceFeatureGRO) ||
+		config.HighPerformancePolicyFor(sb.RuntimeHandler()) != libconfig.RuntimeTypeTuningTune {
+		return nil
+	}
+	if sb.HostNetwork() || sb.NetNsPath() == "" {
+		log.Warnf(ctx, "GRO disabled for pod sandbox %s on the host network, ignoring", sb.ID())
+		return nil
+	}
+	network, err := podNetworkOf(sb, cniResult)
+	if err != nil {
+		return err
+	}
+	log.Infof(ctx, "Turn GRO and LRO off on the interfaces of pod sandbox %s", sb.ID())
+	err = disableGRO(ctx, sb.ID(), network)
+	if err != nil && slices.Contains(settings.FailOpen, libconfig.HighPerformanceFeatureGRO) {
+		log.Warnf(ctx, "Ignoring the failure of %s for pod sandbox %s: %v", libconfig.HighPerformanceFeatureGRO, sb.ID(), err)
+		return nil
+	}
+	return err
+}
+
+// disableGRO turns the receive aggregation features off on the interfaces of the pod network, recording the
+// writes for the ID. The features a device does not allow to change, like LRO on a veth device, are skipped.
+func disableGRO(ctx context.Context, id string, network podNetwork) error {
+	interfaces, err := network.interfaces()
+	if err != nil {
+		return err
+	}
+	for _, iface := range interfaces {
+		for _, feature := range receiveAggregationFeatures {
+			err := writeNetTuningFile(ctx, id, iface.NetNS, ethtoolSettingFile(iface.Name, feature), []byte("off"))
+			if errors.Is(err, errors.ErrUnsupported) {
+				log.Debugf(ctx, "Interface %s does not allow to change %s, skipping", iface, feature)
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("turn %s off on interface %s: %w", feature, iface, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GRO", func() {
+	const (
+		netns     = "/var/run/netns/pod"
+		sandboxID = "sb1"
+	)
+	var (
+		dir     string
+		ethtool fakeEthtool
+	)
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		ethtool = fakeEthtool{
+			"eth0":     {ethtoolGRO: "on"},
+			"net1":     {ethtoolGRO: "on", ethtoolGROHW: "on", ethtoolLRO: "off"},
+			"veth1234": {ethtoolGRO: "on"},
+		}
+		useFakePodNetwork([]podInterface{{Name: "eth0", NetNS: netns}, {Name: "net1", NetNS: netns}, {Name: "veth1234", Peer: "eth0"}}, map[string]string{"": filepath.Join(dir, "host"), netns: filepath.Join(dir, "pod")}, ethtool)
+		Expect(os.MkdirAll(filepath.Join(dir, "pod", "class", "net", "eth0"), 0o755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(dir, "pod", "class", "net", "net1", "device", "physfn"), 0o755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(dir, "host", "class", "net", "veth1234"), 0o755)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		forgetAppliedTuning(context.TODO(), sandboxID)
+	})
+
+	It("should turn the receive aggregation off on the devices of the pod and revert it", func() {
+		Expect(disableGRO(context.TODO(), sandboxID, podNetwork{NetNS: netns})).To(Succeed())
+
+		Expect(ethtool).To(Equal(fakeEthtool{
+			"eth0":     {ethtoolGRO: "off"},
+			"net1":     {ethtoolGRO: "off", ethtoolGROHW: "off", ethtoolLRO: "off"},
+			"veth1234": {ethtoolGRO: "off"},
+		}))
+
+		Expect(RevertPodNetworkTuning(context.TODO(), sandboxID)).To(Succeed())
+
+		Expect(ethtool).To(Equal(fakeEthtool{
+			"eth0":     {ethtoolGRO: "on"},
+			"net1":     {ethtoolGRO: "on", ethtoolGROHW: "on", ethtoolLRO: "off"},
+			"veth1234": {ethtoolGRO: "on"},
+		}))
+		Expect(tuningRecorded(sandboxID)).To(BeFalse())
+	})
+
+	It("should only turn the receive aggregation off on the selected interfaces", func() {
+		Expect(disableGRO(context.TODO(), sandboxID, podNetwork{NetNS: netns, Interfaces: []string{"net1"}})).To(Succeed())
+
+		Expect(ethtool["eth0"][ethtoolGRO]).To(Equal("on"))
+		Expect(ethtool["veth1234"][ethtoolGRO]).To(Equal("on"))
+		Expect(ethtool["net1"][ethtoolGRO]).To(Equal("off"))
+	})
+})
@@ -0,0 +1,52 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/cri-o/cri-o/utils/cmdrunner"
+)
+
+// helperCommandTimeout bounds the duration of a helper command, so that a stuck one cannot block the hook.
+const helperCommandTimeout = 30 * time.Second
+
+// runHelperCommand runs the helper command, like irqbalance, on behalf of the hooks in its own span
+// and returns its stdout. The trace context of the span is passed to the command in the TRACEPARENT and TRACESTATE
+// variables of its environment, which extends env or the one of CRI-O if nil, and the stdout and stderr of the
+// command are recorded as events of the span. The command gets killed after helperCommandTimeout, and its error
+// includes its output.
+func runHelperCommand(ctx context.Context, env []string, name string, args ...string) ([]byte, error) {
+	ctx, span := trace.SpanFromContext(ctx).TracerProvider().Tracer("").Start(ctx, "runtimehandlerhooks.exec/"+name,
+		trace.WithAttributes(attribute.StringSlice("args", args)))
+	defer span.End()
+
+	if env == nil {
+		env = os.Environ()
+	}
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	for key, value := range carrier {
+		env = append(env, strings.ToUpper(key)+"="+value)
+	}
+
+	output, err := cmdrunner.RunContext(ctx, cmdrunner.RunOptions{Timeout: helperCommandTimeout, Env: env}, name, args...)
+
+	if len(output.Stdout) > 0 {
+		span.AddEvent("stdout", trace.WithAttributes(attribute.String("output", string(output.Stdout))))
+	}
+	if len(output.Stderr) > 0 {
+		span.AddEvent("stderr", trace.WithAttributes(attribute.String("output", string(output.Stderr))))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return output.Stdout, err
+}
@@ -0,0 +1,47 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+var _ = Describe("runHelperCommand", func() {
+	It("should return the stdout of the command", func() {
+		stdout, err := runHelperCommand(context.TODO(), []string{"GREETING=hello"}, "sh", "-c", "echo $GREETING")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(stdout)).To(Equal("hello\n"))
+	})
+
+	It("should pass the trace context to the command and record its output in its span", func() {
+		ended := &endedSpans{}
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(ended))
+		ctx, hookSpan := provider.Tracer("").Start(context.TODO(), "PreStart")
+
+		stdout, err := runHelperCommand(ctx, nil, "sh", "-c", "echo $TRACEPARENT; echo failed >&2; exit 1")
+		Expect(err).To(HaveOccurred())
+		hookSpan.End()
+
+		Expect(ended.spans).To(HaveLen(2))
+		cmd := ended.spans[0]
+		Expect(cmd.Name()).To(Equal("runtimehandlerhooks.exec/sh"))
+		Expect(cmd.Parent().SpanID()).To(Equal(hookSpan.SpanContext().SpanID()))
+		Expect(cmd.Status().Code).To(Equal(codes.Error))
+		traceParent := fmt.Sprintf("00-%s-%s-01", cmd.SpanContext().TraceID(), cmd.SpanContext().SpanID())
+		Expect(string(stdout)).To(Equal(traceParent + "\n"))
+
+		outputs := map[string]attribute.KeyValue{}
+		for _, event := range cmd.Events() {
+			if len(event.Attributes) > 0 {
+				outputs[event.Name] = event.Attributes[0]
+			}
+		}
+		Expect(outputs).To(HaveKeyWithValue("stdout", attribute.String("output", traceParent+"\n")))
+		Expect(outputs).To(HaveKeyWithValue("stderr", attribute.String("output", "failed\n")))
+	})
+})
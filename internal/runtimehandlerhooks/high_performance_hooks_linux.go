@@ -2,6 +2,7 @@ package runtimehandlerhooks
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -18,17 +19,20 @@ import (
 	"github.com/opencontainers/runc/libcontainer/configs"
 	specs "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/opencontainers/runtime-tools/generate"
+	"go.opentelemetry.io/otel/attribute"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/utils/cpuset"
 
 	"github.com/cri-o/cri-o/internal/config/cgmgr"
 	"github.com/cri-o/cri-o/internal/config/node"
+	"github.com/cri-o/cri-o/internal/lib/constants"
 	"github.com/cri-o/cri-o/internal/lib/sandbox"
 	"github.com/cri-o/cri-o/internal/log"
 	"github.com/cri-o/cri-o/internal/oci"
 	crioannotations "github.com/cri-o/cri-o/pkg/annotations"
-	"github.com/cri-o/cri-o/utils/cmdrunner"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+	"github.com/cri-o/cri-o/pkg/cpumask"
 )
 
 const (
@@ -42,12 +46,15 @@ const (
 	annotationTrue       = "true"
 	annotationDisable    = "disable"
 	annotationEnable     = "enable"
+	annotationShared     = "shared"
 	schedDomainDir       = "/proc/sys/kernel/sched_domain"
 	cgroupMountPoint     = "/sys/fs/cgroup"
 	irqBalanceBannedCpus = "IRQBALANCE_BANNED_CPUS"
+	irqBalanceArgs       = "IRQBALANCE_ARGS"
 	irqBalancedName      = "irqbalance"
 	sysCPUDir            = "/sys/devices/system/cpu"
 	sysCPUSaveDir        = "/var/run/crio/cpu"
+	cpusetStateDir       = "/var/run/crio/cpuset"
 	milliCPUToCPU        = 1000
 )
 
@@ -57,6 +64,7 @@ const (
 	cgroupV2QuotaFile    = "cpu.max"
 	cpusetCpus           = "cpuset.cpus"
 	cpusetCpusExclusive  = "cpuset.cpus.exclusive"
+	cpusetCpusPartition  = "cpuset.cpus.partition"
 	IsolatedCPUsEnvVar   = "OPENSHIFT_ISOLATED_CPUS"
 	SharedCPUsEnvVar     = "OPENSHIFT_SHARED_CPUS"
 )
@@ -64,8 +72,74 @@ const (
 // HighPerformanceHooks used to run additional hooks that will configure a system for the latency sensitive workloads.
 type HighPerformanceHooks struct {
 	irqBalanceConfigFile string
-	cpusetLock           sync.Mutex
 	sharedCPUs           string
+	// housekeepingCPUs are the CPUs the housekeeping threads of the containers are pinned to.
+	housekeepingCPUs string
+	// isolatedCPUsEnvVar and sharedCPUsEnvVar override the names of the environment
+	// variables injected into containers requesting shared CPUs.
+	isolatedCPUsEnvVar string
+	sharedCPUsEnvVar   string
+	// disabled are the features turned off in the configuration.
+	disabled disabledFeatures
+	// failOpen are the features whose failures do not fail the CRI request.
+	failOpen []string
+	// tunedConflict is the policy applied when the active TuneD profile manages the same settings.
+	tunedConflict string
+	// dryRun makes the hooks log and save the plan of the tuning instead of applying it.
+	dryRun bool
+}
+
+// disabledFeatures lists the features of the high-performance hooks which are turned off,
+// so the zero value enables all of them. A disabled feature is not applied anymore,
+// but the tuning applied before it got disabled is still reverted when the container stops.
+type disabledFeatures struct {
+	cpuLoadBalancing bool
+	irqLoadBalancing bool
+	cpuQuota         bool
+	cStates          bool
+	freqGovernor     bool
+	sharedCPUs       bool
+	packetSteering   bool
+	vfQueues         bool
+	arfs             bool
+	vfIRQAffinity    bool
+	afXDP            bool
+	napiAffinity     bool
+}
+
+// failsOpen returns whether the failure of the feature should be ignored, and logs it if so.
+func (h *HighPerformanceHooks) failsOpen(ctx context.Context, feature string, c *oci.Container, err error) bool {
+	if !slices.Contains(h.failOpen, feature) {
+		return false
+	}
+	log.Warnf(ctx, "Ignoring the failure of %s for container %q: %v", feature, c.ID(), err)
+	noteFailedOpen(ctx, feature, err)
+	return true
+}
+
+// requestedSharedCPUs returns whether the container gets the shared CPUs it may have requested.
+func (h *HighPerformanceHooks) requestedSharedCPUs(ctx context.Context, annotations fields.Set, cName string) bool {
+	if !requestedSharedCPUs(annotations, cName) {
+		return false
+	}
+	if h.disabled.sharedCPUs {
+		log.Infof(ctx, "Shared CPUs are disabled, ignoring the request of container %q", cName)
+		return false
+	}
+	return true
+}
+
+// Contract of the high-performance hooks, which tune the cgroups, IRQs, CPUs and pod interfaces of the container,
+// publish its CPU assignment in its environment and annotations, and grant it what AF_XDP applications need.
+func (*HighPerformanceHooks) Contract() HookContract {
+	return HookContract{
+		Modifies: []HookResource{
+			HookResourceSpecEnv, HookResourceSpecAnnotations, HookResourceCgroupCPUSet, HookResourceCgroupCPU,
+			HookResourceIRQAffinity, HookResourceIRQBalanceConfig, HookResourceCPUPMQoS, HookResourceCPUFreqGovernor,
+			HookResourceNetDevQueues, HookResourceNetDevChannels, HookResourceNetDevFeatures, HookResourceNAPIAffinity,
+			HookResourceNetDevRSS, HookResourceNetDevBusyPoll, HookResourceSpecCapabilities, HookResourceSpecBPFFS,
+		},
+	}
 }
 
 func (h *HighPerformanceHooks) PreCreate(ctx context.Context, specgen *generate.Generator, s *sandbox.Sandbox, c *oci.Container) error {
@@ -74,7 +148,7 @@ func (h *HighPerformanceHooks) PreCreate(ctx context.Context, specgen *generate.
 		return nil
 	}
 
-	if requestedSharedCPUs(s.Annotations(), c.CRIContainer().GetMetadata().GetName()) {
+	if h.requestedSharedCPUs(ctx, s.Annotations(), c.CRIContainer().GetMetadata().GetName()) {
 		if isContainerCPUsSpecEmpty(specgen.Config) {
 			return fmt.Errorf("no cpus found for container %q", c.Name())
 		}
@@ -93,12 +167,27 @@ func (h *HighPerformanceHooks) PreCreate(ctx context.Context, specgen *generate.
 		// We must inject the environment variables in the PreCreate stage,
 		// because in the PreStart stage the process is already constructed.
 		// by the low-level runtime and the environment variables are already finalized.
-		injectCpusetEnv(specgen, &exclusiveCPUs, &sharedCPUSet)
+		if err := h.injectCpusetEnv(c, &exclusiveCPUs, &sharedCPUSet); err != nil {
+			return err
+		}
+		// Publish the assignment to the NRI plugins, which get the spec annotations from PostCreateContainer on.
+		specgen.AddAnnotation(crioannotations.IsolatedCPUs, exclusiveCPUs.String())
+		specgen.AddAnnotation(crioannotations.SharedCPUs, sharedCPUSet.String())
+	}
+
+	// The capabilities of the AF_XDP applications are part of the spec, so they are checked at creation.
+	if _, ok := requestedAFXDP(s.Annotations(), c.CRIContainer().GetMetadata().GetName()); ok && !h.disabled.afXDP {
+		if err := checkAFXDP(specgen, c.ID()); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
 func (h *HighPerformanceHooks) PreStart(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	ctx = withHookStage(withHookContainer(ctx, c), "PreStart")
+	ctx, span := log.StartSpan(ctx)
+	defer span.End()
 	log.Infof(ctx, "Run %q runtime handler pre-start hook for the container %q", HighPerformance, c.ID())
 
 	cSpec := c.Spec()
@@ -106,67 +195,256 @@ func (h *HighPerformanceHooks) PreStart(ctx context.Context, c *oci.Container, s
 		return nil
 	}
 
+	// A PreStart hook run again for the same container, e.g. by a retried CRI call, must not tune it twice.
+	t := h.requestedTuning(ctx, c, s)
+	if h.dryRun {
+		return h.dryRunTuning(ctx, c, s, t)
+	}
+	if tuningApplied(c.ID(), t) {
+		log.Debugf(ctx, "Tuning of container %q is already applied, skipping", c.ID())
+		return nil
+	}
+	ctx, outcome := withTuningOutcome(ctx)
+	err := verifyRequestedDPDKReadiness(ctx, c, s)
+	if err == nil {
+		err = h.applyTuning(ctx, c, s, t, true)
+	}
+	if err == nil {
+		recordAppliedTuning(ctx, c.ID(), t)
+		err = verifyRequestedTuning(ctx, c, s, t)
+	}
+	syncContainerStateTuning(ctx, c)
+	reportTuningOutcome(ctx, c.ID(), outcome, false, err)
+	return err
+}
+
+// tuning is the tuning applied to a container, which is captured in its checkpoint.
+type tuning struct {
+	// CPUs are the exclusive CPUs of the container.
+	CPUs                     string `json:"cpus,omitempty"`
+	SharedCPUs               bool   `json:"sharedCPUs,omitempty"`
+	CPULoadBalancingDisabled bool   `json:"cpuLoadBalancingDisabled,omitempty"`
+	IRQLoadBalancingDisabled bool   `json:"irqLoadBalancingDisabled,omitempty"`
+	CPUQuotaDisabled         bool   `json:"cpuQuotaDisabled,omitempty"`
+	// CStates and FreqGovernor are the values of the c-states and CPU frequency governor annotations,
+	// nil if they are not configured, FreqGovernor being never empty otherwise.
+	CStates      *string `json:"cStates,omitempty"`
+	FreqGovernor *string `json:"freqGovernor,omitempty"`
+	// PacketSteering is the value of the packet steering annotation of the container, nil if not configured.
+	PacketSteering *string `json:"packetSteering,omitempty"`
+	// VFQueues is set if the combined channels of the VFs of the pod are matched to the CPUs of the container.
+	VFQueues bool `json:"vfQueues,omitempty"`
+	// ARFS is set if the flows of the pod interfaces are steered to the CPUs consuming them by accelerated RFS.
+	ARFS bool `json:"arfs,omitempty"`
+	// VFIRQAffinity is set if the IRQs and transmit queues of the VFs of the pod are aligned with the container CPUs.
+	VFIRQAffinity bool `json:"vfIRQAffinity,omitempty"`
+	// AFXDPQueues is the count of queues of the pod interfaces reserved to the AF_XDP applications of the container.
+	AFXDPQueues int `json:"afXDPQueues,omitempty"`
+	// NAPIAffinity is the value of the NAPI affinity annotation of the container, nil if not configured.
+	NAPIAffinity *string `json:"napiAffinity,omitempty"`
+}
+
+// requestedTuning returns the tuning requested for the container by the sandbox annotations
+// and enabled in the configuration.
+func (h *HighPerformanceHooks) requestedTuning(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) *tuning {
+	annotations := s.Annotations()
+	t := &tuning{
+		SharedCPUs:               h.requestedSharedCPUs(ctx, annotations, c.CRIContainer().GetMetadata().GetName()),
+		CPULoadBalancingDisabled: !h.disabled.cpuLoadBalancing && shouldCPULoadBalancingBeDisabled(ctx, annotations),
+		IRQLoadBalancingDisabled: !h.disabled.irqLoadBalancing && shouldIRQLoadBalancingBeDisabled(ctx, annotations),
+		CPUQuotaDisabled:         !h.disabled.cpuQuota && shouldCPUQuotaBeDisabled(ctx, annotations),
+		VFQueues:                 !h.disabled.vfQueues && requestedVFQueues(annotations, c.CRIContainer().GetMetadata().GetName()),
+		ARFS:                     !h.disabled.arfs && requestedARFS(annotations, c.CRIContainer().GetMetadata().GetName()),
+		VFIRQAffinity:            !h.disabled.vfIRQAffinity && requestedVFIRQAffinity(annotations, c.CRIContainer().GetMetadata().GetName()),
+	}
+	if cSpec := c.Spec(); !isContainerCPUsSpecEmpty(&cSpec) {
+		t.CPUs = cSpec.Linux.Resources.CPU.Cpus
+	}
+	if configure, value := shouldCStatesBeConfigured(annotations); configure && !h.disabled.cStates {
+		t.CStates = &value
+	}
+	// an empty governor would restore the saved one instead of setting it, so it is not a request
+	if configure, value := shouldFreqGovernorBeConfigured(annotations); configure && value != "" && !h.disabled.freqGovernor {
+		t.FreqGovernor = &value
+	}
+	if value, ok := requestedPacketSteering(annotations, c.CRIContainer().GetMetadata().GetName()); ok && !h.disabled.packetSteering {
+		t.PacketSteering = &value
+	}
+	if value, ok := requestedNAPIAffinity(annotations, c.CRIContainer().GetMetadata().GetName()); ok && !h.disabled.napiAffinity {
+		t.NAPIAffinity = &value
+	}
+	if queues, ok := requestedAFXDP(annotations, c.CRIContainer().GetMetadata().GetName()); ok && !h.disabled.afXDP {
+		t.AFXDPQueues = queues
+	}
+	return t
+}
+
+// applyTuning applies the tuning to the container. The init process of the container
+// is only moved to the shared CPUs if pinInit is set, as restored processes keep their affinity.
+func (h *HighPerformanceHooks) applyTuning(ctx context.Context, c *oci.Container, s *sandbox.Sandbox, t *tuning, pinInit bool) error {
+	if err := measureHookStep(ctx, hookStepTunedConflicts, hookStepAttributes(c, nil), func(ctx context.Context) error {
+		return h.checkTunedConflicts(ctx, c, t)
+	}); err != nil {
+		return err
+	}
+
 	// creating libctr managers is expensive on v1. Reuse between CPU load balancing and CPU quota
 	podManager, containerManagers, err := libctrManagersForPodAndContainerCgroup(c, s.CgroupParent())
 	if err != nil {
 		return err
 	}
 
-	var sharedCPUsRequested bool
-	if requestedSharedCPUs(s.Annotations(), c.CRIContainer().GetMetadata().GetName()) {
-		sharedCPUsRequested = true
-		if containerManagers, err = setSharedCPUs(c, containerManagers, h.sharedCPUs); err != nil {
-			return fmt.Errorf("setSharedCPUs: failed to set shared CPUs for container %q; %w", c.Name(), err)
-		}
-		if err := injectQuotaGivenSharedCPUs(c, podManager, containerManagers, h.sharedCPUs); err != nil {
+	if t.SharedCPUs {
+		if err := measureHookStep(ctx, hookStepSharedCPUs, append(hookStepAttributes(c, s.Annotations(), crioannotations.CPUSharedAnnotation+"/"+c.CRIContainer().GetMetadata().GetName()), attribute.String("shared_cpuset", h.sharedCPUs)), func(ctx context.Context) error {
+			if containerManagers, err = setSharedCPUs(ctx, c, s.CgroupParent(), containerManagers, h.sharedCPUs); err != nil {
+				return fmt.Errorf("setSharedCPUs: failed to set shared CPUs for container %q; %w", c.Name(), err)
+			}
+			return injectQuotaGivenSharedCPUs(ctx, c, s.ID(), podManager, containerManagers, h.sharedCPUs)
+		}); err != nil {
 			return err
 		}
 	}
 
+	// keep the container init process and the threads it spawns on the shared CPUs
+	if pinInit && requestedInitOnSharedCPUs(s.Annotations(), c.CRIContainer().GetMetadata().GetName()) {
+		if !t.SharedCPUs {
+			log.Warnf(ctx, "Init affinity to shared CPUs requested for container %q without requesting shared CPUs, ignoring", c.ID())
+			noteUnfulfilledAnnotation(ctx, crioannotations.CPUInitAffinityAnnotation, ReasonSharedCPUsNotRequested)
+		} else if err := measureHookStep(ctx, hookStepInitAffinity, append(hookStepAttributes(c, s.Annotations(), crioannotations.CPUInitAffinityAnnotation+"/"+c.CRIContainer().GetMetadata().GetName()), attribute.String("shared_cpuset", h.sharedCPUs)), func(ctx context.Context) error {
+			return setInitAffinityToSharedCPUs(ctx, c, h.sharedCPUs)
+		}); err != nil {
+			return fmt.Errorf("set init affinity to shared CPUs: %w", err)
+		}
+	}
+
 	// disable the CPU load balancing for the container CPUs
-	if shouldCPULoadBalancingBeDisabled(ctx, s.Annotations()) {
-		if err := h.setCPULoadBalancing(ctx, c, podManager, containerManagers, false, sharedCPUsRequested); err != nil {
-			return fmt.Errorf("set CPU load balancing: %w", err)
+	cpuLoadBalancingDisabled := t.CPULoadBalancingDisabled
+	if cpuLoadBalancingDisabled {
+		if err := measureHookStep(ctx, libconfig.HighPerformanceFeatureCPULoadBalancing, hookStepAttributes(c, s.Annotations(), crioannotations.CPULoadBalancingAnnotation), func(ctx context.Context) error {
+			return h.setCPULoadBalancing(ctx, c, s.CgroupParent(), podManager, containerManagers, false, t.SharedCPUs)
+		}); err != nil {
+			if !h.failsOpen(ctx, libconfig.HighPerformanceFeatureCPULoadBalancing, c, err) {
+				return fmt.Errorf("set CPU load balancing: %w", err)
+			}
+			cpuLoadBalancingDisabled = false
+		}
+	}
+
+	// keep the isolated child cgroup alive across cgroup rewrites done by the low-level runtime
+	if t.SharedCPUs && node.CgroupIsV2() {
+		if err := measureHookStep(ctx, hookStepIsolatedCgroup, hookStepAttributes(c, nil), func(ctx context.Context) error {
+			return h.watchIsolatedChildCgroupOfContainer(ctx, c, containerManagers, cpuLoadBalancingDisabled)
+		}); err != nil {
+			return fmt.Errorf("watch isolated child cgroup: %w", err)
 		}
 	}
 
 	// disable the IRQ smp load balancing for the container CPUs
-	if shouldIRQLoadBalancingBeDisabled(ctx, s.Annotations()) {
+	if t.IRQLoadBalancingDisabled {
 		log.Infof(ctx, "Disable irq smp balancing for container %q", c.ID())
-		if err := setIRQLoadBalancing(ctx, c, false, IrqSmpAffinityProcFile, h.irqBalanceConfigFile); err != nil {
+		if err := measureHookStep(ctx, libconfig.HighPerformanceFeatureIRQLoadBalancing, hookStepAttributes(c, s.Annotations(), crioannotations.IRQLoadBalancingAnnotation), func(ctx context.Context) error {
+			return setIRQLoadBalancing(ctx, c, false, IrqSmpAffinityProcFile, h.irqBalanceConfigFile)
+		}); err != nil && !h.failsOpen(ctx, libconfig.HighPerformanceFeatureIRQLoadBalancing, c, err) {
 			return fmt.Errorf("set IRQ load balancing: %w", err)
 		}
 	}
 
+	// match the combined channels of the pod VFs to the container CPUs, before steering their queues
+	if t.VFQueues {
+		if err := measureHookStep(ctx, libconfig.HighPerformanceFeatureVFQueues, hookStepAttributes(c, s.Annotations(), crioannotations.VFQueuesAnnotation+"/"+c.CRIContainer().GetMetadata().GetName()), func(ctx context.Context) error {
+			return h.setVFQueues(ctx, c, s)
+		}); err != nil && !h.failsOpen(ctx, libconfig.HighPerformanceFeatureVFQueues, c, err) {
+			return fmt.Errorf("set VF queues: %w", err)
+		}
+	}
+
+	// reserve queues of the pod interfaces to the AF_XDP sockets, once the VF channels are set
+	if t.AFXDPQueues > 0 {
+		if err := measureHookStep(ctx, libconfig.HighPerformanceFeatureAFXDP, hookStepAttributes(c, s.Annotations(), crioannotations.AFXDPAnnotation+"/"+c.CRIContainer().GetMetadata().GetName()), func(ctx context.Context) error {
+			return h.reserveAFXDPQueues(ctx, c, s, t.AFXDPQueues)
+		}); err != nil && !h.failsOpen(ctx, libconfig.HighPerformanceFeatureAFXDP, c, err) {
+			return fmt.Errorf("reserve AF_XDP queues: %w", err)
+		}
+	}
+
+	// steer the packet processing of the pod interfaces
+	if t.PacketSteering != nil {
+		if err := measureHookStep(ctx, libconfig.HighPerformanceFeaturePacketSteering, hookStepAttributes(c, s.Annotations(), crioannotations.PacketSteeringAnnotation+"/"+c.CRIContainer().GetMetadata().GetName()), func(ctx context.Context) error {
+			return h.steerPodPackets(ctx, c, s, *t.PacketSteering)
+		}); err != nil && !h.failsOpen(ctx, libconfig.HighPerformanceFeaturePacketSteering, c, err) {
+			return fmt.Errorf("set packet steering: %w", err)
+		}
+	}
+
+	// align the IRQs and transmit queues of the pod VFs with the container CPUs, once the IRQs are balanced
+	// away from them and the packets steered, for the alignment to prevail on the VFs
+	if t.VFIRQAffinity {
+		if err := measureHookStep(ctx, libconfig.HighPerformanceFeatureVFIRQAffinity, hookStepAttributes(c, s.Annotations(), crioannotations.VFIRQAffinityAnnotation+"/"+c.CRIContainer().GetMetadata().GetName()), func(ctx context.Context) error {
+			return h.alignVFIRQs(ctx, c, s)
+		}); err != nil && !h.failsOpen(ctx, libconfig.HighPerformanceFeatureVFIRQAffinity, c, err) {
+			return fmt.Errorf("set VF IRQ affinity: %w", err)
+		}
+	}
+
+	// pin the NAPI threads of the pod interfaces
+	if t.NAPIAffinity != nil {
+		if err := measureHookStep(ctx, libconfig.HighPerformanceFeatureNAPIAffinity, hookStepAttributes(c, s.Annotations(), crioannotations.NAPIAffinityAnnotation+"/"+c.CRIContainer().GetMetadata().GetName()), func(ctx context.Context) error {
+			return h.pinPodNAPIThreads(ctx, c, s, *t.NAPIAffinity)
+		}); err != nil && !h.failsOpen(ctx, libconfig.HighPerformanceFeatureNAPIAffinity, c, err) {
+			return fmt.Errorf("set NAPI affinity: %w", err)
+		}
+	}
+
+	// steer the flows of the pod interfaces to the CPUs consuming them
+	if t.ARFS {
+		if err := measureHookStep(ctx, libconfig.HighPerformanceFeatureARFS, hookStepAttributes(c, s.Annotations(), crioannotations.ARFSAnnotation+"/"+c.CRIContainer().GetMetadata().GetName()), func(ctx context.Context) error {
+			return h.steerPodFlows(ctx, c, s)
+		}); err != nil && !h.failsOpen(ctx, libconfig.HighPerformanceFeatureARFS, c, err) {
+			return fmt.Errorf("set accelerated RFS: %w", err)
+		}
+	}
+
 	// disable the CFS quota for the container CPUs
-	if shouldCPUQuotaBeDisabled(ctx, s.Annotations()) {
+	if t.CPUQuotaDisabled {
 		log.Infof(ctx, "Disable cpu cfs quota for container %q", c.ID())
-		if err := setCPUQuota(podManager, containerManagers); err != nil {
+		if err := measureHookStep(ctx, libconfig.HighPerformanceFeatureCPUQuota, hookStepAttributes(c, s.Annotations(), crioannotations.CPUQuotaAnnotation), func(ctx context.Context) error {
+			return setCPUQuota(ctx, podManager, containerManagers)
+		}); err != nil && !h.failsOpen(ctx, libconfig.HighPerformanceFeatureCPUQuota, c, err) {
 			return fmt.Errorf("set CPU CFS quota: %w", err)
 		}
 	}
 
 	// Configure c-states for the container CPUs.
-	if configure, value := shouldCStatesBeConfigured(s.Annotations()); configure {
-		maxLatency, err := convertAnnotationToLatency(value)
+	if t.CStates != nil {
+		maxLatency, err := convertAnnotationToLatency(*t.CStates)
 		if err != nil {
 			return err
 		}
 
 		if maxLatency != "" {
-			log.Infof(ctx, "Configure c-states for container %q to %q (pm_qos_resume_latency_us: %q)", c.ID(), value, maxLatency)
-			if err := setCPUPMQOSResumeLatency(c, maxLatency); err != nil {
+			log.Infof(ctx, "Configure c-states for container %q to %q (pm_qos_resume_latency_us: %q)", c.ID(), *t.CStates, maxLatency)
+			if err := measureHookStep(ctx, libconfig.HighPerformanceFeatureCPUCStates, hookStepAttributes(c, s.Annotations(), crioannotations.CPUCStatesAnnotation), func(ctx context.Context) error {
+				return setCPUPMQOSResumeLatency(ctx, c, maxLatency)
+			}); err != nil && !h.failsOpen(ctx, libconfig.HighPerformanceFeatureCPUCStates, c, err) {
 				return fmt.Errorf("set CPU PM QOS resume latency: %w", err)
 			}
 		}
 	}
 
 	// Configure cpu freq governor for the container CPUs.
-	if configure, value := shouldFreqGovernorBeConfigured(s.Annotations()); configure {
-		log.Infof(ctx, "Configure cpu freq governor for container %q to %q", c.ID(), value)
+	if t.FreqGovernor != nil {
+		log.Infof(ctx, "Configure cpu freq governor for container %q to %q", c.ID(), *t.FreqGovernor)
 		// Set the cpu freq governor to specified value.
-		if err := setCPUFreqGovernor(c, value); err != nil {
-			return fmt.Errorf("set CPU scaling governor: %w", err)
+		if err := measureHookStep(ctx, libconfig.HighPerformanceFeatureCPUFreqGovernor, hookStepAttributes(c, s.Annotations(), crioannotations.CPUFreqGovernorAnnotation), func(ctx context.Context) error {
+			return setCPUFreqGovernor(ctx, c, *t.FreqGovernor)
+		}); err != nil {
+			// the CPUs have no cpufreq driver to set the governor of
+			if errors.Is(err, os.ErrNotExist) {
+				noteUnfulfilledAnnotation(ctx, crioannotations.CPUFreqGovernorAnnotation, ReasonCPUFreqUnavailable)
+			}
+			if !h.failsOpen(ctx, libconfig.HighPerformanceFeatureCPUFreqGovernor, c, err) {
+				return fmt.Errorf("set CPU scaling governor: %w", err)
+			}
 		}
 	}
 
@@ -174,63 +452,955 @@ func (h *HighPerformanceHooks) PreStart(ctx context.Context, c *oci.Container, s
 }
 
 func (h *HighPerformanceHooks) PreStop(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	ctx = withHookStage(withHookContainer(ctx, c), "PreStop")
 	ctx, span := log.StartSpan(ctx)
 	defer span.End()
 	log.Infof(ctx, "Run %q runtime handler pre-stop hook for the container %q", HighPerformance, c.ID())
 
+	// Stop protecting the isolated child cgroup before the tuning gets reverted.
+	releaseIsolatedChildCgroup(c.ID())
+
 	cSpec := c.Spec()
 	if !shouldRunHooks(ctx, c.ID(), &cSpec, s) {
 		return nil
 	}
+	// Nothing got tuned by PreStart in dry-run mode, only the tuning recorded before the dry-run
+	// mode got enabled on reload is left to revert.
+	if h.dryRun {
+		removeTuningPlan(ctx, c.ID())
+		if !tuningRecorded(c.ID()) {
+			return nil
+		}
+	}
+
+	ctx, outcome := withTuningOutcome(ctx)
+	err := h.revertTuning(ctx, c, s)
+	reportTuningOutcome(ctx, c.ID(), outcome, true, err)
+	return err
+}
 
+// revertTuning reverts the tuning applied to the container in PreStart.
+func (h *HighPerformanceHooks) revertTuning(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
 	// enable the IRQ smp balancing for the container CPUs
-	if shouldIRQLoadBalancingBeDisabled(ctx, s.Annotations()) {
-		if err := setIRQLoadBalancing(ctx, c, true, IrqSmpAffinityProcFile, h.irqBalanceConfigFile); err != nil {
+	if shouldIRQLoadBalancingBeDisabled(ctx, sandboxTuningAnnotations(s)) {
+		if err := measureHookStep(ctx, libconfig.HighPerformanceFeatureIRQLoadBalancing, hookStepAttributes(c, sandboxTuningAnnotations(s), crioannotations.IRQLoadBalancingAnnotation), func(ctx context.Context) error {
+			return setIRQLoadBalancing(ctx, c, true, IrqSmpAffinityProcFile, h.irqBalanceConfigFile)
+		}); err != nil && !h.failsOpen(ctx, libconfig.HighPerformanceFeatureIRQLoadBalancing, c, err) {
 			return fmt.Errorf("set IRQ load balancing: %w", err)
 		}
 	}
 
-	// disable the CPU load balancing for the container CPUs
-	if shouldCPULoadBalancingBeDisabled(ctx, s.Annotations()) {
-		podManager, containerManagers, err := libctrManagersForPodAndContainerCgroup(c, s.CgroupParent())
-		if err != nil {
+	// enable the CPU load balancing for the container CPUs
+	if shouldCPULoadBalancingBeDisabled(ctx, sandboxTuningAnnotations(s)) {
+		if err := measureHookStep(ctx, libconfig.HighPerformanceFeatureCPULoadBalancing, hookStepAttributes(c, sandboxTuningAnnotations(s), crioannotations.CPULoadBalancingAnnotation), func(ctx context.Context) error {
+			return h.enableCPULoadBalancing(ctx, c, s)
+		}); err != nil && !h.failsOpen(ctx, libconfig.HighPerformanceFeatureCPULoadBalancing, c, err) {
 			return err
 		}
-		if err := h.setCPULoadBalancing(ctx, c, podManager, containerManagers, true, requestedSharedCPUs(s.Annotations(), c.CRIContainer().GetMetadata().GetName())); err != nil {
-			return fmt.Errorf("set CPU load balancing: %w", err)
+	}
+
+	// restore the CPU affinity of the NAPI threads of the pod interfaces
+	if _, ok := requestedNAPIAffinity(sandboxTuningAnnotations(s), c.CRIContainer().GetMetadata().GetName()); ok {
+		if err := measureHookStep(ctx, libconfig.HighPerformanceFeatureNAPIAffinity, hookStepAttributes(c, sandboxTuningAnnotations(s), crioannotations.NAPIAffinityAnnotation+"/"+c.CRIContainer().GetMetadata().GetName()), func(ctx context.Context) error {
+			return revertNAPIAffinity(ctx, c.ID())
+		}); err != nil && !h.failsOpen(ctx, libconfig.HighPerformanceFeatureNAPIAffinity, c, err) {
+			return fmt.Errorf("revert NAPI affinity: %w", err)
+		}
+	}
+
+	// restore the RFS flow counts and ntuple filters of the pod interfaces
+	if requestedARFS(sandboxTuningAnnotations(s), c.CRIContainer().GetMetadata().GetName()) {
+		if err := measureHookStep(ctx, libconfig.HighPerformanceFeatureARFS, hookStepAttributes(c, sandboxTuningAnnotations(s), crioannotations.ARFSAnnotation+"/"+c.CRIContainer().GetMetadata().GetName()), func(ctx context.Context) error {
+			return revertARFS(ctx, c.ID())
+		}); err != nil && !h.failsOpen(ctx, libconfig.HighPerformanceFeatureARFS, c, err) {
+			return fmt.Errorf("revert accelerated RFS: %w", err)
+		}
+	}
+
+	// restore the affinity of the IRQs and the XPS CPU masks of the pod VFs
+	if requestedVFIRQAffinity(sandboxTuningAnnotations(s), c.CRIContainer().GetMetadata().GetName()) {
+		if err := measureHookStep(ctx, libconfig.HighPerformanceFeatureVFIRQAffinity, hookStepAttributes(c, sandboxTuningAnnotations(s), crioannotations.VFIRQAffinityAnnotation+"/"+c.CRIContainer().GetMetadata().GetName()), func(ctx context.Context) error {
+			return h.revertVFIRQAffinity(ctx, c.ID())
+		}); err != nil && !h.failsOpen(ctx, libconfig.HighPerformanceFeatureVFIRQAffinity, c, err) {
+			return fmt.Errorf("revert VF IRQ affinity: %w", err)
+		}
+	}
+
+	// restore the RPS and XPS CPU masks of the pod interfaces
+	if _, ok := requestedPacketSteering(sandboxTuningAnnotations(s), c.CRIContainer().GetMetadata().GetName()); ok {
+		if err := measureHookStep(ctx, libconfig.HighPerformanceFeaturePacketSteering, hookStepAttributes(c, sandboxTuningAnnotations(s), crioannotations.PacketSteeringAnnotation+"/"+c.CRIContainer().GetMetadata().GetName()), func(ctx context.Context) error {
+			return revertPacketSteering(ctx, c.ID())
+		}); err != nil && !h.failsOpen(ctx, libconfig.HighPerformanceFeaturePacketSteering, c, err) {
+			return fmt.Errorf("revert packet steering: %w", err)
+		}
+	}
+
+	// restore the RSS indirection tables and busy poll parameters of the pod interfaces
+	if _, ok := requestedAFXDP(sandboxTuningAnnotations(s), c.CRIContainer().GetMetadata().GetName()); ok {
+		if err := measureHookStep(ctx, libconfig.HighPerformanceFeatureAFXDP, hookStepAttributes(c, sandboxTuningAnnotations(s), crioannotations.AFXDPAnnotation+"/"+c.CRIContainer().GetMetadata().GetName()), func(ctx context.Context) error {
+			return revertAFXDPQueues(ctx, c.ID())
+		}); err != nil && !h.failsOpen(ctx, libconfig.HighPerformanceFeatureAFXDP, c, err) {
+			return fmt.Errorf("revert AF_XDP queues: %w", err)
+		}
+	}
+
+	// restore the combined channels of the pod VFs
+	if requestedVFQueues(sandboxTuningAnnotations(s), c.CRIContainer().GetMetadata().GetName()) {
+		if err := measureHookStep(ctx, libconfig.HighPerformanceFeatureVFQueues, hookStepAttributes(c, sandboxTuningAnnotations(s), crioannotations.VFQueuesAnnotation+"/"+c.CRIContainer().GetMetadata().GetName()), func(ctx context.Context) error {
+			return revertVFQueues(ctx, c.ID())
+		}); err != nil && !h.failsOpen(ctx, libconfig.HighPerformanceFeatureVFQueues, c, err) {
+			return fmt.Errorf("revert VF queues: %w", err)
 		}
 	}
 
 	// no need to reverse the cgroup CPU CFS quota setting as the pod cgroup will be deleted anyway
 
+	if err := h.restorePowerSettings(ctx, sandboxTuningAnnotations(s), c); err != nil {
+		return err
+	}
+	forgetAppliedTuning(ctx, c.ID())
+	syncContainerStateTuning(ctx, c)
+	return nil
+}
+
+// enableCPULoadBalancing reverts the CPU load balancing of the container CPUs disabled in PreStart.
+func (h *HighPerformanceHooks) enableCPULoadBalancing(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	// The container may have exited on its own, taking its cgroup along.
+	// Nothing can be read from it anymore, so rely on the state recorded in PreStart.
+	exists, err := containerCgroupExists(c.ID(), s.CgroupParent())
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if node.CgroupIsV2() {
+			if err := h.revertCPUSetExclusiveFromState(ctx, c.ID(), cpusetStateDir); err != nil {
+				return fmt.Errorf("revert exclusive cpuset of container %q: %w", c.ID(), err)
+			}
+		}
+		return nil
+	}
+	podManager, containerManagers, err := libctrManagersForPodAndContainerCgroup(c, s.CgroupParent())
+	if err != nil {
+		return err
+	}
+	if err := h.setCPULoadBalancing(ctx, c, s.CgroupParent(), podManager, containerManagers, true, requestedSharedCPUs(sandboxTuningAnnotations(s), c.CRIContainer().GetMetadata().GetName())); err != nil {
+		return fmt.Errorf("set CPU load balancing: %w", err)
+	}
+	return nil
+}
+
+// restorePowerSettings restores the c-states and cpu freq governor of the container CPUs.
+// It only relies on the container spec, so it can be used after the container process is gone.
+func (h *HighPerformanceHooks) restorePowerSettings(ctx context.Context, annotations fields.Set, c *oci.Container) error {
 	// Restore the c-state configuration for the container CPUs (only do this when the annotation is
 	// present - without the annotation we do not modify the c-state).
-	if configure, _ := shouldCStatesBeConfigured(s.Annotations()); configure {
+	if configure, _ := shouldCStatesBeConfigured(annotations); configure {
 		// Restore the original resume latency value.
-		if err := setCPUPMQOSResumeLatency(c, ""); err != nil {
+		if err := measureHookStep(ctx, libconfig.HighPerformanceFeatureCPUCStates, hookStepAttributes(c, annotations, crioannotations.CPUCStatesAnnotation), func(ctx context.Context) error {
+			return setCPUPMQOSResumeLatency(ctx, c, "")
+		}); err != nil && !h.failsOpen(ctx, libconfig.HighPerformanceFeatureCPUCStates, c, err) {
 			return fmt.Errorf("set CPU PM QOS resume latency: %w", err)
 		}
 	}
 
 	// Restore the cpu freq governor for the container CPUs (only do this when the annotation is
 	// present - without the annotation we do not modify the governor).
-	if configure, _ := shouldFreqGovernorBeConfigured(s.Annotations()); configure {
+	if configure, _ := shouldFreqGovernorBeConfigured(annotations); configure {
 		// Restore the original scaling governor.
-		if err := setCPUFreqGovernor(c, ""); err != nil {
+		if err := measureHookStep(ctx, libconfig.HighPerformanceFeatureCPUFreqGovernor, hookStepAttributes(c, annotations, crioannotations.CPUFreqGovernorAnnotation), func(ctx context.Context) error {
+			return setCPUFreqGovernor(ctx, c, "")
+		}); err != nil && !h.failsOpen(ctx, libconfig.HighPerformanceFeatureCPUFreqGovernor, c, err) {
+			return fmt.Errorf("set CPU scaling governor: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// If CPU load balancing is enabled, then *all* containers must run this PostStop hook.
+func (h *HighPerformanceHooks) PostStop(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	ctx = withHookStage(withHookContainer(ctx, c), "PostStop")
+	releaseIsolatedChildCgroup(c.ID())
+	if h.dryRun {
+		removeTuningPlan(ctx, c.ID())
+	}
+
+	// The tuning of a container which did not go through PreStop, e.g. because CRI-O crashed
+	// or the PreStop hook failed, is still recorded. Its cgroup may already be gone, but the
+	// tuning bound to its CPUs still has to be reverted.
+	if tuningRecorded(c.ID()) {
+		ctx, outcome := withTuningOutcome(ctx)
+		err := h.revertRecordedTuning(ctx, c, s)
+		reportTuningOutcome(ctx, c.ID(), outcome, true, err)
+		if err != nil {
+			log.Warnf(ctx, "Failed to revert the recorded tuning of container %q: %v", c.ID(), err)
+		}
+		forgetAppliedTuning(ctx, c.ID())
+		syncContainerStateTuning(ctx, c)
+	}
+
+	// Nothing got tuned by PreStart in dry-run mode, only the tuning recorded before the dry-run
+	// mode got enabled on reload is left to revert.
+	if h.dryRun {
+		return nil
+	}
+
+	// A container that was OOM-killed or whose runtime crashed never went through PreStop,
+	// so its CPUs may still be listed in cpuset.cpus.exclusive of the parent cgroups.
+	// Revert them based on the state recorded in PreStart, as the container cgroup may already be gone.
+	if node.CgroupIsV2() {
+		if err := h.revertCPUSetExclusiveFromState(ctx, c.ID(), cpusetStateDir); err != nil {
+			return fmt.Errorf("revert exclusive cpuset of container %q: %w", c.ID(), err)
+		}
+	}
+
+	// Stop accounting for the shared CPUs in the pod quota once their last consumer is gone,
+	// so a restarted container does not get them accounted for twice.
+	if err := h.releaseSharedCPUs(ctx, c, s); err != nil {
+		log.Warnf(ctx, "Failed to release shared CPUs of container %q: %v", c.ID(), err)
+	}
+
+	// We could check if `!cpuLoadBalancingAllowed()` here, but it requires access to the config, which would be
+	// odd to plumb. Instead, always assume if they're using a HighPerformanceHook, they have CPULoadBalanceDisabled
+	// annotation allowed.
+	defaultHooks := &DefaultCPULoadBalanceHooks{}
+	return defaultHooks.PostStop(ctx, c, s)
+}
+
+// revertRecordedTuning reverts the accelerated RFS, the NAPI affinity, the VF IRQ affinity, the packet steering,
+// the AF_XDP queues and the VF queues of the pod interfaces, which only rely on the recorded writes, and the IRQ load balancing and power settings of the container CPUs, which only rely on the container spec.
+func (h *HighPerformanceHooks) revertRecordedTuning(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	log.Infof(ctx, "Revert the recorded tuning of container %q which did not run the pre-stop hook", c.ID())
+	if err := revertARFS(ctx, c.ID()); err != nil &&
+		!h.failsOpen(ctx, libconfig.HighPerformanceFeatureARFS, c, err) {
+		return fmt.Errorf("revert accelerated RFS: %w", err)
+	}
+	if err := revertNAPIAffinity(ctx, c.ID()); err != nil &&
+		!h.failsOpen(ctx, libconfig.HighPerformanceFeatureNAPIAffinity, c, err) {
+		return fmt.Errorf("revert NAPI affinity: %w", err)
+	}
+	if err := h.revertVFIRQAffinity(ctx, c.ID()); err != nil &&
+		!h.failsOpen(ctx, libconfig.HighPerformanceFeatureVFIRQAffinity, c, err) {
+		return fmt.Errorf("revert VF IRQ affinity: %w", err)
+	}
+	if err := revertPacketSteering(ctx, c.ID()); err != nil &&
+		!h.failsOpen(ctx, libconfig.HighPerformanceFeaturePacketSteering, c, err) {
+		return fmt.Errorf("revert packet steering: %w", err)
+	}
+	if err := revertAFXDPQueues(ctx, c.ID()); err != nil &&
+		!h.failsOpen(ctx, libconfig.HighPerformanceFeatureAFXDP, c, err) {
+		return fmt.Errorf("revert AF_XDP queues: %w", err)
+	}
+	if err := revertVFQueues(ctx, c.ID()); err != nil &&
+		!h.failsOpen(ctx, libconfig.HighPerformanceFeatureVFQueues, c, err) {
+		return fmt.Errorf("revert VF queues: %w", err)
+	}
+	cSpec := c.Spec()
+	if isContainerCPUsSpecEmpty(&cSpec) {
+		return nil
+	}
+	if shouldIRQLoadBalancingBeDisabled(ctx, sandboxTuningAnnotations(s)) {
+		if err := setIRQLoadBalancing(ctx, c, true, IrqSmpAffinityProcFile, h.irqBalanceConfigFile); err != nil &&
+			!h.failsOpen(ctx, libconfig.HighPerformanceFeatureIRQLoadBalancing, c, err) {
+			return fmt.Errorf("set IRQ load balancing: %w", err)
+		}
+	}
+	return h.restorePowerSettings(ctx, sandboxTuningAnnotations(s), c)
+}
+
+// releaseSharedCPUs unregisters the container as a consumer of the shared CPUs,
+// and removes the shared CPUs from the pod quota if it was the last one.
+func (*HighPerformanceHooks) releaseSharedCPUs(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	sharedCPUSet, last := removeSharedCPUsConsumer(s.ID(), c.ID())
+	if !last {
+		return nil
+	}
+	cSpec := c.Spec()
+	if isContainerCPUsSpecEmpty(&cSpec) || cSpec.Linux.Resources.CPU.Period == nil {
+		return nil
+	}
+	podManager, _, err := libctrManagersForPodAndContainerCgroup(c, s.CgroupParent())
+	if err != nil {
+		return err
+	}
+	// the pod is being removed along with its cgroup
+	if _, err := hostFS.Stat(podManager.Path("cpu")); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	noSharedCPUs := cpuset.New()
+	newPodQuota, err := recalculatePodQuota(&sharedCPUSet, &noSharedCPUs, podManager, *cSpec.Linux.Resources.CPU.Period)
+	if err != nil {
+		return fmt.Errorf("failed to calculate pod quota: %w", err)
+	}
+	log.Debugf(ctx, "Removing shared CPUs %q from the quota of the pod of container %q", sharedCPUSet.String(), c.ID())
+	return setCgroupResources(ctx, podManager, &configs.Resources{
+		SkipDevices: true,
+		CpuQuota:    newPodQuota,
+	})
+}
+
+// PreUpdate reverts the tuning bound to the CPUs of the container, if its cpuset is about to change.
+// The tuning is re-applied to the new CPUs by PostUpdate.
+func (h *HighPerformanceHooks) PreUpdate(ctx context.Context, c *oci.Container, s *sandbox.Sandbox, resources *specs.LinuxResources) error {
+	ctx = withHookContainer(ctx, c)
+	log.Infof(ctx, "Run %q runtime handler pre-update hook for the container %q", HighPerformance, c.ID())
+
+	cSpec := c.Spec()
+	if h.dryRun || !shouldRunHooks(ctx, c.ID(), &cSpec, s) || !cpusChanged(&cSpec, resources) {
+		return nil
+	}
+
+	// The isolated child cgroup gets watched again with the new CPUs in PostUpdate.
+	releaseIsolatedChildCgroup(c.ID())
+
+	if shouldIRQLoadBalancingBeDisabled(ctx, sandboxTuningAnnotations(s)) {
+		if err := setIRQLoadBalancing(ctx, c, true, IrqSmpAffinityProcFile, h.irqBalanceConfigFile); err != nil &&
+			!h.failsOpen(ctx, libconfig.HighPerformanceFeatureIRQLoadBalancing, c, err) {
+			return fmt.Errorf("set IRQ load balancing: %w", err)
+		}
+	}
+
+	if shouldCPULoadBalancingBeDisabled(ctx, sandboxTuningAnnotations(s)) {
+		if err := h.enableCPULoadBalancing(ctx, c, s); err != nil &&
+			!h.failsOpen(ctx, libconfig.HighPerformanceFeatureCPULoadBalancing, c, err) {
+			return err
+		}
+	}
+
+	if err := h.restorePowerSettings(ctx, sandboxTuningAnnotations(s), c); err != nil {
+		return err
+	}
+	forgetCPUTuning(ctx, c.ID())
+	syncContainerStateTuning(ctx, c)
+	return nil
+}
+
+// sandboxTuningAnnotations returns the high-performance annotations the sandbox got created with, which are
+// persisted along with it so that the tuning of its containers gets reverted the way it got applied, or its
+// annotations if they were not recorded, like for the sandboxes created by former versions of CRI-O.
+func sandboxTuningAnnotations(s *sandbox.Sandbox) map[string]string {
+	if highPerformance := s.HighPerformance(); highPerformance != nil {
+		return highPerformance.Annotations
+	}
+	return s.Annotations()
+}
+
+// sandboxSharedCPUPool returns the shared CPU pool the sandbox got created with, which is persisted along with it,
+// or sharedCPUs if it was not recorded, like for the sandboxes created by former versions of CRI-O.
+func sandboxSharedCPUPool(s *sandbox.Sandbox, sharedCPUs string) string {
+	if highPerformance := s.HighPerformance(); highPerformance != nil && highPerformance.SharedCPUs != "" {
+		return highPerformance.SharedCPUs
+	}
+	return sharedCPUs
+}
+
+// restoreSharedCPUsConsumer registers a restored container consuming the shared CPUs as a consumer of the pool
+// of its sandbox, which the pod quota already accounts for. Otherwise, the next container of the pod consuming
+// them would add the pool to the pod quota once more, and the last one stopping would not remove it.
+func restoreSharedCPUsConsumer(ctx context.Context, c *oci.Container, s *sandbox.Sandbox, sharedCPUs string) error {
+	if !requestedSharedCPUs(sandboxTuningAnnotations(s), c.CRIContainer().GetMetadata().GetName()) {
+		return nil
+	}
+	cSpec := c.Spec()
+	if isContainerCPUsSpecEmpty(&cSpec) {
+		return nil
+	}
+	exclusiveCPUs, err := cpuset.Parse(cSpec.Linux.Resources.CPU.Cpus)
+	if err != nil {
+		return fmt.Errorf("failed to parse container %q cpus: %w", c.Name(), err)
+	}
+	sharedCPUSet, err := cpuset.Parse(sandboxSharedCPUPool(s, sharedCPUs))
+	if err != nil {
+		return fmt.Errorf("failed to parse shared cpus: %w", err)
+	}
+	if sharedCPUSet.IsEmpty() {
+		return nil
+	}
+	log.Debugf(ctx, "Restoring container %q as a consumer of the shared CPUs %q", c.ID(), sharedCPUSet.String())
+	addSharedCPUsConsumer(s.ID(), c.ID(), exclusiveCPUs, sharedCPUSet)
+	return nil
+}
+
+// PostUpdate re-applies the tuning of the container after its resources got updated.
+// The runtime overwrites the cpuset and CFS quota of the container cgroup, so the shared CPUs
+// and the quota are always re-applied, while the tuning bound to the CPUs is only re-applied
+// if the cpuset changed.
+func (h *HighPerformanceHooks) PostUpdate(ctx context.Context, c *oci.Container, s *sandbox.Sandbox, former *specs.LinuxResources) error {
+	ctx = withHookContainer(ctx, c)
+	log.Infof(ctx, "Run %q runtime handler post-update hook for the container %q", HighPerformance, c.ID())
+
+	cSpec := c.Spec()
+	if !shouldRunHooks(ctx, c.ID(), &cSpec, s) {
+		return nil
+	}
+	if h.dryRun {
+		return h.dryRunTuning(ctx, c, s, h.requestedTuning(ctx, c, s))
+	}
+
+	ctx, outcome := withTuningOutcome(ctx)
+	err := h.reapplyTuning(ctx, c, s, cpusChanged(&cSpec, former))
+	reportTuningOutcome(ctx, c.ID(), outcome, false, err)
+	return err
+}
+
+// reapplyTuning re-applies the tuning of the container after its resources got updated,
+// including the tuning bound to its CPUs if changed is set.
+func (h *HighPerformanceHooks) reapplyTuning(ctx context.Context, c *oci.Container, s *sandbox.Sandbox, changed bool) error {
+	podManager, containerManagers, err := libctrManagersForPodAndContainerCgroup(c, s.CgroupParent())
+	if err != nil {
+		return err
+	}
+
+	sharedCPUsRequested := h.requestedSharedCPUs(ctx, sandboxTuningAnnotations(s), c.CRIContainer().GetMetadata().GetName())
+	if sharedCPUsRequested {
+		if containerManagers, err = setSharedCPUs(ctx, c, s.CgroupParent(), containerManagers, h.sharedCPUs); err != nil {
+			return fmt.Errorf("setSharedCPUs: failed to set shared CPUs for container %q; %w", c.Name(), err)
+		}
+		if err := injectQuotaGivenSharedCPUs(ctx, c, s.ID(), podManager, containerManagers, h.sharedCPUs); err != nil {
+			return err
+		}
+	}
+
+	cpuLoadBalancingDisabled := !h.disabled.cpuLoadBalancing && shouldCPULoadBalancingBeDisabled(ctx, sandboxTuningAnnotations(s))
+	if cpuLoadBalancingDisabled && changed {
+		if err := h.setCPULoadBalancing(ctx, c, s.CgroupParent(), podManager, containerManagers, false, sharedCPUsRequested); err != nil {
+			if !h.failsOpen(ctx, libconfig.HighPerformanceFeatureCPULoadBalancing, c, err) {
+				return fmt.Errorf("set CPU load balancing: %w", err)
+			}
+			cpuLoadBalancingDisabled = false
+		}
+	}
+
+	if sharedCPUsRequested && node.CgroupIsV2() {
+		if err := h.watchIsolatedChildCgroupOfContainer(ctx, c, containerManagers, cpuLoadBalancingDisabled); err != nil {
+			return fmt.Errorf("watch isolated child cgroup: %w", err)
+		}
+	}
+
+	if changed && !h.disabled.irqLoadBalancing && shouldIRQLoadBalancingBeDisabled(ctx, sandboxTuningAnnotations(s)) {
+		if err := setIRQLoadBalancing(ctx, c, false, IrqSmpAffinityProcFile, h.irqBalanceConfigFile); err != nil &&
+			!h.failsOpen(ctx, libconfig.HighPerformanceFeatureIRQLoadBalancing, c, err) {
+			return fmt.Errorf("set IRQ load balancing: %w", err)
+		}
+	}
+
+	if !h.disabled.cpuQuota && shouldCPUQuotaBeDisabled(ctx, sandboxTuningAnnotations(s)) {
+		if err := setCPUQuota(ctx, podManager, containerManagers); err != nil &&
+			!h.failsOpen(ctx, libconfig.HighPerformanceFeatureCPUQuota, c, err) {
+			return fmt.Errorf("set CPU CFS quota: %w", err)
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	if configure, value := shouldCStatesBeConfigured(sandboxTuningAnnotations(s)); configure && !h.disabled.cStates {
+		maxLatency, err := convertAnnotationToLatency(value)
+		if err != nil {
+			return err
+		}
+		if maxLatency != "" {
+			if err := setCPUPMQOSResumeLatency(ctx, c, maxLatency); err != nil &&
+				!h.failsOpen(ctx, libconfig.HighPerformanceFeatureCPUCStates, c, err) {
+				return fmt.Errorf("set CPU PM QOS resume latency: %w", err)
+			}
+		}
+	}
+
+	if configure, value := shouldFreqGovernorBeConfigured(sandboxTuningAnnotations(s)); configure && !h.disabled.freqGovernor {
+		if err := setCPUFreqGovernor(ctx, c, value); err != nil &&
+			!h.failsOpen(ctx, libconfig.HighPerformanceFeatureCPUFreqGovernor, c, err) {
 			return fmt.Errorf("set CPU scaling governor: %w", err)
 		}
 	}
 
-	return nil
-}
-
-// If CPU load balancing is enabled, then *all* containers must run this PostStop hook.
-func (*HighPerformanceHooks) PostStop(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
-	// We could check if `!cpuLoadBalancingAllowed()` here, but it requires access to the config, which would be
-	// odd to plumb. Instead, always assume if they're using a HighPerformanceHook, they have CPULoadBalanceDisabled
-	// annotation allowed.
-	h := &DefaultCPULoadBalanceHooks{}
-	return h.PostStop(ctx, c, s)
+	recordAppliedTuning(ctx, c.ID(), h.requestedTuning(ctx, c, s))
+	syncContainerStateTuning(ctx, c)
+	return nil
+}
+
+// cpusChanged returns true if the resources set a cpuset different from the one of the spec.
+func cpusChanged(spec *specs.Spec, resources *specs.LinuxResources) bool {
+	if resources == nil || resources.CPU == nil || resources.CPU.Cpus == "" || isContainerCPUsSpecEmpty(spec) {
+		return false
+	}
+	current, err := cpuset.Parse(spec.Linux.Resources.CPU.Cpus)
+	if err != nil {
+		return true
+	}
+	updated, err := cpuset.Parse(resources.CPU.Cpus)
+	if err != nil {
+		return true
+	}
+	return !current.Equals(updated)
+}
+
+// PreCheckpoint captures the tuning of the container into its directory, which is part of the checkpoint archive.
+func (h *HighPerformanceHooks) PreCheckpoint(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	log.Infof(ctx, "Run %q runtime handler pre-checkpoint hook for the container %q", HighPerformance, c.ID())
+
+	cSpec := c.Spec()
+	if !shouldRunHooks(ctx, c.ID(), &cSpec, s) {
+		return nil
+	}
+	return saveTuning(c.Dir(), h.requestedTuning(ctx, c, s))
+}
+
+// PostRestore applies the tuning to the container restored from a checkpoint, which does not run PreStart.
+// The tuning applied is the one requested by the sandbox the container got restored into, so that it gets
+// reverted on stop, and the tuning captured in the checkpoint which is not requested anymore is reported.
+func (h *HighPerformanceHooks) PostRestore(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	ctx = withHookStage(withHookContainer(ctx, c), "PostRestore")
+	log.Infof(ctx, "Run %q runtime handler post-restore hook for the container %q", HighPerformance, c.ID())
+
+	captured, err := loadTuning(c.Dir())
+	if err != nil {
+		return err
+	}
+
+	requested := &tuning{}
+	cSpec := c.Spec()
+	eligible := shouldRunHooks(ctx, c.ID(), &cSpec, s)
+	if eligible {
+		requested = h.requestedTuning(ctx, c, s)
+	}
+	if captured != nil {
+		if lost := lostTuning(captured, requested); len(lost) > 0 {
+			log.Warnf(ctx, "Container %q got restored without the tuning captured in its checkpoint: %s", c.ID(), strings.Join(lost, ", "))
+		}
+		if captured.CPUs != requested.CPUs && requested.CPUs != "" {
+			log.Infof(ctx, "Apply the tuning of the restored container %q to the CPUs %s instead of %s", c.ID(), requested.CPUs, captured.CPUs)
+		}
+	}
+	if !eligible {
+		return nil
+	}
+	if h.dryRun {
+		return h.dryRunTuning(ctx, c, s, requested)
+	}
+	ctx, outcome := withTuningOutcome(ctx)
+	err = h.applyTuning(ctx, c, s, requested, false)
+	if err == nil {
+		recordAppliedTuning(ctx, c.ID(), requested)
+		err = verifyRequestedTuning(ctx, c, s, requested)
+	}
+	syncContainerStateTuning(ctx, c)
+	reportTuningOutcome(ctx, c.ID(), outcome, false, err)
+	return err
+}
+
+func tuningFile(dir string) string {
+	return filepath.Join(dir, constants.TuningCheckpointFile)
+}
+
+func saveTuning(dir string, t *tuning) error {
+	content, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(tuningFile(dir), content, 0o644)
+}
+
+// loadTuning returns the tuning captured in the checkpoint the container got restored from,
+// nil if there is none, and removes it from the container directory.
+func loadTuning(dir string) (*tuning, error) {
+	content, err := os.ReadFile(tuningFile(dir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	t := &tuning{}
+	if err := json.Unmarshal(content, t); err != nil {
+		return nil, fmt.Errorf("decode the tuning captured in the checkpoint: %w", err)
+	}
+	if err := os.Remove(tuningFile(dir)); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// lostTuning returns the features of the captured tuning which are not part of the requested one.
+func lostTuning(captured, requested *tuning) []string {
+	var lost []string
+	if captured.SharedCPUs && !requested.SharedCPUs {
+		lost = append(lost, "shared-cpus")
+	}
+	if captured.CPULoadBalancingDisabled && !requested.CPULoadBalancingDisabled {
+		lost = append(lost, libconfig.HighPerformanceFeatureCPULoadBalancing)
+	}
+	if captured.IRQLoadBalancingDisabled && !requested.IRQLoadBalancingDisabled {
+		lost = append(lost, libconfig.HighPerformanceFeatureIRQLoadBalancing)
+	}
+	if captured.CPUQuotaDisabled && !requested.CPUQuotaDisabled {
+		lost = append(lost, libconfig.HighPerformanceFeatureCPUQuota)
+	}
+	if captured.CStates != nil && requested.CStates == nil {
+		lost = append(lost, libconfig.HighPerformanceFeatureCPUCStates)
+	}
+	if captured.FreqGovernor != nil && requested.FreqGovernor == nil {
+		lost = append(lost, libconfig.HighPerformanceFeatureCPUFreqGovernor)
+	}
+	if captured.PacketSteering != nil && requested.PacketSteering == nil {
+		lost = append(lost, libconfig.HighPerformanceFeaturePacketSteering)
+	}
+	if captured.VFQueues && !requested.VFQueues {
+		lost = append(lost, libconfig.HighPerformanceFeatureVFQueues)
+	}
+	if captured.ARFS && !requested.ARFS {
+		lost = append(lost, libconfig.HighPerformanceFeatureARFS)
+	}
+	if captured.VFIRQAffinity && !requested.VFIRQAffinity {
+		lost = append(lost, libconfig.HighPerformanceFeatureVFIRQAffinity)
+	}
+	if captured.AFXDPQueues > 0 && requested.AFXDPQueues == 0 {
+		lost = append(lost, libconfig.HighPerformanceFeatureAFXDP)
+	}
+	if captured.NAPIAffinity != nil && requested.NAPIAffinity == nil {
+		lost = append(lost, libconfig.HighPerformanceFeatureNAPIAffinity)
+	}
+	return lost
+}
+
+// ReconcileCPULoadBalancing verifies the exclusive cpuset chain of a running container with the CPU load
+// balancing disabled, and rebuilds the entries removed by systemd or other agents while CRI-O was down.
+// The chain is checked against the state recorded in PreStart, and rebuilt from the container spec,
+// which is a no-op for the cgroups still holding the exclusive CPUs.
+func (h *HighPerformanceHooks) ReconcileCPULoadBalancing(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	ctx = withHookContainer(ctx, c)
+	if h.dryRun || !node.CgroupIsV2() || h.disabled.cpuLoadBalancing || !shouldCPULoadBalancingBeDisabled(ctx, sandboxTuningAnnotations(s)) {
+		return nil
+	}
+	cSpec := c.Spec()
+	if !shouldRunHooks(ctx, c.ID(), &cSpec, s) {
+		return nil
+	}
+
+	state, err := loadCPUSetState(cpusetStateDir, c.ID())
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		log.Infof(ctx, "No exclusive cpuset recorded for container %q, rebuilding it", c.ID())
+	case err != nil:
+		return fmt.Errorf("load exclusive cpuset state: %w", err)
+	default:
+		drifted, err := cpusetChainDrift(state)
+		if err != nil {
+			return err
+		}
+		if len(drifted) == 0 {
+			log.Debugf(ctx, "Exclusive cpuset of container %q is intact", c.ID())
+			return nil
+		}
+		log.Warnf(ctx, "Repairing the exclusive cpuset of container %q, lost by: %s", c.ID(), strings.Join(drifted, ", "))
+	}
+	return h.rebuildCPUSetChain(ctx, c, s)
+}
+
+// rebuildCPUSetChain disables the CPU load balancing of the container again, which rewrites its exclusive cpuset chain.
+func (h *HighPerformanceHooks) rebuildCPUSetChain(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	podManager, containerManagers, err := libctrManagersForPodAndContainerCgroup(c, s.CgroupParent())
+	if err != nil {
+		return err
+	}
+	sharedCPUsRequested := h.requestedSharedCPUs(ctx, sandboxTuningAnnotations(s), c.CRIContainer().GetMetadata().GetName())
+	if sharedCPUsRequested {
+		if containerManagers, err = setSharedCPUs(ctx, c, s.CgroupParent(), containerManagers, h.sharedCPUs); err != nil {
+			return fmt.Errorf("setSharedCPUs: failed to set shared CPUs for container %q; %w", c.Name(), err)
+		}
+	}
+	if err := h.setCPULoadBalancing(ctx, c, s.CgroupParent(), podManager, containerManagers, false, sharedCPUsRequested); err != nil {
+		return fmt.Errorf("set CPU load balancing: %w", err)
+	}
+	return nil
+}
+
+// cpusetChainDrift returns the cgroups of the recorded chain which do not hold the exclusive CPUs anymore,
+// including the container cgroup if it is not an isolated partition anymore.
+func cpusetChainDrift(state *cpusetState) ([]string, error) {
+	exclusiveCPUs, err := cpuset.Parse(state.ExclusiveCPUs)
+	if err != nil {
+		return nil, err
+	}
+	drifted := []string{}
+	for i, cg := range state.Cgroups {
+		content, err := hostFS.ReadCgroupFile(cg.Path, cpusetCpusExclusive)
+		if err != nil {
+			return nil, err
+		}
+		cpus, err := cpuset.Parse(strings.TrimSpace(content))
+		if err != nil {
+			return nil, err
+		}
+		if !exclusiveCPUs.IsSubsetOf(cpus) {
+			drifted = append(drifted, filepath.Join(cg.Path, cpusetCpusExclusive))
+		}
+		if i == len(state.Cgroups)-1 {
+			partition, err := hostFS.ReadCgroupFile(cg.Path, cpusetCpusPartition)
+			if err != nil {
+				return nil, err
+			}
+			if strings.TrimSpace(partition) != "isolated" {
+				drifted = append(drifted, filepath.Join(cg.Path, cpusetCpusPartition))
+			}
+		}
+	}
+	return drifted, nil
+}
+
+// RepairTuningDrift compares the tuning recorded for a running container with the actual sysfs, cgroup and IRQ
+// settings of the node, and repairs the differences, e.g. after another agent or an operator rewrote them.
+// The per-CPU c-states and governor files have to hold the recorded value, while the IRQ affinity mask and the
+// exclusive cpuset chain, shared with the other containers, only have to keep the CPUs of the container excluded.
+func (h *HighPerformanceHooks) RepairTuningDrift(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) ([]string, error) {
+	ctx = withHookContainer(ctx, c)
+	record, ok := recordedTuning(c.ID())
+	if !ok || record.Tuning == nil {
+		return nil, nil
+	}
+
+	drifted := []string{}
+	var errs []error
+	for _, w := range record.Writes {
+		if w.Path == IrqSmpAffinityProcFile || filepath.Base(w.Path) == cpusetCpusPartition {
+			continue
+		}
+		content, err := w.read()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if strings.TrimSpace(string(content)) == w.Value {
+			continue
+		}
+		drifted = append(drifted, w.Path)
+		// the tuning may have been reverted meanwhile, do not tune the CPUs again
+		if !tuningRecorded(c.ID()) {
+			return drifted, nil
+		}
+		if err := w.write(ctx, []byte(w.Value)); err != nil {
+			errs = append(errs, fmt.Errorf("repair %s: %w", w.Path, err))
+		}
+	}
+
+	if record.Tuning.IRQLoadBalancingDisabled {
+		irqDrifted, err := irqLoadBalancingDrift(record.Tuning.CPUs, IrqSmpAffinityProcFile)
+		switch {
+		case err != nil:
+			errs = append(errs, err)
+		case irqDrifted && tuningRecorded(c.ID()):
+			drifted = append(drifted, IrqSmpAffinityProcFile)
+			if err := setIRQLoadBalancing(ctx, c, false, IrqSmpAffinityProcFile, h.irqBalanceConfigFile); err != nil {
+				errs = append(errs, fmt.Errorf("repair IRQ load balancing: %w", err))
+			}
+		}
+	}
+
+	if record.Tuning.CPULoadBalancingDisabled && node.CgroupIsV2() {
+		state, err := loadCPUSetState(cpusetStateDir, c.ID())
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			// the exclusive cpuset chain is only recorded by the hooks since it is reconciled on startup
+		case err != nil:
+			errs = append(errs, fmt.Errorf("load exclusive cpuset state: %w", err))
+		default:
+			chainDrifted, err := cpusetChainDrift(state)
+			if err != nil {
+				errs = append(errs, err)
+				break
+			}
+			if len(chainDrifted) > 0 && tuningRecorded(c.ID()) {
+				drifted = append(drifted, chainDrifted...)
+				if err := h.rebuildCPUSetChain(ctx, c, s); err != nil {
+					errs = append(errs, fmt.Errorf("repair exclusive cpuset: %w", err))
+				}
+			}
+		}
+	}
+
+	return drifted, errors.Join(errs...)
+}
+
+// ReconcileTuning reconciles the tuning of a running container with the configuration reloaded on SIGHUP.
+// The CPUs of the container excluded from the IRQ load balancing get banned in the irqbalance configuration file
+// of the hooks, which may have changed, before the drift of the recorded tuning gets repaired.
+func (h *HighPerformanceHooks) ReconcileTuning(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) ([]string, error) {
+	record, ok := recordedTuning(c.ID())
+	if !ok || record.Tuning == nil {
+		return nil, nil
+	}
+
+	var errs []error
+	if record.Tuning.IRQLoadBalancingDisabled {
+		if err := setIRQLoadBalancing(withHookContainer(ctx, c), c, false, IrqSmpAffinityProcFile, h.irqBalanceConfigFile); err != nil {
+			errs = append(errs, fmt.Errorf("reconcile IRQ load balancing: %w", err))
+		}
+	}
+	drifted, err := h.RepairTuningDrift(ctx, c, s)
+	return drifted, errors.Join(append(errs, err)...)
+}
+
+// ReconcileRestoredTuning re-establishes the tuning of a running container restored on startup, which may have been
+// lost while CRI-O was down, e.g. rewritten by a node agent. The recorded tuning is verified and repaired, or the
+// requested tuning gets applied again if it was only partially applied. The tuning of a container without record is
+// left as is, as the original values of the files it may have written are unknown.
+// A container consuming the shared CPUs is registered as a consumer of the shared CPU pool of its sandbox again.
+func (h *HighPerformanceHooks) ReconcileRestoredTuning(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) ([]string, error) {
+	ctx = withHookStage(withHookContainer(ctx, c), "Restore")
+	cSpec := c.Spec()
+	if h.dryRun || !shouldRunHooks(ctx, c.ID(), &cSpec, s) {
+		return nil, nil
+	}
+	if err := restoreSharedCPUsConsumer(ctx, c, s, h.sharedCPUs); err != nil {
+		return nil, fmt.Errorf("restore shared CPUs consumer: %w", err)
+	}
+
+	record, ok := recordedTuning(c.ID())
+	if !ok {
+		// The records of the tuning state directory and of the container state are both lost. The tuning may
+		// still be in place, and applying it again would record the tuned values as the ones to restore.
+		if missing := lostTuning(h.requestedTuning(ctx, c, s), &tuning{}); len(missing) > 0 {
+			log.Warnf(ctx, "No tuning recorded for the restored container %q, leaving its tuning as is: %s", c.ID(), strings.Join(missing, ", "))
+		}
+		return nil, nil
+	}
+	if record.Tuning != nil {
+		err := h.ReconcileCPULoadBalancing(ctx, c, s)
+		drifted, driftErr := h.RepairTuningDrift(ctx, c, s)
+		return drifted, errors.Join(err, driftErr)
+	}
+
+	// The tuning got partially applied, the original values of the files written are recorded.
+	requested := h.requestedTuning(ctx, c, s)
+	// the features of the requested tuning, none of them being recorded as applied
+	missing := lostTuning(requested, &tuning{})
+	if len(missing) == 0 {
+		return nil, nil
+	}
+	log.Warnf(ctx, "Tuning of the restored container %q got partially applied, applying it again: %s", c.ID(), strings.Join(missing, ", "))
+	ctx, outcome := withTuningOutcome(ctx)
+	err := h.applyTuning(ctx, c, s, requested, false)
+	if err == nil {
+		recordAppliedTuning(ctx, c.ID(), requested)
+	}
+	syncContainerStateTuning(ctx, c)
+	reportTuningOutcome(ctx, c.ID(), outcome, false, err)
+	return missing, err
+}
+
+// irqLoadBalancingDrift returns true if some of the cpus got added back to the IRQ affinity mask of irqSmpAffinityFile.
+func irqLoadBalancingDrift(cpus, irqSmpAffinityFile string) (bool, error) {
+	expected, current, err := irqLoadBalancingMasks(cpus, irqSmpAffinityFile)
+	if err != nil {
+		return false, err
+	}
+	return expected != current, nil
+}
+
+// irqLoadBalancingMasks returns the current IRQ affinity mask of irqSmpAffinityFile, and the one expected
+// with the cpus excluded from it.
+func irqLoadBalancingMasks(cpus, irqSmpAffinityFile string) (expected, current string, err error) {
+	content, err := hostFS.ReadFile(irqSmpAffinityFile)
+	if err != nil {
+		return "", "", err
+	}
+	current = strings.TrimSpace(string(content))
+	expected, _, err = cpumask.Update(cpus, current, false)
+	if err != nil {
+		return "", "", err
+	}
+	return expected, current, nil
+}
+
+// UpdateSharedCPUs reconciles a running container consuming the shared CPUs with the current shared CPU pool.
+// The container cgroup cpuset and CFS quota, as well as the pod CFS quota, are updated to the new pool.
+// The environment variables injected in PreCreate can not be changed anymore, and keep advertising the former pool.
+// It returns whether the container got updated.
+func (h *HighPerformanceHooks) UpdateSharedCPUs(ctx context.Context, c *oci.Container, s *sandbox.Sandbox, oldSharedCPUs string) (bool, error) {
+	ctx = withHookContainer(ctx, c)
+	if h.dryRun || !h.requestedSharedCPUs(ctx, sandboxTuningAnnotations(s), c.CRIContainer().GetMetadata().GetName()) {
+		return false, nil
+	}
+	cSpec := c.Spec()
+	if !shouldRunHooks(ctx, c.ID(), &cSpec, s) {
+		return false, nil
+	}
+	if isContainerCPUsSpecEmpty(&cSpec) {
+		return false, fmt.Errorf("no cpus found for container %q", c.Name())
+	}
+	exclusiveCPUs, err := cpuset.Parse(cSpec.Linux.Resources.CPU.Cpus)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse container %q cpus: %w", c.Name(), err)
+	}
+	oldSharedCPUSet, err := cpuset.Parse(oldSharedCPUs)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse former shared cpus: %w", err)
+	}
+	newSharedCPUSet, err := cpuset.Parse(h.sharedCPUs)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse shared cpus: %w", err)
+	}
+	if oldSharedCPUSet.Equals(newSharedCPUSet) {
+		return false, nil
+	}
+
+	podManager, containerManagers, err := libctrManagersForPodAndContainerCgroup(c, s.CgroupParent())
+	if err != nil {
+		return false, err
+	}
+	ctrManager, err := getManagerByIndex(len(containerManagers)-1, containerManagers)
+	if err != nil {
+		return false, err
+	}
+
+	// pod level operations, done once per pod as all the containers share the same pool.
+	// A container unknown to the bookkeeping, e.g. after a restart of the server, is assumed
+	// to have been accounted for with the former pool.
+	period := *cSpec.Linux.Resources.CPU.Period
+	addSharedCPUsConsumer(s.ID(), c.ID(), exclusiveCPUs, oldSharedCPUSet)
+	if formerSharedCPUSet, changed := updateSandboxSharedCPUs(s.ID(), newSharedCPUSet); changed {
+		newPodQuota, err := recalculatePodQuota(&formerSharedCPUSet, &newSharedCPUSet, podManager, period)
+		if err != nil {
+			return false, fmt.Errorf("failed to calculate pod quota: %w", err)
+		}
+		if err := setCgroupResources(ctx, podManager, &configs.Resources{
+			SkipDevices: true,
+			CpuQuota:    newPodQuota,
+		}); err != nil {
+			return false, err
+		}
+	}
+
+	// container level operations
+	ctrCPUSet := exclusiveCPUs.Union(newSharedCPUSet)
+	ctrQuota, err := calculateMaximalQuota(&ctrCPUSet, period)
+	if err != nil {
+		return false, fmt.Errorf("failed to calculate container %s quota: %w", c.ID(), err)
+	}
+	// Let the isolated child cgroup watcher know about the new set first, to not have it revert the change.
+	updateIsolatedChildCgroupCPUs(c.ID(), ctrCPUSet)
+	// As in setSharedCPUs, the scope of the container must allow the new pool, or a daemon reload of systemd reverts it.
+	if err := setSystemdContainerCPUs(ctx, c.ID(), s.CgroupParent(), ctrCPUSet.String()); err != nil {
+		return false, err
+	}
+	if err := setCgroupResources(ctx, ctrManager, &configs.Resources{
+		SkipDevices: true,
+		CpusetCpus:  ctrCPUSet.String(),
+		CpuQuota:    ctrQuota,
+	}); err != nil {
+		return false, err
+	}
+	// Rewriting the cpuset of the container may drop its CPUs from cpuset.cpus.exclusive of the chain,
+	// which has to keep them reserved while the CPU load balancing is disabled.
+	if err := h.ReconcileCPULoadBalancing(ctx, c, s); err != nil {
+		return false, fmt.Errorf("keep exclusive cpuset of container %q: %w", c.ID(), err)
+	}
+
+	log.Infof(ctx, "Updated shared CPUs of container %q from %q to %q (cpuset: %q, quota: %d)",
+		c.ID(), oldSharedCPUSet.String(), newSharedCPUSet.String(), ctrCPUSet.String(), ctrQuota)
+	writeContainerStateFile(ctx, c.ID())
+	return true, nil
 }
 
 func shouldCPULoadBalancingBeDisabled(ctx context.Context, annotations fields.Set) bool {
@@ -280,18 +1450,53 @@ func requestedSharedCPUs(annotations fields.Set, cName string) bool {
 	return ok && v == annotationEnable
 }
 
+func requestedInitOnSharedCPUs(annotations fields.Set, cName string) bool {
+	key := crioannotations.CPUInitAffinityAnnotation + "/" + cName
+	v, ok := annotations[key]
+	return ok && v == annotationShared
+}
+
+// requestedPacketSteering returns the packet steering policy requested for the container, if any.
+func requestedPacketSteering(annotations fields.Set, cName string) (string, bool) {
+	value, ok := annotations[crioannotations.PacketSteeringAnnotation+"/"+cName]
+	return value, ok
+}
+
+// requestedNAPIAffinity returns the NAPI affinity policy requested for the container, if any.
+func requestedNAPIAffinity(annotations fields.Set, cName string) (string, bool) {
+	value, ok := annotations[crioannotations.NAPIAffinityAnnotation+"/"+cName]
+	return value, ok
+}
+
+// requestedVFQueues returns whether the combined channels of the VFs of the pod are requested to be
+// matched to the CPUs of the container.
+func requestedVFQueues(annotations fields.Set, cName string) bool {
+	return annotations[crioannotations.VFQueuesAnnotation+"/"+cName] == annotationEnable
+}
+
+// requestedVFIRQAffinity returns whether the IRQs of the VFs of the pod are requested to be aligned
+// with the CPUs of the container.
+func requestedVFIRQAffinity(annotations fields.Set, cName string) bool {
+	return annotations[crioannotations.VFIRQAffinityAnnotation+"/"+cName] == annotationEnable
+}
+
+// requestedARFS returns whether accelerated RFS is requested on the interfaces of the pod of the container.
+func requestedARFS(annotations fields.Set, cName string) bool {
+	return annotations[crioannotations.ARFSAnnotation+"/"+cName] == annotationEnable
+}
+
 // setCPULoadBalancing relies on the cpuset cgroup to disable load balancing for containers.
 // The requisite condition to allow this is `cpuset.sched_load_balance` field must be set to 0 for all cgroups
 // that intersect with `cpuset.cpus` of the container that desires load balancing.
 // Since CRI-O is the owner of the container cgroup, it must set this value for
 // the container. Some other entity (kubelet, external service) must ensure this is the case for all
 // other cgroups that intersect (at minimum: all parent cgroups of this cgroup).
-func (h *HighPerformanceHooks) setCPULoadBalancing(ctx context.Context, c *oci.Container, podManager cgroups.Manager, containerManagers []cgroups.Manager, enable, sharedCPUsRequested bool) error {
+func (h *HighPerformanceHooks) setCPULoadBalancing(ctx context.Context, c *oci.Container, parentDir string, podManager cgroups.Manager, containerManagers []cgroups.Manager, enable, sharedCPUsRequested bool) error {
 	if node.CgroupIsV2() {
 		return h.setCPULoadBalancingV2(ctx, c, podManager, containerManagers, enable, sharedCPUsRequested)
 	}
 	if !enable {
-		if err := disableCPULoadBalancingV1(containerManagers); err != nil {
+		if err := disableCPULoadBalancingV1(ctx, c.ID(), parentDir); err != nil {
 			return err
 		}
 	}
@@ -343,29 +1548,22 @@ func (h *HighPerformanceHooks) setCPULoadBalancingV2(ctx context.Context, c *oci
 	// "shared" in this context means there will be other active cgroups as children, so we can't have cpuset.cpus
 	// only have the exclusive set. Instead, those shared cgroups must have the full set, and cpuset.cpus.exclusive
 	// should still have the exclusive set.
-	directories := strings.Split(strings.TrimPrefix(podManagerPath, cgroupMountPoint), "/")
-	managers := make([]*desiredManagerCPUSetState, 0)
-	parent := ""
-
 	allCPUs, err := fullCPUSet()
 	if err != nil {
 		return err
 	}
 
-	for _, dir := range directories {
-		if dir == "" {
-			continue
-		}
-		mgr, err := libctrManager(dir, parent, systemd)
-		if err != nil {
-			return err
-		}
+	podHierarchy, err := cgroupHierarchyFrom(podManagerPath, systemd)
+	if err != nil {
+		return err
+	}
+	managers := make([]*desiredManagerCPUSetState, 0, len(podHierarchy)+len(containerManagers))
+	for _, mgr := range podHierarchy {
 		managers = append(managers, &desiredManagerCPUSetState{
 			manager:       mgr,
 			exclusiveCPUs: exclusiveCPUs,
 			cpus:          allCPUs,
 		})
-		parent = filepath.Join(parent, dir)
 	}
 
 	var childState *desiredManagerCPUSetState
@@ -409,25 +1607,183 @@ func (h *HighPerformanceHooks) setCPULoadBalancingV2(ctx context.Context, c *oci
 	// Changes are applied in reverse, so hopefully all the changes are reverted correctly.
 	defer func() {
 		if retErr != nil {
-			if err = h.addOrRemoveCpusetFromManagers(managers, enable); err != nil {
+			if err = h.addOrRemoveCpusetFromManagers(ctx, managers, enable); err != nil {
 				log.Errorf(ctx, "Failed to revert cpuset values: %v", err)
 			}
 		}
 	}()
-	if err := h.addOrRemoveCpusetFromManagers(managers, !enable); err != nil {
+	if err := h.addOrRemoveCpusetFromManagers(ctx, managers, !enable); err != nil {
 		return err
 	}
 
 	// If re-enabling load balancing, then no need to write "isolated" to the cgroup.
 	// It should be cleaned up soon anyway.
 	if enable {
-		return nil
+		return removeCPUSetState(cpusetStateDir, c.ID())
 	}
 	// The last entry is the actual container cgroup, so write to it directly to finish the work.
-	return cgroups.WriteFile(managers[len(managers)-1].manager.Path(""), "cpuset.cpus.partition", "isolated")
+	ctrCgroupPath := managers[len(managers)-1].manager.Path("")
+	partition, err := hostFS.ReadCgroupFile(ctrCgroupPath, cpusetCpusPartition)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			if capErr := node.RequireCapability(node.CapabilityCpusetPartition); capErr != nil {
+				return capErr
+			}
+		}
+		return err
+	}
+	if err := writeCgroupFile(ctx, ctrCgroupPath, cpusetCpusPartition, "isolated"); err != nil {
+		return err
+	}
+	recordTuningWrite(ctx, c.ID(), filepath.Join(ctrCgroupPath, cpusetCpusPartition), strings.TrimSpace(partition), "isolated")
+	return saveCPUSetState(cpusetStateDir, c.ID(), newCPUSetState(exclusiveCPUs, managers))
+}
+
+// cpusetState is the on-disk record of the cgroups a container's exclusive CPUs were added to.
+// It allows reverting the cpuset.cpus.exclusive chain without reading the container cgroup,
+// which may already be removed when the container dies abnormally.
+type cpusetState struct {
+	// ExclusiveCPUs are the CPUs added to cpuset.cpus.exclusive of every cgroup in the chain.
+	ExclusiveCPUs string `json:"exclusiveCPUs"`
+	// Cgroups is the chain of cgroups, ordered from top to bottom.
+	Cgroups []cpusetCgroupState `json:"cgroups"`
+}
+
+// cpusetCgroupState describes a single cgroup of the chain.
+type cpusetCgroupState struct {
+	Path string `json:"path"`
+	// ExclusiveOnly is set when cpuset.cpus of the cgroup contains only the exclusive CPUs,
+	// meaning they should be removed from cpuset.cpus as well.
+	ExclusiveOnly bool `json:"exclusiveOnly,omitempty"`
+}
+
+func cpusetStateFile(stateDir, containerID string) string {
+	return filepath.Join(stateDir, containerID+".json")
+}
+
+func newCPUSetState(exclusiveCPUs cpuset.CPUSet, managers []*desiredManagerCPUSetState) *cpusetState {
+	state := &cpusetState{
+		ExclusiveCPUs: exclusiveCPUs.String(),
+		Cgroups:       make([]cpusetCgroupState, 0, len(managers)),
+	}
+	for _, mgr := range managers {
+		state.Cgroups = append(state.Cgroups, cpusetCgroupState{
+			Path:          mgr.manager.Path(""),
+			ExclusiveOnly: mgr.exclusiveCPUs.Equals(mgr.cpus),
+		})
+	}
+	return state
+}
+
+func saveCPUSetState(stateDir, containerID string, state *cpusetState) error {
+	content, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(stateDir, 0o750); err != nil {
+		return err
+	}
+	return os.WriteFile(cpusetStateFile(stateDir, containerID), content, 0o644)
+}
+
+func loadCPUSetState(stateDir, containerID string) (*cpusetState, error) {
+	content, err := os.ReadFile(cpusetStateFile(stateDir, containerID))
+	if err != nil {
+		return nil, err
+	}
+	state := &cpusetState{}
+	if err := json.Unmarshal(content, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func removeCPUSetState(stateDir, containerID string) error {
+	if err := os.Remove(cpusetStateFile(stateDir, containerID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// revertCPUSetExclusiveFromState removes the container's exclusive CPUs from every cgroup of
+// the recorded chain that still exists, going from bottom to top.
+// It is a no-op if no state was recorded, or if PreStop already reverted the changes.
+func (h *HighPerformanceHooks) revertCPUSetExclusiveFromState(ctx context.Context, containerID, stateDir string) error {
+	state, err := loadCPUSetState(stateDir, containerID)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	exclusiveCPUs, err := cpuset.Parse(state.ExclusiveCPUs)
+	if err != nil {
+		return err
+	}
+
+	log.Infof(ctx, "Reverting exclusive cpuset %q of container %q from recorded state", state.ExclusiveCPUs, containerID)
+	for i := len(state.Cgroups) - 1; i >= 0; i-- {
+		if err := revertCgroupCPUSetExclusive(ctx, state.Cgroups[i], i == len(state.Cgroups)-1, exclusiveCPUs); err != nil {
+			return err
+		}
+	}
+	return removeCPUSetState(stateDir, containerID)
+}
+
+// revertCgroupCPUSetExclusive removes the exclusive CPUs from the cgroup of the recorded chain, if it still exists.
+func revertCgroupCPUSetExclusive(ctx context.Context, cg cpusetCgroupState, bottom bool, exclusiveCPUs cpuset.CPUSet) error {
+	unlock, err := cgroupLocks.lock(ctx, cg.Path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, err := hostFS.Stat(cg.Path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// The cgroup was already removed together with the container.
+			return nil
+		}
+		return err
+	}
+	// The bottom cgroup was turned into an isolated partition, which must be
+	// dissolved before its exclusive CPUs can be released.
+	if bottom {
+		if err := writeCgroupFile(ctx, cg.Path, cpusetCpusPartition, "member"); err != nil {
+			return err
+		}
+	}
+	if err := removeCPUsFromCgroupFile(ctx, cg.Path, cpusetCpusExclusive, exclusiveCPUs); err != nil {
+		return err
+	}
+	if cg.ExclusiveOnly {
+		return removeCPUsFromCgroupFile(ctx, cg.Path, cpusetCpus, exclusiveCPUs)
+	}
+	return nil
+}
+
+// removeCPUsFromCgroupFile removes cpus from the cpuset file of the cgroup found in dir.
+func removeCPUsFromCgroupFile(ctx context.Context, dir, file string, cpus cpuset.CPUSet) error {
+	currentCpusStr, err := hostFS.ReadCgroupFile(dir, file)
+	if err != nil {
+		return err
+	}
+	currentCpus, err := cpuset.Parse(strings.TrimSpace(currentCpusStr))
+	if err != nil {
+		return err
+	}
+	targetCpus := currentCpus.Difference(cpus)
+	if targetCpus.Equals(currentCpus) {
+		return nil
+	}
+	// For some reason, just writing the empty string doesn't work
+	toWrite := targetCpus.String()
+	if toWrite == "" {
+		toWrite = "\n"
+	}
+	return writeCgroupFile(ctx, dir, file, toWrite)
 }
 
-func (h *HighPerformanceHooks) addOrRemoveCpusetFromManagers(states []*desiredManagerCPUSetState, add bool) error {
+func (h *HighPerformanceHooks) addOrRemoveCpusetFromManagers(ctx context.Context, states []*desiredManagerCPUSetState, add bool) error {
 	// Adding, we go top to bottom, when removing, bottom to top.
 	if !add {
 		slices.Reverse(states)
@@ -439,13 +1795,13 @@ func (h *HighPerformanceHooks) addOrRemoveCpusetFromManagers(states []*desiredMa
 			// we should write the full cpuset. This allows the kernel to toggle the cpuset
 			// based on whether the cgroup uses partition mode "isolated", and ensures children
 			// cgroups that don't have load balance disabled can use the remaining cpus.
-			if err := h.addOrRemoveCpusetFromManager(state.manager, state.cpus, add, cpusetCpus); err != nil {
+			if err := h.addOrRemoveCpusetFromManager(ctx, state.manager, state.cpus, add, cpusetCpus); err != nil {
 				return err
 			}
 		}
 
 		// Unconditionally update cpuset.cpus.exclusive, as this file must contain any cpus that we intend to isolate.
-		if err := h.addOrRemoveCpusetFromManager(state.manager, state.exclusiveCPUs, add, cpusetCpusExclusive); err != nil {
+		if err := h.addOrRemoveCpusetFromManager(ctx, state.manager, state.exclusiveCPUs, add, cpusetCpusExclusive); err != nil {
 			return err
 		}
 
@@ -453,7 +1809,7 @@ func (h *HighPerformanceHooks) addOrRemoveCpusetFromManagers(states []*desiredMa
 		// Don't modify cpuset.cpus if it contains non-exclusive cpus.
 		// Not only is there no need, it doesn't even work because that cpuset has been written to the other children by the kernel.
 		if !add && state.exclusiveCPUs.Equals(state.cpus) {
-			if err := h.addOrRemoveCpusetFromManager(state.manager, state.cpus, add, cpusetCpus); err != nil {
+			if err := h.addOrRemoveCpusetFromManager(ctx, state.manager, state.cpus, add, cpusetCpus); err != nil {
 				return err
 			}
 		}
@@ -461,11 +1817,14 @@ func (h *HighPerformanceHooks) addOrRemoveCpusetFromManagers(states []*desiredMa
 	return nil
 }
 
-func (h *HighPerformanceHooks) addOrRemoveCpusetFromManager(mgr cgroups.Manager, cpus cpuset.CPUSet, add bool, file string) error {
-	h.cpusetLock.Lock()
-	defer h.cpusetLock.Unlock()
+func (h *HighPerformanceHooks) addOrRemoveCpusetFromManager(ctx context.Context, mgr cgroups.Manager, cpus cpuset.CPUSet, add bool, file string) error {
+	unlock, err := cgroupLocks.lock(ctx, mgr.Path(""))
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
-	currentCpusStr, err := cgroups.ReadFile(mgr.Path(""), file)
+	currentCpusStr, err := hostFS.ReadCgroupFile(mgr.Path(""), file)
 	if err != nil {
 		return err
 	}
@@ -493,10 +1852,10 @@ func (h *HighPerformanceHooks) addOrRemoveCpusetFromManager(mgr cgroups.Manager,
 		if toWrite == "" {
 			toWrite = "\n"
 		}
-		return cgroups.WriteFile(mgr.Path(""), file, toWrite)
+		return writeCgroupFile(ctx, mgr.Path(""), file, toWrite)
 	}
 	// otherwise, we should use the mgr directly, as it will go through systemd if necessary
-	return mgr.Set(&configs.Resources{
+	return setCgroupResources(ctx, mgr, &configs.Resources{
 		SkipDevices: true,
 		CpusetCpus:  targetCpus.String(),
 	})
@@ -512,29 +1871,29 @@ var (
 // Do this just once. It's not particularly inefficient, but there's no need to recalculate.
 func fullCPUSet() (cpuset.CPUSet, error) {
 	fullCPUSetOnce.Do(func() {
-		content, err := os.ReadFile(filepath.Join(sysCPUDir, "online"))
-		if err != nil {
-			fullCPUSetErr = err
-			return
-		}
-		fullCPUSetVar, fullCPUSetErr = cpuset.Parse(strings.TrimSpace(string(content)))
+		fullCPUSetVar, fullCPUSetErr = onlineCPUs()
 	})
 	return fullCPUSetVar, fullCPUSetErr
 }
 
+// onlineCPUs returns the CPUs of the node currently online.
+func onlineCPUs() (cpuset.CPUSet, error) {
+	content, err := hostFS.ReadFile(filepath.Join(sysCPUDir, "online"))
+	if err != nil {
+		return cpuset.New(), err
+	}
+	return cpuset.Parse(strings.TrimSpace(string(content)))
+}
+
 // The requisite condition to allow this is `cpuset.sched_load_balance` field must be set to 0 for all cgroups
 // that intersect with `cpuset.cpus` of the container that desires load balancing.
 // Since CRI-O is the owner of the container cgroup, it must set this value for
 // the container. Some other entity (kubelet, external service) must ensure this is the case for all
 // other cgroups that intersect (at minimum: all parent cgroups of this cgroup).
-func disableCPULoadBalancingV1(containerManagers []cgroups.Manager) error {
-	for i := len(containerManagers) - 1; i >= 0; i-- {
-		cpusetPath := containerManagers[i].Path("cpuset")
-		if err := cgroups.WriteFile(cpusetPath, "cpuset.sched_load_balance", "0"); err != nil {
-			return err
-		}
-	}
-	return nil
+// The container cgroup is written through the cgroup manager, which writes the cgroup the OCI runtime
+// delegated the container to as well.
+func disableCPULoadBalancingV1(ctx context.Context, containerID, parentDir string) error {
+	return writeContainerCgroupFile(ctx, containerID, parentDir, "cpuset", "cpuset.sched_load_balance", "0")
 }
 
 func setIRQLoadBalancing(ctx context.Context, c *oci.Container, enable bool, irqSmpAffinityFile, irqBalanceConfigFile string) error {
@@ -546,59 +1905,79 @@ func setIRQLoadBalancing(ctx context.Context, c *oci.Container, enable bool, irq
 		return fmt.Errorf("find container %s CPUs", c.ID())
 	}
 
-	content, err := os.ReadFile(irqSmpAffinityFile)
+	content, err := hostFS.ReadFile(irqSmpAffinityFile)
 	if err != nil {
 		return err
 	}
 	currentIRQSMPSetting := strings.TrimSpace(string(content))
-	newIRQSMPSetting, newIRQBalanceSetting, err := UpdateIRQSmpAffinityMask(lspec.Resources.CPU.Cpus, currentIRQSMPSetting, enable)
+	newIRQSMPSetting, newIRQBalanceSetting, err := cpumask.Update(lspec.Resources.CPU.Cpus, currentIRQSMPSetting, enable)
 	if err != nil {
 		return err
 	}
-	if err := os.WriteFile(irqSmpAffinityFile, []byte(newIRQSMPSetting), 0o644); err != nil {
-		return err
+	isIrqConfigExists := fileExists(irqBalanceConfigFile)
+
+	// Skip restarting irqbalance if the IRQs are already balanced accordingly,
+	// e.g. the hook was run again for the same container.
+	if newIRQSMPSetting == currentIRQSMPSetting {
+		bannedCPUMasks, err := retrieveIrqBannedCPUMasks(irqBalanceConfigFile)
+		if !isIrqConfigExists || (err == nil && bannedCPUMasks == newIRQBalanceSetting) {
+			log.Debugf(ctx, "IRQ load balancing of container %q is already set, skipping", c.ID())
+			return nil
+		}
 	}
 
-	isIrqConfigExists := fileExists(irqBalanceConfigFile)
+	if enable {
+		err = writeFileIfChanged(ctx, irqSmpAffinityFile, []byte(newIRQSMPSetting), 0o644)
+	} else {
+		err = writeTuningFile(ctx, c.ID(), irqSmpAffinityFile, []byte(newIRQSMPSetting))
+	}
+	if err != nil {
+		return err
+	}
 
 	if isIrqConfigExists {
-		if err := updateIrqBalanceConfigFile(irqBalanceConfigFile, newIRQBalanceSetting); err != nil {
+		if err := updateIrqBalanceConfigFile(ctx, irqBalanceConfigFile, newIRQBalanceSetting); err != nil {
 			return err
 		}
 	}
 
-	if !isServiceEnabled(irqBalancedName) || !isIrqConfigExists {
+	if !isServiceEnabled(ctx, irqBalancedName) || !isIrqConfigExists {
 		if _, err := exec.LookPath(irqBalancedName); err != nil {
 			// irqbalance is not installed, skip the rest; pod should still start, so return nil instead
 			log.Warnf(ctx, "Irqbalance binary not found: %v", err)
+			if !enable {
+				noteUnfulfilledAnnotation(ctx, crioannotations.IRQLoadBalancingAnnotation, ReasonIrqbalanceNotFound)
+			}
 			return nil
 		}
 		// run irqbalance in daemon mode, so this won't cause delay
-		cmd := cmdrunner.Command(irqBalancedName, "--oneshot")
 		additionalEnv := irqBalanceBannedCpus + "=" + newIRQBalanceSetting
-		cmd.Env = append(os.Environ(), additionalEnv)
-		return cmd.Run()
+		return measureIrqBalanceOperation(irqBalanceOperationOneshot, func() error {
+			_, err := runHelperCommand(ctx, append(os.Environ(), additionalEnv), irqBalancedName, "--oneshot")
+			return err
+		})
 	}
 
-	if err := restartIrqBalanceService(); err != nil {
+	if err := restartIrqBalanceService(ctx); err != nil {
 		log.Warnf(ctx, "Irqbalance service restart failed: %v", err)
 	}
 	return nil
 }
 
-func setCPUQuota(podManager cgroups.Manager, containerManagers []cgroups.Manager) error {
-	if err := disableCPUQuotaForCgroup(podManager); err != nil {
+func setCPUQuota(ctx context.Context, podManager cgroups.Manager, containerManagers []cgroups.Manager) error {
+	if err := disableCPUQuotaForCgroup(ctx, podManager); err != nil {
 		return err
 	}
 	for _, containerManager := range containerManagers {
-		if err := disableCPUQuotaForCgroup(containerManager); err != nil {
+		if err := disableCPUQuotaForCgroup(ctx, containerManager); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func libctrManagersForPodAndContainerCgroup(c *oci.Container, parentDir string) (podManager cgroups.Manager, containerManagers []cgroups.Manager, _ error) {
+// cgroupManagerForParent returns the cgroup manager matching the format of the sandbox cgroup parent.
+func cgroupManagerForParent(parentDir string) cgmgr.CgroupManager {
 	var (
 		cgroupManager cgmgr.CgroupManager
 		err           error
@@ -613,6 +1992,29 @@ func libctrManagersForPodAndContainerCgroup(c *oci.Container, parentDir string)
 		// Programming error, this is only possible if the manager string is invalid.
 		panic(err)
 	}
+	return cgroupManager
+}
+
+// containerCgroupExists checks whether the container cgroup is still present on the node.
+// The path is computed from the sandbox cgroup parent and the container ID,
+// so it does not depend on the container process still running.
+func containerCgroupExists(containerID, parentDir string) (bool, error) {
+	// Choose cpuset as the cgroup to check, with little reason.
+	containerCgroupDir, err := cgroupManagerForParent(parentDir).ContainerCgroupDir(parentDir, containerID, "cpuset")
+	if err != nil {
+		return false, err
+	}
+	if _, err := hostFS.Stat(containerCgroupDir); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func libctrManagersForPodAndContainerCgroup(c *oci.Container, parentDir string) (podManager cgroups.Manager, containerManagers []cgroups.Manager, _ error) {
+	cgroupManager := cgroupManagerForParent(parentDir)
 
 	containerCgroupFullPath, err := cgroupManager.ContainerCgroupAbsolutePath(parentDir, c.ID())
 	if err != nil {
@@ -638,7 +2040,7 @@ func libctrManagersForPodAndContainerCgroup(c *oci.Container, parentDir string)
 	containerManagers = []cgroups.Manager{containerManager}
 
 	// crun actually does the cgroup configuration in a child of the cgroup CRI-O expects to be the container's
-	extraManager, err := trueContainerCgroupManager(containerCgroupFullPath)
+	extraManager, err := trueContainerCgroupManager(cgroupManager, parentDir, c.ID())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -648,26 +2050,49 @@ func libctrManagersForPodAndContainerCgroup(c *oci.Container, parentDir string)
 	return podManager, containerManagers, nil
 }
 
-func trueContainerCgroupManager(expectedContainerCgroup string) (cgroups.Manager, error) {
+// cgroupHierarchyFrom returns the managers of the cgroups from the top of the hierarchy down to the cgroup at
+// cgroupPath included, which is either relative to the cgroup mount point or under it, so that a setting can be
+// propagated from the top down, like the exclusive CPUs of a cpuset partition.
+func cgroupHierarchyFrom(cgroupPath string, systemd bool) ([]cgroups.Manager, error) {
+	managers := []cgroups.Manager{}
+	parent := ""
+	for _, dir := range strings.Split(strings.TrimPrefix(cgroupPath, cgroupMountPoint), "/") {
+		if dir == "" {
+			continue
+		}
+		mgr, err := libctrManager(dir, parent, systemd)
+		if err != nil {
+			return nil, err
+		}
+		managers = append(managers, mgr)
+		parent = filepath.Join(parent, dir)
+	}
+	return managers, nil
+}
+
+func trueContainerCgroupManager(cgroupManager cgmgr.CgroupManager, parentDir, containerID string) (cgroups.Manager, error) {
 	// HACK: There isn't really a better way to check if the actual container cgroup is in a child cgroup of the expected.
 	// We could check /proc/$pid/cgroup, but we need to be able to query this after the container exits and the process is gone.
 	// We know the source of this: crun creates a sub cgroup of the container to do the actual management, to enforce systemd's single
 	// owner rule. Thus, we need to hardcode this check.
-	actualContainerCgroup := filepath.Join(expectedContainerCgroup, "container")
 	// Choose cpuset as the cgroup to check, with little reason.
-	cgroupRoot := cgroupMountPoint
-	if !node.CgroupIsV2() {
-		cgroupRoot += "/cpuset"
+	containerCgroupDir, err := cgroupManager.ContainerCgroupDir(parentDir, containerID, "cpuset")
+	if err != nil {
+		return nil, err
 	}
-	if _, err := os.Stat(filepath.Join(cgroupRoot, actualContainerCgroup)); err != nil {
+	if _, err := hostFS.Stat(filepath.Join(containerCgroupDir, "container")); err != nil {
 		return nil, nil
 	}
+	expectedContainerCgroup, err := cgroupManager.ContainerCgroupAbsolutePath(parentDir, containerID)
+	if err != nil {
+		return nil, err
+	}
 	// must be crun, make another libctrManager. Regardless of cgroup driver, it will be treated as cgroupfs
-	return libctrManager(filepath.Base(actualContainerCgroup), filepath.Dir(actualContainerCgroup), false)
+	return libctrManager("container", expectedContainerCgroup, false)
 }
 
-func disableCPUQuotaForCgroup(mgr cgroups.Manager) error {
-	return mgr.Set(&configs.Resources{
+func disableCPUQuotaForCgroup(ctx context.Context, mgr cgroups.Manager) error {
+	return setCgroupResources(ctx, mgr, &configs.Resources{
 		SkipDevices: true,
 		CpuQuota:    -1,
 	})
@@ -714,12 +2139,12 @@ func getManagerByIndex(idx int, containerManagers []cgroups.Manager) (cgroups.Ma
 // setCPUPMQOSResumeLatency sets the pm_qos_resume_latency_us for a cpu and stores the original
 // value so it can be restored later. If the latency is an empty string, the original latency
 // value is restored.
-func setCPUPMQOSResumeLatency(c *oci.Container, latency string) error {
-	return doSetCPUPMQOSResumeLatency(c, latency, sysCPUDir, sysCPUSaveDir)
+func setCPUPMQOSResumeLatency(ctx context.Context, c *oci.Container, latency string) error {
+	return doSetCPUPMQOSResumeLatency(ctx, c, latency, sysCPUDir, sysCPUSaveDir)
 }
 
 // doSetCPUPMQOSResumeLatency facilitates unit testing by allowing the directories to be specified as parameters.
-func doSetCPUPMQOSResumeLatency(c *oci.Container, latency, cpuDir, cpuSaveDir string) error {
+func doSetCPUPMQOSResumeLatency(ctx context.Context, c *oci.Container, latency, cpuDir, cpuSaveDir string) error {
 	lspec := c.Spec().Linux
 	if lspec == nil ||
 		lspec.Resources == nil ||
@@ -733,43 +2158,39 @@ func doSetCPUPMQOSResumeLatency(c *oci.Container, latency, cpuDir, cpuSaveDir st
 		return err
 	}
 
-	for _, cpu := range cpus.List() {
+	return forEachCPU(cpus.List(), func(cpu int) error {
 		latencyFile := fmt.Sprintf("%s/cpu%d/power/pm_qos_resume_latency_us", cpuDir, cpu)
 		cpuPowerSaveDir := fmt.Sprintf("%s/cpu%d/power", cpuSaveDir, cpu)
 		latencyFileOrig := path.Join(cpuPowerSaveDir, "pm_qos_resume_latency_us")
+		defer lockFile(latencyFile)()
 
 		if latency != "" {
 			// Don't overwrite the original latency if it has already been saved. This can happen if
 			// a container is restarted, as this will cause the PreStart hooks to be called again.
 			if !fileExists(latencyFileOrig) {
 				// Retrieve the current latency.
-				latencyOrig, err := os.ReadFile(latencyFile)
+				latencyOrig, err := hostFS.ReadFile(latencyFile)
 				if err != nil {
 					return err
 				}
 
 				// Save the current latency so we can restore it later.
-				err = os.MkdirAll(cpuPowerSaveDir, 0o750)
+				err = hostFS.MkdirAll(cpuPowerSaveDir, 0o750)
 				if err != nil {
 					return err
 				}
-				err = os.WriteFile(latencyFileOrig, latencyOrig, 0o644)
+				err = writeFile(ctx, latencyFileOrig, latencyOrig, 0o644)
 				if err != nil {
 					return err
 				}
 			}
 
 			// Update the pm_qos_resume_latency_us.
-			err = os.WriteFile(latencyFile, []byte(latency), 0o644)
-			if err != nil {
-				return err
-			}
-
-			continue
+			return writeTuningFile(ctx, c.ID(), latencyFile, []byte(latency))
 		}
 
 		// Retrieve the original latency.
-		latencyOrig, err := os.ReadFile(latencyFileOrig)
+		latencyOrig, err := hostFS.ReadFile(latencyFileOrig)
 		if err != nil {
 			if errors.Is(err, os.ErrNotExist) {
 				// The latency may have already been restored by a previous invocation of the hook.
@@ -779,26 +2200,21 @@ func doSetCPUPMQOSResumeLatency(c *oci.Container, latency, cpuDir, cpuSaveDir st
 		}
 
 		// Restore the original latency.
-		err = os.WriteFile(latencyFile, latencyOrig, 0o644)
+		err = writeFileIfChanged(ctx, latencyFile, latencyOrig, 0o644)
 		if err != nil {
 			return err
 		}
 
 		// Remove the saved latency.
-		err = os.Remove(latencyFileOrig)
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
+		return hostFS.Remove(latencyFileOrig)
+	})
 }
 
 // isCPUGovernorSupported checks whether the cpu governor is supported for the specified cpu.
 func isCPUGovernorSupported(governor, cpuDir string, cpu int) error {
 	// Get available cpu scaling governors.
 	availGovernorFile := fmt.Sprintf("%s/cpu%d/cpufreq/scaling_available_governors", cpuDir, cpu)
-	availGovernors, err := os.ReadFile(availGovernorFile)
+	availGovernors, err := hostFS.ReadFile(availGovernorFile)
 	if err != nil {
 		return err
 	}
@@ -816,12 +2232,12 @@ func isCPUGovernorSupported(governor, cpuDir string, cpu int) error {
 // setCPUFreqGovernor sets the scaling_governor for a cpu and stores the original
 // value so it can be restored later. If the governor is an empty string, the original
 // scaling_governor value is restored.
-func setCPUFreqGovernor(c *oci.Container, governor string) error {
-	return doSetCPUFreqGovernor(c, governor, sysCPUDir, sysCPUSaveDir)
+func setCPUFreqGovernor(ctx context.Context, c *oci.Container, governor string) error {
+	return doSetCPUFreqGovernor(ctx, c, governor, sysCPUDir, sysCPUSaveDir)
 }
 
 // doSetCPUFreqGovernor facilitates unit testing by allowing the directories to be specified as parameters.
-func doSetCPUFreqGovernor(c *oci.Container, governor, cpuDir, cpuSaveDir string) error {
+func doSetCPUFreqGovernor(ctx context.Context, c *oci.Container, governor, cpuDir, cpuSaveDir string) error {
 	lspec := c.Spec().Linux
 	if lspec == nil ||
 		lspec.Resources == nil ||
@@ -835,48 +2251,48 @@ func doSetCPUFreqGovernor(c *oci.Container, governor, cpuDir, cpuSaveDir string)
 		return err
 	}
 
-	for _, cpu := range cpus.List() {
+	// Check that the new scaling governor is supported by all the CPUs, before updating any of them.
+	if governor != "" {
+		for _, cpu := range cpus.List() {
+			if err := isCPUGovernorSupported(governor, cpuDir, cpu); err != nil {
+				return err
+			}
+		}
+	}
+
+	return forEachCPU(cpus.List(), func(cpu int) error {
 		governorFile := fmt.Sprintf("%s/cpu%d/cpufreq/scaling_governor", cpuDir, cpu)
 		cpuFreqSaveDir := fmt.Sprintf("%s/cpu%d/cpufreq", cpuSaveDir, cpu)
 		governorFileOrig := path.Join(cpuFreqSaveDir, "scaling_governor")
+		defer lockFile(governorFile)()
 
 		if governor != "" {
-			// Is the new scaling governor supported?
-			if err := isCPUGovernorSupported(governor, cpuDir, cpu); err != nil {
-				return err
-			}
-
 			// Don't overwrite the original governor if it has already been saved. This can happen if
 			// a container is restarted, as this will cause the PreStart hooks to be called again.
 			if !fileExists(governorFileOrig) {
 				// Retrieve the current scaling governor.
-				governorOrig, err := os.ReadFile(governorFile)
+				governorOrig, err := hostFS.ReadFile(governorFile)
 				if err != nil {
 					return err
 				}
 
 				// Save the current governor so we can restore it later.
-				err = os.MkdirAll(cpuFreqSaveDir, 0o750)
+				err = hostFS.MkdirAll(cpuFreqSaveDir, 0o750)
 				if err != nil {
 					return err
 				}
-				err = os.WriteFile(governorFileOrig, governorOrig, 0o644)
+				err = writeFile(ctx, governorFileOrig, governorOrig, 0o644)
 				if err != nil {
 					return err
 				}
 			}
 
 			// Update the governor.
-			err = os.WriteFile(governorFile, []byte(governor), 0o644)
-			if err != nil {
-				return err
-			}
-
-			continue
+			return writeTuningFile(ctx, c.ID(), governorFile, []byte(governor))
 		}
 
 		// Retrieve the original scaling governor.
-		governorOrig, err := os.ReadFile(governorFileOrig)
+		governorOrig, err := hostFS.ReadFile(governorFileOrig)
 		if err != nil {
 			if errors.Is(err, os.ErrNotExist) {
 				// The governor may have already been restored by a previous invocation of the hook.
@@ -886,35 +2302,27 @@ func doSetCPUFreqGovernor(c *oci.Container, governor, cpuDir, cpuSaveDir string)
 		}
 
 		// Restore the original governor.
-		err = os.WriteFile(governorFile, governorOrig, 0o644)
+		err = writeFileIfChanged(ctx, governorFile, governorOrig, 0o644)
 		if err != nil {
 			return err
 		}
 
 		// Remove the saved governor.
-		err = os.Remove(governorFileOrig)
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
+		return hostFS.Remove(governorFileOrig)
+	})
 }
 
 // RestoreIrqBalanceConfig restores irqbalance service with original banned cpu mask settings.
 func RestoreIrqBalanceConfig(ctx context.Context, irqBalanceConfigFile, irqBannedCPUConfigFile, irqSmpAffinityProcFile string) error {
-	content, err := os.ReadFile(irqSmpAffinityProcFile)
+	content, err := hostFS.ReadFile(irqSmpAffinityProcFile)
 	if err != nil {
 		return err
 	}
-	current := strings.TrimSpace(string(content))
-	// remove ","; now each element is "0-9,a-f"
-	s := strings.ReplaceAll(current, ",", "")
-	currentMaskArray, err := mapHexCharToByte(s)
+	currentMask, err := cpumask.Parse(strings.TrimSpace(string(content)))
 	if err != nil {
 		return err
 	}
-	if !isAllBitSet(currentMaskArray) {
+	if !currentMask.IsAllSet() {
 		// not system reboot scenario, just return it.
 		log.Infof(ctx, "Restore irqbalance config: not system reboot, ignoring")
 		return nil
@@ -929,20 +2337,14 @@ func RestoreIrqBalanceConfig(ctx context.Context, irqBalanceConfigFile, irqBanne
 
 	if !fileExists(irqBannedCPUConfigFile) {
 		log.Infof(ctx, "Creating banned CPU list file %q", irqBannedCPUConfigFile)
-		irqBannedCPUsConfig, err := os.Create(irqBannedCPUConfigFile)
-		if err != nil {
-			return err
-		}
-		defer irqBannedCPUsConfig.Close()
-		_, err = irqBannedCPUsConfig.WriteString(bannedCPUMasks)
-		if err != nil {
+		if err := hostFS.WriteFile(irqBannedCPUConfigFile, []byte(bannedCPUMasks), 0o644); err != nil {
 			return err
 		}
 		log.Infof(ctx, "Restore irqbalance config: created backup file")
 		return nil
 	}
 
-	content, err = os.ReadFile(irqBannedCPUConfigFile)
+	content, err = hostFS.ReadFile(irqBannedCPUConfigFile)
 	if err != nil {
 		return err
 	}
@@ -954,11 +2356,11 @@ func RestoreIrqBalanceConfig(ctx context.Context, irqBalanceConfigFile, irqBanne
 	}
 
 	log.Infof(ctx, "Restore irqbalance banned CPU list in %q to %q", irqBalanceConfigFile, origBannedCPUMasks)
-	if err := updateIrqBalanceConfigFile(irqBalanceConfigFile, origBannedCPUMasks); err != nil {
+	if err := updateIrqBalanceConfigFile(ctx, irqBalanceConfigFile, origBannedCPUMasks); err != nil {
 		return err
 	}
-	if isServiceEnabled(irqBalancedName) {
-		if err := restartIrqBalanceService(); err != nil {
+	if isServiceEnabled(ctx, irqBalancedName) {
+		if err := restartIrqBalanceService(ctx); err != nil {
 			log.Warnf(ctx, "Irqbalance service restart failed: %v", err)
 		}
 	}
@@ -1044,7 +2446,7 @@ func convertAnnotationToLatency(annotation string) (maxLatency string, err error
 	return "", fmt.Errorf("invalid annotation value %s", annotation)
 }
 
-func setSharedCPUs(c *oci.Container, containerManagers []cgroups.Manager, sharedCPUs string) ([]cgroups.Manager, error) {
+func setSharedCPUs(ctx context.Context, c *oci.Container, parentDir string, containerManagers []cgroups.Manager, sharedCPUs string) ([]cgroups.Manager, error) {
 	cSpec := c.Spec()
 	if isContainerCPUsSpecEmpty(&cSpec) {
 		return nil, fmt.Errorf("no cpus found for container %q", c.Name())
@@ -1065,7 +2467,11 @@ func setSharedCPUs(c *oci.Container, containerManagers []cgroups.Manager, shared
 	if err != nil {
 		return nil, err
 	}
-	if err := ctrManager.Set(&configs.Resources{
+	// The scope of the container must allow the shared CPUs too, or a daemon reload of systemd reverts them.
+	if err := setSystemdContainerCPUs(ctx, c.ID(), parentDir, exclusiveCPUs.Union(sharedCPUSet).String()); err != nil {
+		return nil, err
+	}
+	if err := setCgroupResources(ctx, ctrManager, &configs.Resources{
 		SkipDevices: true,
 		CpusetCpus:  exclusiveCPUs.Union(sharedCPUSet).String(),
 	}); err != nil {
@@ -1075,11 +2481,11 @@ func setSharedCPUs(c *oci.Container, containerManagers []cgroups.Manager, shared
 		// we need to move the isolated cpus into a separate child cgroup
 		// on V2 all controllers are under the same path
 		ctrCgroup := ctrManager.Path("")
-		if err := cgroups.WriteFile(ctrCgroup, cgroupSubTreeControl, "+cpu +cpuset"); err != nil {
+		if err := writeContainerCgroupFile(ctx, c.ID(), parentDir, "cpuset", cgroupSubTreeControl, "+cpu +cpuset"); err != nil {
 			return nil, err
 		}
 		// create a new cgroupfs manager
-		childCgroup, err := libctrManager("cgroup-child", strings.TrimPrefix(ctrCgroup, cgroupMountPoint), false)
+		childCgroup, err := libctrManager(isolatedChildCgroup, strings.TrimPrefix(ctrCgroup, cgroupMountPoint), false)
 		if err != nil {
 			return nil, err
 		}
@@ -1088,7 +2494,7 @@ func setSharedCPUs(c *oci.Container, containerManagers []cgroups.Manager, shared
 		}
 		// add the exclusive cpus under the child cgroup in case
 		// this makes the handling of load-balancing disablement simpler in case it required
-		if err := childCgroup.Set(&configs.Resources{
+		if err := setCgroupResources(ctx, childCgroup, &configs.Resources{
 			SkipDevices: true,
 			CpusetCpus:  exclusiveCPUs.String(),
 		}); err != nil {
@@ -1108,7 +2514,7 @@ func isContainerCPUsSpecEmpty(spec *specs.Spec) bool {
 		spec.Linux.Resources.CPU.Cpus == ""
 }
 
-func injectQuotaGivenSharedCPUs(c *oci.Container, podManager cgroups.Manager, containerManagers []cgroups.Manager, sharedCPUs string) error {
+func injectQuotaGivenSharedCPUs(ctx context.Context, c *oci.Container, sandboxID string, podManager cgroups.Manager, containerManagers []cgroups.Manager, sharedCPUs string) (retErr error) {
 	cpuSpec := c.Spec().Linux.Resources.CPU
 	isolatedCPUSet, err := cpuset.Parse(cpuSpec.Cpus)
 	if err != nil {
@@ -1122,18 +2528,24 @@ func injectQuotaGivenSharedCPUs(c *oci.Container, podManager cgroups.Manager, co
 		return errors.New("shared CPU set is empty")
 	}
 
-	// pod level operations
-	newPodQuota, err := calculatePodQuota(&sharedCPUSet, podManager, *cpuSpec.Period)
-	if err != nil {
-		return fmt.Errorf("failed to calculate pod quota: %w", err)
-	}
-	// the Set function knows to handle -1 value for both v1 and v2
-	err = podManager.Set(&configs.Resources{
-		SkipDevices: true,
-		CpuQuota:    newPodQuota,
-	})
-	if err != nil {
-		return err
+	// pod level operations, done by the first consumer only, as all the containers share the same pool
+	if addSharedCPUsConsumer(sandboxID, c.ID(), isolatedCPUSet, sharedCPUSet) {
+		defer func() {
+			if retErr != nil {
+				removeSharedCPUsConsumer(sandboxID, c.ID())
+			}
+		}()
+		newPodQuota, err := calculatePodQuota(&sharedCPUSet, podManager, *cpuSpec.Period)
+		if err != nil {
+			return fmt.Errorf("failed to calculate pod quota: %w", err)
+		}
+		// the Set function knows to handle -1 value for both v1 and v2
+		if err := setCgroupResources(ctx, podManager, &configs.Resources{
+			SkipDevices: true,
+			CpuQuota:    newPodQuota,
+		}); err != nil {
+			return err
+		}
 	}
 	// container level operations
 	ctrCPUSet := isolatedCPUSet.Union(sharedCPUSet)
@@ -1145,7 +2557,7 @@ func injectQuotaGivenSharedCPUs(c *oci.Container, podManager cgroups.Manager, co
 	if err != nil {
 		return err
 	}
-	return manager.Set(&configs.Resources{
+	return setCgroupResources(ctx, manager, &configs.Resources{
 		SkipDevices: true,
 		CpuQuota:    ctrQuota,
 	})
@@ -1161,14 +2573,15 @@ func calculateMaximalQuota(cpus *cpuset.CPUSet, period uint64) (quota int64, err
 	return
 }
 
-func calculatePodQuota(sharedCpus *cpuset.CPUSet, podManager cgroups.Manager, period uint64) (int64, error) {
-	var quotaGetFunc func(cgroups.Manager) (string, error)
+func getPodQuota(podManager cgroups.Manager) (string, error) {
 	if node.CgroupIsV2() {
-		quotaGetFunc = getPodQuotaV2
-	} else {
-		quotaGetFunc = getPodQuotaV1
+		return getPodQuotaV2(podManager)
 	}
-	existingQuota, err := quotaGetFunc(podManager)
+	return getPodQuotaV1(podManager)
+}
+
+func calculatePodQuota(sharedCpus *cpuset.CPUSet, podManager cgroups.Manager, period uint64) (int64, error) {
+	existingQuota, err := getPodQuota(podManager)
 	if err != nil {
 		return 0, err
 	}
@@ -1188,9 +2601,40 @@ func calculatePodQuota(sharedCpus *cpuset.CPUSet, podManager cgroups.Manager, pe
 	return q + additionalQuota, err
 }
 
+// recalculatePodQuota replaces the quota granted to the pod for the former shared cpus
+// with the quota of the new shared cpus.
+func recalculatePodQuota(oldSharedCpus, newSharedCpus *cpuset.CPUSet, podManager cgroups.Manager, period uint64) (int64, error) {
+	existingQuota, err := getPodQuota(podManager)
+	if err != nil {
+		return 0, err
+	}
+	// the pod is already at its maximal quota
+	// we return -1 for both cgroup v1 and v2
+	if existingQuota == "-1" || existingQuota == "max" {
+		return -1, nil
+	}
+	q, err := strconv.ParseInt(existingQuota, 10, 0)
+	if err != nil {
+		return 0, err
+	}
+	return adjustQuotaForSharedCPUs(q, oldSharedCpus, newSharedCpus, period)
+}
+
+func adjustQuotaForSharedCPUs(quota int64, oldSharedCpus, newSharedCpus *cpuset.CPUSet, period uint64) (int64, error) {
+	oldQuota, err := calculateMaximalQuota(oldSharedCpus, period)
+	if err != nil {
+		return 0, err
+	}
+	newQuota, err := calculateMaximalQuota(newSharedCpus, period)
+	if err != nil {
+		return 0, err
+	}
+	return quota - oldQuota + newQuota, nil
+}
+
 func getPodQuotaV1(mng cgroups.Manager) (string, error) {
 	controllerPath := mng.Path("cpu")
-	q, err := cgroups.ReadFile(controllerPath, cgroupV1QuotaFile)
+	q, err := hostFS.ReadCgroupFile(controllerPath, cgroupV1QuotaFile)
 	if err != nil {
 		return "", err
 	}
@@ -1200,7 +2644,7 @@ func getPodQuotaV1(mng cgroups.Manager) (string, error) {
 
 func getPodQuotaV2(mng cgroups.Manager) (string, error) {
 	controllerPath := mng.Path("")
-	cpuQuotaAndPeriod, err := cgroups.ReadFile(controllerPath, cgroupV2QuotaFile)
+	cpuQuotaAndPeriod, err := hostFS.ReadCgroupFile(controllerPath, cgroupV2QuotaFile)
 	if err != nil {
 		return "", err
 	}
@@ -1210,9 +2654,22 @@ func getPodQuotaV2(mng cgroups.Manager) (string, error) {
 	return cpuQuota, nil
 }
 
-func injectCpusetEnv(specgen *generate.Generator, isolated, shared *cpuset.CPUSet) {
-	spec := specgen.Config
-	spec.Process.Env = append(spec.Process.Env,
-		fmt.Sprintf("%s=%s", IsolatedCPUsEnvVar, isolated.String()),
-		fmt.Sprintf("%s=%s", SharedCPUsEnvVar, shared.String()))
+// injectCpusetEnv submits the isolated and shared CPUs of the container as variables of its environment,
+// which get merged into its spec before the runtime creates it.
+func (h *HighPerformanceHooks) injectCpusetEnv(c *oci.Container, isolated, shared *cpuset.CPUSet) error {
+	isolatedCPUsEnvVar := IsolatedCPUsEnvVar
+	if h.isolatedCPUsEnvVar != "" {
+		isolatedCPUsEnvVar = h.isolatedCPUsEnvVar
+	}
+	sharedCPUsEnvVar := SharedCPUsEnvVar
+	if h.sharedCPUsEnvVar != "" {
+		sharedCPUsEnvVar = h.sharedCPUsEnvVar
+	}
+	return c.SubmitSpecPatch(&oci.SpecPatch{
+		Source: HighPerformance,
+		Env: []string{
+			fmt.Sprintf("%s=%s", isolatedCPUsEnvVar, isolated.String()),
+			fmt.Sprintf("%s=%s", sharedCPUsEnvVar, shared.String()),
+		},
+	})
 }
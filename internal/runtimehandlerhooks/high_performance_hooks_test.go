@@ -2,6 +2,7 @@ package runtimehandlerhooks
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -9,14 +10,17 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/opencontainers/runc/libcontainer/cgroups"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/opencontainers/runtime-tools/generate"
 	types "k8s.io/cri-api/pkg/apis/runtime/v1"
+	"k8s.io/utils/cpuset"
 
 	"github.com/cri-o/cri-o/internal/lib/sandbox"
 	"github.com/cri-o/cri-o/internal/log"
 	"github.com/cri-o/cri-o/internal/oci"
 	crioannotations "github.com/cri-o/cri-o/pkg/annotations"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
 )
 
 const (
@@ -129,7 +133,7 @@ var _ = Describe("high_performance_hooks", func() {
 			// set irqbalanace config file with no banned cpus
 			err = os.WriteFile(irqBalanceConfigFile, []byte(""), 0o644)
 			Expect(err).ToNot(HaveOccurred())
-			err = updateIrqBalanceConfigFile(irqBalanceConfigFile, bannedCPUFlags)
+			err = updateIrqBalanceConfigFile(context.TODO(), irqBalanceConfigFile, bannedCPUFlags)
 			Expect(err).ToNot(HaveOccurred())
 			bannedCPUs, err := retrieveIrqBannedCPUMasks(irqBalanceConfigFile)
 			Expect(err).ToNot(HaveOccurred())
@@ -182,7 +186,7 @@ var _ = Describe("high_performance_hooks", func() {
 
 		//nolint:dupl
 		verifySetCPUPMQOSResumeLatency := func(latency string, expected string, expected_save string, expect_error bool) {
-			err := doSetCPUPMQOSResumeLatency(container, latency, cpuDir, cpuSaveDir)
+			err := doSetCPUPMQOSResumeLatency(context.TODO(), container, latency, cpuDir, cpuSaveDir)
 			if !expect_error {
 				Expect(err).ShouldNot(HaveOccurred())
 			} else {
@@ -333,7 +337,7 @@ var _ = Describe("high_performance_hooks", func() {
 
 		//nolint:dupl
 		verifySetCPUScalingGovernor := func(governor string, expected string, expected_save string, expect_error bool) {
-			err := doSetCPUFreqGovernor(container, governor, cpuDir, cpuSaveDir)
+			err := doSetCPUFreqGovernor(context.TODO(), container, governor, cpuDir, cpuSaveDir)
 			if !expect_error {
 				Expect(err).ShouldNot(HaveOccurred())
 			} else {
@@ -562,7 +566,7 @@ var _ = Describe("high_performance_hooks", func() {
 			// set irqbalanace config file with banned cpus mask
 			err = os.WriteFile(irqBalanceConfigFile, []byte(""), 0o644)
 			Expect(err).ToNot(HaveOccurred())
-			err = updateIrqBalanceConfigFile(irqBalanceConfigFile, "0000ffff,ffffcfcc")
+			err = updateIrqBalanceConfigFile(context.TODO(), irqBalanceConfigFile, "0000ffff,ffffcfcc")
 			Expect(err).ToNot(HaveOccurred())
 			bannedCPUs, err := retrieveIrqBannedCPUMasks(irqBalanceConfigFile)
 			Expect(err).ToNot(HaveOccurred())
@@ -671,7 +675,7 @@ var _ = Describe("high_performance_hooks", func() {
 				},
 			)
 			It("should result in error", func() {
-				_, err := setSharedCPUs(container, nil, "")
+				_, err := setSharedCPUs(context.TODO(), container, "", nil, "")
 				Expect(err).To(HaveOccurred())
 			})
 		})
@@ -688,7 +692,7 @@ var _ = Describe("high_performance_hooks", func() {
 				},
 			)
 			It("should result in error", func() {
-				_, err := setSharedCPUs(container, nil, "")
+				_, err := setSharedCPUs(context.TODO(), container, "", nil, "")
 				Expect(err).To(HaveOccurred())
 			})
 		})
@@ -734,8 +738,334 @@ var _ = Describe("high_performance_hooks", func() {
 			h := HighPerformanceHooks{sharedCPUs: "3,4"}
 			err := h.PreCreate(context.TODO(), g, sb, c)
 			Expect(err).ToNot(HaveOccurred())
+			c.ApplySpecPatches(g.Config)
 			env := g.Config.Process.Env
 			Expect(env).To(ContainElements("OPENSHIFT_ISOLATED_CPUS=1-2", "OPENSHIFT_SHARED_CPUS=3-4"))
 		})
+
+		It("should publish the cpus assignment through the spec annotations", func() {
+			h := HighPerformanceHooks{sharedCPUs: "3,4"}
+			err := h.PreCreate(context.TODO(), g, sb, c)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(g.Config.Annotations).To(HaveKeyWithValue(crioannotations.IsolatedCPUs, "1-2"))
+			Expect(g.Config.Annotations).To(HaveKeyWithValue(crioannotations.SharedCPUs, "3-4"))
+		})
+
+		It("should inject env variables with the names configured for the runtime handler", func() {
+			h := HighPerformanceHooks{sharedCPUs: "3,4", isolatedCPUsEnvVar: "ISOLATED_CPUS", sharedCPUsEnvVar: "SHARED_CPUS"}
+			err := h.PreCreate(context.TODO(), g, sb, c)
+			Expect(err).ToNot(HaveOccurred())
+			c.ApplySpecPatches(g.Config)
+			env := g.Config.Process.Env
+			Expect(env).To(ContainElements("ISOLATED_CPUS=1-2", "SHARED_CPUS=3-4"))
+		})
+
+		It("should ignore the request if the shared CPUs are disabled", func() {
+			h := HighPerformanceHooks{sharedCPUs: "3,4", disabled: disabledFeatures{sharedCPUs: true}}
+			spec := &generate.Generator{Config: &specs.Spec{
+				Process: &specs.Process{},
+				Linux:   g.Config.Linux,
+			}}
+			err := h.PreCreate(context.TODO(), spec, sb, c)
+			Expect(err).ToNot(HaveOccurred())
+			c.ApplySpecPatches(spec.Config)
+			Expect(spec.Config.Process.Env).To(BeEmpty())
+			Expect(spec.Config.Annotations).To(BeEmpty())
+		})
+	})
+	Describe("failsOpen", func() {
+		c, err := oci.NewContainer("containerID", "", "", "",
+			make(map[string]string), make(map[string]string),
+			make(map[string]string), "pauseImage", nil, nil, "",
+			&types.ContainerMetadata{Name: "cnt1"}, "sandboxID", false, false,
+			false, "", "", time.Now(), "")
+		Expect(err).ToNot(HaveOccurred())
+
+		h := &HighPerformanceHooks{failOpen: []string{libconfig.HighPerformanceFeatureCPUFreqGovernor}}
+
+		It("should ignore the failures of the features configured to fail open", func() {
+			Expect(h.failsOpen(context.TODO(), libconfig.HighPerformanceFeatureCPUFreqGovernor, c, errors.New("unsupported"))).To(BeTrue())
+		})
+
+		It("should not ignore the failures of the other features", func() {
+			Expect(h.failsOpen(context.TODO(), libconfig.HighPerformanceFeatureCPUCStates, c, errors.New("unsupported"))).To(BeFalse())
+			Expect((&HighPerformanceHooks{}).failsOpen(context.TODO(), libconfig.HighPerformanceFeatureCPUFreqGovernor, c, errors.New("unsupported"))).To(BeFalse())
+		})
+
+		It("should collect the failures of the features failing open into the tuning outcome", func() {
+			ctx, outcome := withTuningOutcome(context.TODO())
+			Expect(h.failsOpen(ctx, libconfig.HighPerformanceFeatureCPUFreqGovernor, c, errors.New("unsupported"))).To(BeTrue())
+			Expect(h.failsOpen(ctx, libconfig.HighPerformanceFeatureCPUCStates, c, errors.New("unsupported"))).To(BeFalse())
+			Expect(outcome.failedOpen).To(Equal([]string{"cpu-freq-governor: unsupported"}))
+		})
+
+		It("should not fail restoring the power settings that fail open", func() {
+			annotations := map[string]string{crioannotations.CPUFreqGovernorAnnotation: "performance"}
+			// the container has no CPUs to restore the governor of
+			Expect((&HighPerformanceHooks{}).restorePowerSettings(context.TODO(), annotations, c)).NotTo(Succeed())
+			Expect(h.restorePowerSettings(context.TODO(), annotations, c)).To(Succeed())
+		})
+	})
+	Describe("cpusChanged", func() {
+		spec := &specs.Spec{Linux: &specs.Linux{Resources: &specs.LinuxResources{CPU: &specs.LinuxCPU{Cpus: "1-2"}}}}
+
+		It("should detect a new cpuset", func() {
+			Expect(cpusChanged(spec, &specs.LinuxResources{CPU: &specs.LinuxCPU{Cpus: "1-3"}})).To(BeTrue())
+		})
+
+		It("should ignore the updates keeping the cpuset", func() {
+			Expect(cpusChanged(spec, &specs.LinuxResources{CPU: &specs.LinuxCPU{Cpus: "1,2"}})).To(BeFalse())
+			Expect(cpusChanged(spec, &specs.LinuxResources{CPU: &specs.LinuxCPU{}})).To(BeFalse())
+			Expect(cpusChanged(spec, &specs.LinuxResources{})).To(BeFalse())
+			Expect(cpusChanged(spec, nil)).To(BeFalse())
+		})
+	})
+
+	Describe("tuning", func() {
+		It("should load the captured tuning once", func() {
+			dir := GinkgoT().TempDir()
+			governor := governorPerformance
+			captured := &tuning{CPUs: "1-2", CPUQuotaDisabled: true, FreqGovernor: &governor}
+			Expect(saveTuning(dir, captured)).To(Succeed())
+
+			loaded, err := loadTuning(dir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded).To(Equal(captured))
+
+			loaded, err = loadTuning(dir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded).To(BeNil())
+		})
+
+		It("should report the captured tuning which is not requested anymore", func() {
+			latency := "max_latency:10"
+			captured := &tuning{SharedCPUs: true, CPUQuotaDisabled: true, CStates: &latency}
+			requested := &tuning{CPUQuotaDisabled: true, IRQLoadBalancingDisabled: true}
+
+			Expect(lostTuning(captured, requested)).To(Equal([]string{"shared-cpus", libconfig.HighPerformanceFeatureCPUCStates}))
+			Expect(lostTuning(requested, requested)).To(BeEmpty())
+		})
+	})
+
+	Describe("revertCPUSetExclusiveFromState", func() {
+		stateDir := filepath.Join(fixturesDir, "state")
+		podCgroup := filepath.Join(fixturesDir, "cgroup", "pod")
+		ctrCgroup := filepath.Join(podCgroup, "ctr")
+
+		readCgroupFile := func(dir, file string) string {
+			content, err := os.ReadFile(filepath.Join(dir, file))
+			Expect(err).ToNot(HaveOccurred())
+			return strings.TrimSpace(string(content))
+		}
+
+		BeforeEach(func() {
+			cgroups.TestMode = true
+			Expect(os.MkdirAll(ctrCgroup, os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(podCgroup, cpusetCpus), []byte("0-7"), 0o644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(podCgroup, cpusetCpusExclusive), []byte("2-5"), 0o644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctrCgroup, cpusetCpus), []byte("2-3"), 0o644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctrCgroup, cpusetCpusExclusive), []byte("2-3"), 0o644)).To(Succeed())
+
+			state := &cpusetState{
+				ExclusiveCPUs: "2-3",
+				Cgroups: []cpusetCgroupState{
+					{Path: podCgroup},
+					{Path: ctrCgroup, ExclusiveOnly: true},
+				},
+			}
+			Expect(saveCPUSetState(stateDir, container.ID(), state)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			cgroups.TestMode = false
+		})
+
+		It("should revert the parent cgroups when the container cgroup is gone", func() {
+			Expect(os.RemoveAll(ctrCgroup)).To(Succeed())
+
+			h := &HighPerformanceHooks{}
+			Expect(h.revertCPUSetExclusiveFromState(context.TODO(), container.ID(), stateDir)).To(Succeed())
+
+			Expect(readCgroupFile(podCgroup, cpusetCpusExclusive)).To(Equal("4-5"))
+			Expect(readCgroupFile(podCgroup, cpusetCpus)).To(Equal("0-7"))
+			Expect(cpusetStateFile(stateDir, container.ID())).ToNot(BeAnExistingFile())
+		})
+
+		It("should revert the whole chain when the container cgroup still exists", func() {
+			h := &HighPerformanceHooks{}
+			Expect(h.revertCPUSetExclusiveFromState(context.TODO(), container.ID(), stateDir)).To(Succeed())
+
+			Expect(readCgroupFile(ctrCgroup, cpusetCpusPartition)).To(Equal("member"))
+			Expect(readCgroupFile(ctrCgroup, cpusetCpusExclusive)).To(BeEmpty())
+			Expect(readCgroupFile(ctrCgroup, cpusetCpus)).To(BeEmpty())
+			Expect(readCgroupFile(podCgroup, cpusetCpusExclusive)).To(Equal("4-5"))
+		})
+
+		It("should be a no-op without recorded state", func() {
+			Expect(removeCPUSetState(stateDir, container.ID())).To(Succeed())
+
+			h := &HighPerformanceHooks{}
+			Expect(h.revertCPUSetExclusiveFromState(context.TODO(), container.ID(), stateDir)).To(Succeed())
+			Expect(readCgroupFile(podCgroup, cpusetCpusExclusive)).To(Equal("2-5"))
+		})
+	})
+	Describe("cpusetChainDrift", func() {
+		podCgroup := filepath.Join(fixturesDir, "cgroup", "pod")
+		ctrCgroup := filepath.Join(podCgroup, "ctr")
+		state := &cpusetState{
+			ExclusiveCPUs: "2-3",
+			Cgroups: []cpusetCgroupState{
+				{Path: podCgroup},
+				{Path: ctrCgroup, ExclusiveOnly: true},
+			},
+		}
+
+		BeforeEach(func() {
+			cgroups.TestMode = true
+			Expect(os.MkdirAll(ctrCgroup, os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(podCgroup, cpusetCpusExclusive), []byte("2-5"), 0o644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(podCgroup, cpusetCpusPartition), []byte("member"), 0o644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctrCgroup, cpusetCpusExclusive), []byte("2-3"), 0o644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctrCgroup, cpusetCpusPartition), []byte("isolated"), 0o644)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			cgroups.TestMode = false
+		})
+
+		It("should report an intact chain", func() {
+			Expect(cpusetChainDrift(state)).To(BeEmpty())
+		})
+
+		It("should report the cgroups which lost the exclusive CPUs", func() {
+			Expect(os.WriteFile(filepath.Join(podCgroup, cpusetCpusExclusive), []byte("4-5"), 0o644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ctrCgroup, cpusetCpusPartition), []byte("member invalid"), 0o644)).To(Succeed())
+
+			Expect(cpusetChainDrift(state)).To(Equal([]string{
+				filepath.Join(podCgroup, cpusetCpusExclusive),
+				filepath.Join(ctrCgroup, cpusetCpusPartition),
+			}))
+		})
+	})
+	Describe("RepairTuningDrift", func() {
+		var governorFile string
+		performance := "performance"
+
+		BeforeEach(func() {
+			forgetAppliedTuning(context.TODO(), container.ID())
+			governorFile = filepath.Join(GinkgoT().TempDir(), "scaling_governor")
+			Expect(os.WriteFile(governorFile, []byte("powersave"), 0o644)).To(Succeed())
+			Expect(writeTuningFile(context.TODO(), container.ID(), governorFile, []byte(performance))).To(Succeed())
+			recordAppliedTuning(context.TODO(), container.ID(), &tuning{CPUs: "2-3", FreqGovernor: &performance})
+		})
+
+		AfterEach(func() {
+			forgetAppliedTuning(context.TODO(), container.ID())
+		})
+
+		It("should report no drift while the tuning is in place", func() {
+			h := &HighPerformanceHooks{}
+			Expect(h.RepairTuningDrift(context.TODO(), container, nil)).To(BeEmpty())
+		})
+
+		It("should repair the tuned files which drifted", func() {
+			Expect(os.WriteFile(governorFile, []byte("schedutil"), 0o644)).To(Succeed())
+
+			h := &HighPerformanceHooks{}
+			Expect(h.RepairTuningDrift(context.TODO(), container, nil)).To(Equal([]string{governorFile}))
+			Expect(os.ReadFile(governorFile)).To(Equal([]byte(performance)))
+			Expect(h.RepairTuningDrift(context.TODO(), container, nil)).To(BeEmpty())
+		})
+
+		It("should not repair the tuning of the containers without record", func() {
+			forgetAppliedTuning(context.TODO(), container.ID())
+			Expect(os.WriteFile(governorFile, []byte("schedutil"), 0o644)).To(Succeed())
+
+			h := &HighPerformanceHooks{}
+			Expect(h.RepairTuningDrift(context.TODO(), container, nil)).To(BeEmpty())
+			Expect(os.ReadFile(governorFile)).To(Equal([]byte("schedutil")))
+		})
+
+		It("should not reconcile the tuning of the restored containers in dry run", func() {
+			Expect(os.WriteFile(governorFile, []byte("schedutil"), 0o644)).To(Succeed())
+
+			h := &HighPerformanceHooks{dryRun: true}
+			Expect(h.ReconcileRestoredTuning(context.TODO(), container, nil)).To(BeEmpty())
+			Expect(os.ReadFile(governorFile)).To(Equal([]byte("schedutil")))
+		})
+
+		It("should not apply the tuning of the restored containers without record again", func() {
+			forgetAppliedTuning(context.TODO(), container.ID())
+			shares := uint64(2048)
+			container.SetSpec(&specs.Spec{Linux: &specs.Linux{Resources: &specs.LinuxResources{
+				CPU: &specs.LinuxCPU{Cpus: "2-3", Shares: &shares},
+			}}})
+			sbox := sandbox.NewBuilder()
+			sbox.SetID("sandboxID")
+			sbox.SetCreatedAt(time.Now())
+			Expect(sbox.SetCRISandbox("sandboxID", make(map[string]string), map[string]string{
+				crioannotations.CPUFreqGovernorAnnotation: performance,
+			}, &types.PodSandboxMetadata{})).To(Succeed())
+			sb, err := sbox.GetSandbox()
+			Expect(err).ToNot(HaveOccurred())
+
+			h := &HighPerformanceHooks{}
+			Expect(h.ReconcileRestoredTuning(context.TODO(), container, sb)).To(BeEmpty())
+			Expect(tuningRecorded(container.ID())).To(BeFalse())
+		})
+
+		It("should detect the CPUs added back to the IRQ affinity mask", func() {
+			irqSmpAffinityFile := filepath.Join(GinkgoT().TempDir(), "default_smp_affinity")
+			Expect(os.WriteFile(irqSmpAffinityFile, []byte("fffffff3\n"), 0o644)).To(Succeed())
+			Expect(irqLoadBalancingDrift("2-3", irqSmpAffinityFile)).To(BeFalse())
+
+			Expect(os.WriteFile(irqSmpAffinityFile, []byte("ffffffff\n"), 0o644)).To(Succeed())
+			Expect(irqLoadBalancingDrift("2-3", irqSmpAffinityFile)).To(BeTrue())
+		})
+	})
+	Describe("containerCgroupExists", func() {
+		It("should report a missing container cgroup without requiring a process", func() {
+			exists, err := containerCgroupExists(container.ID(), "/crio-test-missing-parent")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeFalse())
+		})
+
+		It("should report a missing systemd scope without requiring a process", func() {
+			exists, err := containerCgroupExists(container.ID(), "crio-test-missing.slice")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeFalse())
+		})
+	})
+	Describe("cgroupHierarchyFrom", func() {
+		It("should return the cgroups from the top of the hierarchy down to the cgroup", func() {
+			for _, cgroupPath := range []string{cgroupMountPoint + "/kubepods/pod1", "/kubepods/pod1/"} {
+				managers, err := cgroupHierarchyFrom(cgroupPath, false)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(managers).To(HaveLen(2))
+				Expect(managers[0].Path("cpuset")).To(HaveSuffix("/kubepods"))
+				Expect(managers[1].Path("cpuset")).To(HaveSuffix("/kubepods/pod1"))
+			}
+		})
+
+		It("should return no cgroup for the root cgroup", func() {
+			managers, err := cgroupHierarchyFrom(cgroupMountPoint, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(managers).To(BeEmpty())
+		})
+	})
+	Describe("adjustQuotaForSharedCPUs", func() {
+		period := uint64(100000)
+
+		DescribeTable("should replace the quota of the former shared cpus",
+			func(quota int64, oldShared, newShared cpuset.CPUSet, expected int64) {
+				q, err := adjustQuotaForSharedCPUs(quota, &oldShared, &newShared, period)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(q).To(Equal(expected))
+			},
+			Entry("when growing the pool", int64(400000), cpuset.New(0, 1), cpuset.New(0, 1, 2, 3), int64(600000)),
+			Entry("when shrinking the pool", int64(400000), cpuset.New(0, 1), cpuset.New(0), int64(300000)),
+			Entry("when the pool is unchanged in size", int64(400000), cpuset.New(0, 1), cpuset.New(2, 3), int64(400000)),
+			Entry("when the pool is removed", int64(400000), cpuset.New(0, 1), cpuset.New(), int64(200000)),
+		)
 	})
 })
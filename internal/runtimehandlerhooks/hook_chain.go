@@ -0,0 +1,228 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/generate"
+
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	"github.com/cri-o/cri-o/internal/oci"
+)
+
+// The priorities of the hooks applied to the same container. The stages setting up the tuning
+// (PreCreate, PreStart, PostUpdate, PreCheckpoint and PostRestore) run the hooks by increasing
+// priority, and the stages tearing it down (PreUpdate, PreStop and PostStop) by decreasing priority,
+// so a hook always sees the tuning of the hooks of lower priority, which is reverted after its own.
+const (
+	builtinHooksPriority = 100
+	pluginHooksPriority  = 200
+)
+
+// HookResource is a file of the node or a part of the container spec which hooks read or modify.
+type HookResource string
+
+const (
+	// HookResourceSpecEnv is the environment of the container process.
+	HookResourceSpecEnv HookResource = "spec.process.env"
+	// HookResourceSpecAnnotations are the annotations of the container spec.
+	HookResourceSpecAnnotations HookResource = "spec.annotations"
+	// HookResourceSpecMounts are the mounts of the container spec.
+	HookResourceSpecMounts HookResource = "spec.mounts"
+	// HookResourceSpecRlimits are the rlimits of the container process.
+	HookResourceSpecRlimits HookResource = "spec.process.rlimits"
+	// HookResourceCgroupCPUSet are the cpuset files of the pod and container cgroups.
+	HookResourceCgroupCPUSet HookResource = "cgroup.cpuset"
+	// HookResourceCgroupCPU are the CPU quota and shares files of the pod and container cgroups.
+	HookResourceCgroupCPU HookResource = "cgroup.cpu"
+	// HookResourceIRQAffinity are the /proc/irq affinity files.
+	HookResourceIRQAffinity HookResource = "irq.affinity"
+	// HookResourceIRQBalanceConfig is the irqbalance configuration file.
+	HookResourceIRQBalanceConfig HookResource = "irqbalance.config"
+	// HookResourceCPUPMQoS are the per-CPU pm_qos_resume_latency_us sysfs files.
+	HookResourceCPUPMQoS HookResource = "cpu.pm_qos_resume_latency_us"
+	// HookResourceCPUFreqGovernor are the per-CPU cpufreq scaling_governor sysfs files.
+	HookResourceCPUFreqGovernor HookResource = "cpu.cpufreq.scaling_governor"
+	// HookResourceNetDevQueues are the rps_cpus, xps_cpus and rps_flow_cnt sysfs files of the queues of the pod interfaces.
+	HookResourceNetDevQueues HookResource = "netdev.queues"
+	// HookResourceNetDevChannels are the ethtool channels of the VFs of the pod.
+	HookResourceNetDevChannels HookResource = "netdev.channels"
+	// HookResourceNetDevFeatures are the ethtool features of the pod interfaces.
+	HookResourceNetDevFeatures HookResource = "netdev.features"
+	// HookResourceNAPIAffinity is the CPU affinity of the NAPI threads of the pod interfaces.
+	HookResourceNAPIAffinity HookResource = "napi.affinity"
+	// HookResourceNetDevRSS are the RSS indirection tables of the pod interfaces.
+	HookResourceNetDevRSS HookResource = "netdev.rss"
+	// HookResourceNetDevBusyPoll are the napi_defer_hard_irqs and gro_flush_timeout sysfs files of the pod interfaces.
+	HookResourceNetDevBusyPoll HookResource = "netdev.busy_poll"
+	// HookResourceSpecCapabilities are the capabilities of the container process.
+	HookResourceSpecCapabilities HookResource = "spec.process.capabilities"
+	// HookResourceSpecBPFFS is the mount of the BPF filesystem of the container spec, at /sys/fs/bpf.
+	HookResourceSpecBPFFS HookResource = "spec.mounts.bpffs"
+)
+
+// HookContract documents the resources a hook reads and modifies. Two hooks applied to the same container
+// must not modify the same resource, and a hook reading a resource modified by another one must have a
+// higher priority, to run after it on setup.
+type HookContract struct {
+	Reads    []HookResource
+	Modifies []HookResource
+}
+
+// contractedHooks is implemented by the hooks documenting their contract.
+// The hooks without contract are assumed to touch no resource of the other hooks.
+type contractedHooks interface {
+	Contract() HookContract
+}
+
+// HookConflictError is returned when the hooks applied to the same container touch the same resource.
+type HookConflictError struct {
+	Resource HookResource
+	// Hooks are the names of the conflicting hooks, the modifying one first.
+	Hooks []string
+}
+
+func (e *HookConflictError) Error() string {
+	return fmt.Sprintf("runtime handler hooks %q and %q conflict on %s", e.Hooks[0], e.Hooks[1], e.Resource)
+}
+
+// chainedHooks are hooks of a hookChain.
+type chainedHooks struct {
+	name     string
+	priority int
+	hooks    RuntimeHandlerHooks
+}
+
+func (c *chainedHooks) contract() HookContract {
+	if contracted, ok := c.hooks.(contractedHooks); ok {
+		return contracted.Contract()
+	}
+	return HookContract{}
+}
+
+// hookChain runs the hooks applied to the same container in the order of their priorities.
+type hookChain struct {
+	// hooks are sorted by increasing priority.
+	hooks []chainedHooks
+}
+
+// newHookChain chains the non-nil hooks. It returns the hooks themselves if there is only one,
+// and a *HookConflictError if their contracts conflict.
+func newHookChain(hooks ...chainedHooks) (RuntimeHandlerHooks, error) {
+	hooks = slices.DeleteFunc(hooks, func(c chainedHooks) bool {
+		return c.hooks == nil
+	})
+	slices.SortStableFunc(hooks, func(a, b chainedHooks) int {
+		return a.priority - b.priority
+	})
+	if err := checkHookContracts(hooks); err != nil {
+		return nil, err
+	}
+	switch len(hooks) {
+	case 0:
+		return nil, nil
+	case 1:
+		return hooks[0].hooks, nil
+	}
+	return &hookChain{hooks: hooks}, nil
+}
+
+// checkHookContracts checks the contracts of the hooks sorted by priority.
+func checkHookContracts(hooks []chainedHooks) error {
+	for i := range hooks {
+		for j := range hooks {
+			if i == j {
+				continue
+			}
+			first, second := &hooks[i], &hooks[j]
+			if i < j && first.priority == second.priority {
+				return fmt.Errorf("runtime handler hooks %q and %q have the same priority %d", first.name, second.name, first.priority)
+			}
+			for _, resource := range first.contract().Modifies {
+				// a hook may only read the resources modified by the hooks running before it
+				if (i < j && slices.Contains(second.contract().Modifies, resource)) ||
+					(i > j && slices.Contains(second.contract().Reads, resource)) {
+					return &HookConflictError{Resource: resource, Hooks: []string{first.name, second.name}}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// setup runs the hook of every chained hooks by increasing priority, stopping on the first failure.
+func (h *hookChain) setup(hook func(RuntimeHandlerHooks) error) error {
+	for i := range h.hooks {
+		if err := hook(h.hooks[i].hooks); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// teardown runs the hook of every chained hooks by decreasing priority. Unless stopOnError is set, a hook still has to
+// revert its tuning if a hook of higher priority failed, so all the hooks are run and their failures joined.
+func (h *hookChain) teardown(stopOnError bool, hook func(RuntimeHandlerHooks) error) error {
+	var errs []error
+	for i := len(h.hooks) - 1; i >= 0; i-- {
+		if err := hook(h.hooks[i].hooks); err != nil {
+			if stopOnError {
+				return err
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *hookChain) PreCreate(ctx context.Context, specgen *generate.Generator, s *sandbox.Sandbox, c *oci.Container) error {
+	return h.setup(func(hooks RuntimeHandlerHooks) error {
+		return hooks.PreCreate(ctx, specgen, s, c)
+	})
+}
+
+func (h *hookChain) PreStart(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	return h.setup(func(hooks RuntimeHandlerHooks) error {
+		return hooks.PreStart(ctx, c, s)
+	})
+}
+
+func (h *hookChain) PreStop(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	return h.teardown(false, func(hooks RuntimeHandlerHooks) error {
+		return hooks.PreStop(ctx, c, s)
+	})
+}
+
+func (h *hookChain) PostStop(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	return h.teardown(false, func(hooks RuntimeHandlerHooks) error {
+		return hooks.PostStop(ctx, c, s)
+	})
+}
+
+// PreUpdate stops on the first failure, as the update of the container is aborted then.
+func (h *hookChain) PreUpdate(ctx context.Context, c *oci.Container, s *sandbox.Sandbox, resources *rspec.LinuxResources) error {
+	return h.teardown(true, func(hooks RuntimeHandlerHooks) error {
+		return hooks.PreUpdate(ctx, c, s, resources)
+	})
+}
+
+func (h *hookChain) PostUpdate(ctx context.Context, c *oci.Container, s *sandbox.Sandbox, former *rspec.LinuxResources) error {
+	return h.setup(func(hooks RuntimeHandlerHooks) error {
+		return hooks.PostUpdate(ctx, c, s, former)
+	})
+}
+
+func (h *hookChain) PreCheckpoint(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	return h.setup(func(hooks RuntimeHandlerHooks) error {
+		return hooks.PreCheckpoint(ctx, c, s)
+	})
+}
+
+func (h *hookChain) PostRestore(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	return h.setup(func(hooks RuntimeHandlerHooks) error {
+		return hooks.PostRestore(ctx, c, s)
+	})
+}
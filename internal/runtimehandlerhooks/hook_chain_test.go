@@ -0,0 +1,134 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// contractHooks are orderedHooks with a contract.
+type contractHooks struct {
+	orderedHooks
+	contract HookContract
+}
+
+func (c *contractHooks) Contract() HookContract {
+	return c.contract
+}
+
+var _ = Describe("hookChain", func() {
+	var calls []string
+
+	hooks := func(name string, contract HookContract) *contractHooks {
+		return &contractHooks{orderedHooks: orderedHooks{name: name, log: &calls}, contract: contract}
+	}
+
+	BeforeEach(func() {
+		calls = nil
+	})
+
+	It("should run the hooks by priority on setup and in reverse on teardown", func() {
+		chain, err := newHookChain(
+			chainedHooks{name: "last", priority: 300, hooks: hooks("last", HookContract{})},
+			chainedHooks{name: "first", priority: 100, hooks: hooks("first", HookContract{})},
+			chainedHooks{name: "second", priority: 200, hooks: hooks("second", HookContract{})},
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(chain.PreStart(context.Background(), nil, nil)).To(Succeed())
+		Expect(chain.PreStop(context.Background(), nil, nil)).To(Succeed())
+
+		Expect(calls).To(Equal([]string{
+			"first PreStart", "second PreStart", "last PreStart",
+			"last PreStop", "second PreStop", "first PreStop",
+		}))
+	})
+
+	It("should run all the teardown hooks and join their failures", func() {
+		last := hooks("last", HookContract{})
+		last.err = errors.New("last failed")
+		first := hooks("first", HookContract{})
+		first.err = errors.New("first failed")
+		chain, err := newHookChain(
+			chainedHooks{name: "first", priority: 100, hooks: first},
+			chainedHooks{name: "last", priority: 200, hooks: last},
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = chain.PostStop(context.Background(), nil, nil)
+
+		Expect(err).To(MatchError(last.err))
+		Expect(err).To(MatchError(first.err))
+		Expect(calls).To(Equal([]string{"last PostStop", "first PostStop"}))
+
+		calls = nil
+		Expect(chain.PreUpdate(context.Background(), nil, nil, nil)).To(MatchError(last.err))
+		Expect(calls).To(Equal([]string{"last PreUpdate"}))
+	})
+
+	It("should skip the missing hooks", func() {
+		only := hooks("only", HookContract{})
+
+		chain, err := newHookChain(
+			chainedHooks{name: "missing", priority: 100},
+			chainedHooks{name: "only", priority: 200, hooks: only},
+		)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(chain).To(BeIdenticalTo(only))
+	})
+
+	It("should reject the hooks modifying the same resource", func() {
+		_, err := newHookChain(
+			chainedHooks{name: "first", priority: 100, hooks: hooks("first", HookContract{
+				Modifies: []HookResource{HookResourceSpecEnv, HookResourceCgroupCPUSet},
+			})},
+			chainedHooks{name: "second", priority: 200, hooks: hooks("second", HookContract{
+				Modifies: []HookResource{HookResourceCgroupCPUSet},
+			})},
+		)
+
+		var conflict *HookConflictError
+		Expect(errors.As(err, &conflict)).To(BeTrue())
+		Expect(conflict.Resource).To(Equal(HookResourceCgroupCPUSet))
+		Expect(conflict.Hooks).To(Equal([]string{"first", "second"}))
+	})
+
+	It("should reject the hooks reading a resource modified after them", func() {
+		reader := HookContract{Reads: []HookResource{HookResourceIRQAffinity}}
+		writer := HookContract{Modifies: []HookResource{HookResourceIRQAffinity}}
+
+		_, err := newHookChain(
+			chainedHooks{name: "reader", priority: 100, hooks: hooks("reader", reader)},
+			chainedHooks{name: "writer", priority: 200, hooks: hooks("writer", writer)},
+		)
+		Expect(err).To(MatchError(`runtime handler hooks "writer" and "reader" conflict on irq.affinity`))
+
+		_, err = newHookChain(
+			chainedHooks{name: "reader", priority: 200, hooks: hooks("reader", reader)},
+			chainedHooks{name: "writer", priority: 100, hooks: hooks("writer", writer)},
+		)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should reject the hooks of the same priority", func() {
+		_, err := newHookChain(
+			chainedHooks{name: "first", priority: 100, hooks: hooks("first", HookContract{})},
+			chainedHooks{name: "second", priority: 100, hooks: hooks("second", HookContract{})},
+		)
+
+		Expect(err).To(MatchError(`runtime handler hooks "first" and "second" have the same priority 100`))
+	})
+
+	It("should not conflict between the built-in hooks and the plugin", func() {
+		for _, builtin := range []RuntimeHandlerHooks{&HighPerformanceHooks{}, &DefaultCPULoadBalanceHooks{}} {
+			_, err := newHookChain(
+				chainedHooks{name: "built-in", priority: builtinHooksPriority, hooks: builtin},
+				chainedHooks{name: "plugin", priority: pluginHooksPriority, hooks: &pluginHooks{}},
+			)
+			Expect(err).ToNot(HaveOccurred())
+		}
+	})
+})
@@ -0,0 +1,74 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/cri-o/cri-o/internal/log"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+)
+
+// hookLog is the logger of the hooks configured by SetHookLogFormat, nil to log like the rest of CRI-O.
+// The hooks are instantiated per request, so it is kept at package level.
+var hookLog = struct {
+	sync.Mutex
+	logger *logrus.Logger
+}{}
+
+type hookLogKey struct{}
+
+// SetHookLogFormat logs the lines of the hooks as text or JSON from now on, with their container,
+// pod, stage and step as fields, independently of the format of the rest of the logs.
+// An empty format logs them like the rest of the logs again.
+func SetHookLogFormat(format string) {
+	var formatter logrus.Formatter
+	switch format {
+	case libconfig.RuntimeHandlerHooksLogFormatText:
+		formatter = &logrus.TextFormatter{TimestampFormat: time.RFC3339Nano, FullTimestamp: true}
+	case libconfig.RuntimeHandlerHooksLogFormatJSON:
+		formatter = &logrus.JSONFormatter{TimestampFormat: time.RFC3339Nano}
+	}
+
+	hookLog.Lock()
+	defer hookLog.Unlock()
+	hookLog.logger = nil
+	if formatter == nil {
+		return
+	}
+	std := logrus.StandardLogger()
+	hookLog.logger = &logrus.Logger{
+		Out:       std.Out,
+		Hooks:     std.Hooks,
+		Formatter: formatter,
+		Level:     std.GetLevel(),
+		ExitFunc:  std.ExitFunc,
+	}
+}
+
+// hookLogger returns the logger of the hooks at the level of the standard logger, nil if not configured.
+func hookLogger() *logrus.Logger {
+	hookLog.Lock()
+	defer hookLog.Unlock()
+	if hookLog.logger != nil {
+		hookLog.logger.SetLevel(logrus.GetLevel())
+	}
+	return hookLog.logger
+}
+
+// withHookLogFields returns a context logging through the logger of the hooks with the fields
+// added to the ones of ctx, or ctx if the logger of the hooks is not configured.
+func withHookLogFields(ctx context.Context, fields logrus.Fields) context.Context {
+	e, ok := ctx.Value(hookLogKey{}).(*logrus.Entry)
+	if !ok {
+		logger := hookLogger()
+		if logger == nil {
+			return ctx
+		}
+		e = logrus.NewEntry(logger)
+	}
+	e = e.WithFields(fields)
+	return log.WithLogger(context.WithValue(ctx, hookLogKey{}, e), e)
+}
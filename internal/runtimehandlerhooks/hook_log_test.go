@@ -0,0 +1,74 @@
+package runtimehandlerhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/internal/oci"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+)
+
+var _ = Describe("hookLog", func() {
+	var (
+		buf *bytes.Buffer
+		c   *oci.Container
+	)
+
+	BeforeEach(func() {
+		buf = &bytes.Buffer{}
+		out := logrus.StandardLogger().Out
+		logrus.SetOutput(buf)
+		DeferCleanup(func() {
+			logrus.SetOutput(out)
+			SetHookLogFormat("")
+		})
+
+		c = newTestContainer("ctr1", "cnt1", "sandboxID")
+	})
+
+	It("should log the lines of the hooks as JSON with their fields", func() {
+		SetHookLogFormat(libconfig.RuntimeHandlerHooksLogFormatJSON)
+		ctx := withHookStage(withHookContainer(context.TODO(), c), "PreStart")
+
+		Expect(measureHookStep(ctx, hookStepSharedCPUs, nil, func(ctx context.Context) error {
+			log.Infof(ctx, "Inject the shared CPUs")
+			return nil
+		})).To(Succeed())
+
+		line := map[string]any{}
+		Expect(json.Unmarshal(buf.Bytes(), &line)).To(Succeed())
+		Expect(line).To(And(
+			HaveKeyWithValue("msg", "Inject the shared CPUs"),
+			HaveKeyWithValue("container", "ctr1"),
+			HaveKeyWithValue("pod", "sandboxID"),
+			HaveKeyWithValue("stage", "PreStart"),
+			HaveKeyWithValue("step", hookStepSharedCPUs),
+		))
+	})
+
+	It("should follow the level of the rest of the logs", func() {
+		level := logrus.GetLevel()
+		DeferCleanup(logrus.SetLevel, level)
+		SetHookLogFormat(libconfig.RuntimeHandlerHooksLogFormatJSON)
+		logrus.SetLevel(logrus.InfoLevel)
+
+		log.Debugf(withHookContainer(context.TODO(), c), "Not logged")
+
+		Expect(buf.String()).To(BeEmpty())
+	})
+
+	It("should log the lines of the hooks like the rest of the logs if not configured", func() {
+		ctx := withHookContainer(context.TODO(), c)
+
+		log.Infof(ctx, "Inject the shared CPUs")
+
+		Expect(buf.String()).To(ContainSubstring("Inject the shared CPUs"))
+		Expect(buf.String()).ToNot(ContainSubstring("container=ctr1"))
+	})
+})
@@ -0,0 +1,124 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/utils/cpuset"
+
+	"github.com/cri-o/cri-o/internal/oci"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+	"github.com/cri-o/cri-o/server/metrics"
+)
+
+// The steps of the hooks measured in the metrics and traces, besides the high-performance features.
+const (
+	hookStepTunedConflicts = "tuned-conflicts"
+	hookStepSharedCPUs     = planFeatureSharedCPUs
+	hookStepInitAffinity   = "init-affinity"
+	hookStepIsolatedCgroup = "isolated-cgroup"
+	hookStepVerification   = "verification"
+	hookStepDPDKReadiness  = "dpdk-readiness"
+)
+
+// The operations of irqbalance measured in the metrics.
+const (
+	irqBalanceOperationConfigUpdate = "config-update"
+	irqBalanceOperationRestart      = "restart"
+	irqBalanceOperationOneshot      = "oneshot"
+)
+
+type hookStageKey struct{}
+
+// withHookStage returns a context measuring the steps run with it as the ones of the hook stage, like "PreStart".
+func withHookStage(ctx context.Context, stage string) context.Context {
+	return withHookLogFields(context.WithValue(ctx, hookStageKey{}, stage), logrus.Fields{"stage": stage})
+}
+
+// withHookContainer returns a context auditing the writes of the hooks done with it for the container,
+// and logging the container and its pod as fields.
+func withHookContainer(ctx context.Context, c *oci.Container) context.Context {
+	return withHookLogFields(withAuditContainer(ctx, c.ID()), logrus.Fields{"container": c.ID(), "pod": c.Sandbox()})
+}
+
+// measureHookStep runs the step of the hook stage of ctx in its own span with the attrs, recording its duration
+// and failure in the metrics.
+func measureHookStep(ctx context.Context, step string, attrs []attribute.KeyValue, run func(context.Context) error) error {
+	stage, _ := ctx.Value(hookStageKey{}).(string)
+	ctx, span := trace.SpanFromContext(ctx).TracerProvider().Tracer("").Start(ctx, "runtimehandlerhooks."+stage+"/"+step, trace.WithAttributes(attrs...))
+	defer span.End()
+	ctx = withHookLogFields(ctx, logrus.Fields{"step": step})
+	start := time.Now()
+	err := run(ctx)
+	metrics.Instance().MetricRuntimeHandlerHookStepDurationObserve(stage, step, start)
+	if err != nil {
+		metrics.Instance().MetricRuntimeHandlerHookStepFailuresInc(stage, step)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// measureIrqBalanceOperation runs the irqbalance operation, recording its duration and failure in the metrics.
+func measureIrqBalanceOperation(operation string, run func() error) error {
+	start := time.Now()
+	err := run()
+	metrics.Instance().MetricIrqbalanceOperationDurationObserve(operation, start)
+	if err != nil {
+		metrics.Instance().MetricIrqbalanceOperationFailuresInc(operation)
+	}
+	return err
+}
+
+// hookStepAttributes returns the span attributes of a step tuning the container: its cpuset and the values of the
+// annotations requesting the step, keyed by their name.
+func hookStepAttributes(c *oci.Container, annotations fields.Set, keys ...string) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{}
+	if cSpec := c.Spec(); !isContainerCPUsSpecEmpty(&cSpec) {
+		attrs = append(attrs, attribute.String("cpuset", cSpec.Linux.Resources.CPU.Cpus))
+	}
+	for _, key := range keys {
+		if value, ok := annotations[key]; ok {
+			attrs = append(attrs, attribute.String(key, value))
+		}
+	}
+	return attrs
+}
+
+// reportIsolationState exports the amount of CPUs of the container tuned by every feature of its applied tuning t,
+// or stops exporting them if t is nil.
+func reportIsolationState(containerID string, t *tuning) {
+	metrics.Instance().MetricTuningIsolatedCPUsDelete(containerID)
+	for feature, cpus := range isolatedCPUs(t) {
+		metrics.Instance().MetricTuningIsolatedCPUsSet(containerID, feature, float64(cpus))
+	}
+}
+
+// isolatedCPUs returns the amount of CPUs tuned by the applied tuning t, by the features tuning them.
+// Enabling all the c-states of the CPUs does not count as tuning them.
+func isolatedCPUs(t *tuning) map[string]int {
+	if t == nil || t.CPUs == "" {
+		return nil
+	}
+	cpus, err := cpuset.Parse(t.CPUs)
+	if err != nil {
+		return nil
+	}
+	isolated := make(map[string]int)
+	for feature, tuned := range map[string]bool{
+		libconfig.HighPerformanceFeatureCPULoadBalancing: t.CPULoadBalancingDisabled,
+		libconfig.HighPerformanceFeatureIRQLoadBalancing: t.IRQLoadBalancingDisabled,
+		libconfig.HighPerformanceFeatureCPUCStates:       t.CStates != nil && *t.CStates != annotationEnable,
+		libconfig.HighPerformanceFeatureCPUFreqGovernor:  t.FreqGovernor != nil,
+	} {
+		if tuned {
+			isolated[feature] = cpus.Size()
+		}
+	}
+	return isolated
+}
@@ -0,0 +1,127 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+	"github.com/cri-o/cri-o/server/metrics"
+)
+
+// endedSpans records the spans once ended.
+type endedSpans struct {
+	spans []sdktrace.ReadOnlySpan
+}
+
+func (*endedSpans) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+
+func (e *endedSpans) OnEnd(s sdktrace.ReadOnlySpan) { e.spans = append(e.spans, s) }
+
+func (*endedSpans) Shutdown(context.Context) error { return nil }
+
+func (*endedSpans) ForceFlush(context.Context) error { return nil }
+
+var _ = Describe("measureHookStep", func() {
+	It("should run the step and return its failure", func() {
+		ctx := withHookStage(context.TODO(), "PreStart")
+		runs := 0
+		Expect(measureHookStep(ctx, hookStepTunedConflicts, nil, func(context.Context) error {
+			runs++
+			return nil
+		})).To(Succeed())
+
+		stepErr := errors.New("busy")
+		Expect(measureHookStep(ctx, hookStepSharedCPUs, nil, func(context.Context) error {
+			runs++
+			return stepErr
+		})).To(MatchError(stepErr))
+		Expect(runs).To(Equal(2))
+	})
+
+	It("should trace the step in a child span of the hook", func() {
+		ended := &endedSpans{}
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(ended))
+		ctx, hookSpan := provider.Tracer("").Start(withHookStage(context.TODO(), "PreStart"), "PreStart")
+
+		attrs := []attribute.KeyValue{attribute.String("cpuset", "1-2")}
+		Expect(measureHookStep(ctx, hookStepIsolatedCgroup, attrs, func(context.Context) error {
+			return errors.New("busy")
+		})).ToNot(Succeed())
+		hookSpan.End()
+
+		Expect(ended.spans).To(HaveLen(2))
+		step := ended.spans[0]
+		Expect(step.Name()).To(Equal("runtimehandlerhooks.PreStart/" + hookStepIsolatedCgroup))
+		Expect(step.Parent().SpanID()).To(Equal(hookSpan.SpanContext().SpanID()))
+		Expect(step.Attributes()).To(Equal(attrs))
+		Expect(step.Status().Code).To(Equal(codes.Error))
+	})
+})
+
+var _ = Describe("measureIrqBalanceOperation", func() {
+	It("should run the operation and return its failure", func() {
+		runs := 0
+		Expect(measureIrqBalanceOperation(irqBalanceOperationConfigUpdate, func() error {
+			runs++
+			return nil
+		})).To(Succeed())
+
+		restartErr := errors.New("unit not found")
+		Expect(measureIrqBalanceOperation(irqBalanceOperationRestart, func() error {
+			runs++
+			return restartErr
+		})).To(MatchError(restartErr))
+		Expect(runs).To(Equal(2))
+	})
+})
+
+var _ = Describe("reportIsolationState", func() {
+	It("should count the CPUs of the container by the features tuning them", func() {
+		disable, enable, governor := annotationDisable, annotationEnable, "performance"
+		Expect(isolatedCPUs(&tuning{
+			CPUs:                     "1-2,4",
+			CPULoadBalancingDisabled: true,
+			CStates:                  &disable,
+			FreqGovernor:             &governor,
+		})).To(Equal(map[string]int{
+			libconfig.HighPerformanceFeatureCPULoadBalancing: 3,
+			libconfig.HighPerformanceFeatureCPUCStates:       3,
+			libconfig.HighPerformanceFeatureCPUFreqGovernor:  3,
+		}))
+
+		Expect(isolatedCPUs(&tuning{
+			CPUs:                     "1-2",
+			IRQLoadBalancingDisabled: true,
+			CStates:                  &enable,
+		})).To(Equal(map[string]int{libconfig.HighPerformanceFeatureIRQLoadBalancing: 2}))
+	})
+
+	It("should not count the CPUs of the tuning without exclusive CPUs", func() {
+		Expect(isolatedCPUs(nil)).To(BeEmpty())
+		Expect(isolatedCPUs(&tuning{SharedCPUs: true, CPULoadBalancingDisabled: true})).To(BeEmpty())
+		Expect(isolatedCPUs(&tuning{CPUs: "invalid", CPULoadBalancingDisabled: true})).To(BeEmpty())
+	})
+
+	It("should export and stop exporting the CPUs of the container", func() {
+		disable := annotationDisable
+		reportIsolationState("containerID", &tuning{CPUs: "1-2", CPULoadBalancingDisabled: true, CStates: &disable})
+		Expect(metrics.Instance().MetricTuningIsolatedCPUs("containerID")).To(Equal(map[string]float64{
+			libconfig.HighPerformanceFeatureCPULoadBalancing: 2,
+			libconfig.HighPerformanceFeatureCPUCStates:       2,
+		}))
+
+		reportIsolationState("containerID", &tuning{CPUs: "1-3", CPULoadBalancingDisabled: true})
+		Expect(metrics.Instance().MetricTuningIsolatedCPUs("containerID")).To(Equal(map[string]float64{
+			libconfig.HighPerformanceFeatureCPULoadBalancing: 3,
+		}))
+
+		reportIsolationState("containerID", nil)
+		Expect(metrics.Instance().MetricTuningIsolatedCPUs("containerID")).To(BeEmpty())
+	})
+})
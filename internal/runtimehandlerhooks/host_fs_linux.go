@@ -0,0 +1,108 @@
+package runtimehandlerhooks
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	"golang.org/x/sys/unix"
+)
+
+// hostFS is the filesystem of the node tuned by the hooks: the sysfs and procfs files, the cgroups
+// and the configuration of irqbalance, as well as the original values of the tuned files saved to be restored.
+// The hooks are instantiated per request, so it is kept at package level, and the tests replace it with a fake.
+// The state of the hooks, like the tuning records and the checkpointed tuning, is still written to the disk.
+var hostFS hostFilesystem = osFilesystem{}
+
+// hostFilesystem gives access to the files of the node.
+type hostFilesystem interface {
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	Stat(name string) (os.FileInfo, error)
+	ReadDir(name string) ([]os.DirEntry, error)
+	Readlink(name string) (string, error)
+	MkdirAll(path string, perm os.FileMode) error
+	Remove(name string) error
+	// ReadCgroupFile and WriteCgroupFile access the file of the cgroup dir, like cgroups.ReadFile and
+	// cgroups.WriteFile, which check that the file is a cgroupfs one. WriteCgroupFile opens the file
+	// relative to the cgroup dir, which it opens anew on every call, so that the retries of writeCgroupFile
+	// write the new dir if systemd recreated the scope in between.
+	ReadCgroupFile(dir, file string) (string, error)
+	WriteCgroupFile(dir, file, data string) error
+}
+
+// osFilesystem is the hostFilesystem of the actual node.
+type osFilesystem struct{}
+
+func (osFilesystem) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+func (osFilesystem) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+func (osFilesystem) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFilesystem) ReadDir(name string) ([]os.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (osFilesystem) Readlink(name string) (string, error) {
+	return os.Readlink(name)
+}
+
+func (osFilesystem) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+func (osFilesystem) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (osFilesystem) ReadCgroupFile(dir, file string) (string, error) {
+	return cgroups.ReadFile(dir, file)
+}
+
+func (osFilesystem) WriteCgroupFile(dir, file, data string) error {
+	return writeCgroupFileAt(dir, file, data)
+}
+
+// writeCgroupFileAt writes data to the file of the cgroup dir through a file descriptor of dir,
+// so that the dir is resolved only once and the file cannot be one of another cgroup.
+func writeCgroupFileAt(dir, file, data string) error {
+	dirFd, err := unix.Open(dir, unix.O_DIRECTORY|unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return &os.PathError{Op: "open", Path: dir, Err: err}
+	}
+	defer unix.Close(dirFd)
+
+	flags, mode := unix.O_WRONLY|unix.O_CLOEXEC|unix.O_NOFOLLOW, uint32(0)
+	if cgroups.TestMode {
+		// Emulate the cgroupfs for the unit tests, like cgroups.WriteFile.
+		flags |= unix.O_CREAT | unix.O_TRUNC
+		mode = 0o600
+	} else {
+		var st unix.Statfs_t
+		if err := unix.Fstatfs(dirFd, &st); err != nil {
+			return &os.PathError{Op: "statfs", Path: dir, Err: err}
+		}
+		if st.Type != unix.CGROUP2_SUPER_MAGIC && st.Type != unix.CGROUP_SUPER_MAGIC {
+			return fmt.Errorf("%s is not on a cgroup filesystem", dir)
+		}
+	}
+
+	path := dir + "/" + file
+	fd, err := unix.Openat(dirFd, file, flags, mode)
+	if err != nil {
+		return &os.PathError{Op: "openat", Path: path, Err: err}
+	}
+	f := os.NewFile(uintptr(fd), path)
+	defer f.Close()
+	if _, err := f.WriteString(data); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,353 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/cri-o/cri-o/internal/oci"
+)
+
+// fakeHostFS is an in-memory hostFilesystem, faking the sysfs, procfs and cgroup files of a node.
+// Like sysfs and cgroupfs, it only writes existing files, except under the directories created by MkdirAll.
+type fakeHostFS struct {
+	sync.Mutex
+	files map[string]string
+	dirs  map[string]bool
+	// links are the targets of the symbolic links.
+	links map[string]string
+	// writeErrs are returned by the next writes of the files, one per write.
+	writeErrs map[string][]error
+	// writes are the files written, in order.
+	writes []string
+}
+
+// useFakeHostFS replaces the filesystem of the node by a fake one holding the files, for the current spec.
+func useFakeHostFS(files map[string]string) *fakeHostFS {
+	fake := &fakeHostFS{files: map[string]string{}, dirs: map[string]bool{}, links: map[string]string{}, writeErrs: map[string][]error{}}
+	for name, content := range files {
+		fake.files[name] = content
+	}
+	former := hostFS
+	hostFS = fake
+	DeferCleanup(func() {
+		hostFS = former
+	})
+	return fake
+}
+
+func (f *fakeHostFS) ReadFile(name string) ([]byte, error) {
+	f.Lock()
+	defer f.Unlock()
+	content, ok := f.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return []byte(content), nil
+}
+
+func (f *fakeHostFS) WriteFile(name string, data []byte, _ os.FileMode) error {
+	f.Lock()
+	defer f.Unlock()
+	if errs := f.writeErrs[name]; len(errs) > 0 {
+		f.writeErrs[name] = errs[1:]
+		return &fs.PathError{Op: "write", Path: name, Err: errs[0]}
+	}
+	if _, ok := f.files[name]; !ok && !f.dirs[filepath.Dir(name)] {
+		return &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	f.files[name] = string(data)
+	f.writes = append(f.writes, name)
+	return nil
+}
+
+func (f *fakeHostFS) Stat(name string) (os.FileInfo, error) {
+	f.Lock()
+	defer f.Unlock()
+	if _, ok := f.files[name]; ok {
+		return fakeFileInfo{name: filepath.Base(name)}, nil
+	}
+	if f.dirs[name] {
+		return fakeFileInfo{name: filepath.Base(name), dir: true}, nil
+	}
+	for file := range f.files {
+		if strings.HasPrefix(file, name+"/") {
+			return fakeFileInfo{name: filepath.Base(name), dir: true}, nil
+		}
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+func (f *fakeHostFS) ReadDir(name string) ([]os.DirEntry, error) {
+	f.Lock()
+	defer f.Unlock()
+	children := map[string]bool{}
+	paths := slices.Concat(slices.Collect(maps.Keys(f.files)), slices.Collect(maps.Keys(f.links)), slices.Collect(maps.Keys(f.dirs)))
+	for _, path := range paths {
+		if rest, ok := strings.CutPrefix(path, name+"/"); ok {
+			child, _, nested := strings.Cut(rest, "/")
+			children[child] = children[child] || nested || f.dirs[path]
+		}
+	}
+	if len(children) == 0 && !f.dirs[name] {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	entries := make([]os.DirEntry, 0, len(children))
+	for _, child := range slices.Sorted(maps.Keys(children)) {
+		entries = append(entries, fs.FileInfoToDirEntry(fakeFileInfo{name: child, dir: children[child]}))
+	}
+	return entries, nil
+}
+
+func (f *fakeHostFS) Readlink(name string) (string, error) {
+	f.Lock()
+	defer f.Unlock()
+	target, ok := f.links[name]
+	if !ok {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrNotExist}
+	}
+	return target, nil
+}
+
+func (f *fakeHostFS) MkdirAll(path string, _ os.FileMode) error {
+	f.Lock()
+	defer f.Unlock()
+	for ; path != "/" && path != "."; path = filepath.Dir(path) {
+		f.dirs[path] = true
+	}
+	return nil
+}
+
+func (f *fakeHostFS) Remove(name string) error {
+	f.Lock()
+	defer f.Unlock()
+	if _, ok := f.links[name]; ok {
+		delete(f.links, name)
+		return nil
+	}
+	if _, ok := f.files[name]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(f.files, name)
+	return nil
+}
+
+func (f *fakeHostFS) ReadCgroupFile(dir, file string) (string, error) {
+	content, err := f.ReadFile(filepath.Join(dir, file))
+	return string(content), err
+}
+
+func (f *fakeHostFS) WriteCgroupFile(dir, file, data string) error {
+	name := filepath.Join(dir, file)
+	if _, err := f.Stat(name); err != nil {
+		return err
+	}
+	return f.WriteFile(name, []byte(data), 0o644)
+}
+
+// symlink creates the symbolic link name pointing to target.
+func (f *fakeHostFS) symlink(target, name string) {
+	f.Lock()
+	defer f.Unlock()
+	f.links[name] = target
+}
+
+// content returns the content of the file, which must exist.
+func (f *fakeHostFS) content(name string) string {
+	content, err := f.ReadFile(name)
+	Expect(err).ToNot(HaveOccurred())
+	return string(content)
+}
+
+type fakeFileInfo struct {
+	name string
+	dir  bool
+}
+
+func (i fakeFileInfo) Name() string { return i.name }
+
+func (fakeFileInfo) Size() int64 { return 0 }
+
+func (i fakeFileInfo) Mode() os.FileMode {
+	if i.dir {
+		return os.ModeDir | 0o755
+	}
+	return 0o644
+}
+
+func (fakeFileInfo) ModTime() time.Time { return time.Time{} }
+
+func (i fakeFileInfo) IsDir() bool { return i.dir }
+
+func (fakeFileInfo) Sys() any { return nil }
+
+var _ = Describe("hostFS", func() {
+	const (
+		cpuDir     = "/sys/devices/system/cpu"
+		cpuSaveDir = "/var/run/crio/cpu"
+	)
+	var c *oci.Container
+
+	BeforeEach(func() {
+		c = newTestContainer("ctr1", "cnt1", "sandboxID")
+		c.SetSpec(&specs.Spec{Linux: &specs.Linux{Resources: &specs.LinuxResources{CPU: &specs.LinuxCPU{Cpus: "1-2"}}}})
+		DeferCleanup(func() {
+			forgetAppliedTuning(context.TODO(), c.ID())
+		})
+	})
+
+	It("should set and restore the governor of the container cpus", func() {
+		fake := useFakeHostFS(map[string]string{
+			cpuDir + "/cpu1/cpufreq/scaling_available_governors": "performance powersave\n",
+			cpuDir + "/cpu1/cpufreq/scaling_governor":            "powersave\n",
+			cpuDir + "/cpu2/cpufreq/scaling_available_governors": "performance powersave\n",
+			cpuDir + "/cpu2/cpufreq/scaling_governor":            "powersave\n",
+		})
+
+		Expect(doSetCPUFreqGovernor(context.TODO(), c, "performance", cpuDir, cpuSaveDir)).To(Succeed())
+		Expect(fake.content(cpuDir + "/cpu1/cpufreq/scaling_governor")).To(Equal("performance"))
+		Expect(fake.content(cpuDir + "/cpu2/cpufreq/scaling_governor")).To(Equal("performance"))
+		Expect(fake.content(cpuSaveDir + "/cpu1/cpufreq/scaling_governor")).To(Equal("powersave\n"))
+
+		Expect(doSetCPUFreqGovernor(context.TODO(), c, "", cpuDir, cpuSaveDir)).To(Succeed())
+		Expect(fake.content(cpuDir + "/cpu1/cpufreq/scaling_governor")).To(Equal("powersave\n"))
+		Expect(fake.content(cpuDir + "/cpu2/cpufreq/scaling_governor")).To(Equal("powersave\n"))
+		Expect(fileExists(cpuSaveDir + "/cpu1/cpufreq/scaling_governor")).To(BeFalse())
+	})
+
+	It("should not change any governor if one of the cpus does not support it", func() {
+		fake := useFakeHostFS(map[string]string{
+			cpuDir + "/cpu1/cpufreq/scaling_available_governors": "performance powersave\n",
+			cpuDir + "/cpu1/cpufreq/scaling_governor":            "powersave\n",
+			cpuDir + "/cpu2/cpufreq/scaling_available_governors": "powersave\n",
+			cpuDir + "/cpu2/cpufreq/scaling_governor":            "powersave\n",
+		})
+
+		Expect(doSetCPUFreqGovernor(context.TODO(), c, "performance", cpuDir, cpuSaveDir)).To(MatchError("governor performance not available for cpu 2"))
+		Expect(fake.writes).To(BeEmpty())
+	})
+
+	It("should keep the original PM QoS resume latency of a restarted container", func() {
+		fake := useFakeHostFS(map[string]string{
+			cpuDir + "/cpu1/power/pm_qos_resume_latency_us": "0\n",
+			cpuDir + "/cpu2/power/pm_qos_resume_latency_us": "0\n",
+		})
+
+		Expect(doSetCPUPMQOSResumeLatency(context.TODO(), c, "n/a", cpuDir, cpuSaveDir)).To(Succeed())
+		Expect(doSetCPUPMQOSResumeLatency(context.TODO(), c, "10", cpuDir, cpuSaveDir)).To(Succeed())
+		Expect(fake.content(cpuDir + "/cpu2/power/pm_qos_resume_latency_us")).To(Equal("10"))
+		Expect(fake.content(cpuSaveDir + "/cpu2/power/pm_qos_resume_latency_us")).To(Equal("0\n"))
+
+		Expect(doSetCPUPMQOSResumeLatency(context.TODO(), c, "", cpuDir, cpuSaveDir)).To(Succeed())
+		Expect(fake.content(cpuDir + "/cpu2/power/pm_qos_resume_latency_us")).To(Equal("0\n"))
+	})
+
+	It("should ban the container cpus from the IRQs and the irqbalance config", func() {
+		const irqSmpAffinityFile = "/proc/irq/default_smp_affinity"
+		const irqBalanceConfigFile = "/etc/sysconfig/irqbalance"
+		fake := useFakeHostFS(map[string]string{
+			irqSmpAffinityFile:   "ff\n",
+			irqBalanceConfigFile: "IRQBALANCE_ONESHOT=\n",
+		})
+
+		Expect(setIRQLoadBalancing(context.TODO(), c, false, irqSmpAffinityFile, irqBalanceConfigFile)).To(Succeed())
+		Expect(fake.content(irqSmpAffinityFile)).To(Equal("000000f9"))
+		Expect(fake.content(irqBalanceConfigFile)).To(ContainSubstring(`IRQBALANCE_BANNED_CPUS="00000006"`))
+
+		Expect(setIRQLoadBalancing(context.TODO(), c, true, irqSmpAffinityFile, irqBalanceConfigFile)).To(Succeed())
+		Expect(fake.content(irqSmpAffinityFile)).To(Equal("000000ff"))
+		Expect(fake.content(irqBalanceConfigFile)).To(ContainSubstring(`IRQBALANCE_BANNED_CPUS="ffffff00"`))
+	})
+
+	It("should ban the container cpus in the reloaded irqbalance config", func() {
+		const irqBalanceConfigFile = "/etc/default/irqbalance"
+		fake := useFakeHostFS(map[string]string{
+			IrqSmpAffinityProcFile: "000000f9\n",
+			irqBalanceConfigFile:   "IRQBALANCE_ONESHOT=\n",
+		})
+		recordAppliedTuning(context.TODO(), c.ID(), &tuning{CPUs: "1-2", IRQLoadBalancingDisabled: true})
+		DeferCleanup(forgetAppliedTuning, context.TODO(), c.ID())
+
+		h := &HighPerformanceHooks{irqBalanceConfigFile: irqBalanceConfigFile}
+		Expect(h.ReconcileTuning(context.TODO(), c, nil)).To(BeEmpty())
+		Expect(fake.content(IrqSmpAffinityProcFile)).To(Equal("000000f9\n"))
+		Expect(fake.content(irqBalanceConfigFile)).To(ContainSubstring(`IRQBALANCE_BANNED_CPUS="ffffff06"`))
+	})
+
+	It("should detect the drift of the exclusive cpuset chain", func() {
+		const podCgroup = "/sys/fs/cgroup/kubepods.slice/pod"
+		const ctrCgroup = podCgroup + "/ctr1"
+		fake := useFakeHostFS(map[string]string{
+			podCgroup + "/" + cpusetCpusExclusive: "1-2\n",
+			ctrCgroup + "/" + cpusetCpusExclusive: "1-2\n",
+			ctrCgroup + "/" + cpusetCpusPartition: "isolated\n",
+		})
+		state := &cpusetState{ExclusiveCPUs: "1-2", Cgroups: []cpusetCgroupState{{Path: podCgroup}, {Path: ctrCgroup}}}
+
+		Expect(cpusetChainDrift(state)).To(BeEmpty())
+
+		Expect(fake.WriteCgroupFile(podCgroup, cpusetCpusExclusive, "1\n")).To(Succeed())
+		Expect(fake.WriteCgroupFile(ctrCgroup, cpusetCpusPartition, "member\n")).To(Succeed())
+		Expect(cpusetChainDrift(state)).To(Equal([]string{
+			podCgroup + "/" + cpusetCpusExclusive,
+			ctrCgroup + "/" + cpusetCpusPartition,
+		}))
+	})
+
+	It("should retry the cgroup writes failing with EBUSY", func() {
+		backoff := cgroupWriteBackoff
+		cgroupWriteBackoff = wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 3}
+		DeferCleanup(func() {
+			cgroupWriteBackoff = backoff
+		})
+		const ctrCgroup = "/sys/fs/cgroup/kubepods.slice/pod/ctr1"
+		fake := useFakeHostFS(map[string]string{ctrCgroup + "/" + cpusetCpus: "1-2\n"})
+		fake.writeErrs[ctrCgroup+"/"+cpusetCpus] = []error{unix.EBUSY, unix.EBUSY}
+
+		Expect(writeCgroupFile(context.TODO(), ctrCgroup, cpusetCpus, "0-2")).To(Succeed())
+		Expect(fake.content(ctrCgroup + "/" + cpusetCpus)).To(Equal("0-2"))
+
+		Expect(writeCgroupFile(context.TODO(), ctrCgroup, "cpuset.missing", "0")).To(MatchError(os.ErrNotExist))
+	})
+})
+
+var _ = Describe("osFilesystem", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+	})
+
+	It("should write the files of the cgroup dir", func() {
+		cgroups.TestMode = true
+		DeferCleanup(func() {
+			cgroups.TestMode = false
+		})
+		Expect(os.WriteFile(filepath.Join(dir, cpusetCpus), []byte("1-2\n"), 0o644)).To(Succeed())
+
+		Expect(osFilesystem{}.WriteCgroupFile(dir, cpusetCpus, "3")).To(Succeed())
+		Expect(os.ReadFile(filepath.Join(dir, cpusetCpus))).To(Equal([]byte("3")))
+
+		Expect(osFilesystem{}.WriteCgroupFile(filepath.Join(dir, "removed"), cpusetCpus, "3")).To(MatchError(os.ErrNotExist))
+	})
+
+	It("should only write the files of a cgroup filesystem", func() {
+		Expect(os.WriteFile(filepath.Join(dir, cpusetCpus), []byte("1-2\n"), 0o644)).To(Succeed())
+
+		Expect(osFilesystem{}.WriteCgroupFile(dir, cpusetCpus, "3")).To(MatchError(ContainSubstring("not on a cgroup filesystem")))
+		Expect(os.ReadFile(filepath.Join(dir, cpusetCpus))).To(Equal([]byte("1-2\n")))
+	})
+})
@@ -0,0 +1,102 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	"github.com/cri-o/cri-o/internal/log"
+	crioann "github.com/cri-o/cri-o/pkg/annotations"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+)
+
+// interruptCoalescingSettings are the ethtool settings of the interrupt coalescing annotation,
+// in the order they are set.
+var interruptCoalescingSettings = []string{ethtoolAdaptiveRX, ethtoolAdaptiveTX, ethtoolRXUsecs, ethtoolTXUsecs}
+
+// parseInterruptCoalescing returns the ethtool settings of the value of the interrupt coalescing annotation,
+// like "rx-usecs=0,tx-usecs=8". The adaptive coalescing gets turned off in both directions, as it would
+// change the delays of the interrupts on its own.
+func parseInterruptCoalescing(value string) (map[string]string, error) {
+	settings := map[string]string{ethtoolAdaptiveRX: "off", ethtoolAdaptiveTX: "off"}
+	for _, param := range strings.Split(value, ",") {
+		key, usecs, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || (key != ethtoolRXUsecs && key != ethtoolTXUsecs) {
+			return nil, fmt.Errorf("invalid parameter %q, expected %q or %q", param, ethtoolRXUsecs+"=<usecs>", ethtoolTXUsecs+"=<usecs>")
+		}
+		if _, dup := settings[key]; dup {
+			return nil, fmt.Errorf("parameter %q set twice", key)
+		}
+		if _, err := strconv.ParseUint(usecs, 10, 32); err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", key, usecs, err)
+		}
+		settings[key] = usecs
+	}
+	return settings, nil
+}
+
+// SetPodInterruptCoalescing sets the interrupt coalescing requested by the annotations of the pod on the devices
+// backing its interfaces, once its network is set up, until RevertPodNetworkTuning gets called when the pod
+// stops. The writes are recorded for the pod, keyed by its sandbox ID, like the ones of the containers.
+// The CNI result of the pod, if any, resolves its default network for the tuning interfaces annotation.
+func SetPodInterruptCoalescing(ctx context.Context, config *libconfig.Config, sb *sandbox.Sandbox, cniResult string) error {
+	value, ok := sb.Annotations()[crioann.InterruptCoalescingAnnotation]
+	if !ok {
+		return nil
+	}
+	settings := config.HighPerformanceSettingsFor(sb.RuntimeHandler())
+	if !settings.FeatureEnabled(libconfig.HighPerformanceFeatureInterruptCoalescing) ||
+		config.HighPerformancePolicyFor(sb.RuntimeHandler()) != libconfig.RuntimeTypeTuningTune {
+		return nil
+	}
+	if sb.HostNetwork() || sb.NetNsPath() == "" {
+		log.Warnf(ctx, "Interrupt coalescing requested for pod sandbox %s on the host network, ignoring", sb.ID())
+		return nil
+	}
+	coalescing, err := parseInterruptCoalescing(value)
+	if err != nil {
+		return err
+	}
+	network, err := podNetworkOf(sb, cniResult)
+	if err != nil {
+		return err
+	}
+	log.Infof(ctx, "Set the interrupt coalescing of the interfaces of pod sandbox %s to %q", sb.ID(), value)
+	err = setInterruptCoalescing(ctx, sb.ID(), network, coalescing)
+	if err != nil && slices.Contains(settings.FailOpen, libconfig.HighPerformanceFeatureInterruptCoalescing) {
+		log.Warnf(ctx, "Ignoring the failure of %s for pod sandbox %s: %v", libconfig.HighPerformanceFeatureInterruptCoalescing, sb.ID(), err)
+		return nil
+	}
+	return err
+}
+
+// setInterruptCoalescing sets the ethtool coalescing settings of the interfaces of the pod network, recording
+// the writes for the ID. The settings a device does not support, like the ones of a veth
+// device, are skipped.
+func setInterruptCoalescing(ctx context.Context, id string, network podNetwork, coalescing map[string]string) error {
+	interfaces, err := network.interfaces()
+	if err != nil {
+		return err
+	}
+	for _, iface := range interfaces {
+		for _, setting := range interruptCoalescingSettings {
+			value, ok := coalescing[setting]
+			if !ok {
+				continue
+			}
+			err := writeNetTuningFile(ctx, id, iface.NetNS, ethtoolSettingFile(iface.Name, setting), []byte(value))
+			if errors.Is(err, errors.ErrUnsupported) {
+				log.Debugf(ctx, "Interface %s does not support %s, skipping", iface, setting)
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("set interrupt coalescing of interface %s: %w", iface, err)
+			}
+		}
+	}
+	return nil
+}
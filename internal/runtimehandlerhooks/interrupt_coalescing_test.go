@@ -0,0 +1,71 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("interrupt coalescing", func() {
+	const (
+		netns     = "/var/run/netns/pod"
+		sandboxID = "sb1"
+	)
+	var (
+		dir     string
+		ethtool fakeEthtool
+	)
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		ethtool = fakeEthtool{
+			"net1": {ethtoolAdaptiveRX: "on", ethtoolAdaptiveTX: "on", ethtoolRXUsecs: "50", ethtoolTXUsecs: "50"},
+			// veth devices do not support interrupt coalescing
+			"veth1234": {},
+		}
+		useFakePodNetwork([]podInterface{{Name: "net1", NetNS: netns}, {Name: "veth1234"}}, map[string]string{"": filepath.Join(dir, "host"), netns: filepath.Join(dir, "pod")}, ethtool)
+		Expect(os.MkdirAll(filepath.Join(dir, "pod", "class", "net", "net1"), 0o755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(dir, "host", "class", "net", "veth1234"), 0o755)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		forgetAppliedTuning(context.TODO(), sandboxID)
+	})
+
+	It("should set and revert the interrupt coalescing of the devices of the pod", func() {
+		coalescing, err := parseInterruptCoalescing("rx-usecs=0")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(setInterruptCoalescing(context.TODO(), sandboxID, podNetwork{NetNS: netns}, coalescing)).To(Succeed())
+
+		Expect(ethtool["net1"]).To(Equal(map[string]string{
+			ethtoolAdaptiveRX: "off", ethtoolAdaptiveTX: "off", ethtoolRXUsecs: "0", ethtoolTXUsecs: "50",
+		}))
+		record, ok := recordedTuning(sandboxID)
+		Expect(ok).To(BeTrue())
+		Expect(record.Writes).To(HaveLen(3))
+
+		Expect(RevertPodNetworkTuning(context.TODO(), sandboxID)).To(Succeed())
+
+		Expect(ethtool["net1"]).To(Equal(map[string]string{
+			ethtoolAdaptiveRX: "on", ethtoolAdaptiveTX: "on", ethtoolRXUsecs: "50", ethtoolTXUsecs: "50",
+		}))
+		Expect(tuningRecorded(sandboxID)).To(BeFalse())
+	})
+
+	DescribeTable("should reject the invalid values",
+		func(value string) {
+			_, err := parseInterruptCoalescing(value)
+
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("empty", ""),
+		Entry("without usecs", "rx-usecs"),
+		Entry("negative usecs", "rx-usecs=-1"),
+		Entry("adaptive", "adaptive-rx=on"),
+		Entry("set twice", "tx-usecs=0,tx-usecs=8"),
+	)
+})
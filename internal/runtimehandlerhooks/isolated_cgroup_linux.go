@@ -0,0 +1,279 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"k8s.io/utils/cpuset"
+
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/internal/oci"
+)
+
+// isolatedChildCgroup is the name of the cgroup created inside the container cgroup
+// to hold the isolated CPUs of a container that also requested shared CPUs.
+// Management processes inside the container rely on this name to move the
+// data-path threads into the isolated partition.
+const isolatedChildCgroup = "cgroup-child"
+
+// isolatedChildCgroups tracks the child cgroups owned by the high-performance hooks,
+// keyed by container ID. The hooks are instantiated per request, so the ownership
+// needs to be kept at package level to be visible from PreStart to PostStop.
+var isolatedChildCgroups = struct {
+	sync.Mutex
+	watchers map[string]*isolatedChildCgroupWatcher
+}{watchers: make(map[string]*isolatedChildCgroupWatcher)}
+
+// isolatedChildCgroupWatcher re-creates the isolated child cgroup of a container if
+// the low-level runtime clobbers it, for example on resource updates triggered
+// by `kubectl exec` or UpdateContainerResources.
+type isolatedChildCgroupWatcher struct {
+	containerID string
+	// containerManager is the manager of the cgroup holding the child cgroup.
+	containerManager cgroups.Manager
+	// containerCPUs is the union of exclusive and shared CPUs of the container cgroup.
+	containerCPUs     cpuset.CPUSet
+	containerCPUsLock sync.Mutex
+	exclusiveCPUs     cpuset.CPUSet
+	// isolated is set when the child cgroup is an isolated partition.
+	isolated bool
+	watcher  *fsnotify.Watcher
+	done     chan struct{}
+}
+
+func (w *isolatedChildCgroupWatcher) containerCgroup() string {
+	return w.containerManager.Path("")
+}
+
+func (w *isolatedChildCgroupWatcher) childCgroup() string {
+	return filepath.Join(w.containerCgroup(), isolatedChildCgroup)
+}
+
+// watchIsolatedChildCgroup takes ownership of the isolated child cgroup of the container
+// and starts watching it for rewrites done by the low-level runtime.
+// Any previous watcher of the same container is replaced.
+func watchIsolatedChildCgroup(ctx context.Context, containerID string, containerManager cgroups.Manager, exclusiveCPUs, containerCPUs cpuset.CPUSet, isolated bool) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	w := &isolatedChildCgroupWatcher{
+		containerID:      containerID,
+		containerManager: containerManager,
+		containerCPUs:    containerCPUs,
+		exclusiveCPUs:    exclusiveCPUs,
+		isolated:         isolated,
+		watcher:          watcher,
+		done:             make(chan struct{}),
+	}
+	for _, dir := range []string{w.containerCgroup(), w.childCgroup()} {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return err
+		}
+	}
+
+	isolatedChildCgroups.Lock()
+	if previous, ok := isolatedChildCgroups.watchers[containerID]; ok {
+		previous.stop()
+	}
+	isolatedChildCgroups.watchers[containerID] = w
+	isolatedChildCgroups.Unlock()
+
+	go w.run(context.WithoutCancel(ctx))
+	return nil
+}
+
+// watchIsolatedChildCgroupOfContainer starts watching the isolated child cgroup created by setSharedCPUs,
+// which is expected to be the last of the container managers.
+func (h *HighPerformanceHooks) watchIsolatedChildCgroupOfContainer(ctx context.Context, c *oci.Container, containerManagers []cgroups.Manager, isolated bool) error {
+	exclusiveCPUs, err := cpuset.Parse(c.Spec().Linux.Resources.CPU.Cpus)
+	if err != nil {
+		return fmt.Errorf("failed to parse container %q cpus: %w", c.Name(), err)
+	}
+	sharedCPUSet, err := cpuset.Parse(h.sharedCPUs)
+	if err != nil {
+		return fmt.Errorf("failed to parse shared cpus: %w", err)
+	}
+	ctrManager, err := getManagerByIndex(len(containerManagers)-2, containerManagers)
+	if err != nil {
+		return err
+	}
+	return watchIsolatedChildCgroup(ctx, c.ID(), ctrManager, exclusiveCPUs, exclusiveCPUs.Union(sharedCPUSet), isolated)
+}
+
+// updateIsolatedChildCgroupCPUs updates the CPUs the watcher expects in the container cgroup,
+// for example after the shared CPU pool changed. It is a no-op if the container does not own
+// an isolated child cgroup.
+func updateIsolatedChildCgroupCPUs(containerID string, containerCPUs cpuset.CPUSet) {
+	isolatedChildCgroups.Lock()
+	defer isolatedChildCgroups.Unlock()
+	if w, ok := isolatedChildCgroups.watchers[containerID]; ok {
+		w.containerCPUsLock.Lock()
+		w.containerCPUs = containerCPUs
+		w.containerCPUsLock.Unlock()
+	}
+}
+
+// releaseIsolatedChildCgroup stops watching the isolated child cgroup of the container
+// and gives up its ownership. It is a no-op if the container does not own one.
+func releaseIsolatedChildCgroup(containerID string) {
+	isolatedChildCgroups.Lock()
+	defer isolatedChildCgroups.Unlock()
+	if w, ok := isolatedChildCgroups.watchers[containerID]; ok {
+		w.stop()
+		delete(isolatedChildCgroups.watchers, containerID)
+	}
+}
+
+func (w *isolatedChildCgroupWatcher) stop() {
+	close(w.done)
+	w.watcher.Close()
+}
+
+func (w *isolatedChildCgroupWatcher) run(ctx context.Context) {
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if !w.concerns(event) {
+				continue
+			}
+			// The container cgroup itself is gone, nothing left to protect.
+			if _, err := hostFS.Stat(w.containerCgroup()); errors.Is(err, os.ErrNotExist) {
+				return
+			}
+			log.Debugf(ctx, "Isolated child cgroup of container %q was modified: %v", w.containerID, event)
+			if err := w.reconcile(ctx); err != nil {
+				log.Warnf(ctx, "Failed to restore isolated child cgroup of container %q: %v", w.containerID, err)
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Warnf(ctx, "Watch error for isolated child cgroup of container %q: %v", w.containerID, err)
+		}
+	}
+}
+
+// concerns returns true if the event may have altered the isolated child cgroup or
+// the cpuset of the container cgroup.
+func (w *isolatedChildCgroupWatcher) concerns(event fsnotify.Event) bool {
+	switch event.Name {
+	case w.childCgroup():
+		return event.Has(fsnotify.Remove) || event.Has(fsnotify.Create)
+	case filepath.Join(w.containerCgroup(), cpusetCpus),
+		filepath.Join(w.containerCgroup(), cgroupSubTreeControl),
+		filepath.Join(w.childCgroup(), cpusetCpus),
+		filepath.Join(w.childCgroup(), cpusetCpusPartition):
+		return event.Has(fsnotify.Write)
+	}
+	return false
+}
+
+func (w *isolatedChildCgroupWatcher) reconcile(ctx context.Context) error {
+	if err := w.ensureContainerCPUs(ctx); err != nil {
+		return err
+	}
+	created, err := ensureIsolatedChildCgroup(ctx, w.containerCgroup(), w.exclusiveCPUs, w.isolated)
+	if err != nil {
+		return err
+	}
+	if created {
+		// A re-created directory is a new inode, so it must be watched again.
+		return w.watcher.Add(w.childCgroup())
+	}
+	return nil
+}
+
+// ensureContainerCPUs restores the shared CPUs of the container cgroup,
+// in case the runtime narrowed cpuset.cpus down to the exclusive CPUs.
+func (w *isolatedChildCgroupWatcher) ensureContainerCPUs(ctx context.Context) error {
+	currentCpusStr, err := hostFS.ReadCgroupFile(w.containerCgroup(), cpusetCpus)
+	if err != nil {
+		return err
+	}
+	currentCpus, err := cpuset.Parse(strings.TrimSpace(currentCpusStr))
+	if err != nil {
+		return err
+	}
+	w.containerCPUsLock.Lock()
+	containerCPUs := w.containerCPUs
+	w.containerCPUsLock.Unlock()
+	if currentCpus.Equals(containerCPUs) {
+		return nil
+	}
+	return setCgroupResources(ctx, w.containerManager, &configs.Resources{
+		SkipDevices: true,
+		CpusetCpus:  containerCPUs.String(),
+	})
+}
+
+// ensureIsolatedChildCgroup makes sure the isolated child cgroup exists under the container cgroup,
+// with the cpu and cpuset controllers enabled, the exclusive CPUs assigned and, if requested, the
+// isolated partition set up. It returns true if the child cgroup had to be re-created.
+func ensureIsolatedChildCgroup(ctx context.Context, containerCgroup string, exclusiveCPUs cpuset.CPUSet, isolated bool) (created bool, _ error) {
+	subtreeControl, err := hostFS.ReadCgroupFile(containerCgroup, cgroupSubTreeControl)
+	if err != nil {
+		return false, err
+	}
+	controllers := strings.Fields(subtreeControl)
+	if !slices.Contains(controllers, "cpu") || !slices.Contains(controllers, "cpuset") {
+		if err := writeCgroupFile(ctx, containerCgroup, cgroupSubTreeControl, "+cpu +cpuset"); err != nil {
+			return false, err
+		}
+	}
+
+	childCgroup := filepath.Join(containerCgroup, isolatedChildCgroup)
+	if err := os.Mkdir(childCgroup, 0o755); err != nil {
+		if !errors.Is(err, os.ErrExist) {
+			return false, err
+		}
+	} else {
+		created = true
+	}
+
+	if !created {
+		currentCpusStr, err := hostFS.ReadCgroupFile(childCgroup, cpusetCpus)
+		if err != nil {
+			return false, err
+		}
+		currentCpus, err := cpuset.Parse(strings.TrimSpace(currentCpusStr))
+		if err != nil {
+			return false, err
+		}
+		partition, err := hostFS.ReadCgroupFile(childCgroup, cpusetCpusPartition)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return false, err
+		}
+		// cpuset.cpus.partition reads e.g. "isolated invalid (...)" when the partition is broken,
+		// which rewriting it would not fix.
+		if currentCpus.Equals(exclusiveCPUs) && (!isolated || strings.HasPrefix(partition, "isolated")) {
+			return false, nil
+		}
+	}
+
+	if err := writeCgroupFile(ctx, childCgroup, cpusetCpus, exclusiveCPUs.String()); err != nil {
+		return created, err
+	}
+	if !isolated {
+		return created, nil
+	}
+	if err := writeCgroupFile(ctx, childCgroup, cpusetCpusExclusive, exclusiveCPUs.String()); err != nil {
+		return created, err
+	}
+	return created, writeCgroupFile(ctx, childCgroup, cpusetCpusPartition, "isolated")
+}
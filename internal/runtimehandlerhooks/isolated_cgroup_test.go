@@ -0,0 +1,76 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	"k8s.io/utils/cpuset"
+)
+
+var _ = Describe("ensureIsolatedChildCgroup", func() {
+	ctrCgroup := filepath.Join(fixturesDir, "cgroup", "ctr")
+	childCgroup := filepath.Join(ctrCgroup, isolatedChildCgroup)
+	exclusiveCPUs := cpuset.New(2, 3)
+
+	readCgroupFile := func(dir, file string) string {
+		content, err := os.ReadFile(filepath.Join(dir, file))
+		Expect(err).ToNot(HaveOccurred())
+		return strings.TrimSpace(string(content))
+	}
+
+	BeforeEach(func() {
+		cgroups.TestMode = true
+		Expect(os.MkdirAll(ctrCgroup, os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(ctrCgroup, cgroupSubTreeControl), []byte("cpu cpuset"), 0o644)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		cgroups.TestMode = false
+		Expect(os.RemoveAll(fixturesDir)).To(Succeed())
+	})
+
+	It("should re-create a removed child cgroup", func() {
+		created, err := ensureIsolatedChildCgroup(context.TODO(), ctrCgroup, exclusiveCPUs, true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(created).To(BeTrue())
+
+		Expect(readCgroupFile(childCgroup, cpusetCpus)).To(Equal("2-3"))
+		Expect(readCgroupFile(childCgroup, cpusetCpusExclusive)).To(Equal("2-3"))
+		Expect(readCgroupFile(childCgroup, cpusetCpusPartition)).To(Equal("isolated"))
+	})
+
+	It("should not touch an intact child cgroup", func() {
+		Expect(os.MkdirAll(childCgroup, os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(childCgroup, cpusetCpus), []byte("2-3"), 0o644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(childCgroup, cpusetCpusPartition), []byte("isolated"), 0o644)).To(Succeed())
+
+		created, err := ensureIsolatedChildCgroup(context.TODO(), ctrCgroup, exclusiveCPUs, true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(created).To(BeFalse())
+		Expect(filepath.Join(childCgroup, cpusetCpusExclusive)).ToNot(BeAnExistingFile())
+	})
+
+	It("should restore the cpus of a clobbered child cgroup", func() {
+		Expect(os.MkdirAll(childCgroup, os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(childCgroup, cpusetCpus), []byte("0-7"), 0o644)).To(Succeed())
+
+		created, err := ensureIsolatedChildCgroup(context.TODO(), ctrCgroup, exclusiveCPUs, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(created).To(BeFalse())
+		Expect(readCgroupFile(childCgroup, cpusetCpus)).To(Equal("2-3"))
+		Expect(filepath.Join(childCgroup, cpusetCpusPartition)).ToNot(BeAnExistingFile())
+	})
+
+	It("should enable the missing controllers", func() {
+		Expect(os.WriteFile(filepath.Join(ctrCgroup, cgroupSubTreeControl), []byte("memory"), 0o644)).To(Succeed())
+
+		_, err := ensureIsolatedChildCgroup(context.TODO(), ctrCgroup, exclusiveCPUs, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readCgroupFile(ctrCgroup, cgroupSubTreeControl)).To(Equal("+cpu +cpuset"))
+	})
+})
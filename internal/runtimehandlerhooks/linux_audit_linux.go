@@ -0,0 +1,131 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/cri-o/cri-o/internal/log"
+)
+
+// auditUsysConfig is the AUDIT_USYS_CONFIG type of the audit events, for the changes of the system
+// configuration done from the user space, as defined by libaudit.
+const auditUsysConfig = 1111
+
+// The privileged tuning operations reported to the Linux audit subsystem.
+const (
+	linuxAuditOpIRQAffinity      = "irq-affinity"
+	linuxAuditOpCPUFreqGovernor  = "cpu-freq-governor"
+	linuxAuditOpCPUResumeLatency = "cpu-pm-qos-resume-latency"
+)
+
+// linuxAudit is the audit netlink socket the privileged tuning operations of the hooks are reported to,
+// opened by EnableLinuxAudit. The hooks are instantiated per request, so it is kept at package level.
+var linuxAudit = struct {
+	sync.Mutex
+	// fd is -1 if the operations are not reported.
+	fd  int
+	seq uint32
+	// send sends a netlink message to the socket, replaced by the tests.
+	send func(fd int, msg []byte) error
+}{
+	fd:   -1,
+	send: sendNetlinkMessage,
+}
+
+// EnableLinuxAudit reports the privileged tuning operations of the hooks, like the changes of the IRQ
+// affinity and of the CPU frequency governor, to the Linux audit subsystem from now on, or stops
+// reporting them. Reporting them requires the CAP_AUDIT_WRITE capability.
+func EnableLinuxAudit(enable bool) error {
+	linuxAudit.Lock()
+	defer linuxAudit.Unlock()
+	if linuxAudit.fd >= 0 {
+		if err := unix.Close(linuxAudit.fd); err != nil {
+			return fmt.Errorf("close audit netlink socket: %w", err)
+		}
+		linuxAudit.fd = -1
+	}
+	if !enable {
+		return nil
+	}
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_AUDIT)
+	if err != nil {
+		return fmt.Errorf("open audit netlink socket: %w", err)
+	}
+	linuxAudit.fd = fd
+	return nil
+}
+
+func linuxAuditEnabled() bool {
+	linuxAudit.Lock()
+	defer linuxAudit.Unlock()
+	return linuxAudit.fd >= 0
+}
+
+// linuxAuditOperation returns the privileged tuning operation done by writing the file named by path,
+// or an empty string if the write is not reported to the Linux audit subsystem.
+func linuxAuditOperation(path string) string {
+	if path == IrqSmpAffinityProcFile {
+		return linuxAuditOpIRQAffinity
+	}
+	if !strings.HasPrefix(path, sysCPUDir+"/") {
+		return ""
+	}
+	switch filepath.Base(path) {
+	case "scaling_governor":
+		return linuxAuditOpCPUFreqGovernor
+	case "pm_qos_resume_latency_us":
+		return linuxAuditOpCPUResumeLatency
+	}
+	return ""
+}
+
+// linuxAuditMessage formats the audit event of the operation setting the file named by path to value.
+func linuxAuditMessage(ctx context.Context, op, path, old, value string, writeErr error) string {
+	containerID, _ := ctx.Value(auditContainerKey{}).(string)
+	res := "success"
+	if writeErr != nil {
+		res = "failed"
+	}
+	return fmt.Sprintf("op=%s container=%q path=%q old=%q new=%q exe=%q res=%s",
+		op, containerID, path, old, value, os.Args[0], res)
+}
+
+// reportLinuxAudit reports the operation setting the file named by path to value to the Linux
+// audit subsystem, if enabled.
+func reportLinuxAudit(ctx context.Context, op, path, old, value string, writeErr error) {
+	text := linuxAuditMessage(ctx, op, path, old, value, writeErr)
+
+	linuxAudit.Lock()
+	defer linuxAudit.Unlock()
+	if linuxAudit.fd < 0 {
+		return
+	}
+	linuxAudit.seq++
+	if err := linuxAudit.send(linuxAudit.fd, netlinkAuditMessage(auditUsysConfig, linuxAudit.seq, text)); err != nil {
+		log.Warnf(ctx, "Failed to report the write of %s to the Linux audit subsystem: %v", path, err)
+	}
+}
+
+// netlinkAuditMessage encodes the audit event text of type msgType as a netlink message.
+func netlinkAuditMessage(msgType uint16, seq uint32, text string) []byte {
+	// the text is NUL terminated and the message padded to the netlink alignment
+	length := unix.NLMSG_HDRLEN + len(text) + 1
+	msg := make([]byte, (length+unix.NLMSG_ALIGNTO-1) & ^(unix.NLMSG_ALIGNTO-1))
+	binary.NativeEndian.PutUint32(msg[0:4], uint32(length))
+	binary.NativeEndian.PutUint16(msg[4:6], msgType)
+	binary.NativeEndian.PutUint16(msg[6:8], unix.NLM_F_REQUEST)
+	binary.NativeEndian.PutUint32(msg[8:12], seq)
+	copy(msg[unix.NLMSG_HDRLEN:], text)
+	return msg
+}
+
+func sendNetlinkMessage(fd int, msg []byte) error {
+	return unix.Sendto(fd, msg, 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK})
+}
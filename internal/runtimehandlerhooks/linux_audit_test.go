@@ -0,0 +1,94 @@
+package runtimehandlerhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/sys/unix"
+)
+
+var _ = Describe("linuxAudit", func() {
+	var sent [][]byte
+
+	// useFakeLinuxAudit enables the reporting to the Linux audit subsystem, recording the sent messages.
+	useFakeLinuxAudit := func() {
+		sent = nil
+		linuxAudit.Lock()
+		send, fd := linuxAudit.send, linuxAudit.fd
+		linuxAudit.fd = 0
+		linuxAudit.send = func(_ int, msg []byte) error {
+			sent = append(sent, msg)
+			return nil
+		}
+		linuxAudit.Unlock()
+		DeferCleanup(func() {
+			linuxAudit.Lock()
+			linuxAudit.send, linuxAudit.fd = send, fd
+			linuxAudit.Unlock()
+		})
+	}
+
+	textOf := func(msg []byte) string {
+		ExpectWithOffset(1, len(msg)%unix.NLMSG_ALIGNTO).To(BeZero())
+		length := binary.NativeEndian.Uint32(msg[0:4])
+		ExpectWithOffset(1, binary.NativeEndian.Uint16(msg[4:6])).To(BeEquivalentTo(auditUsysConfig))
+		text := msg[unix.NLMSG_HDRLEN:length]
+		ExpectWithOffset(1, text[len(text)-1]).To(BeZero())
+		return string(bytes.TrimRight(text, "\x00"))
+	}
+
+	It("should report the privileged tuning operations", func() {
+		const governorFile = "/sys/devices/system/cpu/cpu1/cpufreq/scaling_governor"
+		fake := useFakeHostFS(map[string]string{
+			governorFile:           "powersave\n",
+			IrqSmpAffinityProcFile: "ff\n",
+		})
+		fake.writeErrs[IrqSmpAffinityProcFile] = []error{unix.EIO}
+		useFakeLinuxAudit()
+		ctx := withAuditContainer(context.TODO(), "ctr1")
+
+		Expect(writeFile(ctx, governorFile, []byte("performance"), 0o644)).To(Succeed())
+		Expect(writeFile(ctx, IrqSmpAffinityProcFile, []byte("f0"), 0o644)).ToNot(Succeed())
+
+		Expect(sent).To(HaveLen(2))
+		Expect(textOf(sent[0])).To(And(
+			HavePrefix(`op=cpu-freq-governor container="ctr1" path="`+governorFile+`" old="powersave" new="performance" `),
+			HaveSuffix(" res=success"),
+		))
+		Expect(textOf(sent[1])).To(And(
+			HavePrefix(`op=irq-affinity container="ctr1" path="`+IrqSmpAffinityProcFile+`" old="ff" new="f0" `),
+			HaveSuffix(" res=failed"),
+		))
+		Expect(binary.NativeEndian.Uint32(sent[1][8:12])).To(Equal(binary.NativeEndian.Uint32(sent[0][8:12]) + 1))
+	})
+
+	It("should not report the other writes", func() {
+		const saveFile = sysCPUSaveDir + "/cpu1/cpufreq/scaling_governor"
+		const ctrCgroup = "/sys/fs/cgroup/kubepods.slice/pod/ctr1"
+		useFakeHostFS(map[string]string{
+			saveFile:                     "performance\n",
+			ctrCgroup + "/" + cpusetCpus: "1\n",
+		})
+		useFakeLinuxAudit()
+
+		Expect(writeFile(context.TODO(), saveFile, []byte("powersave"), 0o644)).To(Succeed())
+		Expect(writeCgroupFile(context.TODO(), ctrCgroup, cpusetCpus, "1-2")).To(Succeed())
+
+		Expect(sent).To(BeEmpty())
+	})
+
+	It("should not report the operations once disabled", func() {
+		useFakeHostFS(map[string]string{IrqSmpAffinityProcFile: "ff\n"})
+		useFakeLinuxAudit()
+		linuxAudit.Lock()
+		linuxAudit.fd = -1
+		linuxAudit.Unlock()
+
+		Expect(writeFile(context.TODO(), IrqSmpAffinityProcFile, []byte("f0"), 0o644)).To(Succeed())
+
+		Expect(sent).To(BeEmpty())
+	})
+})
@@ -0,0 +1,307 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+	"k8s.io/utils/cpuset"
+
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/internal/oci"
+	crioannotations "github.com/cri-o/cri-o/pkg/annotations"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+)
+
+const (
+	// napiThreadsDir is the pseudo directory of a network device holding the CPU affinity of its NAPI threads,
+	// "/sys/class/net/eth0/napi-threads/affinity". The affinity is recorded like the files of the device, but
+	// read and written with the scheduler API instead of sysfs.
+	napiThreadsDir = "napi-threads"
+	napiAffinity   = "affinity"
+	// threadedFile tells whether the device polls in NAPI threads of its own, "1", instead of in softirqs.
+	threadedFile = "threaded"
+)
+
+// napiAffinityFile returns the pseudo file of the CPU affinity of the NAPI threads of the device.
+func napiAffinityFile(device string) string {
+	return netDeviceFile(device, napiThreadsDir, napiAffinity)
+}
+
+// parseNAPIAffinityFile returns the device of the pseudo file of the affinity of its NAPI threads, if it is one.
+func parseNAPIAffinityFile(name string) (device string, ok bool) {
+	rel, ok := strings.CutPrefix(name, netSysfsDir+"/")
+	if !ok {
+		return "", false
+	}
+	parts := strings.Split(rel, "/")
+	if len(parts) != 3 || parts[1] != napiThreadsDir || parts[2] != napiAffinity {
+		return "", false
+	}
+	return parts[0], true
+}
+
+// napiThreadAffinity reads and writes the CPU affinity of the NAPI threads of the network devices of the
+// network namespace of the calling thread. The tests replace it with a fake.
+var napiThreadAffinity napiAffinityAPI = kernelNAPIThreads{}
+
+type napiAffinityAPI interface {
+	// Get returns the CPU affinity of the NAPI threads of the device, as a CPU list.
+	Get(device string) (string, error)
+	// Set sets the CPU affinity of all the NAPI threads of the device to the CPU list.
+	Set(device, cpus string) error
+}
+
+// The NAPI command and attributes of the netdev generic netlink family, from linux/netdev.h.
+const (
+	netdevFamilyName      = "netdev"
+	netdevCmdNAPIGet      = 11
+	netdevAttrNAPIIfindex = 1
+	netdevAttrNAPIPID     = 4
+)
+
+// kernelNAPIThreads finds the NAPI threads of the devices with the netdev generic netlink family, which reports the
+// PID of the thread of every NAPI instance of a device polling in threads. The device is looked up by its index in
+// the network namespace of the calling thread, as the threads are named after the device names, which are only
+// unique per network namespace and get truncated in the thread names.
+type kernelNAPIThreads struct{}
+
+// Get returns the CPUs any NAPI thread of the device may run on.
+func (kernelNAPIThreads) Get(device string) (string, error) {
+	pids, err := napiThreads(device)
+	if err != nil {
+		return "", err
+	}
+	cpus := cpuset.New()
+	for _, pid := range pids {
+		var mask unix.CPUSet
+		if err := unix.SchedGetaffinity(pid, &mask); err != nil {
+			if errors.Is(err, unix.ESRCH) {
+				continue
+			}
+			return "", fmt.Errorf("get affinity of NAPI thread %d: %w", pid, err)
+		}
+		var threadCPUs []int
+		for cpu := 0; len(threadCPUs) < mask.Count(); cpu++ {
+			if mask.IsSet(cpu) {
+				threadCPUs = append(threadCPUs, cpu)
+			}
+		}
+		cpus = cpus.Union(cpuset.New(threadCPUs...))
+	}
+	return cpus.String(), nil
+}
+
+func (kernelNAPIThreads) Set(device, cpus string) error {
+	set, err := cpuset.Parse(cpus)
+	if err != nil {
+		return err
+	}
+	if set.IsEmpty() {
+		return errors.New("CPU set of the affinity is empty")
+	}
+	var mask unix.CPUSet
+	for _, cpu := range set.List() {
+		mask.Set(cpu)
+	}
+	pids, err := napiThreads(device)
+	if err != nil {
+		return err
+	}
+	for _, pid := range pids {
+		if err := unix.SchedSetaffinity(pid, &mask); err != nil && !errors.Is(err, unix.ESRCH) {
+			return fmt.Errorf("set affinity of NAPI thread %d to %q: %w", pid, cpus, err)
+		}
+	}
+	return nil
+}
+
+// napiThreads returns the PIDs of the NAPI threads of the device of the network namespace of the calling thread,
+// os.ErrNotExist if there are none.
+func napiThreads(device string) ([]int, error) {
+	link, err := netlink.LinkByName(device)
+	if err != nil {
+		var notFound netlink.LinkNotFoundError
+		if errors.As(err, &notFound) {
+			return nil, fmt.Errorf("%w: %w", os.ErrNotExist, err)
+		}
+		return nil, err
+	}
+	family, err := netlink.GenlFamilyGet(netdevFamilyName)
+	if err != nil {
+		return nil, fmt.Errorf("get netdev netlink family, required to find the NAPI threads: %w", err)
+	}
+	req := nl.NewNetlinkRequest(int(family.ID), unix.NLM_F_DUMP)
+	req.AddData(&nl.Genlmsg{Command: netdevCmdNAPIGet, Version: uint8(family.Version)})
+	req.AddData(nl.NewRtAttr(netdevAttrNAPIIfindex, nl.Uint32Attr(uint32(link.Attrs().Index))))
+	msgs, err := req.Execute(unix.NETLINK_GENERIC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("dump NAPI instances of device %s: %w", device, err)
+	}
+	pids, err := parseNAPIThreads(msgs, link.Attrs().Index)
+	if err != nil {
+		return nil, err
+	}
+	if len(pids) == 0 {
+		return nil, fmt.Errorf("%w: no NAPI thread of device %s", os.ErrNotExist, device)
+	}
+	return pids, nil
+}
+
+// parseNAPIThreads returns the PIDs of the NAPI threads of the device of the index in the messages of a NAPI dump.
+// The instances without a PID poll in softirqs.
+func parseNAPIThreads(msgs [][]byte, ifindex int) ([]int, error) {
+	var pids []int
+	for _, msg := range msgs {
+		if len(msg) < nl.SizeofGenlmsg {
+			continue
+		}
+		attrs, err := nl.ParseRouteAttr(msg[nl.SizeofGenlmsg:])
+		if err != nil {
+			return nil, fmt.Errorf("parse NAPI instance: %w", err)
+		}
+		var index, pid uint32
+		for _, attr := range attrs {
+			if len(attr.Value) < 4 {
+				continue
+			}
+			switch attr.Attr.Type {
+			case netdevAttrNAPIIfindex:
+				index = nl.NativeEndian().Uint32(attr.Value)
+			case netdevAttrNAPIPID:
+				pid = nl.NativeEndian().Uint32(attr.Value)
+			}
+		}
+		if int(index) == ifindex && pid != 0 {
+			pids = append(pids, int(pid))
+		}
+	}
+	return pids, nil
+}
+
+// readNAPIAffinity reads the affinity of the NAPI threads of the pseudo file, from the network namespace sysfs
+// got mounted at. The device is looked up in sysfs first, so that a device gone is reported as os.ErrNotExist.
+func readNAPIAffinity(sysfs, name string) ([]byte, error) {
+	device, _ := parseNAPIAffinityFile(name)
+	if _, err := os.Stat(filepath.Join(sysfs, "class", "net", device)); err != nil {
+		return nil, err
+	}
+	cpus, err := napiThreadAffinity.Get(device)
+	return []byte(cpus), err
+}
+
+// writeNAPIAffinity writes the affinity of the NAPI threads of the pseudo file, in the network namespace
+// sysfs got mounted at.
+func writeNAPIAffinity(ctx context.Context, sysfs, name string, data []byte) error {
+	device, _ := parseNAPIAffinityFile(name)
+	if _, err := os.Stat(filepath.Join(sysfs, "class", "net", device)); err != nil {
+		return err
+	}
+	cpus := strings.TrimSpace(string(data))
+	log.Debugf(ctx, "Set the affinity of the NAPI threads of device %s to %q", device, cpus)
+	return napiThreadAffinity.Set(device, cpus)
+}
+
+// pinPodNAPIThreads pins the NAPI threads of the interfaces of the pod polling in threads of their own
+// to the CPUs selected by the policy, like the packet steering.
+func (h *HighPerformanceHooks) pinPodNAPIThreads(ctx context.Context, c *oci.Container, s *sandbox.Sandbox, policy string) error {
+	if s.HostNetwork() || s.NetNsPath() == "" {
+		log.Warnf(ctx, "NAPI affinity requested for container %q of a pod on the host network, ignoring", c.ID())
+		noteUnfulfilledAnnotation(ctx, crioannotations.NAPIAffinityAnnotation, ReasonHostNetwork)
+		return nil
+	}
+	if holder, ok := podNetTuningHolder(s, c, func(t *tuning) bool { return t.NAPIAffinity != nil }, napiAffinity); ok {
+		return fmt.Errorf("NAPI threads of the pod of container %q are already pinned by container %q", c.ID(), holder)
+	}
+	cpus, err := h.packetSteeringCPUs(c, policy)
+	if err != nil {
+		return err
+	}
+	network, err := sandboxNetwork(s)
+	if err != nil {
+		return err
+	}
+	pinned, err := setNAPIAffinity(ctx, c.ID(), network, cpus)
+	if err != nil {
+		return err
+	}
+	if len(pinned) == 0 {
+		log.Warnf(ctx, "NAPI affinity requested for container %q of a pod without threaded NAPI interface, ignoring", c.ID())
+		noteUnfulfilledAnnotation(ctx, crioannotations.NAPIAffinityAnnotation, ReasonNAPINotThreaded)
+		return nil
+	}
+	log.Infof(ctx, "Pinned the NAPI threads of interfaces %v of the pod of container %q to CPUs %s", pinned, c.ID(), cpus.String())
+	return nil
+}
+
+// setNAPIAffinity sets the CPU affinity of the NAPI threads of the interfaces of the pod network, recording
+// the writes for the container. It returns the interfaces polling in NAPI threads.
+func setNAPIAffinity(ctx context.Context, containerID string, network podNetwork, cpus cpuset.CPUSet) ([]podInterface, error) {
+	interfaces, err := threadedNAPIInterfaces(network)
+	if err != nil {
+		return nil, err
+	}
+	for _, iface := range interfaces {
+		err := writeNetTuningFile(ctx, containerID, iface.NetNS, napiAffinityFile(iface.Name), []byte(cpus.String()))
+		if err != nil {
+			return nil, fmt.Errorf("pin NAPI threads of interface %s: %w", iface, err)
+		}
+	}
+	return interfaces, nil
+}
+
+// planNAPIAffinity returns the affinity setNAPIAffinity would set for the container.
+func (h *HighPerformanceHooks) planNAPIAffinity(c *oci.Container, network podNetwork, policy string) ([]plannedChange, error) {
+	cpus, err := h.packetSteeringCPUs(c, policy)
+	if err != nil {
+		return nil, err
+	}
+	interfaces, err := threadedNAPIInterfaces(network)
+	if err != nil {
+		return nil, err
+	}
+	var changes []plannedChange
+	for _, iface := range interfaces {
+		changes = append(changes, plannedChange{
+			Feature: libconfig.HighPerformanceFeatureNAPIAffinity,
+			Path:    napiAffinityFile(iface.Name),
+			Value:   cpus.String(),
+		})
+	}
+	return changes, nil
+}
+
+// threadedNAPIInterfaces returns the interfaces of the pod network
+// which poll in NAPI threads of their own.
+func threadedNAPIInterfaces(network podNetwork) ([]podInterface, error) {
+	interfaces, err := network.interfaces()
+	if err != nil {
+		return nil, err
+	}
+	var threaded []podInterface
+	for _, iface := range interfaces {
+		content, err := readNetDeviceFile(iface.NetNS, netDeviceFile(iface.Name, threadedFile))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(string(content)) == "1" {
+			threaded = append(threaded, iface)
+		}
+	}
+	return threaded, nil
+}
+
+// revertNAPIAffinity restores the CPU affinity of the NAPI threads pinned for the container.
+func revertNAPIAffinity(ctx context.Context, containerID string) error {
+	return restoreNetDeviceTuning(ctx, containerID, napiAffinity)
+}
@@ -0,0 +1,94 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink/nl"
+	"k8s.io/utils/cpuset"
+)
+
+// fakeNAPIThreads holds the CPU affinity of the NAPI threads of the devices, keyed by device.
+type fakeNAPIThreads map[string]string
+
+func (f fakeNAPIThreads) Get(device string) (string, error) {
+	cpus, ok := f[device]
+	if !ok {
+		return "", os.ErrNotExist
+	}
+	return cpus, nil
+}
+
+func (f fakeNAPIThreads) Set(device, cpus string) error {
+	if _, ok := f[device]; !ok {
+		return os.ErrNotExist
+	}
+	f[device] = cpus
+	return nil
+}
+
+var _ = Describe("NAPI affinity", func() {
+	const (
+		netns       = "/var/run/netns/pod"
+		containerID = "ctr1"
+	)
+	var (
+		dir              string
+		threads          fakeNAPIThreads
+		savedNAPIThreads = napiThreadAffinity
+	)
+
+	addDevice := func(device, threaded string) {
+		deviceDir := filepath.Join(dir, "class", "net", device)
+		Expect(os.MkdirAll(deviceDir, 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(deviceDir, threadedFile), []byte(threaded+"\n"), 0o644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		useFakePodNetwork([]podInterface{{Name: "eth0", NetNS: netns}, {Name: "net1", NetNS: netns}}, map[string]string{netns: dir}, nil)
+		threads = fakeNAPIThreads{"net1": "0-7"}
+		napiThreadAffinity = threads
+		addDevice("eth0", "0")
+		addDevice("net1", "1")
+	})
+
+	AfterEach(func() {
+		napiThreadAffinity = savedNAPIThreads
+		forgetAppliedTuning(context.TODO(), containerID)
+	})
+
+	It("should pin and restore the NAPI threads of the threaded interfaces", func() {
+		pinned, err := setNAPIAffinity(context.TODO(), containerID, podNetwork{NetNS: netns}, cpuset.New(0, 1))
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pinned).To(Equal([]podInterface{{Name: "net1", NetNS: netns}}))
+		Expect(threads["net1"]).To(Equal("0-1"))
+
+		Expect(revertNAPIAffinity(context.TODO(), containerID)).To(Succeed())
+
+		Expect(threads["net1"]).To(Equal("0-7"))
+	})
+
+	It("should find the NAPI threads of the device by its index", func() {
+		napi := func(ifindex, pid uint32) []byte {
+			msg := (&nl.Genlmsg{Command: netdevCmdNAPIGet}).Serialize()
+			msg = append(msg, nl.NewRtAttr(netdevAttrNAPIIfindex, nl.Uint32Attr(ifindex)).Serialize()...)
+			if pid != 0 {
+				msg = append(msg, nl.NewRtAttr(netdevAttrNAPIPID, nl.Uint32Attr(pid)).Serialize()...)
+			}
+			return msg
+		}
+		// the NAPI instances of device 3 polling in threads and in softirqs, and of device 30
+		msgs := [][]byte{napi(3, 10), napi(30, 11), napi(3, 12), napi(3, 0)}
+
+		pids, err := parseNAPIThreads(msgs, 3)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pids).To(Equal([]int{10, 12}))
+		Expect(parseNAPIThreads(msgs, 4)).To(BeEmpty())
+	})
+})
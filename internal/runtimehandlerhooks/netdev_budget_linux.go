@@ -0,0 +1,92 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	"github.com/cri-o/cri-o/internal/log"
+	crioann "github.com/cri-o/cri-o/pkg/annotations"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+)
+
+const (
+	// netdevBudgetFile and netdevBudgetUsecsFile bound the packets and the microseconds a NET_RX softirq
+	// processes before yielding the CPU, leaving the rest of the packets to the next softirq or to ksoftirqd.
+	netdevBudgetFile      = "/proc/sys/net/core/netdev_budget"
+	netdevBudgetUsecsFile = "/proc/sys/net/core/netdev_budget_usecs"
+
+	netdevBudgetParam      = "budget"
+	netdevBudgetUsecsParam = "budget-usecs"
+)
+
+// netdevBudgetFiles are the sysctl files of the parameters of the netdev budget annotation, in the order they are set.
+var netdevBudgetFiles = []struct {
+	param, file string
+}{
+	{netdevBudgetParam, netdevBudgetFile},
+	{netdevBudgetUsecsParam, netdevBudgetUsecsFile},
+}
+
+// parseNetdevBudget returns the values of the parameters of the netdev budget annotation, like "budget=600,budget-usecs=4000".
+func parseNetdevBudget(value string) (map[string]string, error) {
+	budget := make(map[string]string)
+	for _, param := range strings.Split(value, ",") {
+		key, count, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || (key != netdevBudgetParam && key != netdevBudgetUsecsParam) {
+			return nil, fmt.Errorf("invalid parameter %q, expected %q or %q", param, netdevBudgetParam+"=<packets>", netdevBudgetUsecsParam+"=<usecs>")
+		}
+		if _, dup := budget[key]; dup {
+			return nil, fmt.Errorf("parameter %q set twice", key)
+		}
+		if n, err := strconv.ParseUint(count, 10, 31); err != nil || n == 0 {
+			return nil, fmt.Errorf("invalid %s %q, expected a positive count", key, count)
+		}
+		budget[key] = count
+	}
+	return budget, nil
+}
+
+// SetPodNetdevBudget sets the netdev budget of the node requested by the annotations of the pod, until
+// RevertPodNetworkTuning gets called when the pod stops. The budget is node-wide, so it is held by all the pods
+// requesting it, set by the first one and restored once the last one stops. A pod requesting another budget
+// than the one held fails to start.
+func SetPodNetdevBudget(ctx context.Context, config *libconfig.Config, sb *sandbox.Sandbox) error {
+	value, ok := sb.Annotations()[crioann.NetdevBudgetAnnotation]
+	if !ok {
+		return nil
+	}
+	settings := config.HighPerformanceSettingsFor(sb.RuntimeHandler())
+	if !settings.FeatureEnabled(libconfig.HighPerformanceFeatureNetdevBudget) ||
+		config.HighPerformancePolicyFor(sb.RuntimeHandler()) != libconfig.RuntimeTypeTuningTune {
+		return nil
+	}
+	budget, err := parseNetdevBudget(value)
+	if err != nil {
+		return err
+	}
+	log.Infof(ctx, "Hold the netdev budget of the node at %q for pod sandbox %s", value, sb.ID())
+	err = setNetdevBudget(ctx, sb.ID(), budget)
+	if err != nil && slices.Contains(settings.FailOpen, libconfig.HighPerformanceFeatureNetdevBudget) {
+		log.Warnf(ctx, "Ignoring the failure of %s for pod sandbox %s: %v", libconfig.HighPerformanceFeatureNetdevBudget, sb.ID(), err)
+		return nil
+	}
+	return err
+}
+
+// setNetdevBudget acquires the sysctls of the netdev budget for the ID.
+func setNetdevBudget(ctx context.Context, id string, budget map[string]string) error {
+	for _, f := range netdevBudgetFiles {
+		value, ok := budget[f.param]
+		if !ok {
+			continue
+		}
+		if err := acquireNodeSysctl(ctx, id, f.file, value); err != nil {
+			return fmt.Errorf("set netdev %s: %w", f.param, err)
+		}
+	}
+	return nil
+}
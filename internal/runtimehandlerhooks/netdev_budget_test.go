@@ -0,0 +1,76 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("netdev budget", func() {
+	var fs *fakeHostFS
+
+	BeforeEach(func() {
+		fs = useFakeHostFS(map[string]string{
+			netdevBudgetFile:      "300\n",
+			netdevBudgetUsecsFile: "2000\n",
+		})
+	})
+
+	AfterEach(func() {
+		for _, id := range []string{"sb1", "sb2", "sb3"} {
+			forgetAppliedTuning(context.TODO(), id)
+		}
+	})
+
+	It("should hold the budget until the last pod requesting it stops", func() {
+		budget, err := parseNetdevBudget("budget=600,budget-usecs=4000")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(setNetdevBudget(context.TODO(), "sb1", budget)).To(Succeed())
+		Expect(setNetdevBudget(context.TODO(), "sb2", budget)).To(Succeed())
+
+		Expect(fs.files[netdevBudgetFile]).To(Equal("600"))
+		Expect(fs.files[netdevBudgetUsecsFile]).To(Equal("4000"))
+		Expect(recordedHolds(netdevBudgetFile)).To(HaveLen(2))
+
+		Expect(RevertPodNetworkTuning(context.TODO(), "sb1")).To(Succeed())
+
+		Expect(fs.files[netdevBudgetFile]).To(Equal("600"))
+		Expect(recordedHolds(netdevBudgetFile)).To(HaveKey("sb2"))
+
+		Expect(RevertPodNetworkTuning(context.TODO(), "sb2")).To(Succeed())
+
+		Expect(fs.files[netdevBudgetFile]).To(Equal("300"))
+		Expect(fs.files[netdevBudgetUsecsFile]).To(Equal("2000"))
+		Expect(recordedHolds(netdevBudgetFile)).To(BeEmpty())
+	})
+
+	It("should refuse a budget other than the held one", func() {
+		Expect(setNetdevBudget(context.TODO(), "sb1", map[string]string{netdevBudgetParam: "600"})).To(Succeed())
+
+		err := setNetdevBudget(context.TODO(), "sb3", map[string]string{netdevBudgetParam: "900"})
+
+		Expect(err).To(MatchError(ContainSubstring(`is held at "600" by sb1`)))
+		Expect(fs.files[netdevBudgetFile]).To(Equal("600"))
+	})
+
+	It("should leave a budget changed since it got held", func() {
+		Expect(setNetdevBudget(context.TODO(), "sb1", map[string]string{netdevBudgetParam: "600"})).To(Succeed())
+		fs.files[netdevBudgetFile] = "800"
+
+		Expect(RevertPodNetworkTuning(context.TODO(), "sb1")).To(Succeed())
+
+		Expect(fs.files[netdevBudgetFile]).To(Equal("800"))
+	})
+
+	DescribeTable("should reject the invalid values",
+		func(value string) {
+			_, err := parseNetdevBudget(value)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("unknown parameter", "weight=64"),
+		Entry("zero budget", "budget=0"),
+		Entry("duplicated parameter", "budget=600,budget=300"),
+	)
+})
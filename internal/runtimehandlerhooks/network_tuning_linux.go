@@ -0,0 +1,362 @@
+package runtimehandlerhooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/cri-o/cri-o/internal/log"
+)
+
+const (
+	sysDir = "/sys"
+	// netSysfsDir holds the network devices of the network namespace sysfs got mounted in.
+	netSysfsDir = "/sys/class/net"
+)
+
+// podInterface is a network device of a pod: either a device of its network namespace,
+// or the host side of one of its veth devices.
+type podInterface struct {
+	Name string
+	// NetNS is the path of the network namespace of the device, empty for the host side.
+	NetNS string
+	// Peer is the name of the veth device of the network namespace the host side is the peer of.
+	Peer string
+}
+
+// String returns the name of the device, prefixed with "host:" for the host side.
+func (i podInterface) String() string {
+	if i.NetNS == "" {
+		return "host:" + i.Name
+	}
+	return i.Name
+}
+
+// podInterfaces returns the network devices of the pod whose network namespace is at netnsPath, but its
+// loopback device, followed by the host side of its veth devices. The peers of the veth devices which are
+// not in the host network namespace, like the ones of the veth pairs of the pod network namespace, or the
+// ones of another network namespace, are not host sides of the pod. The tests replace it with a fake.
+var podInterfaces = func(netnsPath string) ([]podInterface, error) {
+	type vethPeer struct {
+		name string
+		peer int
+	}
+	var (
+		interfaces []podInterface
+		peers      []vethPeer
+	)
+	hostNS, err := ns.GetCurrentNS()
+	if err != nil {
+		return nil, err
+	}
+	defer hostNS.Close()
+	if err := ns.WithNetNSPath(netnsPath, func(ns.NetNS) error {
+		links, err := netlink.LinkList()
+		if err != nil {
+			return err
+		}
+		// the network namespace of the peer of a veth device is reported by its ID in the pod network namespace
+		hostNSID, err := netlink.GetNetNsIdByFd(int(hostNS.Fd()))
+		if err != nil {
+			return fmt.Errorf("get ID of host network namespace: %w", err)
+		}
+		for _, link := range links {
+			if link.Attrs().Flags&net.FlagLoopback != 0 {
+				continue
+			}
+			interfaces = append(interfaces, podInterface{Name: link.Attrs().Name, NetNS: netnsPath})
+			veth, ok := link.(*netlink.Veth)
+			if !ok || hostNSID < 0 || veth.NetNsID != hostNSID {
+				continue
+			}
+			peer, err := netlink.VethPeerIndex(veth)
+			if err != nil {
+				return fmt.Errorf("get peer of veth %s: %w", veth.Name, err)
+			}
+			peers = append(peers, vethPeer{name: link.Attrs().Name, peer: peer})
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	for _, v := range peers {
+		link, err := netlink.LinkByIndex(v.peer)
+		if err != nil {
+			return nil, fmt.Errorf("get host side of veth %s: %w", v.name, err)
+		}
+		interfaces = append(interfaces, podInterface{Name: link.Attrs().Name, Peer: v.name})
+	}
+	return interfaces, nil
+}
+
+// podNetwork is the network of a pod whose interfaces get tuned.
+type podNetwork struct {
+	// NetNS is the path of the network namespace of the pod.
+	NetNS string
+	// Interfaces are the names of the devices of the network namespace selected for the tuning, all of them if empty.
+	Interfaces []string
+}
+
+// String returns the path of the network namespace, followed by the selected interfaces if any.
+func (n podNetwork) String() string {
+	if len(n.Interfaces) == 0 {
+		return n.NetNS
+	}
+	return fmt.Sprintf("%s (interfaces %s)", n.NetNS, strings.Join(n.Interfaces, ","))
+}
+
+// interfaces returns the interfaces of the pod network, restricted to the selected devices of its
+// network namespace and to the host side of the selected veth ones.
+func (n podNetwork) interfaces() ([]podInterface, error) {
+	interfaces, err := podInterfaces(n.NetNS)
+	if err != nil {
+		return nil, fmt.Errorf("list interfaces of network namespace %s: %w", n.NetNS, err)
+	}
+	if len(n.Interfaces) == 0 {
+		return interfaces, nil
+	}
+	return slices.DeleteFunc(interfaces, func(i podInterface) bool {
+		name := i.Name
+		if i.NetNS == "" {
+			name = i.Peer
+		}
+		return !slices.Contains(n.Interfaces, name)
+	}), nil
+}
+
+// withNetNSSysfs runs fn with the root of a sysfs listing the network devices of the network namespace
+// at netnsPath, or of the host one if empty. The sysfs of a network namespace gets mounted in a mount
+// namespace of a thread of its own, which exits once fn returns. The tests replace it with a fake.
+var withNetNSSysfs = func(netnsPath string, fn func(sysfs string) error) error {
+	if netnsPath == "" {
+		return fn(sysDir)
+	}
+	netns, err := ns.GetNS(netnsPath)
+	if err != nil {
+		var notExist ns.NSPathNotExistErr
+		if errors.As(err, &notExist) {
+			return fmt.Errorf("%w: %w", os.ErrNotExist, err)
+		}
+		return err
+	}
+	defer netns.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		// The thread is never unlocked, so that it exits along with the goroutine
+		// instead of being reused with the namespaces it entered.
+		runtime.LockOSThread()
+		done <- func() error {
+			if err := unix.Unshare(unix.CLONE_NEWNS); err != nil {
+				return fmt.Errorf("unshare mount namespace: %w", err)
+			}
+			if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_SLAVE, ""); err != nil {
+				return fmt.Errorf("make mounts slave: %w", err)
+			}
+			if err := unix.Setns(int(netns.Fd()), unix.CLONE_NEWNET); err != nil {
+				return fmt.Errorf("enter network namespace %s: %w", netnsPath, err)
+			}
+			dir, err := os.MkdirTemp("", "crio-netns-sysfs-")
+			if err != nil {
+				return err
+			}
+			defer os.Remove(dir)
+			if err := unix.Mount("sysfs", dir, "sysfs", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC, ""); err != nil {
+				return fmt.Errorf("mount sysfs of network namespace %s: %w", netnsPath, err)
+			}
+			defer unix.Unmount(dir, unix.MNT_DETACH) //nolint:errcheck // the mount namespace goes away with the thread
+			return fn(dir)
+		}()
+	}()
+	return <-done
+}
+
+// isNetDeviceFile returns whether the file is a file of a network device, which has to be
+// resolved in the sysfs of the network namespace of the device.
+func isNetDeviceFile(path string) bool {
+	return strings.HasPrefix(path, netSysfsDir+"/")
+}
+
+// netDeviceFile returns the file of the device under netSysfsDir.
+func netDeviceFile(device string, elem ...string) string {
+	return filepath.Join(append([]string{netSysfsDir, device}, elem...)...)
+}
+
+// readNetDeviceFile reads the file, the ethtool setting, the NAPI thread affinity or the root qdisc, of a device of the network namespace at netnsPath.
+func readNetDeviceFile(netnsPath, name string) (content []byte, err error) {
+	err = withNetNSSysfs(netnsPath, func(sysfs string) error {
+		if _, _, ok := parseEthtoolSettingFile(name); ok {
+			content, err = readEthtoolSetting(sysfs, name)
+			return err
+		}
+		if _, ok := parseNAPIAffinityFile(name); ok {
+			content, err = readNAPIAffinity(sysfs, name)
+			return err
+		}
+		if _, ok := parseRootQdiscFile(name); ok {
+			content, err = readRootQdisc(sysfs, name)
+			return err
+		}
+		content, err = hostFS.ReadFile(filepath.Join(sysfs, strings.TrimPrefix(name, sysDir)))
+		return err
+	})
+	return content, err
+}
+
+// writeNetDeviceFile writes data to the file, the ethtool setting, the NAPI thread affinity or the root qdisc, of a device of the network namespace at netnsPath.
+func writeNetDeviceFile(ctx context.Context, netnsPath, name string, data []byte) error {
+	return withNetNSSysfs(netnsPath, func(sysfs string) error {
+		if _, _, ok := parseEthtoolSettingFile(name); ok {
+			return writeEthtoolSetting(ctx, sysfs, name, data)
+		}
+		if _, ok := parseNAPIAffinityFile(name); ok {
+			return writeNAPIAffinity(ctx, sysfs, name, data)
+		}
+		if _, ok := parseRootQdiscFile(name); ok {
+			return writeRootQdisc(ctx, sysfs, name, data)
+		}
+		return writeFile(ctx, filepath.Join(sysfs, strings.TrimPrefix(name, sysDir)), data, 0o644)
+	})
+}
+
+// restoreNetDeviceTuning restores the files of the network devices named like one of the files, written to
+// tune the container, in the reverse order of their writes. The files of the devices gone in between, along
+// with the network namespace of the pod or the host side of its veth devices, are skipped.
+func restoreNetDeviceTuning(ctx context.Context, containerID string, files ...string) error {
+	record, ok := recordedTuning(containerID)
+	if !ok {
+		return nil
+	}
+	var errs []error
+	for i := len(record.Writes) - 1; i >= 0; i-- {
+		w := record.Writes[i]
+		if !isNetDeviceFile(w.Path) || !slices.Contains(files, filepath.Base(w.Path)) {
+			continue
+		}
+		err := restoreTuningWrite(ctx, &w)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			log.Debugf(ctx, "Device of %s tuned for container %q does not exist anymore, skipping", w.Path, containerID)
+		case err != nil:
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// writeNetTuningFile writes data to the file of a device of the network namespace at netnsPath to tune
+// the container, unless the file already holds it, and records the write like writeTuningFile.
+func writeNetTuningFile(ctx context.Context, containerID, netnsPath, name string, data []byte) error {
+	w := fileWrite{Path: name, NetNS: netnsPath, Value: string(bytes.TrimSpace(data))}
+	current, err := w.read()
+	if err != nil {
+		return err
+	}
+	w.Ifindex, w.BusID = netDeviceIdentity(netnsPath, netDeviceFileDevice(name))
+	w.Original = string(bytes.TrimSpace(current))
+	if w.Original == w.Value {
+		log.Debugf(ctx, "File %s is already set to %q, skipping", name, w.Original)
+		return nil
+	}
+	if err := w.write(ctx, data); err != nil {
+		return err
+	}
+	recordFileWrite(ctx, containerID, w)
+	return nil
+}
+
+// netDeviceFileDevice returns the name of the device of the file under netSysfsDir.
+func netDeviceFileDevice(path string) string {
+	device, _, _ := strings.Cut(strings.TrimPrefix(path, netSysfsDir+"/"), "/")
+	return device
+}
+
+// netDeviceIdentity returns the index of the device of the network namespace at netnsPath and the bus ID of the
+// hardware device backing it, zero and empty if unknown, which identify the device across its renames and moves.
+func netDeviceIdentity(netnsPath, device string) (ifindex int, busID string) {
+	_ = withNetNSSysfs(netnsPath, func(sysfs string) error {
+		ifindex, busID = sysfsNetDeviceIdentity(filepath.Join(sysfs, "class", "net", device))
+		return nil
+	})
+	return ifindex, busID
+}
+
+// sysfsNetDeviceIdentity returns the index and the bus ID of the network device of the sysfs directory.
+func sysfsNetDeviceIdentity(dir string) (ifindex int, busID string) {
+	if content, err := os.ReadFile(filepath.Join(dir, "ifindex")); err == nil {
+		ifindex, _ = strconv.Atoi(strings.TrimSpace(string(content)))
+	}
+	if target, err := os.Readlink(filepath.Join(dir, "device")); err == nil {
+		busID = filepath.Base(target)
+	}
+	return ifindex, busID
+}
+
+// locateNetDevice updates the written file to the current name and network namespace of its network device,
+// which may have been renamed, or moved back to the host network namespace along with the deletion of the
+// network namespace of the pod. It returns os.ErrNotExist if the device is gone.
+func (w *fileWrite) locateNetDevice(ctx context.Context) error {
+	if w.Ifindex == 0 && w.BusID == "" {
+		return nil
+	}
+	device := netDeviceFileDevice(w.Path)
+	netnsPath := w.NetNS
+	name, err := findNetDevice(netnsPath, device, w.Ifindex, w.BusID)
+	if errors.Is(err, os.ErrNotExist) && netnsPath != "" && w.BusID != "" {
+		netnsPath = ""
+		name, err = findNetDevice(netnsPath, device, 0, w.BusID)
+	}
+	if err != nil {
+		return err
+	}
+	if name != device || netnsPath != w.NetNS {
+		log.Infof(ctx, "Device %s of %s is now %s, in network namespace %q", device, w.Path, name, netnsPath)
+		w.Path = netDeviceFile(name, strings.TrimPrefix(w.Path, netDeviceFile(device)+"/"))
+		w.NetNS = netnsPath
+	}
+	return nil
+}
+
+// findNetDevice returns the name of the network device of the network namespace at netnsPath with the bus ID,
+// if not empty, or else with the index, looking at the device named like before first.
+// It returns os.ErrNotExist if there is none.
+func findNetDevice(netnsPath, device string, ifindex int, busID string) (name string, err error) {
+	matches := func(dir string) bool {
+		i, b := sysfsNetDeviceIdentity(dir)
+		if busID != "" {
+			return b == busID
+		}
+		return i == ifindex
+	}
+	err = withNetNSSysfs(netnsPath, func(sysfs string) error {
+		dir := filepath.Join(sysfs, "class", "net")
+		if matches(filepath.Join(dir, device)) {
+			name = device
+			return nil
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if matches(filepath.Join(dir, entry.Name())) {
+				name = entry.Name()
+				return nil
+			}
+		}
+		return fmt.Errorf("device %s: %w", device, os.ErrNotExist)
+	})
+	return name, err
+}
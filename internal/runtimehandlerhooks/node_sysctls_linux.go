@@ -0,0 +1,127 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/cri-o/cri-o/internal/log"
+)
+
+// nodeSysctlHolds serializes the acquisitions and releases of the node sysctls held by the pods, so that the
+// first holder of a sysctl sets it and the last one restores it. The holds themselves are the shared writes
+// recorded in the tuning store, which persists them across restarts of CRI-O.
+var nodeSysctlHolds sync.Mutex
+
+// acquireNodeSysctl sets the sysctl file of the node to the value for the holder, like a pod sandbox ID, until
+// it releases it with releaseNodeSysctls. The first holder sets the file and records its original value, the
+// next ones only record their hold along with the same original value. A file held at another value fails
+// to be acquired, as its value is shared by all the holders.
+func acquireNodeSysctl(ctx context.Context, holder, file, value string) error {
+	nodeSysctlHolds.Lock()
+	defer nodeSysctlHolds.Unlock()
+
+	holds := recordedHolds(file)
+	delete(holds, holder)
+	if len(holds) > 0 {
+		others := slices.Sorted(maps.Keys(holds))
+		held := holds[others[0]]
+		if held.Value != value {
+			return fmt.Errorf("%s is held at %q by %s", file, held.Value, strings.Join(others, ", "))
+		}
+		log.Debugf(ctx, "File %s is already held at %q by %s", file, value, strings.Join(others, ", "))
+		recordFileWrite(ctx, holder, fileWrite{Path: file, Original: held.Original, Value: value, Shared: true})
+		return nil
+	}
+
+	current, err := hostFS.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	original := strings.TrimSpace(string(current))
+	if original != value {
+		if err := writeFile(ctx, file, []byte(value), 0o644); err != nil {
+			return err
+		}
+	}
+	recordFileWrite(ctx, holder, fileWrite{Path: file, Original: original, Value: value, Shared: true})
+	return nil
+}
+
+// releaseNodeSysctls releases the sysctl files of the node held by the holder, restoring the original value of
+// the ones no other holder holds anymore, unless they changed since. The released holds are dropped from the
+// record of the holder, as a container releases them before its record gets forgotten, the ones failing to be
+// restored are kept for another attempt.
+func releaseNodeSysctls(ctx context.Context, holder string) error {
+	nodeSysctlHolds.Lock()
+	defer nodeSysctlHolds.Unlock()
+
+	record, ok := recordedTuning(holder)
+	if !ok {
+		return nil
+	}
+	var errs []error
+	for i := len(record.Writes) - 1; i >= 0; i-- {
+		w := record.Writes[i]
+		if !w.Shared {
+			continue
+		}
+		holds := recordedHolds(w.Path)
+		delete(holds, holder)
+		if len(holds) > 0 {
+			log.Debugf(ctx, "File %s is still held by %s, leaving it to %q", w.Path, strings.Join(slices.Sorted(maps.Keys(holds)), ", "), w.Value)
+		} else if err := restoreTuningWrite(ctx, &w); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+			continue
+		}
+		forgetFileWrite(ctx, holder, w.Path)
+	}
+	return errors.Join(errs...)
+}
+
+// nodeSysctlHolders returns the IDs of the containers and pods holding node sysctls.
+func nodeSysctlHolders() []string {
+	tuningStore.Lock()
+	defer tuningStore.Unlock()
+	var holders []string
+	for id, record := range tuningStore.containers {
+		if slices.ContainsFunc(record.Writes, func(w fileWrite) bool { return w.Shared }) {
+			holders = append(holders, id)
+		}
+	}
+	slices.Sort(holders)
+	return holders
+}
+
+// currentNodeSysctls returns the sysctls of the node currently held, along with their holders.
+func currentNodeSysctls() []NodeSysctl {
+	tuningStore.Lock()
+	defer tuningStore.Unlock()
+	sysctls := map[string]*NodeSysctl{}
+	for id, record := range tuningStore.containers {
+		for _, w := range record.Writes {
+			if !w.Shared {
+				continue
+			}
+			sysctl, ok := sysctls[w.Path]
+			if !ok {
+				sysctl = &NodeSysctl{Path: w.Path, Value: w.Value, Original: w.Original}
+				sysctls[w.Path] = sysctl
+			}
+			sysctl.Holders = append(sysctl.Holders, id)
+		}
+	}
+	held := make([]NodeSysctl, 0, len(sysctls))
+	for _, path := range slices.Sorted(maps.Keys(sysctls)) {
+		sysctl := sysctls[path]
+		slices.Sort(sysctl.Holders)
+		sysctl.RefCount = len(sysctl.Holders)
+		held = append(held, *sysctl)
+	}
+	return held
+}
@@ -0,0 +1,147 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+
+	"k8s.io/utils/cpuset"
+
+	"github.com/cri-o/cri-o/pkg/cpumask"
+	"github.com/cri-o/cri-o/server/metrics"
+)
+
+// CurrentNodeTuningState returns the tuning of the node bookkept by the hooks: the exclusive CPUs of the tuned
+// containers, the shared CPU pools with their consumers, the containers banning their CPUs from the IRQ
+// affinity mask and the held node sysctls, along with the CPUs of the current mask and the resulting
+// allocation of the CPUs of the node.
+func CurrentNodeTuningState() *NodeTuningState {
+	state := &NodeTuningState{
+		ExclusiveCPUs:  map[string]string{},
+		SharedCPUPools: []SharedCPUPool{},
+		IRQAffinity:    IRQAffinity{Containers: map[string]string{}},
+		NodeSysctls:    currentNodeSysctls(),
+	}
+	banned := cpuset.New()
+	for _, containerID := range recordedContainers() {
+		record, ok := recordedTuning(containerID)
+		if !ok || record.Tuning == nil || record.Tuning.CPUs == "" {
+			continue
+		}
+		state.ExclusiveCPUs[containerID] = record.Tuning.CPUs
+		if record.Tuning.IRQLoadBalancingDisabled {
+			state.IRQAffinity.Containers[containerID] = record.Tuning.CPUs
+			if cpus, err := cpuset.Parse(record.Tuning.CPUs); err == nil {
+				banned = banned.Union(cpus)
+			}
+		}
+	}
+
+	state.IRQAffinity.BannedCPUs = banned.String()
+
+	sharedCPUsConsumers.Lock()
+	for _, sandboxID := range slices.Sorted(maps.Keys(sharedCPUsConsumers.sandboxes)) {
+		sb := sharedCPUsConsumers.sandboxes[sandboxID]
+		pool := SharedCPUPool{
+			SandboxID:  sandboxID,
+			SharedCPUs: sb.sharedCPUs.String(),
+			Consumers:  make(map[string]string, len(sb.containers)),
+		}
+		for containerID, cpus := range sb.containers {
+			pool.Consumers[containerID] = cpus.String()
+		}
+		state.SharedCPUPools = append(state.SharedCPUPools, pool)
+	}
+	sharedCPUsConsumers.Unlock()
+
+	allocation, _ := currentCPUAllocation()
+	state.CPUAllocation = CPUAllocation{
+		Isolated:     allocation.isolated.String(),
+		Exclusive:    allocation.exclusive.String(),
+		Shared:       allocation.shared.String(),
+		Housekeeping: allocation.housekeeping.String(),
+	}
+
+	content, err := hostFS.ReadFile(IrqSmpAffinityProcFile)
+	if err != nil {
+		state.IRQAffinity.Error = err.Error()
+		return state
+	}
+	state.IRQAffinity.Mask = strings.TrimSpace(string(content))
+	mask, err := cpumask.Parse(state.IRQAffinity.Mask)
+	if err != nil {
+		state.IRQAffinity.Error = err.Error()
+		return state
+	}
+	state.IRQAffinity.CPUs = mask.List()
+	return state
+}
+
+// The allocations of the CPUs of the node, as reported in the metrics.
+const (
+	cpuAllocationIsolated     = "isolated"
+	cpuAllocationExclusive    = "exclusive"
+	cpuAllocationShared       = "shared"
+	cpuAllocationHousekeeping = "housekeeping"
+)
+
+// cpuAllocation partitions the online CPUs of the node: a CPU is only part of its first allocation among
+// isolated, exclusive, shared and housekeeping.
+type cpuAllocation struct {
+	isolated, exclusive, shared, housekeeping cpuset.CPUSet
+}
+
+// currentCPUAllocation returns the allocation of the CPUs of the node derived from the tuning records and the
+// shared CPU pools consumed, with the error to read the CPUs online, leaving no housekeeping CPUs, if any.
+func currentCPUAllocation() (cpuAllocation, error) {
+	allocation := cpuAllocation{
+		isolated:     cpuset.New(),
+		exclusive:    cpuset.New(),
+		shared:       cpuset.New(),
+		housekeeping: cpuset.New(),
+	}
+	for _, containerID := range recordedContainers() {
+		record, ok := recordedTuning(containerID)
+		if !ok || record.Tuning == nil {
+			continue
+		}
+		cpus, err := cpuset.Parse(record.Tuning.CPUs)
+		if err != nil {
+			continue
+		}
+		if record.Tuning.CPULoadBalancingDisabled {
+			allocation.isolated = allocation.isolated.Union(cpus)
+		} else {
+			allocation.exclusive = allocation.exclusive.Union(cpus)
+		}
+	}
+	allocation.exclusive = allocation.exclusive.Difference(allocation.isolated)
+
+	sharedCPUsConsumers.Lock()
+	for _, sb := range sharedCPUsConsumers.sandboxes {
+		allocation.shared = allocation.shared.Union(sb.sharedCPUs)
+	}
+	sharedCPUsConsumers.Unlock()
+	allocation.shared = allocation.shared.Difference(allocation.isolated.Union(allocation.exclusive))
+
+	online, err := onlineCPUs()
+	if err != nil {
+		return allocation, err
+	}
+	allocation.housekeeping = online.Difference(allocation.isolated.Union(allocation.exclusive, allocation.shared))
+	return allocation, nil
+}
+
+// reportNodeCPUAllocation exports the amount of CPUs of the node per allocation, and notifies the topology exporters.
+// It takes the locks of the bookkeeping of the hooks, so the callers updating it defer it before locking.
+func reportNodeCPUAllocation() {
+	allocation, err := currentCPUAllocation()
+	metrics.Instance().MetricTuningNodeCPUsSet(cpuAllocationIsolated, float64(allocation.isolated.Size()))
+	metrics.Instance().MetricTuningNodeCPUsSet(cpuAllocationExclusive, float64(allocation.exclusive.Size()))
+	metrics.Instance().MetricTuningNodeCPUsSet(cpuAllocationShared, float64(allocation.shared.Size()))
+	if err == nil {
+		metrics.Instance().MetricTuningNodeCPUsSet(cpuAllocationHousekeeping, float64(allocation.housekeeping.Size()))
+	}
+	exportTopology(context.Background())
+}
@@ -0,0 +1,97 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/cpuset"
+)
+
+var _ = Describe("CurrentNodeTuningState", func() {
+	const sandboxID = "sandbox"
+
+	AfterEach(func() {
+		forgetAppliedTuning(context.TODO(), "ctr1")
+		forgetAppliedTuning(context.TODO(), "ctr2")
+		sharedCPUsConsumers.Lock()
+		delete(sharedCPUsConsumers.sandboxes, sandboxID)
+		sharedCPUsConsumers.Unlock()
+	})
+
+	It("should report the tuning of the node", func() {
+		useFakeHostFS(map[string]string{
+			IrqSmpAffinityProcFile: "000000f9\n",
+			sysCPUDir + "/online":  "0-7\n",
+		})
+		recordAppliedTuning(context.TODO(), "ctr1", &tuning{CPUs: "1-2", IRQLoadBalancingDisabled: true, SharedCPUs: true})
+		recordAppliedTuning(context.TODO(), "ctr2", &tuning{CPUs: "4", CPULoadBalancingDisabled: true})
+		addSharedCPUsConsumer(sandboxID, "ctr1", cpuset.New(1, 2), cpuset.New(0))
+
+		Expect(CurrentNodeTuningState()).To(Equal(&NodeTuningState{
+			ExclusiveCPUs: map[string]string{"ctr1": "1-2", "ctr2": "4"},
+			SharedCPUPools: []SharedCPUPool{
+				{SandboxID: sandboxID, SharedCPUs: "0", Consumers: map[string]string{"ctr1": "1-2"}},
+			},
+			IRQAffinity: IRQAffinity{
+				Mask:       "000000f9",
+				CPUs:       "0,3-7",
+				BannedCPUs: "1-2",
+				Containers: map[string]string{"ctr1": "1-2"},
+			},
+			CPUAllocation: CPUAllocation{
+				Isolated:     "4",
+				Exclusive:    "1-2",
+				Shared:       "0",
+				Housekeeping: "3,5-7",
+			},
+			NodeSysctls: []NodeSysctl{},
+		}))
+	})
+
+	It("should report the failure to read the IRQ affinity mask", func() {
+		useFakeHostFS(map[string]string{})
+
+		state := CurrentNodeTuningState()
+		Expect(state.ExclusiveCPUs).To(BeEmpty())
+		Expect(state.SharedCPUPools).To(BeEmpty())
+		Expect(state.IRQAffinity.Mask).To(BeEmpty())
+		Expect(state.IRQAffinity.Error).ToNot(BeEmpty())
+		Expect(state.CPUAllocation.Housekeeping).To(BeEmpty())
+	})
+
+	It("should report the held node sysctls with their holders", func() {
+		const file = "/proc/sys/net/core/busy_poll"
+		useFakeHostFS(map[string]string{file: "0\n"})
+		DeferCleanup(func() {
+			Expect(releaseNodeSysctls(context.TODO(), "sandbox1")).To(Succeed())
+			Expect(releaseNodeSysctls(context.TODO(), "sandbox2")).To(Succeed())
+			forgetAppliedTuning(context.TODO(), "sandbox1")
+			forgetAppliedTuning(context.TODO(), "sandbox2")
+		})
+		Expect(acquireNodeSysctl(context.TODO(), "sandbox2", file, "50")).To(Succeed())
+		Expect(acquireNodeSysctl(context.TODO(), "sandbox1", file, "50")).To(Succeed())
+
+		Expect(CurrentNodeTuningState().NodeSysctls).To(Equal([]NodeSysctl{{
+			Path:     file,
+			Value:    "50",
+			Original: "0",
+			Holders:  []string{"sandbox1", "sandbox2"},
+			RefCount: 2,
+		}}))
+	})
+
+	It("should allocate every CPU once", func() {
+		useFakeHostFS(map[string]string{sysCPUDir + "/online": "0-3\n"})
+		recordAppliedTuning(context.TODO(), "ctr1", &tuning{CPUs: "1-2", CPULoadBalancingDisabled: true})
+		recordAppliedTuning(context.TODO(), "ctr2", &tuning{CPUs: "2-3"})
+		addSharedCPUsConsumer(sandboxID, "ctr2", cpuset.New(2, 3), cpuset.New(0, 3))
+
+		allocation, err := currentCPUAllocation()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(allocation.isolated.String()).To(Equal("1-2"))
+		Expect(allocation.exclusive.String()).To(Equal("3"))
+		Expect(allocation.shared.String()).To(Equal("0"))
+		Expect(allocation.housekeeping.String()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,212 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"k8s.io/utils/cpuset"
+
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/internal/oci"
+	crioannotations "github.com/cri-o/cri-o/pkg/annotations"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+	"github.com/cri-o/cri-o/pkg/cpumask"
+)
+
+// The values of the packet steering annotation.
+const (
+	// packetSteeringContainer steers the packet processing to the CPUs of the container.
+	packetSteeringContainer = "container"
+	// packetSteeringHousekeeping steers the packet processing away from the isolated CPUs,
+	// to the housekeeping CPUs.
+	packetSteeringHousekeeping = "housekeeping"
+)
+
+const (
+	rpsCPUsFile = "rps_cpus"
+	xpsCPUsFile = "xps_cpus"
+)
+
+// steerPodPackets steers the receive and transmit packet processing of the interfaces of the pod, both the
+// ones of its network namespace and the host side of its veth devices, to the CPUs selected by the policy.
+func (h *HighPerformanceHooks) steerPodPackets(ctx context.Context, c *oci.Container, s *sandbox.Sandbox, policy string) error {
+	if s.HostNetwork() || s.NetNsPath() == "" {
+		log.Warnf(ctx, "Packet steering requested for container %q of a pod on the host network, ignoring", c.ID())
+		noteUnfulfilledAnnotation(ctx, crioannotations.PacketSteeringAnnotation, ReasonHostNetwork)
+		return nil
+	}
+	cpus, err := h.packetSteeringCPUs(c, policy)
+	if err != nil {
+		return err
+	}
+	if holder, ok := podNetTuningHolder(s, c, func(t *tuning) bool { return t.PacketSteering != nil }, rpsCPUsFile, xpsCPUsFile); ok {
+		return fmt.Errorf("packets of the pod of container %q are already steered by container %q", c.ID(), holder)
+	}
+	network, err := sandboxNetwork(s)
+	if err != nil {
+		return err
+	}
+	log.Infof(ctx, "Steer the packets of the interfaces of the pod of container %q to CPUs %s", c.ID(), cpus.String())
+	return setPacketSteering(ctx, c.ID(), network, cpus)
+}
+
+// podNetTuningHolder returns the ID of another container of the sandbox holding the tuning of the devices of the
+// pod network, of the files or requested by its recorded tuning. The files are recorded for the container which
+// tuned them and restored when it stops, so that only one container of a pod may hold them at a time.
+func podNetTuningHolder(s *sandbox.Sandbox, c *oci.Container, requested func(*tuning) bool, files ...string) (string, bool) {
+	for _, ctr := range s.Containers().List() {
+		if ctr.ID() == c.ID() {
+			continue
+		}
+		record, ok := recordedTuning(ctr.ID())
+		if !ok {
+			continue
+		}
+		if record.Tuning != nil && requested(record.Tuning) {
+			return ctr.ID(), true
+		}
+		for _, w := range record.Writes {
+			if isNetDeviceFile(w.Path) && slices.Contains(files, filepath.Base(w.Path)) {
+				return ctr.ID(), true
+			}
+		}
+	}
+	return "", false
+}
+
+// packetSteeringCPUs returns the CPUs the packet processing gets steered to by the policy: either the CPUs
+// of the container, or the housekeeping CPUs, which default to the CPUs not allocated to tuned containers.
+func (h *HighPerformanceHooks) packetSteeringCPUs(c *oci.Container, policy string) (cpuset.CPUSet, error) {
+	var cpus cpuset.CPUSet
+	switch policy {
+	case packetSteeringContainer:
+		cSpec := c.Spec()
+		if isContainerCPUsSpecEmpty(&cSpec) {
+			return cpus, fmt.Errorf("container %q has no CPUs to steer the packets to", c.ID())
+		}
+		parsed, err := cpuset.Parse(cSpec.Linux.Resources.CPU.Cpus)
+		if err != nil {
+			return cpus, err
+		}
+		cpus = parsed
+	case packetSteeringHousekeeping:
+		if h.housekeepingCPUs != "" {
+			parsed, err := cpuset.Parse(h.housekeepingCPUs)
+			if err != nil {
+				return cpus, err
+			}
+			cpus = parsed
+		} else {
+			allocation, err := currentCPUAllocation()
+			if err != nil {
+				return cpus, err
+			}
+			// the container is not accounted for in the allocation until its tuning is recorded
+			cpus = allocation.housekeeping
+			if cSpec := c.Spec(); !isContainerCPUsSpecEmpty(&cSpec) {
+				if ctrCPUs, err := cpuset.Parse(cSpec.Linux.Resources.CPU.Cpus); err == nil {
+					cpus = cpus.Difference(ctrCPUs)
+				}
+			}
+		}
+	default:
+		return cpus, fmt.Errorf("invalid packet steering policy %q", policy)
+	}
+	if cpus.IsEmpty() {
+		return cpus, fmt.Errorf("no %s CPUs to steer the packets to", policy)
+	}
+	return cpus, nil
+}
+
+// setPacketSteering programs the RPS CPU mask of the receive queues and the XPS CPU mask of the transmit queues
+// of the interfaces of the pod network, recording the writes for the container.
+// The queues of kernels built without RPS or XPS have no mask to program, and are skipped.
+func setPacketSteering(ctx context.Context, containerID string, network podNetwork, cpus cpuset.CPUSet) error {
+	interfaces, err := network.interfaces()
+	if err != nil {
+		return err
+	}
+	mask := []byte(cpumask.FromCPUSet(cpus).String())
+	for _, iface := range interfaces {
+		queues, err := netDeviceQueues(iface)
+		if err != nil {
+			return fmt.Errorf("list queues of interface %s: %w", iface, err)
+		}
+		for _, queue := range queues {
+			file := queueCPUsFile(queue)
+			err := writeNetTuningFile(ctx, containerID, iface.NetNS, netDeviceFile(iface.Name, "queues", queue, file), mask)
+			if errors.Is(err, os.ErrNotExist) {
+				log.Debugf(ctx, "Queue %s of interface %s has no %s, skipping", queue, iface, file)
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("steer packets of interface %s: %w", iface, err)
+			}
+		}
+	}
+	return nil
+}
+
+// planPacketSteering returns the RPS and XPS CPU masks setPacketSteering would program for the container.
+func (h *HighPerformanceHooks) planPacketSteering(c *oci.Container, network podNetwork, policy string) ([]plannedChange, error) {
+	cpus, err := h.packetSteeringCPUs(c, policy)
+	if err != nil {
+		return nil, err
+	}
+	interfaces, err := network.interfaces()
+	if err != nil {
+		return nil, err
+	}
+	var changes []plannedChange
+	for _, iface := range interfaces {
+		queues, err := netDeviceQueues(iface)
+		if err != nil {
+			return nil, err
+		}
+		for _, queue := range queues {
+			changes = append(changes, plannedChange{
+				Feature: libconfig.HighPerformanceFeaturePacketSteering,
+				Path:    netDeviceFile(iface.Name, "queues", queue, queueCPUsFile(queue)),
+				Value:   cpumask.FromCPUSet(cpus).String(),
+			})
+		}
+	}
+	return changes, nil
+}
+
+// queueCPUsFile returns the file of the CPU mask of the queue: the RPS one of a receive queue,
+// the XPS one of a transmit queue.
+func queueCPUsFile(queue string) string {
+	if strings.HasPrefix(queue, "rx-") {
+		return rpsCPUsFile
+	}
+	return xpsCPUsFile
+}
+
+// netDeviceQueues returns the receive and transmit queues of the network device, like "rx-0" or "tx-0".
+func netDeviceQueues(iface podInterface) (queues []string, err error) {
+	err = withNetNSSysfs(iface.NetNS, func(sysfs string) error {
+		entries, err := os.ReadDir(filepath.Join(sysfs, "class", "net", iface.Name, "queues"))
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if strings.HasPrefix(entry.Name(), "rx-") || strings.HasPrefix(entry.Name(), "tx-") {
+				queues = append(queues, entry.Name())
+			}
+		}
+		return nil
+	})
+	return queues, err
+}
+
+// revertPacketSteering restores the RPS and XPS CPU masks programmed for the container.
+func revertPacketSteering(ctx context.Context, containerID string) error {
+	return restoreNetDeviceTuning(ctx, containerID, rpsCPUsFile, xpsCPUsFile)
+}
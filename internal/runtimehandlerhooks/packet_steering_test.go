@@ -0,0 +1,179 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"k8s.io/utils/cpuset"
+
+	crioann "github.com/cri-o/cri-o/pkg/annotations"
+)
+
+var _ = Describe("packet steering", func() {
+	const (
+		netns       = "/var/run/netns/pod"
+		containerID = "ctr1"
+	)
+	var dir string
+
+	// queueFile returns the file of the queue of the device in the sysfs of the network namespace.
+	queueFile := func(netnsPath, device, queue string) string {
+		root := filepath.Join(dir, "host")
+		if netnsPath != "" {
+			root = filepath.Join(dir, "pod")
+		}
+		return filepath.Join(root, "class", "net", device, "queues", queue, queueCPUsFile(queue))
+	}
+	addQueue := func(netnsPath, device, queue, mask string) {
+		file := queueFile(netnsPath, device, queue)
+		Expect(os.MkdirAll(filepath.Dir(file), 0o755)).To(Succeed())
+		Expect(os.WriteFile(file, []byte(mask+"\n"), 0o644)).To(Succeed())
+	}
+	readQueue := func(netnsPath, device, queue string) string {
+		content, err := os.ReadFile(queueFile(netnsPath, device, queue))
+		Expect(err).ToNot(HaveOccurred())
+		return strings.TrimSpace(string(content))
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		useFakePodNetwork([]podInterface{{Name: "eth0", NetNS: netns}, {Name: "veth1234"}}, map[string]string{"": filepath.Join(dir, "host"), netns: filepath.Join(dir, "pod")}, nil)
+		addQueue(netns, "eth0", "rx-0", "00")
+		addQueue(netns, "eth0", "tx-0", "00")
+		addQueue("", "veth1234", "rx-0", "0f")
+		addQueue("", "veth1234", "tx-0", "00")
+	})
+
+	AfterEach(func() {
+		forgetAppliedTuning(context.TODO(), containerID)
+		ForgetTuningStatus(containerID)
+	})
+
+	It("should program and revert the RPS and XPS masks of the pod interfaces", func() {
+		Expect(setPacketSteering(context.TODO(), containerID, podNetwork{NetNS: netns}, cpuset.New(2, 3))).To(Succeed())
+
+		Expect(readQueue(netns, "eth0", "rx-0")).To(Equal("0000000c"))
+		Expect(readQueue(netns, "eth0", "tx-0")).To(Equal("0000000c"))
+		Expect(readQueue("", "veth1234", "rx-0")).To(Equal("0000000c"))
+		Expect(readQueue("", "veth1234", "tx-0")).To(Equal("0000000c"))
+		record, ok := recordedTuning(containerID)
+		Expect(ok).To(BeTrue())
+		Expect(record.Writes).To(ContainElement(fileWrite{
+			Path: "/sys/class/net/eth0/queues/rx-0/rps_cpus", NetNS: netns, Original: "00", Value: "0000000c",
+		}))
+
+		Expect(revertPacketSteering(context.TODO(), containerID)).To(Succeed())
+
+		Expect(readQueue(netns, "eth0", "rx-0")).To(Equal("00"))
+		Expect(readQueue(netns, "eth0", "tx-0")).To(Equal("00"))
+		Expect(readQueue("", "veth1234", "rx-0")).To(Equal("0f"))
+		Expect(readQueue("", "veth1234", "tx-0")).To(Equal("00"))
+	})
+
+	It("should skip the interfaces gone along with the network namespace of the pod", func() {
+		Expect(setPacketSteering(context.TODO(), containerID, podNetwork{NetNS: netns}, cpuset.New(0))).To(Succeed())
+		Expect(os.RemoveAll(filepath.Join(dir, "pod"))).To(Succeed())
+
+		Expect(revertPacketSteering(context.TODO(), containerID)).To(Succeed())
+
+		Expect(readQueue("", "veth1234", "rx-0")).To(Equal("0f"))
+	})
+
+	It("should revert the masks of a pod interface renamed since", func() {
+		Expect(os.WriteFile(filepath.Join(dir, "pod", "class", "net", "eth0", "ifindex"), []byte("3\n"), 0o644)).To(Succeed())
+		Expect(setPacketSteering(context.TODO(), containerID, podNetwork{NetNS: netns}, cpuset.New(2, 3))).To(Succeed())
+		Expect(os.Rename(filepath.Join(dir, "pod", "class", "net", "eth0"), filepath.Join(dir, "pod", "class", "net", "net5"))).To(Succeed())
+
+		Expect(revertPacketSteering(context.TODO(), containerID)).To(Succeed())
+
+		Expect(readQueue(netns, "net5", "rx-0")).To(Equal("00"))
+		Expect(readQueue(netns, "net5", "tx-0")).To(Equal("00"))
+	})
+
+	It("should revert the masks of a VF moved back to the host along with the deletion of the pod", func() {
+		Expect(os.Symlink("../../../0000:3b:02.1", filepath.Join(dir, "pod", "class", "net", "eth0", "device"))).To(Succeed())
+		Expect(setPacketSteering(context.TODO(), containerID, podNetwork{NetNS: netns}, cpuset.New(2, 3))).To(Succeed())
+		Expect(os.Rename(filepath.Join(dir, "pod", "class", "net", "eth0"), filepath.Join(dir, "host", "class", "net", "ens1f0v1"))).To(Succeed())
+		Expect(os.RemoveAll(filepath.Join(dir, "pod"))).To(Succeed())
+
+		Expect(revertPacketSteering(context.TODO(), containerID)).To(Succeed())
+
+		Expect(readQueue("", "ens1f0v1", "rx-0")).To(Equal("00"))
+		Expect(readQueue("", "ens1f0v1", "tx-0")).To(Equal("00"))
+		Expect(readQueue("", "veth1234", "rx-0")).To(Equal("0f"))
+	})
+
+	It("should revert the masks on stop after the CPUs of the container got updated", func() {
+		c := newTestContainer(containerID, containerID, "sandboxID")
+		shares := uint64(2048)
+		c.SetSpec(&specs.Spec{Linux: &specs.Linux{Resources: &specs.LinuxResources{
+			CPU: &specs.LinuxCPU{Cpus: "2-3", Shares: &shares},
+		}}})
+		sb := newTestSandbox("sandboxID", map[string]string{crioann.PacketSteeringAnnotation + "/" + containerID: "isolated"})
+		Expect(setPacketSteering(context.TODO(), containerID, podNetwork{NetNS: netns}, cpuset.New(2, 3))).To(Succeed())
+		recordAppliedTuning(context.TODO(), containerID, &tuning{})
+		h := &HighPerformanceHooks{}
+
+		Expect(h.PreUpdate(context.TODO(), c, sb, &specs.LinuxResources{CPU: &specs.LinuxCPU{Cpus: "4-5"}})).To(Succeed())
+
+		record, ok := recordedTuning(containerID)
+		Expect(ok).To(BeTrue())
+		Expect(record.Tuning).To(BeNil())
+		Expect(record.Writes).To(HaveLen(4))
+
+		Expect(h.PreStop(context.TODO(), c, sb)).To(Succeed())
+
+		Expect(readQueue(netns, "eth0", "rx-0")).To(Equal("00"))
+		Expect(readQueue(netns, "eth0", "tx-0")).To(Equal("00"))
+		Expect(readQueue("", "veth1234", "rx-0")).To(Equal("0f"))
+		Expect(readQueue("", "veth1234", "tx-0")).To(Equal("00"))
+		Expect(tuningRecorded(containerID)).To(BeFalse())
+	})
+
+	It("should steer the packets to the configured housekeeping CPUs", func() {
+		h := &HighPerformanceHooks{housekeepingCPUs: "0-1"}
+
+		cpus, err := h.packetSteeringCPUs(nil, packetSteeringHousekeeping)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cpus.String()).To(Equal("0-1"))
+		_, err = h.packetSteeringCPUs(nil, "isolated")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should find the other container of the pod holding the steering of its packets", func() {
+		sb := newTestSandbox("sandboxID", nil)
+		c1, c2 := newTestContainer(containerID, containerID, "sandboxID"), newTestContainer("ctr2", "ctr2", "sandboxID")
+		sb.AddContainer(context.TODO(), c1)
+		sb.AddContainer(context.TODO(), c2)
+		steering := func(t *tuning) bool { return t.PacketSteering != nil }
+
+		_, ok := podNetTuningHolder(sb, c2, steering, rpsCPUsFile, xpsCPUsFile)
+		Expect(ok).To(BeFalse())
+
+		Expect(setPacketSteering(context.TODO(), containerID, podNetwork{NetNS: netns}, cpuset.New(0, 1))).To(Succeed())
+
+		holder, ok := podNetTuningHolder(sb, c2, steering, rpsCPUsFile, xpsCPUsFile)
+		Expect(ok).To(BeTrue())
+		Expect(holder).To(Equal(containerID))
+		_, ok = podNetTuningHolder(sb, c1, steering, rpsCPUsFile, xpsCPUsFile)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should revert the packet steering recorded before the dry run got enabled", func() {
+		c := newTestContainer(containerID, containerID, "sandboxID")
+		Expect(setPacketSteering(context.TODO(), containerID, podNetwork{NetNS: netns}, cpuset.New(0, 1))).To(Succeed())
+		Expect(readQueue(netns, "eth0", "rx-0")).To(Equal("00000003"))
+
+		h := &HighPerformanceHooks{dryRun: true}
+		Expect(h.PostStop(context.TODO(), c, newTestSandbox("sandboxID", nil))).To(Succeed())
+
+		Expect(readQueue(netns, "eth0", "rx-0")).To(Equal("00"))
+		Expect(tuningRecorded(containerID)).To(BeFalse())
+	})
+})
@@ -0,0 +1,203 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/generate"
+
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/internal/oci"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+	"github.com/cri-o/cri-o/pkg/hooksplugin"
+)
+
+// pluginHookTimeout is the maximum time a plugin gets to run a hook.
+const pluginHookTimeout = 30 * time.Second
+
+// hooksPluginClients caches the clients of the hooks plugins, keyed by socket path.
+// The hooks are instantiated per request, so the connections are kept at package level.
+var hooksPluginClients = struct {
+	sync.Mutex
+	clients map[string]*hooksplugin.Client
+}{clients: make(map[string]*hooksplugin.Client)}
+
+func hooksPluginClient(address string) (*hooksplugin.Client, error) {
+	hooksPluginClients.Lock()
+	defer hooksPluginClients.Unlock()
+	if client, ok := hooksPluginClients.clients[address]; ok {
+		return client, nil
+	}
+	client, err := hooksplugin.NewClient(address)
+	if err != nil {
+		return nil, err
+	}
+	hooksPluginClients.clients[address] = client
+	return client, nil
+}
+
+// pluginHooks runs the hooks of the out-of-process plugin registered for a runtime handler.
+type pluginHooks struct {
+	client *hooksplugin.Client
+}
+
+// withPluginHooks chains the built-in hooks with the hooks plugin registered for the runtime handler, if any.
+// The plugin has a higher priority than the built-in hooks, so it runs after them on start and before them
+// on stop, and always sees the tuning done by the built-in hooks.
+func withPluginHooks(config *libconfig.Config, handler string, builtin RuntimeHandlerHooks) (RuntimeHandlerHooks, error) {
+	runtime := config.RuntimeHandlerOrDefault(handler)
+	if runtime == nil || runtime.HooksPlugin == "" {
+		return builtin, nil
+	}
+	client, err := hooksPluginClient(runtime.HooksPlugin)
+	if err != nil {
+		return nil, err
+	}
+	return newHookChain(
+		chainedHooks{name: "built-in", priority: builtinHooksPriority, hooks: builtin},
+		chainedHooks{name: runtime.HooksPlugin, priority: pluginHooksPriority, hooks: &pluginHooks{client: client}},
+	)
+}
+
+// Contract of the plugin, which may read all the tuning of the built-in hooks.
+func (*pluginHooks) Contract() HookContract {
+	return HookContract{
+		Reads: []HookResource{
+			HookResourceSpecEnv, HookResourceSpecAnnotations, HookResourceCgroupCPUSet, HookResourceCgroupCPU,
+			HookResourceIRQAffinity, HookResourceIRQBalanceConfig, HookResourceCPUPMQoS, HookResourceCPUFreqGovernor,
+			HookResourceNetDevQueues, HookResourceNetDevChannels, HookResourceNetDevFeatures, HookResourceNAPIAffinity,
+			HookResourceNetDevRSS, HookResourceNetDevBusyPoll, HookResourceSpecCapabilities, HookResourceSpecBPFFS,
+		},
+		Modifies: []HookResource{HookResourceSpecMounts, HookResourceSpecRlimits},
+	}
+}
+
+// PreCreate submits the mounts and rlimits contributed by the plugin as a patch of the spec.
+func (p *pluginHooks) PreCreate(ctx context.Context, specgen *generate.Generator, s *sandbox.Sandbox, c *oci.Container) error {
+	ctx, cancel := context.WithTimeout(ctx, pluginHookTimeout)
+	defer cancel()
+	resp, err := p.client.PreCreate(ctx, containerRequest(c, s, specgen.Config))
+	if err != nil {
+		return err
+	}
+	log.Debugf(ctx, "Submitting %d mounts and %d rlimits from hooks plugin", len(resp.Mounts), len(resp.Rlimits))
+	return c.SubmitSpecPatch(&oci.SpecPatch{
+		Source:  "hooks plugin",
+		Mounts:  resp.Mounts,
+		Rlimits: resp.Rlimits,
+	})
+}
+
+func (p *pluginHooks) PreStart(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	pluginCtx, cancel := context.WithTimeout(ctx, pluginHookTimeout)
+	defer cancel()
+	return p.client.PreStart(pluginCtx, pluginRequest(ctx, c, s))
+}
+
+func (p *pluginHooks) PreStop(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	pluginCtx, cancel := context.WithTimeout(ctx, pluginHookTimeout)
+	defer cancel()
+	return p.client.PreStop(pluginCtx, pluginRequest(ctx, c, s))
+}
+
+func (p *pluginHooks) PostStop(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	pluginCtx, cancel := context.WithTimeout(ctx, pluginHookTimeout)
+	defer cancel()
+	return p.client.PostStop(pluginCtx, pluginRequest(ctx, c, s))
+}
+
+func (p *pluginHooks) PreUpdate(ctx context.Context, c *oci.Container, s *sandbox.Sandbox, resources *rspec.LinuxResources) error {
+	pluginCtx, cancel := context.WithTimeout(ctx, pluginHookTimeout)
+	defer cancel()
+	req := pluginRequest(ctx, c, s)
+	req.Resources = resources
+	return p.client.PreUpdate(pluginCtx, req)
+}
+
+func (p *pluginHooks) PostUpdate(ctx context.Context, c *oci.Container, s *sandbox.Sandbox, former *rspec.LinuxResources) error {
+	pluginCtx, cancel := context.WithTimeout(ctx, pluginHookTimeout)
+	defer cancel()
+	req := pluginRequest(ctx, c, s)
+	req.Resources = former
+	return p.client.PostUpdate(pluginCtx, req)
+}
+
+func (p *pluginHooks) PreCheckpoint(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	pluginCtx, cancel := context.WithTimeout(ctx, pluginHookTimeout)
+	defer cancel()
+	return p.client.PreCheckpoint(pluginCtx, pluginRequest(ctx, c, s))
+}
+
+func (p *pluginHooks) PostRestore(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	pluginCtx, cancel := context.WithTimeout(ctx, pluginHookTimeout)
+	defer cancel()
+	return p.client.PostRestore(pluginCtx, pluginRequest(ctx, c, s))
+}
+
+func pluginRequest(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) *hooksplugin.Request {
+	spec := c.Spec()
+	req := containerRequest(c, s, &spec)
+	if pid, err := c.Pid(); err == nil {
+		req.Pid = pid
+	} else {
+		log.Debugf(ctx, "Container %q has no running process for the hooks plugin: %v", c.ID(), err)
+	}
+	return req
+}
+
+// containerRequest describes the container with the given spec, which is not yet set on the container in PreCreate.
+func containerRequest(c *oci.Container, s *sandbox.Sandbox, spec *rspec.Spec) *hooksplugin.Request {
+	req := &hooksplugin.Request{
+		ContainerID:      c.ID(),
+		ContainerName:    c.CRIContainer().GetMetadata().GetName(),
+		SandboxID:        s.ID(),
+		SandboxName:      s.Name(),
+		SandboxNamespace: s.Namespace(),
+		CgroupParent:     s.CgroupParent(),
+		Annotations:      s.Annotations(),
+	}
+	if spec.Linux != nil && spec.Linux.Resources != nil && spec.Linux.Resources.CPU != nil {
+		req.CPUs = spec.Linux.Resources.CPU.Cpus
+	}
+	return req
+}
+
+// AsHighPerformanceHook returns the high-performance hooks of the runtime handler hooks, if any,
+// including when they are chained with a hooks plugin or run under a timeout. The returned hooks
+// keep the lock of the sandbox of the runtime handler hooks, if any.
+func AsHighPerformanceHook(hooks RuntimeHandlerHooks) (HighPerformanceHook, bool) {
+	if t, ok := hooks.(*timeoutHooks); ok {
+		hooks = t.hooks
+	}
+	if l, ok := hooks.(*sandboxLockHooks); ok {
+		h, ok := AsHighPerformanceHook(l.hooks)
+		if !ok {
+			return nil, false
+		}
+		return &sandboxLockHighPerformanceHook{sandboxLockHooks: sandboxLockHooks{hooks: h}, highPerformance: h}, true
+	}
+	if chain, ok := hooks.(*hookChain); ok {
+		for i := range chain.hooks {
+			if h, ok := chain.hooks[i].hooks.(HighPerformanceHook); ok {
+				return h, true
+			}
+		}
+		return nil, false
+	}
+	h, ok := hooks.(HighPerformanceHook)
+	return h, ok
+}
+
+// unwrapHooks returns the hooks run under the timeout and the lock of the sandbox.
+func unwrapHooks(hooks RuntimeHandlerHooks) RuntimeHandlerHooks {
+	if t, ok := hooks.(*timeoutHooks); ok {
+		hooks = t.hooks
+	}
+	if l, ok := hooks.(*sandboxLockHooks); ok {
+		hooks = l.hooks
+	}
+	return hooks
+}
@@ -0,0 +1,257 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/generate"
+	"google.golang.org/grpc"
+
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	"github.com/cri-o/cri-o/internal/oci"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+	"github.com/cri-o/cri-o/pkg/hooksplugin"
+)
+
+// orderedHooks records the hooks run by the built-in hooks and the plugin in a shared log.
+type orderedHooks struct {
+	name string
+	log  *[]string
+	err  error
+}
+
+func (o *orderedHooks) record(stage string) error {
+	*o.log = append(*o.log, o.name+" "+stage)
+	return o.err
+}
+
+func (o *orderedHooks) PreCreate(context.Context, *generate.Generator, *sandbox.Sandbox, *oci.Container) error {
+	return o.record("PreCreate")
+}
+
+func (o *orderedHooks) PreStart(context.Context, *oci.Container, *sandbox.Sandbox) error {
+	return o.record("PreStart")
+}
+
+func (o *orderedHooks) PreStop(context.Context, *oci.Container, *sandbox.Sandbox) error {
+	return o.record("PreStop")
+}
+
+func (o *orderedHooks) PostStop(context.Context, *oci.Container, *sandbox.Sandbox) error {
+	return o.record("PostStop")
+}
+
+func (o *orderedHooks) PreUpdate(context.Context, *oci.Container, *sandbox.Sandbox, *rspec.LinuxResources) error {
+	return o.record("PreUpdate")
+}
+
+func (o *orderedHooks) PostUpdate(context.Context, *oci.Container, *sandbox.Sandbox, *rspec.LinuxResources) error {
+	return o.record("PostUpdate")
+}
+
+func (o *orderedHooks) PreCheckpoint(context.Context, *oci.Container, *sandbox.Sandbox) error {
+	return o.record("PreCheckpoint")
+}
+
+func (o *orderedHooks) PostRestore(context.Context, *oci.Container, *sandbox.Sandbox) error {
+	return o.record("PostRestore")
+}
+
+type orderedPlugin struct {
+	orderedHooks
+	reqs      []*hooksplugin.Request
+	preCreate *hooksplugin.PreCreateResponse
+}
+
+func (o *orderedPlugin) PreCreate(_ context.Context, req *hooksplugin.Request) (*hooksplugin.PreCreateResponse, error) {
+	o.reqs = append(o.reqs, req)
+	return o.preCreate, o.record("PreCreate")
+}
+
+func (o *orderedPlugin) PreStart(_ context.Context, req *hooksplugin.Request) error {
+	o.reqs = append(o.reqs, req)
+	return o.record("PreStart")
+}
+
+func (o *orderedPlugin) PreStop(_ context.Context, req *hooksplugin.Request) error {
+	o.reqs = append(o.reqs, req)
+	return o.record("PreStop")
+}
+
+func (o *orderedPlugin) PostStop(_ context.Context, req *hooksplugin.Request) error {
+	o.reqs = append(o.reqs, req)
+	return o.record("PostStop")
+}
+
+func (o *orderedPlugin) PreUpdate(_ context.Context, req *hooksplugin.Request) error {
+	o.reqs = append(o.reqs, req)
+	return o.record("PreUpdate")
+}
+
+func (o *orderedPlugin) PostUpdate(_ context.Context, req *hooksplugin.Request) error {
+	o.reqs = append(o.reqs, req)
+	return o.record("PostUpdate")
+}
+
+func (o *orderedPlugin) PreCheckpoint(_ context.Context, req *hooksplugin.Request) error {
+	o.reqs = append(o.reqs, req)
+	return o.record("PreCheckpoint")
+}
+
+func (o *orderedPlugin) PostRestore(_ context.Context, req *hooksplugin.Request) error {
+	o.reqs = append(o.reqs, req)
+	return o.record("PostRestore")
+}
+
+var _ = Describe("pluginHooks", func() {
+	var (
+		calls   []string
+		builtin *orderedHooks
+		plugin  *orderedPlugin
+		hooks   RuntimeHandlerHooks
+		server  *grpc.Server
+	)
+
+	c := newTestContainer("containerID", "cnt1", "sandboxID")
+
+	sb := newTestSandbox("sandboxID", map[string]string{"key": "value"})
+
+	BeforeEach(func() {
+		address := filepath.Join(GinkgoT().TempDir(), "plugin.sock")
+		listener, err := net.Listen("unix", address)
+		Expect(err).ToNot(HaveOccurred())
+
+		calls = nil
+		builtin = &orderedHooks{name: "builtin", log: &calls}
+		plugin = &orderedPlugin{orderedHooks: orderedHooks{name: "plugin", log: &calls}}
+		server = grpc.NewServer()
+		hooksplugin.Register(server, plugin)
+		go func() {
+			_ = server.Serve(listener)
+		}()
+
+		config := &libconfig.Config{}
+		config.Runtimes = libconfig.Runtimes{"vendor": {HooksPlugin: address}}
+		hooks, err = withPluginHooks(config, "vendor", builtin)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Stop()
+	})
+
+	It("should run the plugin after the built-in hooks on start and before them on stop", func() {
+		Expect(hooks.PreStart(context.Background(), c, sb)).To(Succeed())
+		Expect(hooks.PreStop(context.Background(), c, sb)).To(Succeed())
+		Expect(hooks.PostStop(context.Background(), c, sb)).To(Succeed())
+
+		Expect(calls).To(Equal([]string{
+			"builtin PreStart", "plugin PreStart",
+			"plugin PreStop", "builtin PreStop",
+			"plugin PostStop", "builtin PostStop",
+		}))
+		Expect(plugin.reqs[0].ContainerID).To(Equal("containerID"))
+		Expect(plugin.reqs[0].ContainerName).To(Equal("cnt1"))
+		Expect(plugin.reqs[0].SandboxID).To(Equal("sandboxID"))
+		Expect(plugin.reqs[0].Annotations).To(HaveKeyWithValue("key", "value"))
+	})
+
+	It("should run the plugin before the built-in hooks on update and after them once updated", func() {
+		resources := &rspec.LinuxResources{CPU: &rspec.LinuxCPU{Cpus: "3-4"}}
+		former := &rspec.LinuxResources{CPU: &rspec.LinuxCPU{Cpus: "1-2"}}
+
+		Expect(hooks.PreUpdate(context.Background(), c, sb, resources)).To(Succeed())
+		Expect(hooks.PostUpdate(context.Background(), c, sb, former)).To(Succeed())
+
+		Expect(calls).To(Equal([]string{
+			"plugin PreUpdate", "builtin PreUpdate",
+			"builtin PostUpdate", "plugin PostUpdate",
+		}))
+		Expect(plugin.reqs[0].Resources).To(Equal(resources))
+		Expect(plugin.reqs[1].Resources).To(Equal(former))
+	})
+
+	It("should run the plugin after the built-in hooks on checkpoint and restore", func() {
+		Expect(hooks.PreCheckpoint(context.Background(), c, sb)).To(Succeed())
+		Expect(hooks.PostRestore(context.Background(), c, sb)).To(Succeed())
+
+		Expect(calls).To(Equal([]string{
+			"builtin PreCheckpoint", "plugin PreCheckpoint",
+			"builtin PostRestore", "plugin PostRestore",
+		}))
+	})
+
+	It("should submit the spec changes of the plugin after the built-in hook", func() {
+		specgen, err := generate.New("linux")
+		Expect(err).ToNot(HaveOccurred())
+		specgen.SetLinuxResourcesCPUCpus("1-2")
+		specgen.AddProcessRlimits("RLIMIT_NOFILE", 1024, 1024)
+		plugin.preCreate = &hooksplugin.PreCreateResponse{
+			Mounts:  []rspec.Mount{{Destination: "/dev/shm", Type: "tmpfs", Source: "shm"}},
+			Rlimits: []rspec.POSIXRlimit{{Type: "RLIMIT_NOFILE", Hard: 4096, Soft: 2048}},
+		}
+
+		Expect(hooks.PreCreate(context.Background(), &specgen, sb, c)).To(Succeed())
+		c.ApplySpecPatches(specgen.Config)
+
+		Expect(calls).To(Equal([]string{"builtin PreCreate", "plugin PreCreate"}))
+		Expect(plugin.reqs[0].CPUs).To(Equal("1-2"))
+		Expect(specgen.Mounts()).To(ContainElement(plugin.preCreate.Mounts[0]))
+		shm := 0
+		for _, m := range specgen.Mounts() {
+			if m.Destination == "/dev/shm" {
+				shm++
+			}
+		}
+		Expect(shm).To(Equal(1))
+		Expect(specgen.Config.Process.Rlimits).To(Equal([]rspec.POSIXRlimit{{Type: "RLIMIT_NOFILE", Hard: 4096, Soft: 2048}}))
+	})
+
+	It("should reject the invalid spec changes of the plugin", func() {
+		specgen, err := generate.New("linux")
+		Expect(err).ToNot(HaveOccurred())
+		plugin.preCreate = &hooksplugin.PreCreateResponse{
+			Mounts: []rspec.Mount{{Destination: "dev/shm", Type: "tmpfs", Source: "shm"}},
+		}
+
+		Expect(hooks.PreCreate(context.Background(), &specgen, sb, c)).NotTo(Succeed())
+	})
+
+	It("should run the built-in stop hooks if the plugin failed", func() {
+		plugin.err = errors.New("plugin failed")
+
+		err := hooks.PreStop(context.Background(), c, sb)
+
+		Expect(err).To(HaveOccurred())
+		Expect(calls).To(Equal([]string{"plugin PreStop", "builtin PreStop"}))
+	})
+
+	It("should not run the plugin if the built-in start hook failed", func() {
+		builtin.err = errors.New("builtin failed")
+
+		Expect(hooks.PreStart(context.Background(), c, sb)).NotTo(Succeed())
+		Expect(calls).To(Equal([]string{"builtin PreStart"}))
+	})
+
+	It("should not wrap the hooks of runtime handlers without plugin", func() {
+		h, err := withPluginHooks(&libconfig.Config{}, "runc", builtin)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(h).To(BeIdenticalTo(builtin))
+	})
+
+	It("should unwrap the high-performance hooks", func() {
+		_, ok := AsHighPerformanceHook(hooks)
+		Expect(ok).To(BeFalse())
+
+		_, ok = AsHighPerformanceHook(&hookChain{hooks: []chainedHooks{
+			{name: "built-in", priority: builtinHooksPriority, hooks: &HighPerformanceHooks{}},
+			{name: "plugin", priority: pluginHooksPriority, hooks: &pluginHooks{}},
+		}})
+		Expect(ok).To(BeTrue())
+	})
+})
@@ -0,0 +1,48 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cri-o/cri-o/internal/log"
+)
+
+// RevertPodNetworkTuning restores the root qdisc, the receive aggregation and the interrupt coalescing set for the pod,
+// releases the node sysctls it holds, like the netdev budget, and forgets about them. Every revert is attempted, even
+// if another one failed, and the tuning of the pod gets forgotten anyway, as it is not retried once the pod stopped.
+func RevertPodNetworkTuning(ctx context.Context, sandboxID string) error {
+	var errs []error
+	if err := releaseNodeSysctls(ctx, sandboxID); err != nil {
+		errs = append(errs, fmt.Errorf("release node sysctls of pod sandbox %s: %w", sandboxID, err))
+	}
+	if err := restoreNetDeviceTuning(ctx, sandboxID, qdiscRoot); err != nil {
+		errs = append(errs, fmt.Errorf("revert qdisc of pod sandbox %s: %w", sandboxID, err))
+	}
+	if err := restoreNetDeviceTuning(ctx, sandboxID, receiveAggregationFeatures...); err != nil {
+		errs = append(errs, fmt.Errorf("revert GRO of pod sandbox %s: %w", sandboxID, err))
+	}
+	if err := restoreNetDeviceTuning(ctx, sandboxID, interruptCoalescingSettings...); err != nil {
+		errs = append(errs, fmt.Errorf("revert interrupt coalescing of pod sandbox %s: %w", sandboxID, err))
+	}
+	forgetAppliedTuning(ctx, sandboxID)
+	return errors.Join(errs...)
+}
+
+// ReleaseStaleNodeSysctls releases the node sysctls held by the pods and containers which do not exist anymore
+// on restore, like the ones removed while CRI-O was down, so that they do not keep the sysctls held forever.
+// The record of a holder is forgotten once it holds nothing else.
+func ReleaseStaleNodeSysctls(ctx context.Context, exists func(id string) bool) {
+	for _, holder := range nodeSysctlHolders() {
+		if exists(holder) {
+			continue
+		}
+		log.Infof(ctx, "Releasing the node sysctls held by %s, which does not exist anymore", holder)
+		if err := releaseNodeSysctls(ctx, holder); err != nil {
+			log.Warnf(ctx, "Failed to release the node sysctls held by %s: %v", holder, err)
+		}
+		if record, ok := recordedTuning(holder); ok && record.Tuning == nil && len(record.Writes) == 0 {
+			forgetAppliedTuning(ctx, holder)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/sys/unix"
+)
+
+var _ = Describe("pod network tuning", func() {
+	var fs *fakeHostFS
+
+	BeforeEach(func() {
+		fs = useFakeHostFS(map[string]string{
+			netdevBudgetFile:      "300\n",
+			netdevBudgetUsecsFile: "2000\n",
+		})
+	})
+
+	AfterEach(func() {
+		for _, id := range []string{"sb1", "sb2"} {
+			forgetAppliedTuning(context.TODO(), id)
+		}
+	})
+
+	It("should revert and forget the tuning of the pod despite the failures", func() {
+		Expect(setNetdevBudget(context.TODO(), "sb1", map[string]string{
+			netdevBudgetParam: "600", netdevBudgetUsecsParam: "4000",
+		})).To(Succeed())
+		fs.writeErrs[netdevBudgetFile] = []error{unix.EACCES}
+
+		err := RevertPodNetworkTuning(context.TODO(), "sb1")
+
+		Expect(err).To(MatchError(ContainSubstring("release node sysctls of pod sandbox sb1")))
+		Expect(fs.files[netdevBudgetUsecsFile]).To(Equal("2000"))
+		Expect(tuningRecorded("sb1")).To(BeFalse())
+	})
+
+	It("should release the node sysctls held by the pods which do not exist anymore", func() {
+		budget := map[string]string{netdevBudgetParam: "600"}
+		Expect(setNetdevBudget(context.TODO(), "sb1", budget)).To(Succeed())
+		Expect(setNetdevBudget(context.TODO(), "sb2", budget)).To(Succeed())
+
+		ReleaseStaleNodeSysctls(context.TODO(), func(id string) bool { return id == "sb2" })
+
+		Expect(fs.files[netdevBudgetFile]).To(Equal("600"))
+		Expect(tuningRecorded("sb1")).To(BeFalse())
+		Expect(recordedHolds(netdevBudgetFile)).To(HaveKey("sb2"))
+
+		ReleaseStaleNodeSysctls(context.TODO(), func(string) bool { return false })
+
+		Expect(fs.files[netdevBudgetFile]).To(Equal("300"))
+		Expect(recordedHolds(netdevBudgetFile)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,178 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	"github.com/cri-o/cri-o/internal/log"
+	crioann "github.com/cri-o/cri-o/pkg/annotations"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+)
+
+const (
+	// qdiscDir is the pseudo directory of a network device holding its root qdisc, "/sys/class/net/eth0/qdisc/root".
+	// The qdisc is recorded like the files of the device, but read and written over rtnetlink instead of sysfs.
+	qdiscDir  = "qdisc"
+	qdiscRoot = "root"
+)
+
+// podQdiscs are the root qdiscs a pod can request for the devices backing its interfaces: none, sending the
+// packets to the driver right away, one per transmit queue of a multiqueue device, or fair queuing with pacing.
+var podQdiscs = []string{"noqueue", "mq", "fq"}
+
+// rootQdiscFile returns the pseudo file of the root qdisc of the device.
+func rootQdiscFile(device string) string {
+	return netDeviceFile(device, qdiscDir, qdiscRoot)
+}
+
+// parseRootQdiscFile returns the device of the pseudo file of its root qdisc, if it is one.
+func parseRootQdiscFile(name string) (device string, ok bool) {
+	rel, ok := strings.CutPrefix(name, netSysfsDir+"/")
+	if !ok {
+		return "", false
+	}
+	parts := strings.Split(rel, "/")
+	if len(parts) != 3 || parts[1] != qdiscDir || parts[2] != qdiscRoot {
+		return "", false
+	}
+	return parts[0], true
+}
+
+// rootQdiscs reads and replaces the root qdisc of the network devices of the network namespace the calling
+// thread is in. The tests replace it with a fake.
+var rootQdiscs qdiscAPI = netlinkQdiscs{}
+
+type qdiscAPI interface {
+	// Get returns the kind of the root qdisc of the device, like "fq_codel".
+	Get(device string) (string, error)
+	// Set replaces the root qdisc of the device by a qdisc of the kind, with its default parameters.
+	Set(device, kind string) error
+}
+
+// netlinkQdiscs implements the root qdiscs over rtnetlink, like "tc qdisc replace" does.
+type netlinkQdiscs struct{}
+
+func (netlinkQdiscs) Get(device string) (string, error) {
+	link, err := netlink.LinkByName(device)
+	if err != nil {
+		return "", err
+	}
+	qdiscs, err := netlink.QdiscList(link)
+	if err != nil {
+		return "", fmt.Errorf("list qdiscs of device %s: %w", device, err)
+	}
+	for _, qdisc := range qdiscs {
+		if qdisc.Attrs().Parent == netlink.HANDLE_ROOT {
+			return qdisc.Type(), nil
+		}
+	}
+	// the devices without transmit queue, like the veth ones by default, may not report their qdisc
+	return "noqueue", nil
+}
+
+func (netlinkQdiscs) Set(device, kind string) error {
+	link, err := netlink.LinkByName(device)
+	if err != nil {
+		return err
+	}
+	qdisc := &netlink.GenericQdisc{
+		QdiscAttrs: netlink.QdiscAttrs{LinkIndex: link.Attrs().Index, Parent: netlink.HANDLE_ROOT},
+		QdiscType:  kind,
+	}
+	if err := netlink.QdiscReplace(qdisc); err != nil {
+		return fmt.Errorf("replace root qdisc of device %s by %s: %w", device, kind, err)
+	}
+	return nil
+}
+
+// readRootQdisc reads the root qdisc of the pseudo file, from the network namespace sysfs got mounted at.
+// The device is looked up in sysfs first, so that a device gone is reported as os.ErrNotExist.
+func readRootQdisc(sysfs, name string) ([]byte, error) {
+	device, _ := parseRootQdiscFile(name)
+	if _, err := os.Stat(filepath.Join(sysfs, "class", "net", device)); err != nil {
+		return nil, err
+	}
+	kind, err := rootQdiscs.Get(device)
+	return []byte(kind), err
+}
+
+// writeRootQdisc replaces the root qdisc of the pseudo file, in the network namespace sysfs got mounted at.
+func writeRootQdisc(ctx context.Context, sysfs, name string, data []byte) error {
+	device, _ := parseRootQdiscFile(name)
+	if _, err := os.Stat(filepath.Join(sysfs, "class", "net", device)); err != nil {
+		return err
+	}
+	kind := strings.TrimSpace(string(data))
+	log.Debugf(ctx, "Replace the root qdisc of device %s by %s", device, kind)
+	return rootQdiscs.Set(device, kind)
+}
+
+// SetPodQdisc replaces the root qdisc of the devices backing the interfaces of the pod by the one requested by
+// its annotations, once its network is set up, until RevertPodNetworkTuning gets called when the pod stops.
+// The writes are recorded for the pod, keyed by its sandbox ID, like its interrupt coalescing. The CNI result
+// of the pod, if any, resolves its default network for the tuning interfaces annotation.
+func SetPodQdisc(ctx context.Context, config *libconfig.Config, sb *sandbox.Sandbox, cniResult string) error {
+	kind, ok := sb.Annotations()[crioann.QdiscAnnotation]
+	if !ok {
+		return nil
+	}
+	settings := config.HighPerformanceSettingsFor(sb.RuntimeHandler())
+	if !settings.FeatureEnabled(libconfig.HighPerformanceFeatureQdisc) ||
+		config.HighPerformancePolicyFor(sb.RuntimeHandler()) != libconfig.RuntimeTypeTuningTune {
+		return nil
+	}
+	if sb.HostNetwork() || sb.NetNsPath() == "" {
+		log.Warnf(ctx, "Qdisc requested for pod sandbox %s on the host network, ignoring", sb.ID())
+		return nil
+	}
+	if !slices.Contains(podQdiscs, kind) {
+		return fmt.Errorf("invalid qdisc %q, expected one of %q", kind, podQdiscs)
+	}
+	network, err := podNetworkOf(sb, cniResult)
+	if err != nil {
+		return err
+	}
+	log.Infof(ctx, "Set the root qdisc of the devices backing the interfaces of pod sandbox %s to %s", sb.ID(), kind)
+	err = setPodQdisc(ctx, sb.ID(), network, kind)
+	if err != nil && slices.Contains(settings.FailOpen, libconfig.HighPerformanceFeatureQdisc) {
+		log.Warnf(ctx, "Ignoring the failure of %s for pod sandbox %s: %v", libconfig.HighPerformanceFeatureQdisc, sb.ID(), err)
+		return nil
+	}
+	return err
+}
+
+// setPodQdisc replaces the root qdisc of the devices backing the interfaces of the pod network, the host side of
+// its veth devices and its SR-IOV VFs, by a qdisc of the kind, recording the writes for the ID. The devices the
+// kind does not apply to, like the single queue ones for "mq", are skipped.
+func setPodQdisc(ctx context.Context, id string, network podNetwork, kind string) error {
+	interfaces, err := network.interfaces()
+	if err != nil {
+		return err
+	}
+	vfs, err := podVFs(network)
+	if err != nil {
+		return err
+	}
+	for _, iface := range interfaces {
+		if iface.NetNS != "" && !slices.Contains(vfs, iface) {
+			continue
+		}
+		err := writeNetTuningFile(ctx, id, iface.NetNS, rootQdiscFile(iface.Name), []byte(kind))
+		if errors.Is(err, errors.ErrUnsupported) {
+			log.Debugf(ctx, "Interface %s does not support the %s qdisc, skipping", iface, kind)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("set root qdisc of interface %s: %w", iface, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeQdiscs holds the kind of the root qdisc of the devices, the single queue ones not supporting "mq".
+type fakeQdiscs struct {
+	kinds       map[string]string
+	singleQueue map[string]bool
+}
+
+func (f fakeQdiscs) Get(device string) (string, error) {
+	return f.kinds[device], nil
+}
+
+func (f fakeQdiscs) Set(device, kind string) error {
+	if kind == "mq" && f.singleQueue[device] {
+		return errors.ErrUnsupported
+	}
+	f.kinds[device] = kind
+	return nil
+}
+
+var _ = Describe("qdisc", func() {
+	const (
+		netns     = "/var/run/netns/pod"
+		sandboxID = "sb1"
+	)
+	var (
+		dir             string
+		qdiscs          fakeQdiscs
+		savedRootQdiscs = rootQdiscs
+	)
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		useFakePodNetwork([]podInterface{{Name: "eth0", NetNS: netns}, {Name: "net1", NetNS: netns}, {Name: "veth1234", Peer: "eth0"}}, map[string]string{"": filepath.Join(dir, "host"), netns: filepath.Join(dir, "pod")}, nil)
+		qdiscs = fakeQdiscs{
+			kinds:       map[string]string{"eth0": "noqueue", "net1": "mq", "veth1234": "fq_codel"},
+			singleQueue: map[string]bool{"eth0": true, "veth1234": true},
+		}
+		rootQdiscs = qdiscs
+		Expect(os.MkdirAll(filepath.Join(dir, "pod", "class", "net", "eth0"), 0o755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(dir, "pod", "class", "net", "net1", "device", "physfn"), 0o755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(dir, "host", "class", "net", "veth1234"), 0o755)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		rootQdiscs = savedRootQdiscs
+		forgetAppliedTuning(context.TODO(), sandboxID)
+	})
+
+	It("should set and revert the root qdisc of the host side of the veth devices and of the VFs of the pod", func() {
+		Expect(setPodQdisc(context.TODO(), sandboxID, podNetwork{NetNS: netns}, "fq")).To(Succeed())
+
+		Expect(qdiscs.kinds).To(Equal(map[string]string{"eth0": "noqueue", "net1": "fq", "veth1234": "fq"}))
+		record, ok := recordedTuning(sandboxID)
+		Expect(ok).To(BeTrue())
+		Expect(record.Writes).To(ContainElement(fileWrite{
+			Path: "/sys/class/net/veth1234/qdisc/root", Original: "fq_codel", Value: "fq",
+		}))
+
+		Expect(RevertPodNetworkTuning(context.TODO(), sandboxID)).To(Succeed())
+
+		Expect(qdiscs.kinds).To(Equal(map[string]string{"eth0": "noqueue", "net1": "mq", "veth1234": "fq_codel"}))
+		Expect(tuningRecorded(sandboxID)).To(BeFalse())
+	})
+
+	It("should skip the single queue devices for mq", func() {
+		Expect(setPodQdisc(context.TODO(), sandboxID, podNetwork{NetNS: netns}, "mq")).To(Succeed())
+
+		Expect(qdiscs.kinds["veth1234"]).To(Equal("fq_codel"))
+		Expect(tuningRecorded(sandboxID)).To(BeFalse())
+	})
+
+	It("should only set the root qdisc of the selected interfaces", func() {
+		Expect(setPodQdisc(context.TODO(), sandboxID, podNetwork{NetNS: netns, Interfaces: []string{"net1"}}, "noqueue")).To(Succeed())
+
+		Expect(qdiscs.kinds).To(Equal(map[string]string{"eth0": "noqueue", "net1": "noqueue", "veth1234": "fq_codel"}))
+	})
+})
@@ -0,0 +1,120 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/cri-o/cri-o/internal/log"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+	"github.com/cri-o/cri-o/pkg/cpumask"
+)
+
+// RestoreTuning reverts the node tuning recorded for all the containers in the tuning state directory,
+// and restores the irqbalance banned CPU list, unless disabled. It is meant to run before the server
+// starts after a reboot or a crash, e.g. from a systemd unit, when no container is left to revert its
+// tuning on stop. The files changed since they got tuned are left alone, as someone else owns them now.
+// The records restored successfully are removed, the other ones are kept for another attempt.
+func RestoreTuning(ctx context.Context, config *libconfig.Config) error {
+	SetHookLogFormat(config.RuntimeHandlerHooksLogFormat)
+	ExportTopologyToFile(config.TuningTopologyFile)
+	WriteContainerStateFiles(config.TuningContainerStateDir)
+	settings := config.HighPerformanceSettings()
+	if err := LoadTuningStore(ctx, settings.StateDir); err != nil {
+		return err
+	}
+	if err := OpenTuningAuditLog(config.TuningAuditLog, config.TuningAuditLogSizeMax); err != nil {
+		return err
+	}
+	if err := EnableLinuxAudit(config.TuningLinuxAudit); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, containerID := range recordedContainers() {
+		record, ok := recordedTuning(containerID)
+		if !ok {
+			continue
+		}
+		log.Infof(ctx, "Restore the node tuning of container %q", containerID)
+		if err := restoreRecordedTuning(withHookLogFields(withAuditContainer(ctx, containerID), logrus.Fields{"container": containerID}), containerID, &record); err != nil {
+			errs = append(errs, fmt.Errorf("restore tuning of container %q: %w", containerID, err))
+			continue
+		}
+		forgetAppliedTuning(ctx, containerID)
+	}
+
+	// the CPUs got added back to the IRQ affinity mask first, so the banned CPU list gets restored as on a reboot
+	if settings.IrqBalanceConfigRestoreEnabled() {
+		if err := RestoreIrqBalanceConfig(ctx, settings.IrqBalanceConfigFile, settings.IrqBalanceConfigRestoreFile, IrqSmpAffinityProcFile); err != nil {
+			errs = append(errs, fmt.Errorf("restore irqbalance config: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// restoreRecordedTuning restores the files written to tune the container, in the reverse order of their writes.
+func restoreRecordedTuning(ctx context.Context, containerID string, record *tuningRecord) error {
+	var errs []error
+	for i := len(record.Writes) - 1; i >= 0; i-- {
+		w := record.Writes[i]
+		err := restoreTuningWrite(ctx, &w)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			// e.g. the cgroup of the container is gone
+			log.Debugf(ctx, "File %s tuned for container %q does not exist anymore, skipping", w.Path, containerID)
+		case err != nil:
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// restoreTuningWrite restores the original value of the file, unless it changed since it got tuned.
+// The IRQ affinity mask is shared with the other containers, so only the CPUs removed from it get added back.
+// The network device of the file is looked up first, in case it got renamed or moved since it got tuned.
+func restoreTuningWrite(ctx context.Context, w *fileWrite) error {
+	if isNetDeviceFile(w.Path) {
+		if err := w.locateNetDevice(ctx); err != nil {
+			return err
+		}
+	}
+	content, err := w.read()
+	if err != nil {
+		return err
+	}
+	current := strings.TrimSpace(string(content))
+
+	if filepath.Base(w.Path) == filepath.Base(IrqSmpAffinityProcFile) {
+		original, err := cpumask.Parse(w.Original)
+		if err != nil {
+			return err
+		}
+		tuned, err := cpumask.Parse(w.Value)
+		if err != nil {
+			return err
+		}
+		removed := original.CPUSet().Difference(tuned.CPUSet())
+		if removed.IsEmpty() {
+			return nil
+		}
+		mask, _, err := cpumask.Update(removed.String(), current, true)
+		if err != nil {
+			return err
+		}
+		log.Infof(ctx, "Restore CPUs %s to the IRQ affinity mask %s", removed.String(), w.Path)
+		return writeFileIfChanged(ctx, w.Path, []byte(mask), 0o644)
+	}
+
+	if current != w.Value {
+		log.Infof(ctx, "File %s changed since it got tuned, leaving it to %q", w.Path, current)
+		return nil
+	}
+	log.Infof(ctx, "Restore %s to %q", w.Path, w.Original)
+	return w.write(ctx, []byte(w.Original))
+}
@@ -0,0 +1,74 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+)
+
+var _ = Describe("RestoreTuning", func() {
+	var dir string
+	var config *libconfig.Config
+
+	writeFile := func(name, content string) string {
+		file := filepath.Join(dir, name)
+		Expect(os.WriteFile(file, []byte(content), 0o644)).To(Succeed())
+		return file
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		config = &libconfig.Config{}
+		config.TuningStateDir = filepath.Join(dir, "tuning")
+		config.IrqBalanceConfigRestoreFile = "disable"
+		Expect(LoadTuningStore(context.TODO(), config.TuningStateDir)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		for _, containerID := range recordedContainers() {
+			forgetAppliedTuning(context.TODO(), containerID)
+		}
+		tuningStore.Lock()
+		tuningStore.dir = ""
+		tuningStore.Unlock()
+	})
+
+	It("should restore the files still holding the tuned values", func() {
+		governor := writeFile("scaling_governor", "performance")
+		latency := writeFile("pm_qos_resume_latency_us", "10")
+		recordTuningWrite(context.TODO(), "ctr1", governor, "powersave", "performance")
+		recordTuningWrite(context.TODO(), "ctr1", latency, "0", "n/a")
+
+		// simulate a restart
+		tuningStore.Lock()
+		delete(tuningStore.containers, "ctr1")
+		tuningStore.Unlock()
+		Expect(RestoreTuning(context.TODO(), config)).To(Succeed())
+
+		Expect(os.ReadFile(governor)).To(Equal([]byte("powersave")))
+		Expect(os.ReadFile(latency)).To(Equal([]byte("10")))
+		Expect(recordedContainers()).To(BeEmpty())
+		Expect(filepath.Join(config.TuningStateDir, "ctr1.json")).ToNot(BeAnExistingFile())
+	})
+
+	It("should only add back the CPUs removed from the IRQ affinity mask", func() {
+		mask := writeFile("default_smp_affinity", "fffffff0\n")
+		recordTuningWrite(context.TODO(), "ctr1", mask, "ffffffff", "fffffff3")
+
+		Expect(RestoreTuning(context.TODO(), config)).To(Succeed())
+
+		Expect(os.ReadFile(mask)).To(Equal([]byte("fffffffc")))
+	})
+
+	It("should skip the files which do not exist anymore", func() {
+		recordTuningWrite(context.TODO(), "ctr1", filepath.Join(dir, "cgroup", "cpuset.cpus.partition"), "member", "isolated")
+
+		Expect(RestoreTuning(context.TODO(), config)).To(Succeed())
+		Expect(recordedContainers()).To(BeEmpty())
+	})
+})
@@ -2,12 +2,18 @@ package runtimehandlerhooks
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"sync"
 
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/opencontainers/runtime-tools/generate"
 
 	"github.com/cri-o/cri-o/internal/lib/sandbox"
 	"github.com/cri-o/cri-o/internal/oci"
+	crioann "github.com/cri-o/cri-o/pkg/annotations"
 )
 
 var (
@@ -17,13 +23,154 @@ var (
 
 //nolint:iface // interface duplication is intentional
 type RuntimeHandlerHooks interface {
+	// PreCreate is run once the spec of the container is complete, before it is saved and
+	// the runtime creates the container, so the changes done to specgen are the ones the runtime uses.
 	PreCreate(ctx context.Context, specgen *generate.Generator, s *sandbox.Sandbox, c *oci.Container) error
 	PreStart(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error
 	PreStop(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error
 	PostStop(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error
+	// PreUpdate is run before the runtime updates the resources of the container to resources,
+	// while the spec of the container still holds the former ones.
+	PreUpdate(ctx context.Context, c *oci.Container, s *sandbox.Sandbox, resources *rspec.LinuxResources) error
+	// PostUpdate is run once the resources of the container got updated from former and recorded in its spec.
+	// It is also run if the update failed after PreUpdate, to re-apply what PreUpdate may have reverted.
+	PostUpdate(ctx context.Context, c *oci.Container, s *sandbox.Sandbox, former *rspec.LinuxResources) error
+	// PreCheckpoint is run before the container gets checkpointed, to capture its tuning into the checkpoint.
+	PreCheckpoint(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error
+	// PostRestore is run once the container got restored from a checkpoint, instead of PreStart.
+	PostRestore(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error
 }
 
-//nolint:iface // interface duplication is intentional
+// HighPerformanceHook extends the RuntimeHandlerHooks with operations specific
+// to the high-performance hooks, applied to containers which are already running.
 type HighPerformanceHook interface {
 	RuntimeHandlerHooks
+	// UpdateSharedCPUs reconciles a running container consuming the shared CPUs
+	// after the shared CPU pool changed from oldSharedCPUs and returns whether it got updated.
+	UpdateSharedCPUs(ctx context.Context, c *oci.Container, s *sandbox.Sandbox, oldSharedCPUs string) (bool, error)
+	// ReconcileCPULoadBalancing repairs the exclusive cpuset chain of a running container
+	// with the CPU load balancing disabled, which may have been rewritten while CRI-O was down.
+	ReconcileCPULoadBalancing(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error
+	// RepairTuningDrift compares the tuning recorded for a running container with the actual
+	// state of the node, repairs the differences and returns the tuned files found drifted.
+	RepairTuningDrift(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) ([]string, error)
+	// ReconcileTuning reconciles the tuning of a running container with the configuration
+	// reloaded on SIGHUP and returns the tuned files found drifted.
+	ReconcileTuning(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) ([]string, error)
+	// ReconcileRestoredTuning re-establishes the tuning of a running container restored on startup,
+	// which may have been lost while CRI-O was down, and returns the tuned files or features found lost.
+	ReconcileRestoredTuning(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) ([]string, error)
+}
+
+// SharedCPUsNotConfiguredError is returned when a container requests the shared CPUs
+// while no shared CPU pool is configured on the node.
+type SharedCPUsNotConfiguredError struct {
+	// Container is the name of the container requesting the shared CPUs.
+	Container string
+}
+
+func (e *SharedCPUsNotConfiguredError) Error() string {
+	return fmt.Sprintf("shared CPUs were requested for container %q but no shared_cpuset is configured on the node", e.Container)
+}
+
+// The machine-readable reasons of the rejections of the high-performance annotations.
+const (
+	// ReasonInvalidTuningAnnotation is the reason of the annotations with an invalid value.
+	ReasonInvalidTuningAnnotation = "InvalidTuningAnnotation"
+	// ReasonUnsupportedTuning is the reason of the annotations requesting a tuning the node does not support.
+	ReasonUnsupportedTuning = "UnsupportedTuning"
+	// ReasonTuningAnnotationNotAllowed is the reason of the annotations the pod is not allowed to use.
+	ReasonTuningAnnotationNotAllowed = "TuningAnnotationNotAllowed"
+	// ReasonSharedCPUsNotConfigured is the reason of the shared CPU requests on nodes without shared CPUs.
+	ReasonSharedCPUsNotConfigured = "SharedCPUsNotConfigured"
+)
+
+// AnnotationError is returned when a high-performance annotation of a pod can not be honored.
+type AnnotationError struct {
+	// Reason is ReasonInvalidTuningAnnotation or ReasonUnsupportedTuning.
+	Reason     string
+	Annotation string
+	Value      string
+	Err        error
+}
+
+func (e *AnnotationError) Error() string {
+	return fmt.Sprintf("annotation %s=%q: %v", e.Annotation, e.Value, e.Err)
+}
+
+func (e *AnnotationError) Unwrap() error {
+	return e.Err
+}
+
+// CgroupWriteError is returned when the hooks fail to write a cgroup of the container or pod.
+type CgroupWriteError struct {
+	// Path is the written cgroup file, or the cgroup when written through a cgroup manager.
+	Path string
+	// Transient is set if the write kept failing with an error expected to go away, like EBUSY
+	// while systemd re-creates the scope of the container, until the retries ran out.
+	Transient bool
+	Err       error
+}
+
+func (e *CgroupWriteError) Error() string {
+	if e.Transient {
+		return fmt.Sprintf("write cgroup %s: transient failure persisted after retries: %v", e.Path, e.Err)
+	}
+	return fmt.Sprintf("write cgroup %s: %v", e.Path, e.Err)
+}
+
+func (e *CgroupWriteError) Unwrap() error {
+	return e.Err
+}
+
+// NetNSSysctlBundle returns the sysctls of the bundle selected by the netns sysctl bundle annotation of the pod,
+// nil if none is selected. It returns an *AnnotationError if the bundle is not defined in bundles, or if the pod
+// shares the network namespace of the host, whose sysctls are not the pod's to set.
+func NetNSSysctlBundle(bundles map[string]map[string]string, annotations map[string]string, hostNetwork bool) (map[string]string, error) {
+	name, ok := annotations[crioann.NetNSSysctlBundleAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	bundle, ok := bundles[name]
+	if !ok {
+		return nil, &AnnotationError{
+			Reason:     ReasonInvalidTuningAnnotation,
+			Annotation: crioann.NetNSSysctlBundleAnnotation,
+			Value:      name,
+			Err:        errors.New("no such bundle in netns_sysctl_bundles"),
+		}
+	}
+	if hostNetwork {
+		return nil, &AnnotationError{
+			Reason:     ReasonUnsupportedTuning,
+			Annotation: crioann.NetNSSysctlBundleAnnotation,
+			Value:      name,
+			Err:        errors.New("the pod shares the network namespace of the host"),
+		}
+	}
+	return bundle, nil
+}
+
+// CheckSharedCPUsConfigured returns a *SharedCPUsNotConfiguredError if the container requests
+// the shared CPUs through the pod annotations while sharedCPUs is empty. An empty containerName
+// checks the requests of all the containers of the pod.
+func CheckSharedCPUsConfigured(annotations map[string]string, containerName, sharedCPUs string) error {
+	if sharedCPUs != "" {
+		return nil
+	}
+	var requesting []string
+	for k, v := range annotations {
+		name, ok := strings.CutPrefix(k, crioann.CPUSharedAnnotation+"/")
+		if !ok || v != "enable" {
+			continue
+		}
+		if containerName == "" || name == containerName {
+			requesting = append(requesting, name)
+		}
+	}
+	if len(requesting) == 0 {
+		return nil
+	}
+	slices.Sort(requesting)
+	return &SharedCPUsNotConfiguredError{Container: requesting[0]}
 }
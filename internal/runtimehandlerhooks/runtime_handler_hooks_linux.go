@@ -3,7 +3,6 @@ package runtimehandlerhooks
 import (
 	"context"
 	"strings"
-	"sync"
 
 	"github.com/cri-o/cri-o/internal/log"
 	crioann "github.com/cri-o/cri-o/pkg/annotations"
@@ -14,35 +13,143 @@ import (
 func GetRuntimeHandlerHooks(ctx context.Context, config *libconfig.Config, handler string, annotations map[string]string) (RuntimeHandlerHooks, error) {
 	ctx, span := log.StartSpan(ctx)
 	defer span.End()
+	hooks, err := withPluginHooks(config, handler, builtinRuntimeHandlerHooks(ctx, config, handler, annotations))
+	if err != nil {
+		return nil, err
+	}
+	return withTimeout(withSandboxLock(hooks), config.RuntimeHandlerHooksTimeout), nil
+}
+
+func builtinRuntimeHandlerHooks(ctx context.Context, config *libconfig.Config, handler string, annotations map[string]string) RuntimeHandlerHooks {
+	switch config.HighPerformancePolicyFor(handler) {
+	case libconfig.RuntimeTypeTuningDelegate:
+		// The tuning annotations of the pods are passed to the runtime on their creation, see VMTuningAnnotations.
+		return nil
+	case libconfig.RuntimeTypeTuningSkip:
+		if !highPerformanceAnnotationsSpecified(annotations) {
+			return nil
+		}
+		var runtimeType string
+		if runtime := config.RuntimeHandlerOrDefault(handler); runtime != nil {
+			runtimeType = runtime.RuntimeType
+		}
+		return &skippedTuningHooks{runtimeType: runtimeType}
+	}
+	if runtime := config.RuntimeHandlerOrDefault(handler); runtime != nil {
+		switch runtime.RuntimeHandlerHooks {
+		case libconfig.RuntimeHandlerHooksHighPerformance:
+			return newHighPerformanceHooks(config, handler)
+		case libconfig.RuntimeHandlerHooksDefault:
+			return &DefaultCPULoadBalanceHooks{}
+		case libconfig.RuntimeHandlerHooksNone:
+			return nil
+		}
+	}
 	if strings.Contains(handler, HighPerformance) {
 		log.Warnf(ctx, "The usage of the handler %q without adding high-performance feature annotations under allowed_annotations will be deprecated under 1.21", HighPerformance)
-		return &HighPerformanceHooks{irqBalanceConfigFile: config.IrqBalanceConfigFile, cpusetLock: sync.Mutex{}, sharedCPUs: config.SharedCPUSet}, nil
+		return newHighPerformanceHooks(config, handler)
 	}
 	if highPerformanceAnnotationsSpecified(annotations) {
 		log.Warnf(ctx, "The usage of the handler %q without adding high-performance feature annotations under allowed_annotations will be deprecated under 1.21", HighPerformance)
-		return &HighPerformanceHooks{irqBalanceConfigFile: config.IrqBalanceConfigFile, cpusetLock: sync.Mutex{}, sharedCPUs: config.SharedCPUSet}, nil
+		return newHighPerformanceHooks(config, handler)
 	}
 	if cpuLoadBalancingAllowed(config) {
-		return &DefaultCPULoadBalanceHooks{}, nil
+		return &DefaultCPULoadBalanceHooks{}
+	}
+
+	return nil
+}
+
+func newHighPerformanceHooks(config *libconfig.Config, handler string) *HighPerformanceHooks {
+	settings := config.HighPerformanceSettingsFor(handler)
+	h := &HighPerformanceHooks{
+		irqBalanceConfigFile: settings.IrqBalanceConfigFile,
+		sharedCPUs:           settings.SharedCPUSet,
+		housekeepingCPUs:     settings.HousekeepingCPUs,
+		disabled:             disabledFeaturesOf(&settings),
+		failOpen:             settings.FailOpen,
+		tunedConflict:        settings.TunedConflict,
+		dryRun:               settings.DryRun,
+	}
+	if runtime := config.RuntimeHandlerOrDefault(handler); runtime != nil {
+		h.isolatedCPUsEnvVar = runtime.IsolatedCPUsEnvVar
+		h.sharedCPUsEnvVar = runtime.SharedCPUsEnvVar
+	}
+	return h
+}
+
+func disabledFeaturesOf(settings *libconfig.HighPerformanceConfig) disabledFeatures {
+	return disabledFeatures{
+		cpuLoadBalancing: !settings.FeatureEnabled(libconfig.HighPerformanceFeatureCPULoadBalancing),
+		irqLoadBalancing: !settings.FeatureEnabled(libconfig.HighPerformanceFeatureIRQLoadBalancing),
+		cpuQuota:         !settings.FeatureEnabled(libconfig.HighPerformanceFeatureCPUQuota),
+		cStates:          !settings.FeatureEnabled(libconfig.HighPerformanceFeatureCPUCStates),
+		freqGovernor:     !settings.FeatureEnabled(libconfig.HighPerformanceFeatureCPUFreqGovernor),
+		sharedCPUs:       !settings.FeatureEnabled(libconfig.HighPerformanceFeatureSharedCPUs),
+		packetSteering:   !settings.FeatureEnabled(libconfig.HighPerformanceFeaturePacketSteering),
+		vfQueues:         !settings.FeatureEnabled(libconfig.HighPerformanceFeatureVFQueues),
+		arfs:             !settings.FeatureEnabled(libconfig.HighPerformanceFeatureARFS),
+		vfIRQAffinity:    !settings.FeatureEnabled(libconfig.HighPerformanceFeatureVFIRQAffinity),
+		afXDP:            !settings.FeatureEnabled(libconfig.HighPerformanceFeatureAFXDP),
+		napiAffinity:     !settings.FeatureEnabled(libconfig.HighPerformanceFeatureNAPIAffinity),
 	}
+}
 
-	return nil, nil
+// highPerformanceAnnotationPrefixes are the prefixes of the annotations requesting a high-performance tuning,
+// the per-container ones being suffixed with the container name.
+var highPerformanceAnnotationPrefixes = []string{
+	crioann.CPULoadBalancingAnnotation,
+	crioann.CPUQuotaAnnotation,
+	crioann.IRQLoadBalancingAnnotation,
+	crioann.CPUCStatesAnnotation,
+	crioann.CPUFreqGovernorAnnotation,
+	crioann.CPUSharedAnnotation,
+	crioann.CPUInitAffinityAnnotation,
+	crioann.PacketSteeringAnnotation,
+	crioann.VFQueuesAnnotation,
+	crioann.ARFSAnnotation,
+	crioann.VFIRQAffinityAnnotation,
+	crioann.AFXDPAnnotation,
+	crioann.NAPIAffinityAnnotation,
+	crioann.InterruptCoalescingAnnotation,
+	crioann.QdiscAnnotation,
+	crioann.NetdevBudgetAnnotation,
+	crioann.GROAnnotation,
+}
+
+func isHighPerformanceAnnotation(key string) bool {
+	for _, prefix := range highPerformanceAnnotationPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
 }
 
 func highPerformanceAnnotationsSpecified(annotations map[string]string) bool {
 	for k := range annotations {
-		if strings.HasPrefix(k, crioann.CPULoadBalancingAnnotation) ||
-			strings.HasPrefix(k, crioann.CPUQuotaAnnotation) ||
-			strings.HasPrefix(k, crioann.IRQLoadBalancingAnnotation) ||
-			strings.HasPrefix(k, crioann.CPUCStatesAnnotation) ||
-			strings.HasPrefix(k, crioann.CPUFreqGovernorAnnotation) ||
-			strings.HasPrefix(k, crioann.CPUSharedAnnotation) {
+		if isHighPerformanceAnnotation(k) {
 			return true
 		}
 	}
 	return false
 }
 
+// HighPerformanceAnnotations returns the annotations requesting a high-performance tuning, nil if there are none.
+func HighPerformanceAnnotations(annotations map[string]string) map[string]string {
+	var highPerformance map[string]string
+	for k, v := range annotations {
+		if !isHighPerformanceAnnotation(k) {
+			continue
+		}
+		if highPerformance == nil {
+			highPerformance = map[string]string{}
+		}
+		highPerformance[k] = v
+	}
+	return highPerformance
+}
+
 func cpuLoadBalancingAllowed(config *libconfig.Config) bool {
 	cpuLoadBalancingAllowedAnywhereOnce.Do(func() {
 		for _, runtime := range config.Runtimes {
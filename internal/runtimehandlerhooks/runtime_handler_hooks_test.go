@@ -0,0 +1,200 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	crioannotations "github.com/cri-o/cri-o/pkg/annotations"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+)
+
+var _ = Describe("CheckSharedCPUsConfigured", func() {
+	annotations := map[string]string{
+		crioannotations.CPUSharedAnnotation + "/ctr2": "enable",
+		crioannotations.CPUSharedAnnotation + "/ctr1": "enable",
+		crioannotations.CPUSharedAnnotation + "/ctr3": "disable",
+	}
+
+	It("should succeed if the shared CPUs are configured", func() {
+		Expect(CheckSharedCPUsConfigured(annotations, "", "0-1")).To(Succeed())
+	})
+
+	It("should succeed if no container requests the shared CPUs", func() {
+		Expect(CheckSharedCPUsConfigured(map[string]string{}, "", "")).To(Succeed())
+		Expect(CheckSharedCPUsConfigured(annotations, "ctr3", "")).To(Succeed())
+	})
+
+	It("should fail with a typed error for the pod", func() {
+		err := CheckSharedCPUsConfigured(annotations, "", "")
+
+		var sharedCPUsErr *SharedCPUsNotConfiguredError
+		Expect(err).To(BeAssignableToTypeOf(sharedCPUsErr))
+		Expect(err.(*SharedCPUsNotConfiguredError).Container).To(Equal("ctr1"))
+	})
+
+	It("should fail with a typed error for the container", func() {
+		err := CheckSharedCPUsConfigured(annotations, "ctr2", "")
+
+		Expect(err).To(MatchError(&SharedCPUsNotConfiguredError{Container: "ctr2"}))
+	})
+})
+
+var _ = Describe("NetNSSysctlBundle", func() {
+	bundles := map[string]map[string]string{
+		"low-latency": {"net.core.busy_poll": "50", "net.core.busy_read": "50"},
+	}
+
+	It("should return the sysctls of the selected bundle", func() {
+		sysctls, err := NetNSSysctlBundle(bundles, map[string]string{crioannotations.NetNSSysctlBundleAnnotation: "low-latency"}, false)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sysctls).To(Equal(bundles["low-latency"]))
+	})
+
+	It("should return nothing if the pod selects no bundle", func() {
+		sysctls, err := NetNSSysctlBundle(bundles, map[string]string{}, true)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sysctls).To(BeNil())
+	})
+
+	It("should reject an unknown bundle", func() {
+		_, err := NetNSSysctlBundle(bundles, map[string]string{crioannotations.NetNSSysctlBundleAnnotation: "turbo"}, false)
+
+		var annotationErr *AnnotationError
+		Expect(errors.As(err, &annotationErr)).To(BeTrue())
+		Expect(annotationErr.Reason).To(Equal(ReasonInvalidTuningAnnotation))
+	})
+
+	It("should reject a pod on the host network", func() {
+		_, err := NetNSSysctlBundle(bundles, map[string]string{crioannotations.NetNSSysctlBundleAnnotation: "low-latency"}, true)
+
+		var annotationErr *AnnotationError
+		Expect(errors.As(err, &annotationErr)).To(BeTrue())
+		Expect(annotationErr.Reason).To(Equal(ReasonUnsupportedTuning))
+	})
+})
+
+var _ = Describe("HighPerformanceAnnotations", func() {
+	It("should only keep the high-performance annotations", func() {
+		annotations := map[string]string{
+			crioannotations.CPULoadBalancingAnnotation:    "disable",
+			crioannotations.CPUSharedAnnotation + "/ctr1": "enable",
+			"io.kubernetes.pod.name":                      "pod",
+		}
+
+		Expect(HighPerformanceAnnotations(annotations)).To(Equal(map[string]string{
+			crioannotations.CPULoadBalancingAnnotation:    "disable",
+			crioannotations.CPUSharedAnnotation + "/ctr1": "enable",
+		}))
+		Expect(HighPerformanceAnnotations(map[string]string{"io.kubernetes.pod.name": "pod"})).To(BeNil())
+	})
+})
+
+var _ = Describe("GetRuntimeHandlerHooks", func() {
+	config := &libconfig.Config{}
+	config.SharedCPUSet = "0-1"
+	config.IrqBalanceConfigFile = "/etc/sysconfig/irqbalance"
+	config.DefaultRuntime = "crun"
+	config.Runtimes = libconfig.Runtimes{
+		"crun": {},
+		"tuned": {
+			RuntimeHandlerHooks:  libconfig.RuntimeHandlerHooksHighPerformance,
+			IrqBalanceConfigFile: "/etc/irqbalance.tuned",
+			SharedCPUSet:         "2-3",
+		},
+		"high-performance": {RuntimeHandlerHooks: libconfig.RuntimeHandlerHooksNone},
+		"balanced":         {RuntimeHandlerHooks: libconfig.RuntimeHandlerHooksDefault},
+		"kata": {
+			RuntimeHandlerHooks: libconfig.RuntimeHandlerHooksHighPerformance,
+			RuntimeType:         libconfig.RuntimeTypeVM,
+		},
+	}
+
+	It("should bind the hooks set configured for the runtime handler", func() {
+		hooks, err := GetRuntimeHandlerHooks(context.Background(), config, "tuned", nil)
+		Expect(err).ToNot(HaveOccurred())
+		hooks = unwrapHooks(hooks)
+		Expect(hooks).To(BeAssignableToTypeOf(&HighPerformanceHooks{}))
+		h := hooks.(*HighPerformanceHooks)
+		Expect(h.irqBalanceConfigFile).To(Equal("/etc/irqbalance.tuned"))
+		Expect(h.sharedCPUs).To(Equal("2-3"))
+
+		hooks, err = GetRuntimeHandlerHooks(context.Background(), config, "balanced", nil)
+		Expect(err).ToNot(HaveOccurred())
+		hooks = unwrapHooks(hooks)
+		Expect(hooks).To(BeAssignableToTypeOf(&DefaultCPULoadBalanceHooks{}))
+	})
+
+	It("should not fall back to the runtime handler name if the hooks are disabled", func() {
+		hooks, err := GetRuntimeHandlerHooks(context.Background(), config, "high-performance", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hooks).To(BeNil())
+	})
+
+	It("should not tune the node for the runtime handlers running VMs", func() {
+		hooks, err := GetRuntimeHandlerHooks(context.Background(), config, "kata", map[string]string{
+			crioannotations.CPULoadBalancingAnnotation: "disable",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hooks).To(BeNil())
+	})
+
+	It("should skip the tuning of the runtime types configured so", func() {
+		config := &libconfig.Config{}
+		config.Runtimes = libconfig.Runtimes{
+			"kata": {RuntimeType: libconfig.RuntimeTypeVM},
+		}
+		config.HighPerformance.RuntimeTypePolicies = map[string]string{
+			libconfig.RuntimeTypeVM: libconfig.RuntimeTypeTuningSkip,
+		}
+
+		hooks, err := GetRuntimeHandlerHooks(context.Background(), config, "kata", map[string]string{
+			crioannotations.CPULoadBalancingAnnotation: "disable",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(unwrapHooks(hooks)).To(Equal(&skippedTuningHooks{runtimeType: libconfig.RuntimeTypeVM}))
+
+		hooks, err = GetRuntimeHandlerHooks(context.Background(), config, "kata", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hooks).To(BeNil())
+	})
+
+	It("should use the global parameters when not overridden", func() {
+		hooks, err := GetRuntimeHandlerHooks(context.Background(), config, "", map[string]string{
+			crioannotations.CPUSharedAnnotation + "/ctr": "enable",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		hooks = unwrapHooks(hooks)
+		Expect(hooks).To(BeAssignableToTypeOf(&HighPerformanceHooks{}))
+		h := hooks.(*HighPerformanceHooks)
+		Expect(h.irqBalanceConfigFile).To(Equal("/etc/sysconfig/irqbalance"))
+		Expect(h.sharedCPUs).To(Equal("0-1"))
+		Expect(h.disabled).To(Equal(disabledFeatures{
+			cpuLoadBalancing: true,
+			irqLoadBalancing: true,
+			cpuQuota:         true,
+			cStates:          true,
+			freqGovernor:     true,
+			sharedCPUs:       true,
+		}))
+	})
+
+	It("should only disable the features turned off in the configuration", func() {
+		config := &libconfig.Config{}
+		config.HighPerformanceCPULoadBalancing = true
+		config.HighPerformanceIRQLoadBalancing = true
+		config.HighPerformanceCPUQuota = true
+		config.HighPerformanceCPUFreqGovernor = true
+		config.HighPerformanceSharedCPUs = true
+
+		hooks, err := GetRuntimeHandlerHooks(context.Background(), config, HighPerformance, nil)
+		Expect(err).ToNot(HaveOccurred())
+		hooks = unwrapHooks(hooks)
+		Expect(hooks).To(BeAssignableToTypeOf(&HighPerformanceHooks{}))
+		Expect(hooks.(*HighPerformanceHooks).disabled).To(Equal(disabledFeatures{cStates: true}))
+	})
+})
@@ -0,0 +1,20 @@
+//go:build test && linux
+
+// All *_inject.go files are meant to be used by tests only. Purpose of this
+// files is to provide a way to inject mocked data into the current setup.
+
+package runtimehandlerhooks
+
+import (
+	"context"
+)
+
+// SetAppliedTuning records the tuning of the exclusive cpus as applied to the container.
+func SetAppliedTuning(containerID, cpus string) {
+	recordAppliedTuning(context.Background(), containerID, &tuning{CPUs: cpus})
+}
+
+// ForgetAppliedTuning forgets the tuning applied to the container.
+func ForgetAppliedTuning(containerID string) {
+	forgetAppliedTuning(context.Background(), containerID)
+}
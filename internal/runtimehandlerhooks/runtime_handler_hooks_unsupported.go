@@ -4,9 +4,14 @@ package runtimehandlerhooks
 
 import (
 	"context"
+	"errors"
+	"io"
 
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
 	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/internal/oci"
 	libconfig "github.com/cri-o/cri-o/pkg/config"
+	crioTypes "github.com/cri-o/cri-o/pkg/types"
 )
 
 const (
@@ -17,10 +22,89 @@ const (
 func GetRuntimeHandlerHooks(ctx context.Context, config *libconfig.Config, handler string, annotations map[string]string) (RuntimeHandlerHooks, error) {
 	ctx, span := log.StartSpan(ctx)
 	defer span.End()
-	return &DefaultCPULoadBalanceHooks{}, nil
+	hooks, err := withPluginHooks(config, handler, &DefaultCPULoadBalanceHooks{})
+	if err != nil {
+		return nil, err
+	}
+	return withTimeout(hooks, config.RuntimeHandlerHooksTimeout), nil
+}
+
+// ValidateHighPerformanceAnnotations validates the high-performance annotations of the pod.
+func ValidateHighPerformanceAnnotations(config *libconfig.Config, handler string, annotations map[string]string, cpus string) error {
+	return nil
 }
 
 // RestoreIrqBalanceConfig restores irqbalance service with original banned cpu mask settings
 func RestoreIrqBalanceConfig(ctx context.Context, irqBalanceConfigFile, irqBannedCPUConfigFile, irqSmpAffinityProcFile string) error {
 	return nil
 }
+
+// RestoreTuning reverts the node tuning recorded in dir for all the containers.
+func RestoreTuning(ctx context.Context, config *libconfig.Config) error {
+	return nil
+}
+
+// LoadTuningStore loads the tuning records of the containers found in dir.
+func LoadTuningStore(ctx context.Context, dir string) error {
+	return nil
+}
+
+// OpenTuningAuditLog records every write of the hooks to the files of the node to path.
+func OpenTuningAuditLog(path string, sizeMax int64) error {
+	return nil
+}
+
+// EnableLinuxAudit reports the privileged tuning operations of the hooks to the Linux audit subsystem.
+func EnableLinuxAudit(enable bool) error {
+	return nil
+}
+
+// CheckTuningHealth checks the capability of the node to get tuned by the hooks.
+func CheckTuningHealth(config *libconfig.Config) []TuningHealthCheck {
+	return nil
+}
+
+// WriteContainerStateFiles writes the tuning of every tuned container to <dir>/<container-id>/tuning.json.
+func WriteContainerStateFiles(dir string) {}
+
+// RestoreContainerTuning restores the tuning record of the container from its state after a restart of CRI-O.
+func RestoreContainerTuning(ctx context.Context, c *oci.Container) {}
+
+// ReleaseStaleNodeSysctls releases the node sysctls held by the pods and containers which do not exist anymore.
+func ReleaseStaleNodeSysctls(ctx context.Context, exists func(id string) bool) {}
+
+// ContainerTuningDetails returns the details of the tuning applied to the container, nil if it did not get tuned.
+func ContainerTuningDetails(containerID string) *crioTypes.TuningDetails {
+	return nil
+}
+
+// StateTuningDetails returns the details of the tuning recorded in the state of the container, nil if it did not get tuned.
+func StateTuningDetails(c *oci.Container) *crioTypes.TuningDetails {
+	return nil
+}
+
+// IneffectiveTuning returns the tuned files of the container which do not hold its tuning anymore.
+func IneffectiveTuning(containerID string) ([]string, error) {
+	return nil, nil
+}
+
+// VerifyTuning re-validates the tuning applied to the container against the live state of the node.
+func VerifyTuning(containerID string) *TuningVerification {
+	return nil
+}
+
+// CurrentNodeTuningState returns the tuning of the node bookkept by the hooks.
+func CurrentNodeTuningState() *NodeTuningState {
+	return &NodeTuningState{}
+}
+
+// WriteDebugSnapshot writes to w an archive of the state of the container relevant to its tuning.
+func WriteDebugSnapshot(w io.Writer, c *oci.Container, s *sandbox.Sandbox) error {
+	return errors.New("debug snapshots are only supported on Linux")
+}
+
+// SampleIsolatedCPUsSchedstat counts the run delay of the isolated CPUs since the previous sample in the metrics.
+func SampleIsolatedCPUsSchedstat(ctx context.Context) {}
+
+// SampleTuningTelemetry samples the CPU telemetry of the containers tuned by the hooks into the metrics.
+func SampleTuningTelemetry(ctx context.Context) {}
@@ -0,0 +1,188 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"sync"
+
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/generate"
+
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	"github.com/cri-o/cri-o/internal/oci"
+)
+
+// keyedMutex holds a mutex per key, like a sandbox ID or a cgroup path, which is released once unused.
+// Locking can be canceled with the context, so that the timeout of the hooks also bounds the wait.
+type keyedMutex struct {
+	sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	ch   chan struct{}
+	refs int
+}
+
+func newKeyedMutex() *keyedMutex {
+	return &keyedMutex{locks: make(map[string]*keyedLock)}
+}
+
+// lock locks the mutex of the key until the returned function gets called, or returns
+// the error of the context if it is done first.
+func (m *keyedMutex) lock(ctx context.Context, key string) (unlock func(), err error) {
+	m.Lock()
+	l, ok := m.locks[key]
+	if !ok {
+		l = &keyedLock{ch: make(chan struct{}, 1)}
+		m.locks[key] = l
+	}
+	l.refs++
+	m.Unlock()
+
+	select {
+	case l.ch <- struct{}{}:
+		return func() {
+			<-l.ch
+			m.release(key, l)
+		}, nil
+	case <-ctx.Done():
+		m.release(key, l)
+		return nil, ctx.Err()
+	}
+}
+
+func (m *keyedMutex) release(key string, l *keyedLock) {
+	m.Lock()
+	defer m.Unlock()
+	l.refs--
+	if l.refs == 0 {
+		delete(m.locks, key)
+	}
+}
+
+// sandboxLocks serialize the hooks of the containers of a sandbox, keyed by sandbox ID. The hooks are
+// instantiated per request, so they are kept at package level.
+var sandboxLocks = newKeyedMutex()
+
+// cgroupLocks serialize the read-modify-write cycles of the cpuset files of a cgroup shared by several
+// sandboxes, like the one of the QoS class, keyed by cgroup path.
+var cgroupLocks = newKeyedMutex()
+
+// sandboxLockHooks runs the hooks of the containers of a sandbox one at a time, so that concurrent
+// requests for containers of the same pod do not interleave their changes of the pod cgroup.
+type sandboxLockHooks struct {
+	hooks RuntimeHandlerHooks
+}
+
+// withSandboxLock wraps the hooks with the lock of the sandbox, if any.
+func withSandboxLock(hooks RuntimeHandlerHooks) RuntimeHandlerHooks {
+	if hooks == nil {
+		return nil
+	}
+	return &sandboxLockHooks{hooks: hooks}
+}
+
+func (l *sandboxLockHooks) run(ctx context.Context, s *sandbox.Sandbox, hook func() error) error {
+	if s == nil {
+		return hook()
+	}
+	unlock, err := sandboxLocks.lock(ctx, s.ID())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return hook()
+}
+
+func (l *sandboxLockHooks) PreCreate(ctx context.Context, specgen *generate.Generator, s *sandbox.Sandbox, c *oci.Container) error {
+	return l.run(ctx, s, func() error {
+		return l.hooks.PreCreate(ctx, specgen, s, c)
+	})
+}
+
+func (l *sandboxLockHooks) PreStart(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	return l.run(ctx, s, func() error {
+		return l.hooks.PreStart(ctx, c, s)
+	})
+}
+
+func (l *sandboxLockHooks) PreStop(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	return l.run(ctx, s, func() error {
+		return l.hooks.PreStop(ctx, c, s)
+	})
+}
+
+func (l *sandboxLockHooks) PostStop(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	return l.run(ctx, s, func() error {
+		return l.hooks.PostStop(ctx, c, s)
+	})
+}
+
+func (l *sandboxLockHooks) PreUpdate(ctx context.Context, c *oci.Container, s *sandbox.Sandbox, resources *rspec.LinuxResources) error {
+	return l.run(ctx, s, func() error {
+		return l.hooks.PreUpdate(ctx, c, s, resources)
+	})
+}
+
+func (l *sandboxLockHooks) PostUpdate(ctx context.Context, c *oci.Container, s *sandbox.Sandbox, former *rspec.LinuxResources) error {
+	return l.run(ctx, s, func() error {
+		return l.hooks.PostUpdate(ctx, c, s, former)
+	})
+}
+
+func (l *sandboxLockHooks) PreCheckpoint(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	return l.run(ctx, s, func() error {
+		return l.hooks.PreCheckpoint(ctx, c, s)
+	})
+}
+
+func (l *sandboxLockHooks) PostRestore(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	return l.run(ctx, s, func() error {
+		return l.hooks.PostRestore(ctx, c, s)
+	})
+}
+
+// sandboxLockHighPerformanceHook runs the high-performance hooks reconciling the running containers
+// under the lock of their sandbox too, so that they do not race with the hooks of its other containers.
+type sandboxLockHighPerformanceHook struct {
+	sandboxLockHooks
+	highPerformance HighPerformanceHook
+}
+
+func (l *sandboxLockHighPerformanceHook) UpdateSharedCPUs(ctx context.Context, c *oci.Container, s *sandbox.Sandbox, oldSharedCPUs string) (updated bool, err error) {
+	err = l.run(ctx, s, func() error {
+		updated, err = l.highPerformance.UpdateSharedCPUs(ctx, c, s, oldSharedCPUs)
+		return err
+	})
+	return updated, err
+}
+
+func (l *sandboxLockHighPerformanceHook) ReconcileCPULoadBalancing(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	return l.run(ctx, s, func() error {
+		return l.highPerformance.ReconcileCPULoadBalancing(ctx, c, s)
+	})
+}
+
+func (l *sandboxLockHighPerformanceHook) RepairTuningDrift(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) (drifted []string, err error) {
+	err = l.run(ctx, s, func() error {
+		drifted, err = l.highPerformance.RepairTuningDrift(ctx, c, s)
+		return err
+	})
+	return drifted, err
+}
+
+func (l *sandboxLockHighPerformanceHook) ReconcileTuning(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) (drifted []string, err error) {
+	err = l.run(ctx, s, func() error {
+		drifted, err = l.highPerformance.ReconcileTuning(ctx, c, s)
+		return err
+	})
+	return drifted, err
+}
+
+func (l *sandboxLockHighPerformanceHook) ReconcileRestoredTuning(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) (lost []string, err error) {
+	err = l.run(ctx, s, func() error {
+		lost, err = l.highPerformance.ReconcileRestoredTuning(ctx, c, s)
+		return err
+	})
+	return lost, err
+}
@@ -0,0 +1,106 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	"github.com/cri-o/cri-o/internal/oci"
+)
+
+// concurrentHooks counts the PreStart hooks running at the same time.
+type concurrentHooks struct {
+	orderedHooks
+	running, maxRunning atomic.Int32
+}
+
+func (h *concurrentHooks) PreStart(context.Context, *oci.Container, *sandbox.Sandbox) error {
+	n := h.running.Add(1)
+	defer h.running.Add(-1)
+	for {
+		old := h.maxRunning.Load()
+		if n <= old || h.maxRunning.CompareAndSwap(old, n) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond)
+	return nil
+}
+
+var _ = Describe("sandboxLockHooks", func() {
+	c := newTestContainer("containerID", "cnt1", "sandboxID")
+
+	runConcurrently := func(hooks RuntimeHandlerHooks, sandboxes ...*sandbox.Sandbox) {
+		var wg sync.WaitGroup
+		for _, sb := range sandboxes {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				Expect(hooks.PreStart(context.Background(), c, sb)).To(Succeed())
+			}()
+		}
+		wg.Wait()
+	}
+
+	It("should run the hooks of the containers of a sandbox one at a time", func() {
+		inner := &concurrentHooks{}
+		sb := newTestSandbox("sandbox1", nil)
+
+		runConcurrently(withSandboxLock(inner), sb, sb, sb, sb, sb, sb, sb, sb)
+
+		Expect(inner.maxRunning.Load()).To(BeEquivalentTo(1))
+		Expect(sandboxLocks.locks).To(BeEmpty())
+	})
+
+	It("should not block the hooks of the other sandboxes", func() {
+		hooks := withSandboxLock(&concurrentHooks{})
+		unlock, err := sandboxLocks.lock(context.Background(), "sandbox1")
+		Expect(err).ToNot(HaveOccurred())
+		defer unlock()
+
+		runConcurrently(hooks, newTestSandbox("sandbox2", nil), newTestSandbox("sandbox3", nil))
+	})
+
+	It("should stop waiting for the lock once the context is done", func() {
+		unlock, err := cgroupLocks.lock(context.Background(), "/sys/fs/cgroup/kubepods.slice")
+		Expect(err).ToNot(HaveOccurred())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err = cgroupLocks.lock(ctx, "/sys/fs/cgroup/kubepods.slice")
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+
+		unlock()
+		Expect(cgroupLocks.locks).To(BeEmpty())
+	})
+
+	It("should reconcile the running containers of a sandbox under its lock", func() {
+		hooks, ok := AsHighPerformanceHook(withTimeout(withSandboxLock(&HighPerformanceHooks{}), time.Minute))
+		Expect(ok).To(BeTrue())
+		sb := newTestSandbox("sandbox1", nil)
+		unlock, err := sandboxLocks.lock(context.Background(), sb.ID())
+		Expect(err).ToNot(HaveOccurred())
+		defer unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err = hooks.UpdateSharedCPUs(ctx, c, sb, "")
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+		_, err = hooks.RepairTuningDrift(ctx, c, sb)
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+		_, err = hooks.ReconcileTuning(ctx, c, sb)
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+		_, err = hooks.ReconcileRestoredTuning(ctx, c, sb)
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+	})
+
+	It("should not wrap missing hooks", func() {
+		Expect(withSandboxLock(nil)).To(BeNil())
+	})
+})
@@ -0,0 +1,102 @@
+package runtimehandlerhooks
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/server/metrics"
+)
+
+// procSchedstat is the file holding the scheduler statistics of every CPU.
+const procSchedstat = "/proc/schedstat"
+
+// cpuSchedstat are the cumulative scheduler statistics of a CPU.
+type cpuSchedstat struct {
+	// runDelay is the time spent by the tasks waiting to run on the CPU.
+	runDelay time.Duration
+	// timeslices is the amount of time slices run on the CPU.
+	timeslices uint64
+}
+
+// isolatedCPUSchedstats are the scheduler statistics of the isolated CPUs at their last sample, keyed by CPU,
+// to count the run delay since then. The hooks are instantiated per request, so they are kept at package level.
+var isolatedCPUSchedstats = struct {
+	sync.Mutex
+	cpus map[int]cpuSchedstat
+}{cpus: make(map[int]cpuSchedstat)}
+
+// SampleIsolatedCPUsSchedstat counts the time spent by the tasks waiting to run on the isolated CPUs and the
+// time slices run on them since the previous sample in the metrics. The CPUs which got isolated since then
+// start being counted from the next sample, and the ones which are not isolated anymore stop being exported.
+func SampleIsolatedCPUsSchedstat(ctx context.Context) {
+	allocation, _ := currentCPUAllocation()
+	content, err := hostFS.ReadFile(procSchedstat)
+	if err != nil {
+		log.Warnf(ctx, "Unable to read the scheduler statistics: %v", err)
+		return
+	}
+	stats, err := parseSchedstat(content)
+	if err != nil {
+		log.Warnf(ctx, "Unable to parse the scheduler statistics: %v", err)
+		return
+	}
+
+	isolatedCPUSchedstats.Lock()
+	defer isolatedCPUSchedstats.Unlock()
+	for cpu := range isolatedCPUSchedstats.cpus {
+		if !allocation.isolated.Contains(cpu) {
+			delete(isolatedCPUSchedstats.cpus, cpu)
+			metrics.Instance().MetricTuningIsolatedCPUSchedstatDelete(strconv.Itoa(cpu))
+		}
+	}
+	for _, cpu := range allocation.isolated.List() {
+		current, ok := stats[cpu]
+		if !ok {
+			continue
+		}
+		// The statistics restart from zero if the CPU went offline in between.
+		if former, ok := isolatedCPUSchedstats.cpus[cpu]; ok && current.runDelay >= former.runDelay && current.timeslices >= former.timeslices {
+			metrics.Instance().MetricTuningIsolatedCPURunDelayAdd(strconv.Itoa(cpu), (current.runDelay - former.runDelay).Seconds())
+			metrics.Instance().MetricTuningIsolatedCPUTimeslicesAdd(strconv.Itoa(cpu), float64(current.timeslices-former.timeslices))
+		}
+		isolatedCPUSchedstats.cpus[cpu] = current
+	}
+}
+
+// parseSchedstat returns the statistics of the CPUs found in the content of /proc/schedstat, keyed by CPU.
+// The run delay and the time slices are the 8th and 9th fields of the lines of the CPUs, like
+// "cpu0 0 0 100 50 60 30 2000000 1000000 40".
+func parseSchedstat(content []byte) (map[int]cpuSchedstat, error) {
+	stats := map[int]cpuSchedstat{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || !strings.HasPrefix(fields[0], "cpu") {
+			continue
+		}
+		cpu, err := strconv.Atoi(strings.TrimPrefix(fields[0], "cpu"))
+		if err != nil {
+			continue
+		}
+		if len(fields) < 10 {
+			return nil, fmt.Errorf("unexpected statistics of cpu %d: %q", cpu, scanner.Text())
+		}
+		runDelay, err := strconv.ParseUint(fields[8], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse run delay of cpu %d: %w", cpu, err)
+		}
+		timeslices, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse time slices of cpu %d: %w", cpu, err)
+		}
+		stats[cpu] = cpuSchedstat{runDelay: time.Duration(runDelay), timeslices: timeslices}
+	}
+	return stats, scanner.Err()
+}
@@ -0,0 +1,58 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SampleIsolatedCPUsSchedstat", func() {
+	const schedstat = `version 15
+timestamp 4295892033
+cpu0 0 0 100 50 60 30 2000000 1000000 40
+domain0 00000003 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0
+cpu1 0 0 200 80 90 60 4000000 3000000 70
+`
+	var fake *fakeHostFS
+
+	BeforeEach(func() {
+		fake = useFakeHostFS(map[string]string{
+			sysCPUDir + "/online": "0-1\n",
+			procSchedstat:         schedstat,
+		})
+		DeferCleanup(func() {
+			forgetAppliedTuning(context.TODO(), "ctr1")
+			isolatedCPUSchedstats.Lock()
+			clear(isolatedCPUSchedstats.cpus)
+			isolatedCPUSchedstats.Unlock()
+		})
+	})
+
+	It("should parse the run delay and time slices of every CPU", func() {
+		Expect(parseSchedstat([]byte(schedstat))).To(Equal(map[int]cpuSchedstat{
+			0: {runDelay: time.Millisecond, timeslices: 40},
+			1: {runDelay: 3 * time.Millisecond, timeslices: 70},
+		}))
+		_, err := parseSchedstat([]byte("cpu0 0 0 100\n"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should only sample the isolated CPUs", func() {
+		recordAppliedTuning(context.TODO(), "ctr1", &tuning{CPUs: "1", CPULoadBalancingDisabled: true})
+
+		SampleIsolatedCPUsSchedstat(context.TODO())
+		Expect(isolatedCPUSchedstats.cpus).To(Equal(map[int]cpuSchedstat{
+			1: {runDelay: 3 * time.Millisecond, timeslices: 70},
+		}))
+
+		Expect(fake.WriteFile(procSchedstat, []byte("cpu1 0 0 200 80 90 60 4000000 5000000 90\n"), 0o444)).To(Succeed())
+		SampleIsolatedCPUsSchedstat(context.TODO())
+		Expect(isolatedCPUSchedstats.cpus).To(HaveKeyWithValue(1, cpuSchedstat{runDelay: 5 * time.Millisecond, timeslices: 90}))
+
+		forgetAppliedTuning(context.TODO(), "ctr1")
+		SampleIsolatedCPUsSchedstat(context.TODO())
+		Expect(isolatedCPUSchedstats.cpus).To(BeEmpty())
+	})
+})
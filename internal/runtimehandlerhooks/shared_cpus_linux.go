@@ -0,0 +1,80 @@
+package runtimehandlerhooks
+
+import (
+	"sync"
+
+	"k8s.io/utils/cpuset"
+)
+
+// sharedCPUsConsumers tracks, per sandbox, the containers consuming the shared CPUs.
+// All the consumers of a pod run on the same shared pool, so the pod CFS quota has to
+// account for the pool once, no matter how many of its containers requested it.
+// The hooks are instantiated per request, so the bookkeeping is kept at package level.
+var sharedCPUsConsumers = struct {
+	sync.Mutex
+	sandboxes map[string]*sandboxSharedCPUs
+}{sandboxes: make(map[string]*sandboxSharedCPUs)}
+
+type sandboxSharedCPUs struct {
+	// sharedCPUs is the shared CPU pool accounted for in the pod quota.
+	sharedCPUs cpuset.CPUSet
+	// containers holds the exclusive CPUs of every consumer, keyed by container ID.
+	containers map[string]cpuset.CPUSet
+}
+
+// addSharedCPUsConsumer registers the container as a consumer of the shared CPUs of its sandbox.
+// It returns true if the container is the first consumer of the sandbox, meaning the shared CPUs
+// are not yet accounted for in the pod quota.
+func addSharedCPUsConsumer(sandboxID, containerID string, exclusiveCPUs, sharedCPUs cpuset.CPUSet) (first bool) {
+	defer reportNodeCPUAllocation()
+	sharedCPUsConsumers.Lock()
+	defer sharedCPUsConsumers.Unlock()
+	sb, ok := sharedCPUsConsumers.sandboxes[sandboxID]
+	if !ok {
+		sb = &sandboxSharedCPUs{
+			sharedCPUs: sharedCPUs,
+			containers: make(map[string]cpuset.CPUSet),
+		}
+		sharedCPUsConsumers.sandboxes[sandboxID] = sb
+	}
+	sb.containers[containerID] = exclusiveCPUs
+	return !ok
+}
+
+// removeSharedCPUsConsumer unregisters the container as a consumer of the shared CPUs of its sandbox.
+// It returns the shared CPUs accounted for in the pod quota and true if the container was the last consumer,
+// in which case the caller is responsible for removing them from the pod quota.
+func removeSharedCPUsConsumer(sandboxID, containerID string) (sharedCPUs cpuset.CPUSet, last bool) {
+	defer reportNodeCPUAllocation()
+	sharedCPUsConsumers.Lock()
+	defer sharedCPUsConsumers.Unlock()
+	sb, ok := sharedCPUsConsumers.sandboxes[sandboxID]
+	if !ok {
+		return cpuset.New(), false
+	}
+	if _, ok := sb.containers[containerID]; !ok {
+		return cpuset.New(), false
+	}
+	delete(sb.containers, containerID)
+	if len(sb.containers) > 0 {
+		return cpuset.New(), false
+	}
+	delete(sharedCPUsConsumers.sandboxes, sandboxID)
+	return sb.sharedCPUs, true
+}
+
+// updateSandboxSharedCPUs records the shared CPU pool accounted for in the pod quota of the sandbox.
+// It returns the formerly accounted pool and true if the pod quota has to be updated,
+// which only happens for the first consumer of the sandbox being reconciled.
+func updateSandboxSharedCPUs(sandboxID string, sharedCPUs cpuset.CPUSet) (former cpuset.CPUSet, changed bool) {
+	defer reportNodeCPUAllocation()
+	sharedCPUsConsumers.Lock()
+	defer sharedCPUsConsumers.Unlock()
+	sb, ok := sharedCPUsConsumers.sandboxes[sandboxID]
+	if !ok || sb.sharedCPUs.Equals(sharedCPUs) {
+		return cpuset.New(), false
+	}
+	former = sb.sharedCPUs
+	sb.sharedCPUs = sharedCPUs
+	return former, true
+}
@@ -0,0 +1,92 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"k8s.io/utils/cpuset"
+
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	crioannotations "github.com/cri-o/cri-o/pkg/annotations"
+)
+
+var _ = Describe("sharedCPUsConsumers", func() {
+	const sandboxID = "sandbox"
+	sharedCPUs := cpuset.New(0, 1)
+
+	AfterEach(func() {
+		sharedCPUsConsumers.Lock()
+		delete(sharedCPUsConsumers.sandboxes, sandboxID)
+		sharedCPUsConsumers.Unlock()
+	})
+
+	It("should account for the shared CPUs once per sandbox", func() {
+		Expect(addSharedCPUsConsumer(sandboxID, "ctr1", cpuset.New(2, 3), sharedCPUs)).To(BeTrue())
+		Expect(addSharedCPUsConsumer(sandboxID, "ctr2", cpuset.New(4, 5), sharedCPUs)).To(BeFalse())
+
+		_, last := removeSharedCPUsConsumer(sandboxID, "ctr1")
+		Expect(last).To(BeFalse())
+		accounted, last := removeSharedCPUsConsumer(sandboxID, "ctr2")
+		Expect(last).To(BeTrue())
+		Expect(accounted.Equals(sharedCPUs)).To(BeTrue())
+
+		Expect(addSharedCPUsConsumer(sandboxID, "ctr3", cpuset.New(2, 3), sharedCPUs)).To(BeTrue())
+	})
+
+	It("should ignore unknown consumers on removal", func() {
+		Expect(addSharedCPUsConsumer(sandboxID, "ctr1", cpuset.New(2, 3), sharedCPUs)).To(BeTrue())
+
+		_, last := removeSharedCPUsConsumer(sandboxID, "unknown")
+		Expect(last).To(BeFalse())
+		_, last = removeSharedCPUsConsumer("unknown", "ctr1")
+		Expect(last).To(BeFalse())
+	})
+
+	It("should update the accounted pool once per sandbox", func() {
+		newSharedCPUs := cpuset.New(0, 1, 6)
+		addSharedCPUsConsumer(sandboxID, "ctr1", cpuset.New(2, 3), sharedCPUs)
+		addSharedCPUsConsumer(sandboxID, "ctr2", cpuset.New(4, 5), sharedCPUs)
+
+		former, changed := updateSandboxSharedCPUs(sandboxID, newSharedCPUs)
+		Expect(changed).To(BeTrue())
+		Expect(former.Equals(sharedCPUs)).To(BeTrue())
+
+		_, changed = updateSandboxSharedCPUs(sandboxID, newSharedCPUs)
+		Expect(changed).To(BeFalse())
+	})
+
+	It("should restore the consumers with the shared CPU pool the sandbox got created with", func() {
+		c := newTestContainer("ctr1", "cnt1", sandboxID)
+		c.SetSpec(&specs.Spec{Linux: &specs.Linux{Resources: &specs.LinuxResources{
+			CPU: &specs.LinuxCPU{Cpus: "2-3"},
+		}}})
+		annotations := map[string]string{crioannotations.CPUSharedAnnotation + "/cnt1": annotationEnable}
+		sb := newTestSandbox(sandboxID, annotations, func(sbox sandbox.Builder) {
+			sbox.SetHighPerformance(&sandbox.HighPerformance{Annotations: annotations, SharedCPUs: sharedCPUs.String()})
+		})
+
+		Expect(restoreSharedCPUsConsumer(context.TODO(), c, sb, "4-5")).To(Succeed())
+
+		Expect(containerSharedCPUs("ctr1").Equals(sharedCPUs)).To(BeTrue())
+		Expect(addSharedCPUsConsumer(sandboxID, "ctr2", cpuset.New(6, 7), sharedCPUs)).To(BeFalse())
+	})
+
+	It("should only update the containers requesting the shared CPUs when the sandbox got created", func() {
+		c := newTestContainer("ctr1", "cnt1", sandboxID)
+		c.SetSpec(&specs.Spec{Linux: &specs.Linux{Resources: &specs.LinuxResources{
+			CPU: &specs.LinuxCPU{Cpus: "2-3"},
+		}}})
+		sb := newTestSandbox(sandboxID, map[string]string{
+			crioannotations.CPUSharedAnnotation + "/cnt1": annotationEnable,
+		}, func(sbox sandbox.Builder) {
+			sbox.SetHighPerformance(&sandbox.HighPerformance{Annotations: map[string]string{}})
+		})
+
+		h := &HighPerformanceHooks{sharedCPUs: "0-1,6"}
+		updated, err := h.UpdateSharedCPUs(context.TODO(), c, sb, sharedCPUs.String())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(updated).To(BeFalse())
+	})
+})
@@ -0,0 +1,91 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/generate"
+
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/internal/oci"
+)
+
+// skippedTuningHooks are the hooks of the runtime handlers whose runtime type is configured to skip the tuning.
+// They do not touch the node, but report the tuning requested for a container as skipped in its status.
+type skippedTuningHooks struct {
+	runtimeType string
+}
+
+// Contract of the skipped tuning hooks, which do not touch anything.
+func (*skippedTuningHooks) Contract() HookContract {
+	return HookContract{}
+}
+
+// No-op.
+func (*skippedTuningHooks) PreCreate(context.Context, *generate.Generator, *sandbox.Sandbox, *oci.Container) error {
+	return nil
+}
+
+func (h *skippedTuningHooks) PreStart(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	h.reportSkipped(withHookStage(withHookContainer(ctx, c), "PreStart"), c, s)
+	return nil
+}
+
+// No-op.
+func (*skippedTuningHooks) PreStop(context.Context, *oci.Container, *sandbox.Sandbox) error {
+	return nil
+}
+
+// No-op.
+func (*skippedTuningHooks) PostStop(context.Context, *oci.Container, *sandbox.Sandbox) error {
+	return nil
+}
+
+// No-op.
+func (*skippedTuningHooks) PreUpdate(context.Context, *oci.Container, *sandbox.Sandbox, *rspec.LinuxResources) error {
+	return nil
+}
+
+// No-op.
+func (*skippedTuningHooks) PostUpdate(context.Context, *oci.Container, *sandbox.Sandbox, *rspec.LinuxResources) error {
+	return nil
+}
+
+// No-op.
+func (*skippedTuningHooks) PreCheckpoint(context.Context, *oci.Container, *sandbox.Sandbox) error {
+	return nil
+}
+
+func (h *skippedTuningHooks) PostRestore(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	h.reportSkipped(withHookStage(withHookContainer(ctx, c), "PostRestore"), c, s)
+	return nil
+}
+
+// reportSkipped reports the tuning annotations applying to the container as skipped, if any.
+func (h *skippedTuningHooks) reportSkipped(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) {
+	if s == nil {
+		return
+	}
+	var skipped []string
+	for _, key := range slices.Sorted(maps.Keys(HighPerformanceAnnotations(s.Annotations()))) {
+		_, container, perContainer := strings.Cut(key, "/")
+		if perContainer && container != c.CRIContainer().GetMetadata().GetName() {
+			continue
+		}
+		skipped = append(skipped, key)
+	}
+	if len(skipped) == 0 {
+		return
+	}
+	log.Warnf(ctx, "Skipping the tuning of container %q for runtime type %q: %s", c.ID(), h.runtimeType, strings.Join(skipped, ", "))
+	ctx, outcome := withTuningOutcome(ctx)
+	for _, annotation := range skipped {
+		noteUnfulfilledAnnotation(ctx, annotation, ReasonRuntimeTypeNotTuned)
+	}
+	reportSkippedTuning(ctx, c.ID(), outcome, fmt.Sprintf("tuning is skipped for the runtime handlers of the %q type", h.runtimeType))
+}
@@ -0,0 +1,46 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	crioann "github.com/cri-o/cri-o/pkg/annotations"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+)
+
+var _ = Describe("skippedTuningHooks", func() {
+	c := newTestContainer("skippedID", "cnt1", "sandboxID")
+
+	AfterEach(func() {
+		ForgetTuningStatus(c.ID())
+	})
+
+	It("should report the tuning requested for the container as skipped", func() {
+		sb := newTestSandbox("sandboxID", map[string]string{
+			crioann.CPULoadBalancingAnnotation:    "disable",
+			crioann.CPUSharedAnnotation + "/cnt1": "enable",
+			crioann.CPUSharedAnnotation + "/cnt2": "enable",
+		})
+
+		h := &skippedTuningHooks{runtimeType: libconfig.RuntimeTypeVM}
+		Expect(h.PreStart(context.TODO(), c, sb)).To(Succeed())
+
+		status := TuningStatusAnnotations(c.ID())
+		Expect(status).To(HaveKeyWithValue(crioann.TuningState, string(TuningStateSkipped)))
+		Expect(status).To(HaveKeyWithValue(crioann.TuningReason, ReasonRuntimeTypeNotTuned))
+		Expect(status).To(HaveKeyWithValue(crioann.TuningUnfulfilled,
+			crioann.CPULoadBalancingAnnotation+"="+ReasonRuntimeTypeNotTuned+","+
+				crioann.CPUSharedAnnotation+"/cnt1="+ReasonRuntimeTypeNotTuned))
+	})
+
+	It("should not report anything without requested tuning", func() {
+		sb := newTestSandbox("sandboxID", map[string]string{crioann.CPUSharedAnnotation + "/cnt2": "enable"})
+
+		h := &skippedTuningHooks{runtimeType: libconfig.RuntimeTypeVM}
+		Expect(h.PreStart(context.TODO(), c, sb)).To(Succeed())
+
+		Expect(TuningStatusAnnotations(c.ID())).To(BeNil())
+	})
+})
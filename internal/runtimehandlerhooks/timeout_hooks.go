@@ -0,0 +1,89 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-tools/generate"
+
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	"github.com/cri-o/cri-o/internal/oci"
+)
+
+// timeoutHooks runs the hooks under a context expiring after the configured timeout.
+// The file writes and commands of the hooks are canceled with the context, so a hung
+// sysfs write or irqbalance restart does not block the CRI request indefinitely.
+type timeoutHooks struct {
+	hooks   RuntimeHandlerHooks
+	timeout time.Duration
+}
+
+// withTimeout wraps the hooks with the timeout, if any.
+func withTimeout(hooks RuntimeHandlerHooks, timeout time.Duration) RuntimeHandlerHooks {
+	if hooks == nil || timeout <= 0 {
+		return hooks
+	}
+	return &timeoutHooks{hooks: hooks, timeout: timeout}
+}
+
+func (t *timeoutHooks) run(ctx context.Context, stage string, c *oci.Container, hook func(context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	if err := hook(ctx); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%s hook of container %q did not complete within %s: %w", stage, c.ID(), t.timeout, err)
+		}
+		return err
+	}
+	return nil
+}
+
+func (t *timeoutHooks) PreCreate(ctx context.Context, specgen *generate.Generator, s *sandbox.Sandbox, c *oci.Container) error {
+	return t.run(ctx, "PreCreate", c, func(ctx context.Context) error {
+		return t.hooks.PreCreate(ctx, specgen, s, c)
+	})
+}
+
+func (t *timeoutHooks) PreStart(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	return t.run(ctx, "PreStart", c, func(ctx context.Context) error {
+		return t.hooks.PreStart(ctx, c, s)
+	})
+}
+
+func (t *timeoutHooks) PreStop(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	return t.run(ctx, "PreStop", c, func(ctx context.Context) error {
+		return t.hooks.PreStop(ctx, c, s)
+	})
+}
+
+func (t *timeoutHooks) PostStop(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	return t.run(ctx, "PostStop", c, func(ctx context.Context) error {
+		return t.hooks.PostStop(ctx, c, s)
+	})
+}
+
+func (t *timeoutHooks) PreUpdate(ctx context.Context, c *oci.Container, s *sandbox.Sandbox, resources *rspec.LinuxResources) error {
+	return t.run(ctx, "PreUpdate", c, func(ctx context.Context) error {
+		return t.hooks.PreUpdate(ctx, c, s, resources)
+	})
+}
+
+func (t *timeoutHooks) PostUpdate(ctx context.Context, c *oci.Container, s *sandbox.Sandbox, former *rspec.LinuxResources) error {
+	return t.run(ctx, "PostUpdate", c, func(ctx context.Context) error {
+		return t.hooks.PostUpdate(ctx, c, s, former)
+	})
+}
+
+func (t *timeoutHooks) PreCheckpoint(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	return t.run(ctx, "PreCheckpoint", c, func(ctx context.Context) error {
+		return t.hooks.PreCheckpoint(ctx, c, s)
+	})
+}
+
+func (t *timeoutHooks) PostRestore(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	return t.run(ctx, "PostRestore", c, func(ctx context.Context) error {
+		return t.hooks.PostRestore(ctx, c, s)
+	})
+}
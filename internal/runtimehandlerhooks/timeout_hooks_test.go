@@ -0,0 +1,100 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	"github.com/cri-o/cri-o/internal/oci"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+)
+
+// blockingHooks blocks in PreStart until the context of the hook is done.
+type blockingHooks struct {
+	orderedHooks
+}
+
+func (b *blockingHooks) PreStart(ctx context.Context, _ *oci.Container, _ *sandbox.Sandbox) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// blockingHostFS blocks the writes of the files until released, like a hung write to sysfs.
+type blockingHostFS struct {
+	*fakeHostFS
+	release chan struct{}
+}
+
+func (b *blockingHostFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	<-b.release
+	return b.fakeHostFS.WriteFile(name, data, perm)
+}
+
+var _ = Describe("timeoutHooks", func() {
+	c := newTestContainer("containerID", "cnt1", "sandboxID")
+
+	It("should cancel the hooks running past the timeout", func() {
+		var calls []string
+		hooks := withTimeout(&blockingHooks{orderedHooks{name: "builtin", log: &calls}}, 10*time.Millisecond)
+
+		err := hooks.PreStart(context.Background(), c, nil)
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+		Expect(err).To(MatchError(ContainSubstring("did not complete within 10ms")))
+
+		Expect(hooks.PreStop(context.Background(), c, nil)).To(Succeed())
+		Expect(calls).To(Equal([]string{"builtin PreStop"}))
+	})
+
+	It("should not wrap the hooks without timeout", func() {
+		builtin := &orderedHooks{}
+		Expect(withTimeout(builtin, 0)).To(BeIdenticalTo(builtin))
+		Expect(withTimeout(nil, time.Minute)).To(BeNil())
+	})
+
+	It("should wrap the hooks of the runtime handlers with the configured timeout", func() {
+		config := &libconfig.Config{}
+		config.RuntimeHandlerHooksTimeout = time.Minute
+
+		hooks, err := GetRuntimeHandlerHooks(context.Background(), config, HighPerformance, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hooks).To(BeAssignableToTypeOf(&timeoutHooks{}))
+
+		_, ok := AsHighPerformanceHook(hooks)
+		Expect(ok).To(BeTrue())
+	})
+
+	It("should not write the files once the context is done", func() {
+		file := filepath.Join(GinkgoT().TempDir(), "file")
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		Expect(writeFile(ctx, file, []byte("1"), 0o644)).To(MatchError(context.Canceled))
+		Expect(file).ToNot(BeAnExistingFile())
+
+		Expect(writeFile(context.Background(), file, []byte("1"), 0o644)).To(Succeed())
+		Expect(os.ReadFile(file)).To(Equal([]byte("1")))
+	})
+
+	It("should fail the writes of a file while a former write of it is blocked", func() {
+		const file = "/sys/devices/system/cpu/cpu1/power/pm_qos_resume_latency_us"
+		fake := useFakeHostFS(map[string]string{file: "0"})
+		blocking := &blockingHostFS{fakeHostFS: fake, release: make(chan struct{})}
+		hostFS = blocking
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		Expect(writeFile(ctx, file, []byte("n/a"), 0o644)).To(MatchError(context.DeadlineExceeded))
+		Expect(writeFile(context.Background(), file, []byte("n/a"), 0o644)).To(MatchError(ContainSubstring("still blocked")))
+
+		close(blocking.release)
+		Eventually(func() error {
+			return writeFile(context.Background(), file, []byte("0"), 0o644)
+		}).Should(Succeed())
+		Expect(fake.ReadFile(file)).To(Equal([]byte("0")))
+	})
+})
@@ -0,0 +1,97 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/google/renameio"
+)
+
+// TopologyExporter publishes the CPU consumption of the containers tuned by the hooks per NUMA zone, for example
+// to the NodeResourceTopology API, so that topology-aware schedulers see the CPUs actually left by the tuning
+// rather than only the view of the kubelet.
+type TopologyExporter interface {
+	// ExportTopology is called with the current topology every time the tuning of a container changes it.
+	ExportTopology(ctx context.Context, topology *NodeTopology) error
+}
+
+// NodeTopology is the CPU consumption of the tuned containers per NUMA zone of the node.
+type NodeTopology struct {
+	Zones      []TopologyZone      `json:"zones"`
+	Containers []TopologyContainer `json:"containers"`
+}
+
+// TopologyZone is the CPU allocation of a NUMA zone of the node.
+type TopologyZone struct {
+	// Name is the name of the zone as in the NodeResourceTopology API, like "node-0".
+	Name      string `json:"name"`
+	CPUs      string `json:"cpus"`
+	Isolated  string `json:"isolated"`
+	Exclusive string `json:"exclusive"`
+	Shared    string `json:"shared"`
+	// Available are the CPUs of the zone not allocated to any tuned container.
+	Available string `json:"available"`
+}
+
+// TopologyContainer is the NUMA alignment of the exclusive CPUs of a tuned container.
+type TopologyContainer struct {
+	ID   string `json:"id"`
+	CPUs string `json:"cpus"`
+	// Zones are the names of the zones holding the CPUs of the container.
+	Zones []string `json:"zones"`
+	// Aligned is set if all the CPUs of the container are in a single zone.
+	Aligned bool `json:"aligned"`
+}
+
+// topologyExporters are the exporters notified of the changes of the topology.
+// The hooks are instantiated per request, so they are kept at package level.
+var topologyExporters = struct {
+	sync.Mutex
+	exporters []TopologyExporter
+	// file is the exporter configured by ExportTopologyToFile, if any.
+	file TopologyExporter
+}{}
+
+// RegisterTopologyExporter notifies the exporter of the changes of the topology from now on.
+func RegisterTopologyExporter(exporter TopologyExporter) {
+	topologyExporters.Lock()
+	defer topologyExporters.Unlock()
+	topologyExporters.exporters = append(topologyExporters.exporters, exporter)
+}
+
+// ExportTopologyToFile writes the topology as JSON to path on every change from now on,
+// for an agent feeding the NodeResourceTopology API, or stops writing it if path is empty.
+func ExportTopologyToFile(path string) {
+	topologyExporters.Lock()
+	defer topologyExporters.Unlock()
+	topologyExporters.file = nil
+	if path != "" {
+		topologyExporters.file = &fileTopologyExporter{path: path}
+	}
+}
+
+// registeredTopologyExporters returns the exporters to notify of the changes of the topology.
+func registeredTopologyExporters() []TopologyExporter {
+	topologyExporters.Lock()
+	defer topologyExporters.Unlock()
+	exporters := append([]TopologyExporter{}, topologyExporters.exporters...)
+	if topologyExporters.file != nil {
+		exporters = append(exporters, topologyExporters.file)
+	}
+	return exporters
+}
+
+// fileTopologyExporter writes the topology to a file, replaced atomically so that its readers
+// never see a partial topology.
+type fileTopologyExporter struct {
+	path string
+}
+
+func (e *fileTopologyExporter) ExportTopology(_ context.Context, topology *NodeTopology) error {
+	content, err := json.Marshal(topology)
+	if err != nil {
+		return err
+	}
+	return renameio.WriteFile(e.path, content, 0o644)
+}
@@ -0,0 +1,106 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/utils/cpuset"
+
+	"github.com/cri-o/cri-o/internal/log"
+)
+
+// sysNodeDir is the sysfs directory of the NUMA nodes.
+const sysNodeDir = "/sys/devices/system/node"
+
+// numaZone is a NUMA node of the node, with its CPUs.
+type numaZone struct {
+	name string
+	cpus cpuset.CPUSet
+}
+
+// numaZones returns the NUMA nodes of the node, named like in the NodeResourceTopology API.
+func numaZones() ([]numaZone, error) {
+	content, err := hostFS.ReadFile(filepath.Join(sysNodeDir, "online"))
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := cpuset.Parse(strings.TrimSpace(string(content)))
+	if err != nil {
+		return nil, fmt.Errorf("parse online NUMA nodes: %w", err)
+	}
+	zones := make([]numaZone, 0, nodes.Size())
+	for _, node := range nodes.List() {
+		content, err := hostFS.ReadFile(filepath.Join(sysNodeDir, fmt.Sprintf("node%d", node), "cpulist"))
+		if err != nil {
+			return nil, err
+		}
+		cpus, err := cpuset.Parse(strings.TrimSpace(string(content)))
+		if err != nil {
+			return nil, fmt.Errorf("parse CPUs of NUMA node %d: %w", node, err)
+		}
+		zones = append(zones, numaZone{name: fmt.Sprintf("node-%d", node), cpus: cpus})
+	}
+	return zones, nil
+}
+
+// currentNodeTopology returns the CPU allocation of every NUMA zone of the node and the alignment of the tuned containers.
+func currentNodeTopology() (*NodeTopology, error) {
+	zones, err := numaZones()
+	if err != nil {
+		return nil, err
+	}
+	allocation, err := currentCPUAllocation()
+	if err != nil {
+		return nil, err
+	}
+	topology := &NodeTopology{Zones: []TopologyZone{}, Containers: []TopologyContainer{}}
+	for _, zone := range zones {
+		topology.Zones = append(topology.Zones, TopologyZone{
+			Name:      zone.name,
+			CPUs:      zone.cpus.String(),
+			Isolated:  allocation.isolated.Intersection(zone.cpus).String(),
+			Exclusive: allocation.exclusive.Intersection(zone.cpus).String(),
+			Shared:    allocation.shared.Intersection(zone.cpus).String(),
+			Available: allocation.housekeeping.Intersection(zone.cpus).String(),
+		})
+	}
+	for _, containerID := range recordedContainers() {
+		record, ok := recordedTuning(containerID)
+		if !ok || record.Tuning == nil || record.Tuning.CPUs == "" {
+			continue
+		}
+		cpus, err := cpuset.Parse(record.Tuning.CPUs)
+		if err != nil {
+			continue
+		}
+		container := TopologyContainer{ID: containerID, CPUs: cpus.String(), Zones: []string{}}
+		for _, zone := range zones {
+			if !zone.cpus.Intersection(cpus).IsEmpty() {
+				container.Zones = append(container.Zones, zone.name)
+			}
+		}
+		container.Aligned = len(container.Zones) == 1
+		topology.Containers = append(topology.Containers, container)
+	}
+	return topology, nil
+}
+
+// exportTopology notifies the registered exporters of the current topology, if any.
+func exportTopology(ctx context.Context) {
+	exporters := registeredTopologyExporters()
+	if len(exporters) == 0 {
+		return
+	}
+	topology, err := currentNodeTopology()
+	if err != nil {
+		log.Warnf(ctx, "Failed to get the node topology to export: %v", err)
+		return
+	}
+	for _, exporter := range exporters {
+		if err := exporter.ExportTopology(ctx, topology); err != nil {
+			log.Warnf(ctx, "Failed to export the node topology: %v", err)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/cpuset"
+)
+
+// recordingTopologyExporter records the exported topologies.
+type recordingTopologyExporter struct {
+	topologies []*NodeTopology
+}
+
+func (e *recordingTopologyExporter) ExportTopology(_ context.Context, topology *NodeTopology) error {
+	e.topologies = append(e.topologies, topology)
+	return nil
+}
+
+var _ = Describe("TopologyExporter", func() {
+	const sandboxID = "sandbox"
+	var exporter *recordingTopologyExporter
+
+	BeforeEach(func() {
+		useFakeHostFS(map[string]string{
+			sysCPUDir + "/online":         "0-7\n",
+			sysNodeDir + "/online":        "0-1\n",
+			sysNodeDir + "/node0/cpulist": "0-3\n",
+			sysNodeDir + "/node1/cpulist": "4-7\n",
+		})
+		exporter = &recordingTopologyExporter{}
+		RegisterTopologyExporter(exporter)
+		DeferCleanup(func() {
+			topologyExporters.Lock()
+			topologyExporters.exporters = nil
+			topologyExporters.Unlock()
+			forgetAppliedTuning(context.TODO(), "ctr1")
+			forgetAppliedTuning(context.TODO(), "ctr2")
+			sharedCPUsConsumers.Lock()
+			delete(sharedCPUsConsumers.sandboxes, sandboxID)
+			sharedCPUsConsumers.Unlock()
+		})
+	})
+
+	It("should export the CPU allocation per NUMA zone on every change", func() {
+		recordAppliedTuning(context.TODO(), "ctr1", &tuning{CPUs: "1-2", CPULoadBalancingDisabled: true})
+		addSharedCPUsConsumer(sandboxID, "ctr1", cpuset.New(1, 2), cpuset.New(0))
+		recordAppliedTuning(context.TODO(), "ctr2", &tuning{CPUs: "3-4"})
+
+		Expect(exporter.topologies).To(HaveLen(3))
+		Expect(exporter.topologies[2]).To(Equal(&NodeTopology{
+			Zones: []TopologyZone{
+				{Name: "node-0", CPUs: "0-3", Isolated: "1-2", Exclusive: "3", Shared: "0", Available: ""},
+				{Name: "node-1", CPUs: "4-7", Isolated: "", Exclusive: "4", Shared: "", Available: "5-7"},
+			},
+			Containers: []TopologyContainer{
+				{ID: "ctr1", CPUs: "1-2", Zones: []string{"node-0"}, Aligned: true},
+				{ID: "ctr2", CPUs: "3-4", Zones: []string{"node-0", "node-1"}, Aligned: false},
+			},
+		}))
+
+		forgetAppliedTuning(context.TODO(), "ctr2")
+		Expect(exporter.topologies).To(HaveLen(4))
+		Expect(exporter.topologies[3].Containers).To(HaveLen(1))
+	})
+
+	It("should write the topology to the configured file", func() {
+		file := filepath.Join(GinkgoT().TempDir(), "topology.json")
+		ExportTopologyToFile(file)
+		DeferCleanup(ExportTopologyToFile, "")
+
+		recordAppliedTuning(context.TODO(), "ctr1", &tuning{CPUs: "4-5"})
+
+		content, err := os.ReadFile(file)
+		Expect(err).ToNot(HaveOccurred())
+		topology := &NodeTopology{}
+		Expect(json.Unmarshal(content, topology)).To(Succeed())
+		Expect(topology).To(Equal(exporter.topologies[0]))
+		Expect(topology.Containers).To(ConsistOf(TopologyContainer{ID: "ctr1", CPUs: "4-5", Zones: []string{"node-1"}, Aligned: true}))
+	})
+})
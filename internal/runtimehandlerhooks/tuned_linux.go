@@ -0,0 +1,234 @@
+package runtimehandlerhooks
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/internal/oci"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+)
+
+const (
+	// tunedPidFile only exists while the TuneD daemon is running.
+	tunedPidFile = "/run/tuned/tuned.pid"
+	// tunedActiveProfileFile holds the space separated names of the active TuneD profiles.
+	tunedActiveProfileFile = "/etc/tuned/active_profile"
+)
+
+// tunedProfileDirs are the directories holding the TuneD profiles, by decreasing priority.
+var tunedProfileDirs = []string{"/etc/tuned/profiles", "/etc/tuned", "/usr/lib/tuned/profiles", "/usr/lib/tuned"}
+
+// tunedConflict is a setting of the active TuneD profile managing the same files as a high-performance feature.
+type tunedConflict struct {
+	// Profile is the profile defining the setting, which may be included by the active one.
+	Profile string
+	Feature string
+	// Setting is the setting of the profile, as "section.option".
+	Setting string
+}
+
+// checkTunedConflicts looks for the settings of the active TuneD profile overlapping the tuning of the container.
+// Every conflict is logged, and the tuning is refused if configured so, as TuneD would keep flipping it back.
+func (h *HighPerformanceHooks) checkTunedConflicts(ctx context.Context, c *oci.Container, t *tuning) error {
+	if h.tunedConflict == "" || h.tunedConflict == libconfig.TunedConflictIgnore {
+		return nil
+	}
+	conflicts, err := activeTunedConflicts(tunedPidFile, tunedActiveProfileFile, tunedProfileDirs)
+	if err != nil {
+		log.Debugf(ctx, "Unable to look for TuneD conflicts: %v", err)
+		return nil
+	}
+
+	features := t.features()
+	var refused []string
+	for _, conflict := range conflicts {
+		if !features[conflict.Feature] {
+			continue
+		}
+		log.WithFields(ctx, map[string]any{
+			"container": c.ID(),
+			"feature":   conflict.Feature,
+			"profile":   conflict.Profile,
+			"setting":   conflict.Setting,
+		}).Warn("TuneD profile manages the same settings as the high-performance tuning of the container")
+		refused = append(refused, fmt.Sprintf("%s (%s.%s)", conflict.Feature, conflict.Profile, conflict.Setting))
+	}
+	if len(refused) > 0 && h.tunedConflict == libconfig.TunedConflictRefuse {
+		return fmt.Errorf("refusing to tune container %q, the active TuneD profile manages %s", c.ID(), strings.Join(refused, ", "))
+	}
+	return nil
+}
+
+// features returns the high-performance features enabled by the tuning.
+func (t *tuning) features() map[string]bool {
+	return map[string]bool{
+		libconfig.HighPerformanceFeatureCPULoadBalancing: t.CPULoadBalancingDisabled,
+		libconfig.HighPerformanceFeatureIRQLoadBalancing: t.IRQLoadBalancingDisabled,
+		libconfig.HighPerformanceFeatureCPUQuota:         t.CPUQuotaDisabled,
+		libconfig.HighPerformanceFeatureCPUCStates:       t.CStates != nil,
+		libconfig.HighPerformanceFeatureCPUFreqGovernor:  t.FreqGovernor != nil,
+		libconfig.HighPerformanceFeaturePacketSteering:   t.PacketSteering != nil,
+		libconfig.HighPerformanceFeatureVFQueues:         t.VFQueues,
+		libconfig.HighPerformanceFeatureARFS:             t.ARFS,
+		libconfig.HighPerformanceFeatureVFIRQAffinity:    t.VFIRQAffinity,
+		libconfig.HighPerformanceFeatureAFXDP:            t.AFXDPQueues > 0,
+		libconfig.HighPerformanceFeatureNAPIAffinity:     t.NAPIAffinity != nil,
+	}
+}
+
+// activeTunedConflicts returns the settings of the active TuneD profiles, and the profiles they include,
+// which manage the same files as the high-performance features. It returns nothing if TuneD is not running.
+func activeTunedConflicts(pidFile, activeProfileFile string, profileDirs []string) ([]tunedConflict, error) {
+	if !fileExists(pidFile) {
+		return nil, nil
+	}
+	content, err := os.ReadFile(activeProfileFile)
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts := []tunedConflict{}
+	visited := make(map[string]bool)
+	var visit func(profile string) error
+	visit = func(profile string) error {
+		if visited[profile] {
+			return nil
+		}
+		visited[profile] = true
+		sections, err := readTunedProfile(profile, profileDirs)
+		if err != nil {
+			return err
+		}
+		for _, section := range sections {
+			if section.name == "main" {
+				for _, include := range strings.Split(section.options["include"], ",") {
+					include = strings.TrimSpace(include)
+					// the includes computed by TuneD functions can not be resolved here
+					if include == "" || strings.Contains(include, "$") {
+						continue
+					}
+					if err := visit(include); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			for _, c := range section.conflicts() {
+				c.Profile = profile
+				conflicts = append(conflicts, c)
+			}
+		}
+		return nil
+	}
+	for _, profile := range strings.Fields(string(content)) {
+		if err := visit(profile); err != nil {
+			return nil, err
+		}
+	}
+	return conflicts, nil
+}
+
+// tunedSection is a section of a TuneD profile, configuring a plugin.
+type tunedSection struct {
+	name    string
+	options map[string]string
+	// keys are the options in the order of the profile.
+	keys []string
+}
+
+// plugin returns the TuneD plugin configured by the section, named after the section unless its type is set.
+func (s *tunedSection) plugin() string {
+	if plugin := s.options["type"]; plugin != "" {
+		return plugin
+	}
+	return s.name
+}
+
+// conflicts returns the options of the section which manage the same files as the high-performance features.
+func (s *tunedSection) conflicts() []tunedConflict {
+	conflicts := []tunedConflict{}
+	add := func(feature, option string) {
+		conflicts = append(conflicts, tunedConflict{Feature: feature, Setting: s.name + "." + option})
+	}
+	for _, option := range s.keys {
+		switch s.plugin() {
+		case "cpu":
+			switch option {
+			case "governor":
+				add(libconfig.HighPerformanceFeatureCPUFreqGovernor, option)
+			case "force_latency", "pm_qos_resume_latency_us":
+				add(libconfig.HighPerformanceFeatureCPUCStates, option)
+			}
+		case "irqbalance":
+			if option == "banned_cpus" {
+				add(libconfig.HighPerformanceFeatureIRQLoadBalancing, option)
+			}
+		case "scheduler":
+			switch option {
+			case "default_irq_smp_affinity":
+				add(libconfig.HighPerformanceFeatureIRQLoadBalancing, option)
+			case "isolated_cores":
+				add(libconfig.HighPerformanceFeatureIRQLoadBalancing, option)
+				add(libconfig.HighPerformanceFeatureCPULoadBalancing, option)
+			}
+		case "sysfs":
+			// the options of the sysfs plugin are the paths of the files it writes
+			switch {
+			case strings.Contains(option, "default_smp_affinity"):
+				add(libconfig.HighPerformanceFeatureIRQLoadBalancing, option)
+			case strings.Contains(option, "sched_domain"):
+				add(libconfig.HighPerformanceFeatureCPULoadBalancing, option)
+			case strings.Contains(option, "cpufreq"):
+				add(libconfig.HighPerformanceFeatureCPUFreqGovernor, option)
+			case strings.Contains(option, "pm_qos_resume_latency_us"):
+				add(libconfig.HighPerformanceFeatureCPUCStates, option)
+			}
+		}
+	}
+	return conflicts
+}
+
+// readTunedProfile reads the sections of the tuned.conf file of the profile, from the first directory holding it.
+func readTunedProfile(profile string, profileDirs []string) ([]*tunedSection, error) {
+	for _, dir := range profileDirs {
+		file, err := os.Open(filepath.Join(dir, profile, "tuned.conf"))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return parseTunedProfile(file)
+	}
+	return nil, fmt.Errorf("TuneD profile %q not found", profile)
+}
+
+func parseTunedProfile(file *os.File) ([]*tunedSection, error) {
+	sections := []*tunedSection{}
+	var section *tunedSection
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			section = &tunedSection{name: strings.TrimSpace(line[1 : len(line)-1]), options: make(map[string]string)}
+			sections = append(sections, section)
+		case section != nil:
+			key, value, _ := strings.Cut(line, "=")
+			key = strings.TrimSpace(key)
+			if _, ok := section.options[key]; !ok {
+				section.keys = append(section.keys, key)
+			}
+			section.options[key] = strings.TrimSpace(value)
+		}
+	}
+	return sections, scanner.Err()
+}
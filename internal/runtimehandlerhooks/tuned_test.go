@@ -0,0 +1,78 @@
+package runtimehandlerhooks
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+)
+
+var _ = Describe("activeTunedConflicts", func() {
+	var dir, pidFile, activeProfileFile string
+	var profileDirs []string
+
+	writeProfile := func(profileDir, profile, content string) {
+		Expect(os.MkdirAll(filepath.Join(profileDir, profile), 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(profileDir, profile, "tuned.conf"), []byte(content), 0o644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		pidFile = filepath.Join(dir, "tuned.pid")
+		activeProfileFile = filepath.Join(dir, "active_profile")
+		profileDirs = []string{filepath.Join(dir, "etc"), filepath.Join(dir, "lib")}
+
+		Expect(os.WriteFile(pidFile, []byte("1"), 0o644)).To(Succeed())
+		Expect(os.WriteFile(activeProfileFile, []byte("node-tuning\n"), 0o644)).To(Succeed())
+		writeProfile(profileDirs[1], "latency-performance", `
+[cpu]
+force_latency=cstate.id_no_zero:1|3
+governor=performance
+energy_perf_bias=performance
+`)
+		writeProfile(profileDirs[0], "node-tuning", `
+[main]
+summary=Node tuning
+include=latency-performance, ${f:virt_check:virtual-guest:virtual-host}
+
+[irq]
+type=irqbalance
+banned_cpus=2-5
+
+[sysfs]
+/sys/kernel/mm/transparent_hugepage/enabled=never
+/proc/irq/default_smp_affinity=3
+`)
+	})
+
+	It("should report the settings managing the tuned files", func() {
+		Expect(activeTunedConflicts(pidFile, activeProfileFile, profileDirs)).To(ConsistOf(
+			tunedConflict{Profile: "latency-performance", Feature: libconfig.HighPerformanceFeatureCPUCStates, Setting: "cpu.force_latency"},
+			tunedConflict{Profile: "latency-performance", Feature: libconfig.HighPerformanceFeatureCPUFreqGovernor, Setting: "cpu.governor"},
+			tunedConflict{Profile: "node-tuning", Feature: libconfig.HighPerformanceFeatureIRQLoadBalancing, Setting: "irq.banned_cpus"},
+			tunedConflict{Profile: "node-tuning", Feature: libconfig.HighPerformanceFeatureIRQLoadBalancing, Setting: "sysfs./proc/irq/default_smp_affinity"},
+		))
+	})
+
+	It("should prefer the profiles of the first directories", func() {
+		writeProfile(profileDirs[0], "latency-performance", "[cpu]\nenergy_perf_bias=performance\n")
+
+		Expect(activeTunedConflicts(pidFile, activeProfileFile, profileDirs)).To(HaveLen(2))
+	})
+
+	It("should report nothing while TuneD is not running", func() {
+		Expect(os.Remove(pidFile)).To(Succeed())
+
+		Expect(activeTunedConflicts(pidFile, activeProfileFile, profileDirs)).To(BeEmpty())
+	})
+
+	It("should fail on a missing profile", func() {
+		Expect(os.WriteFile(activeProfileFile, []byte("missing"), 0o644)).To(Succeed())
+
+		_, err := activeTunedConflicts(pidFile, activeProfileFile, profileDirs)
+		Expect(err).To(MatchError(`TuneD profile "missing" not found`))
+	})
+})
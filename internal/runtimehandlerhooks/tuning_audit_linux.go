@@ -0,0 +1,198 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+
+	"github.com/cri-o/cri-o/internal/log"
+)
+
+// tuningAuditRotations is the number of rotated tuning audit logs kept.
+const tuningAuditRotations = 3
+
+// tuningAudit is the audit log of the writes of the hooks to the files of the node, opened by OpenTuningAuditLog.
+// The hooks are instantiated per request, so it is kept at package level.
+var tuningAudit = struct {
+	sync.Mutex
+	// file is nil if the writes are not recorded.
+	file *os.File
+	path string
+	size int64
+	// sizeMax is the size after which the log gets rotated, 0 to never rotate it.
+	sizeMax int64
+}{}
+
+// tuningAuditEntry is the record of a write of the hooks in the tuning audit log.
+type tuningAuditEntry struct {
+	Time      time.Time `json:"time"`
+	Container string    `json:"container,omitempty"`
+	Path      string    `json:"path"`
+	// Old is the value of the file before the write, empty for the writes done through a cgroup manager.
+	Old   string `json:"old"`
+	New   string `json:"new"`
+	Error string `json:"error,omitempty"`
+}
+
+// OpenTuningAuditLog records every write of the hooks to the files of the node to path from now on,
+// rotating it once it exceeds sizeMax bytes. An empty path stops recording the writes.
+func OpenTuningAuditLog(path string, sizeMax int64) error {
+	tuningAudit.Lock()
+	defer tuningAudit.Unlock()
+	if tuningAudit.file != nil {
+		if err := tuningAudit.file.Close(); err != nil {
+			return fmt.Errorf("close tuning audit log: %w", err)
+		}
+		tuningAudit.file = nil
+	}
+	if path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create tuning audit log directory: %w", err)
+	}
+	tuningAudit.path = path
+	tuningAudit.sizeMax = sizeMax
+	if err := openTuningAuditFile(); err != nil {
+		return fmt.Errorf("open tuning audit log: %w", err)
+	}
+	return nil
+}
+
+// openTuningAuditFile opens the file of the tuning audit log for appending.
+// The caller must hold the lock of the tuning audit log.
+func openTuningAuditFile() error {
+	file, err := os.OpenFile(tuningAudit.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	tuningAudit.file = file
+	tuningAudit.size = info.Size()
+	return nil
+}
+
+// rotateTuningAuditLog moves the tuning audit log to path.1, shifting the former rotated logs,
+// and opens a new one. The caller must hold the lock of the tuning audit log.
+func rotateTuningAuditLog() error {
+	var errs []error
+	if err := tuningAudit.file.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	tuningAudit.file = nil
+	for i := tuningAuditRotations; i > 0; i-- {
+		from := tuningAudit.path
+		if i > 1 {
+			from = fmt.Sprintf("%s.%d", tuningAudit.path, i-1)
+		}
+		if err := os.Rename(from, fmt.Sprintf("%s.%d", tuningAudit.path, i)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	// keep recording the writes even if the former logs could not be rotated
+	if err := openTuningAuditFile(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func tuningAuditEnabled() bool {
+	tuningAudit.Lock()
+	defer tuningAudit.Unlock()
+	return tuningAudit.file != nil
+}
+
+type auditContainerKey struct{}
+
+// withAuditContainer returns a context recording the writes of the hooks done with it for the container.
+func withAuditContainer(ctx context.Context, containerID string) context.Context {
+	return context.WithValue(ctx, auditContainerKey{}, containerID)
+}
+
+// auditTuningWrite records the write of value to the file named by path to the tuning audit log, if any.
+func auditTuningWrite(ctx context.Context, path, old, value string, writeErr error) {
+	entry := tuningAuditEntry{
+		Time: time.Now().UTC(),
+		Path: path,
+		Old:  old,
+		New:  value,
+	}
+	entry.Container, _ = ctx.Value(auditContainerKey{}).(string)
+	if writeErr != nil {
+		entry.Error = writeErr.Error()
+	}
+	line, err := json.Marshal(&entry)
+	if err != nil {
+		log.Warnf(ctx, "Failed to encode the tuning audit entry of %s: %v", path, err)
+		return
+	}
+	line = append(line, '\n')
+
+	tuningAudit.Lock()
+	defer tuningAudit.Unlock()
+	if tuningAudit.file == nil {
+		return
+	}
+	if tuningAudit.sizeMax > 0 && tuningAudit.size > 0 && tuningAudit.size+int64(len(line)) > tuningAudit.sizeMax {
+		if err := rotateTuningAuditLog(); err != nil {
+			log.Warnf(ctx, "Failed to rotate the tuning audit log %s: %v", tuningAudit.path, err)
+		}
+		if tuningAudit.file == nil {
+			return
+		}
+	}
+	n, err := tuningAudit.file.Write(line)
+	tuningAudit.size += int64(n)
+	if err != nil {
+		log.Warnf(ctx, "Failed to record the write of %s to the tuning audit log: %v", path, err)
+	}
+}
+
+// auditedWrite runs write, which sets the file named by path to value, and records it to the tuning
+// audit log along with the former value returned by read, if any. The privileged tuning operations
+// are reported to the Linux audit subsystem as well, if enabled.
+func auditedWrite(ctx context.Context, path, value string, read func() (string, error), write func() error) error {
+	op := linuxAuditOperation(path)
+	if !tuningAuditEnabled() && (op == "" || !linuxAuditEnabled()) {
+		return write()
+	}
+	var old string
+	if read != nil {
+		if current, err := read(); err == nil {
+			old = strings.TrimSpace(current)
+		}
+	}
+	err := write()
+	auditTuningWrite(ctx, path, old, strings.TrimSpace(value), err)
+	if op != "" {
+		reportLinuxAudit(ctx, op, path, old, strings.TrimSpace(value), err)
+	}
+	return err
+}
+
+// resourcesSummary describes the resources set through a cgroup manager in the tuning audit log.
+func resourcesSummary(resources *configs.Resources) string {
+	var fields []string
+	if resources.CpusetCpus != "" {
+		fields = append(fields, "cpuset.cpus="+resources.CpusetCpus)
+	}
+	if resources.CpuQuota != 0 {
+		fields = append(fields, fmt.Sprintf("cpu.quota=%d", resources.CpuQuota))
+	}
+	if resources.CpuPeriod != 0 {
+		fields = append(fields, fmt.Sprintf("cpu.period=%d", resources.CpuPeriod))
+	}
+	return strings.Join(fields, " ")
+}
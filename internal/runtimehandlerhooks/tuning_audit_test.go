@@ -0,0 +1,89 @@
+package runtimehandlerhooks
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/sys/unix"
+)
+
+var _ = Describe("tuningAudit", func() {
+	var auditLog string
+
+	entriesOf := func(file string) []tuningAuditEntry {
+		f, err := os.Open(file)
+		Expect(err).ToNot(HaveOccurred())
+		defer f.Close()
+		entries := []tuningAuditEntry{}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			entry := tuningAuditEntry{}
+			Expect(json.Unmarshal(scanner.Bytes(), &entry)).To(Succeed())
+			entries = append(entries, entry)
+		}
+		Expect(scanner.Err()).ToNot(HaveOccurred())
+		return entries
+	}
+
+	BeforeEach(func() {
+		auditLog = filepath.Join(GinkgoT().TempDir(), "audit", "tuning.log")
+		DeferCleanup(func() {
+			Expect(OpenTuningAuditLog("", 0)).To(Succeed())
+		})
+	})
+
+	It("should record the writes of the hooks", func() {
+		const governorFile = "/sys/devices/system/cpu/cpu1/cpufreq/scaling_governor"
+		const ctrCgroup = "/sys/fs/cgroup/kubepods.slice/pod/ctr1"
+		fake := useFakeHostFS(map[string]string{
+			governorFile:                          "powersave\n",
+			ctrCgroup + "/" + cpusetCpusPartition: "member\n",
+		})
+		fake.writeErrs[ctrCgroup+"/"+cpusetCpusPartition] = []error{unix.EINVAL}
+		Expect(OpenTuningAuditLog(auditLog, 0)).To(Succeed())
+		ctx := withAuditContainer(context.TODO(), "ctr1")
+
+		Expect(writeFile(ctx, governorFile, []byte("performance"), 0o644)).To(Succeed())
+		Expect(writeCgroupFile(ctx, ctrCgroup, cpusetCpusPartition, "isolated")).ToNot(Succeed())
+
+		entries := entriesOf(auditLog)
+		Expect(entries).To(HaveLen(2))
+		for i := range entries {
+			Expect(entries[i].Time).ToNot(BeZero())
+			entries[i].Time = time.Time{}
+		}
+		Expect(entries[0]).To(Equal(tuningAuditEntry{Container: "ctr1", Path: governorFile, Old: "powersave", New: "performance"}))
+		Expect(entries[1].Error).To(ContainSubstring("invalid argument"))
+		entries[1].Error = ""
+		Expect(entries[1]).To(Equal(tuningAuditEntry{Container: "ctr1", Path: ctrCgroup + "/" + cpusetCpusPartition, Old: "member", New: "isolated"}))
+	})
+
+	It("should not record the writes once closed", func() {
+		const file = "/proc/irq/default_smp_affinity"
+		useFakeHostFS(map[string]string{file: "ff\n"})
+		Expect(OpenTuningAuditLog(auditLog, 0)).To(Succeed())
+		Expect(OpenTuningAuditLog("", 0)).To(Succeed())
+
+		Expect(writeFile(context.TODO(), file, []byte("f0"), 0o644)).To(Succeed())
+		Expect(entriesOf(auditLog)).To(BeEmpty())
+	})
+
+	It("should rotate the log once it exceeds its maximum size", func() {
+		Expect(OpenTuningAuditLog(auditLog, 1)).To(Succeed())
+
+		for i := range tuningAuditRotations + 2 {
+			auditTuningWrite(context.TODO(), "/sys/file", "", string(rune('a'+i)), nil)
+		}
+
+		Expect(entriesOf(auditLog)[0].New).To(Equal("e"))
+		Expect(entriesOf(auditLog + ".1")[0].New).To(Equal("d"))
+		Expect(entriesOf(auditLog + ".3")[0].New).To(Equal("b"))
+		Expect(auditLog + ".4").ToNot(BeAnExistingFile())
+	})
+})
@@ -0,0 +1,60 @@
+package runtimehandlerhooks
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/cri-o/cri-o/internal/oci"
+	crioTypes "github.com/cri-o/cri-o/pkg/types"
+)
+
+// ContainerTuningDetails returns the details of the tuning applied to the container, nil if it did not get tuned.
+// The governor and resume latency are the ones written to the per-CPU files, which are the same for all the CPUs.
+func ContainerTuningDetails(containerID string) *crioTypes.TuningDetails {
+	record, ok := recordedTuning(containerID)
+	if !ok {
+		return nil
+	}
+	return tuningDetails(&record)
+}
+
+// StateTuningDetails returns the details of the tuning recorded in the state of the container, nil if it did not
+// get tuned, so that they are available without looking up the tuning store.
+func StateTuningDetails(c *oci.Container) *crioTypes.TuningDetails {
+	tunings := c.Tunings()
+	if len(tunings) == 0 {
+		return nil
+	}
+	record, err := decodeTuningRecord(tunings)
+	if err != nil {
+		return nil
+	}
+	return tuningDetails(record)
+}
+
+func tuningDetails(record *tuningRecord) *crioTypes.TuningDetails {
+	details := &crioTypes.TuningDetails{}
+	if t := record.Tuning; t != nil {
+		details.CPUs = t.CPUs
+		details.SharedCPUs = t.SharedCPUs
+		details.CPULoadBalancingDisabled = t.CPULoadBalancingDisabled
+		details.CPUQuotaDisabled = t.CPUQuotaDisabled
+		if t.IRQLoadBalancingDisabled {
+			details.IRQBannedCPUs = t.CPUs
+		}
+	}
+	for _, w := range record.Writes {
+		details.Files = append(details.Files, crioTypes.TunedFile{Path: w.Path, Original: w.Original, Value: w.Value})
+		name := filepath.Base(w.Path)
+		perCPU := strings.HasPrefix(w.Path, sysCPUDir+"/")
+		switch {
+		case name == cpusetCpusPartition:
+			details.Partition = w.Value
+		case perCPU && name == "scaling_governor":
+			details.FreqGovernor = w.Value
+		case perCPU && name == "pm_qos_resume_latency_us":
+			details.ResumeLatency = w.Value
+		}
+	}
+	return details
+}
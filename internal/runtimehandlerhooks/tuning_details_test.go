@@ -0,0 +1,68 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	crioTypes "github.com/cri-o/cri-o/pkg/types"
+)
+
+var _ = Describe("ContainerTuningDetails", func() {
+	const containerID = "ctr1"
+
+	AfterEach(func() {
+		forgetAppliedTuning(context.TODO(), containerID)
+	})
+
+	It("should not report the containers which did not get tuned", func() {
+		Expect(ContainerTuningDetails(containerID)).To(BeNil())
+	})
+
+	It("should report the applied tuning", func() {
+		const (
+			governorFile  = sysCPUDir + "/cpu1/cpufreq/scaling_governor"
+			latencyFile   = sysCPUDir + "/cpu1/power/pm_qos_resume_latency_us"
+			partitionFile = "/sys/fs/cgroup/kubepods.slice/pod/ctr1/" + cpusetCpusPartition
+		)
+		performance, cStates := "performance", annotationDisable
+		recordTuningWrite(context.TODO(), containerID, governorFile, "powersave", "performance")
+		recordTuningWrite(context.TODO(), containerID, latencyFile, "0", "n/a")
+		recordTuningWrite(context.TODO(), containerID, partitionFile, "member", "isolated")
+		recordTuningWrite(context.TODO(), containerID, IrqSmpAffinityProcFile, "ff", "000000f9")
+		recordAppliedTuning(context.TODO(), containerID, &tuning{
+			CPUs:                     "1-2",
+			CPULoadBalancingDisabled: true,
+			IRQLoadBalancingDisabled: true,
+			CStates:                  &cStates,
+			FreqGovernor:             &performance,
+		})
+
+		Expect(ContainerTuningDetails(containerID)).To(Equal(&crioTypes.TuningDetails{
+			CPUs:                     "1-2",
+			CPULoadBalancingDisabled: true,
+			Partition:                "isolated",
+			IRQBannedCPUs:            "1-2",
+			FreqGovernor:             "performance",
+			ResumeLatency:            "n/a",
+			Files: []crioTypes.TunedFile{
+				{Path: governorFile, Original: "powersave", Value: "performance"},
+				{Path: latencyFile, Original: "0", Value: "n/a"},
+				{Path: partitionFile, Original: "member", Value: "isolated"},
+				{Path: IrqSmpAffinityProcFile, Original: "ff", Value: "000000f9"},
+			},
+		}))
+	})
+	It("should report the tuned files which do not hold the tuning anymore", func() {
+		const governorFile = sysCPUDir + "/cpu1/cpufreq/scaling_governor"
+		useFakeHostFS(map[string]string{governorFile: "powersave\n"})
+		Expect(IneffectiveTuning(containerID)).To(BeEmpty())
+
+		performance := "performance"
+		recordTuningWrite(context.TODO(), containerID, governorFile, "powersave", performance)
+		recordAppliedTuning(context.TODO(), containerID, &tuning{CPUs: "1", FreqGovernor: &performance})
+
+		Expect(IneffectiveTuning(containerID)).To(Equal([]string{governorFile}))
+	})
+})
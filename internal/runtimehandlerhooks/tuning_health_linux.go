@@ -0,0 +1,92 @@
+package runtimehandlerhooks
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+)
+
+// procMountsFile lists the filesystems mounted on the node.
+const procMountsFile = "/proc/mounts"
+
+// CheckTuningHealth checks the capability of the node to get tuned by the hooks: the tuning state directory has
+// to be writable and hold readable records only, irqbalance has to be installed and the resctrl filesystem has to
+// be mounted if RDT is enabled. The checks are cheap enough to be run on every runtime status request.
+func CheckTuningHealth(config *libconfig.Config) []TuningHealthCheck {
+	stateDir := config.HighPerformanceSettings().StateDir
+	checks := []TuningHealthCheck{
+		tuningHealthCheck(TuningHealthCheckStateDir, checkStateDirWritable(stateDir)),
+		tuningHealthCheck(TuningHealthCheckStateStore, checkStateStoreIntegrity(stateDir)),
+		tuningHealthCheck(TuningHealthCheckIrqbalance, checkIrqbalanceAvailable()),
+	}
+	if rdtConfig := config.Rdt(); rdtConfig != nil && rdtConfig.Enabled() {
+		checks = append(checks, tuningHealthCheck(TuningHealthCheckResctrl, checkResctrlMounted()))
+	}
+	return checks
+}
+
+func tuningHealthCheck(name string, err error) TuningHealthCheck {
+	if err != nil {
+		return TuningHealthCheck{Name: name, Message: err.Error()}
+	}
+	return TuningHealthCheck{Name: name, Healthy: true}
+}
+
+// checkStateDirWritable fails if the tuning records cannot be persisted to dir.
+func checkStateDirWritable(dir string) error {
+	file, err := os.CreateTemp(dir, ".health-*")
+	if err != nil {
+		return fmt.Errorf("tuning state directory is not writable: %w", err)
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+// checkStateStoreIntegrity fails if some tuning records of dir cannot be read,
+// in which case the tuning they record cannot be reverted anymore.
+func checkStateStoreIntegrity(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	var corrupted []string
+	for _, file := range files {
+		if _, err := readTuningRecord(file); err != nil && !os.IsNotExist(err) {
+			corrupted = append(corrupted, strings.TrimSuffix(filepath.Base(file), ".json"))
+		}
+	}
+	if len(corrupted) > 0 {
+		return fmt.Errorf("unreadable tuning records of containers %s", strings.Join(corrupted, ", "))
+	}
+	return nil
+}
+
+// checkIrqbalanceAvailable fails if irqbalance is not installed, so the IRQ load balancing cannot be tuned.
+func checkIrqbalanceAvailable() error {
+	if _, err := exec.LookPath(irqBalancedName); err != nil {
+		return fmt.Errorf("irqbalance is not available: %w", err)
+	}
+	return nil
+}
+
+// checkResctrlMounted fails if the resctrl filesystem is not mounted, so the RDT classes cannot be applied.
+func checkResctrlMounted() error {
+	content, err := hostFS.ReadFile(procMountsFile)
+	if err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) > 2 && fields[2] == "resctrl" {
+			return nil
+		}
+	}
+	return errors.New("resctrl filesystem is not mounted")
+}
@@ -0,0 +1,62 @@
+package runtimehandlerhooks
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+)
+
+var _ = Describe("CheckTuningHealth", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		useFakeHostFS(map[string]string{
+			procMountsFile: "sysfs /sys sysfs rw 0 0\nresctrl /sys/fs/resctrl resctrl rw 0 0\n",
+		})
+	})
+
+	It("should pass with a writable state directory holding readable records", func() {
+		Expect(os.WriteFile(filepath.Join(dir, "ctr1.json"), []byte(`{"tuning":{"cpus":"1-2"}}`), 0o600)).To(Succeed())
+
+		Expect(checkStateDirWritable(dir)).To(Succeed())
+		Expect(checkStateStoreIntegrity(dir)).To(Succeed())
+		Expect(checkResctrlMounted()).To(Succeed())
+	})
+
+	It("should only check resctrl with RDT enabled", func() {
+		config, err := libconfig.DefaultConfig()
+		Expect(err).ToNot(HaveOccurred())
+		config.TuningStateDir = dir
+
+		checks := CheckTuningHealth(config)
+
+		names := []string{}
+		for _, check := range checks {
+			names = append(names, check.Name)
+		}
+		Expect(names).To(Equal([]string{TuningHealthCheckStateDir, TuningHealthCheckStateStore, TuningHealthCheckIrqbalance}))
+		Expect(checks[0].Healthy).To(BeTrue())
+		Expect(checks[1].Healthy).To(BeTrue())
+	})
+
+	It("should fail on a missing state directory", func() {
+		Expect(checkStateDirWritable(filepath.Join(dir, "missing"))).ToNot(Succeed())
+	})
+
+	It("should fail on unreadable tuning records", func() {
+		Expect(os.WriteFile(filepath.Join(dir, "ctr1.json"), []byte("{"), 0o600)).To(Succeed())
+
+		Expect(checkStateStoreIntegrity(dir)).To(MatchError(ContainSubstring("ctr1")))
+	})
+
+	It("should fail if resctrl is not mounted", func() {
+		useFakeHostFS(map[string]string{procMountsFile: "sysfs /sys sysfs rw 0 0\n"})
+
+		Expect(checkResctrlMounted()).ToNot(Succeed())
+	})
+})
@@ -0,0 +1,231 @@
+package runtimehandlerhooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/fields"
+
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	crioannotations "github.com/cri-o/cri-o/pkg/annotations"
+)
+
+const (
+	// multusNetworksAnnotation requests the secondary networks of a pod from Multus, either as a comma separated
+	// list of "<namespace>/<network>@<interface>" entries or as a JSON list of network selection elements.
+	multusNetworksAnnotation = "k8s.v1.cni.cncf.io/networks"
+	// multusNetworkStatusAnnotation reports the interfaces Multus attached the networks of a pod to.
+	multusNetworkStatusAnnotation = "k8s.v1.cni.cncf.io/network-status"
+	// multusInterfacePrefix prefixes the index of a secondary network of a pod, starting at 1,
+	// in the name of its interface if its request does not name it.
+	multusInterfacePrefix = "net"
+	// tuningInterfacesDefault selects the interfaces of the default network of the pod.
+	tuningInterfacesDefault = "default"
+	// defaultPodInterface is the interface of the default network of a pod if its CNI result does not name it.
+	defaultPodInterface = "eth0"
+	// maxInterfaceNameLen is the longest name of a network device.
+	maxInterfaceNameLen = 15
+)
+
+// multusNetworkSelection is an entry of the JSON list of the networks requested from Multus.
+type multusNetworkSelection struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Interface string `json:"interface,omitempty"`
+}
+
+// multusNetworkStatus is an entry of the network status reported by Multus.
+type multusNetworkStatus struct {
+	Name      string `json:"name"`
+	Interface string `json:"interface,omitempty"`
+	Default   bool   `json:"default,omitempty"`
+}
+
+// cniResultInterfaces holds the interfaces of the CNI result of a pod.
+type cniResultInterfaces struct {
+	Interfaces []struct {
+		Name    string `json:"name"`
+		Sandbox string `json:"sandbox,omitempty"`
+	} `json:"interfaces,omitempty"`
+}
+
+// requestedTuningInterfaces returns the entries of the tuning interfaces annotation of the pod, if it has one.
+func requestedTuningInterfaces(annotations fields.Set) ([]string, bool) {
+	value, ok := annotations[crioannotations.TuningInterfacesAnnotation]
+	if !ok {
+		return nil, false
+	}
+	return parseTuningInterfaces(value)
+}
+
+// parseTuningInterfaces returns the comma separated entries of the value, if all of them are valid.
+func parseTuningInterfaces(value string) ([]string, bool) {
+	var entries []string
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.ContainsAny(entry, " \t\n@") {
+			return nil, false
+		}
+		entries = append(entries, entry)
+	}
+	return entries, true
+}
+
+// sandboxNetwork returns the network of the pod restricted to the interfaces selected by its tuning
+// interfaces annotation, whose default network is resolved with the CNI result of its infra container.
+func sandboxNetwork(s *sandbox.Sandbox) (podNetwork, error) {
+	var cniResult string
+	if infra := s.InfraContainer(); infra != nil {
+		cniResult = infra.Annotations()[crioannotations.CNIResult]
+	}
+	return podNetworkOf(s, cniResult)
+}
+
+// podNetworkOf returns the network of the pod restricted to the interfaces selected by its tuning
+// interfaces annotation, whose default network is resolved with the CNI result.
+func podNetworkOf(s *sandbox.Sandbox, cniResult string) (podNetwork, error) {
+	network := podNetwork{NetNS: s.NetNsPath()}
+	entries, ok := requestedTuningInterfaces(s.Annotations())
+	if !ok {
+		return network, nil
+	}
+	interfaces, err := resolveTuningInterfaces(entries, s.Annotations(), cniResult)
+	if err != nil {
+		return podNetwork{}, fmt.Errorf("resolve %s of pod sandbox %s: %w", crioannotations.TuningInterfacesAnnotation, s.ID(), err)
+	}
+	network.Interfaces = interfaces
+	return network, nil
+}
+
+// resolveTuningInterfaces returns the names of the interfaces selected by the entries of the tuning interfaces
+// annotation: the interfaces of the default network for "default", the interface of a secondary network for
+// its name, as reported by the network status of Multus or requested by the networks annotation, and the
+// entry itself otherwise, if it is a valid interface name.
+func resolveTuningInterfaces(entries []string, annotations fields.Set, cniResult string) ([]string, error) {
+	networks, defaults, err := multusInterfaces(annotations)
+	if err != nil {
+		return nil, err
+	}
+	if len(defaults) == 0 {
+		defaults, err = cniResultSandboxInterfaces(cniResult)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var interfaces []string
+	for _, entry := range entries {
+		var resolved []string
+		switch {
+		case entry == tuningInterfacesDefault:
+			resolved = defaults
+		case len(networks[entry]) > 0:
+			resolved = networks[entry]
+		case len(entry) <= maxInterfaceNameLen && !strings.Contains(entry, "/"):
+			resolved = []string{entry}
+		default:
+			return nil, fmt.Errorf("%q is neither a network of the pod nor an interface name", entry)
+		}
+		for _, name := range resolved {
+			if !slices.Contains(interfaces, name) {
+				interfaces = append(interfaces, name)
+			}
+		}
+	}
+	return interfaces, nil
+}
+
+// multusInterfaces returns the interfaces of the secondary networks of the pod by name, both with and without
+// their namespace, and the interfaces of its default network, if Multus reports them. The network status reported
+// by Multus is preferred over the networks requested from it, which only name the interfaces Multus would pick.
+func multusInterfaces(annotations fields.Set) (networks map[string][]string, defaults []string, err error) {
+	networks = make(map[string][]string)
+	add := func(name, iface string) {
+		networks[name] = append(networks[name], iface)
+		if _, short, ok := strings.Cut(name, "/"); ok {
+			networks[short] = append(networks[short], iface)
+		}
+	}
+	if value, ok := annotations[multusNetworkStatusAnnotation]; ok {
+		var statuses []multusNetworkStatus
+		if err := json.Unmarshal([]byte(value), &statuses); err != nil {
+			return nil, nil, fmt.Errorf("parse %s: %w", multusNetworkStatusAnnotation, err)
+		}
+		for _, status := range statuses {
+			if status.Interface == "" {
+				continue
+			}
+			if status.Default {
+				defaults = append(defaults, status.Interface)
+				continue
+			}
+			add(status.Name, status.Interface)
+		}
+		return networks, defaults, nil
+	}
+	selections, err := parseMultusNetworks(annotations[multusNetworksAnnotation])
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, selection := range selections {
+		iface := selection.Interface
+		if iface == "" {
+			iface = multusInterfacePrefix + strconv.Itoa(i+1)
+		}
+		name := selection.Name
+		if selection.Namespace != "" {
+			name = selection.Namespace + "/" + name
+		}
+		add(name, iface)
+	}
+	return networks, nil, nil
+}
+
+// parseMultusNetworks parses the networks requested from Multus, in either of their formats.
+func parseMultusNetworks(value string) ([]multusNetworkSelection, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	var selections []multusNetworkSelection
+	if strings.HasPrefix(value, "[") {
+		if err := json.Unmarshal([]byte(value), &selections); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", multusNetworksAnnotation, err)
+		}
+		return selections, nil
+	}
+	for _, entry := range strings.Split(value, ",") {
+		var selection multusNetworkSelection
+		entry, selection.Interface, _ = strings.Cut(strings.TrimSpace(entry), "@")
+		if namespace, name, ok := strings.Cut(entry, "/"); ok {
+			selection.Namespace, entry = namespace, name
+		}
+		selection.Name = entry
+		selections = append(selections, selection)
+	}
+	return selections, nil
+}
+
+// cniResultSandboxInterfaces returns the interfaces of the CNI result in the network namespace of the pod,
+// the default one if it has none.
+func cniResultSandboxInterfaces(cniResult string) ([]string, error) {
+	if cniResult == "" {
+		return []string{defaultPodInterface}, nil
+	}
+	var result cniResultInterfaces
+	if err := json.Unmarshal([]byte(cniResult), &result); err != nil {
+		return nil, fmt.Errorf("parse CNI result: %w", err)
+	}
+	var interfaces []string
+	for _, iface := range result.Interfaces {
+		if iface.Sandbox != "" && iface.Name != "" {
+			interfaces = append(interfaces, iface.Name)
+		}
+	}
+	if len(interfaces) == 0 {
+		return []string{defaultPodInterface}, nil
+	}
+	return interfaces, nil
+}
@@ -0,0 +1,103 @@
+package runtimehandlerhooks
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/fields"
+
+	crioannotations "github.com/cri-o/cri-o/pkg/annotations"
+)
+
+var _ = Describe("tuning interfaces", func() {
+	const (
+		netns     = "/var/run/netns/pod"
+		cniResult = `{"cniVersion":"1.0.0","interfaces":[{"name":"veth1234"},{"name":"eth0","sandbox":"/var/run/netns/pod"}]}`
+	)
+	savedPodInterfaces := podInterfaces
+
+	BeforeEach(func() {
+		podInterfaces = func(string) ([]podInterface, error) {
+			return []podInterface{
+				{Name: "eth0", NetNS: netns},
+				{Name: "net1", NetNS: netns},
+				{Name: "ran", NetNS: netns},
+				{Name: "veth1234", Peer: "eth0"},
+			}, nil
+		}
+	})
+
+	AfterEach(func() {
+		podInterfaces = savedPodInterfaces
+	})
+
+	It("should tune all the interfaces without selection", func() {
+		interfaces, err := podNetwork{NetNS: netns}.interfaces()
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(interfaces).To(HaveLen(4))
+	})
+
+	It("should tune the selected interfaces and the host side of their veth devices", func() {
+		interfaces, err := podNetwork{NetNS: netns, Interfaces: []string{"eth0", "ran"}}.interfaces()
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(interfaces).To(Equal([]podInterface{
+			{Name: "eth0", NetNS: netns},
+			{Name: "ran", NetNS: netns},
+			{Name: "veth1234", Peer: "eth0"},
+		}))
+	})
+
+	It("should resolve the secondary networks requested from Multus", func() {
+		annotations := fields.Set{
+			crioannotations.TuningInterfacesAnnotation: "sriov-a,ns/sriov-b,ran",
+			multusNetworksAnnotation:                   "sriov-a, ns/sriov-b@fh0, ns/sriov-a",
+		}
+		entries, ok := requestedTuningInterfaces(annotations)
+		Expect(ok).To(BeTrue())
+
+		interfaces, err := resolveTuningInterfaces(entries, annotations, cniResult)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(interfaces).To(Equal([]string{"net1", "net3", "fh0", "ran"}))
+	})
+
+	It("should resolve the JSON networks requested from Multus", func() {
+		annotations := fields.Set{
+			multusNetworksAnnotation: `[{"name":"sriov-a","interface":"fh0"},{"name":"sriov-b","namespace":"ns"}]`,
+		}
+
+		interfaces, err := resolveTuningInterfaces([]string{"sriov-b", "sriov-a"}, annotations, "")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(interfaces).To(Equal([]string{"net2", "fh0"}))
+	})
+
+	It("should prefer the network status reported by Multus", func() {
+		annotations := fields.Set{
+			multusNetworksAnnotation:      "sriov-a",
+			multusNetworkStatusAnnotation: `[{"name":"ovn","interface":"eth1","default":true},{"name":"ns/sriov-a","interface":"net7"}]`,
+		}
+
+		interfaces, err := resolveTuningInterfaces([]string{"default", "sriov-a"}, annotations, cniResult)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(interfaces).To(Equal([]string{"eth1", "net7"}))
+	})
+
+	It("should resolve the default network with the CNI result", func() {
+		interfaces, err := resolveTuningInterfaces([]string{"default"}, fields.Set{}, cniResult)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(interfaces).To(Equal([]string{"eth0"}))
+
+		interfaces, err = resolveTuningInterfaces([]string{"default"}, fields.Set{}, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(interfaces).To(Equal([]string{defaultPodInterface}))
+	})
+
+	It("should fail to resolve an unknown network", func() {
+		_, err := resolveTuningInterfaces([]string{"ns/unknown"}, fields.Set{}, cniResult)
+
+		Expect(err).To(MatchError(ContainSubstring("neither a network of the pod nor an interface name")))
+	})
+})
@@ -0,0 +1,249 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/renameio"
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	"k8s.io/utils/cpuset"
+
+	"github.com/cri-o/cri-o/internal/config/node"
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/internal/oci"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+	"github.com/cri-o/cri-o/pkg/cpumask"
+)
+
+const (
+	// tuningPlansDir is the subdirectory of the tuning state directory the dry-run plans are saved to.
+	tuningPlansDir = "plans"
+	// planFeatureSharedCPUs is the feature of the planned changes of the shared CPUs, which cannot fail open.
+	planFeatureSharedCPUs = "shared-cpus"
+)
+
+// tuningPlan is the tuning the high-performance hooks would apply to a container in dry-run mode.
+type tuningPlan struct {
+	ContainerID string          `json:"containerID"`
+	Tuning      *tuning         `json:"tuning"`
+	Changes     []plannedChange `json:"changes"`
+}
+
+// plannedChange is a cgroup, sysfs or procfs file the hooks would write to tune a container.
+type plannedChange struct {
+	// Feature is the high-performance feature the write belongs to, as named in high_performance_fail_open,
+	// or planFeatureSharedCPUs.
+	Feature string `json:"feature"`
+	Path    string `json:"path"`
+	Value   string `json:"value"`
+}
+
+// dryRunTuning computes the plan of the tuning of the container, logs every change of it and saves it
+// to the tuning state directory, instead of applying the tuning.
+func (h *HighPerformanceHooks) dryRunTuning(ctx context.Context, c *oci.Container, s *sandbox.Sandbox, t *tuning) error {
+	podManager, containerManagers, err := libctrManagersForPodAndContainerCgroup(c, s.CgroupParent())
+	if err != nil {
+		return err
+	}
+	plan, err := h.planTuning(c, t, podManager, containerManagers, IrqSmpAffinityProcFile, sysCPUDir)
+	if err != nil {
+		return fmt.Errorf("plan the tuning of container %q: %w", c.ID(), err)
+	}
+	network, err := sandboxNetwork(s)
+	if err != nil {
+		return err
+	}
+	if t.VFQueues && !s.HostNetwork() && s.NetNsPath() != "" {
+		changes, err := planVFQueues(c, network)
+		if err != nil {
+			return fmt.Errorf("plan the VF queues of container %q: %w", c.ID(), err)
+		}
+		plan.Changes = append(plan.Changes, changes...)
+	}
+	if t.AFXDPQueues > 0 && !s.HostNetwork() && s.NetNsPath() != "" {
+		changes, err := planAFXDP(network, t.AFXDPQueues)
+		if err != nil {
+			return fmt.Errorf("plan the AF_XDP queues of container %q: %w", c.ID(), err)
+		}
+		plan.Changes = append(plan.Changes, changes...)
+	}
+	if t.PacketSteering != nil && !s.HostNetwork() && s.NetNsPath() != "" {
+		changes, err := h.planPacketSteering(c, network, *t.PacketSteering)
+		if err != nil {
+			return fmt.Errorf("plan the packet steering of container %q: %w", c.ID(), err)
+		}
+		plan.Changes = append(plan.Changes, changes...)
+	}
+	if t.VFIRQAffinity && !s.HostNetwork() && s.NetNsPath() != "" {
+		changes, err := planVFIRQAffinity(c, network)
+		if err != nil {
+			return fmt.Errorf("plan the VF IRQ affinity of container %q: %w", c.ID(), err)
+		}
+		plan.Changes = append(plan.Changes, changes...)
+	}
+	if t.NAPIAffinity != nil && !s.HostNetwork() && s.NetNsPath() != "" {
+		changes, err := h.planNAPIAffinity(c, network, *t.NAPIAffinity)
+		if err != nil {
+			return fmt.Errorf("plan the NAPI affinity of container %q: %w", c.ID(), err)
+		}
+		plan.Changes = append(plan.Changes, changes...)
+	}
+	if t.ARFS && !s.HostNetwork() && s.NetNsPath() != "" {
+		changes, err := planARFS(network)
+		if err != nil {
+			return fmt.Errorf("plan the accelerated RFS of container %q: %w", c.ID(), err)
+		}
+		plan.Changes = append(plan.Changes, changes...)
+	}
+	log.Infof(ctx, "Dry run: not applying the %d changes of the tuning of container %q", len(plan.Changes), c.ID())
+	for _, change := range plan.Changes {
+		log.WithFields(ctx, map[string]any{
+			"container": c.ID(),
+			"feature":   change.Feature,
+			"path":      change.Path,
+			"value":     change.Value,
+		}).Info("Dry run: the tuning of the container would write the file")
+	}
+	return saveTuningPlan(plan)
+}
+
+// planTuning returns the files written to apply the tuning to the container. Only the files set to the
+// tuned values are listed, not the files the hooks read or write to save and restore the original values.
+func (h *HighPerformanceHooks) planTuning(c *oci.Container, t *tuning, podManager cgroups.Manager, containerManagers []cgroups.Manager, irqSmpAffinityFile, cpuDir string) (*tuningPlan, error) {
+	plan := &tuningPlan{ContainerID: c.ID(), Tuning: t}
+	add := func(feature, path, value string) {
+		plan.Changes = append(plan.Changes, plannedChange{Feature: feature, Path: path, Value: value})
+	}
+	if len(containerManagers) == 0 {
+		return nil, errors.New("no cgroup manager found for the container")
+	}
+	ctrManager := containerManagers[len(containerManagers)-1]
+
+	if t.CPUs == "" {
+		if t.SharedCPUs || t.IRQLoadBalancingDisabled || t.CStates != nil || t.FreqGovernor != nil {
+			return nil, fmt.Errorf("find container %s CPUs", c.ID())
+		}
+	}
+	var cpus cpuset.CPUSet
+	if t.CPUs != "" {
+		var err error
+		if cpus, err = cpuset.Parse(t.CPUs); err != nil {
+			return nil, fmt.Errorf("failed to parse container %q cpus: %w", c.Name(), err)
+		}
+	}
+
+	// The exclusive CPUs are the ones the CPU load balancing gets disabled for,
+	// which live in the isolated child cgroup on cgroup v2 if the shared CPUs are requested.
+	exclusiveCgroup := ctrManager.Path("cpuset")
+	if t.SharedCPUs {
+		if h.sharedCPUs == "" {
+			return nil, fmt.Errorf("shared CPUs were requested for container %q but none are defined", c.Name())
+		}
+		sharedCPUSet, err := cpuset.Parse(h.sharedCPUs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse shared cpus: %w", err)
+		}
+		add(planFeatureSharedCPUs, filepath.Join(ctrManager.Path("cpuset"), cpusetCpus), cpus.Union(sharedCPUSet).String())
+		if node.CgroupIsV2() {
+			exclusiveCgroup = filepath.Join(ctrManager.Path(""), isolatedChildCgroup)
+			add(planFeatureSharedCPUs, filepath.Join(ctrManager.Path(""), cgroupSubTreeControl), "+cpu +cpuset")
+			add(planFeatureSharedCPUs, filepath.Join(exclusiveCgroup, cpusetCpus), cpus.String())
+		}
+	}
+
+	if t.CPULoadBalancingDisabled {
+		if node.CgroupIsV2() {
+			add(libconfig.HighPerformanceFeatureCPULoadBalancing, filepath.Join(exclusiveCgroup, cpusetCpusPartition), "isolated")
+		} else {
+			for i := len(containerManagers) - 1; i >= 0; i-- {
+				add(libconfig.HighPerformanceFeatureCPULoadBalancing, filepath.Join(containerManagers[i].Path("cpuset"), "cpuset.sched_load_balance"), "0")
+			}
+		}
+	}
+
+	if t.IRQLoadBalancingDisabled {
+		content, err := hostFS.ReadFile(irqSmpAffinityFile)
+		if err != nil {
+			return nil, err
+		}
+		mask, bannedCPUs, err := cpumask.Update(t.CPUs, strings.TrimSpace(string(content)), false)
+		if err != nil {
+			return nil, err
+		}
+		add(libconfig.HighPerformanceFeatureIRQLoadBalancing, irqSmpAffinityFile, mask)
+		if fileExists(h.irqBalanceConfigFile) {
+			add(libconfig.HighPerformanceFeatureIRQLoadBalancing, h.irqBalanceConfigFile, irqBalanceBannedCpus+"="+bannedCPUs)
+		}
+	}
+
+	if t.CPUQuotaDisabled {
+		file, value := cgroupV1QuotaFile, "-1"
+		if node.CgroupIsV2() {
+			file, value = cgroupV2QuotaFile, "max"
+		}
+		for _, mgr := range append([]cgroups.Manager{podManager}, containerManagers...) {
+			add(libconfig.HighPerformanceFeatureCPUQuota, filepath.Join(mgr.Path("cpu"), file), value)
+		}
+	}
+
+	if t.CStates != nil {
+		latency, err := convertAnnotationToLatency(*t.CStates)
+		if err != nil {
+			return nil, err
+		}
+		if latency != "" {
+			for _, cpu := range cpus.List() {
+				add(libconfig.HighPerformanceFeatureCPUCStates, fmt.Sprintf("%s/cpu%d/power/pm_qos_resume_latency_us", cpuDir, cpu), latency)
+			}
+		}
+	}
+
+	if t.FreqGovernor != nil {
+		for _, cpu := range cpus.List() {
+			add(libconfig.HighPerformanceFeatureCPUFreqGovernor, fmt.Sprintf("%s/cpu%d/cpufreq/scaling_governor", cpuDir, cpu), *t.FreqGovernor)
+		}
+	}
+	return plan, nil
+}
+
+// tuningPlanFile returns the file the plan of the container is saved to, empty if the tuning store is not persisted.
+func tuningPlanFile(containerID string) string {
+	tuningStore.Lock()
+	defer tuningStore.Unlock()
+	if tuningStore.dir == "" {
+		return ""
+	}
+	return filepath.Join(tuningStore.dir, tuningPlansDir, containerID+".json")
+}
+
+func saveTuningPlan(plan *tuningPlan) error {
+	file := tuningPlanFile(plan.ContainerID)
+	if file == "" {
+		return nil
+	}
+	content, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		return err
+	}
+	return renameio.WriteFile(file, content, 0o600)
+}
+
+// removeTuningPlan removes the plan saved for the container, if any.
+func removeTuningPlan(ctx context.Context, containerID string) {
+	file := tuningPlanFile(containerID)
+	if file == "" {
+		return
+	}
+	if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Warnf(ctx, "Failed to remove the tuning plan of container %q: %v", containerID, err)
+	}
+}
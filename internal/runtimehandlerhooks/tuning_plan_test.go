@@ -0,0 +1,133 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+
+	"github.com/cri-o/cri-o/internal/config/node"
+	"github.com/cri-o/cri-o/internal/oci"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+)
+
+var _ = Describe("planTuning", func() {
+	var (
+		c                  *oci.Container
+		podManager         cgroups.Manager
+		containerManagers  []cgroups.Manager
+		irqSmpAffinityFile string
+		cpuDir             string
+	)
+
+	BeforeEach(func() {
+		var err error
+		c = newTestContainer("ctr1", "cnt1", "sandboxID")
+		c.SetSpec(&specs.Spec{Linux: &specs.Linux{Resources: &specs.LinuxResources{CPU: &specs.LinuxCPU{Cpus: "2-3"}}}})
+
+		podManager, err = libctrManager("pod", "/kubepods", false)
+		Expect(err).ToNot(HaveOccurred())
+		containerManager, err := libctrManager("ctr1", "/kubepods/pod", false)
+		Expect(err).ToNot(HaveOccurred())
+		containerManagers = []cgroups.Manager{containerManager}
+
+		dir := GinkgoT().TempDir()
+		irqSmpAffinityFile = filepath.Join(dir, "irq_smp_affinity")
+		Expect(os.WriteFile(irqSmpAffinityFile, []byte("ff\n"), 0o644)).To(Succeed())
+		cpuDir = filepath.Join(dir, "cpu")
+	})
+
+	It("should list the files written by the tuning without writing them", func() {
+		h := &HighPerformanceHooks{irqBalanceConfigFile: filepath.Join(cpuDir, "missing")}
+		governor := "performance"
+		cStates := "disable"
+		t := &tuning{CPUs: "2-3", IRQLoadBalancingDisabled: true, CPUQuotaDisabled: true, CStates: &cStates, FreqGovernor: &governor}
+
+		plan, err := h.planTuning(c, t, podManager, containerManagers, irqSmpAffinityFile, cpuDir)
+		Expect(err).ToNot(HaveOccurred())
+
+		quotaFile, quota := cgroupV1QuotaFile, "-1"
+		if node.CgroupIsV2() {
+			quotaFile, quota = cgroupV2QuotaFile, "max"
+		}
+		Expect(plan.ContainerID).To(Equal("ctr1"))
+		Expect(plan.Changes).To(Equal([]plannedChange{
+			{Feature: libconfig.HighPerformanceFeatureIRQLoadBalancing, Path: irqSmpAffinityFile, Value: "000000f3"},
+			{Feature: libconfig.HighPerformanceFeatureCPUQuota, Path: filepath.Join(podManager.Path("cpu"), quotaFile), Value: quota},
+			{Feature: libconfig.HighPerformanceFeatureCPUQuota, Path: filepath.Join(containerManagers[0].Path("cpu"), quotaFile), Value: quota},
+			{Feature: libconfig.HighPerformanceFeatureCPUCStates, Path: filepath.Join(cpuDir, "cpu2/power/pm_qos_resume_latency_us"), Value: "n/a"},
+			{Feature: libconfig.HighPerformanceFeatureCPUCStates, Path: filepath.Join(cpuDir, "cpu3/power/pm_qos_resume_latency_us"), Value: "n/a"},
+			{Feature: libconfig.HighPerformanceFeatureCPUFreqGovernor, Path: filepath.Join(cpuDir, "cpu2/cpufreq/scaling_governor"), Value: governor},
+			{Feature: libconfig.HighPerformanceFeatureCPUFreqGovernor, Path: filepath.Join(cpuDir, "cpu3/cpufreq/scaling_governor"), Value: governor},
+		}))
+
+		content, err := os.ReadFile(irqSmpAffinityFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(Equal("ff\n"))
+		Expect(cpuDir).ToNot(BeAnExistingFile())
+	})
+
+	It("should plan the shared CPUs of the container", func() {
+		h := &HighPerformanceHooks{sharedCPUs: "0"}
+
+		plan, err := h.planTuning(c, &tuning{CPUs: "2-3", SharedCPUs: true}, podManager, containerManagers, irqSmpAffinityFile, cpuDir)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(plan.Changes).ToNot(BeEmpty())
+		Expect(plan.Changes[0]).To(Equal(plannedChange{
+			Feature: planFeatureSharedCPUs,
+			Path:    filepath.Join(containerManagers[0].Path("cpuset"), cpusetCpus),
+			Value:   "0,2-3",
+		}))
+	})
+
+	It("should fail if the shared CPUs are not defined", func() {
+		h := &HighPerformanceHooks{}
+
+		_, err := h.planTuning(c, &tuning{CPUs: "2-3", SharedCPUs: true}, podManager, containerManagers, irqSmpAffinityFile, cpuDir)
+		Expect(err).To(MatchError(ContainSubstring("none are defined")))
+	})
+
+	Describe("saveTuningPlan", func() {
+		AfterEach(func() {
+			tuningStore.Lock()
+			tuningStore.dir = ""
+			tuningStore.Unlock()
+		})
+
+		It("should not save the plan if the tuning store is not persisted", func() {
+			Expect(saveTuningPlan(&tuningPlan{ContainerID: "ctr1"})).To(Succeed())
+			Expect(tuningPlanFile("ctr1")).To(BeEmpty())
+		})
+
+		It("should save the plan until it gets removed", func() {
+			dir := filepath.Join(GinkgoT().TempDir(), "tuning")
+			Expect(LoadTuningStore(context.TODO(), dir)).To(Succeed())
+			plan := &tuningPlan{
+				ContainerID: "ctr1",
+				Tuning:      &tuning{CPUs: "2-3", CPUQuotaDisabled: true},
+				Changes:     []plannedChange{{Feature: libconfig.HighPerformanceFeatureCPUQuota, Path: "/sys/fs/cgroup/cpu.max", Value: "max"}},
+			}
+
+			Expect(saveTuningPlan(plan)).To(Succeed())
+			file := filepath.Join(dir, tuningPlansDir, "ctr1.json")
+			content, err := os.ReadFile(file)
+			Expect(err).ToNot(HaveOccurred())
+			saved := &tuningPlan{}
+			Expect(json.Unmarshal(content, saved)).To(Succeed())
+			Expect(saved).To(Equal(plan))
+
+			// the plans are not mistaken for tuning records on restart
+			Expect(LoadTuningStore(context.TODO(), dir)).To(Succeed())
+			Expect(tuningRecorded("ctr1")).To(BeFalse())
+
+			removeTuningPlan(context.TODO(), "ctr1")
+			Expect(file).ToNot(BeAnExistingFile())
+		})
+	})
+})
@@ -0,0 +1,361 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/cri-o/cri-o/internal/log"
+	crioann "github.com/cri-o/cri-o/pkg/annotations"
+	"github.com/cri-o/cri-o/server/metrics"
+)
+
+// TuningState is the outcome of the node tuning of a container by the high-performance hooks.
+type TuningState string
+
+const (
+	// TuningStateApplied is the state of a container with all its requested tuning applied.
+	TuningStateApplied TuningState = "Applied"
+	// TuningStatePartiallyApplied is the state of a container for which some features failed open.
+	TuningStatePartiallyApplied TuningState = "PartiallyApplied"
+	// TuningStateReverted is the state of a container with all its tuning reverted.
+	TuningStateReverted TuningState = "Reverted"
+	// TuningStatePartiallyReverted is the state of a container for which some features could not be reverted.
+	TuningStatePartiallyReverted TuningState = "PartiallyReverted"
+	// TuningStateFailed is the state of a container whose tuning failed to be applied or reverted.
+	TuningStateFailed TuningState = "Failed"
+	// TuningStateSkipped is the state of a container whose requested tuning got skipped by policy.
+	TuningStateSkipped TuningState = "Skipped"
+)
+
+// The machine-readable reasons of the tuning which was not fully applied or reverted.
+const (
+	// ReasonTuningFailed is the reason of a tuning which failed to be applied.
+	ReasonTuningFailed = "TuningFailed"
+	// ReasonTuningRevertFailed is the reason of a tuning which failed to be reverted.
+	ReasonTuningRevertFailed = "TuningRevertFailed"
+	// ReasonTuningFeaturesFailedOpen is the reason of a tuning some features of which failed open.
+	ReasonTuningFeaturesFailedOpen = "TuningFeaturesFailedOpen"
+	// ReasonTuningNotEffective is the reason of a tuning which is still not effective once verified.
+	ReasonTuningNotEffective = "TuningNotEffective"
+	// ReasonDPDKNotReady is the reason of a container annotated as running a DPDK application on a node not ready for it.
+	ReasonDPDKNotReady = "DPDKNotReady"
+)
+
+// The machine-readable reasons of the tuning annotations which could not be honored.
+const (
+	// ReasonIrqbalanceNotFound is the reason of an IRQ load balancing annotation not honored by irqbalance,
+	// as it is not installed.
+	ReasonIrqbalanceNotFound = "IrqbalanceNotFound"
+	// ReasonCPUFreqUnavailable is the reason of a CPU frequency governor annotation on CPUs without cpufreq driver.
+	ReasonCPUFreqUnavailable = "CPUFreqUnavailable"
+	// ReasonSharedCPUsNotRequested is the reason of an init affinity annotation of a container
+	// which does not request the shared CPUs.
+	ReasonSharedCPUsNotRequested = "SharedCPUsNotRequested"
+	// ReasonRuntimeTypeNotTuned is the reason of the tuning annotations of the containers of the runtime
+	// handlers whose runtime type is configured to skip the tuning.
+	ReasonRuntimeTypeNotTuned = "RuntimeTypeNotTuned"
+	// ReasonHostNetwork is the reason of a network tuning annotation of a pod on the host network,
+	// whose interfaces are the ones of the node.
+	ReasonHostNetwork = "HostNetwork"
+	// ReasonNoSRIOVVF is the reason of a VF queues or VF IRQ affinity annotation of a pod without SR-IOV VF attached.
+	ReasonNoSRIOVVF = "NoSRIOVVF"
+	// ReasonNAPINotThreaded is the reason of a NAPI affinity annotation of a pod without interface polling
+	// in NAPI threads, as threaded NAPI is not enabled for any of them.
+	ReasonNAPINotThreaded = "NAPINotThreaded"
+	// ReasonNoRSSInterface is the reason of an AF_XDP annotation of a pod without interface spreading its flows
+	// over combined channels with an RSS indirection table, whose queues could be reserved.
+	ReasonNoRSSInterface = "NoRSSInterface"
+	// ReasonResctrlNotMounted is the reason of an RDT class annotation on a node without the resctrl filesystem mounted.
+	ReasonResctrlNotMounted = "ResctrlNotMounted"
+)
+
+// tuningNotEffectiveError is returned when the tuning of a container is still not effective
+// once the time given to its verification is over.
+type tuningNotEffectiveError struct {
+	// Files are the tuned files which do not hold the tuning of the container.
+	Files []string
+}
+
+func (e *tuningNotEffectiveError) Error() string {
+	return "tuning is not effective in " + strings.Join(e.Files, ", ")
+}
+
+// dpdkNotReadyError is returned when the node is not ready to run the DPDK application of a container.
+type dpdkNotReadyError struct {
+	Container string
+	// Problems are all the problems found, each telling how to fix it.
+	Problems []string
+}
+
+func (e *dpdkNotReadyError) Error() string {
+	return fmt.Sprintf("container %q is not ready for DPDK: %s", e.Container, strings.Join(e.Problems, "; "))
+}
+
+// TuningStatus is the outcome of the last tuning or revert of a container.
+type TuningStatus struct {
+	State TuningState
+	// Reason and Message are only set if the tuning was not fully applied or reverted.
+	Reason  string
+	Message string
+	// Unfulfilled are the reasons of the tuning annotations which could not be honored, keyed by annotation.
+	Unfulfilled map[string]string
+}
+
+// tuningStatuses are the statuses of the tuning of the containers, kept until the containers get removed.
+// The hooks are instantiated per request, so they are kept at package level.
+var tuningStatuses = struct {
+	sync.Mutex
+	statuses map[string]TuningStatus
+}{statuses: make(map[string]TuningStatus)}
+
+// TuningStatusAnnotations returns the annotations reporting the status of the tuning of the container
+// in its CRI status, nil if the container did not get tuned.
+func TuningStatusAnnotations(containerID string) map[string]string {
+	tuningStatuses.Lock()
+	defer tuningStatuses.Unlock()
+	status, ok := tuningStatuses.statuses[containerID]
+	if !ok {
+		return nil
+	}
+	annotations := map[string]string{crioann.TuningState: string(status.State)}
+	if status.Reason != "" {
+		annotations[crioann.TuningReason] = status.Reason
+		annotations[crioann.TuningMessage] = status.Message
+	}
+	if len(status.Unfulfilled) > 0 {
+		unfulfilled := make([]string, 0, len(status.Unfulfilled))
+		for annotation, reason := range status.Unfulfilled {
+			unfulfilled = append(unfulfilled, annotation+"="+reason)
+		}
+		slices.Sort(unfulfilled)
+		annotations[crioann.TuningUnfulfilled] = strings.Join(unfulfilled, ",")
+	}
+	return annotations
+}
+
+// ForgetTuningStatus forgets about the status of the tuning of the container, once it got removed.
+func ForgetTuningStatus(containerID string) {
+	tuningStatuses.Lock()
+	defer tuningStatuses.Unlock()
+	delete(tuningStatuses.statuses, containerID)
+}
+
+// tuningOutcome collects the features which failed open and the annotations which could not be honored
+// while the tuning of a container is applied or reverted.
+type tuningOutcome struct {
+	sync.Mutex
+	failedOpen  []string
+	unfulfilled map[string]string
+}
+
+type tuningOutcomeKey struct{}
+
+// withTuningOutcome returns a context collecting the features failing open with it into the returned outcome.
+func withTuningOutcome(ctx context.Context) (context.Context, *tuningOutcome) {
+	outcome := &tuningOutcome{}
+	return context.WithValue(ctx, tuningOutcomeKey{}, outcome), outcome
+}
+
+// noteFailedOpen adds the failure of the feature to the outcome collected by ctx, if any.
+func noteFailedOpen(ctx context.Context, feature string, err error) {
+	outcome, ok := ctx.Value(tuningOutcomeKey{}).(*tuningOutcome)
+	if !ok {
+		return
+	}
+	outcome.Lock()
+	defer outcome.Unlock()
+	outcome.failedOpen = append(outcome.failedOpen, fmt.Sprintf("%s: %v", feature, err))
+}
+
+// noteUnfulfilledAnnotation counts the tuning annotation which could not be honored for the reason, and adds it
+// to the outcome collected by ctx, if any, so that it is reported in the status of the container and thus in the
+// container events carrying it.
+func noteUnfulfilledAnnotation(ctx context.Context, annotation, reason string) {
+	metrics.Instance().MetricTuningUnfulfilledAnnotationsInc(annotation, reason)
+	outcome, ok := ctx.Value(tuningOutcomeKey{}).(*tuningOutcome)
+	if !ok {
+		return
+	}
+	outcome.Lock()
+	defer outcome.Unlock()
+	if outcome.unfulfilled == nil {
+		outcome.unfulfilled = make(map[string]string)
+	}
+	outcome.unfulfilled[annotation] = reason
+}
+
+// reportTuningOutcome sets the status of the tuning of the container once it got applied,
+// or reverted if reverted is set, with err the failure of the operation, if any.
+func reportTuningOutcome(ctx context.Context, containerID string, outcome *tuningOutcome, reverted bool, err error) {
+	outcome.Lock()
+	failedOpen := strings.Join(outcome.failedOpen, "; ")
+	unfulfilled := maps.Clone(outcome.unfulfilled)
+	outcome.Unlock()
+
+	var (
+		notEffective *tuningNotEffectiveError
+		dpdkNotReady *dpdkNotReadyError
+	)
+	status := TuningStatus{State: TuningStateApplied}
+	switch {
+	case errors.As(err, &notEffective):
+		status = TuningStatus{State: TuningStateFailed, Reason: ReasonTuningNotEffective, Message: err.Error()}
+	case errors.As(err, &dpdkNotReady):
+		status = TuningStatus{State: TuningStateFailed, Reason: ReasonDPDKNotReady, Message: err.Error()}
+	case err != nil && reverted:
+		status = TuningStatus{State: TuningStateFailed, Reason: ReasonTuningRevertFailed, Message: err.Error()}
+	case err != nil:
+		status = TuningStatus{State: TuningStateFailed, Reason: ReasonTuningFailed, Message: err.Error()}
+	case failedOpen != "" && reverted:
+		status = TuningStatus{State: TuningStatePartiallyReverted, Reason: ReasonTuningFeaturesFailedOpen, Message: failedOpen}
+	case failedOpen != "":
+		status = TuningStatus{State: TuningStatePartiallyApplied, Reason: ReasonTuningFeaturesFailedOpen, Message: failedOpen}
+	case reverted:
+		status.State = TuningStateReverted
+	}
+	status.Unfulfilled = unfulfilled
+
+	if status.Reason != "" {
+		log.Warnf(ctx, "Tuning state of container %q is %s (%s): %s", containerID, status.State, status.Reason, status.Message)
+	} else {
+		log.Debugf(ctx, "Tuning state of container %q is %s", containerID, status.State)
+	}
+	tuningStatuses.Lock()
+	defer tuningStatuses.Unlock()
+	tuningStatuses.statuses[containerID] = status
+}
+
+// reportSkippedTuning sets the status of the tuning of the container skipped for the reason given by message.
+func reportSkippedTuning(ctx context.Context, containerID string, outcome *tuningOutcome, message string) {
+	outcome.Lock()
+	unfulfilled := maps.Clone(outcome.unfulfilled)
+	outcome.Unlock()
+
+	tuningStatuses.Lock()
+	defer tuningStatuses.Unlock()
+	tuningStatuses.statuses[containerID] = TuningStatus{
+		State:       TuningStateSkipped,
+		Reason:      ReasonRuntimeTypeNotTuned,
+		Message:     message,
+		Unfulfilled: unfulfilled,
+	}
+}
+
+// NodeTuningState is the tuning of the node bookkept by the high-performance hooks for the running containers.
+type NodeTuningState struct {
+	// ExclusiveCPUs are the exclusive CPUs of the tuned containers, keyed by container ID.
+	ExclusiveCPUs map[string]string `json:"exclusiveCPUs"`
+	// SharedCPUPools are the shared CPU pools accounted for in the quota of the pods consuming them.
+	SharedCPUPools []SharedCPUPool `json:"sharedCPUPools"`
+	IRQAffinity    IRQAffinity     `json:"irqAffinity"`
+	CPUAllocation  CPUAllocation   `json:"cpuAllocation"`
+	// NodeSysctls are the sysctls of the node held by the pods and containers, sorted by path.
+	NodeSysctls []NodeSysctl `json:"nodeSysctls"`
+}
+
+// NodeSysctl is a sysctl of the node set for the pods and containers holding it, and restored once the
+// last of them releases it.
+type NodeSysctl struct {
+	Path  string `json:"path"`
+	Value string `json:"value"`
+	// Original is the value of the sysctl restored once it is not held anymore.
+	Original string `json:"original"`
+	// Holders are the IDs of the pods and containers holding the sysctl, sorted.
+	Holders []string `json:"holders"`
+	// RefCount is the number of holders of the sysctl.
+	RefCount int `json:"refCount"`
+}
+
+// CPUAllocation is the partitioning of the online CPUs of the node resulting from the tuning of the containers.
+type CPUAllocation struct {
+	// Isolated are the exclusive CPUs of the containers with CPU load balancing disabled.
+	Isolated string `json:"isolated"`
+	// Exclusive are the other exclusive CPUs of the tuned containers.
+	Exclusive string `json:"exclusive"`
+	// Shared are the CPUs of the shared pools consumed by the containers.
+	Shared string `json:"shared"`
+	// Housekeeping are the remaining online CPUs, empty if they cannot be read.
+	Housekeeping string `json:"housekeeping"`
+}
+
+// SharedCPUPool is the shared CPU pool consumed by the containers of a pod.
+type SharedCPUPool struct {
+	SandboxID  string `json:"sandboxID"`
+	SharedCPUs string `json:"sharedCPUs"`
+	// Consumers are the exclusive CPUs of the containers consuming the pool, keyed by container ID.
+	Consumers map[string]string `json:"consumers"`
+}
+
+// IRQAffinity is the composition of the IRQ affinity mask of the node.
+type IRQAffinity struct {
+	// Mask is the current IRQ affinity mask, empty if it cannot be read.
+	Mask string `json:"mask,omitempty"`
+	// CPUs are the CPUs set in the current mask.
+	CPUs string `json:"cpus,omitempty"`
+	// BannedCPUs are the CPUs of all the containers banning them from the mask.
+	BannedCPUs string `json:"bannedCPUs,omitempty"`
+	// Containers are the CPUs banned from the mask by the containers with IRQ load balancing disabled,
+	// keyed by container ID.
+	Containers map[string]string `json:"containers"`
+	// Error is the failure to read the current mask, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// TuningVerification is the tuning applied to a container re-validated against the live state of the node.
+type TuningVerification struct {
+	ContainerID string `json:"containerID"`
+	// Verified is set if all the items hold the tuning of the container.
+	Verified bool                     `json:"verified"`
+	Items    []TuningVerificationItem `json:"items"`
+}
+
+// TuningVerificationItem compares the value a tuned file of a container is expected to hold with its actual value.
+type TuningVerificationItem struct {
+	Path     string `json:"path"`
+	Expected string `json:"expected"`
+	// Actual is the value of the file, empty if it cannot be read.
+	Actual    string `json:"actual"`
+	Effective bool   `json:"effective"`
+	// Error is the failure to read or verify the file, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// ContainerTuningStateVersion is the version of the format of the container tuning state files,
+// increased on every incompatible change of ContainerTuningState.
+const ContainerTuningStateVersion = 1
+
+// ContainerTuningState is the tuning of a container written to its state file, for node-local agents like
+// performance profilers or DPDK managers. Its format is stable within a version.
+type ContainerTuningState struct {
+	Version     int    `json:"version"`
+	ContainerID string `json:"containerID"`
+	// ExclusiveCPUs are all the exclusive CPUs of the container, isolated or not.
+	ExclusiveCPUs string `json:"exclusiveCPUs"`
+	// IsolatedCPUs are the exclusive CPUs of the container removed from the CPU load balancing.
+	IsolatedCPUs string `json:"isolatedCPUs"`
+	// SharedCPUs are the shared CPUs the container may run on besides its exclusive CPUs.
+	SharedCPUs string `json:"sharedCPUs"`
+	// Tunings are the high-performance features active for the container, like "cpu-load-balancing", sorted.
+	Tunings []string `json:"tunings"`
+}
+
+// The tuning health checks, as named in TuningHealthCheck.
+const (
+	TuningHealthCheckStateDir   = "state-dir"
+	TuningHealthCheckIrqbalance = "irqbalance"
+	TuningHealthCheckResctrl    = "resctrl"
+	TuningHealthCheckStateStore = "state-store"
+)
+
+// TuningHealthCheck is the outcome of a check of the capability of the node to get tuned by the hooks.
+type TuningHealthCheck struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+	// Message is the reason of the check failure, if any.
+	Message string `json:"message,omitempty"`
+}
@@ -0,0 +1,104 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	crioann "github.com/cri-o/cri-o/pkg/annotations"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+)
+
+var _ = Describe("tuningStatus", func() {
+	const containerID = "ctr1"
+
+	AfterEach(func() {
+		ForgetTuningStatus(containerID)
+	})
+
+	It("should not report the status of containers which did not get tuned", func() {
+		Expect(TuningStatusAnnotations(containerID)).To(BeNil())
+	})
+
+	It("should report the applied and reverted tuning", func() {
+		ctx, outcome := withTuningOutcome(context.TODO())
+		reportTuningOutcome(ctx, containerID, outcome, false, nil)
+		Expect(TuningStatusAnnotations(containerID)).To(Equal(map[string]string{
+			crioann.TuningState: string(TuningStateApplied),
+		}))
+
+		ctx, outcome = withTuningOutcome(context.TODO())
+		reportTuningOutcome(ctx, containerID, outcome, true, nil)
+		Expect(TuningStatusAnnotations(containerID)).To(Equal(map[string]string{
+			crioann.TuningState: string(TuningStateReverted),
+		}))
+
+		ForgetTuningStatus(containerID)
+		Expect(TuningStatusAnnotations(containerID)).To(BeNil())
+	})
+
+	It("should report the features which failed open", func() {
+		ctx, outcome := withTuningOutcome(context.TODO())
+		noteFailedOpen(ctx, libconfig.HighPerformanceFeatureIRQLoadBalancing, errors.New("no irqbalance"))
+		noteFailedOpen(ctx, libconfig.HighPerformanceFeatureCPUFreqGovernor, errors.New("no cpufreq"))
+		reportTuningOutcome(ctx, containerID, outcome, false, nil)
+
+		Expect(TuningStatusAnnotations(containerID)).To(Equal(map[string]string{
+			crioann.TuningState:   string(TuningStatePartiallyApplied),
+			crioann.TuningReason:  ReasonTuningFeaturesFailedOpen,
+			crioann.TuningMessage: "irq-load-balancing: no irqbalance; cpu-freq-governor: no cpufreq",
+		}))
+	})
+
+	It("should report the features which failed open on revert", func() {
+		ctx, outcome := withTuningOutcome(context.TODO())
+		noteFailedOpen(ctx, libconfig.HighPerformanceFeatureCPUCStates, errors.New("busy"))
+		reportTuningOutcome(ctx, containerID, outcome, true, nil)
+
+		Expect(TuningStatusAnnotations(containerID)).To(HaveKeyWithValue(crioann.TuningState, string(TuningStatePartiallyReverted)))
+	})
+
+	It("should report the annotations which could not be honored", func() {
+		ctx, outcome := withTuningOutcome(context.TODO())
+		noteUnfulfilledAnnotation(ctx, crioann.IRQLoadBalancingAnnotation, ReasonIrqbalanceNotFound)
+		noteUnfulfilledAnnotation(ctx, crioann.CPUFreqGovernorAnnotation, ReasonCPUFreqUnavailable)
+		noteFailedOpen(ctx, libconfig.HighPerformanceFeatureCPUFreqGovernor, errors.New("no cpufreq"))
+		reportTuningOutcome(ctx, containerID, outcome, false, nil)
+
+		Expect(TuningStatusAnnotations(containerID)).To(And(
+			HaveKeyWithValue(crioann.TuningState, string(TuningStatePartiallyApplied)),
+			HaveKeyWithValue(crioann.TuningUnfulfilled,
+				crioann.CPUFreqGovernorAnnotation+"="+ReasonCPUFreqUnavailable+","+crioann.IRQLoadBalancingAnnotation+"="+ReasonIrqbalanceNotFound),
+		))
+	})
+
+	It("should report the failure of the tuning", func() {
+		ctx, outcome := withTuningOutcome(context.TODO())
+		noteFailedOpen(ctx, libconfig.HighPerformanceFeatureCPUCStates, errors.New("busy"))
+		reportTuningOutcome(ctx, containerID, outcome, false, errors.New("set CPU CFS quota: invalid argument"))
+		Expect(TuningStatusAnnotations(containerID)).To(Equal(map[string]string{
+			crioann.TuningState:   string(TuningStateFailed),
+			crioann.TuningReason:  ReasonTuningFailed,
+			crioann.TuningMessage: "set CPU CFS quota: invalid argument",
+		}))
+
+		ctx, outcome = withTuningOutcome(context.TODO())
+		reportTuningOutcome(ctx, containerID, outcome, true, errors.New("set IRQ load balancing: busy"))
+		Expect(TuningStatusAnnotations(containerID)).To(HaveKeyWithValue(crioann.TuningReason, ReasonTuningRevertFailed))
+	})
+
+	It("should report the tuning which is not effective", func() {
+		ctx, outcome := withTuningOutcome(context.TODO())
+		err := fmt.Errorf("verify: %w", &tuningNotEffectiveError{Files: []string{IrqSmpAffinityProcFile}})
+		reportTuningOutcome(ctx, containerID, outcome, false, err)
+
+		Expect(TuningStatusAnnotations(containerID)).To(Equal(map[string]string{
+			crioann.TuningState:   string(TuningStateFailed),
+			crioann.TuningReason:  ReasonTuningNotEffective,
+			crioann.TuningMessage: "verify: tuning is not effective in " + IrqSmpAffinityProcFile,
+		}))
+	})
+})
@@ -0,0 +1,383 @@
+package runtimehandlerhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/google/renameio"
+
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/internal/oci"
+)
+
+// tuningStore records the tuning applied to every running container, keyed by container ID,
+// along with the tuning applied to the pods, like their interrupt coalescing, keyed by sandbox ID.
+// It allows detecting a PreStart hook run twice for the same container, e.g. a retried CRI call,
+// which would otherwise save the already tuned values as the original ones to restore on stop.
+// Once loaded by LoadTuningStore, the records are persisted to disk on every change, so that
+// CRI-O does not lose track of the tuning to revert when it crashes or restarts.
+// The hooks are instantiated per request, so the bookkeeping is kept at package level.
+var tuningStore = struct {
+	sync.Mutex
+	// dir is the directory the records are persisted to, empty if they are only kept in memory.
+	dir        string
+	containers map[string]*tuningRecord
+}{containers: make(map[string]*tuningRecord)}
+
+// tuningRecord is the record of the tuning applied to a container.
+type tuningRecord struct {
+	// Tuning is the tuning applied to the container, nil if it was only partially applied.
+	Tuning *tuning `json:"tuning,omitempty"`
+	// Writes are the files written to tune the container, in the order of their first write.
+	Writes []fileWrite `json:"writes,omitempty"`
+}
+
+// fileWrite describes a sysfs, procfs or cgroup file written to tune a container.
+type fileWrite struct {
+	Path string `json:"path"`
+	// NetNS is the network namespace of the network device the file belongs to, empty for the host one.
+	NetNS string `json:"netns,omitempty"`
+	// Ifindex is the index of the network device the file belongs to in its network namespace,
+	// which keeps identifying the device once renamed.
+	Ifindex int `json:"ifindex,omitempty"`
+	// BusID is the bus ID, like the PCI address, of the hardware device backing the network device the file
+	// belongs to, which keeps identifying it once moved back to the host network namespace, e.g. along with
+	// the deletion of the network namespace of the pod. It is empty for the virtual devices.
+	BusID string `json:"busId,omitempty"`
+	// Shared tells the file of the node is held by several pods at once, and only gets restored once
+	// the last of them releases it, see acquireNodeSysctl.
+	Shared bool `json:"shared,omitempty"`
+	// Original is the value of the file before it got written for the container.
+	Original string `json:"original"`
+	Value    string `json:"value"`
+}
+
+// LoadTuningStore loads the tuning records found in dir, and persists the records to dir from now on.
+// Records which cannot be read are discarded, as there is no way to revert their tuning anyway.
+func LoadTuningStore(ctx context.Context, dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("create tuning state directory: %w", err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	defer reportNodeCPUAllocation()
+	tuningStore.Lock()
+	defer tuningStore.Unlock()
+	tuningStore.dir = dir
+	for _, file := range files {
+		containerID := strings.TrimSuffix(filepath.Base(file), ".json")
+		record, err := readTuningRecord(file)
+		if err != nil {
+			log.Warnf(ctx, "Discarding the tuning record of container %q: %v", containerID, err)
+			if err := os.Remove(file); err != nil {
+				log.Warnf(ctx, "Failed to remove the tuning record of container %q: %v", containerID, err)
+			}
+			continue
+		}
+		tuningStore.containers[containerID] = record
+		reportIsolationState(containerID, record.Tuning)
+	}
+	if len(tuningStore.containers) > 0 {
+		log.Infof(ctx, "Loaded the tuning records of %d containers from %s", len(tuningStore.containers), dir)
+	}
+	return nil
+}
+
+func readTuningRecord(file string) (*tuningRecord, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	record := &tuningRecord{}
+	if err := json.Unmarshal(content, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// persistTuningRecord writes the record of the container to the store directory, if any.
+// The record gets replaced atomically, so a crash never leaves a truncated record behind.
+// The caller must hold the lock of the store.
+func persistTuningRecord(containerID string) error {
+	if tuningStore.dir == "" {
+		return nil
+	}
+	file := filepath.Join(tuningStore.dir, containerID+".json")
+	record, ok := tuningStore.containers[containerID]
+	if !ok {
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	content, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return renameio.WriteFile(file, content, 0o600)
+}
+
+// tuningApplied returns true if the tuning is the one already applied to the container.
+func tuningApplied(containerID string, t *tuning) bool {
+	tuningStore.Lock()
+	defer tuningStore.Unlock()
+	record, ok := tuningStore.containers[containerID]
+	return ok && record.Tuning != nil && reflect.DeepEqual(record.Tuning, t)
+}
+
+// tuningRecorded returns true if some tuning is recorded for the container, even partially applied.
+func tuningRecorded(containerID string) bool {
+	tuningStore.Lock()
+	defer tuningStore.Unlock()
+	_, ok := tuningStore.containers[containerID]
+	return ok
+}
+
+// recordedContainers returns the IDs of the containers with a tuning record, sorted.
+func recordedContainers() []string {
+	tuningStore.Lock()
+	defer tuningStore.Unlock()
+	return slices.Sorted(maps.Keys(tuningStore.containers))
+}
+
+// recordedTuning returns a copy of the tuning record of the container, if any.
+func recordedTuning(containerID string) (tuningRecord, bool) {
+	tuningStore.Lock()
+	defer tuningStore.Unlock()
+	record, ok := tuningStore.containers[containerID]
+	if !ok {
+		return tuningRecord{}, false
+	}
+	return tuningRecord{Tuning: record.Tuning, Writes: slices.Clone(record.Writes)}, true
+}
+
+// recordedHolds returns the writes of the shared file recorded for the containers and pods holding it, keyed by ID.
+func recordedHolds(path string) map[string]fileWrite {
+	tuningStore.Lock()
+	defer tuningStore.Unlock()
+	holds := make(map[string]fileWrite)
+	for id, record := range tuningStore.containers {
+		for _, w := range record.Writes {
+			if w.Shared && w.Path == path {
+				holds[id] = w
+			}
+		}
+	}
+	return holds
+}
+
+// recordAppliedTuning records the tuning as applied to the container.
+func recordAppliedTuning(ctx context.Context, containerID string, t *tuning) {
+	defer writeContainerStateFile(ctx, containerID)
+	defer reportNodeCPUAllocation()
+	tuningStore.Lock()
+	defer tuningStore.Unlock()
+	record, ok := tuningStore.containers[containerID]
+	if !ok {
+		record = &tuningRecord{}
+		tuningStore.containers[containerID] = record
+	}
+	record.Tuning = t
+	reportIsolationState(containerID, t)
+	if err := persistTuningRecord(containerID); err != nil {
+		log.Warnf(ctx, "Failed to persist the tuning record of container %q: %v", containerID, err)
+	}
+}
+
+// recordTuningWrite records the write of the file to tune the container.
+// The original value of a file written several times is the one it had before the first write.
+func recordTuningWrite(ctx context.Context, containerID, path, original, value string) {
+	recordFileWrite(ctx, containerID, fileWrite{Path: path, Original: original, Value: value})
+}
+
+// recordFileWrite records the write of the file, identified by its path and network namespace, to tune the container.
+func recordFileWrite(ctx context.Context, containerID string, w fileWrite) {
+	tuningStore.Lock()
+	defer tuningStore.Unlock()
+	record, ok := tuningStore.containers[containerID]
+	if !ok {
+		record = &tuningRecord{}
+		tuningStore.containers[containerID] = record
+	}
+	found := false
+	for i := range record.Writes {
+		if record.Writes[i].Path == w.Path && record.Writes[i].NetNS == w.NetNS {
+			record.Writes[i].Value = w.Value
+			found = true
+			break
+		}
+	}
+	if !found {
+		record.Writes = append(record.Writes, w)
+	}
+	if err := persistTuningRecord(containerID); err != nil {
+		log.Warnf(ctx, "Failed to persist the tuning record of container %q: %v", containerID, err)
+	}
+}
+
+// forgetFileWrite drops the write of the file from the record of the container, once it got reverted
+// while the rest of its tuning is still applied.
+func forgetFileWrite(ctx context.Context, containerID, path string) {
+	tuningStore.Lock()
+	defer tuningStore.Unlock()
+	record, ok := tuningStore.containers[containerID]
+	if !ok {
+		return
+	}
+	record.Writes = slices.DeleteFunc(record.Writes, func(w fileWrite) bool {
+		return w.Path == path
+	})
+	if err := persistTuningRecord(containerID); err != nil {
+		log.Warnf(ctx, "Failed to persist the tuning record of container %q: %v", containerID, err)
+	}
+}
+
+// forgetAppliedTuning forgets about the tuning applied to the container, once it got reverted.
+func forgetAppliedTuning(ctx context.Context, containerID string) {
+	defer writeContainerStateFile(ctx, containerID)
+	defer reportNodeCPUAllocation()
+	tuningStore.Lock()
+	defer tuningStore.Unlock()
+	if _, ok := tuningStore.containers[containerID]; !ok {
+		return
+	}
+	delete(tuningStore.containers, containerID)
+	reportIsolationState(containerID, nil)
+	if err := persistTuningRecord(containerID); err != nil {
+		log.Warnf(ctx, "Failed to remove the tuning record of container %q: %v", containerID, err)
+	}
+}
+
+// forgetCPUTuning forgets about the tuning bound to the CPUs of the container, once it got reverted ahead of
+// a change of its cpuset. The writes of the other files, like the ones of the network devices and the node
+// sysctls, are kept so that they still get restored once the container stops.
+func forgetCPUTuning(ctx context.Context, containerID string) {
+	defer writeContainerStateFile(ctx, containerID)
+	defer reportNodeCPUAllocation()
+	tuningStore.Lock()
+	defer tuningStore.Unlock()
+	record, ok := tuningStore.containers[containerID]
+	if !ok {
+		return
+	}
+	record.Tuning = nil
+	record.Writes = slices.DeleteFunc(record.Writes, cpuBoundWrite)
+	if len(record.Writes) == 0 {
+		delete(tuningStore.containers, containerID)
+	}
+	reportIsolationState(containerID, nil)
+	if err := persistTuningRecord(containerID); err != nil {
+		log.Warnf(ctx, "Failed to persist the tuning record of container %q: %v", containerID, err)
+	}
+}
+
+// cpuBoundWrite returns whether the write belongs to the tuning bound to the CPUs of the container, i.e.
+// the IRQ affinity mask, the partition of its cgroup and the c-states and governor of its CPUs.
+func cpuBoundWrite(w fileWrite) bool {
+	return w.Path == IrqSmpAffinityProcFile || filepath.Base(w.Path) == cpusetCpusPartition ||
+		strings.HasPrefix(w.Path, sysCPUDir+"/")
+}
+
+// syncContainerStateTuning records the tuning record of the container in its state, which is persisted along
+// with the rest of the state of the container, or removes it from there if the container is not tuned anymore.
+func syncContainerStateTuning(ctx context.Context, c *oci.Container) {
+	record, ok := recordedTuning(c.ID())
+	if !ok {
+		c.SetTunings(nil)
+		return
+	}
+	content, err := json.Marshal(record)
+	if err != nil {
+		log.Warnf(ctx, "Failed to record the tuning of container %q in its state: %v", c.ID(), err)
+		return
+	}
+	c.SetTunings(content)
+}
+
+// RestoreContainerTuning restores the tuning record of the running container from its state after a restart of
+// CRI-O, so that its tuning gets reverted on stop and reported even if its record in the tuning state directory
+// got lost. The record of the tuning state directory prevails if both exist. The tuning recorded in the state of
+// a stopped container is discarded, as the files it wrote may have been changed since.
+func RestoreContainerTuning(ctx context.Context, c *oci.Container) {
+	tunings := c.Tunings()
+	if len(tunings) == 0 || tuningRecorded(c.ID()) {
+		return
+	}
+	if c.State().Status != oci.ContainerStateRunning {
+		log.Infof(ctx, "Discarding the tuning recorded in the state of stopped container %q", c.ID())
+		c.SetTunings(nil)
+		return
+	}
+	record, err := decodeTuningRecord(tunings)
+	if err != nil {
+		log.Warnf(ctx, "Discarding the tuning recorded in the state of container %q: %v", c.ID(), err)
+		return
+	}
+	defer writeContainerStateFile(ctx, c.ID())
+	defer reportNodeCPUAllocation()
+	tuningStore.Lock()
+	defer tuningStore.Unlock()
+	tuningStore.containers[c.ID()] = record
+	reportIsolationState(c.ID(), record.Tuning)
+	if err := persistTuningRecord(c.ID()); err != nil {
+		log.Warnf(ctx, "Failed to persist the tuning record of container %q: %v", c.ID(), err)
+	}
+	log.Infof(ctx, "Restored the tuning record of container %q from its state", c.ID())
+}
+
+func decodeTuningRecord(tunings []byte) (*tuningRecord, error) {
+	record := &tuningRecord{}
+	if err := json.Unmarshal(tunings, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// writeTuningFile writes data to the file named by name to tune the container, unless the file already
+// holds it, and records the write along with the original value of the file.
+func writeTuningFile(ctx context.Context, containerID, name string, data []byte) error {
+	current, err := hostFS.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	original := string(bytes.TrimSpace(current))
+	if original == string(bytes.TrimSpace(data)) {
+		log.Debugf(ctx, "File %s is already set to %q, skipping", name, original)
+		return nil
+	}
+	if err := writeFile(ctx, name, data, 0o644); err != nil {
+		return err
+	}
+	recordTuningWrite(ctx, containerID, name, original, string(bytes.TrimSpace(data)))
+	return nil
+}
+
+// read returns the current content of the written file, read from the sysfs of the network namespace
+// of its network device, if any.
+func (w *fileWrite) read() ([]byte, error) {
+	if !isNetDeviceFile(w.Path) {
+		return hostFS.ReadFile(w.Path)
+	}
+	return readNetDeviceFile(w.NetNS, w.Path)
+}
+
+// write writes data to the written file, in the sysfs of the network namespace of its network device, if any.
+func (w *fileWrite) write(ctx context.Context, data []byte) error {
+	if !isNetDeviceFile(w.Path) {
+		return writeFile(ctx, w.Path, data, 0o644)
+	}
+	return writeNetDeviceFile(ctx, w.NetNS, w.Path, data)
+}
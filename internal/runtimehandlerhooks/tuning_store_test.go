@@ -0,0 +1,136 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+
+	"github.com/cri-o/cri-o/internal/oci"
+	crioTypes "github.com/cri-o/cri-o/pkg/types"
+)
+
+var _ = Describe("tuningStore", func() {
+	const containerID = "ctr1"
+	performance := "performance"
+
+	AfterEach(func() {
+		forgetAppliedTuning(context.TODO(), containerID)
+		tuningStore.Lock()
+		tuningStore.dir = ""
+		tuningStore.Unlock()
+	})
+
+	It("should detect the tuning already applied to the container", func() {
+		applied := &tuning{CPUs: "2-3", CPULoadBalancingDisabled: true, FreqGovernor: &performance}
+		Expect(tuningApplied(containerID, applied)).To(BeFalse())
+
+		recordAppliedTuning(context.TODO(), containerID, applied)
+		governor := "performance"
+		Expect(tuningApplied(containerID, &tuning{CPUs: "2-3", CPULoadBalancingDisabled: true, FreqGovernor: &governor})).To(BeTrue())
+		Expect(tuningApplied(containerID, &tuning{CPUs: "4-5", CPULoadBalancingDisabled: true, FreqGovernor: &governor})).To(BeFalse())
+		Expect(tuningApplied("ctr2", applied)).To(BeFalse())
+	})
+
+	It("should forget the tuning once reverted", func() {
+		applied := &tuning{CPUs: "2-3", IRQLoadBalancingDisabled: true}
+		recordAppliedTuning(context.TODO(), containerID, applied)
+
+		forgetAppliedTuning(context.TODO(), containerID)
+		Expect(tuningApplied(containerID, applied)).To(BeFalse())
+		Expect(tuningRecorded(containerID)).To(BeFalse())
+	})
+
+	It("should record the partially applied tuning", func() {
+		recordTuningWrite(context.TODO(), containerID, "/sys/file", "0", "1")
+		recordTuningWrite(context.TODO(), containerID, "/sys/file", "1", "2")
+
+		Expect(tuningRecorded(containerID)).To(BeTrue())
+		Expect(tuningApplied(containerID, &tuning{})).To(BeFalse())
+		tuningStore.Lock()
+		defer tuningStore.Unlock()
+		Expect(tuningStore.containers[containerID].Writes).To(Equal([]fileWrite{{Path: "/sys/file", Original: "0", Value: "2"}}))
+	})
+
+	It("should persist the records once loaded", func() {
+		dir := filepath.Join(GinkgoT().TempDir(), "tuning")
+		Expect(os.MkdirAll(dir, 0o700)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "corrupted.json"), []byte("{"), 0o600)).To(Succeed())
+		Expect(LoadTuningStore(context.TODO(), dir)).To(Succeed())
+		Expect(filepath.Join(dir, "corrupted.json")).ToNot(BeAnExistingFile())
+
+		applied := &tuning{CPUs: "2-3", CStates: &performance}
+		recordAppliedTuning(context.TODO(), containerID, applied)
+		Expect(filepath.Join(dir, containerID+".json")).To(BeAnExistingFile())
+
+		// simulate a restart
+		tuningStore.Lock()
+		delete(tuningStore.containers, containerID)
+		tuningStore.Unlock()
+		Expect(LoadTuningStore(context.TODO(), dir)).To(Succeed())
+		Expect(tuningApplied(containerID, applied)).To(BeTrue())
+
+		forgetAppliedTuning(context.TODO(), containerID)
+		Expect(filepath.Join(dir, containerID+".json")).ToNot(BeAnExistingFile())
+	})
+
+	It("should record the original value of the tuned files", func() {
+		file := filepath.Join(GinkgoT().TempDir(), "scaling_governor")
+		Expect(os.WriteFile(file, []byte("powersave\n"), 0o644)).To(Succeed())
+
+		Expect(writeTuningFile(context.TODO(), containerID, file, []byte("performance"))).To(Succeed())
+		Expect(os.ReadFile(file)).To(Equal([]byte("performance")))
+		Expect(writeTuningFile(context.TODO(), containerID, file, []byte("performance"))).To(Succeed())
+
+		tuningStore.Lock()
+		defer tuningStore.Unlock()
+		Expect(tuningStore.containers[containerID].Writes).To(Equal([]fileWrite{{Path: file, Original: "powersave", Value: "performance"}}))
+	})
+
+	It("should restore the tuning recorded in the container state", func() {
+		c := newTestContainer(containerID, "cnt1", "sandboxID")
+		c.SetState(&oci.ContainerState{State: specs.State{Status: oci.ContainerStateRunning}})
+		applied := &tuning{CPUs: "2-3", CPULoadBalancingDisabled: true}
+		recordAppliedTuning(context.TODO(), containerID, applied)
+		recordTuningWrite(context.TODO(), containerID, "/sys/file", "0", "1")
+
+		syncContainerStateTuning(context.TODO(), c)
+		Expect(c.Tunings()).ToNot(BeEmpty())
+		Expect(StateTuningDetails(c)).To(Equal(&crioTypes.TuningDetails{
+			CPUs:                     "2-3",
+			CPULoadBalancingDisabled: true,
+			Files:                    []crioTypes.TunedFile{{Path: "/sys/file", Original: "0", Value: "1"}},
+		}))
+
+		// A restart of CRI-O which lost the tuning state directory.
+		tuningStore.Lock()
+		delete(tuningStore.containers, containerID)
+		tuningStore.Unlock()
+		RestoreContainerTuning(context.TODO(), c)
+		Expect(tuningApplied(containerID, applied)).To(BeTrue())
+		record, _ := recordedTuning(containerID)
+		Expect(record.Writes).To(Equal([]fileWrite{{Path: "/sys/file", Original: "0", Value: "1"}}))
+
+		forgetAppliedTuning(context.TODO(), containerID)
+		syncContainerStateTuning(context.TODO(), c)
+		Expect(c.Tunings()).To(BeNil())
+	})
+	It("should discard the tuning recorded in the state of a stopped container", func() {
+		c := newTestContainer(containerID, "cnt1", "sandboxID")
+		c.SetState(&oci.ContainerState{State: specs.State{Status: oci.ContainerStateStopped}})
+		recordAppliedTuning(context.TODO(), containerID, &tuning{CPUs: "2-3", CPULoadBalancingDisabled: true})
+		syncContainerStateTuning(context.TODO(), c)
+
+		// A restart of CRI-O which lost the tuning state directory.
+		tuningStore.Lock()
+		delete(tuningStore.containers, containerID)
+		tuningStore.Unlock()
+		RestoreContainerTuning(context.TODO(), c)
+
+		Expect(tuningRecorded(containerID)).To(BeFalse())
+		Expect(c.Tunings()).To(BeNil())
+	})
+})
@@ -0,0 +1,184 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/utils/cpuset"
+
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/server/metrics"
+)
+
+// cpuidleState are the cumulative counters of an idle state of a CPU.
+type cpuidleState struct {
+	// usage is the amount of times the CPU entered the state.
+	usage uint64
+	// time is the time spent by the CPU in the state.
+	time time.Duration
+}
+
+// cpuidleStateKey identifies an idle state of a CPU by its name, like "C1" or "C6".
+type cpuidleStateKey struct {
+	cpu   int
+	state string
+}
+
+// containerCStateSamples are the idle states of the CPUs of the containers tuned for c-states at their last
+// sample, keyed by container ID, to count the residency since then. The hooks are instantiated per request,
+// so they are kept at package level.
+var containerCStateSamples = struct {
+	sync.Mutex
+	containers map[string]map[cpuidleStateKey]cpuidleState
+}{containers: make(map[string]map[cpuidleStateKey]cpuidleState)}
+
+// containerCPUFrequencySamples are the containers tuned for the CPU frequency governor whose frequency
+// is exported, to stop exporting it once they are not tuned anymore.
+var containerCPUFrequencySamples = struct {
+	sync.Mutex
+	containers map[string]struct{}
+}{containers: make(map[string]struct{})}
+
+// SampleTuningTelemetry samples the CPU telemetry of the containers tuned by the hooks into the metrics.
+func SampleTuningTelemetry(ctx context.Context) {
+	sampleContainerCStateResidency(ctx)
+	sampleContainerCPUFrequency(ctx)
+}
+
+// tunedContainerCPUs returns the CPUs of the containers whose recorded tuning matches, keyed by container ID.
+func tunedContainerCPUs(ctx context.Context, matches func(*tuning) bool) map[string]cpuset.CPUSet {
+	tuned := map[string]cpuset.CPUSet{}
+	for _, containerID := range recordedContainers() {
+		record, ok := recordedTuning(containerID)
+		if !ok || record.Tuning == nil || !matches(record.Tuning) {
+			continue
+		}
+		cpus, err := cpuset.Parse(record.Tuning.CPUs)
+		if err != nil {
+			log.Warnf(ctx, "Unable to parse the CPUs %q of container %q: %v", record.Tuning.CPUs, containerID, err)
+			continue
+		}
+		tuned[containerID] = cpus
+	}
+	return tuned
+}
+
+// sampleContainerCStateResidency counts the time the CPUs of the containers tuned for c-states spent in
+// every idle state and the times they entered it since the previous sample in the metrics. The containers
+// which got tuned since then start being counted from the next sample, and the ones which are not tuned
+// anymore stop being exported.
+func sampleContainerCStateResidency(ctx context.Context) {
+	tuned := tunedContainerCPUs(ctx, func(t *tuning) bool {
+		return t.CStates != nil && *t.CStates != annotationEnable
+	})
+
+	containerCStateSamples.Lock()
+	defer containerCStateSamples.Unlock()
+	for containerID := range containerCStateSamples.containers {
+		if _, ok := tuned[containerID]; !ok {
+			delete(containerCStateSamples.containers, containerID)
+			metrics.Instance().MetricTuningCPUCStateDelete(containerID)
+		}
+	}
+	for containerID, cpus := range tuned {
+		current := map[cpuidleStateKey]cpuidleState{}
+		for _, cpu := range cpus.List() {
+			states, err := readCPUIdleStates(cpu)
+			if err != nil {
+				log.Warnf(ctx, "Unable to read the idle states of cpu %d of container %q: %v", cpu, containerID, err)
+				continue
+			}
+			for name, state := range states {
+				current[cpuidleStateKey{cpu: cpu, state: name}] = state
+			}
+		}
+		if former, ok := containerCStateSamples.containers[containerID]; ok {
+			for key, state := range current {
+				// The counters restart from zero if the CPU went offline in between.
+				before, ok := former[key]
+				if !ok || state.usage < before.usage || state.time < before.time {
+					continue
+				}
+				cpu := strconv.Itoa(key.cpu)
+				metrics.Instance().MetricTuningCPUCStateResidencyAdd(containerID, cpu, key.state, (state.time - before.time).Seconds())
+				metrics.Instance().MetricTuningCPUCStateUsageAdd(containerID, cpu, key.state, float64(state.usage-before.usage))
+			}
+		}
+		containerCStateSamples.containers[containerID] = current
+	}
+}
+
+// sampleContainerCPUFrequency exports the current frequency of the CPUs of the containers tuned for the CPU
+// frequency governor, as reported by the cpufreq driver, to confirm that the governor keeps the frequency of
+// the CPUs under thermal pressure. The containers which are not tuned anymore stop being exported.
+func sampleContainerCPUFrequency(ctx context.Context) {
+	tuned := tunedContainerCPUs(ctx, func(t *tuning) bool {
+		return t.FreqGovernor != nil
+	})
+
+	containerCPUFrequencySamples.Lock()
+	defer containerCPUFrequencySamples.Unlock()
+	for containerID := range containerCPUFrequencySamples.containers {
+		if _, ok := tuned[containerID]; !ok {
+			delete(containerCPUFrequencySamples.containers, containerID)
+			metrics.Instance().MetricTuningCPUFrequencyDelete(containerID)
+		}
+	}
+	for containerID, cpus := range tuned {
+		for _, cpu := range cpus.List() {
+			// The frequency is reported in kHz.
+			frequency, err := readCPUCounter(fmt.Sprintf("%s/cpu%d/cpufreq/scaling_cur_freq", sysCPUDir, cpu))
+			if err != nil {
+				log.Warnf(ctx, "Unable to read the frequency of cpu %d of container %q: %v", cpu, containerID, err)
+				continue
+			}
+			metrics.Instance().MetricTuningCPUFrequencySet(containerID, strconv.Itoa(cpu), float64(frequency)*1000)
+		}
+		containerCPUFrequencySamples.containers[containerID] = struct{}{}
+	}
+}
+
+// readCPUIdleStates returns the counters of the idle states of the CPU, keyed by state name.
+// The states are the stateN directories of the cpuidle directory of the CPU, numbered from 0.
+func readCPUIdleStates(cpu int) (map[string]cpuidleState, error) {
+	states := map[string]cpuidleState{}
+	for i := 0; ; i++ {
+		dir := fmt.Sprintf("%s/cpu%d/cpuidle/state%d", sysCPUDir, cpu, i)
+		name, err := hostFS.ReadFile(dir + "/name")
+		if errors.Is(err, os.ErrNotExist) {
+			return states, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		usage, err := readCPUCounter(dir + "/usage")
+		if err != nil {
+			return nil, err
+		}
+		// The time is reported in microseconds.
+		residency, err := readCPUCounter(dir + "/time")
+		if err != nil {
+			return nil, err
+		}
+		states[strings.TrimSpace(string(name))] = cpuidleState{usage: usage, time: time.Duration(residency) * time.Microsecond}
+	}
+}
+
+// readCPUCounter returns the unsigned integer held by a sysfs file of a CPU.
+func readCPUCounter(file string) (uint64, error) {
+	content, err := hostFS.ReadFile(file)
+	if err != nil {
+		return 0, err
+	}
+	value, err := strconv.ParseUint(strings.TrimSpace(string(content)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s: %w", file, err)
+	}
+	return value, nil
+}
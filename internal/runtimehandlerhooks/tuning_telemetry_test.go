@@ -0,0 +1,93 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SampleTuningTelemetry", func() {
+	const state0 = sysCPUDir + "/cpu1/cpuidle/state0"
+	const state1 = sysCPUDir + "/cpu1/cpuidle/state1"
+
+	var fake *fakeHostFS
+
+	BeforeEach(func() {
+		fake = useFakeHostFS(map[string]string{
+			state0 + "/name":  "POLL\n",
+			state0 + "/usage": "10\n",
+			state0 + "/time":  "1000\n",
+			state1 + "/name":  "C1\n",
+			state1 + "/usage": "20\n",
+			state1 + "/time":  "3000\n",
+
+			sysCPUDir + "/cpu1/cpufreq/scaling_cur_freq": "2400000\n",
+		})
+		DeferCleanup(func() {
+			forgetAppliedTuning(context.TODO(), "ctr1")
+			containerCStateSamples.Lock()
+			clear(containerCStateSamples.containers)
+			containerCStateSamples.Unlock()
+			containerCPUFrequencySamples.Lock()
+			clear(containerCPUFrequencySamples.containers)
+			containerCPUFrequencySamples.Unlock()
+		})
+	})
+
+	It("should read the idle states of a CPU", func() {
+		Expect(readCPUIdleStates(1)).To(Equal(map[string]cpuidleState{
+			"POLL": {usage: 10, time: time.Millisecond},
+			"C1":   {usage: 20, time: 3 * time.Millisecond},
+		}))
+		Expect(readCPUIdleStates(0)).To(BeEmpty())
+
+		Expect(fake.WriteFile(state1+"/time", []byte("n/a\n"), 0o444)).To(Succeed())
+		_, err := readCPUIdleStates(1)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should only sample the containers tuned for c-states", func() {
+		disable := annotationDisable
+		recordAppliedTuning(context.TODO(), "ctr1", &tuning{CPUs: "1", CStates: &disable})
+
+		SampleTuningTelemetry(context.TODO())
+		Expect(containerCStateSamples.containers).To(Equal(map[string]map[cpuidleStateKey]cpuidleState{
+			"ctr1": {
+				{cpu: 1, state: "POLL"}: {usage: 10, time: time.Millisecond},
+				{cpu: 1, state: "C1"}:   {usage: 20, time: 3 * time.Millisecond},
+			},
+		}))
+
+		Expect(fake.WriteFile(state1+"/usage", []byte("25\n"), 0o444)).To(Succeed())
+		SampleTuningTelemetry(context.TODO())
+		Expect(containerCStateSamples.containers["ctr1"]).To(HaveKeyWithValue(
+			cpuidleStateKey{cpu: 1, state: "C1"}, cpuidleState{usage: 25, time: 3 * time.Millisecond}))
+
+		forgetAppliedTuning(context.TODO(), "ctr1")
+		SampleTuningTelemetry(context.TODO())
+		Expect(containerCStateSamples.containers).To(BeEmpty())
+	})
+
+	It("should only sample the frequency of the containers tuned for the CPU frequency governor", func() {
+		performance := "performance"
+		recordAppliedTuning(context.TODO(), "ctr1", &tuning{CPUs: "1", FreqGovernor: &performance})
+
+		SampleTuningTelemetry(context.TODO())
+		Expect(containerCPUFrequencySamples.containers).To(HaveKey("ctr1"))
+		Expect(containerCStateSamples.containers).To(BeEmpty())
+
+		forgetAppliedTuning(context.TODO(), "ctr1")
+		SampleTuningTelemetry(context.TODO())
+		Expect(containerCPUFrequencySamples.containers).To(BeEmpty())
+	})
+
+	It("should not sample the containers with all c-states enabled", func() {
+		enable := annotationEnable
+		recordAppliedTuning(context.TODO(), "ctr1", &tuning{CPUs: "1", CStates: &enable})
+
+		SampleTuningTelemetry(context.TODO())
+		Expect(containerCStateSamples.containers).To(BeEmpty())
+	})
+})
@@ -0,0 +1,143 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/fields"
+
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/internal/oci"
+	crioannotations "github.com/cri-o/cri-o/pkg/annotations"
+)
+
+// tuningVerificationInterval is the time between two verification passes of the tuning of a container.
+var tuningVerificationInterval = 100 * time.Millisecond
+
+// requestedTuningVerification returns the maximum time to wait for the tuning of the containers
+// of the pod to be effective, if requested.
+func requestedTuningVerification(annotations fields.Set) (time.Duration, bool) {
+	value, present := annotations[crioannotations.TuningVerificationAnnotation]
+	if !present {
+		return 0, false
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return 0, false
+	}
+	return timeout, true
+}
+
+// verifyRequestedTuning waits for the tuning of the container to be effective before it gets started,
+// if requested by the sandbox annotations, so that it is not reported running before being isolated.
+func verifyRequestedTuning(ctx context.Context, c *oci.Container, s *sandbox.Sandbox, t *tuning) error {
+	timeout, ok := requestedTuningVerification(s.Annotations())
+	if !ok {
+		return nil
+	}
+	log.Infof(ctx, "Verify that the tuning of container %q is effective within %s", c.ID(), timeout)
+	return measureHookStep(ctx, hookStepVerification, hookStepAttributes(c, s.Annotations(), crioannotations.TuningVerificationAnnotation), func(ctx context.Context) error {
+		return verifyTuning(ctx, c.ID(), t, timeout)
+	})
+}
+
+// verifyTuning waits for the tuning of the container to be effective for at most timeout.
+func verifyTuning(ctx context.Context, containerID string, t *tuning, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		ineffective, err := ineffectiveTuning(containerID, t)
+		if err == nil && len(ineffective) == 0 {
+			log.Infof(ctx, "Tuning of container %q is effective", containerID)
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			if err != nil {
+				return fmt.Errorf("verify tuning: %w", err)
+			}
+			return &tuningNotEffectiveError{Files: ineffective}
+		case <-time.After(tuningVerificationInterval):
+		}
+	}
+}
+
+// IneffectiveTuning returns the tuned files of the container which do not hold its tuning anymore.
+func IneffectiveTuning(containerID string) ([]string, error) {
+	record, ok := recordedTuning(containerID)
+	if !ok || record.Tuning == nil {
+		return nil, nil
+	}
+	return ineffectiveTuning(containerID, record.Tuning)
+}
+
+// VerifyTuning re-validates the tuning applied to the container against the live state of the node,
+// nil if the container did not get tuned.
+func VerifyTuning(containerID string) *TuningVerification {
+	record, ok := recordedTuning(containerID)
+	if !ok || record.Tuning == nil {
+		return nil
+	}
+	items := tuningVerificationItems(containerID, record.Tuning)
+	verification := &TuningVerification{ContainerID: containerID, Verified: true, Items: items}
+	for _, item := range items {
+		if !item.Effective {
+			verification.Verified = false
+		}
+	}
+	return verification
+}
+
+// ineffectiveTuning returns the files which do not hold the tuning applied to the container.
+func ineffectiveTuning(containerID string, t *tuning) ([]string, error) {
+	ineffective := []string{}
+	var errs []error
+	for _, item := range tuningVerificationItems(containerID, t) {
+		switch {
+		case item.Error != "":
+			errs = append(errs, errors.New(item.Error))
+		case !item.Effective:
+			ineffective = append(ineffective, item.Path)
+		}
+	}
+	return ineffective, errors.Join(errs...)
+}
+
+// tuningVerificationItems compares the files tuned for the container with their live value: the per-CPU
+// c-states and governor files and the isolated partition of the container, which the kernel reports as
+// invalid if it cannot be isolated, have to hold the recorded value, while the IRQ affinity mask only has
+// to keep the CPUs of the container excluded.
+func tuningVerificationItems(containerID string, t *tuning) []TuningVerificationItem {
+	record, ok := recordedTuning(containerID)
+	if !ok {
+		return nil
+	}
+	items := []TuningVerificationItem{}
+	for _, w := range record.Writes {
+		if w.Path == IrqSmpAffinityProcFile {
+			continue
+		}
+		item := TuningVerificationItem{Path: w.Path, Expected: w.Value}
+		if content, err := w.read(); err != nil {
+			item.Error = err.Error()
+		} else {
+			item.Actual = strings.TrimSpace(string(content))
+			item.Effective = item.Actual == item.Expected
+		}
+		items = append(items, item)
+	}
+	if t.IRQLoadBalancingDisabled {
+		item := TuningVerificationItem{Path: IrqSmpAffinityProcFile}
+		if expected, actual, err := irqLoadBalancingMasks(t.CPUs, IrqSmpAffinityProcFile); err != nil {
+			item.Error = err.Error()
+		} else {
+			item.Expected, item.Actual, item.Effective = expected, actual, expected == actual
+		}
+		items = append(items, item)
+	}
+	return items
+}
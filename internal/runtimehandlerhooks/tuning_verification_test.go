@@ -0,0 +1,104 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+
+	"github.com/cri-o/cri-o/internal/oci"
+	crioannotations "github.com/cri-o/cri-o/pkg/annotations"
+)
+
+var _ = Describe("verifyRequestedTuning", func() {
+	const (
+		governorFile  = "/sys/devices/system/cpu/cpu1/cpufreq/scaling_governor"
+		partitionFile = "/sys/fs/cgroup/kubepods.slice/pod/ctr1/cpuset.cpus.partition"
+	)
+	var (
+		c    *oci.Container
+		fake *fakeHostFS
+	)
+
+	BeforeEach(func() {
+		c = newTestContainer("ctr1", "cnt1", "sandboxID")
+		c.SetSpec(&specs.Spec{Linux: &specs.Linux{Resources: &specs.LinuxResources{CPU: &specs.LinuxCPU{Cpus: "1-2"}}}})
+
+		fake = useFakeHostFS(map[string]string{
+			governorFile:           "performance\n",
+			partitionFile:          "isolated invalid (Cpu list in cpuset.cpus not exclusive)\n",
+			IrqSmpAffinityProcFile: "ff\n",
+		})
+		recordTuningWrite(context.TODO(), c.ID(), governorFile, "powersave", "performance")
+		recordTuningWrite(context.TODO(), c.ID(), partitionFile, "member", "isolated")
+		recordTuningWrite(context.TODO(), c.ID(), IrqSmpAffinityProcFile, "ff", "000000f9")
+
+		interval := tuningVerificationInterval
+		tuningVerificationInterval = time.Millisecond
+		DeferCleanup(func() {
+			tuningVerificationInterval = interval
+			forgetAppliedTuning(context.TODO(), c.ID())
+		})
+	})
+
+	It("should not verify the tuning unless requested", func() {
+		Expect(verifyRequestedTuning(context.TODO(), c, newTestSandbox("sandboxID", nil), &tuning{CPUs: "1-2"})).To(Succeed())
+	})
+
+	It("should fail once the tuning is still not effective by the end of the verification", func() {
+		sb := newTestSandbox("sandboxID", map[string]string{crioannotations.TuningVerificationAnnotation: "20ms"})
+
+		err := verifyRequestedTuning(context.TODO(), c, sb, &tuning{CPUs: "1-2", IRQLoadBalancingDisabled: true})
+
+		var notEffective *tuningNotEffectiveError
+		Expect(errors.As(err, &notEffective)).To(BeTrue())
+		Expect(notEffective.Files).To(Equal([]string{partitionFile, IrqSmpAffinityProcFile}))
+	})
+
+	It("should wait for the tuning to be effective", func() {
+		sb := newTestSandbox("sandboxID", map[string]string{crioannotations.TuningVerificationAnnotation: "10s"})
+		go func() {
+			defer GinkgoRecover()
+			time.Sleep(10 * time.Millisecond)
+			Expect(fake.WriteFile(partitionFile, []byte("isolated\n"), 0o644)).To(Succeed())
+			Expect(fake.WriteFile(IrqSmpAffinityProcFile, []byte("000000f9\n"), 0o644)).To(Succeed())
+		}()
+
+		Expect(verifyRequestedTuning(context.TODO(), c, sb, &tuning{CPUs: "1-2", IRQLoadBalancingDisabled: true})).To(Succeed())
+	})
+})
+
+var _ = Describe("VerifyTuning", func() {
+	const governorFile = "/sys/devices/system/cpu/cpu1/cpufreq/scaling_governor"
+
+	BeforeEach(func() {
+		useFakeHostFS(map[string]string{
+			governorFile:           "powersave\n",
+			IrqSmpAffinityProcFile: "000000f9\n",
+		})
+		DeferCleanup(func() {
+			forgetAppliedTuning(context.TODO(), "ctr1")
+		})
+	})
+
+	It("should not verify a container which did not get tuned", func() {
+		Expect(VerifyTuning("ctr1")).To(BeNil())
+	})
+
+	It("should compare the expected and actual state of each tuned file", func() {
+		recordAppliedTuning(context.TODO(), "ctr1", &tuning{CPUs: "1-2", IRQLoadBalancingDisabled: true})
+		recordTuningWrite(context.TODO(), "ctr1", governorFile, "powersave", "performance")
+
+		Expect(VerifyTuning("ctr1")).To(Equal(&TuningVerification{
+			ContainerID: "ctr1",
+			Verified:    false,
+			Items: []TuningVerificationItem{
+				{Path: governorFile, Expected: "performance", Actual: "powersave"},
+				{Path: IrqSmpAffinityProcFile, Expected: "000000f9", Actual: "000000f9", Effective: true},
+			},
+		}))
+	})
+})
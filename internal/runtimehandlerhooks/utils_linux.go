@@ -1,167 +1,153 @@
 package runtimehandlerhooks
 
 import (
-	"encoding/hex"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
-	"unicode"
+	"sync"
+	"time"
 
+	"github.com/containers/storage/pkg/unshare"
+	systemdDbus "github.com/coreos/go-systemd/v22/dbus"
 	"github.com/sirupsen/logrus"
-	"k8s.io/utils/cpuset"
 
-	"github.com/cri-o/cri-o/utils/cmdrunner"
+	"github.com/cri-o/cri-o/internal/dbusmgr"
+	"github.com/cri-o/cri-o/internal/log"
 )
 
-func isASCII(s string) bool {
-	for i := range len(s) {
-		if s[i] > unicode.MaxASCII {
-			return false
-		}
-	}
-	return true
-}
+// systemdJobTimeout bounds the wait for the completion of a systemd job, so that a stuck one cannot block the hook.
+const systemdJobTimeout = time.Minute
 
-func cpuMaskByte(c int) byte {
-	return byte(1 << c)
-}
+// systemdConnManager returns the manager of the D-Bus connection to systemd, which manages the services the hooks
+// depend on without relying on systemctl being available in the mount namespace of CRI-O.
+var systemdConnManager = sync.OnceValue(func() *dbusmgr.DbusConnManager {
+	return dbusmgr.NewDbusConnManager(unshare.IsRootless())
+})
 
-func mapHexCharToByte(h string) ([]byte, error) {
-	l := len(h)
-	var hexin string
-	if l%2 != 0 {
-		// expect even number of chars
-		hexin = "0" + h
-	} else {
-		hexin = h
-	}
+// maxCPUWorkers bounds the number of CPUs whose sysfs files get written concurrently.
+const maxCPUWorkers = 16
 
-	breversed, err := hex.DecodeString(hexin)
-	if err != nil {
-		return nil, err
+// forEachCPU runs fn for each of the cpus with a bounded pool of workers,
+// so tuning the containers with many CPUs does not delay their start.
+// It returns the errors of all the CPUs, in the order of the cpus.
+func forEachCPU(cpus []int, fn func(cpu int) error) error {
+	errs := make([]error, len(cpus))
+	workers := make(chan struct{}, maxCPUWorkers)
+	var wg sync.WaitGroup
+	for i, cpu := range cpus {
+		workers <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+			errs[i] = fn(cpu)
+		}()
 	}
-
-	l = len(breversed)
-	barray := make([]byte, 0, l)
-	var rindex int
-	for i := range l {
-		rindex = l - i - 1
-		barray = append(barray, breversed[rindex])
-	}
-	return barray, nil
+	wg.Wait()
+	return errors.Join(errs...)
 }
 
-func mapByteToHexChar(b []byte) string {
-	// The kernel will not accept longer bit mask than the count of cpus
-	// on the system rounded up to the closest 32 bit multiple.
-	// See https://bugzilla.redhat.com/show_bug.cgi?id=2181546
-
-	var rindex int
-	l := len(b)
-	breversed := make([]byte, 0, l)
-	// align it to 4 byte
-	if l%4 != 0 {
-		lfill := 4 - l%4
-		l += lfill
-		for range lfill {
-			b = append(b, byte(0))
-		}
-	}
+// fileLocks serializes the writes of the hooks per file of the node, so that the containers sharing a CPU
+// save and restore its original value in turn, without the containers of other CPUs waiting on them.
+var fileLocks = struct {
+	sync.Mutex
+	locks map[string]*fileLock
+}{locks: make(map[string]*fileLock)}
 
-	for i := range l {
-		rindex = l - i - 1
-		breversed = append(breversed, b[rindex])
-	}
-	return hex.EncodeToString(breversed)
+type fileLock struct {
+	sync.Mutex
+	// holders is the number of callers holding or waiting for the lock.
+	holders int
 }
 
-// take a byte array and invert each byte.
-func invertByteArray(in []byte) (out []byte) {
-	for _, b := range in {
-		out = append(out, byte(0xff)-b)
+// lockFile locks the file named by name for the caller, and returns the func unlocking it.
+// The sysfs and cgroupfs files are not synced, the kernel applies their value on write.
+func lockFile(name string) (unlock func()) {
+	fileLocks.Lock()
+	l, ok := fileLocks.locks[name]
+	if !ok {
+		l = &fileLock{}
+		fileLocks.locks[name] = l
 	}
-	return
-}
+	l.holders++
+	fileLocks.Unlock()
 
-// take a byte array and returns true when bits of every byte element
-// set to 1, otherwise returns false.
-func isAllBitSet(in []byte) bool {
-	for _, b := range in {
-		if b&(b+1) != 0 {
-			return false
+	l.Lock()
+	return func() {
+		l.Unlock()
+		fileLocks.Lock()
+		defer fileLocks.Unlock()
+		if l.holders--; l.holders == 0 {
+			delete(fileLocks.locks, name)
 		}
 	}
-	return true
 }
 
-// UpdateIRQSmpAffinityMask take input cpus that need to change irq affinity mask and
-// the current mask string, return an update mask string and inverted mask, with those cpus
-// enabled or disable in the mask.
-func UpdateIRQSmpAffinityMask(cpus, current string, set bool) (cpuMask, bannedCPUMask string, err error) {
-	podcpuset, err := cpuset.Parse(cpus)
-	if err != nil {
-		return cpus, "", err
-	}
+func restartIrqBalanceService(ctx context.Context) error {
+	return measureIrqBalanceOperation(irqBalanceOperationRestart, func() error {
+		return restartService(ctx, irqBalancedName)
+	})
+}
 
-	// only ascii string supported
-	if !isASCII(current) {
-		return cpus, "", fmt.Errorf("non ascii character detected: %s", current)
+// serviceUnitName returns the name of the systemd unit of the service, like "irqbalance.service" for "irqbalance".
+func serviceUnitName(serviceName string) string {
+	if strings.Contains(serviceName, ".") {
+		return serviceName
 	}
+	return serviceName + ".service"
+}
 
-	// remove ","; now each element is "0-9,a-f"
-	s := strings.ReplaceAll(current, ",", "")
-
-	// the index 0 corresponds to the cpu 0-7
-	// the LSb (right-most bit) represents the lowest cpu id from the byte
-	// and the MSb (left-most bit) represents the highest cpu id from the byte
-	currentMaskArray, err := mapHexCharToByte(s)
-	if err != nil {
-		return cpus, "", err
-	}
-	invertedMaskArray := invertByteArray(currentMaskArray)
-
-	for _, cpu := range podcpuset.List() {
-		if set {
-			// each byte represent 8 cpus
-			currentMaskArray[cpu/8] |= cpuMaskByte(cpu % 8)
-			invertedMaskArray[cpu/8] &^= cpuMaskByte(cpu % 8)
-		} else {
-			currentMaskArray[cpu/8] &^= cpuMaskByte(cpu % 8)
-			invertedMaskArray[cpu/8] |= cpuMaskByte(cpu % 8)
-		}
+// restartService restarts the service through systemd and waits for the completion of the restart job.
+func restartService(ctx context.Context, serviceName string) error {
+	unit := serviceUnitName(serviceName)
+	// Make a buffered channel so that go-systemd won't be blocked on sending the job result.
+	ch := make(chan string, 1)
+	if err := systemdConnManager().RetryOnDisconnect(func(c *systemdDbus.Conn) error {
+		_, err := c.RestartUnitContext(ctx, unit, "replace", ch)
+		return err
+	}); err != nil {
+		return fmt.Errorf("restart unit %s: %w", unit, err)
 	}
 
-	maskString := mapByteToHexChar(currentMaskArray)
-	invertedMaskString := mapByteToHexChar(invertedMaskArray)
-
-	maskStringWithComma := maskString[0:8]
-	invertedMaskStringWithComma := invertedMaskString[0:8]
-	for i := 8; i+8 <= len(maskString); i += 8 {
-		maskStringWithComma = maskStringWithComma + "," + maskString[i:i+8]
-		invertedMaskStringWithComma = invertedMaskStringWithComma + "," + invertedMaskString[i:i+8]
+	select {
+	case result := <-ch:
+		if result != "done" {
+			return fmt.Errorf("restart unit %s: job %s", unit, result)
+		}
+		return nil
+	case <-time.After(systemdJobTimeout):
+		return fmt.Errorf("timed out restarting unit %s", unit)
+	case <-ctx.Done():
+		return fmt.Errorf("restart unit %s: %w", unit, ctx.Err())
 	}
-	return maskStringWithComma, invertedMaskStringWithComma, nil
-}
-
-func restartIrqBalanceService() error {
-	return cmdrunner.Command("systemctl", "restart", "irqbalance").Run()
 }
 
-func isServiceEnabled(serviceName string) bool {
-	cmd := cmdrunner.Command("systemctl", "is-enabled", serviceName)
-	status, err := cmd.CombinedOutput()
-	if err != nil {
+func isServiceEnabled(ctx context.Context, serviceName string) bool {
+	unit := serviceUnitName(serviceName)
+	var state string
+	if err := systemdConnManager().RetryOnDisconnect(func(c *systemdDbus.Conn) error {
+		property, err := c.GetUnitPropertyContext(ctx, unit, "UnitFileState")
+		if err != nil {
+			return err
+		}
+		state, _ = property.Value.Value().(string)
+		return nil
+	}); err != nil {
 		logrus.Infof("Service %s is-enabled check returned with: %v", serviceName, err)
 		return false
 	}
-	if strings.TrimSpace(string(status)) == "enabled" {
-		return true
-	}
-	return false
+	return state == "enabled"
 }
 
-func updateIrqBalanceConfigFile(irqBalanceConfigFile, newIRQBalanceSetting string) error {
-	input, err := os.ReadFile(irqBalanceConfigFile)
+func updateIrqBalanceConfigFile(ctx context.Context, irqBalanceConfigFile, newIRQBalanceSetting string) error {
+	input, err := hostFS.ReadFile(irqBalanceConfigFile)
 	if err != nil {
 		return err
 	}
@@ -177,14 +163,111 @@ func updateIrqBalanceConfigFile(irqBalanceConfigFile, newIRQBalanceSetting strin
 	if !found {
 		output = output + "\n" + irqBalanceBannedCpus + "=" + "\"" + newIRQBalanceSetting + "\"" + "\n"
 	}
-	if err := os.WriteFile(irqBalanceConfigFile, []byte(output), 0o644); err != nil {
+	return measureIrqBalanceOperation(irqBalanceOperationConfigUpdate, func() error {
+		return writeFileIfChanged(ctx, irqBalanceConfigFile, []byte(output), 0o644)
+	})
+}
+
+// updateIrqBalanceBannedIRQs bans the IRQs from irqbalance, or lifts their ban, with the --banirq
+// options of the irqbalance arguments of its configuration file, so that it leaves their affinity alone.
+func updateIrqBalanceBannedIRQs(ctx context.Context, irqBalanceConfigFile string, irqs []int, ban bool) error {
+	input, err := hostFS.ReadFile(irqBalanceConfigFile)
+	if err != nil {
 		return err
 	}
-	return nil
+	lines := strings.Split(strings.TrimSuffix(string(input), "\n"), "\n")
+	index := slices.IndexFunc(lines, func(line string) bool {
+		return strings.HasPrefix(line, irqBalanceArgs+"=")
+	})
+	var args []string
+	if index >= 0 {
+		args = strings.Fields(strings.Trim(strings.TrimPrefix(lines[index], irqBalanceArgs+"="), "\""))
+	}
+	for _, irq := range irqs {
+		arg := "--banirq=" + strconv.Itoa(irq)
+		args = slices.DeleteFunc(args, func(a string) bool { return a == arg })
+		if ban {
+			args = append(args, arg)
+		}
+	}
+	line := irqBalanceArgs + "=\"" + strings.Join(args, " ") + "\""
+	if index >= 0 {
+		lines[index] = line
+	} else {
+		lines = append(lines, line)
+	}
+	return measureIrqBalanceOperation(irqBalanceOperationConfigUpdate, func() error {
+		return writeFileIfChanged(ctx, irqBalanceConfigFile, []byte(strings.Join(lines, "\n")+"\n"), 0o644)
+	})
+}
+
+// hungWrites holds the done channel of the writes given up on while blocked in the kernel, per file of the node.
+var hungWrites = struct {
+	sync.Mutex
+	done map[string]chan error
+}{done: make(map[string]chan error)}
+
+// writeFile writes data to the file of the node named by name, like os.WriteFile, giving up once ctx is done.
+// A write to sysfs may block in the kernel, where it cannot be canceled. It is then left to complete in the
+// background, and the writes of the same file fail until it does, instead of blocking more goroutines on it.
+// The write is recorded to the tuning audit log, if any.
+func writeFile(ctx context.Context, name string, data []byte, perm os.FileMode) error {
+	read := func() (string, error) {
+		content, err := hostFS.ReadFile(name)
+		return string(content), err
+	}
+	return auditedWrite(ctx, name, string(data), read, func() error {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("write %s: %w", name, err)
+		}
+		hungWrites.Lock()
+		_, hung := hungWrites.done[name]
+		hungWrites.Unlock()
+		if hung {
+			return fmt.Errorf("write %s: a previous write is still blocked", name)
+		}
+
+		done := make(chan error, 1)
+		go func() {
+			err := hostFS.WriteFile(name, data, perm)
+			hungWrites.Lock()
+			defer hungWrites.Unlock()
+			if hungWrites.done[name] == done {
+				delete(hungWrites.done, name)
+			}
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			return err
+		case <-ctx.Done():
+		}
+
+		hungWrites.Lock()
+		defer hungWrites.Unlock()
+		select {
+		case err := <-done:
+			// the write completed meanwhile
+			return err
+		default:
+			hungWrites.done[name] = done
+			return fmt.Errorf("write %s: %w", name, ctx.Err())
+		}
+	})
+}
+
+// writeFileIfChanged writes data to the file named by name, unless the file already holds it.
+// The values are compared without their surrounding white spaces, as sysfs adds a trailing newline.
+func writeFileIfChanged(ctx context.Context, name string, data []byte, perm os.FileMode) error {
+	if current, err := hostFS.ReadFile(name); err == nil && bytes.Equal(bytes.TrimSpace(current), bytes.TrimSpace(data)) {
+		log.Debugf(ctx, "File %s is already set to %q, skipping", name, bytes.TrimSpace(data))
+		return nil
+	}
+	return writeFile(ctx, name, data, perm)
 }
 
 func retrieveIrqBannedCPUMasks(irqBalanceConfigFile string) (string, error) {
-	input, err := os.ReadFile(irqBalanceConfigFile)
+	input, err := hostFS.ReadFile(irqBalanceConfigFile)
 	if err != nil {
 		return "", err
 	}
@@ -198,7 +281,7 @@ func retrieveIrqBannedCPUMasks(irqBalanceConfigFile string) (string, error) {
 }
 
 func fileExists(filename string) bool {
-	info, err := os.Stat(filename)
+	info, err := hostFS.Stat(filename)
 	if os.IsNotExist(err) {
 		return false
 	}
@@ -2,89 +2,140 @@ package runtimehandlerhooks
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
 
 var _ = Describe("Utils", func() {
-	Describe("UpdateIRQSmpAffinityMask", func() {
-		type Input struct {
-			cpus string
-			mask string
-			set  bool
-		}
-		type Expected struct {
-			mask    string
-			invMask string
-		}
-		type TestData struct {
-			input    Input
-			expected Expected
-		}
-
-		DescribeTable("testing cpu mask",
-			func(c TestData) {
-				mask, invMask, err := UpdateIRQSmpAffinityMask(c.input.cpus, c.input.mask, c.input.set)
+	Context("UpdateIRQBalanceConfigFile", func() {
+		It("Should not let the file grow unbounded", func() {
+			fakeFile, err := writeTempFile(confTemplate)
+			Expect(err).ToNot(HaveOccurred())
+			defer os.Remove(fakeFile)
+
+			fakeData := "000000000,0000000fa" // doesn't need to be valid
+			err = updateIrqBalanceConfigFile(context.TODO(), fakeFile, fakeData)
+			Expect(err).ToNot(HaveOccurred())
+
+			refLineCount, err := countLines(fakeFile)
+			Expect(err).ToNot(HaveOccurred())
+
+			attempts := 10 // random number, no special meaning
+			for idx := range attempts {
+				data := fmt.Sprintf("000000000,0000000%02x", idx)
+				err = updateIrqBalanceConfigFile(context.TODO(), fakeFile, data)
 				Expect(err).ToNot(HaveOccurred())
-				Expect(mask).To(Equal(c.expected.mask))
-				Expect(invMask).To(Equal(c.expected.invMask))
-			},
-			Entry("clear a single bit that was one", TestData{
-				input:    Input{cpus: "0", mask: "0000,00003003", set: false},
-				expected: Expected{mask: "00000000,00003002", invMask: "0000ffff,ffffcffd"},
-			}),
-			Entry("set a single bit that was zero", TestData{
-				input:    Input{cpus: "4", mask: "0000,00003003", set: true},
-				expected: Expected{mask: "00000000,00003013", invMask: "0000ffff,ffffcfec"},
-			}),
-			Entry("clear a set of bits", TestData{
-				input:    Input{cpus: "4-13", mask: "ffff,ffffffff", set: false},
-				expected: Expected{mask: "0000ffff,ffffc00f", invMask: "00000000,00003ff0"},
-			}),
-			Entry("set a set of bits", TestData{
-				input:    Input{cpus: "4-13", mask: "ffff,ffffc00f", set: true},
-				expected: Expected{mask: "0000ffff,ffffffff", invMask: "00000000,00000000"},
-			}),
-			Entry("clear a single bit that was one when odd mask is present", TestData{
-				input:    Input{cpus: "9", mask: "fff", set: false},
-				expected: Expected{mask: "00000dff", invMask: "0000f200"},
-			}),
-			Entry("clear two bits from a short mask", TestData{
-				input:    Input{cpus: "2-3", mask: "ffffff", set: false},
-				expected: Expected{mask: "00fffff3", invMask: "0000000c"},
-			}),
-		)
-
-		Context("UpdateIRQBalanceConfigFile", func() {
-			It("Should not let the file grow unbounded", func() {
-				fakeFile, err := writeTempFile(confTemplate)
-				Expect(err).ToNot(HaveOccurred())
-				defer os.Remove(fakeFile)
 
-				fakeData := "000000000,0000000fa" // doesn't need to be valid
-				err = updateIrqBalanceConfigFile(fakeFile, fakeData)
+				curLineCount, err := countLines(fakeFile)
 				Expect(err).ToNot(HaveOccurred())
 
-				refLineCount, err := countLines(fakeFile)
-				Expect(err).ToNot(HaveOccurred())
+				// we should replace the line in place
+				Expect(curLineCount).To(Equal(refLineCount), "irqbalance file grown from %d to %d lines", refLineCount, curLineCount)
+			}
+		})
+	})
+})
+
+var _ = Describe("updateIrqBalanceBannedIRQs", func() {
+	It("should ban the IRQs in the irqbalance arguments and lift their ban", func() {
+		file := filepath.Join(GinkgoT().TempDir(), "irqbalance")
+		Expect(os.WriteFile(file, []byte("IRQBALANCE_BANNED_CPUS=\"0\"\nIRQBALANCE_ARGS=\"--policyscript=/bin/true\"\n"), 0o644)).To(Succeed())
+
+		Expect(updateIrqBalanceBannedIRQs(context.TODO(), file, []int{41, 42}, true)).To(Succeed())
+		Expect(updateIrqBalanceBannedIRQs(context.TODO(), file, []int{42}, true)).To(Succeed())
+
+		Expect(os.ReadFile(file)).To(Equal([]byte("IRQBALANCE_BANNED_CPUS=\"0\"\nIRQBALANCE_ARGS=\"--policyscript=/bin/true --banirq=41 --banirq=42\"\n")))
+
+		Expect(updateIrqBalanceBannedIRQs(context.TODO(), file, []int{41, 42}, false)).To(Succeed())
+
+		Expect(os.ReadFile(file)).To(Equal([]byte("IRQBALANCE_BANNED_CPUS=\"0\"\nIRQBALANCE_ARGS=\"--policyscript=/bin/true\"\n")))
+	})
+})
+
+var _ = Describe("writeFileIfChanged", func() {
+	It("should only write the files holding a different value", func() {
+		file := filepath.Join(GinkgoT().TempDir(), "scaling_governor")
+		Expect(os.WriteFile(file, []byte("powersave\n"), 0o644)).To(Succeed())
 
-				attempts := 10 // random number, no special meaning
-				for idx := range attempts {
-					data := fmt.Sprintf("000000000,0000000%02x", idx)
-					err = updateIrqBalanceConfigFile(fakeFile, data)
-					Expect(err).ToNot(HaveOccurred())
+		Expect(writeFileIfChanged(context.TODO(), file, []byte("powersave"), 0o644)).To(Succeed())
+		Expect(os.ReadFile(file)).To(Equal([]byte("powersave\n")))
+
+		Expect(writeFileIfChanged(context.TODO(), file, []byte("performance"), 0o644)).To(Succeed())
+		Expect(os.ReadFile(file)).To(Equal([]byte("performance")))
+	})
+})
 
-					curLineCount, err := countLines(fakeFile)
-					Expect(err).ToNot(HaveOccurred())
+var _ = Describe("forEachCPU", func() {
+	It("should run for every CPU with a bounded pool of workers", func() {
+		var running, maxRunning atomic.Int32
+		var visited sync.Map
+		cpus := make([]int, 4*maxCPUWorkers)
+		for i := range cpus {
+			cpus[i] = i
+		}
 
-					// we should replace the line in place
-					Expect(curLineCount).To(Equal(refLineCount), "irqbalance file grown from %d to %d lines", refLineCount, curLineCount)
+		Expect(forEachCPU(cpus, func(cpu int) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				old := maxRunning.Load()
+				if n <= old || maxRunning.CompareAndSwap(old, n) {
+					break
 				}
-			})
+			}
+			time.Sleep(time.Millisecond)
+			visited.Store(cpu, true)
+			return nil
+		})).To(Succeed())
+
+		Expect(maxRunning.Load()).To(BeNumerically("<=", maxCPUWorkers))
+		for _, cpu := range cpus {
+			_, ok := visited.Load(cpu)
+			Expect(ok).To(BeTrue())
+		}
+	})
+
+	It("should aggregate the errors of all the CPUs", func() {
+		err := forEachCPU([]int{0, 1, 2, 3}, func(cpu int) error {
+			if cpu%2 == 1 {
+				return fmt.Errorf("cpu %d failed", cpu)
+			}
+			return nil
 		})
+		Expect(err).To(MatchError("cpu 1 failed\ncpu 3 failed"))
+	})
+})
+
+var _ = Describe("lockFile", func() {
+	It("should serialize the callers of the same file only", func() {
+		unlock := lockFile("/sys/devices/system/cpu/cpu1/cpufreq/scaling_governor")
+
+		// another file is not locked
+		lockFile("/sys/devices/system/cpu/cpu2/cpufreq/scaling_governor")()
+
+		locked := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			lockFile("/sys/devices/system/cpu/cpu1/cpufreq/scaling_governor")()
+			close(locked)
+		}()
+		Consistently(locked, 50*time.Millisecond).ShouldNot(BeClosed())
+
+		unlock()
+		Eventually(locked).Should(BeClosed())
+		Eventually(func() int {
+			fileLocks.Lock()
+			defer fileLocks.Unlock()
+			return len(fileLocks.locks)
+		}).Should(BeZero())
 	})
 })
 
@@ -146,3 +197,10 @@ const confTemplate = `# irqbalance is a daemon process that distributes interrup
 
 IRQBALANCE_BANNED_CPUS=
 `
+
+var _ = Describe("serviceUnitName", func() {
+	It("should only add the service suffix to the bare service names", func() {
+		Expect(serviceUnitName("irqbalance")).To(Equal("irqbalance.service"))
+		Expect(serviceUnitName("irqbalance.service")).To(Equal("irqbalance.service"))
+	})
+})
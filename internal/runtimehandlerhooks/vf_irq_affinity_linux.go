@@ -0,0 +1,275 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/utils/cpuset"
+
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/internal/oci"
+	crioannotations "github.com/cri-o/cri-o/pkg/annotations"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+	"github.com/cri-o/cri-o/pkg/cpumask"
+)
+
+const (
+	// procIRQDir is the directory of the IRQs of the node, holding the affinity of each of them.
+	procIRQDir = "/proc/irq"
+	// smpAffinityListFile is the CPU list an IRQ is delivered to.
+	smpAffinityListFile = "smp_affinity_list"
+)
+
+// alignVFIRQs aligns the IRQs and transmit queues of the SR-IOV VFs attached to the pod with the CPUs of the
+// container, the ones of the NUMA node of each VF first, and bans the IRQs from irqbalance, which would
+// otherwise move them away from the CPUs of the container it is banned from.
+func (h *HighPerformanceHooks) alignVFIRQs(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	if s.HostNetwork() || s.NetNsPath() == "" {
+		log.Warnf(ctx, "VF IRQ affinity requested for container %q of a pod on the host network, ignoring", c.ID())
+		noteUnfulfilledAnnotation(ctx, crioannotations.VFIRQAffinityAnnotation, ReasonHostNetwork)
+		return nil
+	}
+	cSpec := c.Spec()
+	if isContainerCPUsSpecEmpty(&cSpec) {
+		return fmt.Errorf("container %q has no CPUs to align the VF IRQs with", c.ID())
+	}
+	cpus, err := cpuset.Parse(cSpec.Linux.Resources.CPU.Cpus)
+	if err != nil {
+		return err
+	}
+	network, err := sandboxNetwork(s)
+	if err != nil {
+		return err
+	}
+	irqs, err := setVFIRQAffinity(ctx, c.ID(), network, cpus, procIRQDir, sysNodeDir)
+	if err != nil {
+		return err
+	}
+	if len(irqs) == 0 {
+		log.Warnf(ctx, "VF IRQ affinity requested for container %q of a pod without SR-IOV VF, ignoring", c.ID())
+		noteUnfulfilledAnnotation(ctx, crioannotations.VFIRQAffinityAnnotation, ReasonNoSRIOVVF)
+		return nil
+	}
+	log.Infof(ctx, "Aligned IRQs %v of the VFs of the pod of container %q with CPUs %s", irqs, c.ID(), cpus.String())
+	return h.banIRQsFromIrqBalance(ctx, irqs, true)
+}
+
+// banIRQsFromIrqBalance bans the IRQs from irqbalance, or lifts their ban, and restarts the irqbalance
+// service for it to take them into account. Without configuration file, irqbalance only runs once
+// when the IRQ load balancing gets tuned, and never moves the IRQs afterwards.
+func (h *HighPerformanceHooks) banIRQsFromIrqBalance(ctx context.Context, irqs []int, ban bool) error {
+	if !fileExists(h.irqBalanceConfigFile) {
+		return nil
+	}
+	if err := updateIrqBalanceBannedIRQs(ctx, h.irqBalanceConfigFile, irqs, ban); err != nil {
+		return fmt.Errorf("update IRQs banned from irqbalance: %w", err)
+	}
+	if isServiceEnabled(ctx, irqBalancedName) {
+		if err := restartIrqBalanceService(ctx); err != nil {
+			log.Warnf(ctx, "Irqbalance service restart failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// setVFIRQAffinity delivers the queue IRQs of the VFs of the pod network
+// to the CPUs, one CPU per queue, and programs the XPS CPU mask of the matching transmit queues to the same CPU,
+// recording the writes for the container. It returns the aligned IRQs.
+func setVFIRQAffinity(ctx context.Context, containerID string, network podNetwork, cpus cpuset.CPUSet, irqDir, nodeDir string) ([]int, error) {
+	alignments, err := vfIRQAlignments(network, cpus, nodeDir)
+	if err != nil {
+		return nil, err
+	}
+	var irqs []int
+	for _, a := range alignments {
+		cpu := strconv.Itoa(a.cpu)
+		if err := writeTuningFile(ctx, containerID, filepath.Join(irqDir, strconv.Itoa(a.irq), smpAffinityListFile), []byte(cpu)); err != nil {
+			return nil, fmt.Errorf("set affinity of IRQ %d of VF %s: %w", a.irq, a.vf, err)
+		}
+		irqs = append(irqs, a.irq)
+		mask := []byte(cpumask.FromCPUSet(cpuset.New(a.cpu)).String())
+		err := writeNetTuningFile(ctx, containerID, a.vf.NetNS, netDeviceFile(a.vf.Name, "queues", a.queue, xpsCPUsFile), mask)
+		if errors.Is(err, os.ErrNotExist) {
+			log.Debugf(ctx, "VF %s has no %s for queue %s, skipping", a.vf, xpsCPUsFile, a.queue)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("set XPS CPU mask of VF %s: %w", a.vf, err)
+		}
+	}
+	return irqs, nil
+}
+
+// planVFIRQAffinity returns the IRQ affinities and XPS CPU masks setVFIRQAffinity would set for the container.
+func planVFIRQAffinity(c *oci.Container, network podNetwork) ([]plannedChange, error) {
+	cSpec := c.Spec()
+	if isContainerCPUsSpecEmpty(&cSpec) {
+		return nil, fmt.Errorf("container %q has no CPUs to align the VF IRQs with", c.ID())
+	}
+	cpus, err := cpuset.Parse(cSpec.Linux.Resources.CPU.Cpus)
+	if err != nil {
+		return nil, err
+	}
+	alignments, err := vfIRQAlignments(network, cpus, sysNodeDir)
+	if err != nil {
+		return nil, err
+	}
+	var changes []plannedChange
+	for _, a := range alignments {
+		changes = append(changes, plannedChange{
+			Feature: libconfig.HighPerformanceFeatureVFIRQAffinity,
+			Path:    filepath.Join(procIRQDir, strconv.Itoa(a.irq), smpAffinityListFile),
+			Value:   strconv.Itoa(a.cpu),
+		}, plannedChange{
+			Feature: libconfig.HighPerformanceFeatureVFIRQAffinity,
+			Path:    netDeviceFile(a.vf.Name, "queues", a.queue, xpsCPUsFile),
+			Value:   cpumask.FromCPUSet(cpuset.New(a.cpu)).String(),
+		})
+	}
+	return changes, nil
+}
+
+// vfIRQAlignment is the CPU a queue of a VF and its IRQ are aligned with.
+type vfIRQAlignment struct {
+	vf    podInterface
+	irq   int
+	queue string
+	cpu   int
+}
+
+// vfIRQAlignments spreads the queues of the VFs of the pod network over the CPUs,
+// the CPUs of the NUMA node of each VF first. The queue IRQs of a VF are its last MSI-X IRQs, one per combined
+// channel, the first ones serving the mailbox and the events of the device.
+func vfIRQAlignments(network podNetwork, cpus cpuset.CPUSet, nodeDir string) ([]vfIRQAlignment, error) {
+	if cpus.IsEmpty() {
+		return nil, errors.New("no CPU to align the VF IRQs with")
+	}
+	vfs, err := podVFs(network)
+	if err != nil {
+		return nil, err
+	}
+	var alignments []vfIRQAlignment
+	for _, vf := range vfs {
+		irqs, node, err := vfIRQs(vf)
+		if err != nil {
+			return nil, fmt.Errorf("list IRQs of VF %s: %w", vf, err)
+		}
+		queues, err := vfQueueCount(vf)
+		if err != nil {
+			return nil, err
+		}
+		irqs = irqs[max(len(irqs)-queues, 0):]
+		ordered, err := numaOrderedCPUs(cpus, node, nodeDir)
+		if err != nil {
+			return nil, err
+		}
+		for i, irq := range irqs {
+			alignments = append(alignments, vfIRQAlignment{
+				vf:    vf,
+				irq:   irq,
+				queue: "tx-" + strconv.Itoa(i),
+				cpu:   ordered[i%len(ordered)],
+			})
+		}
+	}
+	return alignments, nil
+}
+
+// vfIRQs returns the MSI-X IRQs of the VF, sorted, and the NUMA node of its device, -1 if unknown.
+func vfIRQs(vf podInterface) (irqs []int, node int, err error) {
+	node = -1
+	err = withNetNSSysfs(vf.NetNS, func(sysfs string) error {
+		deviceDir := filepath.Join(sysfs, "class", "net", vf.Name, "device")
+		entries, err := os.ReadDir(filepath.Join(deviceDir, "msi_irqs"))
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if irq, err := strconv.Atoi(entry.Name()); err == nil {
+				irqs = append(irqs, irq)
+			}
+		}
+		if content, err := os.ReadFile(filepath.Join(deviceDir, "numa_node")); err == nil {
+			if n, err := strconv.Atoi(strings.TrimSpace(string(content))); err == nil {
+				node = n
+			}
+		}
+		return nil
+	})
+	slices.Sort(irqs)
+	return irqs, node, err
+}
+
+// vfQueueCount returns the combined channels of the VF, or its receive queues if it does not report its channels.
+func vfQueueCount(vf podInterface) (int, error) {
+	content, err := readNetDeviceFile(vf.NetNS, ethtoolSettingFile(vf.Name, ethtoolCombinedChannels))
+	if err == nil {
+		return strconv.Atoi(strings.TrimSpace(string(content)))
+	}
+	if !errors.Is(err, errors.ErrUnsupported) {
+		return 0, fmt.Errorf("get combined channels of VF %s: %w", vf, err)
+	}
+	queues, err := rxQueues(vf)
+	if err != nil {
+		return 0, fmt.Errorf("list queues of VF %s: %w", vf, err)
+	}
+	return len(queues), nil
+}
+
+// numaOrderedCPUs returns the CPUs, the ones of the NUMA node first, all of them in order if the node is unknown.
+func numaOrderedCPUs(cpus cpuset.CPUSet, node int, nodeDir string) ([]int, error) {
+	if node < 0 {
+		return cpus.List(), nil
+	}
+	content, err := hostFS.ReadFile(filepath.Join(nodeDir, fmt.Sprintf("node%d", node), "cpulist"))
+	if err != nil {
+		return nil, err
+	}
+	nodeCPUs, err := cpuset.Parse(strings.TrimSpace(string(content)))
+	if err != nil {
+		return nil, fmt.Errorf("parse CPUs of NUMA node %d: %w", node, err)
+	}
+	local := cpus.Intersection(nodeCPUs)
+	return append(local.List(), cpus.Difference(local).List()...), nil
+}
+
+// revertVFIRQAffinity restores the affinity of the VF IRQs and the XPS CPU masks set for the container,
+// and lifts the ban of the IRQs from irqbalance.
+func (h *HighPerformanceHooks) revertVFIRQAffinity(ctx context.Context, containerID string) error {
+	record, ok := recordedTuning(containerID)
+	if !ok {
+		return nil
+	}
+	var (
+		irqs []int
+		errs []error
+	)
+	for i := len(record.Writes) - 1; i >= 0; i-- {
+		w := record.Writes[i]
+		if filepath.Base(w.Path) != smpAffinityListFile {
+			continue
+		}
+		if irq, err := strconv.Atoi(filepath.Base(filepath.Dir(w.Path))); err == nil {
+			irqs = append(irqs, irq)
+		}
+		if err := restoreTuningWrite(ctx, &w); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	if err := restoreNetDeviceTuning(ctx, containerID, xpsCPUsFile); err != nil {
+		errs = append(errs, err)
+	}
+	if len(irqs) > 0 {
+		if err := h.banIRQsFromIrqBalance(ctx, irqs, false); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,84 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/cpuset"
+)
+
+var _ = Describe("VF IRQ affinity", func() {
+	const (
+		netns       = "/var/run/netns/pod"
+		containerID = "ctr1"
+	)
+	var dir, irqDir, nodeDir string
+
+	readFile := func(elem ...string) string {
+		content, err := os.ReadFile(filepath.Join(elem...))
+		Expect(err).ToNot(HaveOccurred())
+		return strings.TrimSpace(string(content))
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		irqDir = GinkgoT().TempDir()
+		nodeDir = GinkgoT().TempDir()
+		useFakePodNetwork([]podInterface{{Name: "eth0", NetNS: netns}, {Name: "net1", NetNS: netns}}, map[string]string{netns: dir}, fakeEthtool{"net1": {ethtoolCombinedChannels: "3"}})
+
+		deviceDir := filepath.Join(dir, "class", "net", "net1", "device")
+		Expect(os.MkdirAll(filepath.Join(deviceDir, "physfn"), 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(deviceDir, "numa_node"), []byte("1\n"), 0o644)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(dir, "class", "net", "eth0", "device"), 0o755)).To(Succeed())
+		// the first IRQ of the VF is its mailbox one
+		for _, irq := range []string{"40", "41", "42", "43"} {
+			Expect(os.MkdirAll(filepath.Join(deviceDir, "msi_irqs"), 0o755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(deviceDir, "msi_irqs", irq), []byte("msix\n"), 0o644)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(irqDir, irq), 0o755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(irqDir, irq, smpAffinityListFile), []byte("0-7\n"), 0o644)).To(Succeed())
+		}
+		for _, queue := range []string{"tx-0", "tx-1", "tx-2"} {
+			Expect(os.MkdirAll(filepath.Join(dir, "class", "net", "net1", "queues", queue), 0o755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "class", "net", "net1", "queues", queue, xpsCPUsFile), []byte("00\n"), 0o644)).To(Succeed())
+		}
+		Expect(os.MkdirAll(filepath.Join(nodeDir, "node1"), 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(nodeDir, "node1", "cpulist"), []byte("4-7\n"), 0o644)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		forgetAppliedTuning(context.TODO(), containerID)
+	})
+
+	It("should align the queue IRQs of the VFs with the container CPUs of their NUMA node first", func() {
+		irqs, err := setVFIRQAffinity(context.TODO(), containerID, podNetwork{NetNS: netns}, cpuset.New(2, 4, 5), irqDir, nodeDir)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(irqs).To(Equal([]int{41, 42, 43}))
+		Expect(readFile(irqDir, "40", smpAffinityListFile)).To(Equal("0-7"))
+		Expect(readFile(irqDir, "41", smpAffinityListFile)).To(Equal("4"))
+		Expect(readFile(irqDir, "42", smpAffinityListFile)).To(Equal("5"))
+		Expect(readFile(irqDir, "43", smpAffinityListFile)).To(Equal("2"))
+		Expect(readFile(dir, "class", "net", "net1", "queues", "tx-0", xpsCPUsFile)).To(Equal("00000010"))
+		Expect(readFile(dir, "class", "net", "net1", "queues", "tx-2", xpsCPUsFile)).To(Equal("00000004"))
+
+		Expect((&HighPerformanceHooks{}).revertVFIRQAffinity(context.TODO(), containerID)).To(Succeed())
+
+		Expect(readFile(irqDir, "41", smpAffinityListFile)).To(Equal("0-7"))
+		Expect(readFile(irqDir, "43", smpAffinityListFile)).To(Equal("0-7"))
+		Expect(readFile(dir, "class", "net", "net1", "queues", "tx-0", xpsCPUsFile)).To(Equal("00"))
+	})
+
+	It("should find no IRQ to align in a pod without SR-IOV VF", func() {
+		Expect(os.RemoveAll(filepath.Join(dir, "class", "net", "net1", "device", "physfn"))).To(Succeed())
+
+		irqs, err := setVFIRQAffinity(context.TODO(), containerID, podNetwork{NetNS: netns}, cpuset.New(2, 4, 5), irqDir, nodeDir)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(irqs).To(BeEmpty())
+		Expect(readFile(irqDir, "41", smpAffinityListFile)).To(Equal("0-7"))
+	})
+})
@@ -0,0 +1,151 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"k8s.io/utils/cpuset"
+
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/internal/oci"
+	crioannotations "github.com/cri-o/cri-o/pkg/annotations"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+)
+
+// setVFQueues sets the combined channels of the SR-IOV VFs attached to the pod to the CPU count of the container,
+// bounded by the channels the VFs support, so that every CPU of the container gets a queue of its own.
+func (h *HighPerformanceHooks) setVFQueues(ctx context.Context, c *oci.Container, s *sandbox.Sandbox) error {
+	if s.HostNetwork() || s.NetNsPath() == "" {
+		log.Warnf(ctx, "VF queues requested for container %q of a pod on the host network, ignoring", c.ID())
+		noteUnfulfilledAnnotation(ctx, crioannotations.VFQueuesAnnotation, ReasonHostNetwork)
+		return nil
+	}
+	count, err := containerCPUCount(c)
+	if err != nil {
+		return err
+	}
+	network, err := sandboxNetwork(s)
+	if err != nil {
+		return err
+	}
+	vfs, err := setVFChannels(ctx, c.ID(), network, count)
+	if err != nil {
+		return err
+	}
+	if len(vfs) == 0 {
+		log.Warnf(ctx, "VF queues requested for container %q of a pod without SR-IOV VF, ignoring", c.ID())
+		noteUnfulfilledAnnotation(ctx, crioannotations.VFQueuesAnnotation, ReasonNoSRIOVVF)
+	}
+	return nil
+}
+
+// setVFChannels sets the combined channels of the VFs of the pod network to
+// count, bounded by the channels each of them supports, recording the writes for the container. It returns the VFs.
+func setVFChannels(ctx context.Context, containerID string, network podNetwork, count int) ([]podInterface, error) {
+	vfs, err := podVFs(network)
+	if err != nil {
+		return nil, err
+	}
+	for _, vf := range vfs {
+		channels, err := vfChannels(vf, count)
+		if err != nil {
+			return nil, err
+		}
+		log.Infof(ctx, "Set the combined channels of VF %s of the pod of container %q to %d", vf, containerID, channels)
+		if err := writeNetTuningFile(ctx, containerID, vf.NetNS, ethtoolSettingFile(vf.Name, ethtoolCombinedChannels), []byte(strconv.Itoa(channels))); err != nil {
+			return nil, fmt.Errorf("set combined channels of VF %s: %w", vf, err)
+		}
+	}
+	return vfs, nil
+}
+
+// planVFQueues returns the combined channels setVFQueues would set for the container.
+func planVFQueues(c *oci.Container, network podNetwork) ([]plannedChange, error) {
+	count, err := containerCPUCount(c)
+	if err != nil {
+		return nil, err
+	}
+	vfs, err := podVFs(network)
+	if err != nil {
+		return nil, err
+	}
+	var changes []plannedChange
+	for _, vf := range vfs {
+		channels, err := vfChannels(vf, count)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, plannedChange{
+			Feature: libconfig.HighPerformanceFeatureVFQueues,
+			Path:    ethtoolSettingFile(vf.Name, ethtoolCombinedChannels),
+			Value:   strconv.Itoa(channels),
+		})
+	}
+	return changes, nil
+}
+
+// revertVFQueues restores the combined channels of the VFs set for the container.
+func revertVFQueues(ctx context.Context, containerID string) error {
+	return restoreNetDeviceTuning(ctx, containerID, ethtoolCombinedChannels)
+}
+
+// containerCPUCount returns the number of CPUs of the container.
+func containerCPUCount(c *oci.Container) (int, error) {
+	cSpec := c.Spec()
+	if isContainerCPUsSpecEmpty(&cSpec) {
+		return 0, fmt.Errorf("container %q has no CPUs to match", c.ID())
+	}
+	cpus, err := cpuset.Parse(cSpec.Linux.Resources.CPU.Cpus)
+	if err != nil {
+		return 0, err
+	}
+	return cpus.Size(), nil
+}
+
+// vfChannels returns the combined channels of the VF for count CPUs, bounded by the channels it supports.
+func vfChannels(vf podInterface, count int) (int, error) {
+	content, err := readNetDeviceFile(vf.NetNS, ethtoolSettingFile(vf.Name, ethtoolCombinedChannelsMax))
+	if err != nil {
+		return 0, fmt.Errorf("get combined channels of VF %s: %w", vf, err)
+	}
+	maxChannels, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		return 0, err
+	}
+	if maxChannels == 0 {
+		return 0, fmt.Errorf("VF %s does not support combined channels", vf)
+	}
+	return min(count, maxChannels), nil
+}
+
+// podVFs returns the SR-IOV VFs moved to the network namespace of the pod, which are the devices
+// of the network namespace whose PCI device has a physical function.
+func podVFs(network podNetwork) ([]podInterface, error) {
+	interfaces, err := network.interfaces()
+	if err != nil {
+		return nil, err
+	}
+	var vfs []podInterface
+	for _, iface := range interfaces {
+		if iface.NetNS == "" {
+			continue
+		}
+		if err := withNetNSSysfs(iface.NetNS, func(sysfs string) error {
+			_, err := os.Stat(filepath.Join(sysfs, "class", "net", iface.Name, "device", "physfn"))
+			return err
+		}); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		vfs = append(vfs, iface)
+	}
+	return vfs, nil
+}
@@ -0,0 +1,122 @@
+package runtimehandlerhooks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeEthtool holds the ethtool settings of the devices, keyed by device and setting.
+// The settings it does not hold are not supported by the device.
+type fakeEthtool map[string]map[string]string
+
+func (f fakeEthtool) Get(device, setting string) (string, error) {
+	value, ok := f[device][setting]
+	if !ok {
+		return "", errors.ErrUnsupported
+	}
+	return value, nil
+}
+
+func (f fakeEthtool) Set(device, setting, value string) error {
+	if _, ok := f[device][setting]; !ok {
+		return errors.ErrUnsupported
+	}
+	f[device][setting] = value
+	return nil
+}
+
+var _ = Describe("VF queues", func() {
+	const (
+		netns       = "/var/run/netns/pod"
+		containerID = "ctr1"
+	)
+	var (
+		dir     string
+		ethtool fakeEthtool
+	)
+
+	addDevice := func(device string, vf bool) {
+		deviceDir := filepath.Join(dir, "class", "net", device, "device")
+		Expect(os.MkdirAll(deviceDir, 0o755)).To(Succeed())
+		if vf {
+			Expect(os.Mkdir(filepath.Join(deviceDir, "physfn"), 0o755)).To(Succeed())
+		}
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		ethtool = fakeEthtool{
+			"net1": {ethtoolCombinedChannels: "16", ethtoolCombinedChannelsMax: "16"},
+		}
+		useFakePodNetwork([]podInterface{{Name: "eth0", NetNS: netns}, {Name: "net1", NetNS: netns}, {Name: "veth1234"}}, map[string]string{netns: dir}, ethtool)
+		addDevice("eth0", false)
+		addDevice("net1", true)
+	})
+
+	AfterEach(func() {
+		forgetAppliedTuning(context.TODO(), containerID)
+	})
+
+	It("should set and revert the combined channels of the VFs of the pod", func() {
+		vfs, err := setVFChannels(context.TODO(), containerID, podNetwork{NetNS: netns}, 4)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vfs).To(Equal([]podInterface{{Name: "net1", NetNS: netns}}))
+		Expect(ethtool["net1"][ethtoolCombinedChannels]).To(Equal("4"))
+		record, ok := recordedTuning(containerID)
+		Expect(ok).To(BeTrue())
+		Expect(record.Writes).To(ContainElement(fileWrite{
+			Path: "/sys/class/net/net1/ethtool/combined-channels", NetNS: netns, Original: "16", Value: "4",
+		}))
+
+		Expect(revertVFQueues(context.TODO(), containerID)).To(Succeed())
+
+		Expect(ethtool["net1"][ethtoolCombinedChannels]).To(Equal("16"))
+	})
+
+	It("should bound the combined channels to the ones the VF supports", func() {
+		ethtool["net1"][ethtoolCombinedChannelsMax] = "8"
+
+		_, err := setVFChannels(context.TODO(), containerID, podNetwork{NetNS: netns}, 32)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ethtool["net1"][ethtoolCombinedChannels]).To(Equal("8"))
+	})
+
+	It("should find no VF in a pod without SR-IOV VF", func() {
+		Expect(os.RemoveAll(filepath.Join(dir, "class", "net", "net1", "device", "physfn"))).To(Succeed())
+
+		vfs, err := setVFChannels(context.TODO(), containerID, podNetwork{NetNS: netns}, 4)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vfs).To(BeEmpty())
+		Expect(ethtool["net1"][ethtoolCombinedChannels]).To(Equal("16"))
+	})
+
+	It("should skip the VFs gone along with the network namespace of the pod", func() {
+		_, err := setVFChannels(context.TODO(), containerID, podNetwork{NetNS: netns}, 4)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.RemoveAll(filepath.Join(dir, "class", "net", "net1"))).To(Succeed())
+
+		Expect(revertVFQueues(context.TODO(), containerID)).To(Succeed())
+
+		Expect(ethtool["net1"][ethtoolCombinedChannels]).To(Equal("4"))
+	})
+})
+
+var _ = Describe("ethtoolSettingFile", func() {
+	It("should be parsed back to its device and setting", func() {
+		device, setting, ok := parseEthtoolSettingFile(ethtoolSettingFile("net1", ethtoolCombinedChannels))
+
+		Expect(ok).To(BeTrue())
+		Expect(device).To(Equal("net1"))
+		Expect(setting).To(Equal(ethtoolCombinedChannels))
+		_, _, ok = parseEthtoolSettingFile(netDeviceFile("net1", "queues", "rx-0", rpsCPUsFile))
+		Expect(ok).To(BeFalse())
+	})
+})
@@ -0,0 +1,87 @@
+package runtimehandlerhooks
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	crioann "github.com/cri-o/cri-o/pkg/annotations"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+)
+
+// The annotations of the VM runtimes configuring the hypervisor and the guest of a pod. Kata Containers
+// only honors the ones listed in the enable_annotations option of its hypervisor configuration.
+const (
+	// VMEnableVCPUsPinningAnnotation pins every vCPU of the guest to one of the CPUs of the pod.
+	VMEnableVCPUsPinningAnnotation = "io.katacontainers.config.runtime.enable_vcpus_pinning"
+	// VMKernelParamsAnnotation are the parameters appended to the command line of the guest kernel.
+	VMKernelParamsAnnotation = "io.katacontainers.config.hypervisor.kernel_params"
+)
+
+// The parameters of the guest kernel translating the tuning annotations.
+const (
+	// vmIRQAffinityKernelParam routes the interrupts of the guest devices to its first vCPU.
+	vmIRQAffinityKernelParam = "irqaffinity=0"
+	// vmIdlePollKernelParam makes the idle vCPUs poll instead of halting, which exits the guest.
+	vmIdlePollKernelParam = "idle=poll"
+)
+
+// VMTuningAnnotations translates the tuning annotations of a pod whose runtime handler delegates the tuning
+// to the runtime running it in a VM into the annotations configuring its hypervisor and guest, merged into
+// runtimeAnnotations, the annotations the runtime gets for the pod. It returns the tuning annotations which
+// have no equivalent in a VM, like the CPU frequency governor of the guest CPUs, so that the caller can tell
+// they are ignored.
+func VMTuningAnnotations(config *libconfig.Config, annotations, runtimeAnnotations map[string]string) (vmAnnotations map[string]string, ignored []string) {
+	settings := config.HighPerformanceSettings()
+	disabled := disabledFeaturesOf(&settings)
+	vmAnnotations = map[string]string{}
+	var kernelParams []string
+	addKernelParam := func(param string) {
+		if !slices.Contains(kernelParams, param) {
+			kernelParams = append(kernelParams, param)
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(annotations)) {
+		value := annotations[key]
+		annotation, _, _ := strings.Cut(key, "/")
+		switch annotation {
+		case crioann.CPULoadBalancingAnnotation:
+			if disabled.cpuLoadBalancing || (value != annotationTrue && value != annotationDisable) {
+				continue
+			}
+			// The vCPUs of the guest do not get moved across the CPUs of the pod anymore.
+			vmAnnotations[VMEnableVCPUsPinningAnnotation] = annotationTrue
+		case crioann.IRQLoadBalancingAnnotation:
+			if disabled.irqLoadBalancing || (value != annotationTrue && value != annotationDisable) {
+				continue
+			}
+			vmAnnotations[VMEnableVCPUsPinningAnnotation] = annotationTrue
+			addKernelParam(vmIRQAffinityKernelParam)
+		case crioann.CPUCStatesAnnotation:
+			if disabled.cStates || value == annotationEnable {
+				continue
+			}
+			if value != annotationDisable {
+				// The guest does not bound the exit latency of its idle states.
+				ignored = append(ignored, key)
+				continue
+			}
+			addKernelParam(vmIdlePollKernelParam)
+		case crioann.CPUQuotaAnnotation, crioann.CPUFreqGovernorAnnotation,
+			crioann.CPUSharedAnnotation, crioann.CPUInitAffinityAnnotation, crioann.PacketSteeringAnnotation,
+			crioann.VFQueuesAnnotation, crioann.ARFSAnnotation, crioann.VFIRQAffinityAnnotation, crioann.AFXDPAnnotation,
+			crioann.NAPIAffinityAnnotation, crioann.InterruptCoalescingAnnotation, crioann.QdiscAnnotation,
+			crioann.NetdevBudgetAnnotation, crioann.GROAnnotation:
+			ignored = append(ignored, key)
+		}
+	}
+
+	if len(kernelParams) > 0 {
+		if params := runtimeAnnotations[VMKernelParamsAnnotation]; params != "" {
+			kernelParams = append([]string{params}, kernelParams...)
+		}
+		vmAnnotations[VMKernelParamsAnnotation] = strings.Join(kernelParams, " ")
+	}
+	return vmAnnotations, ignored
+}
@@ -0,0 +1,67 @@
+package runtimehandlerhooks
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	crioann "github.com/cri-o/cri-o/pkg/annotations"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
+)
+
+var _ = Describe("VMTuningAnnotations", func() {
+	var config *libconfig.Config
+
+	BeforeEach(func() {
+		config = &libconfig.Config{}
+		config.HighPerformanceCPULoadBalancing = true
+		config.HighPerformanceIRQLoadBalancing = true
+		config.HighPerformanceCPUQuota = true
+		config.HighPerformanceCPUCStates = true
+		config.HighPerformanceCPUFreqGovernor = true
+		config.HighPerformanceSharedCPUs = true
+	})
+
+	It("should translate the tuning annotations into the hypervisor and guest configuration", func() {
+		vmAnnotations, ignored := VMTuningAnnotations(config, map[string]string{
+			crioann.CPULoadBalancingAnnotation: "disable",
+			crioann.IRQLoadBalancingAnnotation: "disable",
+			crioann.CPUCStatesAnnotation:       "disable",
+		}, map[string]string{VMKernelParamsAnnotation: "quiet"})
+
+		Expect(vmAnnotations).To(Equal(map[string]string{
+			VMEnableVCPUsPinningAnnotation: "true",
+			VMKernelParamsAnnotation:       "quiet idle=poll irqaffinity=0",
+		}))
+		Expect(ignored).To(BeEmpty())
+	})
+
+	It("should report the tuning annotations without equivalent in a VM", func() {
+		vmAnnotations, ignored := VMTuningAnnotations(config, map[string]string{
+			crioann.CPUQuotaAnnotation:                 "disable",
+			crioann.CPUFreqGovernorAnnotation:          "performance",
+			crioann.CPUCStatesAnnotation:               "max_latency:10",
+			crioann.CPUSharedAnnotation + "/ctr":       "enable",
+			crioann.CPULoadBalancingAnnotation:         "enable",
+			crioann.CPUInitAffinityAnnotation + "/ctr": "shared",
+		}, nil)
+
+		Expect(vmAnnotations).To(BeEmpty())
+		Expect(ignored).To(ConsistOf(
+			crioann.CPUQuotaAnnotation,
+			crioann.CPUFreqGovernorAnnotation,
+			crioann.CPUCStatesAnnotation,
+			crioann.CPUSharedAnnotation+"/ctr",
+			crioann.CPUInitAffinityAnnotation+"/ctr",
+		))
+	})
+
+	It("should not translate the annotations of the disabled features", func() {
+		config.HighPerformanceCPULoadBalancing = false
+
+		vmAnnotations, _ := VMTuningAnnotations(config, map[string]string{
+			crioann.CPULoadBalancingAnnotation: "disable",
+		}, nil)
+
+		Expect(vmAnnotations).To(BeEmpty())
+	})
+})
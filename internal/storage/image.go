@@ -33,6 +33,7 @@ import (
 	digest "github.com/opencontainers/go-digest"
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/semaphore"
 	crierrors "k8s.io/cri-api/pkg/errors"
 
 	"github.com/cri-o/cri-o/internal/log"
@@ -104,6 +105,8 @@ type imageService struct {
 	ctx                  context.Context
 	config               *config.Config
 	regexForPinnedImages []*regexp.Regexp
+	// pinnedImagesLock guards regexForPinnedImages, which gets replaced on reload.
+	pinnedImagesLock sync.RWMutex
 }
 
 // ImageBeingPulled map[string]bool to keep track of the images haven't done pulling.
@@ -125,6 +128,13 @@ type ImageCopyOptions struct {
 	ProgressInterval time.Duration
 	Progress         chan types.ProgressProperties `json:"-"`
 	CgroupPull       CgroupPullConfiguration
+	// MaxParallelDownloads is the maximum number of layers the pull downloads at the same time,
+	// 0 for the default of containers/image.
+	MaxParallelDownloads uint
+	// ConcurrentLayerDownloads is shared by the pulls bounding their layer downloads together, like the ones
+	// from the same registry. It takes precedence over MaxParallelDownloads, but it cannot be shared with
+	// a pull running in a separate cgroup, which falls back to MaxParallelDownloads.
+	ConcurrentLayerDownloads *semaphore.Weighted `json:"-"`
 }
 
 // ImageServer wraps up various CRI-related activities into a reusable
@@ -172,6 +182,12 @@ type ImageServer interface {
 
 	// UpdatePinnedImagesList updates pinned and pause images list in imageService.
 	UpdatePinnedImagesList(imageList []string)
+	// IsImagePinned returns whether the image with the ID is pinned, which
+	// protects it from being removed.
+	IsImagePinned(id StorageImageID) (bool, error)
+	// IsImageNamePinned returns whether the name of an image is pinned, which
+	// protects it from being untagged.
+	IsImageNamePinned(name RegistryImageReference) bool
 
 	// IsRunningImageAllowed verifies if running of the container image is allowed.
 	//
@@ -327,13 +343,7 @@ func (svc *imageService) buildImageResult(image *storage.Image, cacheItem imageC
 		}
 	}
 
-	imagePinned := false
-	for _, image := range image.Names {
-		if FilterPinnedImage(image, svc.regexForPinnedImages) {
-			imagePinned = true
-			break
-		}
-	}
+	imagePinned := svc.isPinned(image.Names)
 
 	// Try to retrieve the mountpoint
 	mountPoint := ""
@@ -813,6 +823,9 @@ func pullImageImplementation(ctx context.Context, lookup *imageLookupService, st
 		OciDecryptConfig: options.OciDecryptConfig,
 		ProgressInterval: options.ProgressInterval,
 		Progress:         options.Progress,
+
+		MaxParallelDownloads:          options.MaxParallelDownloads,
+		ConcurrentBlobCopiesSemaphore: options.ConcurrentLayerDownloads,
 	})
 	if err != nil {
 		return RegistryImageReference{}, err
@@ -1022,7 +1035,36 @@ func (st nativeStorageTransport) ResolveReference(ref types.ImageReference) (typ
 
 // UpdatePinnedImagesList updates pinned images list in imageService.
 func (svc *imageService) UpdatePinnedImagesList(pinnedImages []string) {
-	svc.regexForPinnedImages = CompileRegexpsForPinnedImages(pinnedImages)
+	regexps := CompileRegexpsForPinnedImages(pinnedImages)
+	svc.pinnedImagesLock.Lock()
+	defer svc.pinnedImagesLock.Unlock()
+	svc.regexForPinnedImages = regexps
+}
+
+// IsImagePinned returns whether the image with the ID is pinned.
+func (svc *imageService) IsImagePinned(id StorageImageID) (bool, error) {
+	img, err := svc.store.Image(id.privateID)
+	if err != nil {
+		return false, err
+	}
+	return svc.isPinned(img.Names), nil
+}
+
+// IsImageNamePinned returns whether the name of an image is pinned.
+func (svc *imageService) IsImageNamePinned(name RegistryImageReference) bool {
+	return svc.isPinned([]string{name.Raw().String()})
+}
+
+// isPinned returns whether one of the names of an image is pinned.
+func (svc *imageService) isPinned(names []string) bool {
+	svc.pinnedImagesLock.RLock()
+	defer svc.pinnedImagesLock.RUnlock()
+	for _, name := range names {
+		if FilterPinnedImage(name, svc.regexForPinnedImages) {
+			return true
+		}
+	}
+	return false
 }
 
 // FilterPinnedImage checks if the given image needs to be pinned
@@ -653,6 +653,62 @@ var _ = t.Describe("Image", func() {
 		})
 	})
 
+	t.Describe("IsImagePinned", func() {
+		It("should follow the updates of the pinned images", func() {
+			// Given
+			id, err := storage.ParseStorageImageIDFromOutOfProcessData(testSHA256)
+			Expect(err).ToNot(HaveOccurred())
+			storeMock.EXPECT().Image(testSHA256).Times(2).
+				Return(&cs.Image{ID: testSHA256, Names: []string{"quay.io/crio/pause:latest"}}, nil)
+
+			// When
+			sut.UpdatePinnedImagesList([]string{"quay.io/crio/*"})
+			pinned, err := sut.IsImagePinned(id)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pinned).To(BeTrue())
+
+			// When
+			sut.UpdatePinnedImagesList([]string{"docker.io/*"})
+			pinned, err = sut.IsImagePinned(id)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pinned).To(BeFalse())
+		})
+
+		It("should fail if the image is unknown", func() {
+			// Given
+			id, err := storage.ParseStorageImageIDFromOutOfProcessData(testSHA256)
+			Expect(err).ToNot(HaveOccurred())
+			storeMock.EXPECT().Image(testSHA256).Return(nil, cs.ErrImageUnknown)
+
+			// When
+			_, err = sut.IsImagePinned(id)
+
+			// Then
+			Expect(err).To(MatchError(cs.ErrImageUnknown))
+		})
+	})
+
+	t.Describe("IsImageNamePinned", func() {
+		It("should match the name against the pinned images", func() {
+			// Given
+			pinned, err := references.ParseRegistryImageReferenceFromOutOfProcessData("quay.io/crio/pause:latest")
+			Expect(err).ToNot(HaveOccurred())
+			other, err := references.ParseRegistryImageReferenceFromOutOfProcessData("docker.io/library/pause:latest")
+			Expect(err).ToNot(HaveOccurred())
+
+			// When
+			sut.UpdatePinnedImagesList([]string{"quay.io/crio/*"})
+
+			// Then
+			Expect(sut.IsImageNamePinned(pinned)).To(BeTrue())
+			Expect(sut.IsImageNamePinned(other)).To(BeFalse())
+		})
+	})
+
 	t.Describe("CompileRegexpsForPinnedImages", func() {
 		It("should return regexps for exact patterns", func() {
 			patterns := []string{"quay.io/crio/pause:latest", "docker.io/crio/sandbox:latest", "registry.k8s.io/pause:3.10"}
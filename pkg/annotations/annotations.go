@@ -51,6 +51,105 @@ const (
 	// example:  cpu-shared.crio.io/containerA
 	CPUSharedAnnotation = "cpu-shared.crio.io"
 
+	// CPUInitAffinityAnnotation indicates that the init process of a container requesting shared cpus
+	// should be pinned to the shared cpus, leaving the isolated cpus free until the application
+	// explicitly pins its threads to them.
+	// the container name should be appended at the end of the annotation
+	// example:  cpu-init-affinity.crio.io/containerA: "shared"
+	CPUInitAffinityAnnotation = "cpu-init-affinity.crio.io"
+
+	// PacketSteeringAnnotation steers the receive and transmit packet processing of the network interfaces
+	// of the pod, both the ones of the pod and their host side, to the CPUs of the container, "container",
+	// or to the housekeeping CPUs, "housekeeping", by programming their RPS and XPS CPU masks. Only one running
+	// container of a pod can steer its packets, the other ones get rejected.
+	// the container name should be appended at the end of the annotation
+	// example:  packet-steering.crio.io/containerA: "container"
+	PacketSteeringAnnotation = "packet-steering.crio.io"
+
+	// VFQueuesAnnotation sets the combined queue count of the SR-IOV VFs attached to the pod to the CPU count
+	// of the container, bounded by the queues the VFs support, like "ethtool -L combined" does.
+	// the container name should be appended at the end of the annotation
+	// example:  vf-queues.crio.io/containerA: "enable"
+	VFQueuesAnnotation = "vf-queues.crio.io"
+
+	// ARFSAnnotation enables accelerated RFS on the network interfaces of the pod, steering their flows to the
+	// CPUs the threads consuming them run on, by turning their ntuple filters on and programming the RFS flow
+	// count of their receive queues. The global flow table of RFS is held at a size of at least 32768 entries
+	// until the last container requesting it stops.
+	// the container name should be appended at the end of the annotation
+	// example:  arfs.crio.io/containerA: "enable"
+	ARFSAnnotation = "arfs.crio.io"
+
+	// VFIRQAffinityAnnotation aligns the queue IRQs and transmit queues of the SR-IOV VFs attached to the pod with
+	// the CPUs of the container, one CPU per queue, the CPUs of the NUMA node of the VF first, and bans the IRQs
+	// from irqbalance.
+	// the container name should be appended at the end of the annotation
+	// example:  vf-irq-affinity.crio.io/containerA: "enable"
+	VFIRQAffinityAnnotation = "vf-irq-affinity.crio.io"
+
+	// AFXDPAnnotation prepares the container for AF_XDP applications: it reserves the given count of queues of the
+	// network interfaces of the pod for their XDP sockets, by spreading the flows over the other queues only, sets
+	// the busy poll parameters of the interfaces. The container must be granted the CAP_NET_RAW, CAP_NET_ADMIN,
+	// CAP_BPF and CAP_IPC_LOCK capabilities by its security context, it gets rejected otherwise.
+	// the container name should be appended at the end of the annotation
+	// example:  af-xdp.crio.io/containerA: "2"
+	AFXDPAnnotation = "af-xdp.crio.io"
+
+	// NAPIAffinityAnnotation pins the NAPI threads of the network interfaces of the pod which poll in threads of
+	// their own, as threaded NAPI is enabled for them, to the CPUs of the container, "container", or to the
+	// housekeeping CPUs, "housekeeping". Only one running container of a pod can pin its NAPI threads, the other
+	// ones get rejected.
+	// the container name should be appended at the end of the annotation
+	// example:  napi-affinity.crio.io/containerA: "housekeeping"
+	NAPIAffinityAnnotation = "napi-affinity.crio.io"
+
+	// InterruptCoalescingAnnotation sets the interrupt coalescing of the devices backing the interfaces of the pod,
+	// for the lifetime of the pod, turning the adaptive coalescing off and delaying the receive and transmit
+	// interrupts by the given microseconds, like "ethtool -C" does.
+	// example:  interrupt-coalescing.crio.io: "rx-usecs=0,tx-usecs=0"
+	InterruptCoalescingAnnotation = "interrupt-coalescing.crio.io"
+
+	// QdiscAnnotation replaces the root qdisc of the devices backing the interfaces of the pod, the host side of
+	// its veth devices and its SR-IOV VFs, for the lifetime of the pod, by "noqueue", "mq" or "fq", instead of
+	// the default qdisc of the node, which may batch the packets.
+	// example:  qdisc.crio.io: "noqueue"
+	QdiscAnnotation = "qdisc.crio.io"
+
+	// NetdevBudgetAnnotation holds the netdev budget of the node, the packets and microseconds a softirq processes
+	// before yielding the CPU, for the lifetime of the pod. The budget is node-wide, so it is shared by all the pods
+	// requesting it, and restored once the last of them stops.
+	// example:  netdev-budget.crio.io: "budget=600,budget-usecs=4000"
+	NetdevBudgetAnnotation = "netdev-budget.crio.io"
+
+	// GROAnnotation turns the aggregation of the received packets, GRO and LRO, off on the devices backing
+	// the interfaces of the pod for the lifetime of the pod, as it delays the small messages.
+	// example:  gro.crio.io: "disable"
+	GROAnnotation = "gro.crio.io"
+
+	// NetNSSysctlBundleAnnotation selects the bundle of network namespace sysctls, defined in the
+	// netns_sysctl_bundles option of crio.conf, set in the network namespace of the pod on creation.
+	// example:  netns-sysctl-bundle.crio.io: "low-latency"
+	NetNSSysctlBundleAnnotation = "netns-sysctl-bundle.crio.io"
+
+	// TuningVerificationAnnotation delays the start of the containers of the pod until their tuning is verified
+	// to be effective, for at most the duration it is set to.
+	// example:  tuning-verification.crio.io: "10s"
+	TuningVerificationAnnotation = "tuning-verification.crio.io"
+
+	// DPDKAnnotation marks the container as running a DPDK application, whose start fails with all the problems
+	// found if its hugepages are not free on the NUMA node of its CPUs, its VFIO devices are not bound to vfio-pci
+	// in IOMMU groups on this node, or its CPUs are not isolated.
+	// the container name should be appended at the end of the annotation
+	// example:  dpdk.crio.io/containerA: "enable"
+	DPDKAnnotation = "dpdk.crio.io"
+
+	// TuningInterfacesAnnotation restricts the network tuning of the pod, like its packet steering, interrupt
+	// coalescing or VF IRQ affinity, to the interfaces it lists, comma separated. An entry is either the name
+	// of an interface, the name of a secondary network attached by Multus, or "default" for the interfaces
+	// of the default network of the pod.
+	// example:  tuning-interfaces.crio.io: "sriov-net,net3"
+	TuningInterfacesAnnotation = "tuning-interfaces.crio.io"
+
 	// SeccompNotifierActionAnnotation indicates a container is allowed to use the seccomp notifier feature.
 	SeccompNotifierActionAnnotation = "io.kubernetes.cri-o.seccompNotifierAction"
 
@@ -83,6 +182,12 @@ const (
 
 	// DisableFIPSAnnotation is used to disable FIPS mode for a pod within a FIPS-enabled Kubernetes cluster.
 	DisableFIPSAnnotation = "io.kubernetes.cri-o.DisableFIPS"
+
+	// OCIArtifactAnnotation pulls an OCI artifact, like a seccomp or tuning profile or a config bundle, through the
+	// registries of the images when the pod starts, for the runtime and the hooks, by the name suffixing the annotation.
+	// The artifact has to be referenced by digest.
+	// example:  oci-artifact.crio.io/tuning: "quay.io/example/profiles@sha256:<digest>"
+	OCIArtifactAnnotation = "oci-artifact.crio.io"
 )
 
 var AllAllowedAnnotations = []string{
@@ -105,8 +210,24 @@ var AllAllowedAnnotations = []string{
 	PodLinuxResources,
 	LinkLogsAnnotation,
 	CPUSharedAnnotation,
+	CPUInitAffinityAnnotation,
+	PacketSteeringAnnotation,
+	VFQueuesAnnotation,
+	ARFSAnnotation,
+	VFIRQAffinityAnnotation,
+	AFXDPAnnotation,
+	NAPIAffinityAnnotation,
+	InterruptCoalescingAnnotation,
+	QdiscAnnotation,
+	NetdevBudgetAnnotation,
+	GROAnnotation,
+	NetNSSysctlBundleAnnotation,
+	TuningVerificationAnnotation,
+	DPDKAnnotation,
+	TuningInterfacesAnnotation,
 	SeccompProfileAnnotation,
 	DisableFIPSAnnotation,
+	OCIArtifactAnnotation,
 	// Keep in sync with
 	// https://github.com/opencontainers/runc/blob/3db0871f1cf25c7025861ba0d51d25794cb21623/features.go#L67
 	// Once runc 1.2 is released, we can use the `runc features` command to get this programmatically,
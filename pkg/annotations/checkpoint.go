@@ -29,4 +29,19 @@ const (
 	// creating a checkpoint image to specify the version of CRIU used on the
 	// host where the checkpoint was created.
 	CheckpointAnnotationCriuVersion = "io.kubernetes.cri-o.annotations.checkpoint.criu.version"
+
+	// CheckpointAnnotationAnnotations is used by Container Checkpoint when
+	// creating a checkpoint image to specify the annotations of the container,
+	// as JSON.
+	CheckpointAnnotationAnnotations = "io.kubernetes.cri-o.annotations.checkpoint.annotations"
+
+	// CheckpointAnnotationTunings is used by Container Checkpoint when creating
+	// a checkpoint image to specify the tuning the runtime handler hooks applied
+	// to the container, as JSON.
+	CheckpointAnnotationTunings = "io.kubernetes.cri-o.annotations.checkpoint.tunings"
+
+	// CheckpointAnnotationMounts is used by Container Checkpoint when creating
+	// a checkpoint image to specify the external bind mounts of the container,
+	// as JSON.
+	CheckpointAnnotationMounts = "io.kubernetes.cri-o.annotations.checkpoint.mounts"
 )
@@ -74,6 +74,35 @@ const (
 	// HostnamePath is the path to /etc/hostname to bind mount annotation.
 	HostnamePath = "io.kubernetes.cri-o.HostnamePath"
 
+	// IsolatedCPUs holds the isolated CPUs granted to a container consuming the shared CPUs.
+	// Together with SharedCPUs, it lets NRI plugins and node-local agents like device plugins
+	// align their choices with the CPUs the container got at creation time.
+	IsolatedCPUs = "io.kubernetes.cri-o.IsolatedCPUs"
+
+	// SharedCPUs holds the shared CPUs granted to a container consuming the shared CPUs.
+	SharedCPUs = "io.kubernetes.cri-o.SharedCPUs"
+
+	// HighPerformance holds the high-performance annotations of a sandbox and the shared CPU pool
+	// of its runtime handler at its creation, to restore them after a restart.
+	HighPerformance = "io.kubernetes.cri-o.HighPerformance"
+
+	// OCIArtifacts holds the paths of the OCI artifacts pulled for a pod by name, as JSON, for the OCI hooks.
+	OCIArtifacts = "io.kubernetes.cri-o.OCIArtifacts"
+
+	// TuningState holds the outcome of the node tuning of a container by the high-performance hooks,
+	// reported in its status so that it shows up along with the container state.
+	TuningState = "io.kubernetes.cri-o.TuningState"
+
+	// TuningReason holds the machine-readable reason of a tuning which was not fully applied or reverted.
+	TuningReason = "io.kubernetes.cri-o.TuningReason"
+
+	// TuningMessage holds the failures of a tuning which was not fully applied or reverted.
+	TuningMessage = "io.kubernetes.cri-o.TuningMessage"
+
+	// TuningUnfulfilled holds the tuning annotations of a container which could not be honored,
+	// as comma-separated annotation=reason pairs.
+	TuningUnfulfilled = "io.kubernetes.cri-o.TuningUnfulfilled"
+
 	// SandboxID is the sandbox ID annotation.
 	SandboxID = "io.kubernetes.cri-o.SandboxID"
 
@@ -9,6 +9,7 @@ import (
 	"net"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"regexp"
 	"slices"
@@ -62,8 +63,10 @@ const (
 	RuntimeTypeVM                 = "vm"
 	RuntimeTypePod                = "pod"
 	defaultCtrStopTimeout         = 30 // seconds
+	defaultHooksTimeout           = time.Minute
 	defaultNamespacesDir          = "/var/run"
 	RuntimeTypeVMBinaryPattern    = "containerd-shim-([a-zA-Z0-9\\-\\+])+-v2"
+	envVarNamePattern             = "^[a-zA-Z_][a-zA-Z0-9_]*$"
 	tasksetBinary                 = "taskset"
 	MonitorExecCgroupDefault      = ""
 	MonitorExecCgroupContainer    = "container"
@@ -99,6 +102,51 @@ func (c *Config) GetData() *Config {
 	return c
 }
 
+const (
+	// RuntimeHandlerHooksHighPerformance binds the high-performance hooks to a runtime handler.
+	RuntimeHandlerHooksHighPerformance = "high-performance"
+	// RuntimeHandlerHooksDefault binds the default CPU load balancing hooks to a runtime handler.
+	RuntimeHandlerHooksDefault = "default"
+	// RuntimeHandlerHooksNone disables the built-in hooks for a runtime handler.
+	RuntimeHandlerHooksNone = "none"
+)
+
+const (
+	// RuntimeHandlerHooksLogFormatText logs the lines of the runtime handler hooks as text.
+	RuntimeHandlerHooksLogFormatText = "text"
+	// RuntimeHandlerHooksLogFormatJSON logs the lines of the runtime handler hooks as JSON objects.
+	RuntimeHandlerHooksLogFormatJSON = "json"
+)
+
+// Features of the high-performance hooks which can be configured to fail open.
+const (
+	HighPerformanceFeatureCPULoadBalancing    = "cpu-load-balancing"
+	HighPerformanceFeatureIRQLoadBalancing    = "irq-load-balancing"
+	HighPerformanceFeatureCPUQuota            = "cpu-quota"
+	HighPerformanceFeatureCPUCStates          = "cpu-c-states"
+	HighPerformanceFeatureCPUFreqGovernor     = "cpu-freq-governor"
+	HighPerformanceFeaturePacketSteering      = "packet-steering"
+	HighPerformanceFeatureVFQueues            = "vf-queues"
+	HighPerformanceFeatureARFS                = "arfs"
+	HighPerformanceFeatureVFIRQAffinity       = "vf-irq-affinity"
+	HighPerformanceFeatureAFXDP               = "af-xdp"
+	HighPerformanceFeatureNAPIAffinity        = "napi-affinity"
+	HighPerformanceFeatureInterruptCoalescing = "interrupt-coalescing"
+	HighPerformanceFeatureQdisc               = "qdisc"
+	HighPerformanceFeatureNetdevBudget        = "netdev-budget"
+	HighPerformanceFeatureGRO                 = "gro"
+)
+
+// Policies of the high-performance hooks when the active TuneD profile manages the tuning they apply.
+const (
+	// TunedConflictIgnore applies the tuning without looking for conflicts.
+	TunedConflictIgnore = "ignore"
+	// TunedConflictWarn logs the conflicts and applies the tuning anyway.
+	TunedConflictWarn = "warn"
+	// TunedConflictRefuse fails the CRI request instead of applying conflicting tuning.
+	TunedConflictRefuse = "refuse"
+)
+
 // ImageVolumesType describes image volume handling strategies.
 type ImageVolumesType string
 
@@ -132,6 +180,15 @@ const (
 	DefaultIrqBalanceConfigFile = "/etc/sysconfig/irqbalance"
 	// DefaultIrqBalanceConfigRestoreFile contains the banned cpu mask configuration to restore. Name due to backward compatibility.
 	DefaultIrqBalanceConfigRestoreFile = "/etc/sysconfig/orig_irq_banned_cpus"
+	// irqBalanceConfigRestoreDisable is the IrqBalanceConfigRestoreFile disabling the restoration.
+	irqBalanceConfigRestoreDisable = "disable"
+	// DefaultTuningStateDir is the default directory the runtime handler hooks record the node tuning to.
+	DefaultTuningStateDir = "/var/lib/crio/tuning"
+	// DefaultTuningAuditLogSizeMax is the default size in bytes after which the tuning audit log gets rotated.
+	DefaultTuningAuditLogSizeMax = 10 * 1024 * 1024
+	// DefaultTuningContainerStateDir is the default directory the runtime handler hooks write the tuning state
+	// file of every tuned container to.
+	DefaultTuningContainerStateDir = "/var/run/crio"
 )
 
 // This structure is necessary to fake the TOML tables when parsing,
@@ -272,6 +329,29 @@ type RuntimeHandler struct {
 	// Default annotations specified for runtime handler if they're not overridden by
 	// the pod spec.
 	DefaultAnnotations map[string]string `toml:"default_annotations,omitempty"`
+
+	// IsolatedCPUsEnvVar is the name of the environment variable holding the isolated CPUs,
+	// injected into containers requesting shared CPUs. If empty, "OPENSHIFT_ISOLATED_CPUS" is used.
+	IsolatedCPUsEnvVar string `toml:"isolated_cpus_env_var,omitempty"`
+
+	// SharedCPUsEnvVar is the name of the environment variable holding the shared CPUs,
+	// injected into containers requesting shared CPUs. If empty, "OPENSHIFT_SHARED_CPUS" is used.
+	SharedCPUsEnvVar string `toml:"shared_cpus_env_var,omitempty"`
+
+	// HooksPlugin is the path to the unix socket of an out-of-process plugin implementing
+	// runtime handler hooks, run in addition to the built-in hooks for this runtime handler.
+	HooksPlugin string `toml:"hooks_plugin,omitempty"`
+
+	// RuntimeHandlerHooks binds a set of built-in runtime handler hooks to this runtime handler.
+	// If empty, the hooks are chosen based on the runtime handler name and the pod annotations.
+	RuntimeHandlerHooks string `toml:"runtime_handler_hooks,omitempty"`
+
+	// IrqBalanceConfigFile overrides the global irqbalance service config file for
+	// the containers of this runtime handler.
+	IrqBalanceConfigFile string `toml:"irqbalance_config_file,omitempty"`
+
+	// SharedCPUSet overrides the global shared CPU set for the containers of this runtime handler.
+	SharedCPUSet string `toml:"shared_cpuset,omitempty"`
 }
 
 // Multiple runtime Handlers in a map.
@@ -444,6 +524,13 @@ type RuntimeConfig struct {
 	// that will be applied to containers.
 	Workloads Workloads `toml:"workloads"`
 
+	// TuningAnnotationPolicies restricts the Kubernetes namespaces and pods
+	// allowed to use each of the tuning annotations.
+	TuningAnnotationPolicies TuningAnnotationPolicies `toml:"tuning_annotation_policies"`
+
+	// HighPerformance gathers the settings of the high-performance hooks.
+	HighPerformance HighPerformanceConfig `toml:"high_performance"`
+
 	// PidsLimit is the number of processes each container is restricted to
 	// by the cgroup process number controller.
 	PidsLimit int64 `toml:"pids_limit"`
@@ -468,6 +555,67 @@ type RuntimeConfig struct {
 	// want access to shared cpus.
 	SharedCPUSet string `toml:"shared_cpuset"`
 
+	// HighPerformanceCPULoadBalancing enables the handling of the cpu-load-balancing
+	// annotation by the high-performance hooks.
+	HighPerformanceCPULoadBalancing bool `toml:"high_performance_cpu_load_balancing"`
+
+	// HighPerformanceIRQLoadBalancing enables the handling of the irq-load-balancing
+	// annotation by the high-performance hooks.
+	HighPerformanceIRQLoadBalancing bool `toml:"high_performance_irq_load_balancing"`
+
+	// HighPerformanceCPUQuota enables the handling of the cpu-quota annotation by the
+	// high-performance hooks.
+	HighPerformanceCPUQuota bool `toml:"high_performance_cpu_quota"`
+
+	// HighPerformanceCPUCStates enables the handling of the cpu-c-states annotation by
+	// the high-performance hooks.
+	HighPerformanceCPUCStates bool `toml:"high_performance_cpu_c_states"`
+
+	// HighPerformanceCPUFreqGovernor enables the handling of the cpu-freq-governor
+	// annotation by the high-performance hooks.
+	HighPerformanceCPUFreqGovernor bool `toml:"high_performance_cpu_freq_governor"`
+
+	// HighPerformanceSharedCPUs enables the handling of the cpu-shared annotation by the
+	// high-performance hooks.
+	HighPerformanceSharedCPUs bool `toml:"high_performance_shared_cpus"`
+
+	// HighPerformanceFailOpen lists the features of the high-performance hooks whose
+	// failures are logged instead of failing the CRI request.
+	HighPerformanceFailOpen []string `toml:"high_performance_fail_open"`
+
+	// HighPerformanceTunedConflict is the policy of the high-performance hooks when the active
+	// TuneD profile manages the same settings, either "ignore", "warn" or "refuse".
+	HighPerformanceTunedConflict string `toml:"high_performance_tuned_conflict"`
+
+	// HighPerformanceDryRun makes the high-performance hooks log and save the plan of the
+	// tuning of the containers on start instead of applying it.
+	HighPerformanceDryRun bool `toml:"high_performance_dry_run"`
+
+	// HighPerformanceReconcileOnReload makes the high-performance hooks reconcile the tuning
+	// of the running containers with the configuration reloaded on SIGHUP.
+	HighPerformanceReconcileOnReload bool `toml:"high_performance_reconcile_on_reload"`
+
+	// RuntimeHandlerHooksTimeout is the maximum time a runtime handler hook gets to run,
+	// 0 to disable the timeout.
+	RuntimeHandlerHooksTimeout time.Duration `toml:"runtime_handler_hooks_timeout"`
+
+	// RuntimeHandlerHooksLogFormat is the format of the log lines of the runtime handler hooks,
+	// "text" or "json", independently of the format of the rest of the logs. If empty, they are
+	// logged in the same format as the rest of the logs.
+	RuntimeHandlerHooksLogFormat string `toml:"runtime_handler_hooks_log_format"`
+
+	// TuningDriftCheckInterval is the interval at which the tuning of the running containers
+	// is compared with the node and repaired, 0 to disable the drift detection.
+	TuningDriftCheckInterval time.Duration `toml:"tuning_drift_check_interval"`
+
+	// TuningSchedstatInterval is the interval at which the scheduler statistics of the isolated CPUs
+	// are sampled into the run delay metrics, 0 to disable the sampling.
+	TuningSchedstatInterval time.Duration `toml:"tuning_schedstat_interval"`
+
+	// TuningTelemetryInterval is the interval at which the CPU telemetry of the containers tuned
+	// by the runtime handler hooks is sampled into the metrics, 0 to disable the sampling.
+	TuningTelemetryInterval time.Duration `toml:"tuning_telemetry_interval"`
+
 	// AbsentMountSourcesToReject is a list of paths that, when absent from the host,
 	// will cause a container creation to fail (as opposed to the current behavior of creating a directory).
 	AbsentMountSourcesToReject []string `toml:"absent_mount_sources_to_reject"`
@@ -479,6 +627,30 @@ type RuntimeConfig struct {
 	// If empty, no restoration attempt will be done.
 	IrqBalanceConfigRestoreFile string `toml:"irqbalance_config_restore_file"`
 
+	// TuningStateDir is the directory the runtime handler hooks record the tuning they applied
+	// to every container to, so that it can still be reverted after a crash or restart of CRI-O.
+	TuningStateDir string `toml:"tuning_state_dir"`
+
+	// TuningAuditLog is the file every write of the runtime handler hooks to the sysfs, procfs
+	// and cgroup files of the node is recorded to. If empty, the writes are not recorded.
+	TuningAuditLog string `toml:"tuning_audit_log"`
+
+	// TuningAuditLogSizeMax is the size in bytes after which the tuning audit log gets rotated,
+	// 0 to never rotate it.
+	TuningAuditLogSizeMax int64 `toml:"tuning_audit_log_size_max"`
+
+	// TuningLinuxAudit reports the privileged tuning operations of the runtime handler hooks,
+	// like the changes of the IRQ affinity and of the CPU frequency governor, to the Linux audit subsystem.
+	TuningLinuxAudit bool `toml:"tuning_linux_audit"`
+
+	// TuningTopologyFile is the file the runtime handler hooks write the CPU consumption of the tuned
+	// containers per NUMA zone to, for an exporter to the NodeResourceTopology API. If empty, it is not written.
+	TuningTopologyFile string `toml:"tuning_topology_file"`
+
+	// TuningContainerStateDir is the directory the runtime handler hooks write the tuning of every tuned
+	// container to, as <container-id>/tuning.json, for node-local agents. If empty, it is not written.
+	TuningContainerStateDir string `toml:"tuning_container_state_dir"`
+
 	// seccompConfig is the internal seccomp configuration
 	seccompConfig *seccomp.Config
 
@@ -537,13 +709,22 @@ type ImageConfig struct {
 	// /var/lib/kubelet/config.json containing credentials necessary
 	// for pulling PauseImage
 	PauseImageAuthFile string `toml:"pause_image_auth_file"`
+	// CredentialProviderConfig, if not empty, is a path to a kubelet
+	// credential provider config file, whose plugins provide the credentials
+	// for the images CRI-O pulls on its own, like the pause image, as the
+	// kubelet only provides credentials for the images it pulls.
+	CredentialProviderConfig string `toml:"credential_provider_config"`
+	// CredentialProviderBinDir is the directory of the kubelet credential
+	// provider plugins of CredentialProviderConfig.
+	CredentialProviderBinDir string `toml:"credential_provider_bin_dir"`
 	// PauseCommand is the path of the binary we run in an infra
 	// container that's been instantiated using PauseImage.
 	PauseCommand string `toml:"pause_command"`
 	// PinnedImages is a list of container images that should be pinned
 	// and not subject to garbage collection by kubelet.
 	// Pinned images will remain in the container runtime's storage until
-	// they are manually removed. Default value: empty list (no images pinned)
+	// they are unpinned and removed, CRI-O refusing to remove them while
+	// pinned. Default value: empty list (no images pinned)
 	PinnedImages []string `toml:"pinned_images"`
 	// SignaturePolicyPath is the name of the file which decides what sort
 	// of policy we use when deciding whether or not to trust an image that
@@ -559,6 +740,11 @@ type ImageConfig struct {
 	// SignaturePolicyPath or system wide policy will be used as fallback.
 	// Must be an absolute path.
 	SignaturePolicyDir string `toml:"signature_policy_dir"`
+	// SignaturePolicies selects the signature policies of the pods by their
+	// Kubernetes namespace, given as shell patterns like "prod-*", keyed by
+	// the absolute path of the policy. A namespace with a policy in
+	// SignaturePolicyDir uses that one instead.
+	SignaturePolicies map[string][]string `toml:"signature_policies,omitempty"`
 	// InsecureRegistries is a list of registries that must be contacted w/o
 	// TLS verification.
 	InsecureRegistries []string `toml:"insecure_registries"`
@@ -575,6 +761,23 @@ type ImageConfig struct {
 	// calculating the pull progress interval to pullProgressTimeout / 10.
 	// Can be set to 0 to disable the timeout as well as the progress output.
 	PullProgressTimeout time.Duration `toml:"pull_progress_timeout"`
+	// MaxParallelImagePulls is the maximum number of images pulled at the
+	// same time, the other pulls wait for one of them to complete.
+	// Can be set to 0 to not limit the image pulls.
+	MaxParallelImagePulls uint `toml:"max_parallel_image_pulls"`
+	// MaxParallelLayerDownloads is the maximum number of layers an image
+	// pull downloads at the same time. Can be set to 0 to use the default
+	// of the containers/image library.
+	MaxParallelLayerDownloads uint `toml:"max_parallel_layer_downloads"`
+	// RegistryMaxParallelLayerDownloads is the maximum number of layers
+	// downloaded at the same time from a registry, across all the image
+	// pulls from it, keyed by registry host name and optional port.
+	RegistryMaxParallelLayerDownloads map[string]uint `toml:"registry_max_parallel_layer_downloads,omitempty"`
+	// RegistryMirrorHealthCheckInterval is the interval at which the health
+	// of the registry mirrors is checked, the image pulls trying the healthy
+	// mirrors first. Can be set to 0 to disable the health checks, the image
+	// pulls trying the mirrors in the order of the registries configuration.
+	RegistryMirrorHealthCheckInterval time.Duration `toml:"registry_mirror_health_check_interval"`
 }
 
 // NetworkConfig represents the "crio.network" TOML config table.
@@ -917,38 +1120,49 @@ func DefaultConfig() (*Config, error) {
 			Runtimes: Runtimes{
 				DefaultRuntime: defaultRuntimeHandler(),
 			},
-			SELinux:                     selinuxEnabled(),
-			ApparmorProfile:             apparmor.DefaultProfile,
-			BlockIOConfigFile:           DefaultBlockIOConfigFile,
-			BlockIOReload:               DefaultBlockIOReload,
-			IrqBalanceConfigFile:        DefaultIrqBalanceConfigFile,
-			RdtConfigFile:               rdt.DefaultRdtConfigFile,
-			CgroupManagerName:           cgroupManager.Name(),
-			PidsLimit:                   DefaultPidsLimit,
-			ContainerExitsDir:           containerExitsDir,
-			ContainerAttachSocketDir:    conmonconfig.ContainerAttachSocketDir,
-			MinimumMappableUID:          -1,
-			MinimumMappableGID:          -1,
-			LogSizeMax:                  DefaultLogSizeMax,
-			CtrStopTimeout:              defaultCtrStopTimeout,
-			DefaultCapabilities:         capabilities.Default(),
-			LogLevel:                    "info",
-			HooksDir:                    []string{hooks.DefaultDir},
-			CDISpecDirs:                 cdi.DefaultSpecDirs,
-			NamespacesDir:               defaultNamespacesDir,
-			DropInfraCtr:                true,
-			IrqBalanceConfigRestoreFile: DefaultIrqBalanceConfigRestoreFile,
-			seccompConfig:               seccomp.New(),
-			apparmorConfig:              apparmor.New(),
-			blockioConfig:               blockio.New(),
-			cgroupManager:               cgroupManager,
-			deviceConfig:                device.New(),
-			namespaceManager:            nsmgr.New(defaultNamespacesDir, ""),
-			rdtConfig:                   rdt.New(),
-			ulimitsConfig:               ulimits.New(),
-			HostNetworkDisableSELinux:   true,
-			DisableHostPortMapping:      false,
-			EnableCriuSupport:           true,
+			SELinux:                         selinuxEnabled(),
+			ApparmorProfile:                 apparmor.DefaultProfile,
+			BlockIOConfigFile:               DefaultBlockIOConfigFile,
+			BlockIOReload:                   DefaultBlockIOReload,
+			IrqBalanceConfigFile:            DefaultIrqBalanceConfigFile,
+			RdtConfigFile:                   rdt.DefaultRdtConfigFile,
+			CgroupManagerName:               cgroupManager.Name(),
+			PidsLimit:                       DefaultPidsLimit,
+			ContainerExitsDir:               containerExitsDir,
+			ContainerAttachSocketDir:        conmonconfig.ContainerAttachSocketDir,
+			MinimumMappableUID:              -1,
+			MinimumMappableGID:              -1,
+			LogSizeMax:                      DefaultLogSizeMax,
+			CtrStopTimeout:                  defaultCtrStopTimeout,
+			DefaultCapabilities:             capabilities.Default(),
+			LogLevel:                        "info",
+			HooksDir:                        []string{hooks.DefaultDir},
+			CDISpecDirs:                     cdi.DefaultSpecDirs,
+			NamespacesDir:                   defaultNamespacesDir,
+			DropInfraCtr:                    true,
+			IrqBalanceConfigRestoreFile:     DefaultIrqBalanceConfigRestoreFile,
+			TuningStateDir:                  DefaultTuningStateDir,
+			TuningAuditLogSizeMax:           DefaultTuningAuditLogSizeMax,
+			TuningContainerStateDir:         DefaultTuningContainerStateDir,
+			HighPerformanceCPULoadBalancing: true,
+			HighPerformanceIRQLoadBalancing: true,
+			HighPerformanceCPUQuota:         true,
+			HighPerformanceCPUCStates:       true,
+			HighPerformanceCPUFreqGovernor:  true,
+			HighPerformanceSharedCPUs:       true,
+			HighPerformanceTunedConflict:    TunedConflictWarn,
+			RuntimeHandlerHooksTimeout:      defaultHooksTimeout,
+			seccompConfig:                   seccomp.New(),
+			apparmorConfig:                  apparmor.New(),
+			blockioConfig:                   blockio.New(),
+			cgroupManager:                   cgroupManager,
+			deviceConfig:                    device.New(),
+			namespaceManager:                nsmgr.New(defaultNamespacesDir, ""),
+			rdtConfig:                       rdt.New(),
+			ulimitsConfig:                   ulimits.New(),
+			HostNetworkDisableSELinux:       true,
+			DisableHostPortMapping:          false,
+			EnableCriuSupport:               true,
 		},
 		ImageConfig: ImageConfig{
 			DefaultTransport:    "docker://",
@@ -1174,6 +1388,33 @@ func (c *RuntimeConfig) Validate(systemContext *types.SystemContext, onExecution
 		return fmt.Errorf("workloads validation: %w", err)
 	}
 
+	if err := c.TuningAnnotationPolicies.Validate(); err != nil {
+		return fmt.Errorf("tuning annotation policies validation: %w", err)
+	}
+
+	if err := c.HighPerformance.Validate(); err != nil {
+		return fmt.Errorf("high_performance validation: %w", err)
+	}
+
+	if err := c.ValidateHighPerformanceFailOpen(); err != nil {
+		return err
+	}
+
+	if err := c.ValidateHighPerformanceTunedConflict(); err != nil {
+		return err
+	}
+
+	switch c.RuntimeHandlerHooksLogFormat {
+	case "", RuntimeHandlerHooksLogFormatText, RuntimeHandlerHooksLogFormatJSON:
+	default:
+		return fmt.Errorf("runtime_handler_hooks_log_format must be %q or %q, got %q",
+			RuntimeHandlerHooksLogFormatText, RuntimeHandlerHooksLogFormatJSON, c.RuntimeHandlerHooksLogFormat)
+	}
+
+	if c.TuningAuditLogSizeMax < 0 {
+		return fmt.Errorf("tuning_audit_log_size_max must not be negative, got %d", c.TuningAuditLogSizeMax)
+	}
+
 	// check for validation on execution
 	if onExecution {
 		// First, configure cgroup manager so the values of the Runtime.MonitorCgroup can be validated
@@ -1272,6 +1513,32 @@ func (c *RuntimeConfig) Validate(systemContext *types.SystemContext, onExecution
 	return nil
 }
 
+// ValidateHighPerformanceTunedConflict checks if the TuneD conflict policy is known.
+func (c *RuntimeConfig) ValidateHighPerformanceTunedConflict() error {
+	switch c.HighPerformanceTunedConflict {
+	case TunedConflictIgnore, TunedConflictWarn, TunedConflictRefuse:
+		return nil
+	default:
+		return fmt.Errorf("invalid high_performance_tuned_conflict %q", c.HighPerformanceTunedConflict)
+	}
+}
+
+// ValidateHighPerformanceFailOpen checks if the features configured to fail open are known.
+func (c *RuntimeConfig) ValidateHighPerformanceFailOpen() error {
+	for _, feature := range c.HighPerformanceFailOpen {
+		if !failOpenFeature(feature) {
+			return fmt.Errorf("invalid high_performance_fail_open feature %q", feature)
+		}
+	}
+	return nil
+}
+
+// IrqBalanceConfigRestoreEnabled returns whether the irqbalance banned CPU list gets restored.
+func (c *RuntimeConfig) IrqBalanceConfigRestoreEnabled() bool {
+	h := c.HighPerformanceSettings()
+	return h.IrqBalanceConfigRestoreEnabled()
+}
+
 // ValidateDefaultRuntime ensures that the default runtime is set and valid.
 func (c *RuntimeConfig) ValidateDefaultRuntime() error {
 	// If the default runtime is defined in the runtime entry table, then it is valid
@@ -1313,6 +1580,20 @@ func defaultRuntimeHandler() *RuntimeHandler {
 	}
 }
 
+// RuntimeHandlerOrDefault returns the configuration of the runtime handler, or of the default runtime
+// if the handler is empty. It returns nil for unknown runtime handlers.
+func (c *RuntimeConfig) RuntimeHandlerOrDefault(handler string) *RuntimeHandler {
+	if handler == "" {
+		handler = c.DefaultRuntime
+	}
+	return c.Runtimes[handler]
+}
+
+// SharedCPUSetForRuntimeHandler returns the shared CPU set used by the containers of the runtime handler.
+func (c *RuntimeConfig) SharedCPUSetForRuntimeHandler(handler string) string {
+	return c.HighPerformanceSettingsFor(handler).SharedCPUSet
+}
+
 // ValidateRuntimes checks every runtime if its members are valid.
 func (c *RuntimeConfig) ValidateRuntimes() error {
 	var failedValidation []string
@@ -1551,6 +1832,42 @@ func (c *ImageConfig) Validate(onExecution bool) error {
 	if _, err := c.ParsePauseImage(); err != nil {
 		return fmt.Errorf("invalid pause image %q: %w", c.PauseImage, err)
 	}
+	if err := validatePinnedImages(c.PinnedImages); err != nil {
+		return err
+	}
+	for policyPath, namespaces := range c.SignaturePolicies {
+		if !filepath.IsAbs(policyPath) {
+			return fmt.Errorf("signature policy %q is not absolute", policyPath)
+		}
+		for _, namespace := range namespaces {
+			if _, err := path.Match(namespace, ""); err != nil {
+				return fmt.Errorf("invalid namespace pattern %q for signature policy %q: %w", namespace, policyPath, err)
+			}
+		}
+		if onExecution {
+			if _, err := os.Stat(policyPath); err != nil {
+				return fmt.Errorf("signature policy of namespaces %s: %w", strings.Join(namespaces, ", "), err)
+			}
+		}
+	}
+	if c.CredentialProviderConfig != "" {
+		if !filepath.IsAbs(c.CredentialProviderConfig) || !filepath.IsAbs(c.CredentialProviderBinDir) {
+			return fmt.Errorf("credential provider config %q and bin dir %q must be absolute", c.CredentialProviderConfig, c.CredentialProviderBinDir)
+		}
+		if onExecution {
+			if _, err := os.Stat(c.CredentialProviderConfig); err != nil {
+				return fmt.Errorf("credential provider config: %w", err)
+			}
+		}
+	}
+	for registry, limit := range c.RegistryMaxParallelLayerDownloads {
+		if registry == "" || strings.Contains(registry, "/") {
+			return fmt.Errorf("invalid registry %q in registry_max_parallel_layer_downloads, expected a host name with an optional port", registry)
+		}
+		if limit == 0 {
+			return fmt.Errorf("registry_max_parallel_layer_downloads of registry %q must be positive", registry)
+		}
+	}
 	if onExecution {
 		if err := os.MkdirAll(c.SignaturePolicyDir, 0o755); err != nil {
 			return fmt.Errorf("cannot create signature policy dir: %w", err)
@@ -1559,6 +1876,51 @@ func (c *ImageConfig) Validate(onExecution bool) error {
 	return nil
 }
 
+// SignaturePolicyPathForNamespace returns the signature policy of the pods of
+// the namespace, or "" for the default one. The policy of the namespace in
+// SignaturePolicyDir takes precedence over the SignaturePolicies matching it.
+// A namespace matching the patterns of several SignaturePolicies is an error,
+// rather than getting whichever of them, which might be the least strict.
+func (c *ImageConfig) SignaturePolicyPathForNamespace(namespace string) (string, error) {
+	if namespace == "" {
+		return "", nil
+	}
+	policyPath := filepath.Join(c.SignaturePolicyDir, namespace+".json")
+	if _, err := os.Stat(policyPath); err == nil {
+		return policyPath, nil
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("read policy path %s: %w", policyPath, err)
+	}
+	var matched []string
+	for policy, namespaces := range c.SignaturePolicies {
+		if slices.ContainsFunc(namespaces, func(pattern string) bool {
+			ok, _ := path.Match(pattern, namespace)
+			return ok
+		}) {
+			matched = append(matched, policy)
+		}
+	}
+	switch len(matched) {
+	case 0:
+		return "", nil
+	case 1:
+		return matched[0], nil
+	}
+	slices.Sort(matched)
+	return "", fmt.Errorf("namespace %q matches several signature policies: %s", namespace, strings.Join(matched, ", "))
+}
+
+// validatePinnedImages validates the patterns of the pinned images, a lone
+// wildcard being neither a keyword nor a glob pattern.
+func validatePinnedImages(patterns []string) error {
+	for _, pattern := range patterns {
+		if pattern == "*" {
+			return fmt.Errorf("invalid pinned image pattern %q, expected an exact, glob or keyword pattern", pattern)
+		}
+	}
+	return nil
+}
+
 // ParsePauseImage parses the .PauseImage value as into a validated, well-typed value.
 func (c *ImageConfig) ParsePauseImage() (references.RegistryImageReference, error) {
 	return references.ParseRegistryImageReferenceFromOutOfProcessData(c.PauseImage)
@@ -1631,6 +1993,15 @@ func (r *RuntimeHandler) Validate(name string) error {
 	if err := r.ValidateContainerMinMemory(name); err != nil {
 		logrus.Errorf("Unable to set minimum container memory for runtime handler %q: %v", name, err)
 	}
+	if err := r.ValidateCPUsEnvVars(name); err != nil {
+		return err
+	}
+	if r.HooksPlugin != "" && !filepath.IsAbs(r.HooksPlugin) {
+		return fmt.Errorf("hooks_plugin %q for runtime %q must be an absolute path", r.HooksPlugin, name)
+	}
+	if err := r.ValidateRuntimeHandlerHooks(name); err != nil {
+		return err
+	}
 
 	return r.ValidateNoSyncLog()
 }
@@ -1726,6 +2097,41 @@ func (r *RuntimeHandler) ValidateNoSyncLog() error {
 	return fmt.Errorf("no_sync_log is only allowed with runtime type 'oci', runtime type is '%s'", r.RuntimeType)
 }
 
+// ValidateRuntimeHandlerHooks checks if the `RuntimeHandlerHooks` and its parameters are valid.
+func (r *RuntimeHandler) ValidateRuntimeHandlerHooks(name string) error {
+	switch r.RuntimeHandlerHooks {
+	case "", RuntimeHandlerHooksHighPerformance, RuntimeHandlerHooksDefault, RuntimeHandlerHooksNone:
+	default:
+		return fmt.Errorf("invalid runtime_handler_hooks %q for runtime %q", r.RuntimeHandlerHooks, name)
+	}
+	if r.SharedCPUSet != "" {
+		if _, err := cpuset.Parse(r.SharedCPUSet); err != nil {
+			return fmt.Errorf("invalid shared_cpuset %q for runtime %q: %w", r.SharedCPUSet, name, err)
+		}
+	}
+	return nil
+}
+
+// ValidateCPUsEnvVars checks if the `IsolatedCPUsEnvVar` and `SharedCPUsEnvVar` are valid
+// environment variable names.
+func (r *RuntimeHandler) ValidateCPUsEnvVars(name string) error {
+	for option, envVar := range map[string]string{
+		"isolated_cpus_env_var": r.IsolatedCPUsEnvVar,
+		"shared_cpus_env_var":   r.SharedCPUsEnvVar,
+	} {
+		if envVar == "" {
+			continue
+		}
+		if matched, err := regexp.MatchString(envVarNamePattern, envVar); err != nil || !matched {
+			return fmt.Errorf("invalid %s %q for runtime %q", option, envVar, name)
+		}
+	}
+	if r.IsolatedCPUsEnvVar != "" && r.IsolatedCPUsEnvVar == r.SharedCPUsEnvVar {
+		return fmt.Errorf("isolated_cpus_env_var and shared_cpus_env_var must differ for runtime %q", name)
+	}
+	return nil
+}
+
 // ValidateContainerMinMemory sets the minimum container memory for a given runtime.
 // assigns defaultContainerMinMemory if no container_min_memory provided.
 func (r *RuntimeHandler) ValidateContainerMinMemory(name string) error {
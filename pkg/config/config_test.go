@@ -255,6 +255,44 @@ var _ = t.Describe("Config", func() {
 			Expect(err).ToNot(HaveOccurred())
 		})
 
+		It("should succeed with known high-performance fail open features", func() {
+			// Given
+			sut.HighPerformanceFailOpen = []string{
+				config.HighPerformanceFeatureCPUFreqGovernor,
+				config.HighPerformanceFeatureCPUCStates,
+				config.HighPerformanceFeaturePacketSteering,
+				config.HighPerformanceFeatureNAPIAffinity,
+			}
+
+			// When
+			err := sut.RuntimeConfig.Validate(nil, false)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should fail with unknown high-performance fail open features", func() {
+			// Given
+			sut.HighPerformanceFailOpen = []string{"shared-cpus"}
+
+			// When
+			err := sut.RuntimeConfig.Validate(nil, false)
+
+			// Then
+			Expect(err).To(MatchError(`invalid high_performance_fail_open feature "shared-cpus"`))
+		})
+
+		It("should fail with an unknown TuneD conflict policy", func() {
+			// Given
+			sut.HighPerformanceTunedConflict = "fail"
+
+			// When
+			err := sut.RuntimeConfig.Validate(nil, false)
+
+			// Then
+			Expect(err).To(MatchError(`invalid high_performance_tuned_conflict "fail"`))
+		})
+
 		It("should succeed with additional devices", func() {
 			// Given
 			sut = runtimeValidConfig()
@@ -763,6 +801,64 @@ var _ = t.Describe("Config", func() {
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(MatchError("no_sync_log is only allowed with runtime type 'oci', runtime type is 'vm'"))
 		})
+
+		It("should allow custom cpus env var names", func() {
+			handler := &config.RuntimeHandler{
+				IsolatedCPUsEnvVar: "ISOLATED_CPUS",
+				SharedCPUsEnvVar:   "SHARED_CPUS",
+			}
+
+			err := handler.ValidateCPUsEnvVars("runc")
+
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should fail with invalid cpus env var name", func() {
+			handler := &config.RuntimeHandler{SharedCPUsEnvVar: "SHARED-CPUS"}
+
+			err := handler.ValidateCPUsEnvVars("runc")
+
+			Expect(err).To(MatchError(`invalid shared_cpus_env_var "SHARED-CPUS" for runtime "runc"`))
+		})
+
+		It("should fail with invalid runtime handler hooks", func() {
+			handler := &config.RuntimeHandler{RuntimeHandlerHooks: "fast"}
+
+			err := handler.ValidateRuntimeHandlerHooks("runc")
+
+			Expect(err).To(MatchError(`invalid runtime_handler_hooks "fast" for runtime "runc"`))
+		})
+
+		It("should fail with invalid runtime handler shared cpuset", func() {
+			handler := &config.RuntimeHandler{
+				RuntimeHandlerHooks: config.RuntimeHandlerHooksHighPerformance,
+				SharedCPUSet:        "a-b",
+			}
+
+			err := handler.ValidateRuntimeHandlerHooks("runc")
+
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should fall back to the global shared cpuset", func() {
+			sut.SharedCPUSet = "0-1"
+			sut.Runtimes["tuned"] = &config.RuntimeHandler{SharedCPUSet: "2-3"}
+
+			Expect(sut.SharedCPUSetForRuntimeHandler("tuned")).To(Equal("2-3"))
+			Expect(sut.SharedCPUSetForRuntimeHandler("")).To(Equal("0-1"))
+			Expect(sut.SharedCPUSetForRuntimeHandler("unknown")).To(Equal("0-1"))
+		})
+
+		It("should fail with identical cpus env var names", func() {
+			handler := &config.RuntimeHandler{
+				IsolatedCPUsEnvVar: "CPUS",
+				SharedCPUsEnvVar:   "CPUS",
+			}
+
+			err := handler.ValidateCPUsEnvVars("runc")
+
+			Expect(err).To(HaveOccurred())
+		})
 	})
 
 	t.Describe("ValidateConmonPath", func() {
@@ -855,6 +951,151 @@ var _ = t.Describe("Config", func() {
 			// Then
 			Expect(err).To(HaveOccurred())
 		})
+
+		It("should succeed with registry parallel layer downloads", func() {
+			// Given
+			sut.ImageConfig.RegistryMaxParallelLayerDownloads = map[string]uint{"registry.example.com:5000": 8}
+
+			// When
+			err := sut.ImageConfig.Validate(false)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should fail when the registry parallel layer downloads are zero", func() {
+			// Given
+			sut.ImageConfig.RegistryMaxParallelLayerDownloads = map[string]uint{"quay.io": 0}
+
+			// When
+			err := sut.ImageConfig.Validate(false)
+
+			// Then
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should fail when the registry of the parallel layer downloads is a repository", func() {
+			// Given
+			sut.ImageConfig.RegistryMaxParallelLayerDownloads = map[string]uint{"quay.io/crio": 4}
+
+			// When
+			err := sut.ImageConfig.Validate(false)
+
+			// Then
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should fail when the credential provider bin dir is not set", func() {
+			// Given
+			sut.ImageConfig.CredentialProviderConfig = "/etc/crio/credential-providers.yaml"
+
+			// When
+			err := sut.ImageConfig.Validate(false)
+
+			// Then
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should fail when the credential provider config does not exist", func() {
+			// Given
+			sut.ImageConfig.CredentialProviderConfig = "/not-existing/credential-providers.yaml"
+			sut.ImageConfig.CredentialProviderBinDir = "/usr/libexec/kubernetes/kubelet-plugins/credential-provider"
+
+			// When
+			err := sut.ImageConfig.Validate(true)
+
+			// Then
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should fail when a signature policy is not absolute", func() {
+			// Given
+			sut.ImageConfig.SignaturePolicies = map[string][]string{"policies/production.json": {"prod-*"}}
+
+			// When
+			err := sut.ImageConfig.Validate(false)
+
+			// Then
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should fail on execution when a signature policy does not exist", func() {
+			// Given
+			sut.ImageConfig.SignaturePolicyDir = os.TempDir()
+			sut.ImageConfig.SignaturePolicies = map[string][]string{"/not/existing/production.json": {"prod-*"}}
+
+			// When
+			err := sut.ImageConfig.Validate(true)
+
+			// Then
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should fail when a pinned image pattern is a lone wildcard", func() {
+			// Given
+			sut.ImageConfig.PinnedImages = []string{"quay.io/crio/*", "*"}
+
+			// When
+			err := sut.ImageConfig.Validate(false)
+
+			// Then
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	t.Describe("ImageConfig.SignaturePolicyPathForNamespace", func() {
+		var policyDir string
+
+		BeforeEach(func() {
+			policyDir = t.MustTempDir("policies")
+			sut.ImageConfig.SignaturePolicyDir = policyDir
+			sut.ImageConfig.SignaturePolicies = map[string][]string{
+				"/etc/crio/policies/production.json":  {"prod-*", "payments"},
+				"/etc/crio/policies/development.json": {"dev-*"},
+			}
+		})
+
+		It("should select the signature policy matching the namespace", func() {
+			// When
+			policyPath, err := sut.ImageConfig.SignaturePolicyPathForNamespace("prod-eu")
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(policyPath).To(Equal("/etc/crio/policies/production.json"))
+		})
+
+		It("should use the default signature policy without matching namespace", func() {
+			// When
+			policyPath, err := sut.ImageConfig.SignaturePolicyPathForNamespace("staging")
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(policyPath).To(BeEmpty())
+		})
+
+		It("should prefer the signature policy of the namespace in the policy dir", func() {
+			// Given
+			namespacePolicy := filepath.Join(policyDir, "payments.json")
+			Expect(os.WriteFile(namespacePolicy, []byte("{}"), 0o644)).To(Succeed())
+
+			// When
+			policyPath, err := sut.ImageConfig.SignaturePolicyPathForNamespace("payments")
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(policyPath).To(Equal(namespacePolicy))
+		})
+
+		It("should fail when the namespace matches several signature policies", func() {
+			// Given
+			sut.ImageConfig.SignaturePolicies["/etc/crio/policies/eu.json"] = []string{"*-eu"}
+
+			// When
+			_, err := sut.ImageConfig.SignaturePolicyPathForNamespace("prod-eu")
+
+			// Then
+			Expect(err).To(HaveOccurred())
+		})
 	})
 
 	t.Describe("ImageConfig.ParsePauseImage", func() {
@@ -0,0 +1,270 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"k8s.io/utils/cpuset"
+)
+
+// HighPerformanceFeatureSharedCPUs is the feature of the high-performance hooks granting the shared CPUs,
+// which can be disabled but not configured to fail open.
+const HighPerformanceFeatureSharedCPUs = "shared-cpus"
+
+// Policies of the high-performance hooks for the containers of the runtime handlers of a runtime type.
+const (
+	// RuntimeTypeTuningTune tunes the node for the containers.
+	RuntimeTypeTuningTune = "tune"
+	// RuntimeTypeTuningDelegate passes the tuning requested by the pods to the runtime, which applies
+	// it to the VM running them. It is only supported by the runtime handlers of the "vm" type.
+	RuntimeTypeTuningDelegate = "delegate"
+	// RuntimeTypeTuningSkip ignores the tuning requested by the pods, reporting it in the status of their containers.
+	RuntimeTypeTuningSkip = "skip"
+)
+
+// runtimeTypeOCI is the runtime type of the runtime handlers without runtime_type.
+const runtimeTypeOCI = "oci"
+
+// highPerformanceFeatures are the features of the high-performance hooks which can be disabled.
+var highPerformanceFeatures = []string{
+	HighPerformanceFeatureCPULoadBalancing,
+	HighPerformanceFeatureIRQLoadBalancing,
+	HighPerformanceFeatureCPUQuota,
+	HighPerformanceFeatureCPUCStates,
+	HighPerformanceFeatureCPUFreqGovernor,
+	HighPerformanceFeatureSharedCPUs,
+	HighPerformanceFeaturePacketSteering,
+	HighPerformanceFeatureVFQueues,
+	HighPerformanceFeatureARFS,
+	HighPerformanceFeatureVFIRQAffinity,
+	HighPerformanceFeatureAFXDP,
+	HighPerformanceFeatureNAPIAffinity,
+	HighPerformanceFeatureInterruptCoalescing,
+	HighPerformanceFeatureQdisc,
+	HighPerformanceFeatureNetdevBudget,
+	HighPerformanceFeatureGRO,
+}
+
+// failOpenFeature returns whether the failures of the feature can be logged instead of failing the CRI request,
+// which all the features support but the shared CPUs, as they are advertised to the container on creation.
+func failOpenFeature(feature string) bool {
+	return feature != HighPerformanceFeatureSharedCPUs && slices.Contains(highPerformanceFeatures, feature)
+}
+
+// HighPerformanceConfig is the [crio.runtime.high_performance] table, gathering the settings of the
+// high-performance hooks. Its unset options fall back to the former options of [crio.runtime], like
+// shared_cpuset or high_performance_fail_open, which are still supported.
+type HighPerformanceConfig struct {
+	// SharedCPUSet is the pool of CPUs granted to the guaranteed containers requesting shared CPUs.
+	SharedCPUSet string `toml:"shared_cpuset,omitempty"`
+
+	// HousekeepingCPUs are the CPUs the packet steering steers the packets of the containers to with the
+	// "housekeeping" policy, the CPUs left by the tuned containers if empty. Only the packet steering reads it.
+	HousekeepingCPUs string `toml:"housekeeping_cpus,omitempty"`
+
+	// IrqBalanceConfigFile is the irqbalance service config file the CPUs excluded from the IRQ
+	// load balancing are banned in.
+	IrqBalanceConfigFile string `toml:"irqbalance_config_file,omitempty"`
+
+	// IrqBalanceConfigRestoreFile is the irqbalance service banned CPU list to restore on startup,
+	// "disable" to not restore it.
+	IrqBalanceConfigRestoreFile string `toml:"irqbalance_config_restore_file,omitempty"`
+
+	// DisabledFeatures are the features of the high-performance hooks whose annotations are ignored.
+	DisabledFeatures []string `toml:"disabled_features,omitempty"`
+
+	// FailOpen are the features whose failures are logged instead of failing the CRI request.
+	FailOpen []string `toml:"fail_open,omitempty"`
+
+	// TunedConflict is the policy when the active TuneD profile manages the same settings,
+	// either "ignore", "warn" or "refuse".
+	TunedConflict string `toml:"tuned_conflict,omitempty"`
+
+	// DryRun makes the hooks log and save the plan of the tuning of the containers on start
+	// instead of applying it.
+	DryRun bool `toml:"dry_run,omitempty"`
+
+	// StateDir is the directory the hooks record the tuning they applied to every container to.
+	StateDir string `toml:"state_dir,omitempty"`
+
+	// RuntimeTypePolicies are the policies of the hooks keyed by the runtime type of the runtime handlers,
+	// "oci", "vm" or "pod". The handlers of the "vm" type delegate the tuning to the runtime by default,
+	// the others tune the node.
+	RuntimeTypePolicies map[string]string `toml:"runtime_type_policies,omitempty"`
+
+	// NetNSSysctlBundles are the bundles of network namespace sysctls, like net.core.busy_poll, keyed by name,
+	// which the pods select with the netns-sysctl-bundle.crio.io annotation. The pods can only select these.
+	NetNSSysctlBundles map[string]map[string]string `toml:"netns_sysctl_bundles,omitempty"`
+}
+
+// Validate checks the options set in the table.
+func (h *HighPerformanceConfig) Validate() error {
+	if _, err := cpuset.Parse(h.SharedCPUSet); err != nil {
+		return fmt.Errorf("invalid shared_cpuset %q: %w", h.SharedCPUSet, err)
+	}
+	if _, err := cpuset.Parse(h.HousekeepingCPUs); err != nil {
+		return fmt.Errorf("invalid housekeeping_cpus %q: %w", h.HousekeepingCPUs, err)
+	}
+	if h.IrqBalanceConfigFile != "" && !filepath.IsAbs(h.IrqBalanceConfigFile) {
+		return fmt.Errorf("irqbalance_config_file %q is not an absolute path", h.IrqBalanceConfigFile)
+	}
+	if h.IrqBalanceConfigRestoreFile != "" && h.IrqBalanceConfigRestoreEnabled() && !filepath.IsAbs(h.IrqBalanceConfigRestoreFile) {
+		return fmt.Errorf("irqbalance_config_restore_file %q is not an absolute path", h.IrqBalanceConfigRestoreFile)
+	}
+	if h.StateDir != "" && !filepath.IsAbs(h.StateDir) {
+		return fmt.Errorf("state_dir %q is not an absolute path", h.StateDir)
+	}
+	for _, feature := range h.DisabledFeatures {
+		if !slices.Contains(highPerformanceFeatures, feature) {
+			return fmt.Errorf("invalid disabled_features feature %q, expected one of %s", feature, strings.Join(highPerformanceFeatures, ", "))
+		}
+	}
+	for _, feature := range h.FailOpen {
+		if !failOpenFeature(feature) {
+			return fmt.Errorf("invalid fail_open feature %q", feature)
+		}
+	}
+	switch h.TunedConflict {
+	case "", TunedConflictIgnore, TunedConflictWarn, TunedConflictRefuse:
+	default:
+		return fmt.Errorf("invalid tuned_conflict %q", h.TunedConflict)
+	}
+	for _, runtimeType := range slices.Sorted(maps.Keys(h.RuntimeTypePolicies)) {
+		policy := h.RuntimeTypePolicies[runtimeType]
+		switch runtimeType {
+		case runtimeTypeOCI, RuntimeTypeVM, RuntimeTypePod:
+		default:
+			return fmt.Errorf("invalid runtime_type_policies runtime type %q", runtimeType)
+		}
+		switch policy {
+		case RuntimeTypeTuningTune, RuntimeTypeTuningSkip:
+		case RuntimeTypeTuningDelegate:
+			if runtimeType != RuntimeTypeVM {
+				return fmt.Errorf("runtime_type_policies policy %q is only supported by the %q runtime type", policy, RuntimeTypeVM)
+			}
+		default:
+			return fmt.Errorf("invalid runtime_type_policies policy %q for runtime type %q", policy, runtimeType)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(h.NetNSSysctlBundles)) {
+		if name == "" {
+			return errors.New("invalid netns_sysctl_bundles bundle without name")
+		}
+		for key, value := range h.NetNSSysctlBundles[name] {
+			if !strings.HasPrefix(key, string(NetNamespace)+".") {
+				return fmt.Errorf("netns_sysctl_bundles bundle %q sysctl %q is not a network namespace sysctl", name, key)
+			}
+			if value == "" || strings.TrimSpace(value) != value {
+				return fmt.Errorf("invalid netns_sysctl_bundles bundle %q sysctl %q value %q", name, key, value)
+			}
+		}
+	}
+	return nil
+}
+
+// FeatureEnabled returns whether the feature of the high-performance hooks is enabled.
+func (h *HighPerformanceConfig) FeatureEnabled(feature string) bool {
+	return !slices.Contains(h.DisabledFeatures, feature)
+}
+
+// RuntimeTypePolicy returns the policy of the hooks for the runtime handlers of the runtime type,
+// an empty one being the "oci" type.
+func (h *HighPerformanceConfig) RuntimeTypePolicy(runtimeType string) string {
+	if runtimeType == "" {
+		runtimeType = runtimeTypeOCI
+	}
+	if policy, ok := h.RuntimeTypePolicies[runtimeType]; ok {
+		return policy
+	}
+	if runtimeType == RuntimeTypeVM {
+		return RuntimeTypeTuningDelegate
+	}
+	return RuntimeTypeTuningTune
+}
+
+// IrqBalanceConfigRestoreEnabled returns whether the irqbalance banned CPU list gets restored.
+func (h *HighPerformanceConfig) IrqBalanceConfigRestoreEnabled() bool {
+	return strings.ToLower(strings.TrimSpace(h.IrqBalanceConfigRestoreFile)) != irqBalanceConfigRestoreDisable
+}
+
+// HighPerformanceSettings returns the settings of the high-performance hooks, that is the
+// [crio.runtime.high_performance] table completed with the former options of [crio.runtime].
+func (c *RuntimeConfig) HighPerformanceSettings() HighPerformanceConfig {
+	h := c.HighPerformance
+	h.DisabledFeatures = slices.Clone(h.DisabledFeatures)
+	h.FailOpen = slices.Clone(h.FailOpen)
+	h.RuntimeTypePolicies = maps.Clone(h.RuntimeTypePolicies)
+	if h.NetNSSysctlBundles != nil {
+		bundles := make(map[string]map[string]string, len(h.NetNSSysctlBundles))
+		for name, sysctls := range h.NetNSSysctlBundles {
+			bundles[name] = maps.Clone(sysctls)
+		}
+		h.NetNSSysctlBundles = bundles
+	}
+
+	if h.SharedCPUSet == "" {
+		h.SharedCPUSet = c.SharedCPUSet
+	}
+	if h.IrqBalanceConfigFile == "" {
+		h.IrqBalanceConfigFile = c.IrqBalanceConfigFile
+	}
+	if h.IrqBalanceConfigRestoreFile == "" {
+		h.IrqBalanceConfigRestoreFile = c.IrqBalanceConfigRestoreFile
+	}
+	if h.TunedConflict == "" {
+		h.TunedConflict = c.HighPerformanceTunedConflict
+	}
+	if h.StateDir == "" {
+		h.StateDir = c.TuningStateDir
+	}
+	h.DryRun = h.DryRun || c.HighPerformanceDryRun
+
+	for feature, enabled := range map[string]bool{
+		HighPerformanceFeatureCPULoadBalancing: c.HighPerformanceCPULoadBalancing,
+		HighPerformanceFeatureIRQLoadBalancing: c.HighPerformanceIRQLoadBalancing,
+		HighPerformanceFeatureCPUQuota:         c.HighPerformanceCPUQuota,
+		HighPerformanceFeatureCPUCStates:       c.HighPerformanceCPUCStates,
+		HighPerformanceFeatureCPUFreqGovernor:  c.HighPerformanceCPUFreqGovernor,
+		HighPerformanceFeatureSharedCPUs:       c.HighPerformanceSharedCPUs,
+	} {
+		if !enabled && !slices.Contains(h.DisabledFeatures, feature) {
+			h.DisabledFeatures = append(h.DisabledFeatures, feature)
+		}
+	}
+	slices.Sort(h.DisabledFeatures)
+	for _, feature := range c.HighPerformanceFailOpen {
+		if !slices.Contains(h.FailOpen, feature) {
+			h.FailOpen = append(h.FailOpen, feature)
+		}
+	}
+	return h
+}
+
+// HighPerformanceSettingsFor returns the settings of the high-performance hooks for the containers
+// of the runtime handler, whose shared_cpuset and irqbalance_config_file override the global ones.
+func (c *RuntimeConfig) HighPerformanceSettingsFor(handler string) HighPerformanceConfig {
+	h := c.HighPerformanceSettings()
+	if r := c.RuntimeHandlerOrDefault(handler); r != nil {
+		if r.SharedCPUSet != "" {
+			h.SharedCPUSet = r.SharedCPUSet
+		}
+		if r.IrqBalanceConfigFile != "" {
+			h.IrqBalanceConfigFile = r.IrqBalanceConfigFile
+		}
+	}
+	return h
+}
+
+// HighPerformancePolicyFor returns the policy of the high-performance hooks for the containers
+// of the runtime handler, depending on its runtime type.
+func (c *RuntimeConfig) HighPerformancePolicyFor(handler string) string {
+	var runtimeType string
+	if r := c.RuntimeHandlerOrDefault(handler); r != nil {
+		runtimeType = r.RuntimeType
+	}
+	return c.HighPerformance.RuntimeTypePolicy(runtimeType)
+}
@@ -0,0 +1,151 @@
+package config_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cri-o/cri-o/pkg/config"
+)
+
+// The actual test suite.
+var _ = t.Describe("HighPerformanceConfig", func() {
+	BeforeEach(beforeEach)
+
+	It("should validate an empty table", func() {
+		Expect((&config.HighPerformanceConfig{}).Validate()).To(Succeed())
+	})
+
+	DescribeTable("should fail on invalid options",
+		func(h config.HighPerformanceConfig) {
+			Expect(h.Validate()).NotTo(Succeed())
+		},
+		Entry("shared_cpuset", config.HighPerformanceConfig{SharedCPUSet: "0-"}),
+		Entry("housekeeping_cpus", config.HighPerformanceConfig{HousekeepingCPUs: "a"}),
+		Entry("irqbalance_config_file", config.HighPerformanceConfig{IrqBalanceConfigFile: "irqbalance"}),
+		Entry("state_dir", config.HighPerformanceConfig{StateDir: "tuning"}),
+		Entry("disabled_features", config.HighPerformanceConfig{DisabledFeatures: []string{"cpu-magic"}}),
+		Entry("fail_open", config.HighPerformanceConfig{FailOpen: []string{config.HighPerformanceFeatureSharedCPUs}}),
+		Entry("tuned_conflict", config.HighPerformanceConfig{TunedConflict: "maybe"}),
+		Entry("runtime_type_policies type", config.HighPerformanceConfig{RuntimeTypePolicies: map[string]string{"wasm": config.RuntimeTypeTuningSkip}}),
+		Entry("runtime_type_policies policy", config.HighPerformanceConfig{RuntimeTypePolicies: map[string]string{config.RuntimeTypeVM: "maybe"}}),
+		Entry("runtime_type_policies delegate", config.HighPerformanceConfig{RuntimeTypePolicies: map[string]string{"oci": config.RuntimeTypeTuningDelegate}}),
+		Entry("netns_sysctl_bundles sysctl", config.HighPerformanceConfig{NetNSSysctlBundles: map[string]map[string]string{"low-latency": {"kernel.shmmax": "1"}}}),
+		Entry("netns_sysctl_bundles value", config.HighPerformanceConfig{NetNSSysctlBundles: map[string]map[string]string{"low-latency": {"net.core.busy_poll": ""}}}),
+	)
+
+	It("should allow to disable the irqbalance config restoration", func() {
+		h := config.HighPerformanceConfig{IrqBalanceConfigRestoreFile: "disable"}
+
+		Expect(h.Validate()).To(Succeed())
+		Expect(h.IrqBalanceConfigRestoreEnabled()).To(BeFalse())
+	})
+
+	It("should fall back to the former options", func() {
+		sut.SharedCPUSet = "0-1"
+		sut.HighPerformanceCPUQuota = false
+		sut.HighPerformanceFailOpen = []string{config.HighPerformanceFeatureCPUCStates}
+		sut.HighPerformanceDryRun = true
+
+		h := sut.HighPerformanceSettings()
+
+		Expect(h.SharedCPUSet).To(Equal("0-1"))
+		Expect(h.IrqBalanceConfigFile).To(Equal(config.DefaultIrqBalanceConfigFile))
+		Expect(h.IrqBalanceConfigRestoreFile).To(Equal(config.DefaultIrqBalanceConfigRestoreFile))
+		Expect(h.StateDir).To(Equal(config.DefaultTuningStateDir))
+		Expect(h.TunedConflict).To(Equal(config.TunedConflictWarn))
+		Expect(h.DisabledFeatures).To(Equal([]string{config.HighPerformanceFeatureCPUQuota}))
+		Expect(h.FeatureEnabled(config.HighPerformanceFeatureCPUQuota)).To(BeFalse())
+		Expect(h.FeatureEnabled(config.HighPerformanceFeatureSharedCPUs)).To(BeTrue())
+		Expect(h.FailOpen).To(Equal([]string{config.HighPerformanceFeatureCPUCStates}))
+		Expect(h.DryRun).To(BeTrue())
+	})
+
+	It("should take precedence over the former options", func() {
+		sut.SharedCPUSet = "0-1"
+		sut.HighPerformanceCPUQuota = false
+		sut.HighPerformance = config.HighPerformanceConfig{
+			SharedCPUSet:     "2-3",
+			HousekeepingCPUs: "0",
+			StateDir:         "/run/tuning",
+			TunedConflict:    config.TunedConflictRefuse,
+			DisabledFeatures: []string{config.HighPerformanceFeatureCPUFreqGovernor},
+		}
+
+		h := sut.HighPerformanceSettings()
+
+		Expect(h.SharedCPUSet).To(Equal("2-3"))
+		Expect(h.HousekeepingCPUs).To(Equal("0"))
+		Expect(h.StateDir).To(Equal("/run/tuning"))
+		Expect(h.TunedConflict).To(Equal(config.TunedConflictRefuse))
+		Expect(h.DisabledFeatures).To(Equal([]string{
+			config.HighPerformanceFeatureCPUFreqGovernor,
+			config.HighPerformanceFeatureCPUQuota,
+		}))
+		Expect(sut.HighPerformance.DisabledFeatures).To(HaveLen(1))
+	})
+
+	It("should be overridden by the runtime handler", func() {
+		sut.HighPerformance.SharedCPUSet = "2-3"
+		sut.Runtimes["high-performance"] = &config.RuntimeHandler{
+			SharedCPUSet:         "4-5",
+			IrqBalanceConfigFile: "/etc/irqbalance-hp",
+		}
+
+		h := sut.HighPerformanceSettingsFor("high-performance")
+
+		Expect(h.SharedCPUSet).To(Equal("4-5"))
+		Expect(h.IrqBalanceConfigFile).To(Equal("/etc/irqbalance-hp"))
+		Expect(sut.SharedCPUSetForRuntimeHandler("high-performance")).To(Equal("4-5"))
+		Expect(sut.SharedCPUSetForRuntimeHandler("unknown")).To(Equal("2-3"))
+	})
+
+	It("should delegate the tuning of the VM runtime handlers by default", func() {
+		sut.Runtimes["kata"] = &config.RuntimeHandler{RuntimeType: config.RuntimeTypeVM}
+		sut.Runtimes["pods"] = &config.RuntimeHandler{RuntimeType: config.RuntimeTypePod}
+
+		Expect(sut.HighPerformancePolicyFor("kata")).To(Equal(config.RuntimeTypeTuningDelegate))
+		Expect(sut.HighPerformancePolicyFor("pods")).To(Equal(config.RuntimeTypeTuningTune))
+		Expect(sut.HighPerformancePolicyFor("")).To(Equal(config.RuntimeTypeTuningTune))
+
+		sut.HighPerformance.RuntimeTypePolicies = map[string]string{
+			config.RuntimeTypeVM: config.RuntimeTypeTuningSkip,
+			"oci":                config.RuntimeTypeTuningSkip,
+		}
+		Expect(sut.HighPerformance.Validate()).To(Succeed())
+		Expect(sut.HighPerformancePolicyFor("kata")).To(Equal(config.RuntimeTypeTuningSkip))
+		Expect(sut.HighPerformancePolicyFor("")).To(Equal(config.RuntimeTypeTuningSkip))
+		Expect(sut.HighPerformancePolicyFor("pods")).To(Equal(config.RuntimeTypeTuningTune))
+	})
+
+	It("should be preserved by the template", func() {
+		sut.HighPerformance = config.HighPerformanceConfig{
+			SharedCPUSet:     "2-3",
+			HousekeepingCPUs: "0",
+			DisabledFeatures: []string{config.HighPerformanceFeatureCPUQuota},
+			FailOpen:         []string{config.HighPerformanceFeatureCPUCStates},
+			DryRun:           true,
+			RuntimeTypePolicies: map[string]string{
+				config.RuntimeTypeVM: config.RuntimeTypeTuningSkip,
+				"oci":                config.RuntimeTypeTuningTune,
+			},
+			NetNSSysctlBundles: map[string]map[string]string{
+				"low-latency": {"net.core.busy_poll": "50", "net.core.busy_read": "50"},
+				"throughput":  {"net.core.netdev_budget": "600"},
+			},
+		}
+		var wr bytes.Buffer
+		Expect(sut.WriteTemplate(false, &wr)).To(Succeed())
+		file := filepath.Join(GinkgoT().TempDir(), "crio.conf")
+		Expect(os.WriteFile(file, wr.Bytes(), 0o644)).To(Succeed())
+
+		cfg := defaultConfig()
+		Expect(cfg.UpdateFromFile(context.Background(), file)).To(Succeed())
+
+		Expect(cfg.HighPerformance).To(Equal(sut.HighPerformance))
+	})
+})
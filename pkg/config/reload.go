@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -12,6 +14,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/sirupsen/logrus"
+	"k8s.io/utils/cpuset"
 	"tags.cncf.io/container-device-interface/pkg/cdi"
 
 	"github.com/cri-o/cri-o/internal/log"
@@ -54,7 +57,9 @@ func (c *Config) Reload(ctx context.Context) error {
 	if err := c.ReloadPauseImage(newConfig); err != nil {
 		return err
 	}
-	c.ReloadPinnedImages(newConfig)
+	if err := c.ReloadPinnedImages(newConfig); err != nil {
+		return err
+	}
 	if err := c.ReloadRegistries(); err != nil {
 		return err
 	}
@@ -74,6 +79,12 @@ func (c *Config) Reload(ctx context.Context) error {
 	if err := c.ReloadRuntimes(newConfig); err != nil {
 		return err
 	}
+	if err := c.ReloadSharedCPUSet(newConfig); err != nil {
+		return err
+	}
+	if err := c.ReloadHighPerformanceHooks(newConfig); err != nil {
+		return err
+	}
 	if err := cdi.Configure(cdi.WithSpecDirs(newConfig.CDISpecDirs...)); err != nil {
 		return err
 	}
@@ -149,12 +160,16 @@ func (c *Config) ReloadPauseImage(newConfig *Config) error {
 
 // ReloadPinnedImages replace the PinnedImages
 // with the provided `newConfig.PinnedImages`.
-// The method skips empty items and prints a log message.
-func (c *Config) ReloadPinnedImages(newConfig *Config) {
+// The method skips empty items and prints a log message. It fails on an
+// invalid pattern, keeping the current list.
+func (c *Config) ReloadPinnedImages(newConfig *Config) error {
+	if err := validatePinnedImages(newConfig.PinnedImages); err != nil {
+		return err
+	}
 	if len(newConfig.PinnedImages) == 0 {
 		c.PinnedImages = []string{}
 		logConfig("pinned_images", "[]")
-		return
+		return nil
 	}
 
 	if cmp.Equal(c.PinnedImages, newConfig.PinnedImages,
@@ -162,7 +177,7 @@ func (c *Config) ReloadPinnedImages(newConfig *Config) {
 			return a < b
 		}),
 	) {
-		return
+		return nil
 	}
 
 	pinnedImages := []string{}
@@ -175,6 +190,7 @@ func (c *Config) ReloadPinnedImages(newConfig *Config) {
 	logConfig("pinned_images", strings.Join(pinnedImages, ","))
 
 	c.PinnedImages = pinnedImages
+	return nil
 }
 
 // ReloadRegistries reloads the registry configuration from the Configs
@@ -263,6 +279,95 @@ func (c *Config) ReloadRdtConfig(newConfig *Config) error {
 	return nil
 }
 
+// ReloadSharedCPUSet updates the SharedCPUSet with the provided `newConfig`.
+// It errors if the new CPU set is not parsable.
+func (c *Config) ReloadSharedCPUSet(newConfig *Config) error {
+	if c.SharedCPUSet != newConfig.SharedCPUSet {
+		if _, err := cpuset.Parse(newConfig.SharedCPUSet); err != nil {
+			return fmt.Errorf("unable to reload shared_cpuset: %w", err)
+		}
+		c.SharedCPUSet = newConfig.SharedCPUSet
+		logConfig("shared_cpuset", c.SharedCPUSet)
+	}
+	return nil
+}
+
+// ReloadHighPerformanceHooks updates the configuration of the high-performance hooks with the provided
+// `newConfig`. The hooks are instantiated per request, so it applies to the containers started afterwards.
+// It errors if the new fail open features, TuneD conflict policy or high_performance table are invalid.
+func (c *Config) ReloadHighPerformanceHooks(newConfig *Config) error {
+	// The state directory and the irqbalance banned CPU list to restore are only read on startup.
+	newHighPerformance := newConfig.HighPerformance
+	newHighPerformance.StateDir = c.HighPerformance.StateDir
+	newHighPerformance.IrqBalanceConfigRestoreFile = c.HighPerformance.IrqBalanceConfigRestoreFile
+	highPerformanceChanged := !reflect.DeepEqual(c.HighPerformance, newHighPerformance)
+	if highPerformanceChanged {
+		if err := newHighPerformance.Validate(); err != nil {
+			return fmt.Errorf("unable to reload high_performance: %w", err)
+		}
+	}
+	if !slices.Equal(c.HighPerformanceFailOpen, newConfig.HighPerformanceFailOpen) {
+		if err := newConfig.ValidateHighPerformanceFailOpen(); err != nil {
+			return fmt.Errorf("unable to reload high_performance_fail_open: %w", err)
+		}
+	}
+	if c.HighPerformanceTunedConflict != newConfig.HighPerformanceTunedConflict {
+		if err := newConfig.ValidateHighPerformanceTunedConflict(); err != nil {
+			return fmt.Errorf("unable to reload high_performance_tuned_conflict: %w", err)
+		}
+	}
+
+	if c.IrqBalanceConfigFile != newConfig.IrqBalanceConfigFile {
+		c.IrqBalanceConfigFile = newConfig.IrqBalanceConfigFile
+		logConfig("irqbalance_config_file", c.IrqBalanceConfigFile)
+	}
+	if c.HighPerformanceCPULoadBalancing != newConfig.HighPerformanceCPULoadBalancing {
+		c.HighPerformanceCPULoadBalancing = newConfig.HighPerformanceCPULoadBalancing
+		logConfig("high_performance_cpu_load_balancing", strconv.FormatBool(c.HighPerformanceCPULoadBalancing))
+	}
+	if c.HighPerformanceIRQLoadBalancing != newConfig.HighPerformanceIRQLoadBalancing {
+		c.HighPerformanceIRQLoadBalancing = newConfig.HighPerformanceIRQLoadBalancing
+		logConfig("high_performance_irq_load_balancing", strconv.FormatBool(c.HighPerformanceIRQLoadBalancing))
+	}
+	if c.HighPerformanceCPUQuota != newConfig.HighPerformanceCPUQuota {
+		c.HighPerformanceCPUQuota = newConfig.HighPerformanceCPUQuota
+		logConfig("high_performance_cpu_quota", strconv.FormatBool(c.HighPerformanceCPUQuota))
+	}
+	if c.HighPerformanceCPUCStates != newConfig.HighPerformanceCPUCStates {
+		c.HighPerformanceCPUCStates = newConfig.HighPerformanceCPUCStates
+		logConfig("high_performance_cpu_c_states", strconv.FormatBool(c.HighPerformanceCPUCStates))
+	}
+	if c.HighPerformanceCPUFreqGovernor != newConfig.HighPerformanceCPUFreqGovernor {
+		c.HighPerformanceCPUFreqGovernor = newConfig.HighPerformanceCPUFreqGovernor
+		logConfig("high_performance_cpu_freq_governor", strconv.FormatBool(c.HighPerformanceCPUFreqGovernor))
+	}
+	if c.HighPerformanceSharedCPUs != newConfig.HighPerformanceSharedCPUs {
+		c.HighPerformanceSharedCPUs = newConfig.HighPerformanceSharedCPUs
+		logConfig("high_performance_shared_cpus", strconv.FormatBool(c.HighPerformanceSharedCPUs))
+	}
+	if c.HighPerformanceDryRun != newConfig.HighPerformanceDryRun {
+		c.HighPerformanceDryRun = newConfig.HighPerformanceDryRun
+		logConfig("high_performance_dry_run", strconv.FormatBool(c.HighPerformanceDryRun))
+	}
+	if c.HighPerformanceReconcileOnReload != newConfig.HighPerformanceReconcileOnReload {
+		c.HighPerformanceReconcileOnReload = newConfig.HighPerformanceReconcileOnReload
+		logConfig("high_performance_reconcile_on_reload", strconv.FormatBool(c.HighPerformanceReconcileOnReload))
+	}
+	if !slices.Equal(c.HighPerformanceFailOpen, newConfig.HighPerformanceFailOpen) {
+		c.HighPerformanceFailOpen = newConfig.HighPerformanceFailOpen
+		logConfig("high_performance_fail_open", strings.Join(c.HighPerformanceFailOpen, ","))
+	}
+	if c.HighPerformanceTunedConflict != newConfig.HighPerformanceTunedConflict {
+		c.HighPerformanceTunedConflict = newConfig.HighPerformanceTunedConflict
+		logConfig("high_performance_tuned_conflict", c.HighPerformanceTunedConflict)
+	}
+	if highPerformanceChanged {
+		c.HighPerformance = newHighPerformance
+		logConfig("high_performance", fmt.Sprintf("%+v", c.HighPerformance))
+	}
+	return nil
+}
+
 // ReloadRuntimes reloads the runtimes configuration if changed.
 func (c *Config) ReloadRuntimes(newConfig *Config) error {
 	var updated bool
@@ -454,12 +454,149 @@ var _ = t.Describe("Config", func() {
 		})
 	})
 
+	t.Describe("ReloadSharedCPUSet", func() {
+		It("should succeed without any config change", func() {
+			// Given
+			// When
+			err := sut.ReloadSharedCPUSet(sut)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should succeed with config change", func() {
+			// Given
+			newConfig := defaultConfig()
+			newConfig.SharedCPUSet = "0-3"
+
+			// When
+			err := sut.ReloadSharedCPUSet(newConfig)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sut.SharedCPUSet).To(Equal("0-3"))
+		})
+
+		It("should fail with invalid shared_cpuset", func() {
+			// Given
+			newConfig := defaultConfig()
+			newConfig.SharedCPUSet = "invalid"
+
+			// When
+			err := sut.ReloadSharedCPUSet(newConfig)
+
+			// Then
+			Expect(err).To(HaveOccurred())
+			Expect(sut.SharedCPUSet).To(BeEmpty())
+		})
+	})
+
+	t.Describe("ReloadHighPerformanceHooks", func() {
+		It("should succeed without any config change", func() {
+			// Given
+			// When
+			err := sut.ReloadHighPerformanceHooks(sut)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should succeed with config change", func() {
+			// Given
+			newConfig := defaultConfig()
+			newConfig.IrqBalanceConfigFile = "/etc/default/irqbalance"
+			newConfig.HighPerformanceIRQLoadBalancing = false
+			newConfig.HighPerformanceFailOpen = []string{config.HighPerformanceFeatureCPUFreqGovernor}
+			newConfig.HighPerformanceTunedConflict = config.TunedConflictRefuse
+			newConfig.HighPerformanceReconcileOnReload = true
+
+			// When
+			err := sut.ReloadHighPerformanceHooks(newConfig)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sut.IrqBalanceConfigFile).To(Equal("/etc/default/irqbalance"))
+			Expect(sut.HighPerformanceIRQLoadBalancing).To(BeFalse())
+			Expect(sut.HighPerformanceFailOpen).To(Equal([]string{config.HighPerformanceFeatureCPUFreqGovernor}))
+			Expect(sut.HighPerformanceTunedConflict).To(Equal(config.TunedConflictRefuse))
+			Expect(sut.HighPerformanceReconcileOnReload).To(BeTrue())
+		})
+
+		It("should fail with invalid high_performance_fail_open", func() {
+			// Given
+			newConfig := defaultConfig()
+			newConfig.IrqBalanceConfigFile = "/etc/default/irqbalance"
+			newConfig.HighPerformanceFailOpen = []string{"invalid"}
+
+			// When
+			err := sut.ReloadHighPerformanceHooks(newConfig)
+
+			// Then
+			Expect(err).To(HaveOccurred())
+			Expect(sut.HighPerformanceFailOpen).To(BeEmpty())
+			Expect(sut.IrqBalanceConfigFile).To(Equal(config.DefaultIrqBalanceConfigFile))
+		})
+
+		It("should fail with invalid high_performance_tuned_conflict", func() {
+			// Given
+			newConfig := defaultConfig()
+			newConfig.HighPerformanceTunedConflict = "invalid"
+
+			// When
+			err := sut.ReloadHighPerformanceHooks(newConfig)
+
+			// Then
+			Expect(err).To(HaveOccurred())
+			Expect(sut.HighPerformanceTunedConflict).To(Equal(config.TunedConflictWarn))
+		})
+
+		It("should succeed with high_performance table change", func() {
+			// Given
+			sut.HighPerformance.StateDir = "/var/lib/crio/tuning-hp"
+			newConfig := defaultConfig()
+			newConfig.HighPerformance = config.HighPerformanceConfig{
+				SharedCPUSet:     "2-3",
+				HousekeepingCPUs: "0",
+				DisabledFeatures: []string{config.HighPerformanceFeatureCPUQuota},
+				StateDir:         "/var/lib/crio/tuning-new",
+			}
+
+			// When
+			err := sut.ReloadHighPerformanceHooks(newConfig)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sut.HighPerformance.SharedCPUSet).To(Equal("2-3"))
+			Expect(sut.HighPerformance.HousekeepingCPUs).To(Equal("0"))
+			Expect(sut.SharedCPUSetForRuntimeHandler("")).To(Equal("2-3"))
+			settings := sut.HighPerformanceSettings()
+			Expect(settings.FeatureEnabled(config.HighPerformanceFeatureCPUQuota)).To(BeFalse())
+			// the state directory is only read on startup
+			Expect(sut.HighPerformance.StateDir).To(Equal("/var/lib/crio/tuning-hp"))
+		})
+
+		It("should fail with invalid high_performance table", func() {
+			// Given
+			newConfig := defaultConfig()
+			newConfig.HighPerformanceDryRun = true
+			newConfig.HighPerformance.DisabledFeatures = []string{"invalid"}
+
+			// When
+			err := sut.ReloadHighPerformanceHooks(newConfig)
+
+			// Then
+			Expect(err).To(HaveOccurred())
+			Expect(sut.HighPerformance.DisabledFeatures).To(BeEmpty())
+			Expect(sut.HighPerformanceDryRun).To(BeFalse())
+		})
+	})
+
 	t.Describe("ReloadPinnedImages", func() {
 		It("should update PinnedImages with newConfig's PinnedImages if they are different", func() {
 			sut.PinnedImages = []string{"image1", "image4", "image3"}
 			newConfig := &config.Config{}
 			newConfig.PinnedImages = []string{"image5"}
-			sut.ReloadPinnedImages(newConfig)
+			Expect(sut.ReloadPinnedImages(newConfig)).To(Succeed())
 			Expect(sut.PinnedImages).To(Equal([]string{"image5"}))
 		})
 
@@ -467,8 +604,16 @@ var _ = t.Describe("Config", func() {
 			sut.PinnedImages = []string{"image1", "image2", "image3"}
 			newConfig := &config.Config{}
 			newConfig.PinnedImages = []string{"image1", "image2", "image3"}
-			sut.ReloadPinnedImages(newConfig)
+			Expect(sut.ReloadPinnedImages(newConfig)).To(Succeed())
 			Expect(sut.PinnedImages).To(Equal([]string{"image1", "image2", "image3"}))
 		})
+
+		It("should fail to reload an invalid pattern", func() {
+			sut.PinnedImages = []string{"image1"}
+			newConfig := &config.Config{}
+			newConfig.PinnedImages = []string{"image1", "*"}
+			Expect(sut.ReloadPinnedImages(newConfig)).NotTo(Succeed())
+			Expect(sut.PinnedImages).To(Equal([]string{"image1"}))
+		})
 	})
 })
@@ -2,6 +2,7 @@ package config
 
 import (
 	"io"
+	"maps"
 	"reflect"
 	"slices"
 	"strings"
@@ -281,6 +282,36 @@ func initCrioTemplateConfig(c *Config) ([]*templateConfigValue, error) {
 			group:          crioRuntimeConfig,
 			isDefaultValue: simpleEqual(dc.IrqBalanceConfigRestoreFile, c.IrqBalanceConfigRestoreFile),
 		},
+		{
+			templateString: templateStringCrioRuntimeTuningStateDir,
+			group:          crioRuntimeConfig,
+			isDefaultValue: simpleEqual(dc.TuningStateDir, c.TuningStateDir),
+		},
+		{
+			templateString: templateStringCrioRuntimeTuningAuditLog,
+			group:          crioRuntimeConfig,
+			isDefaultValue: simpleEqual(dc.TuningAuditLog, c.TuningAuditLog),
+		},
+		{
+			templateString: templateStringCrioRuntimeTuningAuditLogSizeMax,
+			group:          crioRuntimeConfig,
+			isDefaultValue: simpleEqual(dc.TuningAuditLogSizeMax, c.TuningAuditLogSizeMax),
+		},
+		{
+			templateString: templateStringCrioRuntimeTuningLinuxAudit,
+			group:          crioRuntimeConfig,
+			isDefaultValue: simpleEqual(dc.TuningLinuxAudit, c.TuningLinuxAudit),
+		},
+		{
+			templateString: templateStringCrioRuntimeTuningTopologyFile,
+			group:          crioRuntimeConfig,
+			isDefaultValue: simpleEqual(dc.TuningTopologyFile, c.TuningTopologyFile),
+		},
+		{
+			templateString: templateStringCrioRuntimeTuningContainerStateDir,
+			group:          crioRuntimeConfig,
+			isDefaultValue: simpleEqual(dc.TuningContainerStateDir, c.TuningContainerStateDir),
+		},
 		{
 			templateString: templateStringCrioRuntimeRdtConfigFile,
 			group:          crioRuntimeConfig,
@@ -426,6 +457,81 @@ func initCrioTemplateConfig(c *Config) ([]*templateConfigValue, error) {
 			group:          crioRuntimeConfig,
 			isDefaultValue: simpleEqual(dc.SharedCPUSet, c.SharedCPUSet),
 		},
+		{
+			templateString: templateStringCrioRuntimeHighPerformanceCPULoadBalancing,
+			group:          crioRuntimeConfig,
+			isDefaultValue: simpleEqual(dc.HighPerformanceCPULoadBalancing, c.HighPerformanceCPULoadBalancing),
+		},
+		{
+			templateString: templateStringCrioRuntimeHighPerformanceIRQLoadBalancing,
+			group:          crioRuntimeConfig,
+			isDefaultValue: simpleEqual(dc.HighPerformanceIRQLoadBalancing, c.HighPerformanceIRQLoadBalancing),
+		},
+		{
+			templateString: templateStringCrioRuntimeHighPerformanceCPUQuota,
+			group:          crioRuntimeConfig,
+			isDefaultValue: simpleEqual(dc.HighPerformanceCPUQuota, c.HighPerformanceCPUQuota),
+		},
+		{
+			templateString: templateStringCrioRuntimeHighPerformanceCPUCStates,
+			group:          crioRuntimeConfig,
+			isDefaultValue: simpleEqual(dc.HighPerformanceCPUCStates, c.HighPerformanceCPUCStates),
+		},
+		{
+			templateString: templateStringCrioRuntimeHighPerformanceCPUFreqGovernor,
+			group:          crioRuntimeConfig,
+			isDefaultValue: simpleEqual(dc.HighPerformanceCPUFreqGovernor, c.HighPerformanceCPUFreqGovernor),
+		},
+		{
+			templateString: templateStringCrioRuntimeHighPerformanceSharedCPUs,
+			group:          crioRuntimeConfig,
+			isDefaultValue: simpleEqual(dc.HighPerformanceSharedCPUs, c.HighPerformanceSharedCPUs),
+		},
+		{
+			templateString: templateStringCrioRuntimeHighPerformanceFailOpen,
+			group:          crioRuntimeConfig,
+			isDefaultValue: slices.Equal(dc.HighPerformanceFailOpen, c.HighPerformanceFailOpen),
+		},
+		{
+			templateString: templateStringCrioRuntimeHighPerformanceTunedConflict,
+			group:          crioRuntimeConfig,
+			isDefaultValue: simpleEqual(dc.HighPerformanceTunedConflict, c.HighPerformanceTunedConflict),
+		},
+		{
+			templateString: templateStringCrioRuntimeHighPerformanceDryRun,
+			group:          crioRuntimeConfig,
+			isDefaultValue: simpleEqual(dc.HighPerformanceDryRun, c.HighPerformanceDryRun),
+		},
+		{
+			templateString: templateStringCrioRuntimeHighPerformanceReconcileOnReload,
+			group:          crioRuntimeConfig,
+			isDefaultValue: simpleEqual(dc.HighPerformanceReconcileOnReload, c.HighPerformanceReconcileOnReload),
+		},
+		{
+			templateString: templateStringCrioRuntimeRuntimeHandlerHooksTimeout,
+			group:          crioRuntimeConfig,
+			isDefaultValue: simpleEqual(dc.RuntimeHandlerHooksTimeout, c.RuntimeHandlerHooksTimeout),
+		},
+		{
+			templateString: templateStringCrioRuntimeRuntimeHandlerHooksLogFormat,
+			group:          crioRuntimeConfig,
+			isDefaultValue: simpleEqual(dc.RuntimeHandlerHooksLogFormat, c.RuntimeHandlerHooksLogFormat),
+		},
+		{
+			templateString: templateStringCrioRuntimeTuningDriftCheckInterval,
+			group:          crioRuntimeConfig,
+			isDefaultValue: simpleEqual(dc.TuningDriftCheckInterval, c.TuningDriftCheckInterval),
+		},
+		{
+			templateString: templateStringCrioRuntimeTuningSchedstatInterval,
+			group:          crioRuntimeConfig,
+			isDefaultValue: simpleEqual(dc.TuningSchedstatInterval, c.TuningSchedstatInterval),
+		},
+		{
+			templateString: templateStringCrioRuntimeTuningTelemetryInterval,
+			group:          crioRuntimeConfig,
+			isDefaultValue: simpleEqual(dc.TuningTelemetryInterval, c.TuningTelemetryInterval),
+		},
 		{
 			templateString: templateStringCrioRuntimeNamespacesDir,
 			group:          crioRuntimeConfig,
@@ -466,6 +572,11 @@ func initCrioTemplateConfig(c *Config) ([]*templateConfigValue, error) {
 			group:          crioRuntimeConfig,
 			isDefaultValue: WorkloadsEqual(dc.Workloads, c.Workloads),
 		},
+		{
+			templateString: templateStringCrioRuntimeTuningAnnotationPolicies,
+			group:          crioRuntimeConfig,
+			isDefaultValue: reflect.DeepEqual(dc.TuningAnnotationPolicies, c.TuningAnnotationPolicies),
+		},
 		{
 			templateString: templateStringCrioRuntimeHostNetworkDisableSELinux,
 			group:          crioRuntimeConfig,
@@ -481,6 +592,11 @@ func initCrioTemplateConfig(c *Config) ([]*templateConfigValue, error) {
 			group:          crioRuntimeConfig,
 			isDefaultValue: simpleEqual(dc.Timezone, c.Timezone),
 		},
+		{
+			templateString: templateStringCrioRuntimeHighPerformance,
+			group:          crioRuntimeConfig,
+			isDefaultValue: reflect.DeepEqual(dc.HighPerformance, c.HighPerformance),
+		},
 		{
 			templateString: templateStringCrioImageDefaultTransport,
 			group:          crioImageConfig,
@@ -501,6 +617,16 @@ func initCrioTemplateConfig(c *Config) ([]*templateConfigValue, error) {
 			group:          crioImageConfig,
 			isDefaultValue: simpleEqual(dc.PauseImageAuthFile, c.PauseImageAuthFile),
 		},
+		{
+			templateString: templateStringCrioImageCredentialProviderConfig,
+			group:          crioImageConfig,
+			isDefaultValue: simpleEqual(dc.CredentialProviderConfig, c.CredentialProviderConfig),
+		},
+		{
+			templateString: templateStringCrioImageCredentialProviderBinDir,
+			group:          crioImageConfig,
+			isDefaultValue: simpleEqual(dc.CredentialProviderBinDir, c.CredentialProviderBinDir),
+		},
 		{
 			templateString: templateStringCrioImagePauseCommand,
 			group:          crioImageConfig,
@@ -521,6 +647,11 @@ func initCrioTemplateConfig(c *Config) ([]*templateConfigValue, error) {
 			group:          crioImageConfig,
 			isDefaultValue: simpleEqual(dc.SignaturePolicyDir, c.SignaturePolicyDir),
 		},
+		{
+			templateString: templateStringCrioImageSignaturePolicies,
+			group:          crioImageConfig,
+			isDefaultValue: reflect.DeepEqual(dc.SignaturePolicies, c.SignaturePolicies),
+		},
 		{
 			templateString: templateStringCrioImageInsecureRegistries,
 			group:          crioImageConfig,
@@ -546,6 +677,26 @@ func initCrioTemplateConfig(c *Config) ([]*templateConfigValue, error) {
 			group:          crioImageConfig,
 			isDefaultValue: simpleEqual(dc.PullProgressTimeout, c.PullProgressTimeout),
 		},
+		{
+			templateString: templateStringCrioImageMaxParallelImagePulls,
+			group:          crioImageConfig,
+			isDefaultValue: simpleEqual(dc.MaxParallelImagePulls, c.MaxParallelImagePulls),
+		},
+		{
+			templateString: templateStringCrioImageMaxParallelLayerDownloads,
+			group:          crioImageConfig,
+			isDefaultValue: simpleEqual(dc.MaxParallelLayerDownloads, c.MaxParallelLayerDownloads),
+		},
+		{
+			templateString: templateStringCrioImageRegistryMaxParallelLayerDownloads,
+			group:          crioImageConfig,
+			isDefaultValue: maps.Equal(dc.RegistryMaxParallelLayerDownloads, c.RegistryMaxParallelLayerDownloads),
+		},
+		{
+			templateString: templateStringCrioImageRegistryMirrorHealthCheckInterval,
+			group:          crioImageConfig,
+			isDefaultValue: simpleEqual(dc.RegistryMirrorHealthCheckInterval, c.RegistryMirrorHealthCheckInterval),
+		},
 		{
 			templateString: templateStringCrioNetworkCniDefaultNetwork,
 			group:          crioNetworkConfig,
@@ -1146,6 +1297,49 @@ const templateStringCrioRuntimeIrqBalanceConfigRestoreFile = `# irqbalance_confi
 
 `
 
+const templateStringCrioRuntimeTuningStateDir = `# tuning_state_dir is the directory the runtime handler hooks record the tuning
+# they applied to every container to, so that it can still be reverted after a crash
+# or restart of CRI-O.
+{{ $.Comment }}tuning_state_dir = "{{ .TuningStateDir }}"
+
+`
+
+const templateStringCrioRuntimeTuningAuditLog = `# tuning_audit_log is the file every write of the runtime handler hooks to the sysfs,
+# procfs and cgroup files of the node is recorded to, one JSON object per line holding
+# the file, its former and new values, the container and the time of the write.
+# If empty, the writes are not recorded.
+{{ $.Comment }}tuning_audit_log = "{{ .TuningAuditLog }}"
+
+`
+
+const templateStringCrioRuntimeTuningAuditLogSizeMax = `# The size in bytes after which the tuning_audit_log gets rotated, keeping the
+# 3 most recent rotated files. Set to 0 to never rotate it.
+{{ $.Comment }}tuning_audit_log_size_max = {{ .TuningAuditLogSizeMax }}
+
+`
+
+const templateStringCrioRuntimeTuningLinuxAudit = `# tuning_linux_audit reports the privileged tuning operations of the runtime handler
+# hooks, like the changes of the IRQ affinity, of the CPU frequency governor and of the
+# CPU PM QoS resume latency, to the Linux audit subsystem as AUDIT_USYS_CONFIG events.
+# It requires the CAP_AUDIT_WRITE capability.
+{{ $.Comment }}tuning_linux_audit = {{ .TuningLinuxAudit }}
+
+`
+
+const templateStringCrioRuntimeTuningTopologyFile = `# tuning_topology_file is the file the runtime handler hooks write the CPU consumption
+# of the tuned containers per NUMA zone to as JSON, every time the tuning changes it,
+# for an agent feeding the NodeResourceTopology API. If empty, it is not written.
+{{ $.Comment }}tuning_topology_file = "{{ .TuningTopologyFile }}"
+
+`
+
+const templateStringCrioRuntimeTuningContainerStateDir = `# tuning_container_state_dir is the directory the runtime handler hooks write the
+# isolated and shared CPUs and the active tuning of every tuned container to, as
+# <container-id>/tuning.json, for node-local agents. If empty, it is not written.
+{{ $.Comment }}tuning_container_state_dir = "{{ .TuningContainerStateDir }}"
+
+`
+
 const templateStringCrioRuntimeInfraCtrCpuset = `# infra_ctr_cpuset determines what CPUs will be used to run infra containers.
 # You can use linux CPU list format to specify desired CPUs.
 # To get better isolation for guaranteed pods, set this parameter to be equal to kubelet reserved-cpus.
@@ -1157,10 +1351,126 @@ const templateStringCrioRuntimeSharedCpuset = `# shared_cpuset  determines the C
 # regardless of, and in addition to, the exclusiveness of their CPUs.
 # This field is optional and would not be used if not specified.
 # You can specify CPUs in the Linux CPU list format.
+# This option supports live configuration reload.
 {{ $.Comment }}shared_cpuset = "{{ .SharedCPUSet }}"
 
 `
 
+const templateStringCrioRuntimeHighPerformanceCPULoadBalancing = `# Enables the high-performance hooks to disable the CPU load balancing of the container CPUs,
+# as requested with the "cpu-load-balancing.crio.io" annotation.
+# This option supports live configuration reload.
+{{ $.Comment }}high_performance_cpu_load_balancing = {{ .HighPerformanceCPULoadBalancing }}
+
+`
+
+const templateStringCrioRuntimeHighPerformanceIRQLoadBalancing = `# Enables the high-performance hooks to disable the IRQ load balancing of the container CPUs,
+# as requested with the "irq-load-balancing.crio.io" annotation.
+# This option supports live configuration reload.
+{{ $.Comment }}high_performance_irq_load_balancing = {{ .HighPerformanceIRQLoadBalancing }}
+
+`
+
+const templateStringCrioRuntimeHighPerformanceCPUQuota = `# Enables the high-performance hooks to disable the CFS quota of the container,
+# as requested with the "cpu-quota.crio.io" annotation.
+# This option supports live configuration reload.
+{{ $.Comment }}high_performance_cpu_quota = {{ .HighPerformanceCPUQuota }}
+
+`
+
+const templateStringCrioRuntimeHighPerformanceCPUCStates = `# Enables the high-performance hooks to configure the c-states of the container CPUs,
+# as requested with the "cpu-c-states.crio.io" annotation.
+# This option supports live configuration reload.
+{{ $.Comment }}high_performance_cpu_c_states = {{ .HighPerformanceCPUCStates }}
+
+`
+
+const templateStringCrioRuntimeHighPerformanceCPUFreqGovernor = `# Enables the high-performance hooks to configure the frequency governor of the container CPUs,
+# as requested with the "cpu-freq-governor.crio.io" annotation.
+# This option supports live configuration reload.
+{{ $.Comment }}high_performance_cpu_freq_governor = {{ .HighPerformanceCPUFreqGovernor }}
+
+`
+
+const templateStringCrioRuntimeHighPerformanceSharedCPUs = `# Enables the high-performance hooks to grant the shared_cpuset to the containers,
+# as requested with the "cpu-shared.crio.io" annotation. If disabled, the annotation is ignored.
+# This option supports live configuration reload.
+{{ $.Comment }}high_performance_shared_cpus = {{ .HighPerformanceSharedCPUs }}
+
+`
+
+const templateStringCrioRuntimeHighPerformanceFailOpen = `# A list of high-performance features whose failures are logged, letting the container
+# start or stop anyway, instead of failing the CRI request. Meant for best-effort tunings,
+# like a frequency governor the hardware may not support. The supported features are:
+# "cpu-load-balancing", "irq-load-balancing", "cpu-quota", "cpu-c-states", "cpu-freq-governor",
+# "packet-steering", "vf-queues", "arfs", "vf-irq-affinity", "af-xdp", "napi-affinity",
+# "interrupt-coalescing", "qdisc", "netdev-budget" and "gro".
+# The shared CPUs always fail closed, as they are advertised to the container on creation.
+# This option supports live configuration reload.
+{{ $.Comment }}high_performance_fail_open = [
+{{ range $feature := .HighPerformanceFailOpen}}{{ $.Comment }}{{ printf "\t%q,\n" $feature}}{{ end }}{{ $.Comment }}]
+
+`
+
+const templateStringCrioRuntimeHighPerformanceTunedConflict = `# The policy of the high-performance hooks when the active TuneD profile manages the
+# same settings as the tuning requested for a container, e.g. the IRQ affinity or the CPU
+# frequency governor, which TuneD would keep flipping back. Either "ignore", "warn" to log
+# the conflicts and apply the tuning anyway, or "refuse" to fail the CRI request.
+# This option supports live configuration reload.
+{{ $.Comment }}high_performance_tuned_conflict = "{{ .HighPerformanceTunedConflict }}"
+
+`
+
+const templateStringCrioRuntimeHighPerformanceDryRun = `# Makes the high-performance hooks compute the cgroup, sysfs and IRQ changes of the tuning
+# requested for a container on start, and log and save them in the tuning_state_dir, instead
+# of applying them. Meant to audit the effect of the annotations in staging before a rollout.
+# This option supports live configuration reload.
+{{ $.Comment }}high_performance_dry_run = {{ .HighPerformanceDryRun }}
+
+`
+
+const templateStringCrioRuntimeHighPerformanceReconcileOnReload = `# Makes the high-performance hooks reconcile the tuning of the running containers with the
+# configuration reloaded on SIGHUP, e.g. move their banned CPUs to a new irqbalance_config_file,
+# instead of only applying the reloaded configuration to the containers started afterwards.
+# This option supports live configuration reload.
+{{ $.Comment }}high_performance_reconcile_on_reload = {{ .HighPerformanceReconcileOnReload }}
+
+`
+
+const templateStringCrioRuntimeRuntimeHandlerHooksTimeout = `# The maximum time a runtime handler hook gets to run, the CRI request fails once it
+# expires. The pending file writes and commands of the hook are canceled. Set to 0 to disable the timeout.
+{{ $.Comment }}runtime_handler_hooks_timeout = "{{ .RuntimeHandlerHooksTimeout }}"
+
+`
+
+const templateStringCrioRuntimeRuntimeHandlerHooksLogFormat = `# The format of the log lines of the runtime handler hooks, "text" or "json", independently
+# of the format of the rest of the logs. The lines carry the container, pod, stage and step
+# of the hooks as fields. If empty, they are logged in the same format as the rest of the logs.
+{{ $.Comment }}runtime_handler_hooks_log_format = "{{ .RuntimeHandlerHooksLogFormat }}"
+
+`
+
+const templateStringCrioRuntimeTuningDriftCheckInterval = `# The interval at which the tuning applied by the runtime handler hooks to the running
+# containers is compared with the actual sysfs, cgroup and IRQ settings of the node, and repaired
+# when it drifted. Set to 0 to disable the drift detection.
+{{ $.Comment }}tuning_drift_check_interval = "{{ .TuningDriftCheckInterval }}"
+
+`
+
+const templateStringCrioRuntimeTuningSchedstatInterval = `# The interval at which the scheduler statistics of the CPUs isolated by the runtime
+# handler hooks are sampled from /proc/schedstat into the run delay metrics, measuring
+# whether the isolation delivers a low scheduling latency. Set to 0 to disable the sampling.
+{{ $.Comment }}tuning_schedstat_interval = "{{ .TuningSchedstatInterval }}"
+
+`
+
+const templateStringCrioRuntimeTuningTelemetryInterval = `# The interval at which the CPU telemetry of the containers tuned by the runtime handler
+# hooks, like the residency of their CPUs in every idle state for the containers tuned
+# for c-states or the frequency of their CPUs for the containers tuned for the CPU
+# frequency governor, is sampled from sysfs into the metrics. Set to 0 to disable the sampling.
+{{ $.Comment }}tuning_telemetry_interval = "{{ .TuningTelemetryInterval }}"
+
+`
+
 const templateStringCrioRuntimeNamespacesDir = `# The directory where the state of the managed namespaces gets tracked.
 # Only used when manage_ns_lifecycle is true.
 {{ $.Comment }}namespaces_dir = "{{ .NamespacesDir }}"
@@ -1220,6 +1530,12 @@ const templateStringCrioRuntimeRuntimesRuntimeHandler = `# The "crio.runtime.run
 # platform_runtime_paths = { "os/arch" = "/path/to/binary" }
 # no_sync_log = false
 # default_annotations = {}
+# isolated_cpus_env_var = "OPENSHIFT_ISOLATED_CPUS"
+# shared_cpus_env_var = "OPENSHIFT_SHARED_CPUS"
+# hooks_plugin = "/path/to/plugin.sock"
+# runtime_handler_hooks = "high-performance"
+# irqbalance_config_file = "/etc/sysconfig/irqbalance"
+# shared_cpuset = ""
 # Where:
 # - runtime-handler: Name used to identify the runtime.
 # - runtime_path (optional, string): Absolute path to the runtime executable in
@@ -1256,6 +1572,8 @@ const templateStringCrioRuntimeRuntimesRuntimeHandler = `# The "crio.runtime.run
 #     For images, the plain annotation "seccomp-profile.kubernetes.cri-o.io"
 #     can be used without the required "/POD" suffix or a container name.
 #   "io.kubernetes.cri-o.DisableFIPS" for disabling FIPS mode in a Kubernetes pod within a FIPS-enabled cluster.
+#   "oci-artifact.crio.io" for pulling the OCI artifact referenced by digest by
+#     "oci-artifact.crio.io/<NAME>" when the pod starts, for the runtime and the hooks.
 # - monitor_path (optional, string): The path of the monitor binary. Replaces
 #   deprecated option "conmon".
 # - monitor_cgroup (optional, string): The cgroup the container monitor process will be put in.
@@ -1273,6 +1591,27 @@ const templateStringCrioRuntimeRuntimesRuntimeHandler = `# The "crio.runtime.run
 #   This option is only valid for the 'oci' runtime type. Setting this option to true can cause data loss, e.g.
 #   when a machine crash happens.
 # - default_annotations (optional, map): Default annotations if not overridden by the pod spec.
+# - isolated_cpus_env_var (optional, string): The name of the environment variable holding the
+#   isolated CPUs, injected into containers requesting shared CPUs. If not set, "OPENSHIFT_ISOLATED_CPUS" is used.
+# - shared_cpus_env_var (optional, string): The name of the environment variable holding the
+#   shared CPUs, injected into containers requesting shared CPUs. If not set, "OPENSHIFT_SHARED_CPUS" is used.
+# - hooks_plugin (optional, string): Absolute path to the unix socket of a plugin implementing
+#   the PreStart, PreStop and PostStop runtime handler hooks over gRPC. The plugin hooks run in
+#   addition to the built-in ones, after them on start and before them on stop. A plugin can
+#   also implement the PreCreate hook, to contribute mounts and rlimits to the container spec,
+#   the PreUpdate and PostUpdate hooks, run around the updates of the container resources, and
+#   the PreCheckpoint and PostRestore hooks, run when the container gets checkpointed and restored.
+#   The plugin has a higher priority than the built-in hooks: the hooks run by increasing priority
+#   on the PreCreate, PreStart, PostUpdate, PreCheckpoint and PostRestore stages, and by decreasing
+#   priority on the PreUpdate, PreStop and PostStop stages. The plugin may only modify the mounts and
+#   rlimits of the container spec, as the other resources are tuned by the built-in hooks.
+# - runtime_handler_hooks (optional, string): The built-in runtime handler hooks bound to the
+#   runtime handler, one of "high-performance", "default" (CPU load balancing only) or "none".
+#   If not set, the hooks are chosen based on the runtime handler name and the pod annotations.
+# - irqbalance_config_file (optional, string): Overrides the global irqbalance_config_file for
+#   the containers of the runtime handler.
+# - shared_cpuset (optional, string): Overrides the global shared_cpuset for the containers
+#   of the runtime handler.
 #
 # Using the seccomp notifier feature:
 #
@@ -1325,6 +1664,18 @@ const templateStringCrioRuntimeRuntimesRuntimeHandler = `# The "crio.runtime.run
 {{- $first := true }}{{- range $key, $value := $runtime_handler.DefaultAnnotations }}
 {{- if not $first }},{{ end }}{{- printf "%q = %q" $key $value }}{{- $first = false }}{{- end }}}
 {{ end }}
+{{ if $runtime_handler.IsolatedCPUsEnvVar }}{{ $.Comment }}isolated_cpus_env_var = "{{ $runtime_handler.IsolatedCPUsEnvVar }}"
+{{ end }}
+{{ if $runtime_handler.SharedCPUsEnvVar }}{{ $.Comment }}shared_cpus_env_var = "{{ $runtime_handler.SharedCPUsEnvVar }}"
+{{ end }}
+{{ if $runtime_handler.HooksPlugin }}{{ $.Comment }}hooks_plugin = "{{ $runtime_handler.HooksPlugin }}"
+{{ end }}
+{{ if $runtime_handler.RuntimeHandlerHooks }}{{ $.Comment }}runtime_handler_hooks = "{{ $runtime_handler.RuntimeHandlerHooks }}"
+{{ end }}
+{{ if $runtime_handler.IrqBalanceConfigFile }}{{ $.Comment }}irqbalance_config_file = "{{ $runtime_handler.IrqBalanceConfigFile }}"
+{{ end }}
+{{ if $runtime_handler.SharedCPUSet }}{{ $.Comment }}shared_cpuset = "{{ $runtime_handler.SharedCPUSet }}"
+{{ end }}
 {{ end }}
 `
 
@@ -1370,6 +1721,30 @@ const templateStringCrioRuntimeWorkloads = `# The workloads table defines ways t
 {{ end }}
 `
 
+const templateStringCrioRuntimeTuningAnnotationPolicies = `# The tuning_annotation_policies table restricts the pods allowed to use each of the
+# tuning annotations, which grant node-level tuning to their containers:
+# "cpu-load-balancing.crio.io", "cpu-quota.crio.io", "irq-load-balancing.crio.io",
+# "cpu-c-states.crio.io", "cpu-freq-governor.crio.io", "cpu-shared.crio.io",
+# "cpu-init-affinity.crio.io", "packet-steering.crio.io", "vf-queues.crio.io", "arfs.crio.io",
+# "vf-irq-affinity.crio.io", "af-xdp.crio.io", "napi-affinity.crio.io",
+# "interrupt-coalescing.crio.io", "qdisc.crio.io", "netdev-budget.crio.io", "gro.crio.io"
+# and "netns-sysctl-bundle.crio.io".
+# A pod using an annotation with a policy must either run in one of its namespaces, given as
+# shell patterns, or have all of its pod_labels, otherwise it is rejected at creation.
+# The annotations without policy can be used by all the pods.
+# Example:
+# [crio.runtime.tuning_annotation_policies."cpu-load-balancing.crio.io"]
+# namespaces = ["telco-*"]
+# pod_labels = { "tuning.example.com/allowed" = "true" }
+{{ range $annotation, $policy := .TuningAnnotationPolicies }}
+{{ $.Comment }}[crio.runtime.tuning_annotation_policies."{{ $annotation }}"]
+{{ $.Comment }}namespaces = [
+{{ range $namespace := $policy.Namespaces }}{{ $.Comment }}{{ printf "\t%q,\n" $namespace }}{{ end }}{{ $.Comment }}]
+{{ if $policy.PodLabels }}{{ $.Comment }}[crio.runtime.tuning_annotation_policies."{{ $annotation }}".pod_labels]
+{{ range $key, $value := $policy.PodLabels }}{{ $.Comment }}{{ printf "%q = %q" $key $value }}
+{{ end }}{{ end }}{{ end }}
+`
+
 const templateStringCrioRuntimeHostNetworkDisableSELinux = `# hostnetwork_disable_selinux determines whether
 # SELinux should be disabled within a pod when it is running in the host network namespace
 # Default value is set to true
@@ -1390,6 +1765,72 @@ const templateStringCrioRuntimeTimezone = `# timezone To set the timezone for a
 
 `
 
+const templateStringCrioRuntimeHighPerformance = `# The high_performance table gathers the settings of the high-performance hooks.
+# Its unset options fall back to the former options of the crio.runtime table, like
+# shared_cpuset, irqbalance_config_file, high_performance_fail_open or tuning_state_dir.
+# The command line flags of the former options take precedence over the table, and the
+# shared_cpuset and irqbalance_config_file of a runtime handler over both for its containers.
+# The table supports live configuration reload, except for state_dir and
+# irqbalance_config_restore_file which are only read on startup.
+# The tuning applied by a feature before it got disabled, with disabled_features or the
+# high_performance_* options of the crio.runtime table, is still reverted when the container stops.
+{{ $.Comment }}[crio.runtime.high_performance]
+
+# The CPUs granted to the guaranteed containers requesting shared CPUs.
+{{ $.Comment }}shared_cpuset = "{{ .HighPerformance.SharedCPUSet }}"
+
+# The CPUs the packet steering steers to for the containers with the "housekeeping" policy of
+# the "packet-steering.crio.io" annotation.
+# Only the packet steering reads it, the CPUs left by the tuned containers are used if empty.
+{{ $.Comment }}housekeeping_cpus = "{{ .HighPerformance.HousekeepingCPUs }}"
+
+# The irqbalance service config file the CPUs excluded from the IRQ load balancing are banned in.
+{{ $.Comment }}irqbalance_config_file = "{{ .HighPerformance.IrqBalanceConfigFile }}"
+
+# The irqbalance banned CPU list restored on startup, "disable" to not restore it.
+{{ $.Comment }}irqbalance_config_restore_file = "{{ .HighPerformance.IrqBalanceConfigRestoreFile }}"
+
+# The features of the high-performance hooks whose annotations are ignored, among
+# "cpu-load-balancing", "irq-load-balancing", "cpu-quota", "cpu-c-states",
+# "cpu-freq-governor", "shared-cpus", "packet-steering", "vf-queues", "arfs",
+# "vf-irq-affinity", "af-xdp", "napi-affinity", "interrupt-coalescing", "qdisc",
+# "netdev-budget" and "gro".
+{{ $.Comment }}disabled_features = [
+{{ range $opt := .HighPerformance.DisabledFeatures }}{{ $.Comment }}{{ printf "\t%q,\n" $opt }}{{ end }}{{ $.Comment }}]
+
+# The features whose failures are logged instead of failing the CRI request.
+{{ $.Comment }}fail_open = [
+{{ range $opt := .HighPerformance.FailOpen }}{{ $.Comment }}{{ printf "\t%q,\n" $opt }}{{ end }}{{ $.Comment }}]
+
+# The policy when the active TuneD profile manages the same settings, "ignore", "warn" or "refuse".
+{{ $.Comment }}tuned_conflict = "{{ .HighPerformance.TunedConflict }}"
+
+# Log and save the plan of the tuning of the containers on start instead of applying it.
+{{ $.Comment }}dry_run = {{ .HighPerformance.DryRun }}
+
+# The directory the tuning applied to every container is recorded to.
+{{ $.Comment }}state_dir = "{{ .HighPerformance.StateDir }}"
+
+# The policies of the hooks keyed by the runtime type of the runtime handlers, "oci", "vm" or "pod":
+# "tune" to tune the node, "delegate" to pass the tuning to the runtime of the "vm" runtime handlers,
+# which is the default of the "vm" type, or "skip" to ignore the tuning, reported in the container status.
+{{ $.Comment }}runtime_type_policies = {
+{{- $first := true }}{{- range $key, $value := .HighPerformance.RuntimeTypePolicies }}
+{{- if not $first }},{{ end }}{{- printf "%q = %q" $key $value }}{{- $first = false }}{{- end }}}
+
+# The bundles of network namespace sysctls keyed by name, which the pods select with the
+# "netns-sysctl-bundle.crio.io" annotation, to get them set in their network namespace on creation.
+# The sysctls set by the pod spec take precedence over the ones of its bundle. For example:
+# netns_sysctl_bundles = { "low-latency" = { "net.core.busy_poll" = "50", "net.core.busy_read" = "50" } }
+{{ $.Comment }}netns_sysctl_bundles = {
+{{- $first := true }}{{- range $name, $sysctls := .HighPerformance.NetNSSysctlBundles }}
+{{- if not $first }},{{ end }}{{- printf "%q = {" $name }}
+{{- $firstSysctl := true }}{{- range $key, $value := $sysctls }}
+{{- if not $firstSysctl }},{{ end }}{{- printf "%q = %q" $key $value }}{{- $firstSysctl = false }}{{- end }}}
+{{- $first = false }}{{- end }}}
+
+`
+
 const templateStringCrioImage = `# The crio.image table contains settings pertaining to the management of OCI images.
 #
 # CRI-O reads its configured registries defaults from the system wide
@@ -1422,6 +1863,20 @@ const templateStringCrioImagePauseImageAuthFile = `# The path to a file containi
 
 `
 
+const templateStringCrioImageCredentialProviderConfig = `# The path to a kubelet credential provider config file, whose plugins provide the
+# credentials for the images CRI-O pulls on its own, like the pause_image and the OCI
+# artifacts of the pods, as the kubelet only provides credentials for the images it pulls.
+# The plugins are not used for the pause_image if pause_image_auth_file is set.
+{{ $.Comment }}credential_provider_config = "{{ .CredentialProviderConfig }}"
+
+`
+
+const templateStringCrioImageCredentialProviderBinDir = `# The path to the directory of the kubelet credential provider plugins of
+# credential_provider_config.
+{{ $.Comment }}credential_provider_bin_dir = "{{ .CredentialProviderBinDir }}"
+
+`
+
 const templateStringCrioImagePauseCommand = `# The command to run to have a container stay in the paused state.
 # When explicitly set to "", it will fallback to the entrypoint and command
 # specified in the pause image. When commented out, it will fallback to the
@@ -1436,6 +1891,9 @@ const templateStringCrioImagePinnedImages = `# List of images to be excluded fro
 # have a wildcard * at the end, and keyword matches can have wildcards
 # on both ends. By default, this list includes the "pause" image if
 # configured by the user, which is used as a placeholder in Kubernetes pods.
+# The pinned images are never removed by CRI-O, not even when the kubelet
+# garbage collects the images under disk pressure. This option supports
+# live configuration reload.
 {{ $.Comment }}pinned_images = [
 {{ range $opt := .PinnedImages }}{{ $.Comment }}{{ printf "\t%q,\n" $opt }}{{ end }}{{ $.Comment }}]
 
@@ -1459,6 +1917,20 @@ const templateStringCrioImageSignaturePolicyDir = `# Root path for pod namespace
 
 `
 
+const templateStringCrioImageSignaturePolicies = `# The signature policies of the pods selected by their namespace, given as shell
+# patterns, keyed by the absolute path of the policy, like
+# { "/etc/crio/policies/production.json" = ["prod-*", "payments"] }. It allows to
+# verify the images of the production namespaces more strictly than the ones of the
+# development namespaces sharing the node. A namespace with a policy in
+# signature_policy_dir uses that one, and the image pulls and container creations
+# of a namespace matching several of them fail.
+{{ $.Comment }}signature_policies = {
+{{- $first := true }}{{- range $policy, $namespaces := .SignaturePolicies }}
+{{- if not $first }},{{ end }}{{- printf "%q = [" $policy }}
+{{- range $i, $namespace := $namespaces }}{{ if $i }}, {{ end }}{{ printf "%q" $namespace }}{{ end }}]{{- $first = false }}{{- end }}}
+
+`
+
 const templateStringCrioImageInsecureRegistries = `# List of registries to skip TLS verification for pulling images. Please
 # consider configuring the registries via /etc/containers/registries.conf before
 # changing them here.
@@ -1491,6 +1963,38 @@ const templateStringCrioImagePullProgressTimeout = `# The timeout for an image p
 
 `
 
+const templateStringCrioImageMaxParallelImagePulls = `# The maximum number of images pulled at the same time, the other pulls wait for
+# one of them to complete. Can be set to 0 to not limit the image pulls.
+{{ $.Comment }}max_parallel_image_pulls = {{ .MaxParallelImagePulls }}
+
+`
+
+const templateStringCrioImageMaxParallelLayerDownloads = `# The maximum number of layers an image pull downloads at the same time, so that
+# a large image does not hold all the bandwidth of the node. Can be set to 0 to
+# use the default of the containers/image library.
+{{ $.Comment }}max_parallel_layer_downloads = {{ .MaxParallelLayerDownloads }}
+
+`
+
+const templateStringCrioImageRegistryMaxParallelLayerDownloads = `# The maximum number of layers downloaded at the same time from a registry, across
+# all the image pulls from it, keyed by registry host name and optional port, like
+# { "registry.example.com:5000" = 8 }. It takes precedence over max_parallel_layer_downloads
+# for the pulls from the registry.
+{{ $.Comment }}registry_max_parallel_layer_downloads = {
+{{- $first := true }}{{- range $key, $value := .RegistryMaxParallelLayerDownloads }}
+{{- if not $first }},{{ end }}{{- printf "%q = %d" $key $value }}{{- $first = false }}{{- end }}}
+
+`
+
+const templateStringCrioImageRegistryMirrorHealthCheckInterval = `# The interval at which the health of the registry mirrors is checked. The image
+# pulls try the healthy mirrors first, and the ones which failed their last health
+# check after the others, instead of waiting for an unreachable mirror to time out.
+# Set to 0 to disable the health checks, the mirrors being tried in the order of
+# the registries configuration.
+{{ $.Comment }}registry_mirror_health_check_interval = "{{ .RegistryMirrorHealthCheckInterval }}"
+
+`
+
 const templateStringCrioNetwork = `# The crio.network table containers settings pertaining to the management of
 # CNI plugins.
 [crio.network]
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/cri-o/cri-o/pkg/annotations"
+)
+
+// tuningAnnotations are the pod annotations granting node-level tuning to the containers.
+var tuningAnnotations = []string{
+	annotations.CPULoadBalancingAnnotation,
+	annotations.CPUQuotaAnnotation,
+	annotations.IRQLoadBalancingAnnotation,
+	annotations.CPUCStatesAnnotation,
+	annotations.CPUFreqGovernorAnnotation,
+	annotations.CPUSharedAnnotation,
+	annotations.CPUInitAffinityAnnotation,
+	annotations.PacketSteeringAnnotation,
+	annotations.VFQueuesAnnotation,
+	annotations.ARFSAnnotation,
+	annotations.VFIRQAffinityAnnotation,
+	annotations.AFXDPAnnotation,
+	annotations.NAPIAffinityAnnotation,
+	annotations.InterruptCoalescingAnnotation,
+	annotations.QdiscAnnotation,
+	annotations.NetdevBudgetAnnotation,
+	annotations.GROAnnotation,
+	annotations.NetNSSysctlBundleAnnotation,
+}
+
+// TuningAnnotationPolicies restricts the pods allowed to use the tuning annotations, keyed by annotation.
+// The annotations without policy can be used by all the pods.
+type TuningAnnotationPolicies map[string]*TuningAnnotationPolicy
+
+// TuningAnnotationPolicy restricts the pods allowed to use a tuning annotation, which must either
+// run in one of the namespaces or have all the pod labels.
+type TuningAnnotationPolicy struct {
+	// Namespaces are the Kubernetes namespaces of the allowed pods, as shell patterns like "telco-*".
+	Namespaces []string `toml:"namespaces"`
+	// PodLabels are the labels selecting the allowed pods.
+	PodLabels map[string]string `toml:"pod_labels"`
+}
+
+func (p TuningAnnotationPolicies) Validate() error {
+	for annotation, policy := range p {
+		if !slices.Contains(tuningAnnotations, annotation) {
+			return fmt.Errorf("%q is not a tuning annotation, expected one of %s", annotation, strings.Join(tuningAnnotations, ", "))
+		}
+		if policy == nil {
+			continue
+		}
+		for _, namespace := range policy.Namespaces {
+			if _, err := path.Match(namespace, ""); err != nil {
+				return fmt.Errorf("invalid namespace pattern %q for annotation %q: %w", namespace, annotation, err)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckPod returns a *TuningAnnotationNotAllowedError if the pod of the namespace and labels uses tuning annotations
+// it is not allowed to. The annotations apply to the pod, or to one of its containers when suffixed with "/$ctrName".
+func (p TuningAnnotationPolicies) CheckPod(namespace string, labels, podAnnotations map[string]string) error {
+	var disallowed []string
+	for key := range podAnnotations {
+		annotation, _, _ := strings.Cut(key, "/")
+		policy, ok := p[annotation]
+		if !ok || policy.allows(namespace, labels) || slices.Contains(disallowed, annotation) {
+			continue
+		}
+		disallowed = append(disallowed, annotation)
+	}
+	if len(disallowed) == 0 {
+		return nil
+	}
+	slices.Sort(disallowed)
+	return &TuningAnnotationNotAllowedError{Namespace: namespace, Annotations: disallowed}
+}
+
+// TuningAnnotationNotAllowedError is returned when a pod uses tuning annotations it is not allowed to.
+type TuningAnnotationNotAllowedError struct {
+	Namespace   string
+	Annotations []string
+}
+
+func (e *TuningAnnotationNotAllowedError) Error() string {
+	return fmt.Sprintf("pod of namespace %q is not allowed to use the tuning annotations %s", e.Namespace, strings.Join(e.Annotations, ", "))
+}
+
+func (p *TuningAnnotationPolicy) allows(namespace string, labels map[string]string) bool {
+	if p == nil {
+		return false
+	}
+	for _, pattern := range p.Namespaces {
+		if matched, _ := path.Match(pattern, namespace); matched {
+			return true
+		}
+	}
+	if len(p.PodLabels) == 0 {
+		return false
+	}
+	for key, value := range p.PodLabels {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
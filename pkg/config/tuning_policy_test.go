@@ -0,0 +1,80 @@
+package config_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cri-o/cri-o/pkg/config"
+)
+
+// The actual test suite.
+var _ = t.Describe("TuningAnnotationPolicies", func() {
+	BeforeEach(beforeEach)
+
+	policies := config.TuningAnnotationPolicies{
+		"cpu-load-balancing.crio.io": {
+			Namespaces: []string{"telco-*", "kube-system"},
+			PodLabels:  map[string]string{"tuning.example.com/allowed": "true"},
+		},
+		"cpu-shared.crio.io": {},
+	}
+
+	It("should fail on annotations which are not tuning ones", func() {
+		err := config.TuningAnnotationPolicies{"io.kubernetes.cri-o.Devices": {}}.Validate()
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should fail on invalid namespace patterns", func() {
+		err := config.TuningAnnotationPolicies{
+			"cpu-quota.crio.io": {Namespaces: []string{"telco-["}},
+		}.Validate()
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should allow the pods of the namespaces or with the labels", func() {
+		Expect(policies.Validate()).To(Succeed())
+		annotations := map[string]string{"cpu-load-balancing.crio.io": "disable"}
+
+		Expect(policies.CheckPod("telco-ran", nil, annotations)).To(Succeed())
+		Expect(policies.CheckPod("kube-system", nil, annotations)).To(Succeed())
+		Expect(policies.CheckPod("default", map[string]string{"tuning.example.com/allowed": "true"}, annotations)).To(Succeed())
+		Expect(policies.CheckPod("default", map[string]string{"tuning.example.com/allowed": "false"}, annotations)).To(MatchError(
+			`pod of namespace "default" is not allowed to use the tuning annotations cpu-load-balancing.crio.io`,
+		))
+	})
+
+	It("should check the per-container annotations", func() {
+		annotations := map[string]string{
+			"cpu-shared.crio.io/ctr1":    "enable",
+			"cpu-shared.crio.io/ctr2":    "enable",
+			"cpu-quota.crio.io":          "disable",
+			"cpu-load-balancing.crio.io": "disable",
+		}
+
+		Expect(policies.CheckPod("default", nil, annotations)).To(MatchError(
+			`pod of namespace "default" is not allowed to use the tuning annotations cpu-load-balancing.crio.io, cpu-shared.crio.io`,
+		))
+	})
+
+	It("should be preserved by the template", func() {
+		sut.TuningAnnotationPolicies = policies
+		var wr bytes.Buffer
+		Expect(sut.WriteTemplate(false, &wr)).To(Succeed())
+		file := filepath.Join(GinkgoT().TempDir(), "crio.conf")
+		Expect(os.WriteFile(file, wr.Bytes(), 0o644)).To(Succeed())
+
+		cfg := defaultConfig()
+		Expect(cfg.UpdateFromFile(context.Background(), file)).To(Succeed())
+
+		Expect(cfg.TuningAnnotationPolicies).To(HaveLen(2))
+		Expect(cfg.TuningAnnotationPolicies["cpu-load-balancing.crio.io"]).To(Equal(policies["cpu-load-balancing.crio.io"]))
+		Expect(cfg.TuningAnnotationPolicies["cpu-shared.crio.io"].Namespaces).To(BeEmpty())
+	})
+})
@@ -0,0 +1,177 @@
+// Package cpumask converts between the CPU sets and the hexadecimal CPU masks of the kernel, like
+// the IRQ affinity masks of /proc/irq or the banned CPUs of irqbalance, with the exact same math as
+// the high-performance hooks of CRI-O.
+//
+// The kernel formats a mask as comma separated groups of 32 bits, the highest CPUs first, like
+// "00000000,00003003" for the CPUs 0-1,12-13. It does not accept a mask longer than the count of
+// CPUs of the node rounded up to the closest multiple of 32, which the formatting here respects.
+package cpumask
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"k8s.io/utils/cpuset"
+)
+
+// groupSize is the size in bytes of a comma separated group of a formatted mask.
+const groupSize = 4
+
+// Mask is a CPU mask holding a bit per CPU. Its index 0 holds the CPUs 0-7, whose lowest bit is the CPU 0.
+type Mask []byte
+
+// Parse parses a hexadecimal mask, with or without the commas separating its groups.
+func Parse(mask string) (Mask, error) {
+	for i := range len(mask) {
+		if mask[i] > unicode.MaxASCII {
+			return nil, fmt.Errorf("non ascii character detected: %s", mask)
+		}
+	}
+
+	s := strings.ReplaceAll(mask, ",", "")
+	if len(s)%2 != 0 {
+		// expect even number of chars
+		s = "0" + s
+	}
+	reversed, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(Mask, len(reversed))
+	for i, b := range reversed {
+		m[len(reversed)-i-1] = b
+	}
+	return m, nil
+}
+
+// ParseList parses a CPU list, like "0-3,8", into a mask.
+func ParseList(list string) (Mask, error) {
+	cpus, err := cpuset.Parse(list)
+	if err != nil {
+		return nil, err
+	}
+	return FromCPUSet(cpus), nil
+}
+
+// FromCPUSet returns the mask of the CPUs, which is as long as needed to hold the highest one.
+func FromCPUSet(cpus cpuset.CPUSet) Mask {
+	return Mask(nil).Set(cpus.List()...)
+}
+
+// String formats the mask like the kernel does, in groups of 32 bits separated by commas.
+func (m Mask) String() string {
+	padded := make([]byte, (len(m)+groupSize-1)/groupSize*groupSize)
+	if len(padded) == 0 {
+		padded = make([]byte, groupSize)
+	}
+	for i, b := range m {
+		padded[len(padded)-i-1] = b
+	}
+
+	groups := make([]string, 0, len(padded)/groupSize)
+	for i := 0; i < len(padded); i += groupSize {
+		groups = append(groups, hex.EncodeToString(padded[i:i+groupSize]))
+	}
+	return strings.Join(groups, ",")
+}
+
+// CPUSet returns the CPUs set in the mask.
+func (m Mask) CPUSet() cpuset.CPUSet {
+	cpus := []int{}
+	for i, b := range m {
+		for bit := range 8 {
+			if b&bitOf(bit) != 0 {
+				cpus = append(cpus, i*8+bit)
+			}
+		}
+	}
+	return cpuset.New(cpus...)
+}
+
+// List returns the CPUs set in the mask as a CPU list, like "0-3,8".
+func (m Mask) List() string {
+	return m.CPUSet().String()
+}
+
+// Set returns the mask with the CPUs set, grown as needed to hold them. Like append, the mask
+// gets modified in place if it is long enough already.
+func (m Mask) Set(cpus ...int) Mask {
+	m = m.grow(cpus)
+	for _, cpu := range cpus {
+		m[cpu/8] |= bitOf(cpu % 8)
+	}
+	return m
+}
+
+// Clear returns the mask with the CPUs cleared, grown as needed to hold them. Like append, the
+// mask gets modified in place if it is long enough already.
+func (m Mask) Clear(cpus ...int) Mask {
+	m = m.grow(cpus)
+	for _, cpu := range cpus {
+		m[cpu/8] &^= bitOf(cpu % 8)
+	}
+	return m
+}
+
+// Invert returns a mask of the same length with every bit of the mask inverted.
+func (m Mask) Invert() Mask {
+	inverted := make(Mask, len(m))
+	for i, b := range m {
+		inverted[i] = 0xff - b
+	}
+	return inverted
+}
+
+// IsAllSet returns whether the bits of every byte of the mask are set from the lowest one up, as in the
+// default IRQ affinity mask holding all the CPUs of the node.
+func (m Mask) IsAllSet() bool {
+	for _, b := range m {
+		if b&(b+1) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (m Mask) grow(cpus []int) Mask {
+	size := len(m)
+	for _, cpu := range cpus {
+		size = max(size, cpu/8+1)
+	}
+	if size == len(m) {
+		return m
+	}
+	grown := make(Mask, size)
+	copy(grown, m)
+	return grown
+}
+
+func bitOf(bit int) byte {
+	return byte(1 << bit)
+}
+
+// Update takes the CPU list whose bits need to change in the current mask, and returns the
+// updated mask with those CPUs set or cleared along with the inverted mask, both formatted.
+func Update(cpus, current string, set bool) (mask, inverted string, err error) {
+	cpuSet, err := cpuset.Parse(cpus)
+	if err != nil {
+		return cpus, "", err
+	}
+	currentMask, err := Parse(current)
+	if err != nil {
+		return cpus, "", err
+	}
+
+	invertedMask := currentMask.Invert()
+	if set {
+		currentMask = currentMask.Set(cpuSet.List()...)
+		invertedMask = invertedMask.Clear(cpuSet.List()...)
+	} else {
+		currentMask = currentMask.Clear(cpuSet.List()...)
+		invertedMask = invertedMask.Set(cpuSet.List()...)
+	}
+	return currentMask.String(), invertedMask.String(), nil
+}
@@ -0,0 +1,142 @@
+package cpumask_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/cpuset"
+
+	"github.com/cri-o/cri-o/pkg/cpumask"
+)
+
+var _ = Describe("CPUMask", func() {
+	Describe("Update", func() {
+		type Input struct {
+			cpus string
+			mask string
+			set  bool
+		}
+		type Expected struct {
+			mask    string
+			invMask string
+		}
+		type TestData struct {
+			input    Input
+			expected Expected
+		}
+
+		DescribeTable("testing cpu mask",
+			func(c TestData) {
+				mask, invMask, err := cpumask.Update(c.input.cpus, c.input.mask, c.input.set)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(mask).To(Equal(c.expected.mask))
+				Expect(invMask).To(Equal(c.expected.invMask))
+			},
+			Entry("clear a single bit that was one", TestData{
+				input:    Input{cpus: "0", mask: "0000,00003003", set: false},
+				expected: Expected{mask: "00000000,00003002", invMask: "0000ffff,ffffcffd"},
+			}),
+			Entry("set a single bit that was zero", TestData{
+				input:    Input{cpus: "4", mask: "0000,00003003", set: true},
+				expected: Expected{mask: "00000000,00003013", invMask: "0000ffff,ffffcfec"},
+			}),
+			Entry("clear a set of bits", TestData{
+				input:    Input{cpus: "4-13", mask: "ffff,ffffffff", set: false},
+				expected: Expected{mask: "0000ffff,ffffc00f", invMask: "00000000,00003ff0"},
+			}),
+			Entry("set a set of bits", TestData{
+				input:    Input{cpus: "4-13", mask: "ffff,ffffc00f", set: true},
+				expected: Expected{mask: "0000ffff,ffffffff", invMask: "00000000,00000000"},
+			}),
+			Entry("clear a single bit that was one when odd mask is present", TestData{
+				input:    Input{cpus: "9", mask: "fff", set: false},
+				expected: Expected{mask: "00000dff", invMask: "0000f200"},
+			}),
+			Entry("clear two bits from a short mask", TestData{
+				input:    Input{cpus: "2-3", mask: "ffffff", set: false},
+				expected: Expected{mask: "00fffff3", invMask: "0000000c"},
+			}),
+			Entry("set a bit beyond the current mask", TestData{
+				input:    Input{cpus: "40", mask: "00000001", set: true},
+				expected: Expected{mask: "00000100,00000001", invMask: "00000000,fffffffe"},
+			}),
+		)
+
+		It("should fail on invalid input", func() {
+			_, _, err := cpumask.Update("0-", "ff", true)
+			Expect(err).To(HaveOccurred())
+			_, _, err = cpumask.Update("0", "fg", true)
+			Expect(err).To(HaveOccurred())
+			_, _, err = cpumask.Update("0", "ff,ä", true)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Parse", func() {
+		It("should round-trip the masks of any CPU count", func() {
+			for _, mask := range []string{
+				"00000000",
+				"00003003",
+				"0000ffff,ffffc00f",
+				"80000000,00000000,00000000,00000001",
+			} {
+				m, err := cpumask.Parse(mask)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(m.String()).To(Equal(mask))
+			}
+		})
+
+		It("should pad the short masks to groups of 32 bits", func() {
+			m, err := cpumask.Parse("fff")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(m.String()).To(Equal("00000fff"))
+			Expect(cpumask.Mask(nil).String()).To(Equal("00000000"))
+		})
+
+		It("should report the CPUs of the mask", func() {
+			m, err := cpumask.Parse("00000100,00003003")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(m.List()).To(Equal("0-1,12-13,40"))
+		})
+	})
+
+	Describe("ParseList", func() {
+		It("should round-trip the CPU lists", func() {
+			for _, list := range []string{"", "0", "0-3,8", "1,63-64,127", "1023"} {
+				m, err := cpumask.ParseList(list)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(m.List()).To(Equal(list))
+
+				parsed, err := cpumask.Parse(m.String())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(parsed.List()).To(Equal(list))
+			}
+		})
+
+		It("should size the mask after the highest CPU", func() {
+			Expect(cpumask.FromCPUSet(cpuset.New(0, 1, 12, 13)).String()).To(Equal("00003003"))
+			Expect(cpumask.FromCPUSet(cpuset.New(32)).String()).To(Equal("00000001,00000000"))
+		})
+	})
+
+	Describe("Invert", func() {
+		It("should invert every bit of the mask", func() {
+			m, err := cpumask.Parse("ffff,ffffc00f")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(m.Invert().String()).To(Equal("00000000,00003ff0"))
+			Expect(m.Invert().Invert()).To(Equal(m))
+		})
+	})
+
+	Describe("IsAllSet", func() {
+		DescribeTable("should report the masks of all the CPUs",
+			func(mask string, expected bool) {
+				m, err := cpumask.Parse(mask)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(m.IsAllSet()).To(Equal(expected))
+			},
+			Entry("all CPUs", "ffffffff,ffffffff", true),
+			Entry("an odd count of CPUs", "3f,ffffffff", true),
+			Entry("a cleared CPU", "ffffffff,fffffffe", false),
+		)
+	})
+})
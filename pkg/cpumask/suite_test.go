@@ -0,0 +1,13 @@
+package cpumask_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestCPUMask(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CPUMask")
+}
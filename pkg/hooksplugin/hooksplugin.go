@@ -0,0 +1,317 @@
+// Package hooksplugin defines the gRPC protocol used by CRI-O to run runtime handler hooks
+// implemented out of process. The built-in high-performance hooks are the reference
+// implementation of the hook stages exposed here.
+//
+// The protocol relies on protobuf well-known types only, so plugins do not need generated code:
+// every method takes a google.protobuf.Struct holding the JSON representation of a Request.
+// PreCreate returns a google.protobuf.Struct holding the JSON representation of a
+// PreCreateResponse, the other methods return a google.protobuf.Empty.
+package hooksplugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	// ServiceName is the name of the gRPC service implemented by the plugins.
+	ServiceName = "crio.runtimehandlerhooks.v1alpha1.RuntimeHandlerHooks"
+
+	// PreCreateMethod is run once the spec of the container is complete, before the runtime creates it.
+	PreCreateMethod = "PreCreate"
+
+	// PreStartMethod is run after the container got created, before it gets started.
+	PreStartMethod = "PreStart"
+
+	// PreStopMethod is run before the container gets stopped.
+	PreStopMethod = "PreStop"
+
+	// PostStopMethod is run after the container stopped, including when it exited on its own.
+	PostStopMethod = "PostStop"
+
+	// PreUpdateMethod is run before the resources of the container get updated.
+	PreUpdateMethod = "PreUpdate"
+
+	// PostUpdateMethod is run after the resources of the container got updated.
+	PostUpdateMethod = "PostUpdate"
+
+	// PreCheckpointMethod is run before the container gets checkpointed.
+	PreCheckpointMethod = "PreCheckpoint"
+
+	// PostRestoreMethod is run after the container got restored from a checkpoint.
+	PostRestoreMethod = "PostRestore"
+)
+
+// Request describes the container a hook is run for.
+type Request struct {
+	ContainerID      string `json:"containerId"`
+	ContainerName    string `json:"containerName"`
+	SandboxID        string `json:"sandboxId"`
+	SandboxName      string `json:"sandboxName"`
+	SandboxNamespace string `json:"sandboxNamespace"`
+	// CgroupParent is the cgroup parent of the sandbox.
+	CgroupParent string `json:"cgroupParent"`
+	// Pid is the pid of the container init process, 0 if it is not running.
+	Pid int `json:"pid,omitempty"`
+	// CPUs is the cpuset of the container, in the Linux CPU list format.
+	CPUs string `json:"cpus,omitempty"`
+	// Annotations are the annotations of the sandbox.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Resources are the resources the container gets updated to in PreUpdate,
+	// and the former resources of the container in PostUpdate.
+	Resources *rspec.LinuxResources `json:"resources,omitempty"`
+}
+
+// PreCreateResponse holds the changes a plugin contributes to the spec of the container.
+type PreCreateResponse struct {
+	// Mounts are added to the container, replacing the mounts with the same destination.
+	Mounts []rspec.Mount `json:"mounts,omitempty"`
+	// Rlimits are set on the container process, replacing the rlimits of the same type.
+	// The limits are carried as protobuf numbers, which are exact up to 2^53.
+	Rlimits []rspec.POSIXRlimit `json:"rlimits,omitempty"`
+}
+
+// Hooks is implemented by the plugins.
+type Hooks interface {
+	PreStart(ctx context.Context, req *Request) error
+	PreStop(ctx context.Context, req *Request) error
+	PostStop(ctx context.Context, req *Request) error
+}
+
+// PreCreateHooks is implemented by the plugins which contribute to the spec of the containers.
+type PreCreateHooks interface {
+	PreCreate(ctx context.Context, req *Request) (*PreCreateResponse, error)
+}
+
+// UpdateHooks is implemented by the plugins which re-apply their tuning when the resources
+// of the containers get updated.
+type UpdateHooks interface {
+	PreUpdate(ctx context.Context, req *Request) error
+	PostUpdate(ctx context.Context, req *Request) error
+}
+
+// CheckpointHooks is implemented by the plugins which re-apply their tuning when the containers
+// get restored from a checkpoint. The restored containers do not run the PreStart hook.
+type CheckpointHooks interface {
+	PreCheckpoint(ctx context.Context, req *Request) error
+	PostRestore(ctx context.Context, req *Request) error
+}
+
+// Register registers the hooks as the implementation of the service on the gRPC server.
+// The PreCreate, PreUpdate and PostUpdate, and PreCheckpoint and PostRestore methods are
+// only served if the hooks implement PreCreateHooks, UpdateHooks and CheckpointHooks respectively.
+func Register(s *grpc.Server, hooks Hooks) {
+	methods := []grpc.MethodDesc{
+		methodDesc(PreStartMethod, emptyResponse(Hooks.PreStart)),
+		methodDesc(PreStopMethod, emptyResponse(Hooks.PreStop)),
+		methodDesc(PostStopMethod, emptyResponse(Hooks.PostStop)),
+	}
+	if _, ok := hooks.(PreCreateHooks); ok {
+		methods = append(methods, methodDesc(PreCreateMethod, preCreate))
+	}
+	if _, ok := hooks.(UpdateHooks); ok {
+		methods = append(methods,
+			methodDesc(PreUpdateMethod, emptyResponse(func(h Hooks, ctx context.Context, req *Request) error {
+				return h.(UpdateHooks).PreUpdate(ctx, req)
+			})),
+			methodDesc(PostUpdateMethod, emptyResponse(func(h Hooks, ctx context.Context, req *Request) error {
+				return h.(UpdateHooks).PostUpdate(ctx, req)
+			})),
+		)
+	}
+	if _, ok := hooks.(CheckpointHooks); ok {
+		methods = append(methods,
+			methodDesc(PreCheckpointMethod, emptyResponse(func(h Hooks, ctx context.Context, req *Request) error {
+				return h.(CheckpointHooks).PreCheckpoint(ctx, req)
+			})),
+			methodDesc(PostRestoreMethod, emptyResponse(func(h Hooks, ctx context.Context, req *Request) error {
+				return h.(CheckpointHooks).PostRestore(ctx, req)
+			})),
+		)
+	}
+	s.RegisterService(&grpc.ServiceDesc{
+		ServiceName: ServiceName,
+		HandlerType: (*Hooks)(nil),
+		Methods:     methods,
+	}, hooks)
+}
+
+func emptyResponse(call func(Hooks, context.Context, *Request) error) func(Hooks, context.Context, *Request) (proto.Message, error) {
+	return func(hooks Hooks, ctx context.Context, req *Request) (proto.Message, error) {
+		if err := call(hooks, ctx, req); err != nil {
+			return nil, err
+		}
+		return &emptypb.Empty{}, nil
+	}
+}
+
+func preCreate(hooks Hooks, ctx context.Context, req *Request) (proto.Message, error) {
+	resp, err := hooks.(PreCreateHooks).PreCreate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		resp = &PreCreateResponse{}
+	}
+	return toProto(resp)
+}
+
+func methodDesc(name string, call func(Hooks, context.Context, *Request) (proto.Message, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			in := &structpb.Struct{}
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			handler := func(ctx context.Context, in any) (any, error) {
+				req, err := requestFromProto(in.(*structpb.Struct))
+				if err != nil {
+					return nil, status.Error(codes.InvalidArgument, err.Error())
+				}
+				return call(srv.(Hooks), ctx, req)
+			}
+			if interceptor == nil {
+				return handler(ctx, in)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod(name)}
+			return interceptor(ctx, in, info, handler)
+		},
+	}
+}
+
+// Client runs the hooks of a plugin.
+type Client struct {
+	conn *grpc.ClientConn
+}
+
+// NewClient returns a client of the plugin listening on the unix socket at address.
+// The connection is established lazily, on the first hook run.
+func NewClient(address string) (*Client, error) {
+	conn, err := grpc.NewClient("unix://"+address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("create client of hooks plugin %q: %w", address, err)
+	}
+	return &Client{conn: conn}, nil
+}
+
+// PreCreate runs the PreCreate hook of the plugin.
+// Plugins which do not implement PreCreateHooks return an empty response.
+func (c *Client) PreCreate(ctx context.Context, req *Request) (*PreCreateResponse, error) {
+	in, err := toProto(req)
+	if err != nil {
+		return nil, err
+	}
+	out := &structpb.Struct{}
+	if err := c.conn.Invoke(ctx, fullMethod(PreCreateMethod), in, out); err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			return &PreCreateResponse{}, nil
+		}
+		return nil, fmt.Errorf("run %s hook of plugin: %w", PreCreateMethod, err)
+	}
+	resp := &PreCreateResponse{}
+	if err := fromProto(out, resp); err != nil {
+		return nil, fmt.Errorf("decode %s response of plugin: %w", PreCreateMethod, err)
+	}
+	return resp, nil
+}
+
+// PreStart runs the PreStart hook of the plugin.
+func (c *Client) PreStart(ctx context.Context, req *Request) error {
+	return c.invoke(ctx, PreStartMethod, req)
+}
+
+// PreStop runs the PreStop hook of the plugin.
+func (c *Client) PreStop(ctx context.Context, req *Request) error {
+	return c.invoke(ctx, PreStopMethod, req)
+}
+
+// PostStop runs the PostStop hook of the plugin.
+func (c *Client) PostStop(ctx context.Context, req *Request) error {
+	return c.invoke(ctx, PostStopMethod, req)
+}
+
+// PreUpdate runs the PreUpdate hook of the plugin, if it implements UpdateHooks.
+func (c *Client) PreUpdate(ctx context.Context, req *Request) error {
+	return ignoreUnimplemented(c.invoke(ctx, PreUpdateMethod, req))
+}
+
+// PostUpdate runs the PostUpdate hook of the plugin, if it implements UpdateHooks.
+func (c *Client) PostUpdate(ctx context.Context, req *Request) error {
+	return ignoreUnimplemented(c.invoke(ctx, PostUpdateMethod, req))
+}
+
+// PreCheckpoint runs the PreCheckpoint hook of the plugin, if it implements CheckpointHooks.
+func (c *Client) PreCheckpoint(ctx context.Context, req *Request) error {
+	return ignoreUnimplemented(c.invoke(ctx, PreCheckpointMethod, req))
+}
+
+// PostRestore runs the PostRestore hook of the plugin, if it implements CheckpointHooks.
+func (c *Client) PostRestore(ctx context.Context, req *Request) error {
+	return ignoreUnimplemented(c.invoke(ctx, PostRestoreMethod, req))
+}
+
+func ignoreUnimplemented(err error) error {
+	if status.Code(err) == codes.Unimplemented {
+		return nil
+	}
+	return err
+}
+
+// Close closes the connection to the plugin.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Client) invoke(ctx context.Context, method string, req *Request) error {
+	in, err := toProto(req)
+	if err != nil {
+		return err
+	}
+	if err := c.conn.Invoke(ctx, fullMethod(method), in, &emptypb.Empty{}); err != nil {
+		return fmt.Errorf("run %s hook of plugin: %w", method, err)
+	}
+	return nil
+}
+
+func fullMethod(name string) string {
+	return "/" + ServiceName + "/" + name
+}
+
+func toProto(v any) (*structpb.Struct, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]any{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return structpb.NewStruct(fields)
+}
+
+func fromProto(in *structpb.Struct, v any) error {
+	data, err := json.Marshal(in.AsMap())
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func requestFromProto(in *structpb.Struct) (*Request, error) {
+	req := &Request{}
+	if err := fromProto(in, req); err != nil {
+		return nil, fmt.Errorf("decode request: %w", err)
+	}
+	return req, nil
+}
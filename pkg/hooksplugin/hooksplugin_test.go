@@ -0,0 +1,202 @@
+package hooksplugin_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rspec "github.com/opencontainers/runtime-spec/specs-go"
+	"google.golang.org/grpc"
+
+	"github.com/cri-o/cri-o/pkg/hooksplugin"
+)
+
+type recordingHooks struct {
+	calls []string
+	reqs  []*hooksplugin.Request
+	err   error
+}
+
+func (h *recordingHooks) record(method string, req *hooksplugin.Request) error {
+	h.calls = append(h.calls, method)
+	h.reqs = append(h.reqs, req)
+	return h.err
+}
+
+func (h *recordingHooks) PreStart(_ context.Context, req *hooksplugin.Request) error {
+	return h.record(hooksplugin.PreStartMethod, req)
+}
+
+func (h *recordingHooks) PreStop(_ context.Context, req *hooksplugin.Request) error {
+	return h.record(hooksplugin.PreStopMethod, req)
+}
+
+func (h *recordingHooks) PostStop(_ context.Context, req *hooksplugin.Request) error {
+	return h.record(hooksplugin.PostStopMethod, req)
+}
+
+type preCreateHooks struct {
+	recordingHooks
+	resp *hooksplugin.PreCreateResponse
+}
+
+func (h *preCreateHooks) PreCreate(_ context.Context, req *hooksplugin.Request) (*hooksplugin.PreCreateResponse, error) {
+	return h.resp, h.record(hooksplugin.PreCreateMethod, req)
+}
+
+type updateHooks struct {
+	recordingHooks
+}
+
+func (h *updateHooks) PreUpdate(_ context.Context, req *hooksplugin.Request) error {
+	return h.record(hooksplugin.PreUpdateMethod, req)
+}
+
+func (h *updateHooks) PostUpdate(_ context.Context, req *hooksplugin.Request) error {
+	return h.record(hooksplugin.PostUpdateMethod, req)
+}
+
+type checkpointHooks struct {
+	recordingHooks
+}
+
+func (h *checkpointHooks) PreCheckpoint(_ context.Context, req *hooksplugin.Request) error {
+	return h.record(hooksplugin.PreCheckpointMethod, req)
+}
+
+func (h *checkpointHooks) PostRestore(_ context.Context, req *hooksplugin.Request) error {
+	return h.record(hooksplugin.PostRestoreMethod, req)
+}
+
+var _ = Describe("Client", func() {
+	var (
+		hooks  *recordingHooks
+		server *grpc.Server
+		client *hooksplugin.Client
+	)
+
+	req := &hooksplugin.Request{
+		ContainerID:   "ctr",
+		ContainerName: "name",
+		SandboxID:     "sandbox",
+		Pid:           42,
+		CPUs:          "1-2",
+		Annotations:   map[string]string{"key": "value"},
+	}
+
+	serve := func(plugin hooksplugin.Hooks) {
+		address := filepath.Join(GinkgoT().TempDir(), "plugin.sock")
+		listener, err := net.Listen("unix", address)
+		Expect(err).ToNot(HaveOccurred())
+
+		server = grpc.NewServer()
+		hooksplugin.Register(server, plugin)
+		go func() {
+			_ = server.Serve(listener)
+		}()
+
+		client, err = hooksplugin.NewClient(address)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		hooks = &recordingHooks{}
+		serve(hooks)
+	})
+
+	AfterEach(func() {
+		Expect(client.Close()).To(Succeed())
+		server.Stop()
+	})
+
+	It("should run the hooks of the plugin", func() {
+		Expect(client.PreStart(context.Background(), req)).To(Succeed())
+		Expect(client.PreStop(context.Background(), req)).To(Succeed())
+		Expect(client.PostStop(context.Background(), req)).To(Succeed())
+
+		Expect(hooks.calls).To(Equal([]string{
+			hooksplugin.PreStartMethod,
+			hooksplugin.PreStopMethod,
+			hooksplugin.PostStopMethod,
+		}))
+		Expect(hooks.reqs[0]).To(Equal(req))
+	})
+
+	It("should return the errors of the plugin", func() {
+		hooks.err = errors.New("tuning failed")
+
+		err := client.PreStart(context.Background(), req)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("tuning failed"))
+	})
+
+	It("should return an empty PreCreate response if the plugin does not implement it", func() {
+		resp, err := client.PreCreate(context.Background(), req)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp).To(Equal(&hooksplugin.PreCreateResponse{}))
+		Expect(hooks.calls).To(BeEmpty())
+	})
+
+	It("should return the spec changes of the PreCreate hook", func() {
+		Expect(client.Close()).To(Succeed())
+		server.Stop()
+		plugin := &preCreateHooks{resp: &hooksplugin.PreCreateResponse{
+			Mounts: []rspec.Mount{{
+				Destination: "/dev/hugepages",
+				Type:        "hugetlbfs",
+				Source:      "nodev",
+				Options:     []string{"rw"},
+			}},
+			Rlimits: []rspec.POSIXRlimit{{Type: "RLIMIT_MEMLOCK", Hard: 1 << 30, Soft: 1 << 20}},
+		}}
+		serve(plugin)
+
+		resp, err := client.PreCreate(context.Background(), req)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp).To(Equal(plugin.resp))
+		Expect(plugin.calls).To(Equal([]string{hooksplugin.PreCreateMethod}))
+		Expect(plugin.reqs[0]).To(Equal(req))
+	})
+	It("should ignore the optional hooks the plugin does not implement", func() {
+		Expect(client.PreUpdate(context.Background(), req)).To(Succeed())
+		Expect(client.PostUpdate(context.Background(), req)).To(Succeed())
+		Expect(client.PreCheckpoint(context.Background(), req)).To(Succeed())
+		Expect(client.PostRestore(context.Background(), req)).To(Succeed())
+
+		Expect(hooks.calls).To(BeEmpty())
+	})
+
+	It("should pass the resources to the update hooks", func() {
+		Expect(client.Close()).To(Succeed())
+		server.Stop()
+		plugin := &updateHooks{}
+		serve(plugin)
+		shares := uint64(1024)
+		update := *req
+		update.Resources = &rspec.LinuxResources{CPU: &rspec.LinuxCPU{Cpus: "3-4", Shares: &shares}}
+
+		Expect(client.PreUpdate(context.Background(), &update)).To(Succeed())
+		Expect(client.PostUpdate(context.Background(), &update)).To(Succeed())
+
+		Expect(plugin.calls).To(Equal([]string{hooksplugin.PreUpdateMethod, hooksplugin.PostUpdateMethod}))
+		Expect(plugin.reqs[0]).To(Equal(&update))
+	})
+	It("should run the checkpoint hooks of the plugin", func() {
+		Expect(client.Close()).To(Succeed())
+		server.Stop()
+		plugin := &checkpointHooks{}
+		serve(plugin)
+
+		Expect(client.PreCheckpoint(context.Background(), req)).To(Succeed())
+		Expect(client.PostRestore(context.Background(), req)).To(Succeed())
+
+		Expect(plugin.calls).To(Equal([]string{hooksplugin.PreCheckpointMethod, hooksplugin.PostRestoreMethod}))
+		Expect(plugin.reqs[1]).To(Equal(req))
+	})
+})
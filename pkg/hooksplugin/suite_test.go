@@ -0,0 +1,13 @@
+package hooksplugin_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestHooksPlugin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "HooksPlugin")
+}
@@ -1,8 +1,6 @@
 package types
 
-import (
-	"github.com/containers/storage/pkg/idtools"
-)
+import "github.com/containers/storage/pkg/idtools"
 
 // ContainerInfo stores information about containers.
 type ContainerInfo struct {
@@ -20,6 +18,57 @@ type ContainerInfo struct {
 	IPs             []string          `json:"ip_addresses"`
 }
 
+// ContainerTuning stores the high-performance tuning in effect for a container.
+type ContainerTuning struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// PodName and PodNamespace identify the pod of the container.
+	PodName      string         `json:"pod_name"`
+	PodNamespace string         `json:"pod_namespace"`
+	Tuning       *TuningDetails `json:"tuning"`
+	// Verified is true if all the tuned files currently hold the tuning of the container.
+	Verified bool `json:"verified"`
+	// Ineffective are the tuned files which do not hold the tuning of the container anymore.
+	Ineffective []string `json:"ineffective,omitempty"`
+	// VerificationError is the error preventing the verification of the tuning, if any.
+	VerificationError string `json:"verification_error,omitempty"`
+}
+
+// TuningDetails describes the tuning applied to a running container, as reported in the verbose info of its status.
+type TuningDetails struct {
+	// CPUs are the exclusive CPUs of the container.
+	CPUs                     string `json:"cpus,omitempty"`
+	SharedCPUs               bool   `json:"sharedCPUs"`
+	CPULoadBalancingDisabled bool   `json:"cpuLoadBalancingDisabled"`
+	// Partition is the state of the isolated partition of the container cgroup on cgroup v2, like "isolated".
+	Partition string `json:"partition,omitempty"`
+	// IRQBannedCPUs are the CPUs of the container removed from the IRQ affinity mask.
+	IRQBannedCPUs    string `json:"irqBannedCPUs,omitempty"`
+	CPUQuotaDisabled bool   `json:"cpuQuotaDisabled"`
+	FreqGovernor     string `json:"freqGovernor,omitempty"`
+	// ResumeLatency is the PM QoS resume latency of the container CPUs in microseconds, "n/a" with all the c-states disabled.
+	ResumeLatency string `json:"resumeLatency,omitempty"`
+	// Files are the files written to tune the container.
+	Files []TunedFile `json:"files,omitempty"`
+}
+
+// TunedFile is a file of the node written to tune a container.
+type TunedFile struct {
+	Path string `json:"path"`
+	// Original is the value of the file restored once the container stops.
+	Original string `json:"original"`
+	Value    string `json:"value"`
+}
+
+// TuningFilter selects the containers whose high-performance tuning gets reported.
+// The empty fields do not filter the containers.
+type TuningFilter struct {
+	PodName      string
+	PodNamespace string
+	// CPUs selects the containers with any of these exclusive CPUs, like "2-3,6".
+	CPUs string
+}
+
 // IDMappings specifies the ID mappings used for containers.
 type IDMappings struct {
 	Uids []idtools.IDMap `json:"uids"`
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	metadata "github.com/checkpoint-restore/checkpointctl/lib"
 	"google.golang.org/grpc/codes"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/cri-o/cri-o/internal/lib"
 	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/internal/runtimehandlerhooks"
 )
 
 // CheckpointContainer checkpoints a container.
@@ -19,21 +21,39 @@ func (s *Server) CheckpointContainer(ctx context.Context, req *types.CheckpointC
 		return nil, errors.New("checkpoint/restore support not available")
 	}
 
-	_, err := s.GetContainerFromShortID(ctx, req.ContainerId)
+	c, err := s.GetContainerFromShortID(ctx, req.ContainerId)
 	if err != nil {
 		return nil, status.Errorf(codes.NotFound, "could not find container %q: %v", req.ContainerId, err)
 	}
 
+	sb := s.getSandbox(ctx, c.Sandbox())
+	hooks, err := runtimehandlerhooks.GetRuntimeHandlerHooks(ctx, &s.config, sb.RuntimeHandler(), sb.Annotations())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get runtime handler %q hooks", sb.RuntimeHandler())
+	}
+
+	if hooks != nil {
+		if err := hooks.PreCheckpoint(ctx, c, sb); err != nil {
+			return nil, fmt.Errorf("failed to run pre-checkpoint hook for container %q: %w", c.ID(), err)
+		}
+	}
+
 	log.Infof(ctx, "Checkpointing container: %s", req.ContainerId)
 	config := &metadata.ContainerConfig{
 		ID: req.ContainerId,
 	}
 	opts := &lib.ContainerCheckpointOptions{
-		TargetFile: req.Location,
 		// For the forensic container checkpointing use case we
 		// keep the container running after checkpointing it.
 		KeepRunning: true,
 	}
+	// A location with a transport, like docker://quay.io/crio/checkpoint:latest,
+	// is the image to write the checkpoint to instead of an archive.
+	if lib.IsCheckpointImage(req.Location) {
+		opts.TargetImage = req.Location
+	} else {
+		opts.TargetFile = req.Location
+	}
 
 	_, err = s.ContainerServer.ContainerCheckpoint(ctx, config, opts)
 	if err != nil {
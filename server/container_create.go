@@ -23,6 +23,7 @@ import (
 	"github.com/cri-o/cri-o/internal/log"
 	"github.com/cri-o/cri-o/internal/oci"
 	"github.com/cri-o/cri-o/internal/resourcestore"
+	"github.com/cri-o/cri-o/internal/runtimehandlerhooks"
 	"github.com/cri-o/cri-o/internal/storage"
 	"github.com/cri-o/cri-o/pkg/config"
 	"github.com/cri-o/cri-o/utils"
@@ -419,6 +420,17 @@ func (s *Server) CreateContainer(ctx context.Context, req *types.CreateContainer
 		return nil, fmt.Errorf("CreateContainer failed as the sandbox was stopped: %s", sb.ID())
 	}
 
+	if highPerformance := s.config.HighPerformanceSettingsFor(sb.RuntimeHandler()); highPerformance.FeatureEnabled(config.HighPerformanceFeatureSharedCPUs) {
+		if err := runtimehandlerhooks.CheckSharedCPUsConfigured(sb.Annotations(), req.Config.GetMetadata().GetName(), highPerformance.SharedCPUSet); err != nil {
+			return nil, tuningAnnotationsStatus(err)
+		}
+	}
+
+	// The CPUs of the container are known from now on, check that the node supports their requested tuning.
+	if err := runtimehandlerhooks.ValidateHighPerformanceAnnotations(&s.config, sb.RuntimeHandler(), sb.Annotations(), req.GetConfig().GetLinux().GetResources().GetCpusetCpus()); err != nil {
+		return nil, tuningAnnotationsStatus(err)
+	}
+
 	ctr, err := container.New()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create container: %w", err)
@@ -21,6 +21,7 @@ import (
 	"github.com/containers/storage/pkg/unshare"
 	securejoin "github.com/cyphar/filepath-securejoin"
 	"github.com/intel/goresctrl/pkg/blockio"
+	goresctrlrdt "github.com/intel/goresctrl/pkg/rdt"
 	rspec "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/opencontainers/runtime-tools/generate"
 	"golang.org/x/sys/unix"
@@ -39,6 +40,7 @@ import (
 	"github.com/cri-o/cri-o/internal/storage"
 	"github.com/cri-o/cri-o/internal/storage/references"
 	crioann "github.com/cri-o/cri-o/pkg/annotations"
+	"github.com/cri-o/cri-o/server/metrics"
 )
 
 const (
@@ -539,7 +541,7 @@ func (s *Server) createSandboxContainer(ctx context.Context, ctr ctrfactory.Cont
 	if !ctr.Privileged() {
 		notifier, ref, err := s.config.Seccomp().Setup(
 			ctx,
-			s.config.SystemContext,
+			s.seccompProfileContext(ctx, ctr.Config().Metadata.Name, sb.Annotations(), imgResult.Annotations),
 			s.seccompNotifierChan,
 			containerID,
 			ctr.Config().Metadata.Name,
@@ -560,6 +562,13 @@ func (s *Server) createSandboxContainer(ctx context.Context, ctr ctrfactory.Cont
 	// Get RDT class
 	rdtClass, err := s.Config().Rdt().ContainerClassFromAnnotations(metadata.Name, containerConfig.Annotations, sb.Annotations())
 	if err != nil {
+		if !node.HasResctrl() {
+			annotation := goresctrlrdt.RdtPodAnnotation
+			if _, ok := containerConfig.Annotations[goresctrlrdt.RdtContainerAnnotation]; ok {
+				annotation = goresctrlrdt.RdtContainerAnnotation
+			}
+			metrics.Instance().MetricTuningUnfulfilledAnnotationsInc(annotation, runtimehandlerhooks.ReasonResctrlNotMounted)
+		}
 		return nil, err
 	}
 	if rdtClass != "" {
@@ -800,6 +809,15 @@ func (s *Server) createSandboxContainer(ctx context.Context, ctr ctrfactory.Cont
 		return nil, fmt.Errorf("failed to get runtime handler %q hooks", sb.RuntimeHandler())
 	}
 
+	// The runtime handler hooks run before NRI, so that NRI plugins observe the adjustments
+	// done by the hooks, like the cpuset environment, and can veto or extend them.
+	if hooks != nil {
+		if err := hooks.PreCreate(ctx, specgen, sb, ociContainer); err != nil {
+			return nil, fmt.Errorf("failed to run pre-create hook for container %q: %w", ociContainer.ID(), err)
+		}
+		ociContainer.ApplySpecPatches(specgen.Config)
+	}
+
 	if err := s.nri.createContainer(ctx, specgen, sb, ociContainer); err != nil {
 		return nil, err
 	}
@@ -810,12 +828,6 @@ func (s *Server) createSandboxContainer(ctx context.Context, ctr ctrfactory.Cont
 		}
 	}()
 
-	if hooks != nil {
-		if err := hooks.PreCreate(ctx, specgen, sb, ociContainer); err != nil {
-			return nil, fmt.Errorf("failed to run pre-create hook for container %q: %w", ociContainer.ID(), err)
-		}
-	}
-
 	if emptyDirVolName, ok := sb.Annotations()[crioann.LinkLogsAnnotation]; ok {
 		if err := linklogs.LinkContainerLogs(ctx, sb.Labels()[kubeletTypes.KubernetesPodUIDLabel], emptyDirVolName, ctr.ID(), containerConfig.Metadata); err != nil {
 			log.Warnf(ctx, "Failed to link container logs: %v", err)
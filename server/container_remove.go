@@ -16,6 +16,7 @@ import (
 	"github.com/cri-o/cri-o/internal/lib/sandbox"
 	"github.com/cri-o/cri-o/internal/log"
 	"github.com/cri-o/cri-o/internal/oci"
+	"github.com/cri-o/cri-o/internal/runtimehandlerhooks"
 )
 
 // RemoveContainer removes the container. If the container is running, the container
@@ -88,6 +89,7 @@ func (s *Server) removeContainerInPod(ctx context.Context, sb *sandbox.Sandbox,
 		return fmt.Errorf("failed to delete container %s in pod sandbox %s from index: %w", c.Name(), sb.ID(), err)
 	}
 	sb.RemoveContainer(ctx, c)
+	runtimehandlerhooks.ForgetTuningStatus(c.ID())
 
 	return nil
 }
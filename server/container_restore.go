@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	metadata "github.com/checkpoint-restore/checkpointctl/lib"
+	storagetypes "github.com/containers/storage"
 	"github.com/containers/storage/pkg/archive"
 	spec "github.com/opencontainers/runtime-spec/specs-go"
 	types "k8s.io/cri-api/pkg/apis/runtime/v1"
@@ -18,6 +19,7 @@ import (
 	"github.com/cri-o/cri-o/internal/lib/sandbox"
 	"github.com/cri-o/cri-o/internal/log"
 	"github.com/cri-o/cri-o/internal/storage"
+	"github.com/cri-o/cri-o/internal/storage/references"
 	"github.com/cri-o/cri-o/pkg/annotations"
 )
 
@@ -165,34 +167,19 @@ func (s *Server) CRImportCheckpoint(
 		return "", fmt.Errorf("failed to read %q: %w", annotations.Annotations, err)
 	}
 
+	// The annotations of the kubelet describe the container it creates, like its hash, which
+	// needs to be updated or Kubernetes thinks the container needs to be restarted, or its
+	// restart count, and take precedence over the ones of the checkpointed container.
+	for key, value := range createAnnotations {
+		originalAnnotations[key] = value
+	}
+
 	if sandboxUID != "" {
 		if _, ok := originalAnnotations[kubetypes.KubernetesPodUIDLabel]; ok {
 			originalAnnotations[kubetypes.KubernetesPodUIDLabel] = sandboxUID
 		}
 	}
 
-	if createAnnotations != nil {
-		// The hash also needs to be update or Kubernetes thinks the container needs to be restarted
-		_, ok1 := createAnnotations["io.kubernetes.container.hash"]
-		_, ok2 := originalAnnotations["io.kubernetes.container.hash"]
-
-		if ok1 && ok2 {
-			originalAnnotations["io.kubernetes.container.hash"] = createAnnotations["io.kubernetes.container.hash"]
-		}
-	}
-
-	stopMutex := sb.StopMutex()
-	stopMutex.RLock()
-	defer stopMutex.RUnlock()
-	if sb.Stopped() {
-		return "", fmt.Errorf("CreateContainer failed as the sandbox was stopped: %s", sb.ID())
-	}
-
-	ctr, err := container.New()
-	if err != nil {
-		return "", fmt.Errorf("failed to create container: %w", err)
-	}
-
 	// Newer checkpoints archives have RootfsImageRef set
 	// and using it for the restore is more correct.
 	// For the Kubernetes use case the output of 'crictl ps'
@@ -214,7 +201,24 @@ func (s *Server) CRImportCheckpoint(
 		// This is not quite out-of-process consumption, but types.ContainerConfig is at least
 		// a cross-process API, and this value is correct in that API.
 		rootFSImage = id.IDStringForOutOfProcessConsumptionOnly()
+
+		if err := s.pullCheckpointRootfsImage(ctx, sb, config, id); err != nil {
+			return "", err
+		}
+	}
+
+	stopMutex := sb.StopMutex()
+	stopMutex.RLock()
+	defer stopMutex.RUnlock()
+	if sb.Stopped() {
+		return "", fmt.Errorf("CreateContainer failed as the sandbox was stopped: %s", sb.ID())
+	}
+
+	ctr, err := container.New()
+	if err != nil {
+		return "", fmt.Errorf("failed to create container: %w", err)
 	}
+
 	containerConfig := &types.ContainerConfig{
 		Metadata: &types.ContainerMetadata{
 			Name:    createConfig.Metadata.Name,
@@ -392,5 +396,40 @@ func (s *Server) CRImportCheckpoint(
 		log.Infof(ctx, "RestoreCtr: context was either canceled or the deadline was exceeded: %v", ctx.Err())
 		return "", ctx.Err()
 	}
+	// The restored container is created like any other, the kubelet only learning it got restored once started.
+	s.generateCRIEvent(ctx, newContainer, types.ContainerEventType_CONTAINER_CREATED_EVENT)
 	return ctr.ID(), nil
 }
+
+// pullCheckpointRootfsImage pulls the image the checkpointed container got created from if it is
+// missing, like on the node a container migrates to, as the checkpoint only holds the changes of
+// the container to it. The image gets pulled by name, which must still refer to the same image.
+func (s *Server) pullCheckpointRootfsImage(ctx context.Context, sb *sandbox.Sandbox, config *metadata.ContainerConfig, id storage.StorageImageID) error {
+	_, err := s.StorageImageServer().ImageStatusByID(s.config.SystemContext, id)
+	if err == nil || !errors.Is(err, storagetypes.ErrImageUnknown) {
+		return err
+	}
+	if config.RootfsImageName == "" {
+		return fmt.Errorf("image %s of the checkpoint: %w", id.IDStringForOutOfProcessConsumptionOnly(), err)
+	}
+
+	log.Infof(ctx, "Pulling image %s of the checkpoint", config.RootfsImageName)
+	pullArgs := &pullArguments{
+		image:         config.RootfsImageName,
+		sandboxCgroup: sb.CgroupParent(),
+		namespace:     sb.Metadata().Namespace,
+	}
+	// The kubelet only sends the credentials of the checkpoint image.
+	if name, err := references.ParseRegistryImageReferenceFromOutOfProcessData(config.RootfsImageName); err == nil {
+		if credentials := s.credentialsContext(ctx, s.config.SystemContext, name.Raw()).DockerAuthConfig; credentials != nil {
+			pullArgs.credentials = *credentials
+		}
+	}
+	if _, err := s.pullImage(ctx, pullArgs, sb.Metadata().Namespace+"/"+sb.Metadata().Name); err != nil {
+		return fmt.Errorf("pull image %s of the checkpoint: %w", config.RootfsImageName, err)
+	}
+	if _, err := s.StorageImageServer().ImageStatusByID(s.config.SystemContext, id); err != nil {
+		return fmt.Errorf("image %s of the checkpoint does not refer to %s anymore: %w", config.RootfsImageName, id.IDStringForOutOfProcessConsumptionOnly(), err)
+	}
+	return nil
+}
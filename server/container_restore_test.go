@@ -7,6 +7,7 @@ import (
 	"os"
 
 	criu "github.com/checkpoint-restore/go-criu/v7/utils"
+	cstorage "github.com/containers/storage"
 	"github.com/containers/storage/pkg/archive"
 	"github.com/containers/storage/pkg/unshare"
 	. "github.com/onsi/ginkgo/v2"
@@ -425,6 +426,8 @@ var _ = t.Describe("ContainerRestore", func() {
 				var imageLookup mockutils.MockSequence
 				if image.byID {
 					imageLookup = mockutils.InOrder(
+						imageServerMock.EXPECT().ImageStatusByID(gomock.Any(), imageID).
+							Return(&storage.ImageResult{ID: imageID, User: "10", Size: &size}, nil),
 						imageServerMock.EXPECT().HeuristicallyTryResolvingStringAsIDPrefix(imageID.IDStringForOutOfProcessConsumptionOnly()).
 							Return(&imageID),
 
@@ -492,6 +495,57 @@ var _ = t.Describe("ContainerRestore", func() {
 			})
 		}
 	})
+	t.Describe("ContainerRestore from archive into new node", func() {
+		It("should fail without the image of the checkpoint", func() {
+			// Given
+			addContainerAndSandbox()
+
+			err := os.WriteFile(
+				"spec.dump",
+				[]byte(`{"annotations":{"io.kubernetes.cri-o.Annotations": "{\"name\":\"NAME\"}"}}`),
+				0o644,
+			)
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll("spec.dump")
+			err = os.WriteFile("config.dump", []byte(`{"rootfsImageRef": "8a788232037eaf17794408ff3df6b922a1aedf9ef8de36afdae3ed0b0381907b"}`), 0o644)
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll("config.dump")
+			outFile, err := os.Create("archive.tar")
+			Expect(err).ToNot(HaveOccurred())
+			defer outFile.Close()
+			input, err := archive.TarWithOptions(".", &archive.TarOptions{
+				Compression:      archive.Uncompressed,
+				IncludeSourceDir: true,
+				IncludeFiles:     []string{"spec.dump", "config.dump"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll("archive.tar")
+			_, err = io.Copy(outFile, input)
+			Expect(err).ToNot(HaveOccurred())
+			containerConfig := &types.ContainerConfig{
+				Metadata: &types.ContainerMetadata{Name: "name"},
+				Image: &types.ImageSpec{
+					Image: "archive.tar",
+				},
+			}
+			imageID, err := storage.ParseStorageImageIDFromOutOfProcessData("8a788232037eaf17794408ff3df6b922a1aedf9ef8de36afdae3ed0b0381907b")
+			Expect(err).ToNot(HaveOccurred())
+			imageServerMock.EXPECT().ImageStatusByID(gomock.Any(), imageID).
+				Return(nil, cstorage.ErrImageUnknown)
+
+			// When
+			_, err = sut.CRImportCheckpoint(
+				context.Background(),
+				containerConfig,
+				testSandbox,
+				"",
+			)
+
+			// Then
+			Expect(err).To(MatchError(cstorage.ErrImageUnknown))
+			Expect(err.Error()).To(ContainSubstring("image 8a788232037eaf17794408ff3df6b922a1aedf9ef8de36afdae3ed0b0381907b of the checkpoint"))
+		})
+	})
 	t.Describe("ContainerRestore from OCI archive", func() {
 		It("should fail because archive does not exist", func() {
 			// Given
@@ -542,3 +596,71 @@ var _ = t.Describe("ContainerRestore", func() {
 		})
 	})
 })
+
+var _ = t.Describe("PullCheckpointRootfsImage", func() {
+	BeforeEach(func() {
+		beforeEach()
+		setupSUT()
+	})
+	AfterEach(afterEach)
+
+	imageID, err := storage.ParseStorageImageIDFromOutOfProcessData("8a788232037eaf17794408ff3df6b922a1aedf9ef8de36afdae3ed0b0381907b")
+	Expect(err).ToNot(HaveOccurred())
+	imageCandidate, err := references.ParseRegistryImageReferenceFromOutOfProcessData("docker.io/library/image:latest")
+	Expect(err).ToNot(HaveOccurred())
+	canonicalImageCandidate, err := references.ParseRegistryImageReferenceFromOutOfProcessData("docker.io/library/image@sha256:340d9b015b194dc6e2a13938944e0d016e57b9679963fdeb9ce021daac430221")
+	Expect(err).ToNot(HaveOccurred())
+
+	It("should pull the missing image of the checkpoint", func() {
+		// Given
+		gomock.InOrder(
+			imageServerMock.EXPECT().ImageStatusByID(gomock.Any(), imageID).
+				Return(nil, cstorage.ErrImageUnknown),
+			imageServerMock.EXPECT().CandidatesForPotentiallyShortImageName(gomock.Any(), "image").
+				Return([]storage.RegistryImageReference{imageCandidate}, nil),
+			imageServerMock.EXPECT().PullImage(gomock.Any(), imageCandidate, gomock.Any()).
+				Return(canonicalImageCandidate, nil),
+			imageServerMock.EXPECT().ImageStatusByID(gomock.Any(), imageID).
+				Return(&storage.ImageResult{ID: imageID}, nil),
+		)
+
+		// When
+		err := sut.PullCheckpointRootfsImage(context.Background(), testSandbox, "image", imageID)
+
+		// Then
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should not pull the image of the checkpoint if it is present", func() {
+		// Given
+		imageServerMock.EXPECT().ImageStatusByID(gomock.Any(), imageID).
+			Return(&storage.ImageResult{ID: imageID}, nil)
+
+		// When
+		err := sut.PullCheckpointRootfsImage(context.Background(), testSandbox, "image", imageID)
+
+		// Then
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should fail if the name of the image refers to another image", func() {
+		// Given
+		gomock.InOrder(
+			imageServerMock.EXPECT().ImageStatusByID(gomock.Any(), imageID).
+				Return(nil, cstorage.ErrImageUnknown),
+			imageServerMock.EXPECT().CandidatesForPotentiallyShortImageName(gomock.Any(), "image").
+				Return([]storage.RegistryImageReference{imageCandidate}, nil),
+			imageServerMock.EXPECT().PullImage(gomock.Any(), imageCandidate, gomock.Any()).
+				Return(canonicalImageCandidate, nil),
+			imageServerMock.EXPECT().ImageStatusByID(gomock.Any(), imageID).
+				Return(nil, cstorage.ErrImageUnknown),
+		)
+
+		// When
+		err := sut.PullCheckpointRootfsImage(context.Background(), testSandbox, "image", imageID)
+
+		// Then
+		Expect(err).To(MatchError(cstorage.ErrImageUnknown))
+		Expect(err.Error()).To(ContainSubstring("does not refer to " + imageID.IDStringForOutOfProcessConsumptionOnly() + " anymore"))
+	})
+})
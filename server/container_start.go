@@ -53,6 +53,11 @@ func (s *Server) StartContainer(ctx context.Context, req *types.StartContainerRe
 			return nil, err
 		}
 
+		if err := s.runPostRestoreHook(ctx, c); err != nil {
+			return nil, err
+		}
+		s.generateCRIEvent(ctx, c, types.ContainerEventType_CONTAINER_STARTED_EVENT)
+
 		log.Infof(ctx, "Restored container: %s", ctr)
 		return &types.StartContainerResponse{}, nil
 	}
@@ -68,10 +73,6 @@ func (s *Server) StartContainer(ctx context.Context, req *types.StartContainerRe
 		return nil, fmt.Errorf("failed to get runtime handler %q hooks", sandbox.RuntimeHandler())
 	}
 
-	if err := s.nri.startContainer(ctx, sandbox, c); err != nil {
-		log.Warnf(ctx, "NRI start failed for container %q: %v", c.ID(), err)
-	}
-
 	defer func() {
 		// if the call to StartContainer fails below we still want to fill
 		// some fields of a container status. In particular, we're going to
@@ -103,6 +104,12 @@ func (s *Server) StartContainer(ctx context.Context, req *types.StartContainerRe
 		}
 	}
 
+	// NRI plugins are notified after the runtime handler hooks tuned the container,
+	// so they do not race with the hooks on the container cgroup.
+	if err := s.nri.startContainer(ctx, sandbox, c); err != nil {
+		log.Warnf(ctx, "NRI start failed for container %q: %v", c.ID(), err)
+	}
+
 	if err := s.Runtime().StartContainer(ctx, c); err != nil {
 		return nil, fmt.Errorf("failed to start container %s: %w", c.ID(), err)
 	}
@@ -121,3 +128,24 @@ func (s *Server) StartContainer(ctx context.Context, req *types.StartContainerRe
 
 	return &types.StartContainerResponse{}, nil
 }
+
+// runPostRestoreHook runs the post-restore hook of the runtime handler for the container restored
+// from a checkpoint, which does not run the pre-start hook. The tuning gets reverted if the hook failed.
+func (s *Server) runPostRestoreHook(ctx context.Context, c *oci.Container) error {
+	sb := s.getSandbox(ctx, c.Sandbox())
+	hooks, err := runtimehandlerhooks.GetRuntimeHandlerHooks(ctx, &s.config, sb.RuntimeHandler(), sb.Annotations())
+	if err != nil {
+		return fmt.Errorf("failed to get runtime handler %q hooks", sb.RuntimeHandler())
+	}
+	if hooks == nil {
+		return nil
+	}
+
+	if err := hooks.PostRestore(ctx, c, sb); err != nil {
+		if err := hooks.PreStop(ctx, c, sb); err != nil {
+			log.Warnf(ctx, "Failed to run pre-stop hook for container %q: %v", c.ID(), err)
+		}
+		return fmt.Errorf("failed to run post-restore hook for container %q: %w", c.ID(), err)
+	}
+	return nil
+}
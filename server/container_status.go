@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"fmt"
+	"maps"
 	"time"
 
 	json "github.com/json-iterator/go"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/cri-o/cri-o/internal/log"
 	oci "github.com/cri-o/cri-o/internal/oci"
+	"github.com/cri-o/cri-o/internal/runtimehandlerhooks"
 	"github.com/cri-o/cri-o/internal/storage"
 )
 
@@ -71,6 +73,16 @@ func (s *Server) ContainerStatus(ctx context.Context, req *types.ContainerStatus
 	}
 	resp.Status.Mounts = mounts
 
+	// report the outcome of the node tuning along with the container state
+	if tuningStatus := runtimehandlerhooks.TuningStatusAnnotations(containerID); tuningStatus != nil {
+		annotations := maps.Clone(resp.Status.Annotations)
+		if annotations == nil {
+			annotations = make(map[string]string, len(tuningStatus))
+		}
+		maps.Copy(annotations, tuningStatus)
+		resp.Status.Annotations = annotations
+	}
+
 	containerSpec := c.Spec()
 	if containerSpec.Linux != nil {
 		resp.Status.Resources = c.GetResources()
@@ -182,5 +194,15 @@ func (s *Server) createContainerInfo(container *oci.Container) (map[string]strin
 	if err != nil {
 		return nil, fmt.Errorf("marshal data: %w", err)
 	}
-	return map[string]string{"info": string(bytes)}, nil
+	info := map[string]string{"info": string(bytes)}
+
+	// report the tuning applied by the runtime handler hooks, the node files included
+	if tuning := runtimehandlerhooks.StateTuningDetails(container); tuning != nil {
+		bytes, err := json.Marshal(tuning)
+		if err != nil {
+			return nil, fmt.Errorf("marshal tuning details: %w", err)
+		}
+		info["tuning"] = string(bytes)
+	}
+	return info, nil
 }
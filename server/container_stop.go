@@ -65,10 +65,6 @@ func (s *Server) stopContainer(ctx context.Context, ctr *oci.Container, timeout
 		return fmt.Errorf("failed to unmount container %s: %w", ctr.ID(), err)
 	}
 
-	if err := s.ContainerStateToDisk(ctx, ctr); err != nil {
-		log.Warnf(ctx, "Unable to write containers %s state to disk: %v", ctr.ID(), err)
-	}
-
 	if hooks != nil {
 		if err := hooks.PostStop(ctx, ctr, sb); err != nil {
 			log.Errorf(ctx, "Failed to run post-stop hook for container %s: %v", ctr.ID(), err)
@@ -76,6 +72,11 @@ func (s *Server) stopContainer(ctx context.Context, ctr *oci.Container, timeout
 		}
 	}
 
+	// The state is written after the post-stop hook, which clears the tuning it records.
+	if err := s.ContainerStateToDisk(ctx, ctr); err != nil {
+		log.Warnf(ctx, "Unable to write containers %s state to disk: %v", ctr.ID(), err)
+	}
+
 	if err := s.nri.stopContainer(ctx, sb, ctr); err != nil {
 		return err
 	}
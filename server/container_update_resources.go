@@ -13,6 +13,7 @@ import (
 	"github.com/cri-o/cri-o/internal/config/node"
 	"github.com/cri-o/cri-o/internal/log"
 	"github.com/cri-o/cri-o/internal/oci"
+	"github.com/cri-o/cri-o/internal/runtimehandlerhooks"
 )
 
 // UpdateContainerResources updates ContainerConfig of the container.
@@ -29,24 +30,10 @@ func (s *Server) UpdateContainerResources(ctx context.Context, req *types.Update
 	}
 
 	if req.Linux != nil {
-		if err := reapplySharedCPUs(c, req); err != nil {
-			return nil, err
-		}
-		updated, err := s.nri.updateContainer(ctx, c, req.Linux)
-		if err != nil {
-			return nil, err
-		}
-		if updated == nil {
-			updated = req.Linux
-		}
-		resources := toOCIResources(updated)
-		if err := s.Runtime().UpdateContainer(ctx, c, resources); err != nil {
+		if err := s.updateContainerLinuxResources(ctx, c, req); err != nil {
 			return nil, err
 		}
 
-		// update memory store with updated resources
-		s.UpdateContainerLinuxResources(c, resources)
-
 		if err := s.nri.postUpdateContainer(ctx, c); err != nil {
 			log.Errorf(ctx, "NRI container post-update failed: %v", err)
 		}
@@ -55,6 +42,85 @@ func (s *Server) UpdateContainerResources(ctx context.Context, req *types.Update
 	return &types.UpdateContainerResourcesResponse{}, nil
 }
 
+// updateContainerLinuxResources updates the Linux resources of the container,
+// running the runtime handler hooks around the update.
+func (s *Server) updateContainerLinuxResources(ctx context.Context, c *oci.Container, req *types.UpdateContainerResourcesRequest) (retErr error) {
+	sb := s.getSandbox(ctx, c.Sandbox())
+	hooks, err := runtimehandlerhooks.GetRuntimeHandlerHooks(ctx, &s.config, sb.RuntimeHandler(), sb.Annotations())
+	if err != nil {
+		return fmt.Errorf("failed to get runtime handler %q hooks", sb.RuntimeHandler())
+	}
+
+	exclusiveCPUs := req.Linux.CpusetCpus
+	if err := reapplySharedCPUs(c, req); err != nil {
+		return err
+	}
+	updated, err := s.nri.updateContainer(ctx, c, req.Linux)
+	if err != nil {
+		return err
+	}
+	if updated == nil {
+		updated = req.Linux
+	}
+	resources := toOCIResources(updated)
+
+	// The spec keeps the exclusive CPUs only, the shared ones are added to the cgroup by the hooks.
+	recorded := resources
+	if resources.CPU.Cpus != "" && resources.CPU.Cpus == req.Linux.CpusetCpus && exclusiveCPUs != req.Linux.CpusetCpus {
+		recorded = toOCIResources(updated)
+		recorded.CPU.Cpus = exclusiveCPUs
+	}
+
+	if hooks != nil {
+		former := containerResources(c)
+		if err := hooks.PreUpdate(ctx, c, sb, recorded); err != nil {
+			return fmt.Errorf("failed to run pre-update hook for container %q: %w", c.ID(), err)
+		}
+		// The tuning reverted by the pre-update hook has to be re-applied, even if the update failed.
+		// In that case the spec keeps the former resources, and the ones of the failed update are
+		// passed instead, so that the hook re-applies the tuning reverted for them.
+		defer func() {
+			if retErr != nil {
+				former = recorded
+			}
+			if err := hooks.PostUpdate(ctx, c, sb, former); err != nil {
+				if retErr != nil {
+					log.Errorf(ctx, "Failed to run post-update hook for container %q: %v", c.ID(), err)
+					return
+				}
+				retErr = fmt.Errorf("failed to run post-update hook for container %q: %w", c.ID(), err)
+			}
+		}()
+	}
+
+	if err := s.Runtime().UpdateContainer(ctx, c, resources); err != nil {
+		return err
+	}
+
+	// update memory store with updated resources
+	s.UpdateContainerLinuxResources(c, recorded)
+	return nil
+}
+
+// containerResources returns a copy of the CPU and memory resources of the container spec,
+// which get updated in place.
+func containerResources(c *oci.Container) *rspec.LinuxResources {
+	spec := c.Spec()
+	if spec.Linux == nil || spec.Linux.Resources == nil {
+		return nil
+	}
+	resources := &rspec.LinuxResources{}
+	if cpu := spec.Linux.Resources.CPU; cpu != nil {
+		cpuCopy := *cpu
+		resources.CPU = &cpuCopy
+	}
+	if memory := spec.Linux.Resources.Memory; memory != nil {
+		memoryCopy := *memory
+		resources.Memory = &memoryCopy
+	}
+	return resources
+}
+
 // toOCIResources converts CRI resource constraints to OCI.
 func toOCIResources(r *types.LinuxContainerResources) *rspec.LinuxResources {
 	update := rspec.LinuxResources{
@@ -100,8 +166,8 @@ func reapplySharedCPUs(c *oci.Container, req *types.UpdateContainerResourcesRequ
 			sharedCpus = keyAndValue[1]
 		}
 	}
-	// nothing to do
-	if sharedCpus == "" {
+	// nothing to do, also if the cpuset is not updated
+	if sharedCpus == "" || req.Linux.CpusetCpus == "" {
 		return nil
 	}
 	shared, err := cpuset.Parse(sharedCpus)
@@ -82,6 +82,41 @@ var _ = t.Describe("UpdateContainerResources", func() {
 			Expect(c.Spec().Linux.Resources.CPU.Mems).To(Equal("0,1"))
 		})
 
+		It("should keep the exclusive CPUs only in the spec of a container using the shared CPUs", func() {
+			// Given
+			testContainer.SetSpec(&specs.Spec{
+				Process: &specs.Process{Env: []string{"OPENSHIFT_SHARED_CPUS=4-5"}},
+				Linux: &specs.Linux{
+					Resources: &specs.LinuxResources{CPU: &specs.LinuxCPU{Cpus: "0-1"}},
+				},
+			})
+			testContainer.SetState(&oci.ContainerState{
+				State: specs.State{Status: oci.ContainerStateRunning},
+			})
+			addContainerAndSandbox()
+
+			// When
+			_, err := sut.UpdateContainerResources(context.Background(),
+				&types.UpdateContainerResourcesRequest{
+					ContainerId: testContainer.ID(),
+					Linux:       &types.LinuxContainerResources{MemoryLimitInBytes: 1 << 30},
+				},
+			)
+			Expect(err).ToNot(HaveOccurred())
+			cpus := sut.GetContainer(context.TODO(), testContainer.ID()).Spec().Linux.Resources.CPU.Cpus
+			_, err2 := sut.UpdateContainerResources(context.Background(),
+				&types.UpdateContainerResourcesRequest{
+					ContainerId: testContainer.ID(),
+					Linux:       &types.LinuxContainerResources{CpusetCpus: "0-3"},
+				},
+			)
+
+			// Then
+			Expect(cpus).To(Equal("0-1"))
+			Expect(err2).ToNot(HaveOccurred())
+			Expect(sut.GetContainer(context.TODO(), testContainer.ID()).Spec().Linux.Resources.CPU.Cpus).To(Equal("0-3"))
+		})
+
 		It("should fail when container is not in created/running state", func() {
 			// Given
 			addContainerAndSandbox()
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+
+	"github.com/containers/image/v5/docker/reference"
+	imageTypes "github.com/containers/image/v5/types"
+
+	"github.com/cri-o/cri-o/internal/config/seccomp/seccompociartifact"
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/internal/storage"
+)
+
+// credentialsContext returns the system context with the credentials of the credential provider plugins
+// for an image CRI-O pulls on its own, if any provides some. A failing plugin does not fail the pull,
+// which may not need any credentials.
+func (s *Server) credentialsContext(ctx context.Context, sys *imageTypes.SystemContext, image reference.Named) *imageTypes.SystemContext {
+	if s.credentialProviders == nil || sys.DockerAuthConfig != nil {
+		return sys
+	}
+	credentials, err := s.credentialProviders.Credentials(ctx, image)
+	if err != nil {
+		log.Warnf(ctx, "Unable to get the credentials of image %s from the credential providers: %v", image, err)
+		return sys
+	}
+	if credentials == nil {
+		return sys
+	}
+	withCredentials := *sys // A shallow copy we can modify
+	withCredentials.DockerAuthConfig = credentials
+	return &withCredentials
+}
+
+// pauseImageContext returns the system context to create a pod sandbox with, which pulls the pause image
+// with the credentials of the credential provider plugins if it is missing, unless it has an auth file.
+func (s *Server) pauseImageContext(ctx context.Context, pauseImage storage.RegistryImageReference) *imageTypes.SystemContext {
+	if s.credentialProviders == nil || s.config.PauseImageAuthFile != "" {
+		return s.config.SystemContext
+	}
+	if _, err := s.StorageImageServer().ImageStatusByName(s.config.SystemContext, pauseImage); err == nil {
+		return s.config.SystemContext
+	}
+	return s.credentialsContext(ctx, s.config.SystemContext, pauseImage.Raw())
+}
+
+// seccompProfileContext returns the system context to pull the OCI artifact seccomp profile of the container with,
+// if its pod or image requests one, with the credentials of the credential provider plugins for the profile.
+func (s *Server) seccompProfileContext(ctx context.Context, containerName string, podAnnotations, imageAnnotations map[string]string) *imageTypes.SystemContext {
+	ref, _ := seccompociartifact.ProfileReference(containerName, podAnnotations, imageAnnotations)
+	if ref == "" || s.credentialProviders == nil {
+		return s.config.SystemContext
+	}
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		// The pull of the profile reports the invalid reference.
+		return s.config.SystemContext
+	}
+	return s.credentialsContext(ctx, s.config.SystemContext, named)
+}
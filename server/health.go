@@ -32,6 +32,10 @@ func (s *Server) checkCRIHealth(ctx context.Context, timeout time.Duration) erro
 	}
 
 	for _, c := range response.GetStatus().GetConditions() {
+		// A degraded tuning capability is left to the node problem detectors, restarting does not fix it.
+		if c.GetType() == TuningReady {
+			continue
+		}
 		if !c.GetStatus() {
 			return fmt.Errorf(
 				"runtime status %q is invalid: %s (reason: %s)",
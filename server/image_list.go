@@ -7,6 +7,7 @@ import (
 
 	"github.com/cri-o/cri-o/internal/log"
 	"github.com/cri-o/cri-o/internal/storage"
+	"github.com/cri-o/cri-o/server/metrics"
 )
 
 // ListImages lists existing images.
@@ -36,6 +37,7 @@ func (s *Server) ListImages(ctx context.Context, req *types.ListImagesRequest) (
 	if err != nil {
 		return nil, err
 	}
+	setPinnedImagesSize(results)
 	resp := &types.ListImagesResponse{}
 	for i := range results {
 		image := ConvertImage(&results[i])
@@ -44,6 +46,29 @@ func (s *Server) ListImages(ctx context.Context, req *types.ListImagesRequest) (
 	return resp, nil
 }
 
+// updatePinnedImagesSize lists the images to update the disk usage of the
+// pinned images metric, which is otherwise updated on every listing.
+func (s *Server) updatePinnedImagesSize(ctx context.Context) {
+	results, err := s.StorageImageServer().ListImages(s.config.SystemContext)
+	if err != nil {
+		log.Warnf(ctx, "Unable to list the images for the pinned images metric: %v", err)
+		return
+	}
+	setPinnedImagesSize(results)
+}
+
+// setPinnedImagesSize sets the disk usage of the pinned images metric from
+// the images listed. The layers shared by pinned images count for each of them.
+func setPinnedImagesSize(results []storage.ImageResult) {
+	var size uint64
+	for i := range results {
+		if results[i].Pinned && results[i].Size != nil {
+			size += *results[i].Size
+		}
+	}
+	metrics.Instance().MetricImagesPinnedSizeBytesSet(float64(size))
+}
+
 // ConvertImage takes an containers/storage ImageResult and converts it into a
 // CRI protobuf type. More information about the "why"s of this function can be
 // found in ../cri.md.
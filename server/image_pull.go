@@ -5,8 +5,6 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
 	"strings"
 	"syscall"
 	"time"
@@ -15,6 +13,7 @@ import (
 	encconfig "github.com/containers/ocicrypt/config"
 	"github.com/docker/distribution/registry/api/errcode"
 	"github.com/opencontainers/go-digest"
+	"golang.org/x/sync/semaphore"
 	types "k8s.io/cri-api/pkg/apis/runtime/v1"
 	crierrors "k8s.io/cri-api/pkg/errors"
 
@@ -38,6 +37,9 @@ func (s *Server) PullImage(ctx context.Context, req *types.PullImageRequest) (*t
 	log.Infof(ctx, "Pulling image: %s", image)
 
 	pullArgs := pullArguments{image: image}
+	// pod is the pod the image gets pulled for, which the pull progress metrics refer to.
+	// It is not part of the pull arguments, the pods pulling the same image share the pull.
+	pod := ""
 
 	sc := req.SandboxConfig
 	if sc != nil {
@@ -46,6 +48,7 @@ func (s *Server) PullImage(ctx context.Context, req *types.PullImageRequest) (*t
 		}
 		if sc.Metadata != nil {
 			pullArgs.namespace = sc.Metadata.Namespace
+			pod = sc.Metadata.Namespace + "/" + sc.Metadata.Name
 		}
 	}
 
@@ -95,7 +98,7 @@ func (s *Server) PullImage(ctx context.Context, req *types.PullImageRequest) (*t
 			pullOp.wg.Done()
 			s.pullOperationsLock.Unlock()
 		}()
-		pullOp.imageRef, pullOp.err = s.pullImage(ctx, &pullArgs)
+		pullOp.imageRef, pullOp.err = s.pullImage(ctx, &pullArgs, pod)
 	} else {
 		// Wait for the pull operation to finish.
 		pullOp.wg.Wait()
@@ -118,7 +121,7 @@ func (s *Server) PullImage(ctx context.Context, req *types.PullImageRequest) (*t
 // pullImage performs the actual pull operation of PullImage. Used to separate
 // the pull implementation from the pullCache logic in PullImage and improve
 // readability and maintainability.
-func (s *Server) pullImage(ctx context.Context, pullArgs *pullArguments) (storage.RegistryImageReference, error) {
+func (s *Server) pullImage(ctx context.Context, pullArgs *pullArguments, pod string) (storage.RegistryImageReference, error) {
 	var err error
 	ctx, span := log.StartSpan(ctx)
 	defer span.End()
@@ -134,16 +137,6 @@ func (s *Server) pullImage(ctx context.Context, pullArgs *pullArguments) (storag
 		sourceCtx.DockerAuthConfig = &pullArgs.credentials
 	}
 
-	if pullArgs.namespace != "" {
-		policyPath := filepath.Join(s.config.SignaturePolicyDir, pullArgs.namespace+".json")
-		if _, err := os.Stat(policyPath); err == nil {
-			sourceCtx.SignaturePolicyPath = policyPath
-		} else if !os.IsNotExist(err) {
-			return storage.RegistryImageReference{}, fmt.Errorf("read policy path %s: %w", policyPath, err)
-		}
-	}
-	log.Debugf(ctx, "Using pull policy path for image %s: %s", pullArgs.image, sourceCtx.SignaturePolicyPath)
-
 	decryptConfig, err := getDecryptionKeys(s.config.DecryptionKeysPath)
 	if err != nil {
 		return storage.RegistryImageReference{}, err
@@ -171,8 +164,19 @@ func (s *Server) pullImage(ctx context.Context, pullArgs *pullArguments) (storag
 	// CandidatesForPotentiallyShortImageName is defined never to return an empty slice on success, so if the loop considers all candidates
 	// and they all fail, this error value should be overwritten by a real failure.
 	lastErr := errors.New("internal error: pullImage failed but reported no error reason")
+
+	// The time spent waiting for another pull to complete does not count in the pull progress timeout.
+	if s.imagePulls != nil {
+		if !s.imagePulls.TryAcquire(1) {
+			log.Infof(ctx, "Waiting for one of the %d image pulls in progress to complete to pull image %s", s.config.MaxParallelImagePulls, pullArgs.image)
+			if err := s.imagePulls.Acquire(ctx, 1); err != nil {
+				return storage.RegistryImageReference{}, fmt.Errorf("wait to pull image %s: %w", pullArgs.image, err)
+			}
+		}
+		defer s.imagePulls.Release(1)
+	}
 	for _, remoteCandidateName := range remoteCandidates {
-		repoDigest, err := s.pullImageCandidate(ctx, &sourceCtx, remoteCandidateName, decryptConfig, cgroup)
+		repoDigest, err := s.pullImageCandidate(ctx, &sourceCtx, remoteCandidateName, decryptConfig, cgroup, pod)
 		if err == nil {
 			// Update metric for successful image pulls
 			metrics.Instance().MetricImagePullsSuccessesInc(remoteCandidateName)
@@ -184,23 +188,26 @@ func (s *Server) pullImage(ctx context.Context, pullArgs *pullArguments) (storag
 }
 
 // contextForNamespace takes the provided namespace and returns a modifiable
-// copy of the servers system context.
+// copy of the servers system context, using the signature policy of the
+// namespace if any, and the registry mirrors ordered by health if enabled.
 func (s *Server) contextForNamespace(namespace string) (imageTypes.SystemContext, error) {
 	ctx := *s.config.SystemContext // A shallow copy we can modify
+	if s.registryMirrors != nil {
+		s.registryMirrors.systemContextFor(&ctx)
+	}
 
-	if namespace != "" {
-		policyPath := filepath.Join(s.config.SignaturePolicyDir, namespace+".json")
-		if _, err := os.Stat(policyPath); err == nil {
-			ctx.SignaturePolicyPath = policyPath
-		} else if !os.IsNotExist(err) {
-			return ctx, fmt.Errorf("read policy path %s: %w", policyPath, err)
-		}
+	policyPath, err := s.config.SignaturePolicyPathForNamespace(namespace)
+	if err != nil {
+		return ctx, err
+	}
+	if policyPath != "" {
+		ctx.SignaturePolicyPath = policyPath
 	}
 
 	return ctx, nil
 }
 
-func (s *Server) pullImageCandidate(ctx context.Context, sourceCtx *imageTypes.SystemContext, remoteCandidateName storage.RegistryImageReference, decryptConfig *encconfig.DecryptConfig, cgroup string) (storage.RegistryImageReference, error) {
+func (s *Server) pullImageCandidate(ctx context.Context, sourceCtx *imageTypes.SystemContext, remoteCandidateName storage.RegistryImageReference, decryptConfig *encconfig.DecryptConfig, cgroup, pod string) (storage.RegistryImageReference, error) {
 	// Collect pull progress metrics
 	progress := make(chan imageTypes.ProgressProperties)
 	defer close(progress)
@@ -211,7 +218,10 @@ func (s *Server) pullImageCandidate(ctx context.Context, sourceCtx *imageTypes.S
 
 	// Cancel the pull if no progress is made
 	pullCtx, cancel := context.WithCancel(ctx)
-	go consumeImagePullProgress(ctx, cancel, s.Config().PullProgressTimeout, progress, remoteCandidateName)
+	pullProgress := newImagePullProgress(remoteCandidateName.StringForOutOfProcessConsumptionOnly(), pod, time.Now())
+	go consumeImagePullProgress(ctx, cancel, s.Config().PullProgressTimeout, progress, remoteCandidateName, pullProgress)
+
+	maxParallelDownloads, layerDownloads := s.layerDownloadLimits(remoteCandidateName)
 
 	repoDigest, err := s.StorageImageServer().PullImage(pullCtx, remoteCandidateName, &storage.ImageCopyOptions{
 		SourceCtx:        sourceCtx,
@@ -223,6 +233,8 @@ func (s *Server) pullImageCandidate(ctx context.Context, sourceCtx *imageTypes.S
 			UseNewCgroup: s.config.SeparatePullCgroup != "",
 			ParentCgroup: cgroup,
 		},
+		MaxParallelDownloads:     maxParallelDownloads,
+		ConcurrentLayerDownloads: layerDownloads,
 	})
 	if err != nil {
 		log.Debugf(ctx, "Error pulling image %s: %v", remoteCandidateName, err)
@@ -233,20 +245,33 @@ func (s *Server) pullImageCandidate(ctx context.Context, sourceCtx *imageTypes.S
 	return repoDigest, nil
 }
 
-// consumeImagePullProgress consumes progress and turns it into metrics updates.
+// layerDownloadLimits returns the maximum number of layers the pull of the image downloads at the same time,
+// along with the semaphore shared by all the pulls from its registry if the registry limits its layer downloads.
+func (s *Server) layerDownloadLimits(name storage.RegistryImageReference) (uint, *semaphore.Weighted) {
+	registry := name.Registry()
+	if limit, ok := s.config.RegistryMaxParallelLayerDownloads[registry]; ok {
+		return limit, s.registryLayerDownloads[registry]
+	}
+	return s.config.MaxParallelLayerDownloads, nil
+}
+
+// consumeImagePullProgress consumes progress and turns it into metrics updates,
+// including the progress metrics of the pull, removed once it completes.
 // It also checks if progress is being made within a constant timeout.
 // If the timeout is reached because no progress updates have been made, then
 // the cancel function will be called.
-func consumeImagePullProgress(ctx context.Context, cancel context.CancelFunc, pullProgressTimeout time.Duration, progress <-chan imageTypes.ProgressProperties, remoteCandidateName storage.RegistryImageReference) {
+func consumeImagePullProgress(ctx context.Context, cancel context.CancelFunc, pullProgressTimeout time.Duration, progress <-chan imageTypes.ProgressProperties, remoteCandidateName storage.RegistryImageReference, pullProgress *imagePullProgress) {
 	timer := time.AfterFunc(pullProgressTimeout, func() {
 		log.Warnf(ctx, "Timed out on waiting up to %s for image pull progress updates", pullProgressTimeout)
 		cancel()
 	})
 	timer.Stop()       // don't start the timer immediately
 	defer timer.Stop() // ensure that the timer is stopped when we exit the progress loop
+	defer pullProgress.finish(ctx)
 
 	for p := range progress {
 		timer.Reset(pullProgressTimeout)
+		pullProgress.update(p, time.Now())
 
 		if p.Event == imageTypes.ProgressEventSkipped {
 			// Skipped digests metrics
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	imageTypes "github.com/containers/image/v5/types"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/server/metrics"
+)
+
+// imagePullProgress tracks the progress of an image pull for the image pull progress metrics, which tell
+// a slow registry, still pulling bytes at a low rate, from a hung pull, which stopped pulling any.
+// CRI has no event for the progress of an image pull, so the metrics are the way to follow it.
+type imagePullProgress struct {
+	image, pod string
+	start      time.Time
+	// sizes are the sizes of the layers started by the pull, -1 if unknown, and pulled the bytes
+	// pulled so far for them.
+	sizes, pulled map[digest.Digest]int64
+	// done are the layers pulled, and skipped the ones not pulled as already in the storage.
+	done, skipped int
+}
+
+// imagePullProgressSeries counts the pulls in progress per image and pod, which share the same series of the
+// image pull progress metrics. The pulls of the same image get deduplicated unless their credentials or
+// namespaces differ, in which case the series are only removed once the last of the pulls is finished.
+var imagePullProgressSeries = struct {
+	sync.Mutex
+	pulls map[[2]string]int
+}{pulls: make(map[[2]string]int)}
+
+// newImagePullProgress returns the progress of the pull of the image for the pod, the "namespace/name"
+// of the pod sandbox the image gets pulled for, if any. It must be finished once the pull is over.
+func newImagePullProgress(image, pod string, start time.Time) *imagePullProgress {
+	imagePullProgressSeries.Lock()
+	imagePullProgressSeries.pulls[[2]string{image, pod}]++
+	imagePullProgressSeries.Unlock()
+	return &imagePullProgress{
+		image:  image,
+		pod:    pod,
+		start:  start,
+		sizes:  make(map[digest.Digest]int64),
+		pulled: make(map[digest.Digest]int64),
+	}
+}
+
+// update accounts for the progress event of the pull at the time.
+func (p *imagePullProgress) update(event imageTypes.ProgressProperties, now time.Time) {
+	layer := event.Artifact.Digest
+	switch event.Event {
+	case imageTypes.ProgressEventNewArtifact:
+		p.sizes[layer] = event.Artifact.Size
+	case imageTypes.ProgressEventRead:
+		p.pulled[layer] = int64(event.Offset)
+	case imageTypes.ProgressEventDone:
+		p.pulled[layer] = int64(event.Offset)
+		p.done++
+	case imageTypes.ProgressEventSkipped:
+		p.skipped++
+	}
+
+	m := metrics.Instance()
+	m.MetricImagePullProgressBytesSet(p.image, p.pod, float64(p.bytes()))
+	m.MetricImagePullProgressLayersSet(p.image, p.pod, "started", float64(len(p.sizes)+p.skipped))
+	m.MetricImagePullProgressLayersSet(p.image, p.pod, "done", float64(p.done+p.skipped))
+	if event.OffsetUpdate > 0 || event.Event != imageTypes.ProgressEventRead {
+		m.MetricImagePullProgressUpdateTimestampSet(p.image, p.pod, float64(now.Unix()))
+	}
+	if eta, ok := p.eta(now); ok {
+		m.MetricImagePullProgressETASet(p.image, p.pod, eta.Seconds())
+	}
+}
+
+// bytes returns the bytes pulled so far.
+func (p *imagePullProgress) bytes() (bytes int64) {
+	for _, pulled := range p.pulled {
+		bytes += pulled
+	}
+	return bytes
+}
+
+// eta returns the time left to pull the layers started so far at the average rate of the pull, unless the size
+// of one of them or the rate is unknown. The layers not started yet are not accounted for, as the pull does
+// not report them beforehand.
+func (p *imagePullProgress) eta(now time.Time) (time.Duration, bool) {
+	var total int64
+	for _, size := range p.sizes {
+		if size < 0 {
+			return 0, false
+		}
+		total += size
+	}
+	bytes, elapsed := p.bytes(), now.Sub(p.start)
+	if bytes == 0 || elapsed <= 0 {
+		return 0, false
+	}
+	left := max(total-bytes, 0)
+	return time.Duration(float64(left) / float64(bytes) * float64(elapsed)), true
+}
+
+// finish removes the metrics of the pull, once it completed or failed, unless another pull of the image
+// for the pod is still in progress.
+func (p *imagePullProgress) finish(ctx context.Context) {
+	log.Debugf(ctx, "ImagePull %s for pod %q: pulled %d bytes of %d layers, skipped %d layers in %s",
+		p.image, p.pod, p.bytes(), p.done, p.skipped, time.Since(p.start))
+	imagePullProgressSeries.Lock()
+	defer imagePullProgressSeries.Unlock()
+	key := [2]string{p.image, p.pod}
+	if imagePullProgressSeries.pulls[key]--; imagePullProgressSeries.pulls[key] > 0 {
+		return
+	}
+	delete(imagePullProgressSeries.pulls, key)
+	metrics.Instance().MetricImagePullProgressDelete(p.image, p.pod)
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	imageTypes "github.com/containers/image/v5/types"
+	"github.com/opencontainers/go-digest"
+)
+
+func TestImagePullProgress(t *testing.T) {
+	start := time.Unix(1000, 0)
+	layer := func(d string, size int64) imageTypes.BlobInfo {
+		return imageTypes.BlobInfo{Digest: digest.FromString(d), Size: size}
+	}
+	p := newImagePullProgress("quay.io/crio/image:latest", "default/pod", start)
+	defer p.finish(context.Background())
+
+	p.update(imageTypes.ProgressProperties{Event: imageTypes.ProgressEventSkipped, Artifact: layer("base", 300)}, start)
+	p.update(imageTypes.ProgressProperties{Event: imageTypes.ProgressEventNewArtifact, Artifact: layer("a", 400)}, start)
+	p.update(imageTypes.ProgressProperties{Event: imageTypes.ProgressEventNewArtifact, Artifact: layer("b", 200)}, start)
+	if _, ok := p.eta(start); ok {
+		t.Error("expected no ETA before any byte got pulled")
+	}
+
+	now := start.Add(2 * time.Second)
+	p.update(imageTypes.ProgressProperties{Event: imageTypes.ProgressEventRead, Artifact: layer("a", 400), Offset: 100, OffsetUpdate: 100}, now)
+	p.update(imageTypes.ProgressProperties{Event: imageTypes.ProgressEventDone, Artifact: layer("b", 200), Offset: 200, OffsetUpdate: 200}, now)
+
+	if bytes := p.bytes(); bytes != 300 {
+		t.Errorf("expected 300 bytes pulled, got %d", bytes)
+	}
+	if p.done != 1 || p.skipped != 1 {
+		t.Errorf("expected 1 layer done and 1 skipped, got %d and %d", p.done, p.skipped)
+	}
+	// 300 bytes left of the started layers, at 150 bytes per second
+	if eta, ok := p.eta(now); !ok || eta != 2*time.Second {
+		t.Errorf("expected an ETA of 2s, got %s (%v)", eta, ok)
+	}
+
+	p.update(imageTypes.ProgressProperties{Event: imageTypes.ProgressEventNewArtifact, Artifact: layer("c", -1)}, now)
+	if _, ok := p.eta(now); ok {
+		t.Error("expected no ETA with a layer of unknown size")
+	}
+}
+
+func TestImagePullProgressConcurrentPulls(t *testing.T) {
+	const image, pod = "quay.io/crio/image:concurrent", "default/pod"
+	key := [2]string{image, pod}
+	pulls := func() int {
+		imagePullProgressSeries.Lock()
+		defer imagePullProgressSeries.Unlock()
+		return imagePullProgressSeries.pulls[key]
+	}
+
+	first := newImagePullProgress(image, pod, time.Now())
+	second := newImagePullProgress(image, pod, time.Now())
+	if n := pulls(); n != 2 {
+		t.Fatalf("expected 2 pulls in progress, got %d", n)
+	}
+
+	first.finish(context.Background())
+	if n := pulls(); n != 1 {
+		t.Errorf("expected the series to be kept for the pull still in progress, got %d pulls", n)
+	}
+	second.finish(context.Background())
+	imagePullProgressSeries.Lock()
+	defer imagePullProgressSeries.Unlock()
+	if _, ok := imagePullProgressSeries.pulls[key]; ok {
+		t.Error("expected the series to be removed once the last pull finished")
+	}
+}
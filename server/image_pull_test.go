@@ -102,3 +102,75 @@ var _ = t.Describe("ImagePull", func() {
 		})
 	})
 })
+
+var _ = t.Describe("ImagePull with parallel download limits", func() {
+	imageCandidate, err := references.ParseRegistryImageReferenceFromOutOfProcessData("docker.io/library/image:latest")
+	Expect(err).ToNot(HaveOccurred())
+	limitedImageCandidate, err := references.ParseRegistryImageReferenceFromOutOfProcessData("quay.io/crio/image:latest")
+	Expect(err).ToNot(HaveOccurred())
+
+	// Prepare the sut
+	BeforeEach(func() {
+		beforeEach()
+		serverConfig.MaxParallelLayerDownloads = 3
+		serverConfig.RegistryMaxParallelLayerDownloads = map[string]uint{"quay.io": 2}
+		setupSUT()
+	})
+	AfterEach(afterEach)
+
+	t.Describe("ImagePull", func() {
+		It("should bound the layer downloads of the pull", func() {
+			// Given
+			var options *storage.ImageCopyOptions
+			gomock.InOrder(
+				imageServerMock.EXPECT().CandidatesForPotentiallyShortImageName(
+					gomock.Any(), "image").
+					Return([]storage.RegistryImageReference{imageCandidate}, nil),
+				imageServerMock.EXPECT().PullImage(gomock.Any(), imageCandidate, gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ storage.RegistryImageReference, o *storage.ImageCopyOptions) (storage.RegistryImageReference, error) {
+						options = o
+						return imageCandidate, nil
+					}),
+			)
+
+			// When
+			_, err := sut.PullImage(context.Background(),
+				&types.PullImageRequest{Image: &types.ImageSpec{
+					Image: "image",
+				}})
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(options.MaxParallelDownloads).To(BeEquivalentTo(3))
+			Expect(options.ConcurrentLayerDownloads).To(BeNil())
+		})
+
+		It("should share the layer downloads of the registry limiting them", func() {
+			// Given
+			var options []*storage.ImageCopyOptions
+			imageServerMock.EXPECT().CandidatesForPotentiallyShortImageName(
+				gomock.Any(), gomock.Any()).
+				Return([]storage.RegistryImageReference{limitedImageCandidate}, nil).Times(2)
+			imageServerMock.EXPECT().PullImage(gomock.Any(), limitedImageCandidate, gomock.Any()).
+				DoAndReturn(func(_ context.Context, _ storage.RegistryImageReference, o *storage.ImageCopyOptions) (storage.RegistryImageReference, error) {
+					options = append(options, o)
+					return limitedImageCandidate, nil
+				}).Times(2)
+
+			// When
+			for _, image := range []string{"quay.io/crio/image:latest", "quay.io/crio/image"} {
+				_, err := sut.PullImage(context.Background(),
+					&types.PullImageRequest{Image: &types.ImageSpec{
+						Image: image,
+					}})
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			// Then
+			Expect(options).To(HaveLen(2))
+			Expect(options[0].MaxParallelDownloads).To(BeEquivalentTo(2))
+			Expect(options[0].ConcurrentLayerDownloads).NotTo(BeNil())
+			Expect(options[1].ConcurrentLayerDownloads).To(BeIdenticalTo(options[0].ConcurrentLayerDownloads))
+		})
+	})
+})
@@ -35,7 +35,14 @@ func (s *Server) removeImage(ctx context.Context, imageRef string) (untagErr err
 	defer span.End()
 
 	if id := s.StorageImageServer().HeuristicallyTryResolvingStringAsIDPrefix(imageRef); id != nil {
-		if err := s.StorageImageServer().DeleteImage(s.config.SystemContext, *id); err != nil {
+		pinned, err := s.StorageImageServer().IsImagePinned(*id)
+		if err == nil {
+			if pinned {
+				return fmt.Errorf("image %s is pinned, refusing to remove it", id)
+			}
+			err = s.StorageImageServer().DeleteImage(s.config.SystemContext, *id)
+		}
+		if err != nil {
 			if errors.Is(err, storagetypes.ErrImageUnknown) {
 				// The RemoveImage RPC is idempotent, and must not return an
 				// error if the image has already been removed. Ref:
@@ -63,6 +70,12 @@ func (s *Server) removeImage(ctx context.Context, imageRef string) (untagErr err
 			log.Errorf(ctx, "Error getting image status %s: %v", name, statusErr)
 			continue
 		}
+		// The pinned images are never removed, not even by the image garbage
+		// collection of the kubelet under disk pressure. The other names of a
+		// pinned image can still be untagged, which leaves the image in place.
+		if status.Pinned && s.StorageImageServer().IsImageNamePinned(name) {
+			return fmt.Errorf("image %q is pinned, refusing to remove it", name)
+		}
 		if status.MountPoint != "" {
 			containerList, err := s.ContainerServer.ListContainers()
 			if err != nil {
@@ -56,6 +56,8 @@ var _ = t.Describe("ImageRemove", func() {
 			gomock.InOrder(
 				imageServerMock.EXPECT().HeuristicallyTryResolvingStringAsIDPrefix(testSHA256).
 					Return(&parsedTestSHA256),
+				imageServerMock.EXPECT().IsImagePinned(parsedTestSHA256).
+					Return(false, nil),
 				imageServerMock.EXPECT().DeleteImage(
 					gomock.Any(), parsedTestSHA256).
 					Return(nil),
@@ -68,6 +70,69 @@ var _ = t.Describe("ImageRemove", func() {
 			Expect(err).ToNot(HaveOccurred())
 		})
 
+		It("should fail when the image is pinned", func() {
+			// Given
+			gomock.InOrder(
+				imageServerMock.EXPECT().HeuristicallyTryResolvingStringAsIDPrefix("image").
+					Return(nil),
+				imageServerMock.EXPECT().CandidatesForPotentiallyShortImageName(
+					gomock.Any(), "image").
+					Return([]storage.RegistryImageReference{resolvedImageName}, nil),
+				imageServerMock.EXPECT().ImageStatusByName(gomock.Any(), gomock.Any()).
+					Return(&storage.ImageResult{Pinned: true}, nil),
+				imageServerMock.EXPECT().IsImageNamePinned(resolvedImageName).
+					Return(true),
+			)
+			// When
+			_, err := sut.RemoveImage(context.Background(),
+				&types.RemoveImageRequest{Image: &types.ImageSpec{Image: "image"}})
+
+			// Then
+			Expect(err).To(MatchError(ContainSubstring("is pinned")))
+		})
+
+		It("should untag the names of a pinned image which are not pinned", func() {
+			// Given
+			gomock.InOrder(
+				imageServerMock.EXPECT().HeuristicallyTryResolvingStringAsIDPrefix("image").
+					Return(nil),
+				imageServerMock.EXPECT().CandidatesForPotentiallyShortImageName(
+					gomock.Any(), "image").
+					Return([]storage.RegistryImageReference{resolvedImageName}, nil),
+				imageServerMock.EXPECT().ImageStatusByName(gomock.Any(), gomock.Any()).
+					Return(&storage.ImageResult{Pinned: true}, nil),
+				imageServerMock.EXPECT().IsImageNamePinned(resolvedImageName).
+					Return(false),
+				imageServerMock.EXPECT().UntagImage(gomock.Any(),
+					resolvedImageName).Return(nil),
+			)
+			// When
+			_, err := sut.RemoveImage(context.Background(),
+				&types.RemoveImageRequest{Image: &types.ImageSpec{Image: "image"}})
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should fail when the image of the full image id is pinned", func() {
+			// Given
+			const testSHA256 = "2a03a6059f21e150ae84b0973863609494aad70f0a80eaeb64bddd8d92465812"
+			parsedTestSHA256, err := storage.ParseStorageImageIDFromOutOfProcessData(testSHA256)
+			Expect(err).ToNot(HaveOccurred())
+			gomock.InOrder(
+				imageServerMock.EXPECT().HeuristicallyTryResolvingStringAsIDPrefix(testSHA256).
+					Return(&parsedTestSHA256),
+				imageServerMock.EXPECT().IsImagePinned(parsedTestSHA256).
+					Return(true, nil),
+			)
+			// When
+			_, err = sut.RemoveImage(context.Background(),
+				&types.RemoveImageRequest{Image: &types.ImageSpec{Image: testSHA256}})
+
+			// Then
+			Expect(err).To(MatchError(ContainSubstring("is pinned")))
+		})
+
 		It("should fail when image untag errors", func() {
 			// Given
 			gomock.InOrder(
@@ -125,9 +190,8 @@ var _ = t.Describe("ImageRemove", func() {
 			gomock.InOrder(
 				imageServerMock.EXPECT().HeuristicallyTryResolvingStringAsIDPrefix(testSHA256).
 					Return(&parsedTestSHA256),
-				imageServerMock.EXPECT().DeleteImage(
-					gomock.Any(), parsedTestSHA256).
-					Return(fmt.Errorf("invalid image: %w", storagetypes.ErrImageUnknown)),
+				imageServerMock.EXPECT().IsImagePinned(parsedTestSHA256).
+					Return(false, fmt.Errorf("invalid image: %w", storagetypes.ErrImageUnknown)),
 			)
 
 			// When
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -15,10 +16,13 @@ import (
 	"github.com/go-chi/chi/v5"
 	json "github.com/json-iterator/go"
 	"github.com/sirupsen/logrus"
+	kubeletTypes "k8s.io/kubelet/pkg/types"
+	"k8s.io/utils/cpuset"
 
 	"github.com/cri-o/cri-o/internal/lib/sandbox"
 	"github.com/cri-o/cri-o/internal/log"
 	"github.com/cri-o/cri-o/internal/oci"
+	"github.com/cri-o/cri-o/internal/runtimehandlerhooks"
 	"github.com/cri-o/cri-o/pkg/types"
 	"github.com/cri-o/cri-o/utils"
 )
@@ -60,6 +64,8 @@ var (
 	errCtrNotFound     = errors.New("container not found")
 	errCtrStateNil     = errors.New("container state is nil")
 	errSandboxNotFound = errors.New("sandbox for container not found")
+
+	errInvalidTuningFilter = errors.New("invalid tuning filter")
 )
 
 func (s *Server) getContainerInfo(ctx context.Context, id string, getContainerFunc, getInfraContainerFunc func(ctx context.Context, id string) *oci.Container, getSandboxFunc func(ctx context.Context, id string) *sandbox.Sandbox) (types.ContainerInfo, error) {
@@ -122,14 +128,79 @@ func (s *Server) getContainerInfo(ctx context.Context, id string, getContainerFu
 	}, nil
 }
 
+// getTuningInfo returns the high-performance tuning in effect for the containers selected by the filter,
+// and whether it currently holds.
+func (s *Server) getTuningInfo(listContainersFunc func(filters ...func(*oci.Container) bool) ([]*oci.Container, error), filter types.TuningFilter) ([]types.ContainerTuning, error) {
+	var filterCPUs cpuset.CPUSet
+	if filter.CPUs != "" {
+		cpus, err := cpuset.Parse(filter.CPUs)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid cpus %q: %w", errInvalidTuningFilter, filter.CPUs, err)
+		}
+		filterCPUs = cpus
+	}
+	ctrs, err := listContainersFunc(func(c *oci.Container) bool {
+		labels := c.Labels()
+		return (filter.PodName == "" || labels[kubeletTypes.KubernetesPodNameLabel] == filter.PodName) &&
+			(filter.PodNamespace == "" || labels[kubeletTypes.KubernetesPodNamespaceLabel] == filter.PodNamespace)
+	})
+	if err != nil {
+		return nil, err
+	}
+	tunings := []types.ContainerTuning{}
+	for _, ctr := range ctrs {
+		details := runtimehandlerhooks.ContainerTuningDetails(ctr.ID())
+		if details == nil {
+			continue
+		}
+		if filter.CPUs != "" {
+			cpus, err := cpuset.Parse(details.CPUs)
+			if err != nil || cpus.Intersection(filterCPUs).IsEmpty() {
+				continue
+			}
+		}
+		tuning := types.ContainerTuning{
+			ID:           ctr.ID(),
+			Name:         ctr.Name(),
+			PodName:      ctr.Labels()[kubeletTypes.KubernetesPodNameLabel],
+			PodNamespace: ctr.Labels()[kubeletTypes.KubernetesPodNamespaceLabel],
+			Tuning:       details,
+		}
+		ineffective, err := runtimehandlerhooks.IneffectiveTuning(ctr.ID())
+		switch {
+		case err != nil:
+			tuning.VerificationError = err.Error()
+		case len(ineffective) > 0:
+			tuning.Ineffective = ineffective
+		default:
+			tuning.Verified = true
+		}
+		tunings = append(tunings, tuning)
+	}
+	return tunings, nil
+}
+
+const (
+	InspectConfigEndpoint       = "/config"
+	InspectContainersEndpoint   = "/containers"
+	InspectInfoEndpoint         = "/info"
+	InspectPauseEndpoint        = "/pause"
+	InspectUnpauseEndpoint      = "/unpause"
+	InspectGoRoutinesEndpoint   = "/debug/goroutines"
+	InspectHeapEndpoint         = "/debug/heap"
+	InspectTuningEndpoint       = "/tuning"
+	InspectNodeTuningEndpoint   = "/tuning/node"
+	InspectSnapshotEndpoint     = "/tuning/snapshot"
+	InspectVerifyEndpoint       = "/tuning/verify"
+	InspectTuningHealthEndpoint = "/tuning/health"
+)
+
+// The query parameters of the tuning endpoint, filtering the containers by pod name,
+// pod namespace and exclusive CPUs.
 const (
-	InspectConfigEndpoint     = "/config"
-	InspectContainersEndpoint = "/containers"
-	InspectInfoEndpoint       = "/info"
-	InspectPauseEndpoint      = "/pause"
-	InspectUnpauseEndpoint    = "/unpause"
-	InspectGoRoutinesEndpoint = "/debug/goroutines"
-	InspectHeapEndpoint       = "/debug/heap"
+	InspectTuningPodQuery       = "pod"
+	InspectTuningNamespaceQuery = "namespace"
+	InspectTuningCPUsQuery      = "cpus"
 )
 
 // GetExtendInterfaceMux returns the mux used to serve extend interface requests.
@@ -190,6 +261,103 @@ func (s *Server) GetExtendInterfaceMux(enableProfile bool) *chi.Mux {
 		}
 	}))
 
+	mux.Get(InspectTuningEndpoint, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		tunings, err := s.getTuningInfo(s.ContainerServer.ListContainers, types.TuningFilter{
+			PodName:      query.Get(InspectTuningPodQuery),
+			PodNamespace: query.Get(InspectTuningNamespaceQuery),
+			CPUs:         query.Get(InspectTuningCPUsQuery),
+		})
+		if errors.Is(err, errInvalidTuningFilter) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		js, err := json.Marshal(tunings)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(js); err != nil {
+			logrus.Errorf("Unable to write response JSON: %v", err)
+		}
+	}))
+
+	mux.Get(InspectNodeTuningEndpoint, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		js, err := json.Marshal(runtimehandlerhooks.CurrentNodeTuningState())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(js); err != nil {
+			logrus.Errorf("Unable to write response JSON: %v", err)
+		}
+	}))
+
+	mux.Get(InspectVerifyEndpoint+"/{id}", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		containerID := chi.URLParam(req, "id")
+		ctr := s.GetContainer(context.TODO(), containerID)
+		if ctr == nil {
+			http.Error(w, "can't find the container with id "+containerID, http.StatusNotFound)
+			return
+		}
+		verification := runtimehandlerhooks.VerifyTuning(ctr.ID())
+		if verification == nil {
+			http.Error(w, "container with id "+containerID+" is not tuned", http.StatusNotFound)
+			return
+		}
+		js, err := json.Marshal(verification)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(js); err != nil {
+			logrus.Errorf("Unable to write response JSON: %v", err)
+		}
+	}))
+
+	mux.Get(InspectTuningHealthEndpoint, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		js, err := json.Marshal(runtimehandlerhooks.CheckTuningHealth(&s.config))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(js); err != nil {
+			logrus.Errorf("Unable to write response JSON: %v", err)
+		}
+	}))
+
+	mux.Get(InspectSnapshotEndpoint+"/{id}", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := context.TODO()
+		containerID := chi.URLParam(req, "id")
+		ctr := s.GetContainer(ctx, containerID)
+		if ctr == nil {
+			http.Error(w, "can't find the container with id "+containerID, http.StatusNotFound)
+			return
+		}
+		sb := s.getSandbox(ctx, ctr.Sandbox())
+		if sb == nil {
+			http.Error(w, "can't find the sandbox for container id "+containerID, http.StatusNotFound)
+			return
+		}
+		var snapshot bytes.Buffer
+		if err := runtimehandlerhooks.WriteDebugSnapshot(&snapshot, ctr, sb); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/gzip")
+		if _, err := w.Write(snapshot.Bytes()); err != nil {
+			logrus.Errorf("Unable to write the debug snapshot: %v", err)
+		}
+	}))
+
 	mux.Get(InspectPauseEndpoint+"/{id}", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		containerID := chi.URLParam(req, "id")
 		ctx := context.TODO()
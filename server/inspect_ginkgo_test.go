@@ -255,5 +255,30 @@ var _ = t.Describe("Inspect", func() {
 			Expect(request).NotTo(BeNil())
 			Expect(recorder.Code).To(BeEquivalentTo(http.StatusConflict))
 		})
+
+		It("should succeed with filters on /tuning route", func() {
+			// Given
+			// When
+			request, err := http.NewRequest(http.MethodGet, "/tuning?pod=pod1&namespace=ns1&cpus=2-3", http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(request).NotTo(BeNil())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusOK))
+			Expect(recorder.Body.String()).To(MatchJSON("[]"))
+		})
+
+		It("should fail with an invalid cpus filter on /tuning route", func() {
+			// Given
+			// When
+			request, err := http.NewRequest(http.MethodGet, "/tuning?cpus=2-", http.NoBody)
+			mux.ServeHTTP(recorder, request)
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(request).NotTo(BeNil())
+			Expect(recorder.Code).To(BeEquivalentTo(http.StatusBadRequest))
+		})
 	})
 })
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	types "k8s.io/cri-api/pkg/apis/runtime/v1"
+	kubeletTypes "k8s.io/kubelet/pkg/types"
+
+	"github.com/cri-o/cri-o/internal/oci"
+	"github.com/cri-o/cri-o/internal/runtimehandlerhooks"
+	crioTypes "github.com/cri-o/cri-o/pkg/types"
+)
+
+func TestGetTuningInfoFilters(t *testing.T) {
+	s := &Server{}
+	var ctrs []*oci.Container
+	for _, ctr := range []struct {
+		id, pod, namespace, cpus string
+	}{
+		{"ctr1", "pod1", "ns1", "1-2"},
+		{"ctr2", "pod2", "ns1", "3-4"},
+		{"ctr3", "pod1", "ns2", "5-6"},
+	} {
+		labels := map[string]string{
+			kubeletTypes.KubernetesPodNameLabel:      ctr.pod,
+			kubeletTypes.KubernetesPodNamespaceLabel: ctr.namespace,
+		}
+		container, err := oci.NewContainer(ctr.id, ctr.id, "", "/container/logs", labels, map[string]string{}, map[string]string{}, "imageName", nil, nil, "", &types.ContainerMetadata{}, "testsandboxid", false, false, false, "", "/root/for/container", time.Now(), "SIGKILL")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctrs = append(ctrs, container)
+		runtimehandlerhooks.SetAppliedTuning(ctr.id, ctr.cpus)
+		t.Cleanup(func() {
+			runtimehandlerhooks.ForgetAppliedTuning(container.ID())
+		})
+	}
+	listContainersFunc := func(filters ...func(*oci.Container) bool) ([]*oci.Container, error) {
+		selected := []*oci.Container{}
+	next:
+		for _, ctr := range ctrs {
+			for _, filter := range filters {
+				if !filter(ctr) {
+					continue next
+				}
+			}
+			selected = append(selected, ctr)
+		}
+		return selected, nil
+	}
+
+	for _, tc := range []struct {
+		name     string
+		filter   crioTypes.TuningFilter
+		expected []string
+	}{
+		{"no filter", crioTypes.TuningFilter{}, []string{"ctr1", "ctr2", "ctr3"}},
+		{"pod", crioTypes.TuningFilter{PodName: "pod1"}, []string{"ctr1", "ctr3"}},
+		{"namespace", crioTypes.TuningFilter{PodNamespace: "ns1"}, []string{"ctr1", "ctr2"}},
+		{"pod and namespace", crioTypes.TuningFilter{PodName: "pod1", PodNamespace: "ns2"}, []string{"ctr3"}},
+		{"cpus", crioTypes.TuningFilter{CPUs: "2-3"}, []string{"ctr1", "ctr2"}},
+		{"cpus and namespace", crioTypes.TuningFilter{CPUs: "2-3", PodNamespace: "ns2"}, []string{}},
+		{"unknown pod", crioTypes.TuningFilter{PodName: "pod3"}, []string{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tunings, err := s.getTuningInfo(listContainersFunc, tc.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := []string{}
+			for _, tuning := range tunings {
+				ids = append(ids, tuning.ID)
+			}
+			if len(ids) != len(tc.expected) {
+				t.Fatalf("expected the tuning of %v, got %v", tc.expected, ids)
+			}
+			for i := range ids {
+				if ids[i] != tc.expected[i] {
+					t.Fatalf("expected the tuning of %v, got %v", tc.expected, ids)
+				}
+			}
+		})
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/cri-o/cri-o/internal/storage"
 	"github.com/cri-o/cri-o/internal/storage/references"
 	"github.com/cri-o/cri-o/pkg/config"
+	crioTypes "github.com/cri-o/cri-o/pkg/types"
 )
 
 const systemdCgroupManager = "systemd"
@@ -248,3 +249,43 @@ func TestGetContainerInfoSandboxNotFound(t *testing.T) {
 		t.Fatalf("expected errSandboxNotFound error, got %v", err)
 	}
 }
+
+func TestGetTuningInfo(t *testing.T) {
+	s := &Server{}
+	listContainersFunc := func(filters ...func(*oci.Container) bool) ([]*oci.Container, error) {
+		container, err := oci.NewContainer("testid", "testname", "", "/container/logs", map[string]string{}, map[string]string{}, map[string]string{}, "imageName", nil, nil, "", &types.ContainerMetadata{}, "testsandboxid", false, false, false, "", "/root/for/container", time.Now(), "SIGKILL")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return []*oci.Container{container}, nil
+	}
+	tunings, err := s.getTuningInfo(listContainersFunc, crioTypes.TuningFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tunings) != 0 {
+		t.Fatalf("expected no tuning for the container which did not get tuned, got %v", tunings)
+	}
+}
+
+func TestGetTuningInfoListError(t *testing.T) {
+	s := &Server{}
+	listErr := errors.New("list")
+	listContainersFunc := func(filters ...func(*oci.Container) bool) ([]*oci.Container, error) {
+		return nil, listErr
+	}
+	if _, err := s.getTuningInfo(listContainersFunc, crioTypes.TuningFilter{}); !errors.Is(err, listErr) {
+		t.Fatalf("expected the list error, got %v", err)
+	}
+}
+
+func TestGetTuningInfoInvalidCPUsFilter(t *testing.T) {
+	s := &Server{}
+	listContainersFunc := func(filters ...func(*oci.Container) bool) ([]*oci.Container, error) {
+		t.Fatal("unexpected list of the containers")
+		return nil, nil
+	}
+	if _, err := s.getTuningInfo(listContainersFunc, crioTypes.TuningFilter{CPUs: "1-"}); !errors.Is(err, errInvalidTuningFilter) {
+		t.Fatalf("expected an invalid tuning filter error, got %v", err)
+	}
+}
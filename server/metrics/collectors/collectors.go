@@ -63,6 +63,66 @@ const (
 
 	// ResourcesStalledAtStage is the key for the resources stalled at different stages in container and pod creation.
 	ResourcesStalledAtStage Collector = crioPrefix + "resources_stalled_at_stage"
+
+	// TuningDriftTotal is the key for the tuning drifts of the running containers repaired by CRI-O per container name.
+	TuningDriftTotal Collector = crioPrefix + "tuning_drift_total"
+
+	// RuntimeHandlerHookStepDurationSeconds is the key for the duration of the tuning steps of the runtime handler hooks.
+	RuntimeHandlerHookStepDurationSeconds Collector = crioPrefix + "runtime_handler_hook_step_duration_seconds"
+
+	// RuntimeHandlerHookStepFailuresTotal is the key for the failures of the tuning steps of the runtime handler hooks.
+	RuntimeHandlerHookStepFailuresTotal Collector = crioPrefix + "runtime_handler_hook_step_failures_total"
+
+	// TuningIsolatedCPUs is the key for the CPUs of the running containers tuned per container ID and feature.
+	TuningIsolatedCPUs Collector = crioPrefix + "tuning_isolated_cpus"
+
+	// TuningNodeCPUs is the key for the CPUs of the node per allocation of the high-performance hooks.
+	TuningNodeCPUs Collector = crioPrefix + "tuning_node_cpus"
+
+	// TuningIsolatedCPURunDelaySecondsTotal is the key for the time the tasks waited to run on the isolated CPUs per CPU.
+	TuningIsolatedCPURunDelaySecondsTotal Collector = crioPrefix + "tuning_isolated_cpu_run_delay_seconds_total"
+
+	// TuningIsolatedCPUTimeslicesTotal is the key for the time slices run on the isolated CPUs per CPU.
+	TuningIsolatedCPUTimeslicesTotal Collector = crioPrefix + "tuning_isolated_cpu_timeslices_total"
+
+	// TuningUnfulfilledAnnotationsTotal is the key for the tuning annotations which could not be honored per annotation and reason.
+	TuningUnfulfilledAnnotationsTotal Collector = crioPrefix + "tuning_unfulfilled_annotations_total"
+
+	// IrqbalanceOperationDurationSeconds is the key for the duration of the irqbalance operations of the runtime handler hooks.
+	IrqbalanceOperationDurationSeconds Collector = crioPrefix + "irqbalance_operation_duration_seconds"
+
+	// IrqbalanceOperationFailuresTotal is the key for the failures of the irqbalance operations of the runtime handler hooks.
+	IrqbalanceOperationFailuresTotal Collector = crioPrefix + "irqbalance_operation_failures_total"
+
+	// TuningCPUCStateResidencySecondsTotal is the key for the time the CPUs of the containers tuned for c-states spent in every idle state per container ID, CPU and state.
+	TuningCPUCStateResidencySecondsTotal Collector = crioPrefix + "tuning_cpu_cstate_residency_seconds_total"
+
+	// TuningCPUCStateUsageTotal is the key for the times the CPUs of the containers tuned for c-states entered every idle state per container ID, CPU and state.
+	TuningCPUCStateUsageTotal Collector = crioPrefix + "tuning_cpu_cstate_usage_total"
+
+	// TuningCPUFrequencyHertz is the key for the current frequency of the CPUs of the containers tuned for the CPU frequency governor per container ID and CPU.
+	TuningCPUFrequencyHertz Collector = crioPrefix + "tuning_cpu_frequency_hertz"
+
+	// ImagePullProgressBytes is the key for the bytes pulled by the image pulls in progress per image and pod.
+	ImagePullProgressBytes Collector = crioPrefix + "image_pull_progress_bytes"
+
+	// ImagePullProgressLayers is the key for the layers of the image pulls in progress per image, pod and state.
+	ImagePullProgressLayers Collector = crioPrefix + "image_pull_progress_layers"
+
+	// ImagePullProgressETASeconds is the key for the estimated time left to the image pulls in progress per image and pod.
+	ImagePullProgressETASeconds Collector = crioPrefix + "image_pull_progress_eta_seconds"
+
+	// ImagePullProgressUpdateTimestampSeconds is the key for the time of the last progress of the image pulls in progress per image and pod.
+	ImagePullProgressUpdateTimestampSeconds Collector = crioPrefix + "image_pull_progress_update_timestamp_seconds"
+
+	// ImagesPinnedSizeBytes is the key for the disk usage of the pinned images.
+	ImagesPinnedSizeBytes Collector = crioPrefix + "images_pinned_size_bytes"
+
+	// RegistryMirrorHealthy is the key for the health of the registry mirrors per mirror.
+	RegistryMirrorHealthy Collector = crioPrefix + "registry_mirror_healthy"
+
+	// RegistryMirrorHealthCheckFailuresTotal is the key for the failed health checks of the registry mirrors per mirror.
+	RegistryMirrorHealthCheckFailuresTotal Collector = crioPrefix + "registry_mirror_health_check_failures_total"
 )
 
 // FromSlice converts a string slice to a Collectors type.
@@ -101,6 +161,26 @@ func All() Collectors {
 		ContainersOOMCountTotal.Stripped(),
 		ContainersSeccompNotifierCountTotal.Stripped(),
 		ResourcesStalledAtStage.Stripped(),
+		TuningDriftTotal.Stripped(),
+		RuntimeHandlerHookStepDurationSeconds.Stripped(),
+		RuntimeHandlerHookStepFailuresTotal.Stripped(),
+		TuningIsolatedCPUs.Stripped(),
+		TuningNodeCPUs.Stripped(),
+		TuningIsolatedCPURunDelaySecondsTotal.Stripped(),
+		TuningIsolatedCPUTimeslicesTotal.Stripped(),
+		TuningUnfulfilledAnnotationsTotal.Stripped(),
+		IrqbalanceOperationDurationSeconds.Stripped(),
+		IrqbalanceOperationFailuresTotal.Stripped(),
+		TuningCPUCStateResidencySecondsTotal.Stripped(),
+		TuningCPUCStateUsageTotal.Stripped(),
+		TuningCPUFrequencyHertz.Stripped(),
+		ImagePullProgressBytes.Stripped(),
+		ImagePullProgressLayers.Stripped(),
+		ImagePullProgressETASeconds.Stripped(),
+		ImagePullProgressUpdateTimestampSeconds.Stripped(),
+		ImagesPinnedSizeBytes.Stripped(),
+		RegistryMirrorHealthy.Stripped(),
+		RegistryMirrorHealthCheckFailuresTotal.Stripped(),
 	}
 }
 
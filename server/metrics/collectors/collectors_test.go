@@ -53,11 +53,21 @@ var _ = t.Describe("Collectors", func() {
 				collectors.ContainersOOMCountTotal,
 				collectors.ContainersSeccompNotifierCountTotal,
 				collectors.ResourcesStalledAtStage,
+				collectors.TuningDriftTotal,
+				collectors.RuntimeHandlerHookStepDurationSeconds,
+				collectors.RuntimeHandlerHookStepFailuresTotal,
+				collectors.ImagePullProgressBytes,
+				collectors.ImagePullProgressLayers,
+				collectors.ImagePullProgressETASeconds,
+				collectors.ImagePullProgressUpdateTimestampSeconds,
+				collectors.ImagesPinnedSizeBytes,
+				collectors.RegistryMirrorHealthy,
+				collectors.RegistryMirrorHealthCheckFailuresTotal,
 			} {
 				Expect(all.Contains(collector)).To(BeTrue())
 			}
 
-			Expect(all).To(HaveLen(16))
+			Expect(all).To(HaveLen(36))
 		})
 	})
 
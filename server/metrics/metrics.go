@@ -79,6 +79,26 @@ type Metrics struct {
 	metricContainersOOMCountTotal             *prometheus.CounterVec
 	metricContainersSeccompNotifierCountTotal *prometheus.CounterVec
 	metricResourcesStalledAtStage             *prometheus.CounterVec
+	metricTuningDriftTotal                    *prometheus.CounterVec
+	metricRuntimeHandlerHookStepDuration      *prometheus.HistogramVec
+	metricRuntimeHandlerHookStepFailuresTotal *prometheus.CounterVec
+	metricTuningIsolatedCPUs                  *prometheus.GaugeVec
+	metricTuningNodeCPUs                      *prometheus.GaugeVec
+	metricTuningIsolatedCPURunDelayTotal      *prometheus.CounterVec
+	metricTuningIsolatedCPUTimeslicesTotal    *prometheus.CounterVec
+	metricTuningUnfulfilledAnnotationsTotal   *prometheus.CounterVec
+	metricIrqbalanceOperationDuration         *prometheus.HistogramVec
+	metricIrqbalanceOperationFailuresTotal    *prometheus.CounterVec
+	metricTuningCPUCStateResidencyTotal       *prometheus.CounterVec
+	metricTuningCPUCStateUsageTotal           *prometheus.CounterVec
+	metricTuningCPUFrequency                  *prometheus.GaugeVec
+	metricImagePullProgressBytes              *prometheus.GaugeVec
+	metricImagePullProgressLayers             *prometheus.GaugeVec
+	metricImagePullProgressETA                *prometheus.GaugeVec
+	metricImagePullProgressUpdateTimestamp    *prometheus.GaugeVec
+	metricImagesPinnedSizeBytes               prometheus.Gauge
+	metricRegistryMirrorHealthy               *prometheus.GaugeVec
+	metricRegistryMirrorHealthCheckFailures   *prometheus.CounterVec
 }
 
 var instance *Metrics
@@ -235,6 +255,169 @@ func New(config *libconfig.MetricsConfig) *Metrics {
 			},
 			[]string{"stage"},
 		),
+		metricTuningDriftTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Subsystem: collectors.Subsystem,
+				Name:      collectors.TuningDriftTotal.String(),
+				Help:      "Amount of tuned files found drifted from the tuning of the running containers by container name",
+			},
+			[]string{"name"},
+		),
+		metricRuntimeHandlerHookStepDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Subsystem: collectors.Subsystem,
+				Name:      collectors.RuntimeHandlerHookStepDurationSeconds.String(),
+				Help:      "Duration in seconds of the tuning steps of the runtime handler hooks by hook and step",
+				// from 1ms to 16s
+				Buckets: prometheus.ExponentialBuckets(0.001, 2, 15),
+			},
+			[]string{"hook", "step"},
+		),
+		metricRuntimeHandlerHookStepFailuresTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Subsystem: collectors.Subsystem,
+				Name:      collectors.RuntimeHandlerHookStepFailuresTotal.String(),
+				Help:      "Amount of failures of the tuning steps of the runtime handler hooks by hook and step",
+			},
+			[]string{"hook", "step"},
+		),
+		metricTuningIsolatedCPUs: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Subsystem: collectors.Subsystem,
+				Name:      collectors.TuningIsolatedCPUs.String(),
+				Help:      "Amount of CPUs of the running containers tuned by the high-performance hooks by container ID and feature",
+			},
+			[]string{"id", "feature"},
+		),
+		metricTuningNodeCPUs: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Subsystem: collectors.Subsystem,
+				Name:      collectors.TuningNodeCPUs.String(),
+				Help:      "Amount of CPUs of the node by allocation of the high-performance hooks",
+			},
+			[]string{"allocation"},
+		),
+		metricTuningIsolatedCPURunDelayTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Subsystem: collectors.Subsystem,
+				Name:      collectors.TuningIsolatedCPURunDelaySecondsTotal.String(),
+				Help:      "Time spent by the tasks waiting to run on the isolated CPUs by CPU",
+			},
+			[]string{"cpu"},
+		),
+		metricTuningIsolatedCPUTimeslicesTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Subsystem: collectors.Subsystem,
+				Name:      collectors.TuningIsolatedCPUTimeslicesTotal.String(),
+				Help:      "Amount of time slices run on the isolated CPUs by CPU",
+			},
+			[]string{"cpu"},
+		),
+		metricTuningUnfulfilledAnnotationsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Subsystem: collectors.Subsystem,
+				Name:      collectors.TuningUnfulfilledAnnotationsTotal.String(),
+				Help:      "Amount of tuning annotations which could not be honored by annotation and reason",
+			},
+			[]string{"annotation", "reason"},
+		),
+		metricIrqbalanceOperationDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Subsystem: collectors.Subsystem,
+				Name:      collectors.IrqbalanceOperationDurationSeconds.String(),
+				Help:      "Duration in seconds of the irqbalance operations of the runtime handler hooks by operation",
+				// from 1ms to 16s
+				Buckets: prometheus.ExponentialBuckets(0.001, 2, 15),
+			},
+			[]string{"operation"},
+		),
+		metricIrqbalanceOperationFailuresTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Subsystem: collectors.Subsystem,
+				Name:      collectors.IrqbalanceOperationFailuresTotal.String(),
+				Help:      "Amount of failures of the irqbalance operations of the runtime handler hooks by operation",
+			},
+			[]string{"operation"},
+		),
+		metricTuningCPUCStateResidencyTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Subsystem: collectors.Subsystem,
+				Name:      collectors.TuningCPUCStateResidencySecondsTotal.String(),
+				Help:      "Time spent by the CPUs of the containers tuned for c-states in every idle state by container ID, CPU and state",
+			},
+			[]string{"id", "cpu", "state"},
+		),
+		metricTuningCPUCStateUsageTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Subsystem: collectors.Subsystem,
+				Name:      collectors.TuningCPUCStateUsageTotal.String(),
+				Help:      "Amount of times the CPUs of the containers tuned for c-states entered every idle state by container ID, CPU and state",
+			},
+			[]string{"id", "cpu", "state"},
+		),
+		metricTuningCPUFrequency: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Subsystem: collectors.Subsystem,
+				Name:      collectors.TuningCPUFrequencyHertz.String(),
+				Help:      "Current frequency in hertz of the CPUs of the containers tuned for the CPU frequency governor by container ID and CPU",
+			},
+			[]string{"id", "cpu"},
+		),
+		metricImagePullProgressBytes: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Subsystem: collectors.Subsystem,
+				Name:      collectors.ImagePullProgressBytes.String(),
+				Help:      "Bytes pulled so far by the image pulls in progress by image and pod",
+			},
+			[]string{"image", "pod"},
+		),
+		metricImagePullProgressLayers: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Subsystem: collectors.Subsystem,
+				Name:      collectors.ImagePullProgressLayers.String(),
+				Help:      "Layers of the image pulls in progress by image, pod and state, either started or done",
+			},
+			[]string{"image", "pod", "state"},
+		),
+		metricImagePullProgressETA: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Subsystem: collectors.Subsystem,
+				Name:      collectors.ImagePullProgressETASeconds.String(),
+				Help:      "Estimated seconds left to pull the layers started by the image pulls in progress by image and pod",
+			},
+			[]string{"image", "pod"},
+		),
+		metricImagePullProgressUpdateTimestamp: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Subsystem: collectors.Subsystem,
+				Name:      collectors.ImagePullProgressUpdateTimestampSeconds.String(),
+				Help:      "Unix time of the last bytes pulled by the image pulls in progress by image and pod",
+			},
+			[]string{"image", "pod"},
+		),
+		metricImagesPinnedSizeBytes: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Subsystem: collectors.Subsystem,
+				Name:      collectors.ImagesPinnedSizeBytes.String(),
+				Help:      "Disk usage in bytes of the pinned images",
+			},
+		),
+		metricRegistryMirrorHealthy: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Subsystem: collectors.Subsystem,
+				Name:      collectors.RegistryMirrorHealthy.String(),
+				Help:      "Health of the registry mirrors by mirror, 1 if healthy and 0 otherwise",
+			},
+			[]string{"mirror"},
+		),
+		metricRegistryMirrorHealthCheckFailures: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Subsystem: collectors.Subsystem,
+				Name:      collectors.RegistryMirrorHealthCheckFailuresTotal.String(),
+				Help:      "Failed health checks of the registry mirrors by mirror",
+			},
+			[]string{"mirror"},
+		),
 	}
 	return Instance()
 }
@@ -397,25 +580,260 @@ func (m *Metrics) MetricResourcesStalledAtStage(stage string) {
 	c.Inc()
 }
 
+func (m *Metrics) MetricTuningDriftTotalAdd(name string, value float64) {
+	c, err := m.metricTuningDriftTotal.GetMetricWithLabelValues(name)
+	if err != nil {
+		logrus.Warnf("Unable to write tuning drift metric: %v", err)
+		return
+	}
+	c.Add(value)
+}
+
+func (m *Metrics) MetricTuningDriftTotalDelete(name string) {
+	m.metricTuningDriftTotal.DeleteLabelValues(name)
+}
+
+func (m *Metrics) MetricRuntimeHandlerHookStepDurationObserve(hook, step string, start time.Time) {
+	o, err := m.metricRuntimeHandlerHookStepDuration.GetMetricWithLabelValues(hook, step)
+	if err != nil {
+		logrus.Warnf("Unable to write runtime handler hook step duration metric: %v", err)
+		return
+	}
+	o.Observe(SinceInSeconds(start))
+}
+
+func (m *Metrics) MetricRuntimeHandlerHookStepFailuresInc(hook, step string) {
+	c, err := m.metricRuntimeHandlerHookStepFailuresTotal.GetMetricWithLabelValues(hook, step)
+	if err != nil {
+		logrus.Warnf("Unable to write runtime handler hook step failures metric: %v", err)
+		return
+	}
+	c.Inc()
+}
+
+func (m *Metrics) MetricTuningIsolatedCPUsSet(id, feature string, cpus float64) {
+	g, err := m.metricTuningIsolatedCPUs.GetMetricWithLabelValues(id, feature)
+	if err != nil {
+		logrus.Warnf("Unable to write tuning isolated CPUs metric: %v", err)
+		return
+	}
+	g.Set(cpus)
+}
+
+func (m *Metrics) MetricTuningIsolatedCPUsDelete(id string) {
+	m.metricTuningIsolatedCPUs.DeletePartialMatch(prometheus.Labels{"id": id})
+}
+
+func (m *Metrics) MetricTuningNodeCPUsSet(allocation string, cpus float64) {
+	g, err := m.metricTuningNodeCPUs.GetMetricWithLabelValues(allocation)
+	if err != nil {
+		logrus.Warnf("Unable to write tuning node CPUs metric: %v", err)
+		return
+	}
+	g.Set(cpus)
+}
+
+func (m *Metrics) MetricTuningIsolatedCPURunDelayAdd(cpu string, seconds float64) {
+	c, err := m.metricTuningIsolatedCPURunDelayTotal.GetMetricWithLabelValues(cpu)
+	if err != nil {
+		logrus.Warnf("Unable to write tuning isolated CPU run delay metric: %v", err)
+		return
+	}
+	c.Add(seconds)
+}
+
+func (m *Metrics) MetricTuningIsolatedCPUTimeslicesAdd(cpu string, timeslices float64) {
+	c, err := m.metricTuningIsolatedCPUTimeslicesTotal.GetMetricWithLabelValues(cpu)
+	if err != nil {
+		logrus.Warnf("Unable to write tuning isolated CPU timeslices metric: %v", err)
+		return
+	}
+	c.Add(timeslices)
+}
+
+func (m *Metrics) MetricTuningIsolatedCPUSchedstatDelete(cpu string) {
+	m.metricTuningIsolatedCPURunDelayTotal.DeleteLabelValues(cpu)
+	m.metricTuningIsolatedCPUTimeslicesTotal.DeleteLabelValues(cpu)
+}
+
+func (m *Metrics) MetricTuningUnfulfilledAnnotationsInc(annotation, reason string) {
+	c, err := m.metricTuningUnfulfilledAnnotationsTotal.GetMetricWithLabelValues(annotation, reason)
+	if err != nil {
+		logrus.Warnf("Unable to write tuning unfulfilled annotations metric: %v", err)
+		return
+	}
+	c.Inc()
+}
+
+func (m *Metrics) MetricIrqbalanceOperationDurationObserve(operation string, start time.Time) {
+	o, err := m.metricIrqbalanceOperationDuration.GetMetricWithLabelValues(operation)
+	if err != nil {
+		logrus.Warnf("Unable to write irqbalance operation duration metric: %v", err)
+		return
+	}
+	o.Observe(SinceInSeconds(start))
+}
+
+func (m *Metrics) MetricIrqbalanceOperationFailuresInc(operation string) {
+	c, err := m.metricIrqbalanceOperationFailuresTotal.GetMetricWithLabelValues(operation)
+	if err != nil {
+		logrus.Warnf("Unable to write irqbalance operation failures metric: %v", err)
+		return
+	}
+	c.Inc()
+}
+
+func (m *Metrics) MetricTuningCPUCStateResidencyAdd(id, cpu, state string, seconds float64) {
+	c, err := m.metricTuningCPUCStateResidencyTotal.GetMetricWithLabelValues(id, cpu, state)
+	if err != nil {
+		logrus.Warnf("Unable to write tuning CPU c-state residency metric: %v", err)
+		return
+	}
+	c.Add(seconds)
+}
+
+func (m *Metrics) MetricTuningCPUCStateUsageAdd(id, cpu, state string, usage float64) {
+	c, err := m.metricTuningCPUCStateUsageTotal.GetMetricWithLabelValues(id, cpu, state)
+	if err != nil {
+		logrus.Warnf("Unable to write tuning CPU c-state usage metric: %v", err)
+		return
+	}
+	c.Add(usage)
+}
+
+func (m *Metrics) MetricTuningCPUCStateDelete(id string) {
+	m.metricTuningCPUCStateResidencyTotal.DeletePartialMatch(prometheus.Labels{"id": id})
+	m.metricTuningCPUCStateUsageTotal.DeletePartialMatch(prometheus.Labels{"id": id})
+}
+
+func (m *Metrics) MetricTuningCPUFrequencySet(id, cpu string, hertz float64) {
+	g, err := m.metricTuningCPUFrequency.GetMetricWithLabelValues(id, cpu)
+	if err != nil {
+		logrus.Warnf("Unable to write tuning CPU frequency metric: %v", err)
+		return
+	}
+	g.Set(hertz)
+}
+
+func (m *Metrics) MetricTuningCPUFrequencyDelete(id string) {
+	m.metricTuningCPUFrequency.DeletePartialMatch(prometheus.Labels{"id": id})
+}
+
+func (m *Metrics) MetricImagePullProgressBytesSet(image, pod string, bytes float64) {
+	g, err := m.metricImagePullProgressBytes.GetMetricWithLabelValues(image, pod)
+	if err != nil {
+		logrus.Warnf("Unable to write image pull progress bytes metric: %v", err)
+		return
+	}
+	g.Set(bytes)
+}
+
+func (m *Metrics) MetricImagePullProgressLayersSet(image, pod, state string, layers float64) {
+	g, err := m.metricImagePullProgressLayers.GetMetricWithLabelValues(image, pod, state)
+	if err != nil {
+		logrus.Warnf("Unable to write image pull progress layers metric: %v", err)
+		return
+	}
+	g.Set(layers)
+}
+
+func (m *Metrics) MetricImagePullProgressETASet(image, pod string, seconds float64) {
+	g, err := m.metricImagePullProgressETA.GetMetricWithLabelValues(image, pod)
+	if err != nil {
+		logrus.Warnf("Unable to write image pull progress ETA metric: %v", err)
+		return
+	}
+	g.Set(seconds)
+}
+
+func (m *Metrics) MetricImagePullProgressUpdateTimestampSet(image, pod string, timestamp float64) {
+	g, err := m.metricImagePullProgressUpdateTimestamp.GetMetricWithLabelValues(image, pod)
+	if err != nil {
+		logrus.Warnf("Unable to write image pull progress update timestamp metric: %v", err)
+		return
+	}
+	g.Set(timestamp)
+}
+
+func (m *Metrics) MetricImagePullProgressDelete(image, pod string) {
+	labels := prometheus.Labels{"image": image, "pod": pod}
+	m.metricImagePullProgressBytes.DeletePartialMatch(labels)
+	m.metricImagePullProgressLayers.DeletePartialMatch(labels)
+	m.metricImagePullProgressETA.DeletePartialMatch(labels)
+	m.metricImagePullProgressUpdateTimestamp.DeletePartialMatch(labels)
+}
+
+func (m *Metrics) MetricImagesPinnedSizeBytesSet(bytes float64) {
+	m.metricImagesPinnedSizeBytes.Set(bytes)
+}
+
+func (m *Metrics) MetricRegistryMirrorHealthySet(mirror string, healthy bool) {
+	g, err := m.metricRegistryMirrorHealthy.GetMetricWithLabelValues(mirror)
+	if err != nil {
+		logrus.Warnf("Unable to write registry mirror healthy metric: %v", err)
+		return
+	}
+	if healthy {
+		g.Set(1)
+	} else {
+		g.Set(0)
+	}
+}
+
+func (m *Metrics) MetricRegistryMirrorHealthCheckFailuresInc(mirror string) {
+	c, err := m.metricRegistryMirrorHealthCheckFailures.GetMetricWithLabelValues(mirror)
+	if err != nil {
+		logrus.Warnf("Unable to write registry mirror health check failures metric: %v", err)
+		return
+	}
+	c.Inc()
+}
+
+func (m *Metrics) MetricRegistryMirrorDelete(mirror string) {
+	labels := prometheus.Labels{"mirror": mirror}
+	m.metricRegistryMirrorHealthy.DeletePartialMatch(labels)
+	m.metricRegistryMirrorHealthCheckFailures.DeletePartialMatch(labels)
+}
+
 // createEndpoint creates a /metrics endpoint for prometheus monitoring.
 func (m *Metrics) createEndpoint() (*http.ServeMux, error) {
 	for collector, metric := range map[collectors.Collector]prometheus.Collector{
-		collectors.ContainersEventsDropped:             m.metricContainersEventsDropped,
-		collectors.ContainersOOMCountTotal:             m.metricContainersOOMCountTotal,
-		collectors.ContainersOOMTotal:                  m.metricContainersOOMTotal,
-		collectors.ContainersSeccompNotifierCountTotal: m.metricContainersSeccompNotifierCountTotal,
-		collectors.ImageLayerReuseTotal:                m.metricImageLayerReuseTotal,
-		collectors.ImagePullsBytesTotal:                m.metricImagePullsBytesTotal,
-		collectors.ImagePullsFailureTotal:              m.metricImagePullsFailureTotal,
-		collectors.ImagePullsLayerSize:                 m.metricImagePullsLayerSize,
-		collectors.ImagePullsSkippedBytesTotal:         m.metricImagePullsSkippedBytesTotal,
-		collectors.ImagePullsSuccessTotal:              m.metricImagePullsSuccessTotal,
-		collectors.OperationsErrorsTotal:               m.metricOperationsErrorsTotal,
-		collectors.OperationsLatencySeconds:            m.metricOperationsLatencySeconds,
-		collectors.OperationsLatencySecondsTotal:       m.metricOperationsLatencySecondsTotal,
-		collectors.OperationsTotal:                     m.metricOperationsTotal,
-		collectors.ProcessesDefunct:                    m.metricProcessesDefunct,
-		collectors.ResourcesStalledAtStage:             m.metricResourcesStalledAtStage,
+		collectors.ContainersEventsDropped:                 m.metricContainersEventsDropped,
+		collectors.ContainersOOMCountTotal:                 m.metricContainersOOMCountTotal,
+		collectors.ContainersOOMTotal:                      m.metricContainersOOMTotal,
+		collectors.ContainersSeccompNotifierCountTotal:     m.metricContainersSeccompNotifierCountTotal,
+		collectors.ImageLayerReuseTotal:                    m.metricImageLayerReuseTotal,
+		collectors.ImagePullsBytesTotal:                    m.metricImagePullsBytesTotal,
+		collectors.ImagePullsFailureTotal:                  m.metricImagePullsFailureTotal,
+		collectors.ImagePullsLayerSize:                     m.metricImagePullsLayerSize,
+		collectors.ImagePullsSkippedBytesTotal:             m.metricImagePullsSkippedBytesTotal,
+		collectors.ImagePullsSuccessTotal:                  m.metricImagePullsSuccessTotal,
+		collectors.OperationsErrorsTotal:                   m.metricOperationsErrorsTotal,
+		collectors.OperationsLatencySeconds:                m.metricOperationsLatencySeconds,
+		collectors.OperationsLatencySecondsTotal:           m.metricOperationsLatencySecondsTotal,
+		collectors.OperationsTotal:                         m.metricOperationsTotal,
+		collectors.ProcessesDefunct:                        m.metricProcessesDefunct,
+		collectors.ResourcesStalledAtStage:                 m.metricResourcesStalledAtStage,
+		collectors.TuningDriftTotal:                        m.metricTuningDriftTotal,
+		collectors.RuntimeHandlerHookStepDurationSeconds:   m.metricRuntimeHandlerHookStepDuration,
+		collectors.RuntimeHandlerHookStepFailuresTotal:     m.metricRuntimeHandlerHookStepFailuresTotal,
+		collectors.TuningIsolatedCPUs:                      m.metricTuningIsolatedCPUs,
+		collectors.TuningNodeCPUs:                          m.metricTuningNodeCPUs,
+		collectors.TuningIsolatedCPURunDelaySecondsTotal:   m.metricTuningIsolatedCPURunDelayTotal,
+		collectors.TuningIsolatedCPUTimeslicesTotal:        m.metricTuningIsolatedCPUTimeslicesTotal,
+		collectors.TuningUnfulfilledAnnotationsTotal:       m.metricTuningUnfulfilledAnnotationsTotal,
+		collectors.IrqbalanceOperationDurationSeconds:      m.metricIrqbalanceOperationDuration,
+		collectors.IrqbalanceOperationFailuresTotal:        m.metricIrqbalanceOperationFailuresTotal,
+		collectors.TuningCPUCStateResidencySecondsTotal:    m.metricTuningCPUCStateResidencyTotal,
+		collectors.TuningCPUCStateUsageTotal:               m.metricTuningCPUCStateUsageTotal,
+		collectors.TuningCPUFrequencyHertz:                 m.metricTuningCPUFrequency,
+		collectors.ImagePullProgressBytes:                  m.metricImagePullProgressBytes,
+		collectors.ImagePullProgressLayers:                 m.metricImagePullProgressLayers,
+		collectors.ImagePullProgressETASeconds:             m.metricImagePullProgressETA,
+		collectors.ImagePullProgressUpdateTimestampSeconds: m.metricImagePullProgressUpdateTimestamp,
+		collectors.ImagesPinnedSizeBytes:                   m.metricImagesPinnedSizeBytes,
+		collectors.RegistryMirrorHealthy:                   m.metricRegistryMirrorHealthy,
+		collectors.RegistryMirrorHealthCheckFailuresTotal:  m.metricRegistryMirrorHealthCheckFailures,
 	} {
 		if m.config.MetricsCollectors.Contains(collector) {
 			logrus.Debugf("Enabling metric: %s", collector.Stripped())
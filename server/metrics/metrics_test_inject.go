@@ -0,0 +1,35 @@
+//go:build test
+
+// All *_inject.go files are meant to be used by tests only. Purpose of this
+// files is to provide a way to inject mocked data into the current setup.
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// MetricTuningIsolatedCPUs returns the isolated CPUs of the container exported by the gauge, by feature.
+func (m *Metrics) MetricTuningIsolatedCPUs(id string) map[string]float64 {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		m.metricTuningIsolatedCPUs.Collect(ch)
+		close(ch)
+	}()
+	cpus := map[string]float64{}
+	for metric := range ch {
+		var written dto.Metric
+		if err := metric.Write(&written); err != nil {
+			continue
+		}
+		labels := map[string]string{}
+		for _, label := range written.GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+		if labels["id"] == id {
+			cpus[labels["feature"]] = written.GetGauge().GetValue()
+		}
+	}
+	return cpus
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/containers/image/v5/docker/reference"
+
+	"github.com/cri-o/cri-o/internal/config/ociartifact"
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/pkg/annotations"
+)
+
+const (
+	// ociArtifactsDir is the directory of the infra container holding the OCI artifacts pulled for the pod,
+	// removed along with the pod.
+	ociArtifactsDir = "oci-artifacts"
+
+	// ociArtifactsCachePath caches the layers of the OCI artifacts pulled for the pods by digest.
+	ociArtifactsCachePath = "/var/lib/crio/oci-artifacts"
+)
+
+// podOCIArtifactRefs returns the references of the OCI artifacts requested by the annotations of a pod by name.
+// The artifacts have to be referenced by digest, so that all the pods requesting one get the same content.
+func podOCIArtifactRefs(podAnnotations map[string]string) (map[string]reference.Canonical, error) {
+	refs := make(map[string]reference.Canonical)
+	for key, value := range podAnnotations {
+		if key != annotations.OCIArtifactAnnotation && !strings.HasPrefix(key, annotations.OCIArtifactAnnotation+"/") {
+			continue
+		}
+		name := strings.TrimPrefix(key, annotations.OCIArtifactAnnotation+"/")
+		if name == "" || name == key || name == "." || name == ".." || strings.Contains(name, "/") {
+			return nil, fmt.Errorf("invalid OCI artifact annotation %q, expected %s/<name>", key, annotations.OCIArtifactAnnotation)
+		}
+		named, err := reference.ParseNormalizedNamed(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OCI artifact reference %q of annotation %s: %w", value, key, err)
+		}
+		canonical, ok := named.(reference.Canonical)
+		if !ok {
+			return nil, fmt.Errorf("OCI artifact %q of annotation %s is not referenced by digest", value, key)
+		}
+		refs[name] = canonical
+	}
+	return refs, nil
+}
+
+// pullPodOCIArtifacts pulls the OCI artifacts of a pod through the registries of the images, like their mirrors,
+// with the credentials of the credential provider plugins if any, into the directory of its infra container,
+// and returns their paths by name for the runtime and the hooks.
+func (s *Server) pullPodOCIArtifacts(ctx context.Context, refs map[string]reference.Canonical, infraDir string) (map[string]string, error) {
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	dir := filepath.Join(infraDir, ociArtifactsDir)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create OCI artifacts directory: %w", err)
+	}
+	paths := make(map[string]string, len(refs))
+	for name, ref := range refs {
+		artifact, err := ociartifact.New().Pull(ctx, ref.String(), &ociartifact.PullOptions{
+			SystemContext: s.credentialsContext(ctx, s.config.SystemContext, ref),
+			CachePath:     ociArtifactsCachePath,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("pull OCI artifact %s: %w", name, err)
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, artifact.Data, 0o600); err != nil {
+			return nil, fmt.Errorf("write OCI artifact %s: %w", name, err)
+		}
+		log.Infof(ctx, "Pulled OCI artifact %s of %d bytes from %s", name, len(artifact.Data), ref)
+		paths[name] = path
+	}
+	return paths, nil
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/cri-o/cri-o/pkg/annotations"
+)
+
+func TestPodOCIArtifactRefs(t *testing.T) {
+	const digest = "sha256:2a03a6059f21e150ae84b0973863609494aad70f0a80eaeb64bddd8d92465812"
+
+	refs, err := podOCIArtifactRefs(map[string]string{
+		annotations.OCIArtifactAnnotation + "/tuning":  "quay.io/crio/profiles@" + digest,
+		annotations.OCIArtifactAnnotation + "/seccomp": "profiles:v1@" + digest,
+		"other.crio.io/tuning":                         "quay.io/crio/profiles:latest",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) != 2 {
+		t.Fatalf("expected 2 OCI artifacts, got %v", refs)
+	}
+	if ref := refs["tuning"].String(); ref != "quay.io/crio/profiles@"+digest {
+		t.Errorf("unexpected reference of the tuning OCI artifact %q", ref)
+	}
+	if ref := refs["seccomp"].String(); ref != "docker.io/library/profiles:v1@"+digest {
+		t.Errorf("unexpected reference of the seccomp OCI artifact %q", ref)
+	}
+
+	for key, value := range map[string]string{
+		annotations.OCIArtifactAnnotation + "/tuning": "quay.io/crio/profiles:latest",
+		annotations.OCIArtifactAnnotation + "/a/b":    "quay.io/crio/profiles@" + digest,
+		annotations.OCIArtifactAnnotation + "/..":     "quay.io/crio/profiles@" + digest,
+		annotations.OCIArtifactAnnotation:             "quay.io/crio/profiles@" + digest,
+		annotations.OCIArtifactAnnotation + "/bad":    "quay.io/crio/profiles@sha256:invalid",
+	} {
+		if _, err := podOCIArtifactRefs(map[string]string{key: value}); err == nil {
+			t.Errorf("expected annotation %s: %q to be rejected", key, value)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/containers/image/v5/pkg/sysregistriesv2"
+	imageTypes "github.com/containers/image/v5/types"
+	"github.com/google/renameio"
+
+	"github.com/cri-o/cri-o/internal/log"
+	"github.com/cri-o/cri-o/server/metrics"
+)
+
+const (
+	// registryMirrorsDir is the directory of the registries configuration generated for the image pulls,
+	// with the mirrors of every registry ordered by health.
+	registryMirrorsDir = "/var/run/crio/registries"
+
+	// registryMirrorHealthCheckTimeout bounds the health check of a registry mirror.
+	registryMirrorHealthCheckTimeout = 5 * time.Second
+)
+
+// registryMirrors tracks the health of the registry mirrors, so that the image pulls try the healthy mirrors
+// first instead of waiting for an unreachable one to time out before trying the next one, as the mirrors are
+// otherwise tried in the order of the registries configuration. The mirrors are reordered in a copy of the
+// registries configuration the pulls use, the pulls still failing over to the next mirror on any error.
+type registryMirrors struct {
+	// dir is the directory of the generated registries configuration.
+	dir string
+	// check returns an error if the mirror is not reachable.
+	check func(ctx context.Context, mirror sysregistriesv2.Endpoint) error
+
+	lock sync.RWMutex
+	// systemContext is the system context the registries configuration got loaded with.
+	systemContext *imageTypes.SystemContext
+	// conf is the registries configuration, which must not be modified as it is shared with the cache of
+	// the containers/image library.
+	conf *sysregistriesv2.V2RegistriesConf
+	// healthy is the health of the mirrors by location as of their last health check, the mirrors not
+	// checked yet being missing.
+	healthy map[string]bool
+	// generated is true once the registries configuration with the mirrors ordered by health got written.
+	generated bool
+}
+
+func newRegistryMirrors(dir string) *registryMirrors {
+	return &registryMirrors{
+		dir:     dir,
+		check:   checkRegistryMirrorHealth,
+		healthy: make(map[string]bool),
+	}
+}
+
+// startRegistryMirrorHealthChecks periodically checks the health of the registry mirrors, if enabled.
+func (s *Server) startRegistryMirrorHealthChecks(ctx context.Context) error {
+	interval := s.config.RegistryMirrorHealthCheckInterval
+	if interval <= 0 {
+		log.Debugf(ctx, "Registry mirror health checks are disabled")
+		return nil
+	}
+
+	s.registryMirrors = newRegistryMirrors(registryMirrorsDir)
+	if err := s.registryMirrors.load(ctx, s.config.SystemContext); err != nil {
+		return fmt.Errorf("load registry mirrors: %w", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			s.registryMirrors.checkHealth(ctx)
+			select {
+			case <-ticker.C:
+			case <-s.monitorsChan:
+				log.Debugf(ctx, "Closing registry mirror health checks...")
+				return
+			}
+		}
+	}()
+
+	log.Infof(ctx, "Started registry mirror health checks with an interval of %s", interval)
+	return nil
+}
+
+// reloadRegistryMirrors loads the mirrors again after a reload of the registries configuration.
+func (s *Server) reloadRegistryMirrors(ctx context.Context) {
+	if s.registryMirrors == nil {
+		return
+	}
+	if err := s.registryMirrors.load(ctx, s.config.SystemContext); err != nil {
+		log.Errorf(ctx, "Unable to reload registry mirrors: %v", err)
+	}
+}
+
+// load loads the registries configuration of the system context, forgetting the health of the mirrors
+// not configured anymore.
+func (m *registryMirrors) load(ctx context.Context, sys *imageTypes.SystemContext) error {
+	conf, err := sysregistriesv2.TryUpdatingCache(sys)
+	if err != nil {
+		return err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.systemContext, m.conf = sys, conf
+	configured := make(map[string]bool)
+	for _, mirror := range m.mirrorsLocked() {
+		configured[mirror.Location] = true
+	}
+	for location := range m.healthy {
+		if !configured[location] {
+			delete(m.healthy, location)
+			metrics.Instance().MetricRegistryMirrorDelete(location)
+		}
+	}
+	return m.writeLocked(ctx)
+}
+
+// mirrorsLocked returns the mirrors of all the registries, once each.
+func (m *registryMirrors) mirrorsLocked() []sysregistriesv2.Endpoint {
+	var mirrors []sysregistriesv2.Endpoint
+	seen := make(map[string]bool)
+	for i := range m.conf.Registries {
+		for _, mirror := range m.conf.Registries[i].Mirrors {
+			if !seen[mirror.Location] {
+				seen[mirror.Location] = true
+				mirrors = append(mirrors, mirror)
+			}
+		}
+	}
+	return mirrors
+}
+
+// checkHealth checks the health of all the mirrors at the same time, and reorders them if their health changed.
+func (m *registryMirrors) checkHealth(ctx context.Context) {
+	m.lock.RLock()
+	mirrors := m.mirrorsLocked()
+	m.lock.RUnlock()
+
+	results := make([]error, len(mirrors))
+	var wg sync.WaitGroup
+	for i, mirror := range mirrors {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, registryMirrorHealthCheckTimeout)
+			defer cancel()
+			results[i] = m.check(checkCtx, mirror)
+		}()
+	}
+	wg.Wait()
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	changed := false
+	for i, mirror := range mirrors {
+		healthy := results[i] == nil
+		if !healthy {
+			metrics.Instance().MetricRegistryMirrorHealthCheckFailuresInc(mirror.Location)
+		}
+		metrics.Instance().MetricRegistryMirrorHealthySet(mirror.Location, healthy)
+		wasHealthy := m.healthyLocked(mirror)
+		m.healthy[mirror.Location] = healthy
+		if healthy == wasHealthy {
+			continue
+		}
+		changed = true
+		if healthy {
+			log.Infof(ctx, "Registry mirror %s is healthy again", mirror.Location)
+		} else {
+			log.Warnf(ctx, "Registry mirror %s is unhealthy, trying it last: %v", mirror.Location, results[i])
+		}
+	}
+	if changed {
+		if err := m.writeLocked(ctx); err != nil {
+			log.Errorf(ctx, "Unable to reorder the registry mirrors: %v", err)
+		}
+	}
+}
+
+// orderedLocked returns a copy of the registries configuration with the healthy mirrors of every registry
+// first, in the order of the configuration, followed by the unhealthy ones. The mirrors not checked yet
+// count as healthy.
+func (m *registryMirrors) orderedLocked() *sysregistriesv2.V2RegistriesConf {
+	conf := *m.conf
+	conf.Registries = slices.Clone(m.conf.Registries)
+	for i := range conf.Registries {
+		conf.Registries[i].Mirrors = slices.Clone(conf.Registries[i].Mirrors)
+		slices.SortStableFunc(conf.Registries[i].Mirrors, func(a, b sysregistriesv2.Endpoint) int {
+			switch healthyA, healthyB := m.healthyLocked(a), m.healthyLocked(b); {
+			case healthyA == healthyB:
+				return 0
+			case healthyA:
+				return -1
+			default:
+				return 1
+			}
+		})
+	}
+	return &conf
+}
+
+// healthyLocked returns false if the last health check of the mirror failed.
+func (m *registryMirrors) healthyLocked(mirror sysregistriesv2.Endpoint) bool {
+	healthy, checked := m.healthy[mirror.Location]
+	return healthy || !checked
+}
+
+// writeLocked writes the registries configuration with the mirrors ordered by health, with an empty
+// drop-in directory as the drop-in configurations are part of it already. Nothing is written until a
+// mirror is unhealthy, the pulls using the registries configuration of the system until then.
+func (m *registryMirrors) writeLocked(ctx context.Context) error {
+	if !m.generated && !slices.ContainsFunc(m.mirrorsLocked(), func(mirror sysregistriesv2.Endpoint) bool {
+		return !m.healthyLocked(mirror)
+	}) {
+		return nil
+	}
+	if err := os.MkdirAll(m.confDirPath(), 0o700); err != nil {
+		return err
+	}
+	var content bytes.Buffer
+	if err := toml.NewEncoder(&content).Encode(m.orderedLocked()); err != nil {
+		return fmt.Errorf("encode registries configuration: %w", err)
+	}
+	if err := renameio.WriteFile(m.confPath(), content.Bytes(), 0o600); err != nil {
+		return err
+	}
+	// The configuration is cached by path, which does not change.
+	sys := m.generatedSystemContext(m.systemContext)
+	if _, err := sysregistriesv2.TryUpdatingCache(sys); err != nil {
+		return fmt.Errorf("load generated registries configuration: %w", err)
+	}
+	if !m.generated {
+		log.Infof(ctx, "Ordering the registry mirrors by health in %s", m.confPath())
+	}
+	m.generated = true
+	return nil
+}
+
+func (m *registryMirrors) confPath() string {
+	return filepath.Join(m.dir, "registries.conf")
+}
+
+func (m *registryMirrors) confDirPath() string {
+	return filepath.Join(m.dir, "registries.conf.d")
+}
+
+// generatedSystemContext returns a copy of the system context using the registries configuration with
+// the mirrors ordered by health, if generated.
+func (m *registryMirrors) generatedSystemContext(sys *imageTypes.SystemContext) *imageTypes.SystemContext {
+	generated := *sys // A shallow copy we can modify
+	generated.SystemRegistriesConfPath = m.confPath()
+	generated.SystemRegistriesConfDirPath = m.confDirPath()
+	return &generated
+}
+
+// systemContextFor makes the system context of an image pull use the mirrors ordered by health.
+func (m *registryMirrors) systemContextFor(sys *imageTypes.SystemContext) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	if m.generated {
+		*sys = *m.generatedSystemContext(sys)
+	}
+}
+
+// checkRegistryMirrorHealth checks that the mirror answers on its registry API endpoint, whatever the answer,
+// like an authentication request. The certificate of the mirror is not verified, the check not sending
+// anything to it, as the certificates of the registries are set up for the pulls only.
+func checkRegistryMirrorHealth(ctx context.Context, mirror sysregistriesv2.Endpoint) error {
+	host, _, _ := strings.Cut(mirror.Location, "/")
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy:             http.ProxyFromEnvironment,
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true}, //nolint:gosec // see above
+			DisableKeepAlives: true,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	schemes := []string{"https"}
+	if mirror.Insecure {
+		schemes = append(schemes, "http")
+	}
+	var err error
+	for _, scheme := range schemes {
+		var req *http.Request
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, scheme+"://"+host+"/v2/", http.NoBody)
+		if err != nil {
+			return err
+		}
+		var resp *http.Response
+		resp, err = client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			return nil
+		}
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containers/image/v5/pkg/sysregistriesv2"
+	imageTypes "github.com/containers/image/v5/types"
+)
+
+func TestRegistryMirrorsOrderedByHealth(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	conf := filepath.Join(dir, "registries.conf")
+	if err := os.WriteFile(conf, []byte(`
+unqualified-search-registries = ["quay.io"]
+
+[[registry]]
+location = "quay.io"
+
+[[registry.mirror]]
+location = "mirror-a.example.com/quay"
+
+[[registry.mirror]]
+location = "mirror-b.example.com/quay"
+insecure = true
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sys := &imageTypes.SystemContext{
+		SystemRegistriesConfPath:    conf,
+		SystemRegistriesConfDirPath: filepath.Join(dir, "registries.conf.d"),
+	}
+
+	unhealthy := map[string]bool{}
+	m := newRegistryMirrors(filepath.Join(dir, "generated"))
+	m.check = func(_ context.Context, mirror sysregistriesv2.Endpoint) error {
+		if unhealthy[mirror.Location] {
+			return errors.New("unreachable")
+		}
+		return nil
+	}
+	if err := m.load(ctx, sys); err != nil {
+		t.Fatal(err)
+	}
+	mirrors := func() []string {
+		t.Helper()
+		pullCtx := *sys
+		m.systemContextFor(&pullCtx)
+		registry, err := sysregistriesv2.FindRegistry(&pullCtx, "quay.io/crio/image:latest")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var locations []string
+		for _, mirror := range registry.Mirrors {
+			locations = append(locations, mirror.Location)
+		}
+		return locations
+	}
+
+	m.checkHealth(ctx)
+	if m.generated {
+		t.Error("expected no registries configuration to be generated while all the mirrors are healthy")
+	}
+	if got := mirrors(); len(got) != 2 || got[0] != "mirror-a.example.com/quay" {
+		t.Errorf("expected the mirrors in the configured order, got %v", got)
+	}
+
+	unhealthy["mirror-a.example.com/quay"] = true
+	m.checkHealth(ctx)
+	if got := mirrors(); len(got) != 2 || got[0] != "mirror-b.example.com/quay" || got[1] != "mirror-a.example.com/quay" {
+		t.Errorf("expected the unhealthy mirror last, got %v", got)
+	}
+	registry, err := sysregistriesv2.FindRegistry(m.generatedSystemContext(sys), "quay.io/crio/image:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !registry.Mirrors[0].Insecure {
+		t.Error("expected the mirror settings to be kept")
+	}
+	registries, err := sysregistriesv2.UnqualifiedSearchRegistries(m.generatedSystemContext(sys))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(registries) != 1 || registries[0] != "quay.io" {
+		t.Errorf("expected the unqualified search registries to be kept, got %v", registries)
+	}
+
+	unhealthy["mirror-a.example.com/quay"] = false
+	m.checkHealth(ctx)
+	if got := mirrors(); len(got) != 2 || got[0] != "mirror-a.example.com/quay" {
+		t.Errorf("expected the mirrors in the configured order once healthy again, got %v", got)
+	}
+}
@@ -4,12 +4,23 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	types "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"github.com/cri-o/cri-o/internal/runtimehandlerhooks"
+	libconfig "github.com/cri-o/cri-o/pkg/config"
 )
 
-// networkNotReadyReason is the reason reported when network is not ready.
-const networkNotReadyReason = "NetworkPluginNotReady"
+const (
+	// networkNotReadyReason is the reason reported when network is not ready.
+	networkNotReadyReason = "NetworkPluginNotReady"
+	// TuningReady is the condition reporting the capability of the node to get tuned
+	// by the high-performance hooks, only set if some runtime handler uses them.
+	TuningReady = "TuningReady"
+	// tuningDegradedReason is the reason reported when the tuning capability is degraded.
+	tuningDegradedReason = "TuningDegraded"
+)
 
 // Status returns the status of the runtime.
 func (s *Server) Status(ctx context.Context, req *types.StatusRequest) (*types.StatusResponse, error) {
@@ -28,12 +39,17 @@ func (s *Server) Status(ctx context.Context, req *types.StatusRequest) (*types.S
 		networkCondition.Message = fmt.Sprintf("Network plugin returns error: %v", err)
 	}
 
+	conditions := []*types.RuntimeCondition{
+		runtimeCondition,
+		networkCondition,
+	}
+	if s.highPerformanceHooksConfigured() {
+		conditions = append(conditions, s.tuningCondition())
+	}
+
 	resp := &types.StatusResponse{
 		Status: &types.RuntimeStatus{
-			Conditions: []*types.RuntimeCondition{
-				runtimeCondition,
-				networkCondition,
-			},
+			Conditions: conditions,
 		},
 		Features: &types.RuntimeFeatures{
 			SupplementalGroupsPolicy: true,
@@ -73,6 +89,37 @@ func (s *Server) Status(ctx context.Context, req *types.StatusRequest) (*types.S
 	return resp, nil
 }
 
+// highPerformanceHooksConfigured returns true if some runtime handler uses the high-performance hooks.
+func (s *Server) highPerformanceHooksConfigured() bool {
+	for name, runtime := range s.config.Runtimes {
+		if runtime.RuntimeHandlerHooks == libconfig.RuntimeHandlerHooksHighPerformance ||
+			strings.Contains(name, runtimehandlerhooks.HighPerformance) {
+			return true
+		}
+	}
+	return false
+}
+
+// tuningCondition returns the condition reporting the failed tuning health checks, if any.
+func (s *Server) tuningCondition() *types.RuntimeCondition {
+	condition := &types.RuntimeCondition{
+		Type:   TuningReady,
+		Status: true,
+	}
+	var failed []string
+	for _, check := range runtimehandlerhooks.CheckTuningHealth(&s.config) {
+		if !check.Healthy {
+			failed = append(failed, check.Name+": "+check.Message)
+		}
+	}
+	if len(failed) > 0 {
+		condition.Status = false
+		condition.Reason = tuningDegradedReason
+		condition.Message = "Tuning capability is degraded: " + strings.Join(failed, "; ")
+	}
+	return condition
+}
+
 func (s *Server) createRuntimeInfo() (map[string]string, error) {
 	config := map[string]any{
 		"sandboxImage": s.config.ImageConfig.PauseImage,
@@ -6,6 +6,9 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	types "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"github.com/cri-o/cri-o/pkg/config"
+	"github.com/cri-o/cri-o/server"
 )
 
 // The actual test suite.
@@ -47,6 +50,22 @@ var _ = t.Describe("Status", func() {
 			}
 		})
 
+		It("should report the tuning capability with high-performance hooks", func() {
+			// Given
+			serverConfig.Runtimes["high-performance"] = &config.RuntimeHandler{
+				RuntimeHandlerHooks: config.RuntimeHandlerHooksHighPerformance,
+			}
+
+			// When
+			response, err := sut.Status(context.Background(),
+				&types.StatusRequest{})
+
+			// Then
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.Status.Conditions).To(HaveLen(3))
+			Expect(response.Status.Conditions[2].Type).To(Equal(server.TuningReady))
+		})
+
 		It("should return info as part of a verbose response", func() {
 			// When
 			response, err := sut.Status(context.Background(),
@@ -146,7 +146,7 @@ func (s *Server) runPodSandbox(ctx context.Context, req *types.RunPodSandboxRequ
 	if err != nil {
 		return nil, err
 	}
-	podContainer, err := s.StorageRuntimeServer().CreatePodSandbox(s.config.SystemContext,
+	podContainer, err := s.StorageRuntimeServer().CreatePodSandbox(s.pauseImageContext(ctx, pauseImage),
 		sboxName, sboxId,
 		pauseImage,
 		s.config.PauseImageAuthFile,
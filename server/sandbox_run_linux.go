@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -451,6 +452,11 @@ func (s *Server) runPodSandbox(ctx context.Context, req *types.RunPodSandboxRequ
 		return nil, err
 	}
 
+	// The tuning annotations grant node-level tuning, so they are reserved to the pods allowed by the policies.
+	if err := s.config.TuningAnnotationPolicies.CheckPod(sbox.Config().GetMetadata().GetNamespace(), sbox.Config().GetLabels(), sbox.Config().Annotations); err != nil {
+		return nil, tuningAnnotationsStatus(err)
+	}
+
 	// override default annotations with pod spec specified ones
 	for k, v := range sbox.Config().Annotations {
 		if _, ok := kubeAnnotations[k]; ok {
@@ -459,6 +465,33 @@ func (s *Server) runPodSandbox(ctx context.Context, req *types.RunPodSandboxRequ
 		kubeAnnotations[k] = v
 	}
 
+	// Reject the pod before anything is set up if its tuning annotations can not be honored,
+	// rather than failing later on the start of its containers.
+	if err := runtimehandlerhooks.ValidateHighPerformanceAnnotations(&s.config, runtimeHandler, kubeAnnotations, ""); err != nil {
+		return nil, tuningAnnotationsStatus(err)
+	}
+
+	// Reject the pod before anything is set up if its containers can not get the shared CPUs.
+	// The request is ignored altogether when the shared CPUs are disabled.
+	highPerformanceSettings := s.config.HighPerformanceSettingsFor(runtimeHandler)
+	if highPerformanceSettings.FeatureEnabled(libconfig.HighPerformanceFeatureSharedCPUs) {
+		if err := runtimehandlerhooks.CheckSharedCPUsConfigured(kubeAnnotations, "", highPerformanceSettings.SharedCPUSet); err != nil {
+			return nil, tuningAnnotationsStatus(err)
+		}
+	}
+
+	// Reject the pod before anything is set up if it selects a netns sysctl bundle it can not get.
+	netnsSysctls, err := runtimehandlerhooks.NetNSSysctlBundle(highPerformanceSettings.NetNSSysctlBundles, kubeAnnotations, hostNetwork)
+	if err != nil {
+		return nil, tuningAnnotationsStatus(err)
+	}
+
+	// Reject the pod before anything is set up if it requests OCI artifacts not referenced by digest.
+	ociArtifactRefs, err := podOCIArtifactRefs(kubeAnnotations)
+	if err != nil {
+		return nil, err
+	}
+
 	usernsMode := kubeAnnotations[annotations.UsernsModeAnnotation]
 	if usernsMode != "" {
 		log.Warnf(ctx, "Annotation 'io.kubernetes.cri-o.userns-mode' is deprecated, and will be replaced with native Kubernetes support for user namespaces in the future")
@@ -494,7 +527,7 @@ func (s *Server) runPodSandbox(ctx context.Context, req *types.RunPodSandboxRequ
 	if err != nil {
 		return nil, err
 	}
-	podContainer, err := s.StorageRuntimeServer().CreatePodSandbox(s.config.SystemContext,
+	podContainer, err := s.StorageRuntimeServer().CreatePodSandbox(s.pauseImageContext(ctx, pauseImage),
 		sboxName, sboxID,
 		pauseImage,
 		s.config.PauseImageAuthFile,
@@ -763,6 +796,33 @@ func (s *Server) runPodSandbox(ctx context.Context, req *types.RunPodSandboxRequ
 	sbox.SetPodLinuxResources(resources)
 	g.AddAnnotation(annotations.PodLinuxResources, string(resourcesJSON))
 
+	if highPerformanceAnnotations := runtimehandlerhooks.HighPerformanceAnnotations(kubeAnnotations); len(highPerformanceAnnotations) > 0 {
+		highPerformance := &libsandbox.HighPerformance{
+			Annotations: highPerformanceAnnotations,
+			SharedCPUs:  s.config.SharedCPUSetForRuntimeHandler(runtimeHandler),
+		}
+		highPerformanceJSON, err := json.Marshal(highPerformance)
+		if err != nil {
+			return nil, err
+		}
+		sbox.SetHighPerformance(highPerformance)
+		g.AddAnnotation(annotations.HighPerformance, string(highPerformanceJSON))
+	}
+
+	s.resourceStore.SetStageForResource(ctx, sboxName, "sandbox OCI artifacts pull")
+	ociArtifacts, err := s.pullPodOCIArtifacts(ctx, ociArtifactRefs, podContainer.Dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(ociArtifacts) > 0 {
+		ociArtifactsJSON, err := json.Marshal(ociArtifacts)
+		if err != nil {
+			return nil, err
+		}
+		sbox.SetOCIArtifacts(ociArtifacts)
+		g.AddAnnotation(annotations.OCIArtifacts, string(ociArtifactsJSON))
+	}
+
 	seccompRef := types.SecurityProfile_Unconfined.String()
 	if !privileged {
 		_, ref, err := s.config.Seccomp().Setup(
@@ -820,8 +880,12 @@ func (s *Server) runPodSandbox(ctx context.Context, req *types.RunPodSandboxRequ
 		g.AddAnnotation(k, v)
 	}
 
-	// Add default sysctls given in crio.conf
-	sysctls := s.configureGeneratorForSysctls(ctx, g, hostNetwork, hostIPC, sandboxIDMappings, req.Config.Linux.Sysctls)
+	// Add default sysctls given in crio.conf, and the ones of the netns sysctl bundle of the pod,
+	// which the sysctls of the pod spec override.
+	podSysctls := make(map[string]string, len(netnsSysctls)+len(req.Config.Linux.Sysctls))
+	maps.Copy(podSysctls, netnsSysctls)
+	maps.Copy(podSysctls, req.Config.Linux.Sysctls)
+	sysctls := s.configureGeneratorForSysctls(ctx, g, hostNetwork, hostIPC, sandboxIDMappings, podSysctls)
 
 	// set up namespaces
 	// TODO: Pass interface instead of individual field.
@@ -866,6 +930,7 @@ func (s *Server) runPodSandbox(ctx context.Context, req *types.RunPodSandboxRequ
 		log.Infof(ctx, "%s", nsCleanupDescription)
 		return nsCleanupFunc()
 	})
+
 	if result != nil {
 		resultCurrent, err := current.NewResultFromResult(result)
 		if err != nil {
@@ -877,6 +942,23 @@ func (s *Server) runPodSandbox(ctx context.Context, req *types.RunPodSandboxRequ
 		}
 		g.AddAnnotation(annotations.CNIResult, string(cniResultJSON))
 	}
+
+	// The devices backing the interfaces of the pod only exist once its network is set up.
+	resourceCleaner.Add(ctx, "runSandbox: reverting network tuning for sandbox "+sb.ID(), func() error {
+		return runtimehandlerhooks.RevertPodNetworkTuning(context.Background(), sb.ID())
+	})
+	if err := runtimehandlerhooks.SetPodInterruptCoalescing(ctx, &s.config, sb, g.Config.Annotations[annotations.CNIResult]); err != nil {
+		return nil, fmt.Errorf("set interrupt coalescing of pod sandbox %s(%s): %w", sb.Name(), sb.ID(), err)
+	}
+	if err := runtimehandlerhooks.SetPodQdisc(ctx, &s.config, sb, g.Config.Annotations[annotations.CNIResult]); err != nil {
+		return nil, fmt.Errorf("set qdisc of pod sandbox %s(%s): %w", sb.Name(), sb.ID(), err)
+	}
+	if err := runtimehandlerhooks.SetPodGRO(ctx, &s.config, sb, g.Config.Annotations[annotations.CNIResult]); err != nil {
+		return nil, fmt.Errorf("turn GRO off for pod sandbox %s(%s): %w", sb.Name(), sb.ID(), err)
+	}
+	if err := runtimehandlerhooks.SetPodNetdevBudget(ctx, &s.config, sb); err != nil {
+		return nil, fmt.Errorf("set netdev budget of pod sandbox %s(%s): %w", sb.Name(), sb.ID(), err)
+	}
 	// TODO: Pass interface instead of individual field.
 	s.resourceStore.SetStageForResource(ctx, sboxName, "sandbox storage start")
 
@@ -984,6 +1066,19 @@ func (s *Server) runPodSandbox(ctx context.Context, req *types.RunPodSandboxRequ
 		strings.Contains(strings.ToLower(runtimeHandler), "kata") ||
 		(runtimeHandler == "" && strings.Contains(strings.ToLower(s.config.DefaultRuntime), "kata"))
 
+	if s.config.HighPerformancePolicyFor(runtimeHandler) == libconfig.RuntimeTypeTuningDelegate {
+		if highPerformanceAnnotations := runtimehandlerhooks.HighPerformanceAnnotations(kubeAnnotations); len(highPerformanceAnnotations) > 0 {
+			// The tuning of the node does not reach the guest, so let the runtime tune the VM instead.
+			vmAnnotations, ignored := runtimehandlerhooks.VMTuningAnnotations(&s.config, highPerformanceAnnotations, g.Config.Annotations)
+			for k, v := range vmAnnotations {
+				g.AddAnnotation(k, v)
+			}
+			if len(ignored) > 0 {
+				log.Warnf(ctx, "Ignoring the tuning annotations of pod %s without equivalent for VM runtime handler %q: %s", sboxID, runtimeHandler, strings.Join(ignored, ", "))
+			}
+		}
+	}
+
 	var container *oci.Container
 	// In the case of kernel separated containers, we need the infra container to create the VM for the pod
 	if sb.NeedsInfra(s.config.DropInfraCtr) || podIsKernelSeparated {
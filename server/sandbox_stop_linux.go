@@ -14,6 +14,7 @@ import (
 	"github.com/cri-o/cri-o/internal/linklogs"
 	"github.com/cri-o/cri-o/internal/log"
 	oci "github.com/cri-o/cri-o/internal/oci"
+	"github.com/cri-o/cri-o/internal/runtimehandlerhooks"
 	ann "github.com/cri-o/cri-o/pkg/annotations"
 )
 
@@ -32,6 +33,12 @@ func (s *Server) stopPodSandbox(ctx context.Context, sb *sandbox.Sandbox) error
 		}
 	}
 
+	// Restore the qdisc, receive aggregation and interrupt coalescing of the devices backing the interfaces of the pod while they are still there,
+	// and release the node sysctls held by the pod.
+	if err := runtimehandlerhooks.RevertPodNetworkTuning(ctx, sb.ID()); err != nil {
+		log.Warnf(ctx, "Failed to revert the network tuning of pod sandbox %s: %v", sb.ID(), err)
+	}
+
 	// Clean up sandbox networking and close its network namespace.
 	if err := s.networkStop(ctx, sb); err != nil {
 		return err
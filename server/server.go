@@ -18,6 +18,7 @@ import (
 	"github.com/containers/storage/pkg/idtools"
 	storageTypes "github.com/containers/storage/types"
 	"github.com/fsnotify/fsnotify"
+	"golang.org/x/sync/semaphore"
 	"golang.org/x/sys/unix"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -26,6 +27,7 @@ import (
 	kubetypes "k8s.io/kubelet/pkg/types"
 
 	"github.com/cri-o/cri-o/internal/cert"
+	"github.com/cri-o/cri-o/internal/config/credentialprovider"
 	"github.com/cri-o/cri-o/internal/config/seccomp"
 	"github.com/cri-o/cri-o/internal/hostport"
 	"github.com/cri-o/cri-o/internal/lib"
@@ -45,10 +47,9 @@ import (
 )
 
 const (
-	rootlessEnvName                = "_CRIO_ROOTLESS"
-	irqBalanceConfigRestoreDisable = "disable"
-	debounceDuration               = 200 * time.Millisecond
-	defaultRegistriesConfDDir      = "/etc/containers/registries.conf.d"
+	rootlessEnvName           = "_CRIO_ROOTLESS"
+	debounceDuration          = 200 * time.Millisecond
+	defaultRegistriesConfDDir = "/etc/containers/registries.conf.d"
 )
 
 var errSandboxNotCreated = errors.New("sandbox not created")
@@ -80,6 +81,15 @@ type Server struct {
 	pullOperationsInProgress map[pullArguments]*pullOperation
 	// pullOperationsLock is used to synchronize pull operations.
 	pullOperationsLock sync.Mutex
+	// imagePulls bounds the number of images pulled at the same time, nil if unbounded.
+	imagePulls *semaphore.Weighted
+	// registryLayerDownloads bound the number of layers downloaded at the same time from
+	// the registries limiting them, keyed by registry.
+	registryLayerDownloads map[string]*semaphore.Weighted
+	// registryMirrors orders the registry mirrors by health for the image pulls, nil if disabled.
+	registryMirrors *registryMirrors
+	// credentialProviders provide the credentials of the images CRI-O pulls on its own, nil if not configured.
+	credentialProviders *credentialprovider.Providers
 
 	resourceStore *resourcestore.ResourceStore
 
@@ -233,6 +243,11 @@ func (s *Server) restore(ctx context.Context) []storage.StorageImageID {
 	// release the name associated with you.
 	for containerID := range podContainers {
 		err := s.LoadContainer(ctx, containerID)
+		if err == nil {
+			if ctr := s.GetContainer(ctx, containerID); ctr != nil {
+				runtimehandlerhooks.RestoreContainerTuning(ctx, ctr)
+			}
+		}
 		if err == nil || errors.Is(err, lib.ErrIsNonCrioContainer) {
 			delete(containersAndTheirImages, containerID)
 			continue
@@ -247,6 +262,11 @@ func (s *Server) restore(ctx context.Context) []storage.StorageImageID {
 		}
 	}
 
+	// Release the node sysctls still held by the pods and containers removed while the server was down.
+	runtimehandlerhooks.ReleaseStaleNodeSysctls(ctx, func(id string) bool {
+		return s.getSandbox(ctx, id) != nil || s.GetContainer(ctx, id) != nil
+	})
+
 	// Cleanup any potential stale network resources not associated to any
 	// pod known to us using CNI GC
 	err = s.networkGC(context.Background(), knownPods)
@@ -384,14 +404,28 @@ func New(
 		return nil, err
 	}
 
-	if strings.ToLower(strings.TrimSpace(config.IrqBalanceConfigRestoreFile)) != irqBalanceConfigRestoreDisable {
-		log.Infof(ctx, "Attempting to restore irqbalance config from %s", config.IrqBalanceConfigRestoreFile)
-		err = runtimehandlerhooks.RestoreIrqBalanceConfig(context.TODO(), config.IrqBalanceConfigFile, config.IrqBalanceConfigRestoreFile, runtimehandlerhooks.IrqSmpAffinityProcFile)
+	highPerformance := config.HighPerformanceSettings()
+	if highPerformance.IrqBalanceConfigRestoreEnabled() {
+		log.Infof(ctx, "Attempting to restore irqbalance config from %s", highPerformance.IrqBalanceConfigRestoreFile)
+		err = runtimehandlerhooks.RestoreIrqBalanceConfig(context.TODO(), highPerformance.IrqBalanceConfigFile, highPerformance.IrqBalanceConfigRestoreFile, runtimehandlerhooks.IrqSmpAffinityProcFile)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	runtimehandlerhooks.SetHookLogFormat(config.RuntimeHandlerHooksLogFormat)
+	runtimehandlerhooks.ExportTopologyToFile(config.TuningTopologyFile)
+	runtimehandlerhooks.WriteContainerStateFiles(config.TuningContainerStateDir)
+	if err := runtimehandlerhooks.LoadTuningStore(ctx, highPerformance.StateDir); err != nil {
+		return nil, err
+	}
+	if err := runtimehandlerhooks.OpenTuningAuditLog(config.TuningAuditLog, config.TuningAuditLogSizeMax); err != nil {
+		return nil, err
+	}
+	if err := runtimehandlerhooks.EnableLinuxAudit(config.TuningLinuxAudit); err != nil {
+		return nil, err
+	}
+
 	// Check for hostport mapping
 	var hostportManager hostport.HostPortManager
 	if config.RuntimeConfig.DisableHostPortMapping {
@@ -424,8 +458,21 @@ func New(
 		minimumMappableUID:       config.MinimumMappableUID,
 		minimumMappableGID:       config.MinimumMappableGID,
 		pullOperationsInProgress: make(map[pullArguments]*pullOperation),
+		registryLayerDownloads:   make(map[string]*semaphore.Weighted),
 		resourceStore:            resourcestore.New(),
 	}
+	if config.MaxParallelImagePulls > 0 {
+		s.imagePulls = semaphore.NewWeighted(int64(config.MaxParallelImagePulls))
+	}
+	for registry, limit := range config.RegistryMaxParallelLayerDownloads {
+		s.registryLayerDownloads[registry] = semaphore.NewWeighted(int64(limit))
+	}
+	if config.CredentialProviderConfig != "" {
+		s.credentialProviders, err = credentialprovider.New(config.CredentialProviderConfig, config.CredentialProviderBinDir)
+		if err != nil {
+			return nil, fmt.Errorf("load credential providers: %w", err)
+		}
+	}
 	if s.config.EnablePodEvents {
 		// creating a container events channel only if the evented pleg is enabled
 		s.ContainerEventsChan = make(chan types.ContainerEventResponse, 1000)
@@ -447,6 +494,7 @@ func New(
 
 	deletedImages := s.restore(ctx)
 	s.wipeIfAppropriate(ctx, deletedImages)
+	s.reconcileRestoredTuning(ctx)
 
 	var bindAddressStr string
 	bindAddress := net.ParseIP(config.StreamAddress)
@@ -508,7 +556,13 @@ func New(
 
 	log.Debugf(ctx, "Sandboxes: %v", s.ContainerServer.ListSandboxes())
 
+	if err := s.startRegistryMirrorHealthChecks(ctx); err != nil {
+		return nil, err
+	}
 	s.startReloadWatcher(ctx)
+	s.startTuningDriftController(ctx)
+	s.startTuningSchedstatSampler(ctx)
+	s.startTuningTelemetrySampler(ctx)
 	if s.config.AutoReloadRegistries {
 		go s.startWatcherForMirrorRegistries(ctx, s.config.SystemContext.SystemRegistriesConfDirPath)
 	}
@@ -559,13 +613,20 @@ func (s *Server) startReloadWatcher(ctx context.Context) {
 		for {
 			// Block until the signal is received
 			<-ch
+			oldSharedCPUSets := s.sharedCPUSetsByRuntimeHandler()
 			if err := s.config.Reload(ctx); err != nil {
 				log.Errorf(ctx, "Unable to reload configuration: %v", err)
 				continue
 			}
+			s.reconcileSharedCPUs(ctx, oldSharedCPUSets)
+			s.reloadRegistryMirrors(ctx)
+			if s.config.HighPerformanceReconcileOnReload {
+				s.reconcileTuning(ctx)
+			}
 			// ImageServer compiles the list with regex for both
 			// pinned and sandbox/pause images, we need to update them
 			s.StorageImageServer().UpdatePinnedImagesList(append(s.config.PinnedImages, s.config.PauseImage))
+			s.updatePinnedImagesSize(ctx)
 			log.Infof(ctx, "Configuration reload completed")
 			// Print the current configuration.
 			tomlConfig, err := s.config.ToString()
@@ -652,6 +713,10 @@ func (s *Server) wipeIfAppropriate(ctx context.Context, imagesToDelete []storage
 	// disk usage gets too high.
 	if shouldWipeImages {
 		for img := range imageMapToDelete {
+			if pinned, err := s.StorageImageServer().IsImagePinned(img); err == nil && pinned {
+				log.Infof(ctx, "Keeping pinned image %s", img)
+				continue
+			}
 			if err := s.StorageImageServer().DeleteImage(s.config.SystemContext, img); err != nil {
 				log.Warnf(ctx, "Failed to remove image %s: %v", img, err)
 			}
@@ -665,6 +730,193 @@ func (s *Server) addSandbox(ctx context.Context, sb *sandbox.Sandbox) error {
 	return s.ContainerServer.AddSandbox(ctx, sb)
 }
 
+// sharedCPUSetsByRuntimeHandler returns the shared CPU set used by each runtime handler,
+// the empty handler standing for the default runtime.
+func (s *Server) sharedCPUSetsByRuntimeHandler() map[string]string {
+	sharedCPUSets := map[string]string{"": s.config.SharedCPUSetForRuntimeHandler("")}
+	for handler := range s.config.Runtimes {
+		sharedCPUSets[handler] = s.config.SharedCPUSetForRuntimeHandler(handler)
+	}
+	return sharedCPUSets
+}
+
+// reconcileSharedCPUs updates the running containers consuming the shared CPUs,
+// after the shared CPU pool of their runtime handler changed from oldSharedCPUSets on configuration reload.
+// The kubelet is notified of the containers updated, so that it refreshes their status.
+func (s *Server) reconcileSharedCPUs(ctx context.Context, oldSharedCPUSets map[string]string) {
+	ctx, span := log.StartSpan(ctx)
+	defer span.End()
+
+	changed := false
+	for handler, oldSharedCPUSet := range oldSharedCPUSets {
+		if s.config.SharedCPUSetForRuntimeHandler(handler) != oldSharedCPUSet {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return
+	}
+
+	if err := s.forEachHighPerformanceContainer(ctx, func(ctr *oci.Container, sb *sandbox.Sandbox, hooks runtimehandlerhooks.HighPerformanceHook) {
+		oldSharedCPUSet, ok := oldSharedCPUSets[sb.RuntimeHandler()]
+		if !ok || oldSharedCPUSet == s.config.SharedCPUSetForRuntimeHandler(sb.RuntimeHandler()) {
+			return
+		}
+		updated, err := hooks.UpdateSharedCPUs(ctx, ctr, sb, oldSharedCPUSet)
+		if err != nil {
+			log.Errorf(ctx, "Failed to update shared CPUs of container %s: %v", ctr.ID(), err)
+			return
+		}
+		if updated {
+			s.generateTuningEvent(ctx, ctr)
+		}
+	}); err != nil {
+		log.Errorf(ctx, "Unable to list containers to reconcile shared CPUs: %v", err)
+	}
+}
+
+// forEachHighPerformanceContainer calls fn for the running containers whose runtime handler has
+// high-performance hooks, along with their sandbox and hooks.
+func (s *Server) forEachHighPerformanceContainer(ctx context.Context, fn func(*oci.Container, *sandbox.Sandbox, runtimehandlerhooks.HighPerformanceHook)) error {
+	ctrs, err := s.ContainerServer.ListContainers(func(c *oci.Container) bool {
+		return c.State().Status == oci.ContainerStateRunning
+	})
+	if err != nil {
+		return err
+	}
+	for _, ctr := range ctrs {
+		sb := s.getSandbox(ctx, ctr.Sandbox())
+		if sb == nil {
+			continue
+		}
+		hooks, err := runtimehandlerhooks.GetRuntimeHandlerHooks(ctx, &s.config, sb.RuntimeHandler(), sb.Annotations())
+		if err != nil {
+			log.Warnf(ctx, "Failed to get runtime handler %q hooks", sb.RuntimeHandler())
+			continue
+		}
+		if highPerformanceHooks, ok := runtimehandlerhooks.AsHighPerformanceHook(hooks); ok {
+			fn(ctr, sb, highPerformanceHooks)
+		}
+	}
+	return nil
+}
+
+// reconcileRestoredTuning re-establishes the tuning of the running containers restored on startup,
+// which may have been lost or rewritten by systemd or other agents while the server was down.
+func (s *Server) reconcileRestoredTuning(ctx context.Context) {
+	ctx, span := log.StartSpan(ctx)
+	defer span.End()
+
+	if err := s.forEachHighPerformanceContainer(ctx, func(ctr *oci.Container, sb *sandbox.Sandbox, hooks runtimehandlerhooks.HighPerformanceHook) {
+		lost, err := hooks.ReconcileRestoredTuning(ctx, ctr, sb)
+		if len(lost) > 0 {
+			log.Warnf(ctx, "Tuning of container %s (%s) got lost while the server was down, repairing: %s", ctr.ID(), ctr.Name(), strings.Join(lost, ", "))
+			metrics.Instance().MetricTuningDriftTotalAdd(ctr.Name(), float64(len(lost)))
+		}
+		if err != nil {
+			log.Errorf(ctx, "Failed to reconcile the tuning of restored container %s: %v", ctr.ID(), err)
+		}
+	}); err != nil {
+		log.Errorf(ctx, "Unable to list containers to reconcile their tuning: %v", err)
+	}
+}
+
+// startTuningDriftController periodically repairs the tuning of the running containers
+// which drifted from the one applied by the runtime handler hooks, if enabled.
+func (s *Server) startTuningDriftController(ctx context.Context) {
+	interval := s.config.TuningDriftCheckInterval
+	if interval <= 0 {
+		log.Debugf(ctx, "Tuning drift detection is disabled")
+		return
+	}
+
+	s.runPeriodically(ctx, "tuning drift controller", interval, s.repairTuningDrift)
+}
+
+// startTuningSchedstatSampler periodically samples the scheduler statistics of the CPUs isolated
+// by the runtime handler hooks into the run delay metrics, if enabled.
+func (s *Server) startTuningSchedstatSampler(ctx context.Context) {
+	interval := s.config.TuningSchedstatInterval
+	if interval <= 0 {
+		log.Debugf(ctx, "Sampling of the scheduler statistics of the isolated CPUs is disabled")
+		return
+	}
+
+	s.runPeriodically(ctx, "tuning schedstat sampler", interval, runtimehandlerhooks.SampleIsolatedCPUsSchedstat)
+}
+
+// startTuningTelemetrySampler periodically samples the CPU telemetry of the containers tuned
+// by the runtime handler hooks into the metrics, if enabled.
+func (s *Server) startTuningTelemetrySampler(ctx context.Context) {
+	interval := s.config.TuningTelemetryInterval
+	if interval <= 0 {
+		log.Debugf(ctx, "Sampling of the CPU telemetry of the tuned containers is disabled")
+		return
+	}
+
+	s.runPeriodically(ctx, "tuning telemetry sampler", interval, runtimehandlerhooks.SampleTuningTelemetry)
+}
+
+// runPeriodically runs fn in the background every interval, until the monitors get closed on shutdown.
+func (s *Server) runPeriodically(ctx context.Context, name string, interval time.Duration, fn func(context.Context)) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				fn(ctx)
+			case <-s.monitorsChan:
+				log.Debugf(ctx, "Closing %s...", name)
+				return
+			}
+		}
+	}()
+
+	log.Infof(ctx, "Started %s with an interval of %s", name, interval)
+}
+
+// repairTuningDrift compares the tuning of the running containers with the node and repairs the differences.
+// Every drift is logged for its container, counted by the tuning drift metric and notified to the kubelet.
+func (s *Server) repairTuningDrift(ctx context.Context) {
+	ctx, span := log.StartSpan(ctx)
+	defer span.End()
+
+	if err := s.forEachHighPerformanceContainer(ctx, func(ctr *oci.Container, sb *sandbox.Sandbox, hooks runtimehandlerhooks.HighPerformanceHook) {
+		drifted, err := hooks.RepairTuningDrift(ctx, ctr, sb)
+		if len(drifted) > 0 {
+			log.Warnf(ctx, "Tuning of container %s (%s) drifted, repairing: %s", ctr.ID(), ctr.Name(), strings.Join(drifted, ", "))
+			metrics.Instance().MetricTuningDriftTotalAdd(ctr.Name(), float64(len(drifted)))
+			s.generateTuningEvent(ctx, ctr)
+		}
+		if err != nil {
+			log.Errorf(ctx, "Failed to repair tuning drift of container %s: %v", ctr.ID(), err)
+		}
+	}); err != nil {
+		log.Errorf(ctx, "Unable to list containers to detect tuning drift: %v", err)
+	}
+}
+
+// reconcileTuning reconciles the tuning of the running containers with the reloaded configuration
+// of the runtime handler hooks, repairing the tuning which does not hold anymore.
+func (s *Server) reconcileTuning(ctx context.Context) {
+	ctx, span := log.StartSpan(ctx)
+	defer span.End()
+
+	if err := s.forEachHighPerformanceContainer(ctx, func(ctr *oci.Container, sb *sandbox.Sandbox, hooks runtimehandlerhooks.HighPerformanceHook) {
+		drifted, err := hooks.ReconcileTuning(ctx, ctr, sb)
+		if len(drifted) > 0 {
+			log.Infof(ctx, "Repaired the tuning of container %s (%s) on reload: %s", ctr.ID(), ctr.Name(), strings.Join(drifted, ", "))
+		}
+		if err != nil {
+			log.Errorf(ctx, "Failed to reconcile tuning of container %s: %v", ctr.ID(), err)
+		}
+	}); err != nil {
+		log.Errorf(ctx, "Unable to list containers to reconcile tuning: %v", err)
+	}
+}
+
 func (s *Server) getSandbox(ctx context.Context, id string) *sandbox.Sandbox {
 	_, span := log.StartSpan(ctx)
 	defer span.End()
@@ -797,10 +1049,6 @@ func (s *Server) handleExit(ctx context.Context, event fsnotify.Event) {
 	}
 	log.Debugf(ctx, "%s exited and found: %v", resource, containerID)
 
-	if err := s.ContainerStateToDisk(ctx, c); err != nil {
-		log.Warnf(ctx, "Unable to write %s %s state to disk: %v", resource, c.ID(), err)
-	}
-
 	if nriCtr != nil {
 		if err := s.nri.stopContainer(ctx, nil, nriCtr); err != nil {
 			log.Warnf(ctx, "NRI stop container request of %s failed: %v", nriCtr.ID(), err)
@@ -816,6 +1064,11 @@ func (s *Server) handleExit(ctx context.Context, event fsnotify.Event) {
 		}
 	}
 
+	// The state is written after the post-stop hook, which clears the tuning it records.
+	if err := s.ContainerStateToDisk(ctx, c); err != nil {
+		log.Warnf(ctx, "Unable to write %s %s state to disk: %v", resource, c.ID(), err)
+	}
+
 	s.generateCRIEvent(ctx, c, types.ContainerEventType_CONTAINER_STOPPED_EVENT)
 	if err := os.Remove(event.Name); err != nil {
 		log.Warnf(ctx, "Failed to remove exit file: %v", err)
@@ -924,6 +1177,13 @@ func (s *Server) generateCRIEvent(ctx context.Context, container *oci.Container,
 	}
 }
 
+// generateTuningEvent notifies the kubelet of a change of the tuning of a running container, which its status
+// reports. CRI has no event type for it, so the container is reported as started again, which only makes the
+// kubelet update the status it holds for the container.
+func (s *Server) generateTuningEvent(ctx context.Context, container *oci.Container) {
+	s.generateCRIEvent(ctx, container, types.ContainerEventType_CONTAINER_STARTED_EVENT)
+}
+
 func isNotFound(err error) bool {
 	s, ok := status.FromError(err)
 	if !ok {
@@ -972,7 +1232,9 @@ func (s *Server) watchAndReloadMirrorRegistriesConfiguration(ctx context.Context
 			log.Infof(ctx, "File %q changed, reloading registries configuration", evenName)
 			if err := s.config.ReloadRegistries(); err != nil {
 				log.Errorf(ctx, "Failed to reload registry configuration: %v", err)
+				continue
 			}
+			s.reloadRegistryMirrors(ctx)
 		}
 	}()
 
@@ -5,7 +5,21 @@
 
 package server
 
+import (
+	"context"
+
+	metadata "github.com/checkpoint-restore/checkpointctl/lib"
+
+	"github.com/cri-o/cri-o/internal/lib/sandbox"
+	"github.com/cri-o/cri-o/internal/storage"
+)
+
 // SetStorageRuntimeServer sets the runtime server for the ContainerServer.
 func (s *StreamService) SetRuntimeServer(server *Server) {
 	s.runtimeServer = server
 }
+
+// PullCheckpointRootfsImage pulls the image of a checkpoint by its name, if the image is missing.
+func (s *Server) PullCheckpointRootfsImage(ctx context.Context, sb *sandbox.Sandbox, rootfsImageName string, id storage.StorageImageID) error {
+	return s.pullCheckpointRootfsImage(ctx, sb, &metadata.ContainerConfig{RootfsImageName: rootfsImageName}, id)
+}
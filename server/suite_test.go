@@ -145,6 +145,7 @@ var beforeEach = func() {
 	serverConfig.ContainerExitsDir = path.Join(testPath, "exits")
 	serverConfig.LogDir = path.Join(testPath, "log")
 	serverConfig.CleanShutdownFile = path.Join(testPath, "clean.shutdown")
+	serverConfig.TuningStateDir = path.Join(testPath, "tuning")
 	serverConfig.EnablePodEvents = true
 	serverConfig.Seccomp().SetNotifierPath(t.MustTempDir("seccomp-notifier"))
 	serverConfig.NRI.SocketPath = t.MustTempDir("nri")
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"errors"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/cri-o/cri-o/internal/runtimehandlerhooks"
+	"github.com/cri-o/cri-o/pkg/config"
+)
+
+// tuningErrorDomain is the domain of the ErrorInfo details of the tuning annotation rejections.
+const tuningErrorDomain = "crio.io"
+
+// tuningAnnotationsStatus converts the rejections of the tuning annotations of a pod into gRPC status errors,
+// carrying an ErrorInfo with the machine-readable reason of the rejection. Other errors are returned as is.
+func tuningAnnotationsStatus(err error) error {
+	var (
+		annotationErr *runtimehandlerhooks.AnnotationError
+		sharedCPUsErr *runtimehandlerhooks.SharedCPUsNotConfiguredError
+		policyErr     *config.TuningAnnotationNotAllowedError
+	)
+	var code codes.Code
+	info := &errdetails.ErrorInfo{Domain: tuningErrorDomain}
+	switch {
+	case errors.As(err, &annotationErr):
+		code = codes.InvalidArgument
+		if annotationErr.Reason == runtimehandlerhooks.ReasonUnsupportedTuning {
+			code = codes.FailedPrecondition
+		}
+		info.Reason = annotationErr.Reason
+		info.Metadata = map[string]string{"annotation": annotationErr.Annotation, "value": annotationErr.Value}
+	case errors.As(err, &sharedCPUsErr):
+		code = codes.FailedPrecondition
+		info.Reason = runtimehandlerhooks.ReasonSharedCPUsNotConfigured
+		info.Metadata = map[string]string{"container": sharedCPUsErr.Container}
+	case errors.As(err, &policyErr):
+		code = codes.PermissionDenied
+		info.Reason = runtimehandlerhooks.ReasonTuningAnnotationNotAllowed
+		info.Metadata = map[string]string{"namespace": policyErr.Namespace, "annotations": strings.Join(policyErr.Annotations, ",")}
+	default:
+		return err
+	}
+	st, detailsErr := status.New(code, err.Error()).WithDetails(info)
+	if detailsErr != nil {
+		return status.Error(code, err.Error())
+	}
+	return st.Err()
+}
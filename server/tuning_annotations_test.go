@@ -0,0 +1,71 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/cri-o/cri-o/internal/runtimehandlerhooks"
+	"github.com/cri-o/cri-o/pkg/config"
+)
+
+func TestTuningAnnotationsStatus(t *testing.T) {
+	for _, tc := range []struct {
+		err    error
+		code   codes.Code
+		reason string
+	}{
+		{
+			err: fmt.Errorf("wrapped: %w", &runtimehandlerhooks.AnnotationError{
+				Reason: runtimehandlerhooks.ReasonInvalidTuningAnnotation, Annotation: "cpu-c-states.crio.io", Value: "max_latency:x",
+			}),
+			code:   codes.InvalidArgument,
+			reason: runtimehandlerhooks.ReasonInvalidTuningAnnotation,
+		},
+		{
+			err: &runtimehandlerhooks.AnnotationError{
+				Reason: runtimehandlerhooks.ReasonUnsupportedTuning, Annotation: "cpu-freq-governor.crio.io", Value: "performance",
+			},
+			code:   codes.FailedPrecondition,
+			reason: runtimehandlerhooks.ReasonUnsupportedTuning,
+		},
+		{
+			err:    &runtimehandlerhooks.SharedCPUsNotConfiguredError{Container: "ctr"},
+			code:   codes.FailedPrecondition,
+			reason: runtimehandlerhooks.ReasonSharedCPUsNotConfigured,
+		},
+		{
+			err:    &config.TuningAnnotationNotAllowedError{Namespace: "default", Annotations: []string{"cpu-quota.crio.io"}},
+			code:   codes.PermissionDenied,
+			reason: runtimehandlerhooks.ReasonTuningAnnotationNotAllowed,
+		},
+	} {
+		st, ok := status.FromError(tuningAnnotationsStatus(tc.err))
+		if !ok {
+			t.Fatalf("Expected a status error for %v", tc.err)
+		}
+		if st.Code() != tc.code {
+			t.Errorf("Expected code %v for %v, got %v", tc.code, tc.err, st.Code())
+		}
+		if st.Message() != tc.err.Error() {
+			t.Errorf("Expected message %q, got %q", tc.err.Error(), st.Message())
+		}
+		details := st.Details()
+		if len(details) != 1 {
+			t.Fatalf("Expected one detail for %v, got %v", tc.err, details)
+		}
+		info, ok := details[0].(*errdetails.ErrorInfo)
+		if !ok || info.GetReason() != tc.reason || info.GetDomain() != tuningErrorDomain {
+			t.Errorf("Expected the reason %s for %v, got %v", tc.reason, tc.err, details[0])
+		}
+	}
+
+	err := errors.New("other")
+	if tuningAnnotationsStatus(err) != err {
+		t.Errorf("Expected the other errors to be returned as is")
+	}
+}
@@ -31,6 +31,7 @@ var (
 		"plugin_dir",                  // deprecated
 		"runtimes",                    // printed as separate table
 		"workloads",                   // printed as separate table
+		"tuning_annotation_policies",  // printed as separate table
 		"manage_network_ns_lifecycle", // deprecated
 	}
 
@@ -44,7 +45,8 @@ var (
 
 	// Tags where it should not validate the values.
 	excludedCLI = []string{
-		"workloads", // too complex an option for a CLI flag
+		"workloads",                  // too complex an option for a CLI flag
+		"tuning_annotation_policies", // too complex an option for a CLI flag
 	}
 
 	// Mapping for inconsistencies between tags and CLI arguments.
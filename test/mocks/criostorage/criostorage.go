@@ -132,6 +132,35 @@ func (mr *MockImageServerMockRecorder) ImageStatusByName(systemContext, name any
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImageStatusByName", reflect.TypeOf((*MockImageServer)(nil).ImageStatusByName), systemContext, name)
 }
 
+// IsImageNamePinned mocks base method.
+func (m *MockImageServer) IsImageNamePinned(name references.RegistryImageReference) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsImageNamePinned", name)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsImageNamePinned indicates an expected call of IsImageNamePinned.
+func (mr *MockImageServerMockRecorder) IsImageNamePinned(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsImageNamePinned", reflect.TypeOf((*MockImageServer)(nil).IsImageNamePinned), name)
+}
+
+// IsImagePinned mocks base method.
+func (m *MockImageServer) IsImagePinned(id storage0.StorageImageID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsImagePinned", id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsImagePinned indicates an expected call of IsImagePinned.
+func (mr *MockImageServerMockRecorder) IsImagePinned(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsImagePinned", reflect.TypeOf((*MockImageServer)(nil).IsImagePinned), id)
+}
+
 // IsRunningImageAllowed mocks base method.
 func (m *MockImageServer) IsRunningImageAllowed(ctx context.Context, systemContext *types.SystemContext, userSpecifiedImage references.RegistryImageReference, imageID storage0.StorageImageID) error {
 	m.ctrl.T.Helper()
@@ -1,7 +1,10 @@
 package cmdrunner_test
 
 import (
+	"context"
+	"errors"
 	"os/exec"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -69,3 +72,42 @@ var _ = t.Describe("CommandRunner", func() {
 		Expect(cmdrunner.GetPrependedCmd()).To(Equal(""))
 	})
 })
+
+var _ = t.Describe("RunContext", func() {
+	BeforeEach(cmdrunner.ResetPrependedCmd)
+
+	It("should capture the output of the command", func() {
+		// When
+		output, err := cmdrunner.RunContext(context.Background(), cmdrunner.RunOptions{},
+			"sh", "-c", "echo out; echo err >&2")
+
+		// Then
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(output.Stdout)).To(Equal("out\n"))
+		Expect(string(output.Stderr)).To(Equal("err\n"))
+		Expect(string(output.Combined)).To(And(ContainSubstring("out\n"), ContainSubstring("err\n")))
+	})
+	It("should include the output of the command in its error", func() {
+		// When
+		output, err := cmdrunner.RunContext(context.Background(), cmdrunner.RunOptions{Env: []string{"MSG=failed"}},
+			"sh", "-c", "echo $MSG >&2; exit 3")
+
+		// Then
+		var cmdErr *cmdrunner.CommandError
+		Expect(errors.As(err, &cmdErr)).To(BeTrue())
+		Expect(cmdErr.ExitCode()).To(Equal(3))
+		Expect(err.Error()).To(HaveSuffix(": failed"))
+		Expect(string(output.Stderr)).To(Equal("failed\n"))
+	})
+	It("should kill the command once the timeout elapses", func() {
+		// When
+		_, err := cmdrunner.RunContext(context.Background(), cmdrunner.RunOptions{Timeout: 100 * time.Millisecond},
+			"sleep", "10")
+
+		// Then
+		var timeoutErr *cmdrunner.TimeoutError
+		Expect(errors.As(err, &timeoutErr)).To(BeTrue())
+		Expect(timeoutErr.Timeout).To(Equal(100 * time.Millisecond))
+		Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+	})
+})
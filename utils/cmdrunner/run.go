@@ -0,0 +1,142 @@
+package cmdrunner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// waitDelay is the time given to the command to release its output once it got killed,
+// so that a child holding the output open cannot block the caller.
+const waitDelay = time.Second
+
+// RunOptions configure a command run by RunContext.
+type RunOptions struct {
+	// Timeout bounds the duration of the command, which gets killed once elapsed.
+	// The command is only bound by the context if zero.
+	Timeout time.Duration
+	// Env is the environment of the command, the one of the caller if nil.
+	Env []string
+}
+
+// Output is the output captured from a command run by RunContext.
+type Output struct {
+	// Stdout is the standard output of the command.
+	Stdout []byte
+	// Stderr is the standard error of the command.
+	Stderr []byte
+	// Combined is the standard output and error of the command interleaved as written.
+	Combined []byte
+}
+
+// CommandError is returned by RunContext when the command fails to run or exits with a non-zero status.
+type CommandError struct {
+	// Command and Args are the ones the command got run with.
+	Command string
+	Args    []string
+	// Output is the combined output of the command until it failed.
+	Output []byte
+	// Err is the *exec.ExitError of the command, or the reason why it could not run.
+	Err error
+}
+
+func (e *CommandError) Error() string {
+	msg := fmt.Sprintf("run %s: %v", strings.Join(append([]string{e.Command}, e.Args...), " "), e.Err)
+	if output := strings.TrimSpace(string(e.Output)); output != "" {
+		msg += ": " + output
+	}
+	return msg
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit status of the command, or -1 if it did not exit by itself.
+func (e *CommandError) ExitCode() int {
+	var exitErr *exec.ExitError
+	if errors.As(e.Err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// TimeoutError is returned by RunContext when the command got killed because it did not complete in time.
+type TimeoutError struct {
+	CommandError
+	// Timeout is the time the command was given.
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%v (timed out after %v)", &e.CommandError, e.Timeout)
+}
+
+func (e *TimeoutError) Unwrap() []error {
+	return []error{&e.CommandError, context.DeadlineExceeded}
+}
+
+// RunContext runs the command through the defined commandRunner until it completes, the context
+// is done or the timeout of the options elapses, and returns its captured output. The output is
+// returned as captured so far along with a *CommandError if the command fails, or with a
+// *TimeoutError if it got killed because of the timeout.
+func RunContext(ctx context.Context, opts RunOptions, command string, args ...string) (*Output, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	combined := &lockedBuffer{}
+	cmd := CommandContext(ctx, command, args...)
+	cmd.Env = opts.Env
+	cmd.Stdout = &teeWriter{&stdout, combined}
+	cmd.Stderr = &teeWriter{&stderr, combined}
+	cmd.WaitDelay = waitDelay
+	err := cmd.Run()
+
+	output := &Output{Stdout: stdout.Bytes(), Stderr: stderr.Bytes(), Combined: combined.Bytes()}
+	if err == nil {
+		return output, nil
+	}
+	cmdErr := CommandError{Command: command, Args: args, Output: output.Combined, Err: err}
+	if opts.Timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return output, &TimeoutError{CommandError: cmdErr, Timeout: opts.Timeout}
+	}
+	return output, &cmdErr
+}
+
+// lockedBuffer is a buffer which can be written by the goroutines copying the stdout and stderr of a command.
+type lockedBuffer struct {
+	sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) Bytes() []byte {
+	b.Lock()
+	defer b.Unlock()
+	return b.buf.Bytes()
+}
+
+// teeWriter writes to its own buffer and to the combined one of a command.
+type teeWriter struct {
+	own      *bytes.Buffer
+	combined *lockedBuffer
+}
+
+func (w *teeWriter) Write(p []byte) (int, error) {
+	w.own.Write(p)
+	return w.combined.Write(p)
+}